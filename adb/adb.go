@@ -0,0 +1,146 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adb implements the host side of the Android Debug Bridge protocol
+// directly over USB, without the adb server.
+//
+// Open claims the ADB interface of a device, authenticates with an RSA key
+// and returns a Conn, which multiplexes streams to services on the device:
+//
+//	key, err := adb.ParsePrivateKey(pemBytes) // e.g. ~/.android/adbkey
+//	...
+//	c, err := adb.Open(ctx, dev, adb.Config{Key: key})
+//	...
+//	defer c.Close()
+//	out, err := c.Shell(ctx, "getprop ro.build.version.release")
+//
+// Files are transferred with the sync service:
+//
+//	sc, err := c.Sync(ctx)
+//	...
+//	defer sc.Close()
+//	err = sc.Push(f, "/data/local/tmp/file", 0644, time.Now())
+package adb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Class codes of the ADB interface.
+const (
+	subClassADB gousb.Class    = 0x42
+	protocolADB gousb.Protocol = 0x01
+)
+
+func isADB(s gousb.InterfaceSetting) bool {
+	return s.Class == gousb.ClassVendorSpec && s.SubClass == subClassADB && s.Protocol == protocolADB
+}
+
+// Match returns true if the device has an ADB interface in any of its
+// configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		for _, intf := range cfg.Interfaces {
+			for _, alt := range intf.AltSettings {
+				if isADB(alt) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// findInterface returns the ADB interface setting of the configuration
+// and the addresses of its bulk endpoints.
+func findInterface(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if !isADB(alt) {
+				continue
+			}
+			var in, out *gousb.EndpointDesc
+			for _, ep := range alt.Endpoints {
+				if ep.TransferType != gousb.TransferTypeBulk {
+					continue
+				}
+				ep := ep
+				if ep.Direction == gousb.EndpointDirectionIn {
+					in = &ep
+				} else {
+					out = &ep
+				}
+			}
+			if in == nil || out == nil {
+				return nil, nil, nil, fmt.Errorf("ADB interface %d alt %d has no bulk IN and OUT endpoints", alt.Number, alt.Alternate)
+			}
+			alt := alt
+			return &alt, in, out, nil
+		}
+	}
+	return nil, nil, nil, fmt.Errorf("%s has no ADB interface", desc)
+}
+
+// endpointReader reads from an IN endpoint until the context is canceled,
+// so that closing the connection stops the pending read.
+type endpointReader struct {
+	ctx context.Context
+	in  *gousb.InEndpoint
+}
+
+func (r *endpointReader) Read(p []byte) (int, error) {
+	return r.in.ReadContext(r.ctx, p)
+}
+
+// Open claims the ADB interface in the active configuration of the device
+// and connects to adbd. The context bounds the handshake, which may
+// include the user accepting the key on the device if
+// Config.SendPublicKey is set.
+func Open(ctx context.Context, dev *gousb.Device, cfg Config) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, inDesc, outDesc, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	c, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	readCtx, cancel := context.WithCancel(context.Background())
+	release := func() {
+		// Stop the reads of the connection before the interface is
+		// released.
+		cancel()
+		c.Close()
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	conn, err := Connect(ctx, &endpointReader{ctx: readCtx, in: in}, out, cfg)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	conn.release = release
+	return conn, nil
+}
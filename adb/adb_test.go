@@ -0,0 +1,375 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+var (
+	keyOnce  sync.Once
+	testKeys [2]*rsa.PrivateKey
+)
+
+// keys returns two 2048 bit keys, generated once per test binary.
+func keys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	t.Helper()
+	keyOnce.Do(func() {
+		for i := range testKeys {
+			k, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				t.Fatalf("rsa.GenerateKey(): %v", err)
+			}
+			testKeys[i] = k
+		}
+	})
+	return testKeys[0], testKeys[1]
+}
+
+func TestMessage(t *testing.T) {
+	m := &message{command: cmdWRTE, arg0: 1, arg1: 2, data: []byte("hello")}
+	var buf bytes.Buffer
+	if err := writeMessage(&buf, m); err != nil {
+		t.Fatalf("writeMessage(): %v", err)
+	}
+	raw := buf.Bytes()
+	if got, want := raw[:4], []byte("WRTE"); !bytes.Equal(got, want) {
+		t.Errorf("command bytes: got %q, want %q", got, want)
+	}
+	if got, want := binary.LittleEndian.Uint32(raw[16:]), uint32(532); got != want {
+		t.Errorf("checksum: got %d, want %d", got, want)
+	}
+	got, err := readMessage(bytes.NewReader(raw), maxPayload)
+	if err != nil {
+		t.Fatalf("readMessage(): %v", err)
+	}
+	if got.command != m.command || got.arg0 != 1 || got.arg1 != 2 || string(got.data) != "hello" {
+		t.Errorf("readMessage(): got %s %q, want %s %q", got, got.data, m, m.data)
+	}
+	if got.String() != "WRTE(0x1, 0x2, 5 bytes)" {
+		t.Errorf("String(): got %q", got.String())
+	}
+
+	for _, tc := range []struct {
+		desc    string
+		corrupt func([]byte)
+		maxData int
+	}{
+		{"bad magic", func(b []byte) { b[20] ^= 1 }, maxPayload},
+		{"bad checksum", func(b []byte) { b[headerSize] = 'j' }, maxPayload},
+		{"payload too long", func([]byte) {}, 4},
+		{"truncated payload", nil, maxPayload},
+	} {
+		b := append([]byte(nil), raw...)
+		if tc.corrupt == nil {
+			b = b[:len(b)-1]
+		} else {
+			tc.corrupt(b)
+		}
+		if _, err := readMessage(bytes.NewReader(b), tc.maxData); err == nil {
+			t.Errorf("%s: readMessage(): got nil error, want non-nil", tc.desc)
+		}
+	}
+}
+
+func TestEncodePublicKey(t *testing.T) {
+	key, _ := keys(t)
+	enc, err := EncodePublicKey(&key.PublicKey, "user@host")
+	if err != nil {
+		t.Fatalf("EncodePublicKey(): %v", err)
+	}
+	parts := strings.Split(string(enc), " ")
+	if len(parts) != 2 || parts[1] != "user@host" {
+		t.Fatalf("EncodePublicKey(): got %q, want base64 data followed by the key name", enc)
+	}
+	raw, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatalf("base64 decoding: %v", err)
+	}
+	if got, want := len(raw), 524; got != want {
+		t.Fatalf("encoded key has %d bytes, want %d", got, want)
+	}
+	if got := binary.LittleEndian.Uint32(raw); got != keyWords {
+		t.Errorf("modulus size: got %d words, want %d", got, keyWords)
+	}
+	// n0inv * n[0] == -1 mod 2^32
+	n0inv, n0 := binary.LittleEndian.Uint32(raw[4:]), binary.LittleEndian.Uint32(raw[8:])
+	if n0inv*n0 != 0xffffffff {
+		t.Errorf("n0inv 0x%08x is not -1/n[0] for n[0] 0x%08x", n0inv, n0)
+	}
+	le := raw[8 : 8+4*keyWords]
+	be := make([]byte, len(le))
+	for i := range le {
+		be[i] = le[len(le)-1-i]
+	}
+	if n := new(big.Int).SetBytes(be); n.Cmp(key.N) != 0 {
+		t.Error("encoded modulus does not match the key")
+	}
+	if got := binary.LittleEndian.Uint32(raw[len(raw)-4:]); int(got) != key.E {
+		t.Errorf("exponent: got %d, want %d", got, key.E)
+	}
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey(): %v", err)
+	}
+	if _, err := EncodePublicKey(&small.PublicKey, "x"); err == nil {
+		t.Error("EncodePublicKey(1024 bit key): got nil error, want non-nil")
+	}
+}
+
+func TestConnect(t *testing.T) {
+	key, other := keys(t)
+	for _, tc := range []struct {
+		desc        string
+		devKey      *rsa.PublicKey
+		acceptKey   bool
+		cfg         Config
+		wantErr     bool
+		wantOffered bool
+	}{
+		{
+			desc: "no authentication",
+		},
+		{
+			desc:   "known key",
+			devKey: &key.PublicKey,
+			cfg:    Config{Key: key},
+		},
+		{
+			desc:        "unknown key, accepted by the user",
+			devKey:      &other.PublicKey,
+			acceptKey:   true,
+			cfg:         Config{Key: key, KeyName: "user@host", SendPublicKey: true},
+			wantOffered: true,
+		},
+		{
+			desc:    "unknown key",
+			devKey:  &other.PublicKey,
+			cfg:     Config{Key: key},
+			wantErr: true,
+		},
+		{
+			desc:    "no key",
+			devKey:  &key.PublicKey,
+			wantErr: true,
+		},
+	} {
+		d, r, w, cleanup := newFakeDevice()
+		d.key, d.acceptKey = tc.devKey, tc.acceptKey
+		go d.run()
+		c, err := Connect(context.Background(), r, w, tc.cfg)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: Connect(): got error %v, want error: %v", tc.desc, err, tc.wantErr)
+		}
+		if err == nil {
+			if !strings.HasPrefix(c.Banner, "device::") || !c.HasFeature("shell_v2") || c.HasFeature("foo") {
+				t.Errorf("%s: got banner %q, features %v", tc.desc, c.Banner, c.Features)
+			}
+			c.Close()
+		}
+		if got := d.offered != nil; got != tc.wantOffered {
+			t.Errorf("%s: public key offered: %v, want %v", tc.desc, got, tc.wantOffered)
+		} else if got && !bytes.HasSuffix(d.offered, []byte(" user@host\x00")) {
+			t.Errorf("%s: offered key %q does not end with the key name", tc.desc, d.offered)
+		}
+		cleanup()
+	}
+}
+
+func connect(t *testing.T) (*fakeDevice, *Conn) {
+	t.Helper()
+	d, r, w, cleanup := newFakeDevice()
+	t.Cleanup(cleanup)
+	go d.run()
+	c, err := Connect(context.Background(), r, w, Config{})
+	if err != nil {
+		t.Fatalf("Connect(): %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return d, c
+}
+
+func TestShell(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+	out, err := c.Shell(ctx, "echo hello world")
+	if err != nil {
+		t.Fatalf("Shell(): %v", err)
+	}
+	if got, want := string(out), "hello world\n"; got != want {
+		t.Errorf("Shell(): got %q, want %q", got, want)
+	}
+	if _, err := c.Open(ctx, "jdwp:1234"); err == nil {
+		t.Error("Open(unsupported service): got nil error, want non-nil")
+	}
+}
+
+func TestStreams(t *testing.T) {
+	d, c := connect(t)
+	ctx := context.Background()
+	// Run several streams concurrently, each echoing more data than fits
+	// in a single message.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		s, err := c.Open(ctx, "shell:cat")
+		if err != nil {
+			t.Fatalf("Open(shell:cat): %v", err)
+		}
+		want := bytes.Repeat([]byte{byte(i)}, 3*d.maxData+100)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if n, err := s.Write(want); n != len(want) || err != nil {
+				t.Errorf("Write(): got %d, %v, want %d, nil", n, err, len(want))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			got := make([]byte, len(want))
+			n := 0
+			for n < len(got) {
+				m, err := s.Read(got[n:])
+				if err != nil {
+					t.Errorf("Read(): %v", err)
+					return
+				}
+				n += m
+			}
+			if !bytes.Equal(got, want) {
+				t.Error("data read from the stream differs from the data written")
+			}
+			s.Close()
+		}()
+	}
+	wg.Wait()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.maxWrite != d.maxData {
+		t.Errorf("largest WRTE payload: %d bytes, want %d", d.maxWrite, d.maxData)
+	}
+}
+
+func TestSync(t *testing.T) {
+	_, c := connect(t)
+	sc, err := c.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync(): %v", err)
+	}
+	defer sc.Close()
+
+	data := make([]byte, 3*syncMaxData+17)
+	rand.Read(data)
+	mtime := time.Unix(1700000000, 0)
+	if err := sc.Push(bytes.NewReader(data), "/data/local/tmp/blob", 0640, mtime); err != nil {
+		t.Fatalf("Push(): %v", err)
+	}
+	fi, err := sc.Stat("/data/local/tmp/blob")
+	if err != nil {
+		t.Fatalf("Stat(): %v", err)
+	}
+	if want := (FileInfo{Mode: 0640, Size: int64(len(data)), ModTime: mtime}); *fi != want {
+		t.Errorf("Stat(): got %+v, want %+v", *fi, want)
+	}
+	var got bytes.Buffer
+	if n, err := sc.Pull("/data/local/tmp/blob", &got); err != nil || n != int64(len(data)) {
+		t.Fatalf("Pull(): got %d, %v, want %d, nil", n, err, len(data))
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Error("Pull(): data differs from the pushed file")
+	}
+
+	if _, err := sc.Stat("/missing"); !os.IsNotExist(err) {
+		t.Errorf("Stat(missing file): got %v, want a not exist error", err)
+	}
+	if _, err := sc.Pull("/missing", &got); err == nil || !strings.Contains(err.Error(), "No such file") {
+		t.Errorf("Pull(missing file): got %v, want the device error", err)
+	}
+	if err := sc.Push(strings.NewReader("x"), "/readonly/x", 0644, mtime); err == nil {
+		t.Error("Push() to a read-only path: got nil error, want non-nil")
+	}
+	// The session is still usable after a failed request.
+	if _, err := sc.Stat("/data/local/tmp/blob"); err != nil {
+		t.Errorf("Stat() after a failed request: %v", err)
+	}
+}
+
+func TestConnClose(t *testing.T) {
+	_, c := connect(t)
+	ctx := context.Background()
+	s, err := c.Open(ctx, "shell:cat")
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	c.Close()
+	if _, err := s.Read(make([]byte, 1)); err != errConnClosed {
+		t.Errorf("Read() after Close: got %v, want %v", err, errConnClosed)
+	}
+	if _, err := c.Open(ctx, "shell:cat"); err != errConnClosed {
+		t.Errorf("Open() after Close: got %v, want %v", err, errConnClosed)
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	adbIntf := gousb.InterfaceSetting{
+		Number:   1,
+		Class:    gousb.ClassVendorSpec,
+		SubClass: subClassADB,
+		Protocol: protocolADB,
+		Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{
+			0x81: {Address: 0x81, Number: 1, Direction: gousb.EndpointDirectionIn, TransferType: gousb.TransferTypeBulk, MaxPacketSize: 512},
+			0x02: {Address: 0x02, Number: 2, Direction: gousb.EndpointDirectionOut, TransferType: gousb.TransferTypeBulk, MaxPacketSize: 512},
+		},
+	}
+	mtp := gousb.InterfaceSetting{Number: 0, Class: gousb.ClassPTP}
+	desc := gousb.ConfigDesc{
+		Number: 1,
+		Interfaces: []gousb.InterfaceDesc{
+			{Number: 0, AltSettings: []gousb.InterfaceSetting{mtp}},
+			{Number: 1, AltSettings: []gousb.InterfaceSetting{adbIntf}},
+		},
+	}
+	alt, in, out, err := findInterface(desc)
+	if err != nil {
+		t.Fatalf("findInterface(): %v", err)
+	}
+	if alt.Number != 1 || in.Address != 0x81 || out.Address != 0x02 {
+		t.Errorf("findInterface(): got interface %d, endpoints %s and %s, want interface 1, endpoints 0x81 and 0x02", alt.Number, in.Address, out.Address)
+	}
+	if !Match(&gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: desc}}) {
+		t.Error("Match(): got false, want true")
+	}
+
+	desc.Interfaces = desc.Interfaces[:1]
+	if _, _, _, err := findInterface(desc); err == nil {
+		t.Error("findInterface() without an ADB interface: got nil error, want non-nil")
+	}
+	if Match(&gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: desc}}) {
+		t.Error("Match() without an ADB interface: got true, want false")
+	}
+}
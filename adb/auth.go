@@ -0,0 +1,107 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// tokenSize is the size of the AUTH token sent by the device.
+	tokenSize = 20
+	// keyWords is the size of the RSA modulus accepted by adbd in 32 bit
+	// words.
+	keyWords = 2048 / 32
+)
+
+// ParsePrivateKey parses a PEM encoded RSA private key, e.g. the contents
+// of ~/.android/adbkey. Both PKCS#8 and PKCS#1 encodings are accepted.
+func ParsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%T is not an RSA private key", key)
+	}
+	return rsaKey, nil
+}
+
+// signToken signs the AUTH token sent by the device. adbd treats the token
+// as a SHA-1 digest and verifies a PKCS#1 v1.5 signature over it.
+func signToken(key *rsa.PrivateKey, token []byte) ([]byte, error) {
+	if len(token) != tokenSize {
+		return nil, fmt.Errorf("AUTH token has %d bytes, want %d", len(token), tokenSize)
+	}
+	return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, token)
+}
+
+// EncodePublicKey encodes an RSA public key in the format used by adbd
+// (~/.android/adbkey.pub): the base64 encoding of the modulus in
+// little-endian 32 bit words along with precomputed Montgomery values,
+// followed by a space and a name identifying the key, e.g. "user@host".
+// Only 2048 bit keys are supported by adbd.
+func EncodePublicKey(pub *rsa.PublicKey, name string) ([]byte, error) {
+	if pub.N.BitLen() != keyWords*32 {
+		return nil, fmt.Errorf("RSA key has %d bits, want %d", pub.N.BitLen(), keyWords*32)
+	}
+	// struct RSAPublicKey {
+	//   uint32 modulus_size_words; uint32 n0inv;
+	//   uint32 modulus[64]; uint32 rr[64]; uint32 exponent;
+	// }
+	raw := make([]byte, 4*(3+2*keyWords))
+	binary.LittleEndian.PutUint32(raw[0:], keyWords)
+
+	r32 := new(big.Int).Lsh(big.NewInt(1), 32)
+	n0 := new(big.Int).Mod(pub.N, r32)
+	n0inv := new(big.Int).ModInverse(n0, r32)
+	n0inv.Sub(r32, n0inv)
+	binary.LittleEndian.PutUint32(raw[4:], uint32(n0inv.Uint64()))
+
+	rr := new(big.Int).Lsh(big.NewInt(1), 2*keyWords*32)
+	rr.Mod(rr, pub.N)
+	putWords(raw[8:], pub.N)
+	putWords(raw[8+4*keyWords:], rr)
+	binary.LittleEndian.PutUint32(raw[8+8*keyWords:], uint32(pub.E))
+
+	enc := base64.StdEncoding.EncodeToString(raw)
+	return []byte(enc + " " + name), nil
+}
+
+// putWords stores x in b as little-endian 32 bit words, least significant
+// word first.
+func putWords(b []byte, x *big.Int) {
+	be := x.FillBytes(make([]byte, 4*keyWords))
+	for i := range be {
+		b[i] = be[len(be)-1-i]
+	}
+}
@@ -0,0 +1,451 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrUnauthorized is returned by Connect if the device rejected the key
+// and the user didn't accept it on the device.
+var ErrUnauthorized = errors.New("device rejected the ADB key")
+
+// errConnClosed is returned by stream operations after the connection
+// was closed.
+var errConnClosed = errors.New("ADB connection closed")
+
+// Config configures the connection handshake.
+type Config struct {
+	// Key is the private key used to authenticate with the device. If nil,
+	// only devices that don't require authentication can be used.
+	Key *rsa.PrivateKey
+	// KeyName identifies the key when its public part is offered to the
+	// device, e.g. "user@host". It's shown to the user in the authorization
+	// prompt.
+	KeyName string
+	// SendPublicKey allows offering the public key to the device if the
+	// signature is rejected. The device then prompts the user to accept
+	// the key and the handshake blocks until the user responds.
+	SendPublicKey bool
+}
+
+// Conn is an ADB connection to a device, multiplexing streams over a pair
+// of bulk endpoints.
+type Conn struct {
+	// Banner is the connection banner sent by the device, e.g.
+	// "device::ro.product.name=...;ro.product.model=...;features=...".
+	Banner string
+	// Features lists the features supported by the device, parsed from
+	// the banner.
+	Features []string
+
+	r       io.Reader
+	w       io.Writer
+	maxData int
+	// wmu serializes messages written to the device.
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	streams map[uint32]*Stream
+	nextID  uint32
+	err     error
+	done    chan struct{}
+	release func()
+}
+
+// Connect performs the CNXN/AUTH handshake on the given endpoints and
+// starts processing messages from the device. r and w are usually the bulk
+// IN and OUT endpoints of the ADB interface, see Open.
+func Connect(ctx context.Context, r io.Reader, w io.Writer, cfg Config) (*Conn, error) {
+	c := &Conn{
+		r:       r,
+		w:       w,
+		streams: make(map[uint32]*Stream),
+		nextID:  1,
+		done:    make(chan struct{}),
+	}
+	if err := c.handshake(ctx, cfg); err != nil {
+		return nil, err
+	}
+	go c.run()
+	return c, nil
+}
+
+// readContext reads a message, giving up when ctx is done. The read itself
+// is not interrupted, only abandoned.
+func (c *Conn) readContext(ctx context.Context, maxData int) (*message, error) {
+	type result struct {
+		m   *message
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		m, err := readMessage(c.r, maxData)
+		ch <- result{m, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.m, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Conn) handshake(ctx context.Context, cfg Config) error {
+	if err := c.send(&message{command: cmdCNXN, arg0: protocolVersion, arg1: maxPayload, data: []byte("host::\x00")}); err != nil {
+		return err
+	}
+	var signed, sentKey bool
+	for {
+		m, err := c.readContext(ctx, maxPayload)
+		if err != nil {
+			return fmt.Errorf("ADB handshake: %v", err)
+		}
+		switch m.command {
+		case cmdCNXN:
+			c.maxData = int(m.arg1)
+			if c.maxData > maxPayload || c.maxData == 0 {
+				c.maxData = maxPayload
+			}
+			c.parseBanner(m.data)
+			return nil
+		case cmdAUTH:
+			if m.arg0 != authToken {
+				return fmt.Errorf("ADB handshake: unexpected AUTH type %d", m.arg0)
+			}
+			switch {
+			case cfg.Key != nil && !signed:
+				sig, err := signToken(cfg.Key, m.data)
+				if err != nil {
+					return fmt.Errorf("ADB handshake: %v", err)
+				}
+				signed = true
+				err = c.send(&message{command: cmdAUTH, arg0: authSignature, data: sig})
+				if err != nil {
+					return err
+				}
+			case cfg.Key != nil && cfg.SendPublicKey && !sentKey:
+				pub, err := EncodePublicKey(&cfg.Key.PublicKey, cfg.KeyName)
+				if err != nil {
+					return fmt.Errorf("ADB handshake: %v", err)
+				}
+				sentKey = true
+				if err := c.send(&message{command: cmdAUTH, arg0: authRSAPublicKey, data: append(pub, 0)}); err != nil {
+					return err
+				}
+			default:
+				return ErrUnauthorized
+			}
+		default:
+			return fmt.Errorf("ADB handshake: unexpected message %s", m)
+		}
+	}
+}
+
+func (c *Conn) parseBanner(b []byte) {
+	c.Banner = strings.TrimRight(string(b), "\x00")
+	i := strings.Index(c.Banner, "::")
+	if i < 0 {
+		return
+	}
+	for _, p := range strings.Split(c.Banner[i+2:], ";") {
+		if strings.HasPrefix(p, "features=") && len(p) > len("features=") {
+			c.Features = strings.Split(p[len("features="):], ",")
+		}
+	}
+}
+
+// HasFeature returns true if the device announced the given feature,
+// e.g. "shell_v2".
+func (c *Conn) HasFeature(f string) bool {
+	for _, got := range c.Features {
+		if got == f {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Conn) send(m *message) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return writeMessage(c.w, m)
+}
+
+// run dispatches the messages received from the device to the streams
+// until the connection fails or is closed.
+func (c *Conn) run() {
+	for {
+		m, err := readMessage(c.r, c.maxData)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch m.command {
+		case cmdOKAY:
+			if s := c.stream(m.arg1); s != nil {
+				s.okay(m.arg0)
+			}
+		case cmdWRTE:
+			if s := c.stream(m.arg1); s != nil {
+				s.deliver(m.data)
+			}
+		case cmdCLSE:
+			if s := c.stream(m.arg1); s != nil {
+				c.remove(s)
+				s.remoteClose()
+			}
+		case cmdCNXN, cmdAUTH:
+			c.fail(errors.New("device restarted the ADB connection"))
+			return
+		}
+	}
+}
+
+func (c *Conn) stream(local uint32) *Stream {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.streams[local]
+}
+
+func (c *Conn) remove(s *Stream) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.streams, s.local)
+}
+
+// fail terminates the connection with the given error.
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// Err returns the error that terminated the connection, if any.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Open opens a stream to a service on the device, e.g. "shell:ls" or
+// "sync:". Open returns an error if the device refuses the service.
+func (c *Conn) Open(ctx context.Context, service string) (*Stream, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	s := newStream(c, c.nextID)
+	c.nextID++
+	c.streams[s.local] = s
+	c.mu.Unlock()
+
+	if err := c.send(&message{command: cmdOPEN, arg0: s.local, data: append([]byte(service), 0)}); err != nil {
+		c.remove(s)
+		return nil, err
+	}
+	select {
+	case <-s.opened:
+		return s, nil
+	case <-s.remoteClosed:
+		return nil, fmt.Errorf("device refused to open service %q", service)
+	case <-c.done:
+		return nil, c.Err()
+	case <-ctx.Done():
+		c.remove(s)
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the connection. Open streams fail with an error. If the
+// connection was created by Open, the ADB interface is released.
+func (c *Conn) Close() error {
+	c.fail(errConnClosed)
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
+
+// Stream is a bidirectional byte stream to a service on the device.
+type Stream struct {
+	c      *Conn
+	local  uint32
+	remote uint32
+
+	// opened is closed when the device accepts the stream.
+	opened chan struct{}
+	// in carries the payload of a WRTE message. The device only sends
+	// the next WRTE after it receives OKAY, so in never holds more than
+	// one payload.
+	in chan []byte
+	// acks receives the OKAY messages for WRTE messages sent to the device.
+	acks chan struct{}
+	// remoteClosed is closed when the device closes the stream.
+	remoteClosed chan struct{}
+	// closed is closed by Close.
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// buf is the unread part of the last received payload.
+	buf []byte
+	// wmu serializes writes, which are acknowledged one by one.
+	wmu sync.Mutex
+}
+
+func newStream(c *Conn, local uint32) *Stream {
+	return &Stream{
+		c:            c,
+		local:        local,
+		opened:       make(chan struct{}),
+		in:           make(chan []byte, 1),
+		acks:         make(chan struct{}, 1),
+		remoteClosed: make(chan struct{}),
+		closed:       make(chan struct{}),
+	}
+}
+
+// okay handles an OKAY message: the first one acknowledges OPEN, the
+// following ones acknowledge WRTE.
+func (s *Stream) okay(remote uint32) {
+	select {
+	case <-s.opened:
+		select {
+		case s.acks <- struct{}{}:
+		default:
+		}
+	default:
+		s.remote = remote
+		close(s.opened)
+	}
+}
+
+func (s *Stream) deliver(data []byte) {
+	select {
+	case s.in <- data:
+	default:
+		// The device ignored flow control, drop the stream.
+		s.c.remove(s)
+		s.remoteClose()
+	}
+}
+
+func (s *Stream) remoteClose() {
+	close(s.remoteClosed)
+}
+
+// Read reads data sent by the service. Read returns io.EOF after the device
+// closed the stream and all data was read.
+func (s *Stream) Read(p []byte) (int, error) {
+	if len(s.buf) == 0 {
+		select {
+		case s.buf = <-s.in:
+		default:
+			select {
+			case s.buf = <-s.in:
+			case <-s.remoteClosed:
+				// Data might have arrived just before CLSE.
+				select {
+				case s.buf = <-s.in:
+				default:
+					return 0, io.EOF
+				}
+			case <-s.closed:
+				return 0, io.ErrClosedPipe
+			case <-s.c.done:
+				return 0, s.c.Err()
+			}
+		}
+		if len(s.buf) == 0 {
+			return 0, nil
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	if len(s.buf) == 0 && !isClosed(s.remoteClosed) {
+		// Ask the device for more data.
+		if err := s.c.send(&message{command: cmdOKAY, arg0: s.local, arg1: s.remote}); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// Write sends data to the service. Data is split into messages of the
+// maximum size accepted by the device, each acknowledged by the device
+// before the next one is sent.
+func (s *Stream) Write(p []byte) (int, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	written := 0
+	for written < len(p) {
+		n := len(p) - written
+		if n > s.c.maxData {
+			n = s.c.maxData
+		}
+		if err := s.c.send(&message{command: cmdWRTE, arg0: s.local, arg1: s.remote, data: p[written : written+n]}); err != nil {
+			return written, err
+		}
+		select {
+		case <-s.acks:
+		case <-s.remoteClosed:
+			return written, io.ErrClosedPipe
+		case <-s.closed:
+			return written, io.ErrClosedPipe
+		case <-s.c.done:
+			return written, s.c.Err()
+		}
+		written += n
+	}
+	return written, nil
+}
+
+// Close closes the stream.
+func (s *Stream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.c.remove(s)
+		select {
+		case <-s.remoteClosed:
+			// The device already closed its end.
+		case <-s.c.done:
+		default:
+			err = s.c.send(&message{command: cmdCLSE, arg0: s.local, arg1: s.remote})
+		}
+	})
+	return err
+}
@@ -0,0 +1,282 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fakeFile is a file stored by fakeDevice.
+type fakeFile struct {
+	mode  uint32
+	mtime uint32
+	data  []byte
+}
+
+// fakeStream is the device side of a stream.
+type fakeStream struct {
+	id, host uint32
+	service  string
+	// out is the data queued for the host, sent one message at a time.
+	out [][]byte
+	// waitingAck is true while a WRTE sent to the host is not acknowledged.
+	waitingAck bool
+	// closing is set when the stream should be closed after out is sent.
+	closing bool
+	// in buffers the data received from the host (sync service).
+	in bytes.Buffer
+	// sendPath, sendMode and sendData hold the state of a sync SEND request.
+	sendPath string
+	sendMode uint32
+	sendData []byte
+}
+
+// fakeDevice implements the device side of the ADB protocol: the handshake,
+// stream multiplexing with flow control and a few services:
+// "shell:echo ...", "shell:cat", which echoes the data back, and "sync:"
+// backed by an in-memory file system.
+type fakeDevice struct {
+	r io.Reader
+	w io.Writer
+	// key is the public key the device trusts. If nil and acceptKey is
+	// false, the device doesn't require authentication.
+	key *rsa.PublicKey
+	// acceptKey makes the device accept any public key offered by
+	// the host, as if the user accepted the prompt.
+	acceptKey bool
+	// maxData is the maximum payload announced by the device.
+	maxData int
+	banner  string
+
+	mu       sync.Mutex
+	files    map[string]*fakeFile
+	offered  []byte
+	streams  map[uint32]*fakeStream
+	nextID   uint32
+	token    []byte
+	maxWrite int
+}
+
+// newFakeDevice returns a fake device and the host ends of the pipes
+// connected to it. cleanup closes the pipes, which stops the device.
+func newFakeDevice() (*fakeDevice, io.Reader, io.Writer, func()) {
+	hostR, devW := io.Pipe()
+	devR, hostW := io.Pipe()
+	d := &fakeDevice{
+		r:       devR,
+		w:       devW,
+		maxData: 4096,
+		banner:  "device::ro.product.name=fake;ro.product.model=Fake;features=shell_v2,cmd\x00",
+		files:   make(map[string]*fakeFile),
+		streams: make(map[uint32]*fakeStream),
+		nextID:  100,
+	}
+	cleanup := func() {
+		hostR.Close()
+		hostW.Close()
+	}
+	return d, hostR, hostW, cleanup
+}
+
+func (d *fakeDevice) send(cmd, arg0, arg1 uint32, data []byte) {
+	writeMessage(d.w, &message{command: cmd, arg0: arg0, arg1: arg1, data: data})
+}
+
+func (d *fakeDevice) sendToken() {
+	d.token = make([]byte, tokenSize)
+	rand.Read(d.token)
+	d.send(cmdAUTH, authToken, 0, d.token)
+}
+
+// run processes host messages until the pipes are closed.
+func (d *fakeDevice) run() {
+	for {
+		m, err := readMessage(d.r, maxPayload)
+		if err != nil {
+			return
+		}
+		d.handle(m)
+	}
+}
+
+func (d *fakeDevice) handle(m *message) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch m.command {
+	case cmdCNXN:
+		if d.key == nil && !d.acceptKey {
+			d.send(cmdCNXN, protocolVersion, uint32(d.maxData), []byte(d.banner))
+			return
+		}
+		d.sendToken()
+	case cmdAUTH:
+		switch m.arg0 {
+		case authSignature:
+			if d.key != nil && rsa.VerifyPKCS1v15(d.key, crypto.SHA1, d.token, m.data) == nil {
+				d.send(cmdCNXN, protocolVersion, uint32(d.maxData), []byte(d.banner))
+				return
+			}
+			d.sendToken()
+		case authRSAPublicKey:
+			d.offered = m.data
+			if d.acceptKey {
+				d.send(cmdCNXN, protocolVersion, uint32(d.maxData), []byte(d.banner))
+			}
+		}
+	case cmdOPEN:
+		service := strings.TrimRight(string(m.data), "\x00")
+		if !strings.HasPrefix(service, "shell:") && service != "sync:" {
+			d.send(cmdCLSE, 0, m.arg0, nil)
+			return
+		}
+		s := &fakeStream{id: d.nextID, host: m.arg0, service: service}
+		d.nextID++
+		d.streams[s.id] = s
+		d.send(cmdOKAY, s.id, s.host, nil)
+		if cmd := strings.TrimPrefix(service, "shell:"); strings.HasPrefix(cmd, "echo ") {
+			d.queue(s, []byte(strings.TrimPrefix(cmd, "echo ")+"\n"))
+			s.closing = true
+			d.pump(s)
+		}
+	case cmdWRTE:
+		s, ok := d.streams[m.arg1]
+		if !ok {
+			return
+		}
+		if len(m.data) > d.maxWrite {
+			d.maxWrite = len(m.data)
+		}
+		d.send(cmdOKAY, s.id, s.host, nil)
+		if s.service == "shell:cat" {
+			d.queue(s, m.data)
+		} else if s.service == "sync:" {
+			s.in.Write(m.data)
+			d.handleSync(s)
+		}
+		d.pump(s)
+	case cmdOKAY:
+		if s, ok := d.streams[m.arg1]; ok {
+			s.waitingAck = false
+			d.pump(s)
+		}
+	case cmdCLSE:
+		delete(d.streams, m.arg1)
+	}
+}
+
+func (d *fakeDevice) queue(s *fakeStream, data []byte) {
+	for len(data) > 0 {
+		n := len(data)
+		if n > d.maxData {
+			n = d.maxData
+		}
+		s.out = append(s.out, append([]byte(nil), data[:n]...))
+		data = data[n:]
+	}
+}
+
+// pump sends the next queued message of the stream if the previous one
+// was acknowledged.
+func (d *fakeDevice) pump(s *fakeStream) {
+	if s.waitingAck {
+		return
+	}
+	if len(s.out) > 0 {
+		d.send(cmdWRTE, s.id, s.host, s.out[0])
+		s.out = s.out[1:]
+		s.waitingAck = true
+		return
+	}
+	if s.closing {
+		delete(d.streams, s.id)
+		d.send(cmdCLSE, s.id, s.host, nil)
+	}
+}
+
+func syncPacket(id string, n uint32, data []byte) []byte {
+	b := make([]byte, 8, 8+len(data))
+	copy(b, id)
+	binary.LittleEndian.PutUint32(b[4:], n)
+	return append(b, data...)
+}
+
+// handleSync processes the complete sync requests buffered in s.in.
+func (d *fakeDevice) handleSync(s *fakeStream) {
+	for s.in.Len() >= 8 {
+		hdr := s.in.Bytes()[:8]
+		id, n := string(hdr[:4]), binary.LittleEndian.Uint32(hdr[4:])
+		argLen := int(n)
+		if id == syncDONE {
+			argLen = 0 // DONE carries the mtime instead of a length
+		}
+		if s.in.Len() < 8+argLen {
+			return
+		}
+		s.in.Next(8)
+		arg := s.in.Next(argLen)
+		switch id {
+		case syncSTAT:
+			resp := make([]byte, 12)
+			if f, ok := d.files[string(arg)]; ok {
+				binary.LittleEndian.PutUint32(resp[0:], f.mode)
+				binary.LittleEndian.PutUint32(resp[4:], uint32(len(f.data)))
+				binary.LittleEndian.PutUint32(resp[8:], f.mtime)
+			}
+			d.queue(s, append([]byte(syncSTAT), resp...))
+		case syncSEND:
+			path := string(arg)
+			i := strings.LastIndex(path, ",")
+			mode, _ := strconv.Atoi(path[i+1:])
+			s.sendPath, s.sendMode, s.sendData = path[:i], uint32(mode), nil
+		case syncDATA:
+			s.sendData = append(s.sendData, arg...)
+		case syncDONE:
+			if strings.HasPrefix(s.sendPath, "/readonly/") {
+				msg := "Read-only file system"
+				d.queue(s, syncPacket(syncFAIL, uint32(len(msg)), []byte(msg)))
+				continue
+			}
+			d.files[s.sendPath] = &fakeFile{mode: s.sendMode, mtime: n, data: s.sendData}
+			d.queue(s, syncPacket(syncOKAY, 0, nil))
+		case syncRECV:
+			f, ok := d.files[string(arg)]
+			if !ok {
+				msg := "No such file or directory"
+				d.queue(s, syncPacket(syncFAIL, uint32(len(msg)), []byte(msg)))
+				continue
+			}
+			var resp []byte
+			for data := f.data; len(data) > 0; {
+				n := len(data)
+				if n > syncMaxData {
+					n = syncMaxData
+				}
+				resp = append(resp, syncPacket(syncDATA, uint32(n), data[:n])...)
+				data = data[n:]
+			}
+			d.queue(s, append(resp, syncPacket(syncDONE, 0, nil)...))
+		case syncQUIT:
+			s.closing = true
+		}
+	}
+}
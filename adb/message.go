@@ -0,0 +1,140 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Message commands, the ASCII command names in little-endian order.
+const (
+	cmdSYNC = 0x434e5953
+	cmdCNXN = 0x4e584e43
+	cmdAUTH = 0x48545541
+	cmdOPEN = 0x4e45504f
+	cmdOKAY = 0x59414b4f
+	cmdCLSE = 0x45534c43
+	cmdWRTE = 0x45545257
+)
+
+// AUTH message types, sent in arg0.
+const (
+	authToken        = 1
+	authSignature    = 2
+	authRSAPublicKey = 3
+)
+
+const (
+	// headerSize is the size of the message header.
+	headerSize = 24
+	// protocolVersion is the version sent in CNXN. Devices that report
+	// this version or newer don't check the data checksum.
+	protocolVersion = 0x01000001
+	// maxPayload is the maximum payload size announced to the device.
+	maxPayload = 256 * 1024
+)
+
+func commandName(cmd uint32) string {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], cmd)
+	for _, c := range b {
+		if c < 'A' || c > 'Z' {
+			return fmt.Sprintf("0x%08x", cmd)
+		}
+	}
+	return string(b[:])
+}
+
+// message is a single ADB protocol message: a header and an optional
+// payload.
+type message struct {
+	command    uint32
+	arg0, arg1 uint32
+	data       []byte
+}
+
+func (m *message) String() string {
+	return fmt.Sprintf("%s(0x%x, 0x%x, %d bytes)", commandName(m.command), m.arg0, m.arg1, len(m.data))
+}
+
+// checksum is the sum of all payload bytes, as used by protocol versions
+// before 0x01000001.
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for _, b := range data {
+		sum += uint32(b)
+	}
+	return sum
+}
+
+func (m *message) header() []byte {
+	h := make([]byte, headerSize)
+	binary.LittleEndian.PutUint32(h[0:], m.command)
+	binary.LittleEndian.PutUint32(h[4:], m.arg0)
+	binary.LittleEndian.PutUint32(h[8:], m.arg1)
+	binary.LittleEndian.PutUint32(h[12:], uint32(len(m.data)))
+	binary.LittleEndian.PutUint32(h[16:], checksum(m.data))
+	binary.LittleEndian.PutUint32(h[20:], m.command^0xffffffff)
+	return h
+}
+
+// writeMessage sends the header and the payload of a message in separate
+// writes, i.e. separate USB transfers, as expected by adbd.
+func writeMessage(w io.Writer, m *message) error {
+	if _, err := w.Write(m.header()); err != nil {
+		return fmt.Errorf("writing %s header: %v", m, err)
+	}
+	if len(m.data) == 0 {
+		return nil
+	}
+	if _, err := w.Write(m.data); err != nil {
+		return fmt.Errorf("writing %s payload: %v", m, err)
+	}
+	return nil
+}
+
+// readMessage reads a message with a payload of at most maxData bytes.
+func readMessage(r io.Reader, maxData int) (*message, error) {
+	h := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, err
+	}
+	m := &message{
+		command: binary.LittleEndian.Uint32(h[0:]),
+		arg0:    binary.LittleEndian.Uint32(h[4:]),
+		arg1:    binary.LittleEndian.Uint32(h[8:]),
+	}
+	if magic := binary.LittleEndian.Uint32(h[20:]); magic != m.command^0xffffffff {
+		return nil, fmt.Errorf("invalid message header %x: magic 0x%08x does not match command 0x%08x", h, magic, m.command)
+	}
+	n := int(binary.LittleEndian.Uint32(h[12:]))
+	if n > maxData {
+		return nil, fmt.Errorf("%s: payload of %d bytes exceeds the maximum of %d", m, n, maxData)
+	}
+	if n == 0 {
+		return m, nil
+	}
+	m.data = make([]byte, n)
+	if _, err := io.ReadFull(r, m.data); err != nil {
+		return nil, fmt.Errorf("reading %s payload: %v", m, err)
+	}
+	// Newer devices send a zero checksum and don't verify it.
+	if sum := binary.LittleEndian.Uint32(h[16:]); sum != 0 && sum != checksum(m.data) {
+		return nil, fmt.Errorf("%s: payload checksum 0x%x, want 0x%x", m, checksum(m.data), sum)
+	}
+	return m, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Sync protocol request and response IDs.
+const (
+	syncSTAT = "STAT"
+	syncSEND = "SEND"
+	syncRECV = "RECV"
+	syncDATA = "DATA"
+	syncDONE = "DONE"
+	syncOKAY = "OKAY"
+	syncFAIL = "FAIL"
+	syncQUIT = "QUIT"
+)
+
+// syncMaxData is the maximum payload of a single DATA packet.
+const syncMaxData = 64 * 1024
+
+// Linux file type bits of st_mode.
+const (
+	modeTypeMask = 0170000
+	modeDir      = 0040000
+	modeSymlink  = 0120000
+	modeRegular  = 0100000
+)
+
+// FileInfo describes a file on the device.
+type FileInfo struct {
+	Mode    os.FileMode
+	Size    int64
+	ModTime time.Time
+}
+
+func fileMode(mode uint32) os.FileMode {
+	m := os.FileMode(mode & 0777)
+	switch mode & modeTypeMask {
+	case modeDir:
+		m |= os.ModeDir
+	case modeSymlink:
+		m |= os.ModeSymlink
+	}
+	return m
+}
+
+// Shell runs a command on the device and returns its output.
+func (c *Conn) Shell(ctx context.Context, cmd string) ([]byte, error) {
+	s, err := c.Open(ctx, "shell:"+cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	return io.ReadAll(s)
+}
+
+// SyncConn is a session of the file sync service, used to transfer files
+// to and from the device.
+type SyncConn struct {
+	s *Stream
+	r *bufio.Reader
+}
+
+// Sync opens a file sync session.
+func (c *Conn) Sync(ctx context.Context) (*SyncConn, error) {
+	s, err := c.Open(ctx, "sync:")
+	if err != nil {
+		return nil, err
+	}
+	return &SyncConn{s: s, r: bufio.NewReader(s)}, nil
+}
+
+func syncHeader(id string, n int) []byte {
+	b := make([]byte, 8, 8+n)
+	copy(b, id)
+	binary.LittleEndian.PutUint32(b[4:], uint32(n))
+	return b
+}
+
+func (sc *SyncConn) request(id, arg string) error {
+	_, err := sc.s.Write(append(syncHeader(id, len(arg)), arg...))
+	return err
+}
+
+func (sc *SyncConn) readHeader() (string, uint32, error) {
+	var h [8]byte
+	if _, err := io.ReadFull(sc.r, h[:]); err != nil {
+		return "", 0, err
+	}
+	return string(h[:4]), binary.LittleEndian.Uint32(h[4:]), nil
+}
+
+// readFail reads the message of a FAIL response.
+func (sc *SyncConn) readFail(n uint32) error {
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(sc.r, msg); err != nil {
+		return err
+	}
+	return fmt.Errorf("sync: %s", msg)
+}
+
+// Stat returns information about a file on the device. It returns an error
+// satisfying os.IsNotExist if the file doesn't exist.
+func (sc *SyncConn) Stat(path string) (*FileInfo, error) {
+	if err := sc.request(syncSTAT, path); err != nil {
+		return nil, err
+	}
+	var resp [16]byte
+	if _, err := io.ReadFull(sc.r, resp[:]); err != nil {
+		return nil, err
+	}
+	if id := string(resp[:4]); id != syncSTAT {
+		return nil, fmt.Errorf("sync: unexpected response %q to STAT", id)
+	}
+	mode := binary.LittleEndian.Uint32(resp[4:])
+	if mode == 0 {
+		return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+	return &FileInfo{
+		Mode:    fileMode(mode),
+		Size:    int64(binary.LittleEndian.Uint32(resp[8:])),
+		ModTime: time.Unix(int64(binary.LittleEndian.Uint32(resp[12:])), 0),
+	}, nil
+}
+
+// Push copies the contents of r to a file on the device, with the given
+// permissions and modification time.
+func (sc *SyncConn) Push(r io.Reader, path string, perm os.FileMode, mtime time.Time) error {
+	arg := path + "," + strconv.Itoa(int(modeRegular|uint32(perm&0777)))
+	if err := sc.request(syncSEND, arg); err != nil {
+		return err
+	}
+	buf := make([]byte, 8+syncMaxData)
+	for {
+		n, err := r.Read(buf[8:])
+		if n > 0 {
+			copy(buf, syncHeader(syncDATA, n))
+			if _, err := sc.s.Write(buf[:8+n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := sc.s.Write(syncHeader(syncDONE, int(mtime.Unix()))); err != nil {
+		return err
+	}
+	id, n, err := sc.readHeader()
+	if err != nil {
+		return err
+	}
+	switch id {
+	case syncOKAY:
+		return nil
+	case syncFAIL:
+		return sc.readFail(n)
+	}
+	return fmt.Errorf("sync: unexpected response %q to SEND", id)
+}
+
+// Pull copies the contents of a file on the device to w. It returns
+// the number of bytes copied.
+func (sc *SyncConn) Pull(path string, w io.Writer) (int64, error) {
+	if err := sc.request(syncRECV, path); err != nil {
+		return 0, err
+	}
+	var total int64
+	for {
+		id, n, err := sc.readHeader()
+		if err != nil {
+			return total, err
+		}
+		switch id {
+		case syncDATA:
+			if n > syncMaxData {
+				return total, fmt.Errorf("sync: DATA packet of %d bytes exceeds the maximum of %d", n, syncMaxData)
+			}
+			got, err := io.CopyN(w, sc.r, int64(n))
+			total += got
+			if err != nil {
+				return total, err
+			}
+		case syncDONE:
+			return total, nil
+		case syncFAIL:
+			return total, sc.readFail(n)
+		default:
+			return total, fmt.Errorf("sync: unexpected response %q to RECV", id)
+		}
+	}
+}
+
+// Close ends the sync session.
+func (sc *SyncConn) Close() error {
+	err := sc.request(syncQUIT, "")
+	if cErr := sc.s.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
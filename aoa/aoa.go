@@ -0,0 +1,357 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aoa switches Android devices to Android Open Accessory mode and
+// communicates with the accessory application on the device.
+//
+// Open sends the identifying strings of the accessory, asks the device to
+// start in accessory mode, waits for it to reappear with a Google accessory
+// product ID and claims the accessory interface:
+//
+//	dev, err := ctx.OpenDeviceWithVIDPID(0x18d1, 0x4ee7)
+//	...
+//	defer dev.Close()
+//	c, err := aoa.Open(context.Background(), ctx, dev, aoa.Config{
+//		Manufacturer: "Example",
+//		Model:        "Widget",
+//		Version:      "1.0",
+//	})
+//	...
+//	defer c.Close()
+//	_, err = c.Write([]byte("hello"))
+//
+// AOA2 devices also support audio output and HID input devices, see
+// Config.Audio and Config.HID.
+package aoa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Vendor and product IDs of devices in accessory mode.
+const (
+	VendorGoogle             gousb.ID = 0x18d1
+	ProductAccessory         gousb.ID = 0x2d00
+	ProductAccessoryADB      gousb.ID = 0x2d01
+	ProductAudio             gousb.ID = 0x2d02
+	ProductAudioADB          gousb.ID = 0x2d03
+	ProductAccessoryAudio    gousb.ID = 0x2d04
+	ProductAccessoryAudioADB gousb.ID = 0x2d05
+)
+
+// subClassAccessory is the subclass of the vendor specific accessory
+// interface.
+const subClassAccessory gousb.Class = 0xff
+
+// pollInterval is the interval between enumerations while waiting for
+// the device to reappear in accessory mode.
+const pollInterval = 100 * time.Millisecond
+
+// Config describes the accessory to the device. The strings are used by
+// Android to find the application handling the accessory. Empty strings
+// are not sent.
+type Config struct {
+	Manufacturer string
+	Model        string
+	Description  string
+	Version      string
+	// URI is shown to the user if no application handles the accessory.
+	URI    string
+	Serial string
+
+	// Audio enables the AOA2 audio output of the device, as 16 bit stereo
+	// PCM at 44.1kHz on a USB audio interface.
+	Audio bool
+	// HID lists the AOA2 HID input devices registered once the device
+	// is in accessory mode.
+	HID []HIDDevice
+}
+
+// HIDDevice is a HID input device emulated by the accessory.
+type HIDDevice struct {
+	// ID identifies the HID device in later events, chosen by the
+	// accessory.
+	ID uint16
+	// ReportDescriptor is the HID report descriptor of the device.
+	ReportDescriptor []byte
+}
+
+// IsAccessory returns true if the device is in accessory mode with an
+// accessory interface.
+func IsAccessory(desc *gousb.DeviceDesc) bool {
+	if desc.Vendor != VendorGoogle {
+		return false
+	}
+	switch desc.Product {
+	case ProductAccessory, ProductAccessoryADB, ProductAccessoryAudio, ProductAccessoryAudioADB:
+		return true
+	}
+	return false
+}
+
+// findInterface returns the accessory interface setting of the
+// configuration and its bulk endpoints.
+func findInterface(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if alt.Class != gousb.ClassVendorSpec || alt.SubClass != subClassAccessory {
+				continue
+			}
+			var in, out *gousb.EndpointDesc
+			for _, ep := range alt.Endpoints {
+				if ep.TransferType != gousb.TransferTypeBulk {
+					continue
+				}
+				ep := ep
+				if ep.Direction == gousb.EndpointDirectionIn {
+					in = &ep
+				} else {
+					out = &ep
+				}
+			}
+			if in == nil || out == nil {
+				return nil, nil, nil, fmt.Errorf("accessory interface %d alt %d has no bulk IN and OUT endpoints", alt.Number, alt.Alternate)
+			}
+			alt := alt
+			return &alt, in, out, nil
+		}
+	}
+	return nil, nil, nil, fmt.Errorf("%s has no accessory interface", desc)
+}
+
+// opener opens the devices selected by a function, like
+// gousb.Context.OpenDevices.
+type opener func(func(*gousb.DeviceDesc) bool) ([]*gousb.Device, error)
+
+// samePort returns true if both devices are connected to the same port.
+// Devices without a known port path are assumed to be on the same port.
+func samePort(a, b *gousb.DeviceDesc) bool {
+	if len(a.Path) == 0 || len(b.Path) == 0 {
+		return true
+	}
+	if a.Bus != b.Bus || len(a.Path) != len(b.Path) {
+		return false
+	}
+	for i := range a.Path {
+		if a.Path[i] != b.Path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// waitAccessory waits for a device in accessory mode to appear on the port
+// of the original device and opens it.
+func waitAccessory(ctx context.Context, open opener, orig *gousb.DeviceDesc, interval time.Duration) (*gousb.Device, error) {
+	var lastErr error
+	for {
+		devs, err := open(func(desc *gousb.DeviceDesc) bool {
+			return IsAccessory(desc) && samePort(desc, orig)
+		})
+		if len(devs) > 0 {
+			for _, d := range devs[1:] {
+				d.Close()
+			}
+			return devs[0], nil
+		}
+		// Enumeration or opening may fail while the device is being
+		// re-enumerated, keep trying until ctx is done.
+		if err != nil {
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("waiting for the accessory device: %v (last error: %v)", ctx.Err(), lastErr)
+			}
+			return nil, fmt.Errorf("waiting for the accessory device: %v", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// reader receives the data sent by the accessory application.
+// *gousb.InEndpoint implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends data to the accessory application. *gousb.OutEndpoint
+// implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Conn is a connection to the accessory application on a device in
+// accessory mode.
+type Conn struct {
+	// Device is the device in accessory mode.
+	Device *gousb.Device
+	// Protocol is the AOA protocol version supported by the device.
+	Protocol int
+
+	in      reader
+	out     writer
+	ctl     controller
+	hid     []uint16
+	release func()
+}
+
+// Open switches the device to accessory mode and returns a connection to
+// the accessory interface once the device reappears on the bus. The
+// context limits the time spent waiting for the device. After a successful
+// switch dev no longer refers to a connected device, but must still be
+// closed by the caller. If dev is already in accessory mode, its
+// accessory interface is used directly.
+func Open(ctx context.Context, gctx *gousb.Context, dev *gousb.Device, cfg Config) (*Conn, error) {
+	acc, own := dev, false
+	if !IsAccessory(dev.Desc) {
+		if err := start(dev, cfg); err != nil {
+			return nil, fmt.Errorf("device %s: %v", dev, err)
+		}
+		var err error
+		if acc, err = waitAccessory(ctx, gctx.OpenDevices, dev.Desc, pollInterval); err != nil {
+			return nil, fmt.Errorf("device %s: %v", dev, err)
+		}
+		own = true
+	}
+	v, err := Protocol(acc)
+	if err != nil {
+		if own {
+			acc.Close()
+		}
+		return nil, fmt.Errorf("accessory %s: %v", acc, err)
+	}
+	if len(cfg.HID) > 0 && v < 2 {
+		if own {
+			acc.Close()
+		}
+		return nil, fmt.Errorf("HID devices require AOA protocol version 2, device supports version %d", v)
+	}
+	c, err := open(acc, cfg)
+	if err != nil {
+		if own {
+			acc.Close()
+		}
+		return nil, err
+	}
+	c.Protocol = v
+	if own {
+		release := c.release
+		c.release = func() {
+			release()
+			acc.Close()
+		}
+	}
+	return c, nil
+}
+
+// open claims the accessory interface of a device in accessory mode and
+// registers the HID devices.
+func open(dev *gousb.Device, cfg Config) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, inDesc, outDesc, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	ucfg, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{Device: dev, ctl: dev}
+	c.release = func() {
+		for _, id := range c.hid {
+			unregisterHID(c.ctl, id)
+		}
+		c.hid = nil
+		ucfg.Close()
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		c.release()
+		return nil, err
+	}
+	c.in, c.out = in, out
+	if err := c.registerHID(cfg.HID, dev.Desc.MaxControlPacketSize); err != nil {
+		c.release()
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	return c, nil
+}
+
+// registerHID registers the HID devices, remembering them to be
+// unregistered on Close.
+func (c *Conn) registerHID(devs []HIDDevice, maxChunk int) error {
+	if maxChunk <= 0 {
+		maxChunk = 64
+	}
+	for _, h := range devs {
+		if err := registerHID(c.ctl, h, maxChunk); err != nil {
+			return err
+		}
+		c.hid = append(c.hid, h.ID)
+	}
+	return nil
+}
+
+// Read reads data sent by the accessory application.
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.in.ReadContext(context.Background(), p)
+}
+
+// ReadContext reads data sent by the accessory application. The context
+// controls the cancellation of this particular read.
+func (c *Conn) ReadContext(ctx context.Context, p []byte) (int, error) {
+	return c.in.ReadContext(ctx, p)
+}
+
+// Write sends data to the accessory application.
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.out.WriteContext(context.Background(), p)
+}
+
+// WriteContext sends data to the accessory application. The context
+// controls the cancellation of this particular write.
+func (c *Conn) WriteContext(ctx context.Context, p []byte) (int, error) {
+	return c.out.WriteContext(ctx, p)
+}
+
+// SendHIDEvent sends an input report of a HID device registered through
+// Config.HID.
+func (c *Conn) SendHIDEvent(id uint16, report []byte) error {
+	for _, h := range c.hid {
+		if h == id {
+			return sendHIDEvent(c.ctl, id, report)
+		}
+	}
+	return fmt.Errorf("HID device %d is not registered", id)
+}
+
+// Close unregisters the HID devices and releases the accessory interface.
+// If the device was switched to accessory mode by Open, it is closed too.
+func (c *Conn) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
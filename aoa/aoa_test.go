@@ -0,0 +1,128 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aoa
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+func TestIsAccessory(t *testing.T) {
+	for _, tc := range []struct {
+		vid, pid gousb.ID
+		want     bool
+	}{
+		{VendorGoogle, ProductAccessory, true},
+		{VendorGoogle, ProductAccessoryAudioADB, true},
+		{VendorGoogle, ProductAudio, false},
+		{VendorGoogle, 0x4ee7, false},
+		{0x1234, ProductAccessory, false},
+	} {
+		if got := IsAccessory(&gousb.DeviceDesc{Vendor: tc.vid, Product: tc.pid}); got != tc.want {
+			t.Errorf("IsAccessory(%s:%s): got %v, want %v", tc.vid, tc.pid, got, tc.want)
+		}
+	}
+}
+
+func TestWaitAccessory(t *testing.T) {
+	orig := &gousb.DeviceDesc{Bus: 1, Path: []int{2, 3}, Vendor: VendorGoogle, Product: 0x4ee7}
+	enumerations := [][]*gousb.DeviceDesc{
+		// The device is still connected in the original mode.
+		{orig},
+		// The device is gone.
+		{},
+		// An accessory on another port, then the device reappears.
+		{
+			{Bus: 1, Path: []int{4}, Vendor: VendorGoogle, Product: ProductAccessory},
+			{Bus: 1, Path: []int{2, 3}, Vendor: VendorGoogle, Product: ProductAccessoryADB},
+		},
+	}
+	calls := 0
+	want := &gousb.Device{}
+	open := func(match func(*gousb.DeviceDesc) bool) ([]*gousb.Device, error) {
+		if calls >= len(enumerations) {
+			return nil, errors.New("too many enumerations")
+		}
+		var devs []*gousb.Device
+		for _, desc := range enumerations[calls] {
+			if match(desc) {
+				if desc.Path[0] != 2 {
+					return nil, errors.New("matched an accessory on another port")
+				}
+				devs = append(devs, want)
+			}
+		}
+		calls++
+		if calls == 2 {
+			return nil, errors.New("enumeration failed")
+		}
+		return devs, nil
+	}
+	got, err := waitAccessory(context.Background(), open, orig, time.Millisecond)
+	if err != nil {
+		t.Fatalf("waitAccessory(): %v", err)
+	}
+	if got != want || calls != 3 {
+		t.Errorf("waitAccessory(): got device %p after %d enumerations, want %p after 3", got, calls, want)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	never := func(func(*gousb.DeviceDesc) bool) ([]*gousb.Device, error) { return nil, nil }
+	if _, err := waitAccessory(ctx, never, orig, time.Millisecond); err == nil {
+		t.Error("waitAccessory() for a device that never appears: got nil error, want non-nil")
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	bulk := func(addr gousb.EndpointAddress) gousb.EndpointDesc {
+		dir := gousb.EndpointDirectionOut
+		if addr&0x80 != 0 {
+			dir = gousb.EndpointDirectionIn
+		}
+		return gousb.EndpointDesc{Address: addr, Number: int(addr & 0x0f), Direction: dir, TransferType: gousb.TransferTypeBulk, MaxPacketSize: 512}
+	}
+	acc := gousb.InterfaceSetting{
+		Class:     gousb.ClassVendorSpec,
+		SubClass:  subClassAccessory,
+		Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{0x81: bulk(0x81), 0x01: bulk(0x01)},
+	}
+	adb := gousb.InterfaceSetting{
+		Number:    1,
+		Class:     gousb.ClassVendorSpec,
+		SubClass:  0x42,
+		Protocol:  0x01,
+		Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{0x82: bulk(0x82), 0x02: bulk(0x02)},
+	}
+	cfg := gousb.ConfigDesc{Number: 1, Interfaces: []gousb.InterfaceDesc{
+		{Number: 1, AltSettings: []gousb.InterfaceSetting{adb}},
+		{Number: 0, AltSettings: []gousb.InterfaceSetting{acc}},
+	}}
+	alt, in, out, err := findInterface(cfg)
+	if err != nil {
+		t.Fatalf("findInterface(): %v", err)
+	}
+	if alt.Number != 0 || in.Address != 0x81 || out.Address != 0x01 {
+		t.Errorf("findInterface(): got interface %d, endpoints %s and %s, want interface 0, endpoints 0x81 and 0x01", alt.Number, in.Address, out.Address)
+	}
+	cfg.Interfaces = cfg.Interfaces[:1]
+	if _, _, _, err := findInterface(cfg); err == nil {
+		t.Error("findInterface() without an accessory interface: got nil error, want non-nil")
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aoa
+
+import (
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// Vendor requests of the Android Open Accessory protocol.
+const (
+	reqGetProtocol      = 51
+	reqSendString       = 52
+	reqStart            = 53
+	reqRegisterHID      = 54
+	reqUnregisterHID    = 55
+	reqSetHIDReportDesc = 56
+	reqSendHIDEvent     = 57
+	reqSetAudioMode     = 58
+)
+
+// Indices of the identifying strings sent with reqSendString.
+const (
+	stringManufacturer = iota
+	stringModel
+	stringDescription
+	stringVersion
+	stringURI
+	stringSerial
+)
+
+const (
+	rTypeOut = uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlDevice)
+	rTypeIn  = uint8(gousb.ControlIn | gousb.ControlVendor | gousb.ControlDevice)
+)
+
+// controller carries the vendor requests of the accessory protocol.
+// *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// Protocol returns the version of the accessory protocol supported by
+// the device: 1 for AOA, 2 for AOA2 with audio and HID support. Devices
+// that do not support accessory mode return an error.
+func Protocol(ctl controller) (int, error) {
+	var b [2]byte
+	n, err := ctl.Control(rTypeIn, reqGetProtocol, 0, 0, b[:])
+	if err != nil {
+		return 0, fmt.Errorf("AOA get protocol: %v", err)
+	}
+	if n != len(b) {
+		return 0, fmt.Errorf("AOA get protocol: got %d bytes, want %d", n, len(b))
+	}
+	v := int(b[0]) | int(b[1])<<8
+	if v == 0 {
+		return 0, fmt.Errorf("device does not support accessory mode")
+	}
+	return v, nil
+}
+
+func sendString(ctl controller, idx uint16, s string) error {
+	if s == "" {
+		return nil
+	}
+	data := append([]byte(s), 0)
+	if _, err := ctl.Control(rTypeOut, reqSendString, 0, idx, data); err != nil {
+		return fmt.Errorf("AOA send string %d: %v", idx, err)
+	}
+	return nil
+}
+
+// start sends the identifying strings, the audio mode and asks the device
+// to switch to accessory mode. The device disconnects and reappears with
+// the accessory product ID.
+func start(ctl controller, cfg Config) error {
+	v, err := Protocol(ctl)
+	if err != nil {
+		return err
+	}
+	for _, s := range []struct {
+		idx uint16
+		val string
+	}{
+		{stringManufacturer, cfg.Manufacturer},
+		{stringModel, cfg.Model},
+		{stringDescription, cfg.Description},
+		{stringVersion, cfg.Version},
+		{stringURI, cfg.URI},
+		{stringSerial, cfg.Serial},
+	} {
+		if err := sendString(ctl, s.idx, s.val); err != nil {
+			return err
+		}
+	}
+	if cfg.Audio {
+		if v < 2 {
+			return fmt.Errorf("audio requires AOA protocol version 2, device supports version %d", v)
+		}
+		if _, err := ctl.Control(rTypeOut, reqSetAudioMode, 1, 0, nil); err != nil {
+			return fmt.Errorf("AOA set audio mode: %v", err)
+		}
+	}
+	if _, err := ctl.Control(rTypeOut, reqStart, 0, 0, nil); err != nil {
+		return fmt.Errorf("AOA start: %v", err)
+	}
+	return nil
+}
+
+// registerHID registers a HID device and sends its report descriptor
+// in chunks of at most maxChunk bytes.
+func registerHID(ctl controller, h HIDDevice, maxChunk int) error {
+	desc := h.ReportDescriptor
+	if len(desc) == 0 || len(desc) > 0xffff {
+		return fmt.Errorf("HID device %d: invalid report descriptor length %d", h.ID, len(desc))
+	}
+	if _, err := ctl.Control(rTypeOut, reqRegisterHID, h.ID, uint16(len(desc)), nil); err != nil {
+		return fmt.Errorf("AOA register HID %d: %v", h.ID, err)
+	}
+	for off := 0; off < len(desc); off += maxChunk {
+		end := off + maxChunk
+		if end > len(desc) {
+			end = len(desc)
+		}
+		if _, err := ctl.Control(rTypeOut, reqSetHIDReportDesc, h.ID, uint16(off), desc[off:end]); err != nil {
+			return fmt.Errorf("AOA set HID %d report descriptor: %v", h.ID, err)
+		}
+	}
+	return nil
+}
+
+func unregisterHID(ctl controller, id uint16) error {
+	if _, err := ctl.Control(rTypeOut, reqUnregisterHID, id, 0, nil); err != nil {
+		return fmt.Errorf("AOA unregister HID %d: %v", id, err)
+	}
+	return nil
+}
+
+func sendHIDEvent(ctl controller, id uint16, event []byte) error {
+	if _, err := ctl.Control(rTypeOut, reqSendHIDEvent, id, 0, event); err != nil {
+		return fmt.Errorf("AOA send HID %d event: %v", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aoa
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+// newFakeDevice returns the control endpoint of a device that answers get
+// protocol with version, 0 if it doesn't support accessory mode.
+func newFakeDevice(version uint16) *fakeusb.Control {
+	return &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+		switch {
+		case r.Request == reqGetProtocol && r.RType == rTypeIn:
+			data[0], data[1] = byte(version), byte(version>>8)
+			return 2, nil
+		case r.RType != rTypeOut:
+			return 0, errors.New("bad request type")
+		}
+		return len(data), nil
+	}}
+}
+
+func TestProtocol(t *testing.T) {
+	for _, v := range []uint16{1, 2} {
+		if got, err := Protocol(newFakeDevice(v)); err != nil || got != int(v) {
+			t.Errorf("Protocol(): got %d, %v, want %d, nil", got, err, v)
+		}
+	}
+	if _, err := Protocol(newFakeDevice(0)); err == nil {
+		t.Error("Protocol() of a device without accessory support: got nil error, want non-nil")
+	}
+}
+
+func TestStart(t *testing.T) {
+	f := newFakeDevice(2)
+	cfg := Config{Manufacturer: "Example", Model: "Widget", Version: "1.0", Serial: "42", Audio: true}
+	if err := start(f, cfg); err != nil {
+		t.Fatalf("start(): %v", err)
+	}
+	want := []fakeusb.Request{
+		{RType: rTypeIn, Request: reqGetProtocol},
+		{RType: rTypeOut, Request: reqSendString, Idx: stringManufacturer, Data: "Example\x00"},
+		{RType: rTypeOut, Request: reqSendString, Idx: stringModel, Data: "Widget\x00"},
+		{RType: rTypeOut, Request: reqSendString, Idx: stringVersion, Data: "1.0\x00"},
+		{RType: rTypeOut, Request: reqSendString, Idx: stringSerial, Data: "42\x00"},
+		{RType: rTypeOut, Request: reqSetAudioMode, Val: 1},
+		{RType: rTypeOut, Request: reqStart},
+	}
+	if !reflect.DeepEqual(f.Requests(), want) {
+		t.Errorf("start(): got requests\n%v\nwant\n%v", f.Requests(), want)
+	}
+	if err := start(newFakeDevice(1), cfg); err == nil {
+		t.Error("start() with audio on an AOA1 device: got nil error, want non-nil")
+	}
+	if err := start(newFakeDevice(0), Config{}); err == nil {
+		t.Error("start() on a device without accessory support: got nil error, want non-nil")
+	}
+}
+
+func TestHID(t *testing.T) {
+	f := newFakeDevice(2)
+	c := &Conn{ctl: f}
+	desc := make([]byte, 150)
+	for i := range desc {
+		desc[i] = byte(i)
+	}
+	if err := c.registerHID([]HIDDevice{{ID: 7, ReportDescriptor: desc}}, 64); err != nil {
+		t.Fatalf("registerHID(): %v", err)
+	}
+	if err := c.SendHIDEvent(7, []byte{1, 2}); err != nil {
+		t.Errorf("SendHIDEvent(7): %v", err)
+	}
+	if err := c.SendHIDEvent(8, []byte{1, 2}); err == nil {
+		t.Error("SendHIDEvent() of an unregistered device: got nil error, want non-nil")
+	}
+	want := []fakeusb.Request{
+		{RType: rTypeOut, Request: reqRegisterHID, Val: 7, Idx: 150},
+		{RType: rTypeOut, Request: reqSetHIDReportDesc, Val: 7, Data: string(desc[:64])},
+		{RType: rTypeOut, Request: reqSetHIDReportDesc, Val: 7, Idx: 64, Data: string(desc[64:128])},
+		{RType: rTypeOut, Request: reqSetHIDReportDesc, Val: 7, Idx: 128, Data: string(desc[128:])},
+		{RType: rTypeOut, Request: reqSendHIDEvent, Val: 7, Data: "\x01\x02"},
+	}
+	if !reflect.DeepEqual(f.Requests(), want) {
+		t.Errorf("HID requests: got\n%v\nwant\n%v", f.Requests(), want)
+	}
+	if err := c.registerHID([]HIDDevice{{ID: 9}}, 64); err == nil {
+		t.Error("registerHID() without a report descriptor: got nil error, want non-nil")
+	}
+}
@@ -0,0 +1,579 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ccid implements a driver for USB smart card readers of the
+// Chip/Smart Card Interface Devices class, without pcscd.
+//
+// Open claims the smart card interface of a reader. Cards are powered on
+// with PowerOn and APDUs are exchanged with Transmit:
+//
+//	c, err := ccid.Open(dev)
+//	...
+//	defer c.Close()
+//	atr, err := c.PowerOn(ctx, 0, ccid.PowerAuto)
+//	...
+//	// SELECT the PIV application.
+//	resp, err := c.Transmit(ctx, 0, []byte{0x00, 0xa4, 0x04, 0x00, 0x05, 0xa0, 0x00, 0x00, 0x03, 0x08})
+//	...
+//	// resp ends with the status word, 0x90 0x00 on success.
+//
+// Commands are matched with their responses by sequence number. The
+// reader may ask for more time to complete a command, in which case the
+// host keeps waiting until the context is done; the command is then
+// aborted.
+//
+// Transmit supports readers exchanging short or extended APDUs, and TPDU
+// readers with cards using the T=0 protocol.
+package ccid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+const (
+	reqAbort = 0x01
+	rTypeOut = gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface
+)
+
+const (
+	// minMessageLength is the smallest MaxMessageLength allowed by the
+	// specification, used if the reader reports a smaller one.
+	minMessageLength = 271
+	// eventBufferSize is the size of the buffer used to read the
+	// interrupt endpoint.
+	eventBufferSize = 128
+	// abortTimeout bounds the abort of a command after its context is
+	// done.
+	abortTimeout = time.Second
+)
+
+// PowerSelect is the voltage applied to the card by PowerOn.
+type PowerSelect uint8
+
+// Voltages of PowerOn.
+const (
+	// PowerAuto lets the reader select the voltage.
+	PowerAuto PowerSelect = 0
+	Power5V   PowerSelect = 1
+	Power3V   PowerSelect = 2
+	Power18V  PowerSelect = 3
+)
+
+// controller carries the class requests of the smart card interface,
+// e.g. ABORT. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the response messages of the bulk IN endpoint and the
+// slot change notifications of the interrupt endpoint. *gousb.InEndpoint
+// implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the command messages. *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Conn is a smart card reader.
+type Conn struct {
+	// Desc is the CCID class descriptor of the reader.
+	Desc *Descriptor
+
+	ctl  controller
+	intf uint16
+	r    reader
+	w    writer
+
+	// mu serializes the commands.
+	mu  sync.Mutex
+	seq uint8
+	buf []byte
+	// protocols caches the protocol of the powered cards, by slot.
+	protocols map[int]int
+
+	evMu sync.Mutex
+	ev   reader
+
+	release func()
+}
+
+func newConn(ctl controller, intf int, desc *Descriptor, r reader, w writer, ev reader, maxPacket int) *Conn {
+	size := desc.MaxMessageLength
+	if size < minMessageLength {
+		size = minMessageLength
+	}
+	if maxPacket > 0 {
+		size = (size + maxPacket - 1) / maxPacket * maxPacket
+	}
+	return &Conn{
+		Desc:      desc,
+		ctl:       ctl,
+		intf:      uint16(intf),
+		r:         r,
+		w:         w,
+		ev:        ev,
+		buf:       make([]byte, size),
+		protocols: map[int]int{},
+	}
+}
+
+// Match returns true if the device has a smart card interface in any of
+// its configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if _, _, _, _, _, err := findInterface(cfg); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findInterface returns the smart card interface setting of the
+// configuration with its class descriptor, its bulk endpoints and its
+// interrupt endpoint, if any.
+func findInterface(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, *Descriptor, *gousb.EndpointDesc, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if alt.Class != gousb.ClassSmartCard {
+				continue
+			}
+			d, err := ParseDescriptor(alt.Extra)
+			if err != nil {
+				return nil, nil, nil, nil, nil, fmt.Errorf("smart card interface %d: %v", alt.Number, err)
+			}
+			var in, out, ev *gousb.EndpointDesc
+			for _, ep := range alt.Endpoints {
+				ep := ep
+				switch {
+				case ep.TransferType == gousb.TransferTypeBulk && ep.Direction == gousb.EndpointDirectionIn:
+					in = &ep
+				case ep.TransferType == gousb.TransferTypeBulk:
+					out = &ep
+				case ep.TransferType == gousb.TransferTypeInterrupt && ep.Direction == gousb.EndpointDirectionIn:
+					ev = &ep
+				}
+			}
+			if in == nil || out == nil {
+				return nil, nil, nil, nil, nil, fmt.Errorf("smart card interface %d alt %d has no bulk IN and OUT endpoints", alt.Number, alt.Alternate)
+			}
+			alt := alt
+			return &alt, d, in, out, ev, nil
+		}
+	}
+	return nil, nil, nil, nil, nil, fmt.Errorf("%s has no smart card interface", desc)
+}
+
+// Open claims the smart card interface in the active configuration of the
+// device. The Conn should be Close()d after use.
+func Open(dev *gousb.Device) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, ccid, inDesc, outDesc, evDesc, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	var ev reader
+	if evDesc != nil {
+		if ev, err = intf.InEndpoint(evDesc.Number); err != nil {
+			cfg.Close()
+			return nil, err
+		}
+	}
+	c := newConn(dev, alt.Number, ccid, in, out, ev, inDesc.MaxPacketSize)
+	c.release = func() { cfg.Close() }
+	return c, nil
+}
+
+// Close releases the interface.
+func (c *Conn) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
+
+// command sends a command message and waits for its response of type
+// want. Responses to earlier, aborted commands are skipped, as are the
+// time extension requests of the reader. A failed command is returned as
+// a *SlotError with its response. c.mu must be held.
+func (c *Conn) command(ctx context.Context, typ, want uint8, slot int, params [3]byte, data []byte) (*response, error) {
+	if slot < 0 || slot > c.Desc.MaxSlotIndex {
+		return nil, fmt.Errorf("invalid slot %d, the reader has %d slots", slot, c.Desc.MaxSlotIndex+1)
+	}
+	seq := c.seq
+	c.seq++
+	if _, err := c.w.WriteContext(ctx, marshalCommand(typ, slot, seq, params, data)); err != nil {
+		return nil, err
+	}
+	for {
+		r, err := c.readResponse(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.abort(slot, seq)
+			}
+			return nil, err
+		}
+		if r.slot != slot || r.seq != seq {
+			continue
+		}
+		switch r.cmdStatus() {
+		case cmdTimeExtended:
+			continue
+		case cmdFailed:
+			return r, &SlotError{Slot: slot, Status: r.iccStatus(), Code: r.err}
+		}
+		if r.typ != want {
+			return nil, fmt.Errorf("got response type 0x%02x to command 0x%02x, want 0x%02x", r.typ, typ, want)
+		}
+		return r, nil
+	}
+}
+
+// readResponse reads a message from the bulk IN endpoint.
+func (c *Conn) readResponse(ctx context.Context) (*response, error) {
+	var n int
+	for n == 0 {
+		var err error
+		if n, err = c.r.ReadContext(ctx, c.buf); err != nil {
+			return nil, err
+		}
+	}
+	r, length, err := parseHeader(c.buf[:n])
+	if err != nil {
+		return nil, err
+	}
+	if headerSize+length > len(c.buf) {
+		return nil, fmt.Errorf("message of %d bytes longer than the maximum %d", headerSize+length, len(c.buf))
+	}
+	for n < headerSize+length {
+		m, err := c.r.ReadContext(ctx, c.buf[n:])
+		if err != nil {
+			return nil, err
+		}
+		n += m
+	}
+	r.data = append([]byte(nil), c.buf[headerSize:headerSize+length]...)
+	return r, nil
+}
+
+// abort aborts a command with the ABORT class request followed by an
+// Abort message, as required by the specification, and waits for the
+// reader to acknowledge it.
+func (c *Conn) abort(slot int, seq uint8) {
+	c.ctl.Control(rTypeOut, reqAbort, uint16(seq)<<8|uint16(slot), c.intf, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+	defer cancel()
+	if _, err := c.w.WriteContext(ctx, marshalCommand(msgAbort, slot, seq, [3]byte{}, nil)); err != nil {
+		return
+	}
+	for {
+		r, err := c.readResponse(ctx)
+		if err != nil || r.typ == msgSlotStatus && r.slot == slot && r.seq == seq {
+			return
+		}
+	}
+}
+
+// PowerOn activates the card in the slot and returns its Answer To Reset.
+func (c *Conn) PowerOn(ctx context.Context, slot int, power PowerSelect) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.protocols, slot)
+	r, err := c.command(ctx, msgIccPowerOn, msgDataBlock, slot, [3]byte{uint8(power)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r.data, nil
+}
+
+// PowerOff deactivates the card in the slot.
+func (c *Conn) PowerOff(ctx context.Context, slot int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.protocols, slot)
+	_, err := c.command(ctx, msgIccPowerOff, msgSlotStatus, slot, [3]byte{}, nil)
+	return err
+}
+
+// GetSlotStatus returns the state of the card in the slot.
+func (c *Conn) GetSlotStatus(ctx context.Context, slot int) (ICCStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, err := c.command(ctx, msgGetSlotStatus, msgSlotStatus, slot, [3]byte{}, nil)
+	var serr *SlotError
+	if errors.As(err, &serr) && serr.Status == ICCAbsent {
+		return ICCAbsent, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return r.iccStatus(), nil
+}
+
+// Parameters are the transmission parameters of the card in a slot.
+type Parameters struct {
+	// Protocol is 0 for T=0 and 1 for T=1.
+	Protocol int
+	// FiDi holds the Fi and Di indexes of the clock rate conversion and
+	// baud rate adjustment factors.
+	FiDi uint8
+	// TCCKS selects the convention and, for T=1, the checksum.
+	TCCKS uint8
+	// GuardTime is the extra guard time.
+	GuardTime uint8
+	// WaitingInteger is WI for T=0, and BWI and CWI in the high and low
+	// nibbles for T=1.
+	WaitingInteger uint8
+	// ClockStop is the clock stop support of the card.
+	ClockStop uint8
+	// IFSC and NAD are the information field size and node address of
+	// the card for T=1.
+	IFSC, NAD uint8
+}
+
+func parseParameters(protocol uint8, b []byte) (*Parameters, error) {
+	want := map[uint8]int{0: 5, 1: 7}[protocol]
+	if want == 0 {
+		return nil, fmt.Errorf("unknown protocol %d", protocol)
+	}
+	if len(b) < want {
+		return nil, fmt.Errorf("T=%d parameters too short: %d bytes, want %d", protocol, len(b), want)
+	}
+	p := &Parameters{
+		Protocol:       int(protocol),
+		FiDi:           b[0],
+		TCCKS:          b[1],
+		GuardTime:      b[2],
+		WaitingInteger: b[3],
+		ClockStop:      b[4],
+	}
+	if protocol == 1 {
+		p.IFSC, p.NAD = b[5], b[6]
+	}
+	return p, nil
+}
+
+// GetParameters returns the transmission parameters of the card in the
+// slot.
+func (c *Conn) GetParameters(ctx context.Context, slot int) (*Parameters, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.getParameters(ctx, slot)
+}
+
+func (c *Conn) getParameters(ctx context.Context, slot int) (*Parameters, error) {
+	r, err := c.command(ctx, msgGetParameters, msgParameters, slot, [3]byte{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parseParameters(r.param, r.data)
+	if err != nil {
+		return nil, err
+	}
+	c.protocols[slot] = p.Protocol
+	return p, nil
+}
+
+// XfrBlock sends a block of data to the card in the slot and returns the
+// block of the card. The block is an APDU, a TPDU or characters depending
+// on the exchange level of the reader. Blocks longer than the reader
+// messages are chained for extended APDU readers.
+func (c *Conn) XfrBlock(ctx context.Context, slot int, block []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.xfrBlock(ctx, slot, block)
+}
+
+func levelParams(level uint16) [3]byte {
+	return [3]byte{0, uint8(level), uint8(level >> 8)}
+}
+
+func (c *Conn) xfrBlock(ctx context.Context, slot int, block []byte) ([]byte, error) {
+	max := c.Desc.MaxMessageLength - headerSize
+	if max < minMessageLength-headerSize {
+		max = minMessageLength - headerSize
+	}
+	if len(block) > max && c.Desc.ExchangeLevel() != LevelExtendedAPDU {
+		return nil, fmt.Errorf("block of %d bytes longer than the maximum %d", len(block), max)
+	}
+	var r *response
+	for first := true; first || len(block) > 0; first = false {
+		chunk, level := block, uint16(chainNone)
+		switch {
+		case len(block) > max && first:
+			chunk, level = block[:max], chainBegin
+		case len(block) > max:
+			chunk, level = block[:max], chainContinue
+		case !first:
+			level = chainEnd
+		}
+		block = block[len(chunk):]
+		var err error
+		if r, err = c.command(ctx, msgXfrBlock, msgDataBlock, slot, levelParams(level), chunk); err != nil {
+			return nil, err
+		}
+	}
+	out := r.data
+	for r.param == chainBegin || r.param == chainContinue {
+		var err error
+		if r, err = c.command(ctx, msgXfrBlock, msgDataBlock, slot, levelParams(chainMore), nil); err != nil {
+			return nil, err
+		}
+		out = append(out, r.data...)
+	}
+	return out, nil
+}
+
+// Transmit sends an APDU to the card in the slot and returns the response
+// APDU, ending with the status word. For TPDU readers, the APDU is mapped
+// to T=0 TPDUs, and the response data announced by the card with the 61xx
+// status words is retrieved with GET RESPONSE.
+func (c *Conn) Transmit(ctx context.Context, slot int, apdu []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch l := c.Desc.ExchangeLevel(); l {
+	case LevelShortAPDU, LevelExtendedAPDU:
+		return c.xfrBlock(ctx, slot, apdu)
+	case LevelTPDU:
+		protocol, ok := c.protocols[slot]
+		if !ok {
+			p, err := c.getParameters(ctx, slot)
+			if err != nil {
+				return nil, err
+			}
+			protocol = p.Protocol
+		}
+		if protocol != 0 {
+			return nil, fmt.Errorf("T=%d is not supported with %s readers", protocol, l)
+		}
+		return c.transmitT0(ctx, slot, apdu)
+	default:
+		return nil, fmt.Errorf("%s readers are not supported", l)
+	}
+}
+
+// transmitT0 maps a short APDU to T=0 TPDUs, as in ISO 7816-3.
+func (c *Conn) transmitT0(ctx context.Context, slot int, apdu []byte) ([]byte, error) {
+	cmd := apdu
+	switch {
+	case len(apdu) == 4:
+		// No data: P3 is 0.
+		cmd = append(apdu[:4:4], 0)
+	case len(apdu) == 5:
+	case len(apdu) > 5 && apdu[4] != 0 && len(apdu) == 5+int(apdu[4]):
+	case len(apdu) > 5 && apdu[4] != 0 && len(apdu) == 6+int(apdu[4]):
+		// The response data is announced by the card with 61xx.
+		cmd = apdu[:len(apdu)-1]
+	default:
+		return nil, fmt.Errorf("APDU of %d bytes is not a short APDU", len(apdu))
+	}
+	resp, err := c.xfrBlock(ctx, slot, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) >= 2 && resp[len(resp)-2] == 0x6c && len(cmd) == 5 {
+		// Wrong Le: the card gives the right one.
+		cmd = append(cmd[:4:4], resp[len(resp)-1])
+		if resp, err = c.xfrBlock(ctx, slot, cmd); err != nil {
+			return nil, err
+		}
+	}
+	var out []byte
+	for len(resp) >= 2 && resp[len(resp)-2] == 0x61 {
+		out = append(out, resp[:len(resp)-2]...)
+		cla := c.Desc.ClassGetResponse
+		if cla == 0xff {
+			cla = apdu[0]
+		}
+		if resp, err = c.xfrBlock(ctx, slot, []byte{cla, 0xc0, 0x00, 0x00, resp[len(resp)-1]}); err != nil {
+			return nil, err
+		}
+	}
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("response of %d bytes has no status word", len(resp))
+	}
+	return append(out, resp...), nil
+}
+
+// SlotChange is the state of a slot reported by the reader.
+type SlotChange struct {
+	Slot int
+	// Present is set if a card is in the slot.
+	Present bool
+	// Changed is set if the card was inserted or removed since the last
+	// report.
+	Changed bool
+}
+
+func parseSlotChange(b []byte, maxSlot int) []SlotChange {
+	var s []SlotChange
+	for slot := 0; slot <= maxSlot && slot/4 < len(b); slot++ {
+		bits := b[slot/4] >> (2 * uint(slot%4))
+		s = append(s, SlotChange{Slot: slot, Present: bits&1 != 0, Changed: bits&2 != 0})
+	}
+	return s
+}
+
+// ReadSlotChange waits for the reader to report a card insertion or
+// removal and returns the state of all the slots. Hardware errors reported
+// by the reader are returned as a *HardwareError. ReadSlotChange can be
+// called concurrently with the other methods.
+func (c *Conn) ReadSlotChange(ctx context.Context) ([]SlotChange, error) {
+	c.evMu.Lock()
+	defer c.evMu.Unlock()
+	if c.ev == nil {
+		return nil, fmt.Errorf("reader has no interrupt endpoint")
+	}
+	buf := make([]byte, eventBufferSize)
+	for {
+		n, err := c.ev.ReadContext(ctx, buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		switch buf[0] {
+		case msgNotifySlotChange:
+			return parseSlotChange(buf[1:n], c.Desc.MaxSlotIndex), nil
+		case msgHardwareError:
+			if n < hardwareErrorSize {
+				return nil, fmt.Errorf("hardware error message too short: %d bytes", n)
+			}
+			return nil, &HardwareError{Slot: int(buf[1]), Seq: buf[2], Code: buf[3]}
+		}
+	}
+}
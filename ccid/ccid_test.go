@@ -0,0 +1,387 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ccid
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+var atr = []byte{0x3b, 0x8c, 0x80, 0x01}
+
+func testDescriptor(features uint32, maxMessage, maxSlot int) *Descriptor {
+	return &Descriptor{
+		MaxSlotIndex:     maxSlot,
+		Protocols:        ProtocolT0 | ProtocolT1,
+		Features:         features,
+		MaxMessageLength: maxMessage,
+		ClassGetResponse: 0xff,
+	}
+}
+
+func newFakeConn(f *fakeReader, desc *Descriptor, ev reader) *Conn {
+	return newConn(f, 0, desc, f, f, ev, 64)
+}
+
+func TestPowerOnTransmit(t *testing.T) {
+	f := newFakeReader(func(cmd fakeCommand) [][]byte {
+		switch cmd.typ {
+		case msgIccPowerOn:
+			if cmd.params[0] != uint8(Power3V) {
+				return [][]byte{cmd.reply(msgDataBlock, 0x41, 7, 0, nil)}
+			}
+			return [][]byte{cmd.reply(msgDataBlock, 0, 0, 0, atr)}
+		case msgXfrBlock:
+			return [][]byte{
+				// The reader needs more time twice.
+				cmd.reply(msgDataBlock, 0x80, 1, 0, nil),
+				cmd.reply(msgDataBlock, 0x80, 1, 0, nil),
+				cmd.reply(msgDataBlock, 0, 0, 0, append(bytes.Repeat([]byte{0xaa}, 300), 0x90, 0x00)),
+			}
+		case msgIccPowerOff, msgGetSlotStatus:
+			return [][]byte{cmd.reply(msgSlotStatus, 0, 0, 0, nil)}
+		}
+		return nil
+	})
+	// The long response spans many transfers.
+	f.chunk = 64
+	c := newFakeConn(f, testDescriptor(FeatureLevelShortAPDU, 512, 0), nil)
+	ctx := context.Background()
+
+	got, err := c.PowerOn(ctx, 0, Power3V)
+	if err != nil {
+		t.Fatalf("PowerOn(): %v", err)
+	}
+	if !bytes.Equal(got, atr) {
+		t.Errorf("PowerOn(): got % x, want % x", got, atr)
+	}
+	resp, err := c.Transmit(ctx, 0, []byte{0x00, 0xb0, 0x00, 0x00, 0x00})
+	if err != nil {
+		t.Fatalf("Transmit(): %v", err)
+	}
+	if len(resp) != 302 || !bytes.Equal(resp[300:], []byte{0x90, 0x00}) {
+		t.Errorf("Transmit(): got %d bytes ending with % x, want 302 bytes ending with 90 00", len(resp), resp[len(resp)-2:])
+	}
+	if status, err := c.GetSlotStatus(ctx, 0); err != nil || status != ICCActive {
+		t.Errorf("GetSlotStatus(): got %s, %v, want %s", status, err, ICCActive)
+	}
+	if err := c.PowerOff(ctx, 0); err != nil {
+		t.Errorf("PowerOff(): %v", err)
+	}
+	want := [][2]uint8{{msgIccPowerOn, 0}, {msgXfrBlock, 1}, {msgGetSlotStatus, 2}, {msgIccPowerOff, 3}}
+	if got := f.commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands: got %x, want %x", got, want)
+	}
+
+	if _, err := c.PowerOn(ctx, 1, PowerAuto); err == nil {
+		t.Error("PowerOn() of slot 1 of a single slot reader: got nil error, want an error")
+	}
+	_, err = c.PowerOn(ctx, 0, Power5V)
+	var serr *SlotError
+	if !errors.As(err, &serr) || serr.Code != 7 || serr.Status != ICCInactive {
+		t.Errorf("PowerOn() with a bad voltage: got error %v, want a SlotError for offset 7", err)
+	}
+}
+
+func TestStaleResponse(t *testing.T) {
+	f := newFakeReader(func(cmd fakeCommand) [][]byte {
+		stale := cmd
+		stale.seq--
+		return [][]byte{
+			// A late response to an aborted command, and an empty
+			// packet.
+			stale.reply(msgDataBlock, 0, 0, 0, []byte{0x6a, 0x82}),
+			{},
+			cmd.reply(msgDataBlock, 0, 0, 0, []byte{0x90, 0x00}),
+		}
+	})
+	c := newFakeConn(f, testDescriptor(FeatureLevelShortAPDU, 271, 0), nil)
+	c.seq = 10
+	resp, err := c.Transmit(context.Background(), 0, []byte{0x00, 0xa4, 0x04, 0x00})
+	if err != nil {
+		t.Fatalf("Transmit(): %v", err)
+	}
+	if want := []byte{0x90, 0x00}; !bytes.Equal(resp, want) {
+		t.Errorf("Transmit(): got % x, want % x", resp, want)
+	}
+}
+
+func TestGetSlotStatusAbsent(t *testing.T) {
+	f := newFakeReader(func(cmd fakeCommand) [][]byte {
+		return [][]byte{cmd.reply(msgSlotStatus, 0x42, uint8(ErrICCMute), 0, nil)}
+	})
+	c := newFakeConn(f, testDescriptor(FeatureLevelShortAPDU, 271, 0), nil)
+	ctx := context.Background()
+	if status, err := c.GetSlotStatus(ctx, 0); err != nil || status != ICCAbsent {
+		t.Errorf("GetSlotStatus(): got %s, %v, want %s", status, err, ICCAbsent)
+	}
+}
+
+func TestChaining(t *testing.T) {
+	apdu := make([]byte, 600)
+	for i := range apdu {
+		apdu[i] = byte(i)
+	}
+	resp := bytes.Repeat([]byte{0x55}, 700)
+	var levels []uint16
+	var received []byte
+	sent := 0
+	f := newFakeReader(func(cmd fakeCommand) [][]byte {
+		levels = append(levels, cmd.level())
+		received = append(received, cmd.data...)
+		switch cmd.level() {
+		case chainBegin, chainContinue:
+			return [][]byte{cmd.reply(msgDataBlock, 0, 0, chainMore, nil)}
+		}
+		// Send the response in parts of up to 261 bytes.
+		n, param := len(resp)-sent, uint8(chainEnd)
+		if n > 261 {
+			n, param = 261, chainContinue
+			if sent == 0 {
+				param = chainBegin
+			}
+		} else if sent == 0 {
+			param = chainNone
+		}
+		sent += n
+		return [][]byte{cmd.reply(msgDataBlock, 0, 0, param, resp[sent-n:sent])}
+	})
+	c := newFakeConn(f, testDescriptor(FeatureLevelExtendedAPDU, 271, 0), nil)
+	got, err := c.Transmit(context.Background(), 0, apdu)
+	if err != nil {
+		t.Fatalf("Transmit(): %v", err)
+	}
+	if !bytes.Equal(got, resp) {
+		t.Errorf("Transmit(): got %d bytes, want %d", len(got), len(resp))
+	}
+	if !bytes.Equal(received, apdu) {
+		t.Errorf("Transmit() sent %d bytes, want %d", len(received), len(apdu))
+	}
+	want := []uint16{chainBegin, chainContinue, chainEnd, chainMore, chainMore}
+	if !reflect.DeepEqual(levels, want) {
+		t.Errorf("Transmit() level parameters: got %x, want %x", levels, want)
+	}
+
+	c = newFakeConn(f, testDescriptor(FeatureLevelShortAPDU, 271, 0), nil)
+	if _, err := c.Transmit(context.Background(), 0, apdu); err == nil {
+		t.Error("Transmit() of a long APDU with a short APDU reader: got nil error, want an error")
+	}
+}
+
+func TestTransmitT0(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		apdu []byte
+		// card answers the TPDUs.
+		card func(tpdu []byte) []byte
+		want []byte
+		// tpdus are the TPDUs sent to the card.
+		tpdus [][]byte
+	}{
+		{
+			desc: "case 1",
+			apdu: []byte{0x00, 0x44, 0x00, 0x00},
+			card: func([]byte) []byte { return []byte{0x90, 0x00} },
+			want: []byte{0x90, 0x00},
+			tpdus: [][]byte{
+				{0x00, 0x44, 0x00, 0x00, 0x00},
+			},
+		},
+		{
+			desc: "case 2 with wrong length",
+			apdu: []byte{0x00, 0xb0, 0x00, 0x00, 0x00},
+			card: func(tpdu []byte) []byte {
+				if tpdu[4] != 3 {
+					return []byte{0x6c, 0x03}
+				}
+				return []byte{1, 2, 3, 0x90, 0x00}
+			},
+			want: []byte{1, 2, 3, 0x90, 0x00},
+			tpdus: [][]byte{
+				{0x00, 0xb0, 0x00, 0x00, 0x00},
+				{0x00, 0xb0, 0x00, 0x00, 0x03},
+			},
+		},
+		{
+			desc: "case 4 with GET RESPONSE",
+			apdu: []byte{0x80, 0xa4, 0x04, 0x00, 0x02, 0x3f, 0x00, 0x00},
+			card: func(tpdu []byte) []byte {
+				switch {
+				case tpdu[1] == 0xa4:
+					return []byte{0x61, 0x02}
+				case tpdu[4] == 2:
+					return []byte{1, 2, 0x61, 0x01}
+				}
+				return []byte{3, 0x90, 0x00}
+			},
+			want: []byte{1, 2, 3, 0x90, 0x00},
+			tpdus: [][]byte{
+				{0x80, 0xa4, 0x04, 0x00, 0x02, 0x3f, 0x00},
+				{0x80, 0xc0, 0x00, 0x00, 0x02},
+				{0x80, 0xc0, 0x00, 0x00, 0x01},
+			},
+		},
+	} {
+		var tpdus [][]byte
+		f := newFakeReader(func(cmd fakeCommand) [][]byte {
+			if cmd.typ == msgGetParameters {
+				return [][]byte{cmd.reply(msgParameters, 0, 0, 0, []byte{0x11, 0x00, 0x00, 0x0a, 0x00})}
+			}
+			tpdus = append(tpdus, cmd.data)
+			return [][]byte{cmd.reply(msgDataBlock, 0, 0, 0, tc.card(cmd.data))}
+		})
+		c := newFakeConn(f, testDescriptor(FeatureLevelTPDU, 271, 0), nil)
+		got, err := c.Transmit(context.Background(), 0, tc.apdu)
+		if err != nil {
+			t.Errorf("%s: Transmit(): %v", tc.desc, err)
+			continue
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("%s: Transmit(): got % x, want % x", tc.desc, got, tc.want)
+		}
+		if !reflect.DeepEqual(tpdus, tc.tpdus) {
+			t.Errorf("%s: Transmit() sent % x, want % x", tc.desc, tpdus, tc.tpdus)
+		}
+	}
+}
+
+func TestTransmitT1TPDU(t *testing.T) {
+	f := newFakeReader(func(cmd fakeCommand) [][]byte {
+		return [][]byte{cmd.reply(msgParameters, 0, 0, 1, []byte{0x11, 0x10, 0x00, 0x4d, 0x00, 0xfe, 0x00})}
+	})
+	c := newFakeConn(f, testDescriptor(FeatureLevelTPDU, 271, 0), nil)
+	ctx := context.Background()
+	p, err := c.GetParameters(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetParameters(): %v", err)
+	}
+	want := &Parameters{Protocol: 1, FiDi: 0x11, TCCKS: 0x10, WaitingInteger: 0x4d, IFSC: 0xfe}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("GetParameters(): got %+v, want %+v", p, want)
+	}
+	if _, err := c.Transmit(ctx, 0, []byte{0x00, 0xa4, 0x04, 0x00}); err == nil {
+		t.Error("Transmit() to a T=1 card with a TPDU reader: got nil error, want an error")
+	}
+}
+
+func TestAbort(t *testing.T) {
+	f := newFakeReader(func(cmd fakeCommand) [][]byte {
+		return nil
+	})
+	c := newFakeConn(f, testDescriptor(FeatureLevelShortAPDU, 271, 1), nil)
+	c.seq = 4
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Transmit(ctx, 1, []byte{0x00, 0xa4, 0x04, 0x00}); err == nil {
+		t.Fatal("Transmit() to a silent reader: got nil error, want a context error")
+	}
+	if want := []uint16{0x0401}; !reflect.DeepEqual(f.aborts, want) {
+		t.Errorf("ABORT requests: got %x, want %x", f.aborts, want)
+	}
+	want := [][2]uint8{{msgXfrBlock, 4}, {msgAbort, 4}}
+	if got := f.commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands: got %x, want %x", got, want)
+	}
+}
+
+func TestReadSlotChange(t *testing.T) {
+	ev := fakeusb.NewIn(
+		[]byte{0x50, 0x03},
+		[]byte{0x51, 0x00, 0x05, 0x01},
+		[]byte{0x50, 0x09},
+	)
+	c := newFakeConn(newFakeReader(nil), testDescriptor(FeatureLevelShortAPDU, 271, 1), ev)
+	ctx := context.Background()
+	got, err := c.ReadSlotChange(ctx)
+	if err != nil {
+		t.Fatalf("ReadSlotChange(): %v", err)
+	}
+	want := []SlotChange{{Slot: 0, Present: true, Changed: true}, {Slot: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadSlotChange(): got %+v, want %+v", got, want)
+	}
+	_, err = c.ReadSlotChange(ctx)
+	var herr *HardwareError
+	if !errors.As(err, &herr) || *herr != (HardwareError{Slot: 0, Seq: 5, Code: 1}) {
+		t.Errorf("ReadSlotChange(): got error %v, want a hardware error", err)
+	}
+	got, err = c.ReadSlotChange(ctx)
+	if err != nil {
+		t.Fatalf("ReadSlotChange(): %v", err)
+	}
+	want = []SlotChange{{Slot: 0, Present: true}, {Slot: 1, Changed: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadSlotChange(): got %+v, want %+v", got, want)
+	}
+
+	c = newFakeConn(newFakeReader(nil), testDescriptor(FeatureLevelShortAPDU, 271, 0), nil)
+	if _, err := c.ReadSlotChange(ctx); err == nil {
+		t.Error("ReadSlotChange() without an interrupt endpoint: got nil error, want an error")
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	eps := map[gousb.EndpointAddress]gousb.EndpointDesc{
+		0x81: {Number: 1, Direction: gousb.EndpointDirectionIn, TransferType: gousb.TransferTypeBulk},
+		0x02: {Number: 2, Direction: gousb.EndpointDirectionOut, TransferType: gousb.TransferTypeBulk},
+		0x83: {Number: 3, Direction: gousb.EndpointDirectionIn, TransferType: gousb.TransferTypeInterrupt},
+	}
+	for _, tc := range []struct {
+		desc string
+		alt  gousb.InterfaceSetting
+		ok   bool
+	}{
+		{
+			desc: "reader",
+			alt:  gousb.InterfaceSetting{Class: gousb.ClassSmartCard, Extra: readerDescriptor, Endpoints: eps},
+			ok:   true,
+		},
+		{
+			desc: "no CCID descriptor",
+			alt:  gousb.InterfaceSetting{Class: gousb.ClassSmartCard, Endpoints: eps},
+		},
+		{
+			desc: "no bulk endpoints",
+			alt:  gousb.InterfaceSetting{Class: gousb.ClassSmartCard, Extra: readerDescriptor},
+		},
+		{
+			desc: "mass storage",
+			alt:  gousb.InterfaceSetting{Class: gousb.ClassMassStorage, Endpoints: eps},
+		},
+	} {
+		cfg := gousb.ConfigDesc{Number: 1, Interfaces: []gousb.InterfaceDesc{{AltSettings: []gousb.InterfaceSetting{tc.alt}}}}
+		_, d, in, out, ev, err := findInterface(cfg)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: findInterface(): got error %v, want ok %v", tc.desc, err, tc.ok)
+			continue
+		}
+		if got := Match(&gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: cfg}}); got != tc.ok {
+			t.Errorf("%s: Match(): got %v, want %v", tc.desc, got, tc.ok)
+		}
+		if err != nil {
+			continue
+		}
+		if d.MaxMessageLength != 271 || in.Number != 1 || out.Number != 2 || ev == nil || ev.Number != 3 {
+			t.Errorf("%s: findInterface(): got descriptor %+v, endpoints %v, %v, %v", tc.desc, d, in, out, ev)
+		}
+	}
+}
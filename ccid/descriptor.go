@@ -0,0 +1,198 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ccid
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// dtCCID is the descriptor type of the CCID class descriptor.
+const dtCCID = 0x21
+
+// descriptorSize is the size of the CCID class descriptor.
+const descriptorSize = 54
+
+// Protocols supported by the reader, in Descriptor.Protocols.
+const (
+	ProtocolT0 = 1 << 0
+	ProtocolT1 = 1 << 1
+)
+
+// Voltages supported by the reader, in Descriptor.Voltages.
+const (
+	Voltage5V  = 1 << 0
+	Voltage3V  = 1 << 1
+	Voltage18V = 1 << 2
+)
+
+// Features of the reader, in Descriptor.Features.
+const (
+	FeatureAutoATRParams     = 0x00000002
+	FeatureAutoActivation    = 0x00000004
+	FeatureAutoVoltage       = 0x00000008
+	FeatureAutoClock         = 0x00000010
+	FeatureAutoBaudRate      = 0x00000020
+	FeatureAutoParamsNego    = 0x00000040
+	FeatureAutoPPS           = 0x00000080
+	FeatureClockStop         = 0x00000100
+	FeatureNADOther          = 0x00000200
+	FeatureAutoIFSD          = 0x00000400
+	FeatureLevelTPDU         = 0x00010000
+	FeatureLevelShortAPDU    = 0x00020000
+	FeatureLevelExtendedAPDU = 0x00040000
+	FeatureUSBWakeUp         = 0x00100000
+
+	featureLevelMask = 0x00070000
+)
+
+// ExchangeLevel is the level at which the reader exchanges data with the
+// card.
+type ExchangeLevel int
+
+// Exchange levels.
+const (
+	// LevelCharacter readers exchange single characters, the host
+	// implements the transmission protocol.
+	LevelCharacter ExchangeLevel = iota
+	// LevelTPDU readers exchange transmission protocol data units.
+	LevelTPDU
+	// LevelShortAPDU readers exchange APDUs with up to 256 bytes of
+	// data.
+	LevelShortAPDU
+	// LevelExtendedAPDU readers exchange APDUs with up to 65536 bytes of
+	// data, in chained messages if needed.
+	LevelExtendedAPDU
+)
+
+var levelNames = map[ExchangeLevel]string{
+	LevelCharacter:    "character",
+	LevelTPDU:         "TPDU",
+	LevelShortAPDU:    "short APDU",
+	LevelExtendedAPDU: "extended APDU",
+}
+
+// String returns a human-readable name of the exchange level.
+func (l ExchangeLevel) String() string {
+	if n, ok := levelNames[l]; ok {
+		return n
+	}
+	return fmt.Sprintf("exchange level %d", int(l))
+}
+
+// Descriptor is the CCID class descriptor of a smart card reader.
+type Descriptor struct {
+	// Version is the version of the CCID specification.
+	Version gousb.BCD
+	// MaxSlotIndex is the index of the highest slot, 0 for single slot
+	// readers.
+	MaxSlotIndex int
+	// Voltages is a bitmap of Voltage5V, Voltage3V and Voltage18V.
+	Voltages uint8
+	// Protocols is a bitmap of ProtocolT0 and ProtocolT1.
+	Protocols uint32
+	// DefaultClock and MaxClock are the clock frequencies in kHz.
+	DefaultClock, MaxClock uint32
+	// NumClocks is the number of clock frequencies supported, 0 if they
+	// are set automatically.
+	NumClocks int
+	// DataRate and MaxDataRate are in bits per second.
+	DataRate, MaxDataRate uint32
+	// NumDataRates is the number of data rates supported, 0 if they are
+	// set automatically.
+	NumDataRates int
+	// MaxIFSD is the maximum IFSD supported for T=1.
+	MaxIFSD uint32
+	// SynchProtocols is a bitmap of the synchronous protocols supported.
+	SynchProtocols uint32
+	// Mechanical is a bitmap of the mechanical characteristics.
+	Mechanical uint32
+	// Features is a bitmap of the Feature values.
+	Features uint32
+	// MaxMessageLength is the maximum size of a message, including the
+	// 10 byte header.
+	MaxMessageLength int
+	// ClassGetResponse and ClassEnvelope are the class bytes used by the
+	// reader in GET RESPONSE and ENVELOPE commands, 0xff to echo the
+	// class of the APDU.
+	ClassGetResponse, ClassEnvelope uint8
+	// LCDLayout is the number of lines and characters of the LCD, or 0.
+	LCDLayout uint16
+	// PINSupport is a bitmap of the PIN verification and modification
+	// support.
+	PINSupport uint8
+	// MaxBusySlots is the maximum number of slots which can be busy at
+	// the same time.
+	MaxBusySlots int
+}
+
+// ExchangeLevel returns the exchange level of the reader.
+func (d *Descriptor) ExchangeLevel() ExchangeLevel {
+	switch d.Features & featureLevelMask {
+	case FeatureLevelTPDU:
+		return LevelTPDU
+	case FeatureLevelShortAPDU:
+		return LevelShortAPDU
+	case FeatureLevelExtendedAPDU:
+		return LevelExtendedAPDU
+	}
+	return LevelCharacter
+}
+
+// ParseDescriptor parses the CCID class descriptor found in the extra
+// descriptors of a smart card interface, as available in
+// gousb.InterfaceSetting.Extra.
+func ParseDescriptor(extra []byte) (*Descriptor, error) {
+	for len(extra) > 0 {
+		l := int(extra[0])
+		if l < 2 || l > len(extra) {
+			return nil, fmt.Errorf("invalid descriptor length %d with %d bytes left", l, len(extra))
+		}
+		d := extra[:l]
+		extra = extra[l:]
+		if d[1] != dtCCID {
+			continue
+		}
+		if l < descriptorSize {
+			return nil, fmt.Errorf("CCID descriptor too short: %d bytes", l)
+		}
+		le := binary.LittleEndian
+		return &Descriptor{
+			Version:          gousb.BCD(le.Uint16(d[2:])),
+			MaxSlotIndex:     int(d[4]),
+			Voltages:         d[5],
+			Protocols:        le.Uint32(d[6:]),
+			DefaultClock:     le.Uint32(d[10:]),
+			MaxClock:         le.Uint32(d[14:]),
+			NumClocks:        int(d[18]),
+			DataRate:         le.Uint32(d[19:]),
+			MaxDataRate:      le.Uint32(d[23:]),
+			NumDataRates:     int(d[27]),
+			MaxIFSD:          le.Uint32(d[28:]),
+			SynchProtocols:   le.Uint32(d[32:]),
+			Mechanical:       le.Uint32(d[36:]),
+			Features:         le.Uint32(d[40:]),
+			MaxMessageLength: int(le.Uint32(d[44:])),
+			ClassGetResponse: d[48],
+			ClassEnvelope:    d[49],
+			LCDLayout:        le.Uint16(d[50:]),
+			PINSupport:       d[52],
+			MaxBusySlots:     int(d[53]),
+		}, nil
+	}
+	return nil, fmt.Errorf("no CCID descriptor")
+}
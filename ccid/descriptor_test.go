@@ -0,0 +1,94 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ccid
+
+import (
+	"reflect"
+	"testing"
+)
+
+// readerDescriptor is the CCID descriptor of a single slot reader
+// exchanging short APDUs, preceded by an unrelated descriptor.
+var readerDescriptor = []byte{
+	0x03, 0x24, 0x00,
+	0x36, 0x21, 0x10, 0x01, 0x00, 0x07, 0x03, 0x00, 0x00, 0x00,
+	0xc0, 0x12, 0x00, 0x00, 0xc0, 0x12, 0x00, 0x00, 0x00, 0x67,
+	0x32, 0x00, 0x00, 0xce, 0x99, 0x0c, 0x00, 0x00, 0xfe, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xba, 0x04, 0x02, 0x00, 0x0f, 0x01, 0x00, 0x00, 0xff, 0xff,
+	0x00, 0x00, 0x00, 0x01,
+}
+
+func TestParseDescriptor(t *testing.T) {
+	got, err := ParseDescriptor(readerDescriptor)
+	if err != nil {
+		t.Fatalf("ParseDescriptor(): %v", err)
+	}
+	want := &Descriptor{
+		Version:          0x0110,
+		MaxSlotIndex:     0,
+		Voltages:         Voltage5V | Voltage3V | Voltage18V,
+		Protocols:        ProtocolT0 | ProtocolT1,
+		DefaultClock:     4800,
+		MaxClock:         4800,
+		DataRate:         12903,
+		MaxDataRate:      825806,
+		NumDataRates:     0,
+		MaxIFSD:          254,
+		Features:         0x000204ba,
+		MaxMessageLength: 271,
+		ClassGetResponse: 0xff,
+		ClassEnvelope:    0xff,
+		MaxBusySlots:     1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDescriptor(): got %+v, want %+v", got, want)
+	}
+	if got, want := got.ExchangeLevel(), LevelShortAPDU; got != want {
+		t.Errorf("ExchangeLevel(): got %s, want %s", got, want)
+	}
+
+	for _, tc := range []struct {
+		desc  string
+		extra []byte
+	}{
+		{"empty", nil},
+		{"no CCID descriptor", []byte{0x03, 0x24, 0x00}},
+		{"truncated", readerDescriptor[:20]},
+		{"short CCID descriptor", []byte{0x05, 0x21, 0x10, 0x01, 0x00}},
+		{"invalid length", []byte{0x00, 0x21}},
+	} {
+		if _, err := ParseDescriptor(tc.extra); err == nil {
+			t.Errorf("%s: ParseDescriptor(): got nil error, want an error", tc.desc)
+		}
+	}
+}
+
+func TestExchangeLevel(t *testing.T) {
+	for _, tc := range []struct {
+		features uint32
+		want     ExchangeLevel
+	}{
+		{0x00000000, LevelCharacter},
+		{0x000100ba, LevelTPDU},
+		{0x000204ba, LevelShortAPDU},
+		{0x000404fe, LevelExtendedAPDU},
+	} {
+		d := &Descriptor{Features: tc.features}
+		if got := d.ExchangeLevel(); got != tc.want {
+			t.Errorf("ExchangeLevel() with features 0x%08x: got %s, want %s", tc.features, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ccid
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+)
+
+// fakeCommand is a command message received by the fake reader.
+type fakeCommand struct {
+	typ    uint8
+	slot   int
+	seq    uint8
+	params [3]byte
+	data   []byte
+}
+
+// level returns the level parameter of an XfrBlock command.
+func (c fakeCommand) level() uint16 {
+	return uint16(c.params[1]) | uint16(c.params[2])<<8
+}
+
+// reply returns a response message to the command.
+func (c fakeCommand) reply(typ, status, errCode, param uint8, data []byte) []byte {
+	b := make([]byte, headerSize+len(data))
+	b[0] = typ
+	binary.LittleEndian.PutUint32(b[1:], uint32(len(data)))
+	b[5] = uint8(c.slot)
+	b[6] = c.seq
+	b[7] = status
+	b[8] = errCode
+	b[9] = param
+	copy(b[headerSize:], data)
+	return b
+}
+
+// fakeReader is a smart card reader answering the commands written to it
+// with the messages returned by handle.
+type fakeReader struct {
+	mu     sync.Mutex
+	handle func(fakeCommand) [][]byte
+	// chunk is the maximum size of a transfer read by the host.
+	chunk  int
+	cmds   []fakeCommand
+	aborts []uint16
+	queue  [][]byte
+	ready  chan struct{}
+}
+
+func newFakeReader(handle func(fakeCommand) [][]byte) *fakeReader {
+	return &fakeReader{handle: handle, chunk: 1 << 16, ready: make(chan struct{}, 1)}
+}
+
+func (f *fakeReader) WriteContext(ctx context.Context, p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cmd := fakeCommand{
+		typ:  p[0],
+		slot: int(p[5]),
+		seq:  p[6],
+		data: append([]byte(nil), p[headerSize:]...),
+	}
+	copy(cmd.params[:], p[7:headerSize])
+	f.cmds = append(f.cmds, cmd)
+	var msgs [][]byte
+	if cmd.typ == msgAbort {
+		msgs = [][]byte{cmd.reply(msgSlotStatus, 0, 0, 0, nil)}
+	} else {
+		msgs = f.handle(cmd)
+	}
+	for _, m := range msgs {
+		for len(m) > f.chunk {
+			f.queue = append(f.queue, m[:f.chunk])
+			m = m[f.chunk:]
+		}
+		f.queue = append(f.queue, m)
+	}
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+func (f *fakeReader) ReadContext(ctx context.Context, p []byte) (int, error) {
+	for {
+		f.mu.Lock()
+		if len(f.queue) > 0 {
+			n := copy(p, f.queue[0])
+			f.queue = f.queue[1:]
+			f.mu.Unlock()
+			return n, nil
+		}
+		f.mu.Unlock()
+		select {
+		case <-f.ready:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func (f *fakeReader) Control(rType, req uint8, val, idx uint16, data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rType == rTypeOut && req == reqAbort {
+		f.aborts = append(f.aborts, val)
+	}
+	return len(data), nil
+}
+
+// commands returns the types and sequence numbers of the commands
+// received.
+func (f *fakeReader) commands() [][2]uint8 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var c [][2]uint8
+	for _, cmd := range f.cmds {
+		c = append(c, [2]uint8{cmd.typ, cmd.seq})
+	}
+	return c
+}
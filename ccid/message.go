@@ -0,0 +1,218 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ccid
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// headerSize is the size of the header of the bulk messages.
+const headerSize = 10
+
+// Bulk message types.
+const (
+	msgIccPowerOn    = 0x62
+	msgIccPowerOff   = 0x63
+	msgGetSlotStatus = 0x65
+	msgXfrBlock      = 0x6f
+	msgGetParameters = 0x6c
+	msgAbort         = 0x72
+	msgDataBlock     = 0x80
+	msgSlotStatus    = 0x81
+	msgParameters    = 0x82
+)
+
+// Interrupt message types.
+const (
+	msgNotifySlotChange = 0x50
+	msgHardwareError    = 0x51
+	hardwareErrorSize   = 4
+)
+
+// Values of the level parameter of XfrBlock and of the chain parameter of
+// DataBlock, for chained extended APDUs.
+const (
+	chainNone     = 0x00
+	chainBegin    = 0x01
+	chainEnd      = 0x02
+	chainContinue = 0x03
+	chainMore     = 0x10
+)
+
+// Command status in the status byte of responses.
+const (
+	cmdOK           = 0
+	cmdFailed       = 1
+	cmdTimeExtended = 2
+)
+
+// ICCStatus is the state of the card in a slot.
+type ICCStatus uint8
+
+// ICC states.
+const (
+	ICCActive   ICCStatus = 0
+	ICCInactive ICCStatus = 1
+	ICCAbsent   ICCStatus = 2
+)
+
+var iccNames = map[ICCStatus]string{
+	ICCActive:   "card present and active",
+	ICCInactive: "card present and inactive",
+	ICCAbsent:   "no card present",
+}
+
+// String returns a human-readable description of the status.
+func (s ICCStatus) String() string {
+	if n, ok := iccNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("ICC status %d", uint8(s))
+}
+
+// ErrorCode is the slot error reported by the reader for a failed
+// command. Codes from 1 to 127 are the offset of the invalid field in the
+// command message.
+type ErrorCode uint8
+
+// Slot errors.
+const (
+	ErrCommandNotSupported   ErrorCode = 0x00
+	ErrCommandAborted        ErrorCode = 0xff
+	ErrICCMute               ErrorCode = 0xfe
+	ErrXfrParity             ErrorCode = 0xfd
+	ErrXfrOverrun            ErrorCode = 0xfc
+	ErrHardware              ErrorCode = 0xfb
+	ErrBadATRTS              ErrorCode = 0xf8
+	ErrBadATRTCK             ErrorCode = 0xf7
+	ErrProtocolNotSupported  ErrorCode = 0xf6
+	ErrICCClassNotSupported  ErrorCode = 0xf5
+	ErrProcedureByteConflict ErrorCode = 0xf4
+	ErrDeactivatedProtocol   ErrorCode = 0xf3
+	ErrBusyAutoSequence      ErrorCode = 0xf2
+	ErrPINTimeout            ErrorCode = 0xf0
+	ErrPINCancelled          ErrorCode = 0xef
+	ErrSlotBusy              ErrorCode = 0xe0
+)
+
+var errorNames = map[ErrorCode]string{
+	ErrCommandNotSupported:   "command not supported",
+	ErrCommandAborted:        "command aborted",
+	ErrICCMute:               "card mute",
+	ErrXfrParity:             "parity error",
+	ErrXfrOverrun:            "overrun",
+	ErrHardware:              "hardware error",
+	ErrBadATRTS:              "bad ATR TS",
+	ErrBadATRTCK:             "bad ATR TCK",
+	ErrProtocolNotSupported:  "protocol not supported by the card",
+	ErrICCClassNotSupported:  "card class not supported",
+	ErrProcedureByteConflict: "procedure byte conflict",
+	ErrDeactivatedProtocol:   "deactivated protocol",
+	ErrBusyAutoSequence:      "busy with automatic sequence",
+	ErrPINTimeout:            "PIN timeout",
+	ErrPINCancelled:          "PIN cancelled",
+	ErrSlotBusy:              "slot busy",
+}
+
+// String returns a human-readable description of the error.
+func (e ErrorCode) String() string {
+	if n, ok := errorNames[e]; ok {
+		return n
+	}
+	if e < 0x80 {
+		return fmt.Sprintf("invalid parameter at offset %d", uint8(e))
+	}
+	return fmt.Sprintf("error 0x%02x", uint8(e))
+}
+
+// SlotError is returned for commands failed by the reader.
+type SlotError struct {
+	Slot int
+	// Status is the state of the card after the command.
+	Status ICCStatus
+	Code   ErrorCode
+}
+
+func (e *SlotError) Error() string {
+	if e.Status == ICCAbsent {
+		return fmt.Sprintf("ccid: slot %d: %s", e.Slot, e.Status)
+	}
+	return fmt.Sprintf("ccid: slot %d: %s", e.Slot, e.Code)
+}
+
+// HardwareError is a hardware error reported on the interrupt endpoint.
+type HardwareError struct {
+	Slot int
+	// Seq is the sequence number of the command during which the error
+	// occurred.
+	Seq  uint8
+	Code uint8
+}
+
+func (e *HardwareError) Error() string {
+	return fmt.Sprintf("ccid: slot %d: hardware error 0x%02x during command %d", e.Slot, e.Code, e.Seq)
+}
+
+// marshalCommand returns a command message. params are the three message
+// specific bytes of the header.
+func marshalCommand(typ uint8, slot int, seq uint8, params [3]byte, data []byte) []byte {
+	b := make([]byte, headerSize+len(data))
+	b[0] = typ
+	binary.LittleEndian.PutUint32(b[1:], uint32(len(data)))
+	b[5] = uint8(slot)
+	b[6] = seq
+	copy(b[7:], params[:])
+	copy(b[headerSize:], data)
+	return b
+}
+
+// response is a message from the reader on the bulk IN endpoint.
+type response struct {
+	typ    uint8
+	slot   int
+	seq    uint8
+	status uint8
+	err    ErrorCode
+	// param is the chain parameter of DataBlock, the clock status of
+	// SlotStatus and the protocol of Parameters.
+	param uint8
+	data  []byte
+}
+
+func (r *response) iccStatus() ICCStatus {
+	return ICCStatus(r.status & 0x03)
+}
+
+func (r *response) cmdStatus() uint8 {
+	return r.status >> 6
+}
+
+// parseHeader parses the header of a response and returns the length of
+// its data.
+func parseHeader(b []byte) (*response, int, error) {
+	if len(b) < headerSize {
+		return nil, 0, fmt.Errorf("message too short: %d bytes", len(b))
+	}
+	r := &response{
+		typ:    b[0],
+		slot:   int(b[5]),
+		seq:    b[6],
+		status: b[7],
+		err:    ErrorCode(b[8]),
+		param:  b[9],
+	}
+	return r, int(binary.LittleEndian.Uint32(b[1:])), nil
+}
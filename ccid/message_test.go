@@ -0,0 +1,58 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ccid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshalCommand(t *testing.T) {
+	got := marshalCommand(msgXfrBlock, 1, 7, levelParams(chainContinue), []byte{0x00, 0xa4, 0x04, 0x00})
+	want := []byte{0x6f, 0x04, 0x00, 0x00, 0x00, 0x01, 0x07, 0x00, 0x03, 0x00, 0x00, 0xa4, 0x04, 0x00}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marshalCommand(): got % x, want % x", got, want)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	r, length, err := parseHeader([]byte{0x80, 0x02, 0x00, 0x00, 0x00, 0x00, 0x05, 0x41, 0xfe, 0x00, 0x90, 0x00})
+	if err != nil {
+		t.Fatalf("parseHeader(): %v", err)
+	}
+	if length != 2 || r.typ != msgDataBlock || r.seq != 5 || r.cmdStatus() != cmdFailed || r.iccStatus() != ICCInactive || r.err != ErrICCMute {
+		t.Errorf("parseHeader(): got %+v with length %d, want a failed DataBlock 5 of length 2", r, length)
+	}
+	if _, _, err := parseHeader([]byte{0x80, 0x02}); err == nil {
+		t.Error("parseHeader() of a truncated header: got nil error, want an error")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{&SlotError{Slot: 0, Status: ICCActive, Code: ErrICCMute}, "ccid: slot 0: card mute"},
+		{&SlotError{Slot: 1, Status: ICCAbsent, Code: ErrICCMute}, "ccid: slot 1: no card present"},
+		{&SlotError{Slot: 0, Status: ICCActive, Code: 5}, "ccid: slot 0: invalid parameter at offset 5"},
+		{&SlotError{Slot: 0, Status: ICCActive, Code: 0xe1}, "ccid: slot 0: error 0xe1"},
+		{&HardwareError{Slot: 0, Seq: 3, Code: 0x01}, "ccid: slot 0: hardware error 0x01 during command 3"},
+	} {
+		if got := tc.err.Error(); got != tc.want {
+			t.Errorf("Error(): got %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/google/gousb"
+)
+
+// Interface subclasses of the communications class.
+const (
+	subClassECM gousb.Class = 0x06
+	subClassNCM gousb.Class = 0x0d
+)
+
+// dtCSInterface is the descriptor type of class-specific interface descriptors.
+const dtCSInterface = 0x24
+
+// Functional descriptor subtypes.
+const (
+	fdHeader   = 0x00
+	fdUnion    = 0x06
+	fdEthernet = 0x0f
+	fdNCM      = 0x1a
+)
+
+// Sizes of the functional descriptors, up to the last field used.
+const (
+	fdUnionSize    = 5
+	fdEthernetSize = 13
+	fdNCMSize      = 6
+)
+
+// EthernetDesc is the Ethernet networking functional descriptor of an
+// ECM or NCM function.
+type EthernetDesc struct {
+	// MACAddressIndex is the index of the string descriptor holding the
+	// MAC address of the device as 12 hexadecimal digits.
+	MACAddressIndex int
+	// Statistics is the bitmap of Ethernet statistics collected by the
+	// device.
+	Statistics uint32
+	// MaxSegmentSize is the maximum size of an Ethernet frame, including
+	// the 14 byte header, typically 1514.
+	MaxSegmentSize int
+	// MulticastFilters is the number of multicast filters supported by
+	// the device.
+	MulticastFilters int
+	// ImperfectMulticast is set if the multicast filtering is imperfect,
+	// i.e. the host may receive frames for addresses it didn't request.
+	ImperfectMulticast bool
+	// PowerFilters is the number of pattern filters that can wake up the
+	// host.
+	PowerFilters int
+}
+
+// NCMDesc is the NCM functional descriptor.
+type NCMDesc struct {
+	// Version is the version of the NCM specification.
+	Version gousb.BCD
+	// Capabilities is the bmNetworkCapabilities bitmap.
+	Capabilities uint8
+}
+
+// functional holds the functional descriptors of a communications interface.
+type functional struct {
+	// control and data are the interfaces of the union descriptor.
+	control, data int
+	ethernet      *EthernetDesc
+	ncm           *NCMDesc
+}
+
+// parseFunctional parses the functional descriptors found in the extra
+// descriptors of a communications interface. The union and Ethernet
+// networking descriptors are required.
+func parseFunctional(extra []byte) (*functional, error) {
+	f := &functional{control: -1, data: -1}
+	for len(extra) > 0 {
+		l := int(extra[0])
+		if l < 3 || l > len(extra) {
+			return nil, fmt.Errorf("invalid descriptor length %d with %d bytes left", l, len(extra))
+		}
+		d := extra[:l]
+		extra = extra[l:]
+		if d[1] != dtCSInterface {
+			continue
+		}
+		switch d[2] {
+		case fdUnion:
+			if l < fdUnionSize {
+				return nil, fmt.Errorf("union descriptor too short: %d bytes", l)
+			}
+			f.control, f.data = int(d[3]), int(d[4])
+		case fdEthernet:
+			if l < fdEthernetSize {
+				return nil, fmt.Errorf("Ethernet networking descriptor too short: %d bytes", l)
+			}
+			mc := binary.LittleEndian.Uint16(d[10:])
+			f.ethernet = &EthernetDesc{
+				MACAddressIndex:    int(d[3]),
+				Statistics:         binary.LittleEndian.Uint32(d[4:]),
+				MaxSegmentSize:     int(binary.LittleEndian.Uint16(d[8:])),
+				MulticastFilters:   int(mc & 0x7fff),
+				ImperfectMulticast: mc&0x8000 != 0,
+				PowerFilters:       int(d[12]),
+			}
+		case fdNCM:
+			if l < fdNCMSize {
+				return nil, fmt.Errorf("NCM descriptor too short: %d bytes", l)
+			}
+			f.ncm = &NCMDesc{
+				Version:      gousb.BCD(binary.LittleEndian.Uint16(d[3:])),
+				Capabilities: d[5],
+			}
+		}
+	}
+	if f.data < 0 {
+		return nil, fmt.Errorf("no union descriptor")
+	}
+	if f.ethernet == nil {
+		return nil, fmt.Errorf("no Ethernet networking descriptor")
+	}
+	return f, nil
+}
+
+// parseMAC parses the MAC address string descriptor of the device.
+func parseMAC(s string) (net.HardwareAddr, error) {
+	if len(s) != 12 {
+		return nil, fmt.Errorf("invalid MAC address %q: want 12 hexadecimal digits", s)
+	}
+	mac := make(net.HardwareAddr, 6)
+	for i := range mac {
+		b, err := strconv.ParseUint(s[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAC address %q: %v", s, err)
+		}
+		mac[i] = byte(b)
+	}
+	return mac, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+// testNCMExtra holds the functional descriptors of an NCM function with
+// the communications interface 0 and the data interface 1.
+var testNCMExtra = []byte{
+	// Header: CDC 1.10.
+	0x05, 0x24, 0x00, 0x10, 0x01,
+	// Union: control 0, data 1.
+	0x05, 0x24, 0x06, 0x00, 0x01,
+	// Ethernet networking: MAC in string 4, no statistics, 1514 bytes
+	// segments, 0x8020 multicast filters, no power filters.
+	0x0d, 0x24, 0x0f, 0x04, 0x00, 0x00, 0x00, 0x00, 0xea, 0x05, 0x20, 0x80, 0x00,
+	// NCM 1.00, SetNetAddress and SetMaxDatagramSize.
+	0x06, 0x24, 0x1a, 0x00, 0x01, 0x0a,
+}
+
+func TestParseFunctional(t *testing.T) {
+	f, err := parseFunctional(testNCMExtra)
+	if err != nil {
+		t.Fatalf("parseFunctional(): %v", err)
+	}
+	want := &functional{
+		control: 0,
+		data:    1,
+		ethernet: &EthernetDesc{
+			MACAddressIndex:    4,
+			MaxSegmentSize:     1514,
+			MulticastFilters:   0x20,
+			ImperfectMulticast: true,
+		},
+		ncm: &NCMDesc{Version: gousb.Version(1, 0), Capabilities: 0x0a},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("parseFunctional():\ngot  %+v\nwant %+v", f, want)
+	}
+
+	for _, tc := range []struct {
+		desc  string
+		extra []byte
+	}{
+		{"no union", testNCMExtra[10:]},
+		{"no Ethernet networking", append(append([]byte(nil), testNCMExtra[:10]...), testNCMExtra[23:]...)},
+		{"truncated", testNCMExtra[:12]},
+		{"short Ethernet networking", append(append([]byte(nil), testNCMExtra[:10]...), 0x05, 0x24, 0x0f, 0x04, 0x00)},
+	} {
+		if _, err := parseFunctional(tc.extra); err == nil {
+			t.Errorf("%s: parseFunctional(): got nil error, want non-nil", tc.desc)
+		}
+	}
+}
+
+func TestParseMAC(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{"02005E10000A", "02:00:5e:10:00:0a", false},
+		{"a0b1c2d3e4f5", "a0:b1:c2:d3:e4:f5", false},
+		{"02005E10000", "", true},
+		{"02005E10000G", "", true},
+	} {
+		got, err := parseMAC(tc.s)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseMAC(%q): got error %v, want error: %v", tc.s, err, tc.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tc.want {
+			t.Errorf("parseMAC(%q): got %s, want %s", tc.s, got, tc.want)
+		}
+	}
+}
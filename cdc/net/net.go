@@ -0,0 +1,600 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package net implements Ethernet networking over USB CDC-ECM and CDC-NCM
+// functions in userspace, without the cdc_ether and cdc_ncm kernel
+// drivers.
+//
+// Open claims the communications and data interfaces of the function and
+// returns a Conn, which sends and receives Ethernet frames. It can be
+// plugged into a userspace TCP/IP stack:
+//
+//	c, err := net.Open(dev, net.Config{})
+//	...
+//	defer c.Close()
+//	fmt.Println("device MAC address:", c.MAC)
+//	buf := make([]byte, c.Ethernet.MaxSegmentSize)
+//	for {
+//		n, err := c.ReadFrame(ctx, buf)
+//		...
+//		// handle the frame in buf[:n]
+//	}
+//
+// Frames are written with WriteFrame. On NCM devices, WriteFrames packs
+// several frames into a single transfer block. Link state and speed
+// changes reported by the device are delivered on the channel returned
+// by Events.
+package net
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Class-specific requests of the ECM and NCM subclasses.
+const (
+	reqSetMulticastFilters = 0x40
+	reqSetPacketFilter     = 0x43
+	reqGetNTBParameters    = 0x80
+	reqSetNTBFormat        = 0x84
+	reqSetNTBInputSize     = 0x86
+
+	rTypeOut = gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface
+	rTypeIn  = gousb.ControlIn | gousb.ControlClass | gousb.ControlInterface
+)
+
+const (
+	// readTransfers is the number of bulk IN transfers kept in flight.
+	readTransfers = 4
+	// eventQueue is the number of events buffered for the reader.
+	eventQueue = 16
+	// ncmInputSize8 is the bmNetworkCapabilities bit set if the device
+	// expects an 8 byte SET_NTB_INPUT_SIZE request.
+	ncmInputSize8 = 1 << 5
+)
+
+// Protocol identifies the subclass of the networking function.
+type Protocol int
+
+// Supported networking subclasses.
+const (
+	// ECM is the Ethernet Control Model, with one frame per transfer.
+	ECM Protocol = iota
+	// NCM is the Network Control Model, with frames aggregated in
+	// transfer blocks (NTBs).
+	NCM
+)
+
+// String returns the name of the protocol.
+func (p Protocol) String() string {
+	switch p {
+	case ECM:
+		return "CDC-ECM"
+	case NCM:
+		return "CDC-NCM"
+	}
+	return fmt.Sprintf("protocol %d", int(p))
+}
+
+// PacketFilter selects the frames forwarded by the device to the host.
+type PacketFilter uint16
+
+// Packet filter bits of SET_ETHERNET_PACKET_FILTER.
+const (
+	FilterPromiscuous  PacketFilter = 1 << 0
+	FilterAllMulticast PacketFilter = 1 << 1
+	FilterDirected     PacketFilter = 1 << 2
+	FilterBroadcast    PacketFilter = 1 << 3
+	FilterMulticast    PacketFilter = 1 << 4
+)
+
+// DefaultPacketFilter is the packet filter used if Config.PacketFilter is
+// not set. It includes all multicast frames, which are needed by IPv6
+// neighbor discovery.
+const DefaultPacketFilter = FilterDirected | FilterBroadcast | FilterAllMulticast
+
+// Config holds the options of Open.
+type Config struct {
+	// PacketFilter is the initial packet filter. DefaultPacketFilter is
+	// used if it's 0.
+	PacketFilter PacketFilter
+	// NTB32 selects 32-bit transfer blocks on NCM devices. Open fails if
+	// the device doesn't support them. It's ignored for ECM devices.
+	NTB32 bool
+}
+
+// controller carries the class requests of the communication interface:
+// packet filters and NTB parameters. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the Ethernet frames, one per transfer for ECM or
+// packed in NTBs for NCM, and the notifications of the interrupt
+// endpoint. *gousb.ReadStream and *gousb.InEndpoint implement reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the Ethernet frames, or the NTBs packing them.
+// *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Conn is an open ECM or NCM networking function.
+type Conn struct {
+	// Protocol is the subclass of the function.
+	Protocol Protocol
+	// MAC is the Ethernet address of the device.
+	MAC net.HardwareAddr
+	// Ethernet is the Ethernet networking functional descriptor.
+	Ethernet EthernetDesc
+	// NCM is the NCM functional descriptor, nil for ECM devices.
+	NCM *NCMDesc
+	// NTB are the transfer block parameters, nil for ECM devices.
+	NTB *NTBParams
+
+	ctl  controller
+	intf uint16
+
+	rmu  sync.Mutex
+	r    reader
+	rbuf []byte
+	// frames holds the datagrams of the last NTB not returned yet.
+	frames [][]byte
+
+	wmu       sync.Mutex
+	w         writer
+	wbuf      []byte
+	enc       *ntbEncoder
+	maxPacket int
+
+	events chan Event
+	// mu protects the link state.
+	mu               sync.Mutex
+	connected        bool
+	downlink, uplink int
+	cancel           context.CancelFunc
+	done             chan struct{}
+	release          func()
+}
+
+func findFunction(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, Protocol, error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if alt.Class != gousb.ClassComm || alt.Protocol != 0 {
+				continue
+			}
+			switch alt.SubClass {
+			case subClassECM:
+				return &alt, ECM, nil
+			case subClassNCM:
+				return &alt, NCM, nil
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("%s has no CDC-ECM or CDC-NCM interface", desc)
+}
+
+// Match returns true if the device has an ECM or NCM function in any of
+// its configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if _, _, err := findFunction(cfg); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findData returns the alternate setting of the data interface with bulk
+// endpoints, and the endpoints.
+func findData(desc gousb.ConfigDesc, num int) (*gousb.InterfaceSetting, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		if intf.Number != num {
+			continue
+		}
+		for _, alt := range intf.AltSettings {
+			var in, out *gousb.EndpointDesc
+			for _, ep := range alt.Endpoints {
+				if ep.TransferType != gousb.TransferTypeBulk {
+					continue
+				}
+				ep := ep
+				if ep.Direction == gousb.EndpointDirectionIn {
+					in = &ep
+				} else {
+					out = &ep
+				}
+			}
+			if in != nil && out != nil {
+				alt := alt
+				return &alt, in, out, nil
+			}
+		}
+		return nil, nil, nil, fmt.Errorf("data interface %d has no setting with bulk IN and OUT endpoints", num)
+	}
+	return nil, nil, nil, fmt.Errorf("%s has no data interface %d", desc, num)
+}
+
+// findNotification returns the interrupt endpoint of the communications
+// interface, or nil if there is none.
+func findNotification(alt *gousb.InterfaceSetting) *gousb.EndpointDesc {
+	for _, ep := range alt.Endpoints {
+		if ep.TransferType == gousb.TransferTypeInterrupt && ep.Direction == gousb.EndpointDirectionIn {
+			ep := ep
+			return &ep
+		}
+	}
+	return nil
+}
+
+// Open claims the ECM or NCM function in the active configuration of the
+// device, sets the packet filter and starts receiving frames. The Conn
+// should be Close()d after use.
+func Open(dev *gousb.Device, config Config) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	comm, proto, err := findFunction(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	f, err := parseFunctional(comm.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %s interface %d: %v", dev, proto, comm.Number, err)
+	}
+	dataAlt, inDesc, outDesc, err := findData(desc, f.data)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	s, err := dev.GetStringDescriptor(f.ethernet.MACAddressIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the MAC address of device %s: %v", dev, err)
+	}
+	mac, err := parseMAC(s)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+
+	c := &Conn{
+		Protocol:  proto,
+		MAC:       mac,
+		Ethernet:  *f.ethernet,
+		NCM:       f.ncm,
+		ctl:       dev,
+		intf:      uint16(comm.Number),
+		maxPacket: outDesc.MaxPacketSize,
+		events:    make(chan Event, eventQueue),
+	}
+	cfg, commIntf, err := claim.OpenInterface(dev, cfgNum, comm)
+	if err != nil {
+		return nil, err
+	}
+	var rs *gousb.ReadStream
+	release := func() {
+		if c.cancel != nil {
+			c.cancel()
+			<-c.done
+		}
+		if rs != nil {
+			rs.Close()
+		}
+		cfg.Close()
+	}
+
+	readSize := roundUp(c.Ethernet.MaxSegmentSize, inDesc.MaxPacketSize)
+	if proto == NCM {
+		// The NTB format can only be changed while the data interface
+		// is in the alternate setting without endpoints.
+		idle, err := cfg.Interface(dataAlt.Number, 0)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		err = c.setupNCM(config.NTB32)
+		idle.Close()
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("device %s: %v", dev, err)
+		}
+		readSize = c.NTB.InMaxSize
+	}
+	filter := config.PacketFilter
+	if filter == 0 {
+		filter = DefaultPacketFilter
+	}
+	if err := c.SetPacketFilter(filter); err != nil {
+		release()
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+
+	dataIntf, err := cfg.Claim(dataAlt)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(dataIntf, inDesc, outDesc)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	c.rbuf = make([]byte, readSize)
+	rs, err = in.NewStream(readSize, readTransfers)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	c.r, c.w = rs, out
+
+	if ep := findNotification(comm); ep != nil {
+		notify, err := commIntf.InEndpoint(ep.Number)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		c.readNotifications(notify, ep.MaxPacketSize)
+	} else {
+		// Without notifications, assume the link is up.
+		c.connected = true
+	}
+	c.release = release
+	return c, nil
+}
+
+func roundUp(n, m int) int {
+	if m <= 0 {
+		return n
+	}
+	return (n + m - 1) / m * m
+}
+
+// setupNCM reads the NTB parameters and selects the NTB format.
+func (c *Conn) setupNCM(ntb32 bool) error {
+	b := make([]byte, ntbParamSize)
+	n, err := c.ctl.Control(rTypeIn, reqGetNTBParameters, 0, c.intf, b)
+	if err != nil {
+		return fmt.Errorf("GET_NTB_PARAMETERS: %v", err)
+	}
+	p, err := parseNTBParams(b[:n])
+	if err != nil {
+		return err
+	}
+	if p.InMaxSize < nth16Size || p.OutMaxSize < nth16Size {
+		return fmt.Errorf("invalid NTB sizes %d (IN) and %d (OUT)", p.InMaxSize, p.OutMaxSize)
+	}
+	if ntb32 && p.Formats&FormatNTB32 == 0 {
+		return fmt.Errorf("device doesn't support 32-bit NTBs")
+	}
+	// SET_NTB_FORMAT is only supported by devices with NTB32 support.
+	if p.Formats&FormatNTB32 != 0 {
+		var format uint16
+		if ntb32 {
+			format = 1
+		}
+		if _, err := c.ctl.Control(rTypeOut, reqSetNTBFormat, format, c.intf, nil); err != nil {
+			return fmt.Errorf("SET_NTB_FORMAT: %v", err)
+		}
+	}
+	if !ntb32 && p.InMaxSize > 0xffff {
+		// Limit the size of NTB16s to what their header can describe.
+		size := 4
+		if c.NCM != nil && c.NCM.Capabilities&ncmInputSize8 != 0 {
+			size = 8
+		}
+		b := make([]byte, size)
+		binary.LittleEndian.PutUint32(b, 0xffff)
+		if _, err := c.ctl.Control(rTypeOut, reqSetNTBInputSize, 0, c.intf, b); err != nil {
+			return fmt.Errorf("SET_NTB_INPUT_SIZE: %v", err)
+		}
+		p.InMaxSize = 0xffff
+	}
+	c.NTB = p
+	c.enc = newNTBEncoder(p, ntb32, c.maxPacket)
+	return nil
+}
+
+// SetPacketFilter selects the frames forwarded by the device.
+func (c *Conn) SetPacketFilter(f PacketFilter) error {
+	if _, err := c.ctl.Control(rTypeOut, reqSetPacketFilter, uint16(f), c.intf, nil); err != nil {
+		return fmt.Errorf("SET_ETHERNET_PACKET_FILTER: %v", err)
+	}
+	return nil
+}
+
+// SetMulticastFilters sets the multicast addresses forwarded by the device
+// when the packet filter includes FilterMulticast. The number of addresses
+// is limited by Ethernet.MulticastFilters.
+func (c *Conn) SetMulticastFilters(addrs []net.HardwareAddr) error {
+	if len(addrs) > c.Ethernet.MulticastFilters {
+		return fmt.Errorf("%d multicast filters requested, the device supports %d", len(addrs), c.Ethernet.MulticastFilters)
+	}
+	b := make([]byte, 0, 6*len(addrs))
+	for _, a := range addrs {
+		if len(a) != 6 {
+			return fmt.Errorf("invalid multicast address %s", a)
+		}
+		b = append(b, a...)
+	}
+	if _, err := c.ctl.Control(rTypeOut, reqSetMulticastFilters, uint16(len(addrs)), c.intf, b); err != nil {
+		return fmt.Errorf("SET_ETHERNET_MULTICAST_FILTERS: %v", err)
+	}
+	return nil
+}
+
+// readNotifications reads the interrupt endpoint until the Conn is closed
+// and updates the link state with the notifications of the device.
+func (c *Conn) readNotifications(r reader, size int) {
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		var p notificationParser
+		buf := make([]byte, size)
+		for {
+			n, err := r.ReadContext(ctx, buf)
+			if err != nil {
+				return
+			}
+			for _, e := range p.parse(buf[:n]) {
+				c.mu.Lock()
+				switch e.Type {
+				case EventConnection:
+					c.connected = e.Connected
+				case EventSpeedChange:
+					c.downlink, c.uplink = e.Downlink, e.Uplink
+				}
+				c.mu.Unlock()
+				select {
+				case c.events <- e:
+				default:
+					// The reader is too slow, the state is still
+					// available through Connected and Speed.
+				}
+			}
+		}
+	}()
+}
+
+// Events returns the channel of the notifications received from the
+// device. Events are dropped if the channel is full. The channel is closed
+// by Close.
+func (c *Conn) Events() <-chan Event {
+	return c.events
+}
+
+// Connected returns the last link state reported by the device.
+func (c *Conn) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+// Speed returns the last link speeds reported by the device, in bits per
+// second, or zeros if the device didn't report them.
+func (c *Conn) Speed() (downlink, uplink int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.downlink, c.uplink
+}
+
+// ReadFrame reads the next Ethernet frame received from the device into p
+// and returns its length. If p is too short, the frame is truncated and
+// io.ErrShortBuffer is returned. Malformed NTBs are dropped.
+//
+// The context controls the cancellation of this read. Canceling a read
+// stops the reception of frames, subsequent reads return an error.
+func (c *Conn) ReadFrame(ctx context.Context, p []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+	for {
+		if len(c.frames) > 0 {
+			f := c.frames[0]
+			c.frames = c.frames[1:]
+			return copyFrame(p, f)
+		}
+		n, err := c.r.ReadContext(ctx, c.rbuf)
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			continue
+		}
+		if c.Protocol == ECM {
+			return copyFrame(p, c.rbuf[:n])
+		}
+		if c.frames, err = decodeNTB(c.rbuf[:n]); err != nil {
+			c.frames = nil
+		}
+	}
+}
+
+func copyFrame(p, f []byte) (int, error) {
+	n := copy(p, f)
+	if n < len(f) {
+		return n, io.ErrShortBuffer
+	}
+	return n, nil
+}
+
+// WriteFrame sends an Ethernet frame to the device.
+func (c *Conn) WriteFrame(ctx context.Context, frame []byte) error {
+	_, err := c.WriteFrames(ctx, [][]byte{frame})
+	return err
+}
+
+// WriteFrames sends Ethernet frames to the device and returns the number
+// of frames sent. On NCM devices, frames are packed into as few transfer
+// blocks as the NTB parameters of the device allow.
+func (c *Conn) WriteFrames(ctx context.Context, frames [][]byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	sent := 0
+	for sent < len(frames) {
+		var b []byte
+		n := 1
+		if c.Protocol == ECM {
+			b = c.padFrame(frames[sent])
+		} else {
+			var err error
+			if b, n, err = c.enc.encode(c.wbuf, frames[sent:]); err != nil {
+				return sent, err
+			}
+			c.wbuf = b
+		}
+		if _, err := c.w.WriteContext(ctx, b); err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+// padFrame returns the frame, with an extra byte if its length is a
+// multiple of the max packet size of the endpoint. Without it, the
+// transfer would end without a short packet and the device would wait
+// for the rest of the frame. A zero-length packet would end it as well,
+// but it takes either a separate transfer or the zero-length packet flag
+// of the transfer, which libusb only supports on some platforms, see
+// gousb.OutEndpoint.ZeroLengthPacket. Like the Linux usbnet driver, this
+// relies on the device dropping the trailing byte based on the lengths
+// in the frame.
+func (c *Conn) padFrame(f []byte) []byte {
+	if c.maxPacket == 0 || len(f)%c.maxPacket != 0 {
+		return f
+	}
+	c.wbuf = append(append(c.wbuf[:0], f...), 0)
+	return c.wbuf
+}
+
+// Close stops the reception of frames and notifications and releases the
+// interfaces.
+func (c *Conn) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+		close(c.events)
+	}
+	return nil
+}
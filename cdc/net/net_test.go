@@ -0,0 +1,228 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+// newFakeControl returns a control endpoint that answers
+// GET_NTB_PARAMETERS with params.
+func newFakeControl(params []byte) *fakeusb.Control {
+	return &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+		if r.RType&gousb.ControlIn == 0 {
+			return len(data), nil
+		}
+		if r.Request != reqGetNTBParameters {
+			return 0, gousb.ErrorPipe
+		}
+		return copy(data, params), nil
+	}}
+}
+
+// newFakeBulk returns an IN endpoint that reads the transfers, then
+// io.EOF, and an OUT endpoint.
+func newFakeBulk(transfers ...[]byte) (*fakeusb.In, *fakeusb.Out) {
+	in := fakeusb.NewIn(transfers...)
+	in.Close(io.EOF)
+	return in, &fakeusb.Out{}
+}
+
+func TestSetupNCM(t *testing.T) {
+	params := []byte{
+		0x1c, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00,
+		0x04, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00,
+		0x00, 0x40, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00,
+		0x04, 0x00, 0x00, 0x00,
+	}
+	in := uint8(rTypeIn)
+	out := uint8(rTypeOut)
+	for _, tc := range []struct {
+		ntb32 bool
+		caps  uint8
+		want  []fakeusb.Request
+	}{
+		{
+			ntb32: false,
+			want: []fakeusb.Request{
+				{RType: in, Request: reqGetNTBParameters, Idx: 2},
+				{RType: out, Request: reqSetNTBFormat, Idx: 2},
+				{RType: out, Request: reqSetNTBInputSize, Idx: 2, Data: "\xff\xff\x00\x00"},
+			},
+		},
+		{
+			ntb32: false,
+			caps:  ncmInputSize8,
+			want: []fakeusb.Request{
+				{RType: in, Request: reqGetNTBParameters, Idx: 2},
+				{RType: out, Request: reqSetNTBFormat, Idx: 2},
+				{RType: out, Request: reqSetNTBInputSize, Idx: 2, Data: "\xff\xff\x00\x00\x00\x00\x00\x00"},
+			},
+		},
+		{
+			ntb32: true,
+			want: []fakeusb.Request{
+				{RType: in, Request: reqGetNTBParameters, Idx: 2},
+				{RType: out, Request: reqSetNTBFormat, Val: 1, Idx: 2},
+			},
+		},
+	} {
+		ctl := newFakeControl(params)
+		c := &Conn{Protocol: NCM, NCM: &NCMDesc{Capabilities: tc.caps}, ctl: ctl, intf: 2, maxPacket: 512}
+		if err := c.setupNCM(tc.ntb32); err != nil {
+			t.Fatalf("NTB32 %v: setupNCM(): %v", tc.ntb32, err)
+		}
+		if got := ctl.Requests(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("NTB32 %v: setupNCM() requests:\ngot  %q\nwant %q", tc.ntb32, got, tc.want)
+		}
+	}
+
+	// NTB16 only.
+	params[2] = FormatNTB16
+	c := &Conn{Protocol: NCM, ctl: newFakeControl(params), intf: 2}
+	if err := c.setupNCM(true); err == nil {
+		t.Error("setupNCM(NTB32) on a device without NTB32 support: got nil error, want non-nil")
+	}
+}
+
+func TestPacketFilters(t *testing.T) {
+	ctl := newFakeControl(nil)
+	c := &Conn{ctl: ctl, intf: 1, Ethernet: EthernetDesc{MulticastFilters: 1}}
+	if err := c.SetPacketFilter(DefaultPacketFilter); err != nil {
+		t.Fatalf("SetPacketFilter(): %v", err)
+	}
+	if err := c.SetMulticastFilters([]net.HardwareAddr{{0x01, 0x00, 0x5e, 0x00, 0x00, 0xfb}}); err != nil {
+		t.Fatalf("SetMulticastFilters(): %v", err)
+	}
+	want := []fakeusb.Request{
+		{RType: uint8(rTypeOut), Request: reqSetPacketFilter, Val: 0x0e, Idx: 1},
+		{RType: uint8(rTypeOut), Request: reqSetMulticastFilters, Val: 1, Idx: 1, Data: "\x01\x00\x5e\x00\x00\xfb"},
+	}
+	if got := ctl.Requests(); !reflect.DeepEqual(got, want) {
+		t.Errorf("requests:\ngot  %q\nwant %q", got, want)
+	}
+	if err := c.SetMulticastFilters(make([]net.HardwareAddr, 2)); err == nil {
+		t.Error("SetMulticastFilters() with too many addresses: got nil error, want non-nil")
+	}
+}
+
+func TestECMFrames(t *testing.T) {
+	in, out := newFakeBulk([]byte{}, []byte("frame one"), []byte("frame two"))
+	c := &Conn{Protocol: ECM, r: in, w: out, rbuf: make([]byte, 64), maxPacket: 8}
+	buf := make([]byte, 16)
+	n, err := c.ReadFrame(context.Background(), buf)
+	if err != nil || string(buf[:n]) != "frame one" {
+		t.Errorf("ReadFrame(): got %q, %v, want %q, nil", buf[:n], err, "frame one")
+	}
+	if n, err := c.ReadFrame(context.Background(), buf[:5]); err != io.ErrShortBuffer || n != 5 {
+		t.Errorf("ReadFrame() into a short buffer: got %d, %v, want 5, %v", n, err, io.ErrShortBuffer)
+	}
+	if _, err := c.ReadFrame(context.Background(), buf); err != io.EOF {
+		t.Errorf("ReadFrame() at the end of the stream: got %v, want %v", err, io.EOF)
+	}
+
+	if n, err := c.WriteFrames(context.Background(), [][]byte{[]byte("12345"), []byte("12345678")}); err != nil || n != 2 {
+		t.Fatalf("WriteFrames(): got %d, %v, want 2, nil", n, err)
+	}
+	// The frame of exactly one packet is padded.
+	if want := [][]byte{[]byte("12345"), []byte("12345678\x00")}; !reflect.DeepEqual(out.Transfers(), want) {
+		t.Errorf("written transfers: got %q, want %q", out.Transfers(), want)
+	}
+}
+
+func TestNCMFrames(t *testing.T) {
+	params := &NTBParams{OutMaxSize: 2048, OutDivisor: 4, OutAlignment: 4}
+	enc := newNTBEncoder(params, false, 512)
+	frames := testFrames(60, 70, 80)
+	ntb, _, err := enc.encode(nil, frames[:2])
+	if err != nil {
+		t.Fatalf("encode(): %v", err)
+	}
+	ntb2, _, err := enc.encode(nil, frames[2:])
+	if err != nil {
+		t.Fatalf("encode(): %v", err)
+	}
+	in, out := newFakeBulk(ntb, []byte("garbage NTB"), ntb2)
+	c := &Conn{Protocol: NCM, r: in, w: out, rbuf: make([]byte, 2048), enc: newNTBEncoder(params, false, 512)}
+	buf := make([]byte, 1514)
+	var got [][]byte
+	for {
+		n, err := c.ReadFrame(context.Background(), buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadFrame(): %v", err)
+		}
+		got = append(got, append([]byte(nil), buf[:n]...))
+	}
+	if !reflect.DeepEqual(got, frames) {
+		t.Errorf("ReadFrame(): got %d frames, want %d identical frames", len(got), len(frames))
+	}
+
+	if err := c.WriteFrame(context.Background(), frames[0]); err != nil {
+		t.Fatalf("WriteFrame(): %v", err)
+	}
+	if n, err := c.WriteFrames(context.Background(), frames); err != nil || n != 3 {
+		t.Fatalf("WriteFrames(): got %d, %v, want 3, nil", n, err)
+	}
+	written := out.Transfers()
+	if len(written) != 2 {
+		t.Fatalf("written transfers: got %d, want 2", len(written))
+	}
+	if got, err := decodeNTB(written[1]); err != nil || !reflect.DeepEqual(got, frames) {
+		t.Errorf("decodeNTB() of the written NTB: got %d frames, %v, want %d identical frames", len(got), err, len(frames))
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	c := &Conn{events: make(chan Event, eventQueue)}
+	c.readNotifications(fakeusb.NewIn(
+		[]byte{0xa1, 0x2a, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x40, 0x42, 0x0f, 0x00, 0x80, 0x84, 0x1e, 0x00},
+		[]byte{0xa1, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00},
+	), 16)
+	want := []Event{
+		{Type: EventSpeedChange, Downlink: 1000000, Uplink: 2000000},
+		{Type: EventConnection, Connected: true},
+	}
+	for _, w := range want {
+		if got := <-c.Events(); got != w {
+			t.Errorf("Events(): got %v, want %v", got, w)
+		}
+	}
+	if !c.Connected() {
+		t.Error("Connected(): got false, want true")
+	}
+	if down, up := c.Speed(); down != 1000000 || up != 2000000 {
+		t.Errorf("Speed(): got %d, %d, want 1000000, 2000000", down, up)
+	}
+	c.release = func() {
+		c.cancel()
+		<-c.done
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close(): %v", err)
+	}
+	if _, ok := <-c.Events(); ok {
+		t.Error("Events() after Close: channel still open")
+	}
+}
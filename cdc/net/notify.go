@@ -0,0 +1,120 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Notification codes of the communications class.
+const (
+	// rTypeNotification is the bmRequestType of notifications.
+	rTypeNotification       = 0xa1
+	notifyNetworkConnection = 0x00
+	notifySpeedChange       = 0x2a
+	notifyHeaderSize        = 8
+	speedChangeSize         = 8
+)
+
+// EventType identifies the kind of an Event.
+type EventType int
+
+// Events reported by the device.
+const (
+	// EventConnection reports a change of the link state.
+	EventConnection EventType = iota
+	// EventSpeedChange reports a change of the link speed.
+	EventSpeedChange
+)
+
+// String returns a human-readable name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventConnection:
+		return "connection"
+	case EventSpeedChange:
+		return "speed change"
+	}
+	return fmt.Sprintf("event type %d", int(t))
+}
+
+// Event is a notification received from the device.
+type Event struct {
+	Type EventType
+	// Connected is the link state, set for EventConnection.
+	Connected bool
+	// Downlink and Uplink are the link speeds in bits per second, set
+	// for EventSpeedChange. Downlink is the speed of the data sent by
+	// the device to the host.
+	Downlink, Uplink int
+}
+
+// String returns a human-readable description of the event.
+func (e Event) String() string {
+	switch e.Type {
+	case EventConnection:
+		if e.Connected {
+			return "connected"
+		}
+		return "disconnected"
+	case EventSpeedChange:
+		return fmt.Sprintf("speed %d/%d bps", e.Downlink, e.Uplink)
+	}
+	return e.Type.String()
+}
+
+// notificationParser reassembles notifications from the data read from
+// the interrupt endpoint. A notification with data may span several
+// transfers if the max packet size of the endpoint is small.
+type notificationParser struct {
+	pending []byte
+}
+
+// parse returns the events in the data of an interrupt transfer.
+// Unknown notifications are skipped.
+func (p *notificationParser) parse(b []byte) []Event {
+	p.pending = append(p.pending, b...)
+	var events []Event
+	for len(p.pending) >= notifyHeaderSize {
+		n := p.pending
+		if n[0] != rTypeNotification {
+			// Lost track of the notification boundaries.
+			p.pending = nil
+			break
+		}
+		l := notifyHeaderSize + int(binary.LittleEndian.Uint16(n[6:]))
+		if len(n) < l {
+			break
+		}
+		switch n[1] {
+		case notifyNetworkConnection:
+			events = append(events, Event{Type: EventConnection, Connected: binary.LittleEndian.Uint16(n[2:]) != 0})
+		case notifySpeedChange:
+			if l >= notifyHeaderSize+speedChangeSize {
+				events = append(events, Event{
+					Type:     EventSpeedChange,
+					Downlink: int(binary.LittleEndian.Uint32(n[8:])),
+					Uplink:   int(binary.LittleEndian.Uint32(n[12:])),
+				})
+			}
+		}
+		p.pending = n[l:]
+	}
+	if len(p.pending) == 0 {
+		p.pending = nil
+	}
+	return events
+}
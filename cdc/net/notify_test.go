@@ -0,0 +1,64 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotificationParser(t *testing.T) {
+	connected := []byte{0xa1, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}
+	speed := []byte{
+		0xa1, 0x2a, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00,
+		0x00, 0xe1, 0xf5, 0x05, 0x00, 0xca, 0x9a, 0x3b,
+	}
+	unknown := []byte{0xa1, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	for _, tc := range []struct {
+		desc      string
+		transfers [][]byte
+		want      []Event
+	}{
+		{
+			desc:      "connection",
+			transfers: [][]byte{connected},
+			want:      []Event{{Type: EventConnection, Connected: true}},
+		},
+		{
+			desc:      "speed change in 8 byte packets",
+			transfers: [][]byte{speed[:8], speed[8:]},
+			want:      []Event{{Type: EventSpeedChange, Downlink: 100000000, Uplink: 1000000000}},
+		},
+		{
+			desc:      "unknown notification and disconnection",
+			transfers: [][]byte{append(append([]byte(nil), unknown...), 0xa1, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)},
+			want:      []Event{{Type: EventConnection}},
+		},
+		{
+			desc:      "garbage",
+			transfers: [][]byte{{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, connected},
+			want:      []Event{{Type: EventConnection, Connected: true}},
+		},
+	} {
+		var p notificationParser
+		var got []Event
+		for _, b := range tc.transfers {
+			got = append(got, p.parse(b)...)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: parse(): got %v, want %v", tc.desc, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Signatures of the NCM transfer headers and datagram pointer tables.
+const (
+	sigNTH16     = 0x484d434e // "NCMH"
+	sigNTH32     = 0x686d636e // "ncmh"
+	sigNDP16     = 0x304d434e // "NCM0"
+	sigNDP16CRC  = 0x314d434e // "NCM1"
+	sigNDP32     = 0x306d636e // "ncm0"
+	sigNDP32CRC  = 0x316d636e // "ncm1"
+	nth16Size    = 12
+	nth32Size    = 16
+	ndp16Size    = 8
+	ndp32Size    = 16
+	ndp16Entry   = 4
+	ndp32Entry   = 8
+	crcSize      = 4
+	ntbParamSize = 28
+)
+
+// NTB formats, as reported in NTBParams.Formats.
+const (
+	FormatNTB16 = 1 << 0
+	FormatNTB32 = 1 << 1
+)
+
+// NTBParams are the NCM transfer block parameters reported by the device
+// in response to GET_NTB_PARAMETERS.
+type NTBParams struct {
+	// Formats is the bitmap of supported NTB formats, FormatNTB16 and
+	// FormatNTB32.
+	Formats uint16
+	// InMaxSize is the maximum size of NTBs sent by the device.
+	InMaxSize int
+	// InDivisor, InRemainder and InAlignment describe the alignment of
+	// datagrams and NDPs in NTBs sent by the device.
+	InDivisor, InRemainder, InAlignment int
+	// OutMaxSize is the maximum size of NTBs accepted by the device.
+	OutMaxSize int
+	// OutDivisor and OutRemainder constrain the offset of datagrams in
+	// NTBs sent to the device: offset % OutDivisor == OutRemainder.
+	OutDivisor, OutRemainder int
+	// OutAlignment is the alignment of NDPs in NTBs sent to the device.
+	OutAlignment int
+	// OutMaxDatagrams is the maximum number of datagrams in an NTB sent
+	// to the device, or 0 if there is no limit.
+	OutMaxDatagrams int
+}
+
+func parseNTBParams(b []byte) (*NTBParams, error) {
+	if len(b) < ntbParamSize {
+		return nil, fmt.Errorf("NTB parameters too short: %d bytes", len(b))
+	}
+	u16 := func(off int) int { return int(binary.LittleEndian.Uint16(b[off:])) }
+	u32 := func(off int) int { return int(binary.LittleEndian.Uint32(b[off:])) }
+	return &NTBParams{
+		Formats:         uint16(u16(2)),
+		InMaxSize:       u32(4),
+		InDivisor:       u16(8),
+		InRemainder:     u16(10),
+		InAlignment:     u16(12),
+		OutMaxSize:      u32(16),
+		OutDivisor:      u16(20),
+		OutRemainder:    u16(22),
+		OutAlignment:    u16(24),
+		OutMaxDatagrams: u16(26),
+	}, nil
+}
+
+// align returns the smallest offset not lower than off such that
+// offset % divisor == remainder.
+func align(off, divisor, remainder int) int {
+	if divisor <= 1 {
+		return off
+	}
+	return off + ((remainder-off)%divisor+divisor)%divisor
+}
+
+// ntbEncoder builds NTBs sent to the device. The datagrams follow the
+// transfer header and the datagram pointer table is placed at the end
+// of the block, so that its size doesn't need to be known in advance.
+type ntbEncoder struct {
+	ntb32 bool
+	// maxSize is the maximum size of an NTB.
+	maxSize int
+	// divisor, remainder and ndpAlign are the alignment constraints.
+	divisor, remainder, ndpAlign int
+	// maxDatagrams is the maximum number of datagrams per NTB, or 0.
+	maxDatagrams int
+	// maxPacket is the max packet size of the OUT endpoint. NTBs shorter
+	// than maxSize are padded so that they end with a short packet.
+	maxPacket int
+	seq       uint16
+}
+
+func newNTBEncoder(p *NTBParams, ntb32 bool, maxPacket int) *ntbEncoder {
+	e := &ntbEncoder{
+		ntb32:        ntb32,
+		maxSize:      p.OutMaxSize,
+		divisor:      p.OutDivisor,
+		remainder:    p.OutRemainder,
+		ndpAlign:     p.OutAlignment,
+		maxDatagrams: p.OutMaxDatagrams,
+		maxPacket:    maxPacket,
+	}
+	if !ntb32 && e.maxSize > 0xffff {
+		e.maxSize = 0xffff
+	}
+	if e.ndpAlign < 4 {
+		e.ndpAlign = 4
+	}
+	return e
+}
+
+func (e *ntbEncoder) sizes() (nth, ndp, entry int) {
+	if e.ntb32 {
+		return nth32Size, ndp32Size, ndp32Entry
+	}
+	return nth16Size, ndp16Size, ndp16Entry
+}
+
+// encode appends an NTB holding the first frames to buf[:0] and returns
+// it with the number of frames it holds. At least one frame is encoded,
+// or an error is returned if the first frame doesn't fit in an NTB.
+func (e *ntbEncoder) encode(buf []byte, frames [][]byte) ([]byte, int, error) {
+	nthSize, ndpSize, entrySize := e.sizes()
+	// offsets of the datagrams, and the end of the last one.
+	var offs []int
+	end := nthSize
+	for _, f := range frames {
+		if e.maxDatagrams > 0 && len(offs) == e.maxDatagrams {
+			break
+		}
+		off := align(end, e.divisor, e.remainder)
+		// the NDP holds the datagrams and a terminating null entry.
+		ndp := align(off+len(f), e.ndpAlign, 0)
+		if ndp+ndpSize+(len(offs)+2)*entrySize > e.maxSize {
+			break
+		}
+		offs = append(offs, off)
+		end = off + len(f)
+	}
+	if len(offs) == 0 {
+		if len(frames) == 0 {
+			return nil, 0, fmt.Errorf("no frames to encode")
+		}
+		return nil, 0, fmt.Errorf("frame of %d bytes doesn't fit in an NTB of at most %d bytes", len(frames[0]), e.maxSize)
+	}
+	ndpOff := align(end, e.ndpAlign, 0)
+	ndpLen := ndpSize + (len(offs)+1)*entrySize
+	size := ndpOff + ndpLen
+	if e.maxPacket > 0 && size%e.maxPacket == 0 && size < e.maxSize {
+		// Pad the block so that the transfer ends with a short packet,
+		// instead of a zero-length packet, which needs another transfer
+		// where libusb can't append it. The block length in the header
+		// covers the padding.
+		size++
+	}
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	b := buf[:size]
+	for i := range b {
+		b[i] = 0
+	}
+	le := binary.LittleEndian
+	if e.ntb32 {
+		le.PutUint32(b[0:], sigNTH32)
+		le.PutUint16(b[4:], nth32Size)
+		le.PutUint16(b[6:], e.seq)
+		le.PutUint32(b[8:], uint32(size))
+		le.PutUint32(b[12:], uint32(ndpOff))
+		ndp := b[ndpOff:]
+		le.PutUint32(ndp[0:], sigNDP32)
+		le.PutUint16(ndp[4:], uint16(ndpLen))
+		for i, off := range offs {
+			le.PutUint32(ndp[ndp32Size+i*ndp32Entry:], uint32(off))
+			le.PutUint32(ndp[ndp32Size+i*ndp32Entry+4:], uint32(len(frames[i])))
+		}
+	} else {
+		le.PutUint32(b[0:], sigNTH16)
+		le.PutUint16(b[4:], nth16Size)
+		le.PutUint16(b[6:], e.seq)
+		le.PutUint16(b[8:], uint16(size))
+		le.PutUint16(b[10:], uint16(ndpOff))
+		ndp := b[ndpOff:]
+		le.PutUint32(ndp[0:], sigNDP16)
+		le.PutUint16(ndp[4:], uint16(ndpLen))
+		for i, off := range offs {
+			le.PutUint16(ndp[ndp16Size+i*ndp16Entry:], uint16(off))
+			le.PutUint16(ndp[ndp16Size+i*ndp16Entry+2:], uint16(len(frames[i])))
+		}
+	}
+	for i, off := range offs {
+		copy(b[off:], frames[i])
+	}
+	e.seq++
+	return b, len(offs), nil
+}
+
+// decodeNTB returns the datagrams of an NTB16 or NTB32 received from the
+// device. The datagrams are slices of b.
+func decodeNTB(b []byte) ([][]byte, error) {
+	if len(b) < nth16Size {
+		return nil, fmt.Errorf("NTB too short: %d bytes", len(b))
+	}
+	le := binary.LittleEndian
+	var ntb32 bool
+	var blockLen, ndpOff int
+	switch sig := le.Uint32(b); sig {
+	case sigNTH16:
+		if hl := le.Uint16(b[4:]); hl != nth16Size {
+			return nil, fmt.Errorf("invalid NTH16 header length %d", hl)
+		}
+		blockLen, ndpOff = int(le.Uint16(b[8:])), int(le.Uint16(b[10:]))
+		if blockLen == 0 {
+			// The NTB is terminated by a short packet.
+			blockLen = len(b)
+		}
+	case sigNTH32:
+		if len(b) < nth32Size {
+			return nil, fmt.Errorf("NTB32 too short: %d bytes", len(b))
+		}
+		if hl := le.Uint16(b[4:]); hl != nth32Size {
+			return nil, fmt.Errorf("invalid NTH32 header length %d", hl)
+		}
+		ntb32 = true
+		blockLen, ndpOff = int(le.Uint32(b[8:])), int(le.Uint32(b[12:]))
+	default:
+		return nil, fmt.Errorf("invalid NTB signature 0x%08x", sig)
+	}
+	if blockLen > len(b) {
+		return nil, fmt.Errorf("NTB block length %d larger than the %d bytes received", blockLen, len(b))
+	}
+	b = b[:blockLen]
+
+	var frames [][]byte
+	// Every NDP takes at least ndp16Size bytes, a longer chain is a loop.
+	for n := 0; ndpOff != 0; n++ {
+		if n > blockLen/ndp16Size {
+			return nil, fmt.Errorf("loop in the NDP chain")
+		}
+		var next int
+		var err error
+		if ntb32 {
+			frames, next, err = decodeNDP32(b, ndpOff, frames)
+		} else {
+			frames, next, err = decodeNDP16(b, ndpOff, frames)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ndpOff = next
+	}
+	return frames, nil
+}
+
+// datagram returns the datagram at off in the NTB, without its CRC.
+func datagram(b []byte, off, l int, crc bool) ([]byte, error) {
+	if off < 0 || l < 0 || off+l > len(b) || off+l < off {
+		return nil, fmt.Errorf("datagram at offset %d with length %d outside of the %d bytes NTB", off, l, len(b))
+	}
+	if crc {
+		if l < crcSize {
+			return nil, fmt.Errorf("datagram of %d bytes too short for a CRC", l)
+		}
+		l -= crcSize
+	}
+	return b[off : off+l : off+l], nil
+}
+
+func decodeNDP16(b []byte, off int, frames [][]byte) ([][]byte, int, error) {
+	le := binary.LittleEndian
+	if off%4 != 0 || off+ndp16Size > len(b) {
+		return nil, 0, fmt.Errorf("invalid NDP16 offset %d in a %d bytes NTB", off, len(b))
+	}
+	ndp := b[off:]
+	var crc bool
+	switch sig := le.Uint32(ndp); sig {
+	case sigNDP16:
+	case sigNDP16CRC:
+		crc = true
+	default:
+		return nil, 0, fmt.Errorf("invalid NDP16 signature 0x%08x", sig)
+	}
+	l := int(le.Uint16(ndp[4:]))
+	if l < ndp16Size+2*ndp16Entry || l%4 != 0 || l > len(ndp) {
+		return nil, 0, fmt.Errorf("invalid NDP16 length %d", l)
+	}
+	for e := ndp[ndp16Size:l]; len(e) >= ndp16Entry; e = e[ndp16Entry:] {
+		dOff, dLen := int(le.Uint16(e)), int(le.Uint16(e[2:]))
+		if dOff == 0 || dLen == 0 {
+			break
+		}
+		d, err := datagram(b, dOff, dLen, crc)
+		if err != nil {
+			return nil, 0, err
+		}
+		frames = append(frames, d)
+	}
+	return frames, int(le.Uint16(ndp[6:])), nil
+}
+
+func decodeNDP32(b []byte, off int, frames [][]byte) ([][]byte, int, error) {
+	le := binary.LittleEndian
+	if off%4 != 0 || off+ndp32Size > len(b) || off+ndp32Size < off {
+		return nil, 0, fmt.Errorf("invalid NDP32 offset %d in a %d bytes NTB", off, len(b))
+	}
+	ndp := b[off:]
+	var crc bool
+	switch sig := le.Uint32(ndp); sig {
+	case sigNDP32:
+	case sigNDP32CRC:
+		crc = true
+	default:
+		return nil, 0, fmt.Errorf("invalid NDP32 signature 0x%08x", sig)
+	}
+	l := int(le.Uint16(ndp[4:]))
+	if l < ndp32Size+2*ndp32Entry || l%8 != 0 || l > len(ndp) {
+		return nil, 0, fmt.Errorf("invalid NDP32 length %d", l)
+	}
+	for e := ndp[ndp32Size:l]; len(e) >= ndp32Entry; e = e[ndp32Entry:] {
+		dOff, dLen := int(le.Uint32(e)), int(le.Uint32(e[4:]))
+		if dOff == 0 || dLen == 0 {
+			break
+		}
+		d, err := datagram(b, dOff, dLen, crc)
+		if err != nil {
+			return nil, 0, err
+		}
+		frames = append(frames, d)
+	}
+	return frames, int(le.Uint32(ndp[8:])), nil
+}
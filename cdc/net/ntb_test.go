@@ -0,0 +1,182 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestAlign(t *testing.T) {
+	for _, tc := range []struct {
+		off, divisor, remainder int
+		want                    int
+	}{
+		{12, 4, 0, 12},
+		{13, 4, 0, 16},
+		{12, 4, 2, 14},
+		{15, 4, 2, 18},
+		{0, 16, 14, 14},
+		{13, 0, 0, 13},
+	} {
+		if got := align(tc.off, tc.divisor, tc.remainder); got != tc.want {
+			t.Errorf("align(%d, %d, %d): got %d, want %d", tc.off, tc.divisor, tc.remainder, got, tc.want)
+		}
+	}
+}
+
+func TestParseNTBParams(t *testing.T) {
+	b := []byte{
+		0x1c, 0x00, 0x03, 0x00, 0x00, 0x40, 0x00, 0x00,
+		0x04, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00,
+		0x00, 0x20, 0x00, 0x00, 0x04, 0x00, 0x02, 0x00,
+		0x04, 0x00, 0x10, 0x00,
+	}
+	got, err := parseNTBParams(b)
+	if err != nil {
+		t.Fatalf("parseNTBParams(): %v", err)
+	}
+	want := &NTBParams{
+		Formats:         FormatNTB16 | FormatNTB32,
+		InMaxSize:       0x4000,
+		InDivisor:       4,
+		InAlignment:     4,
+		OutMaxSize:      0x2000,
+		OutDivisor:      4,
+		OutRemainder:    2,
+		OutAlignment:    4,
+		OutMaxDatagrams: 16,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNTBParams():\ngot  %+v\nwant %+v", got, want)
+	}
+	if _, err := parseNTBParams(b[:20]); err == nil {
+		t.Error("parseNTBParams() of a short response: got nil error, want non-nil")
+	}
+}
+
+func testFrames(sizes ...int) [][]byte {
+	var frames [][]byte
+	for i, s := range sizes {
+		frames = append(frames, bytes.Repeat([]byte{byte(i + 1)}, s))
+	}
+	return frames
+}
+
+// offsetIn returns the offset of the datagram d in the NTB b.
+func offsetIn(b, d []byte) int {
+	for i := range b {
+		if &b[i] == &d[0] {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestNTBRoundTrip(t *testing.T) {
+	params := &NTBParams{OutMaxSize: 2048, OutDivisor: 4, OutRemainder: 2, OutAlignment: 4, OutMaxDatagrams: 3}
+	for _, ntb32 := range []bool{false, true} {
+		e := newNTBEncoder(params, ntb32, 512)
+		frames := testFrames(60, 1514, 100, 99, 42)
+		var got [][]byte
+		var blocks int
+		for rest := frames; len(rest) > 0; blocks++ {
+			b, n, err := e.encode(nil, rest)
+			if err != nil {
+				t.Fatalf("NTB32 %v: encode(): %v", ntb32, err)
+			}
+			if len(b) > params.OutMaxSize {
+				t.Errorf("NTB32 %v: encode(): got a %d bytes NTB, want at most %d", ntb32, len(b), params.OutMaxSize)
+			}
+			if len(b)%512 == 0 {
+				t.Errorf("NTB32 %v: encode(): got a %d bytes NTB, want a short last packet", ntb32, len(b))
+			}
+			d, err := decodeNTB(b)
+			if err != nil {
+				t.Fatalf("NTB32 %v: decodeNTB(): %v", ntb32, err)
+			}
+			for _, f := range d {
+				if off := offsetIn(b, f); off%4 != 2 {
+					t.Errorf("NTB32 %v: datagram at offset %d, want offset %% 4 == 2", ntb32, off)
+				}
+			}
+			got = append(got, d...)
+			rest = rest[n:]
+		}
+		if !reflect.DeepEqual(got, frames) {
+			t.Errorf("NTB32 %v: decoded %d frames, want %d identical frames", ntb32, len(got), len(frames))
+		}
+		// 60 and 1514 bytes fit in the first block, then 3 datagrams max.
+		if blocks != 2 {
+			t.Errorf("NTB32 %v: got %d NTBs, want 2", ntb32, blocks)
+		}
+		if e.seq != 2 {
+			t.Errorf("NTB32 %v: sequence number %d after 2 NTBs, want 2", ntb32, e.seq)
+		}
+	}
+	e := newNTBEncoder(params, false, 512)
+	if _, _, err := e.encode(nil, testFrames(4000)); err == nil {
+		t.Error("encode() of a frame larger than the NTB: got nil error, want non-nil")
+	}
+}
+
+// ntb16 builds an NTB16 with a single NDP at offset 12 holding the
+// entries, followed by data.
+func ntb16(blockLen int, entries []uint16, data []byte) []byte {
+	le := binary.LittleEndian
+	ndpLen := ndp16Size + 2*len(entries)
+	b := make([]byte, nth16Size+ndpLen)
+	le.PutUint32(b, sigNTH16)
+	le.PutUint16(b[4:], nth16Size)
+	le.PutUint16(b[8:], uint16(blockLen))
+	le.PutUint16(b[10:], nth16Size)
+	le.PutUint32(b[12:], sigNDP16)
+	le.PutUint16(b[16:], uint16(ndpLen))
+	for i, e := range entries {
+		le.PutUint16(b[20+2*i:], e)
+	}
+	return append(b, data...)
+}
+
+func TestDecodeNTB(t *testing.T) {
+	// header 12 bytes, NDP 8+12 bytes, data at 32.
+	valid := ntb16(40, []uint16{32, 3, 36, 4, 0, 0}, []byte{1, 2, 3, 0, 4, 5, 6, 7})
+	got, err := decodeNTB(valid)
+	if err != nil {
+		t.Fatalf("decodeNTB(): %v", err)
+	}
+	if want := [][]byte{{1, 2, 3}, {4, 5, 6, 7}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeNTB(): got %v, want %v", got, want)
+	}
+
+	loop := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint16(loop[18:], nth16Size)
+	for _, tc := range []struct {
+		desc string
+		b    []byte
+	}{
+		{"bad signature", append([]byte("XCMH"), valid[4:]...)},
+		{"block longer than data", ntb16(100, []uint16{32, 3, 0, 0}, []byte{1, 2, 3, 4})},
+		{"datagram out of bounds", ntb16(36, []uint16{32, 8, 0, 0}, []byte{1, 2, 3, 4})},
+		{"short NDP", ntb16(20, nil, nil)},
+		{"NDP loop", loop},
+	} {
+		if _, err := decodeNTB(tc.b); err == nil {
+			t.Errorf("%s: decodeNTB(): got nil error, want non-nil", tc.desc)
+		}
+	}
+}
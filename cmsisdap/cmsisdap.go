@@ -0,0 +1,331 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cmsisdap implements the command transport of CMSIS-DAP debug
+// probes, which drive the SWD or JTAG port of ARM microcontrollers.
+//
+// Open claims the CMSIS-DAP interface of a probe: the bulk endpoints of
+// CMSIS-DAP v2 probes, or the HID reports of CMSIS-DAP v1 probes. Probes
+// are identified by the "CMSIS-DAP" string in the name of their interface,
+// or of the device for v1 probes:
+//
+//	p, err := cmsisdap.Open(dev)
+//	...
+//	defer p.Close()
+//	if _, err := p.Connect(ctx, cmsisdap.PortSWD); err != nil {
+//		...
+//	}
+//	// Switch the target from JTAG to SWD and read its DP IDCODE.
+//	err = p.SWJSequence(ctx, 51, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+//	...
+//	err = p.SWJSequence(ctx, 16, []byte{0x9e, 0xe7})
+//	...
+//	err = p.SWJSequence(ctx, 51, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+//	...
+//	err = p.SWJSequence(ctx, 8, []byte{0x00})
+//	...
+//	v, err := p.Transfer(ctx, 0, []cmsisdap.Request{{Read: true, Addr: 0x0}})
+//
+// v1 probes are bound to the HID driver of the kernel on Linux, enable
+// gousb.Device.SetAutoDetach before Open.
+package cmsisdap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// interfaceName is the string identifying CMSIS-DAP interfaces.
+const interfaceName = "CMSIS-DAP"
+
+const (
+	// HID class request sending an output report with ID 0.
+	reqSetReport   = 0x09
+	reportOutput   = 0x02
+	rTypeSetReport = gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface
+)
+
+// maxStaleResponses is the number of responses to earlier commands, given
+// up on, skipped before a response is considered invalid.
+const maxStaleResponses = 8
+
+// ErrUnsupported is returned for commands not implemented by the probe.
+var ErrUnsupported = errors.New("cmsisdap: command not supported by the probe")
+
+// controller carries the SET_REPORT requests of v1 probes without an
+// interrupt OUT endpoint. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the responses of the probe, as bulk transfers or HID
+// reports. *gousb.InEndpoint implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the commands to the probe, as bulk transfers or HID
+// reports. *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// transport sends the commands and receives the responses.
+type transport interface {
+	send(ctx context.Context, cmd []byte) error
+	receive(ctx context.Context) ([]byte, error)
+}
+
+// bulkTransport is the transport of v2 probes: a command or a response per
+// bulk transfer.
+type bulkTransport struct {
+	r   reader
+	w   writer
+	buf []byte
+}
+
+func (t *bulkTransport) send(ctx context.Context, cmd []byte) error {
+	_, err := t.w.WriteContext(ctx, cmd)
+	return err
+}
+
+func (t *bulkTransport) receive(ctx context.Context) ([]byte, error) {
+	n, err := t.r.ReadContext(ctx, t.buf)
+	if err != nil {
+		return nil, err
+	}
+	return t.buf[:n], nil
+}
+
+// hidTransport is the transport of v1 probes: a command or a response per
+// HID report. The reports are sent on the interrupt OUT endpoint, or with
+// SET_REPORT requests if there's none.
+type hidTransport struct {
+	r    reader
+	w    writer
+	ctl  controller
+	intf uint16
+	// reportSize is the size of the reports, commands are padded to it.
+	reportSize int
+	buf        []byte
+}
+
+func newHIDTransport(r reader, w writer, ctl controller, intf, reportSize int) *hidTransport {
+	return &hidTransport{r: r, w: w, ctl: ctl, intf: uint16(intf), reportSize: reportSize, buf: make([]byte, reportSize)}
+}
+
+func (t *hidTransport) send(ctx context.Context, cmd []byte) error {
+	report := make([]byte, t.reportSize)
+	copy(report, cmd)
+	if t.w != nil {
+		_, err := t.w.WriteContext(ctx, report)
+		return err
+	}
+	_, err := t.ctl.Control(rTypeSetReport, reqSetReport, reportOutput<<8, t.intf, report)
+	return err
+}
+
+func (t *hidTransport) receive(ctx context.Context) ([]byte, error) {
+	n, err := t.r.ReadContext(ctx, t.buf)
+	if err != nil {
+		return nil, err
+	}
+	return t.buf[:n], nil
+}
+
+// Probe is an open CMSIS-DAP probe. The methods of Probe can be called
+// concurrently, the commands are executed one at a time.
+type Probe struct {
+	// Version is the version of the transport: 1 for HID reports, 2 for
+	// bulk endpoints.
+	Version int
+	// PacketSize is the maximum size of the commands and responses.
+	PacketSize int
+
+	mu      sync.Mutex
+	t       transport
+	release func()
+}
+
+// do sends a command and returns its response, starting with the command
+// ID.
+func (p *Probe) do(ctx context.Context, cmd []byte) ([]byte, error) {
+	if len(cmd) > p.PacketSize {
+		return nil, fmt.Errorf("%s: command of %d bytes, the packet size is %d", command(cmd[0]), len(cmd), p.PacketSize)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.t.send(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("%s: %v", command(cmd[0]), err)
+	}
+	for i := 0; i < maxStaleResponses; i++ {
+		resp, err := p.t.receive(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", command(cmd[0]), err)
+		}
+		switch {
+		case len(resp) == 0:
+			continue
+		case resp[0] == cmd[0]:
+			return resp, nil
+		case resp[0] == cmdInvalid:
+			return nil, fmt.Errorf("%s: %w", command(cmd[0]), ErrUnsupported)
+		}
+	}
+	return nil, fmt.Errorf("%s: no valid response", command(cmd[0]))
+}
+
+// Close releases the interface of the probe.
+func (p *Probe) Close() error {
+	if p.release != nil {
+		p.release()
+		p.release = nil
+	}
+	return nil
+}
+
+// dapInterface is the CMSIS-DAP interface of a probe.
+type dapInterface struct {
+	alt     *gousb.InterfaceSetting
+	version int
+	in, out *gousb.EndpointDesc
+}
+
+// findInterface returns the CMSIS-DAP interface of the configuration,
+// preferring the bulk interface of v2. name returns the name of an
+// interface setting, product is the name of the device.
+func findInterface(desc gousb.ConfigDesc, product string, name func(intf, alt int) string) (*dapInterface, error) {
+	var hid *dapInterface
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			named := strings.Contains(name(alt.Number, alt.Alternate), interfaceName)
+			switch {
+			case alt.Class == gousb.ClassVendorSpec && named:
+				// The DAP endpoints are the first OUT and IN endpoints, an
+				// optional second IN endpoint carries the SWO trace.
+				var in, out *gousb.EndpointDesc
+				for _, ep := range alt.Endpoints {
+					if ep.TransferType != gousb.TransferTypeBulk {
+						continue
+					}
+					ep := ep
+					switch {
+					case ep.Direction == gousb.EndpointDirectionIn && (in == nil || ep.Number < in.Number):
+						in = &ep
+					case ep.Direction == gousb.EndpointDirectionOut && (out == nil || ep.Number < out.Number):
+						out = &ep
+					}
+				}
+				if in != nil && out != nil {
+					alt := alt
+					return &dapInterface{alt: &alt, version: 2, in: in, out: out}, nil
+				}
+			case alt.Class == gousb.ClassHID && hid == nil && (named || strings.Contains(product, interfaceName)):
+				var in, out *gousb.EndpointDesc
+				for _, ep := range alt.Endpoints {
+					if ep.TransferType != gousb.TransferTypeInterrupt {
+						continue
+					}
+					ep := ep
+					if ep.Direction == gousb.EndpointDirectionIn {
+						in = &ep
+					} else {
+						out = &ep
+					}
+				}
+				if in != nil {
+					alt := alt
+					hid = &dapInterface{alt: &alt, version: 1, in: in, out: out}
+				}
+			}
+		}
+	}
+	if hid == nil {
+		return nil, fmt.Errorf("%s has no CMSIS-DAP interface", desc)
+	}
+	return hid, nil
+}
+
+// newProbe returns a probe using the transport, with the packet size
+// reported by the probe if it's smaller than the default.
+func newProbe(ctx context.Context, version int, t transport, defaultSize int) (*Probe, error) {
+	p := &Probe{Version: version, PacketSize: defaultSize, t: t}
+	size, err := p.infoUint16(ctx, InfoPacketSize)
+	if err != nil {
+		return nil, err
+	}
+	if size > 0 && (int(size) < defaultSize || version == 2) {
+		p.PacketSize = int(size)
+	}
+	if bt, ok := t.(*bulkTransport); ok {
+		// A response filling the buffer ends the bulk transfer, the probes
+		// don't send zero-length packets.
+		bt.buf = make([]byte, p.PacketSize)
+	}
+	return p, nil
+}
+
+// Open claims the CMSIS-DAP interface in the active configuration of the
+// device and reads the packet size of the probe. The Probe should be
+// Close()d after use.
+func Open(dev *gousb.Device) (*Probe, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	product, _ := dev.Product()
+	d, err := findInterface(desc, product, func(intf, alt int) string {
+		s, _ := dev.InterfaceDescription(cfgNum, intf, alt)
+		return s
+	})
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, d.alt)
+	if err != nil {
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(intf, d.in, d.out)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	var t transport
+	size := d.in.MaxPacketSize
+	if d.version == 2 {
+		// The packet size of v2 probes may exceed the packet size of the
+		// endpoints, use the largest possible buffer until it's known.
+		size = maxPacketSize
+		t = &bulkTransport{r: in, w: out, buf: make([]byte, size)}
+	} else {
+		var w writer
+		if out != nil {
+			w = out
+		}
+		t = newHIDTransport(in, w, dev, d.alt.Number, size)
+	}
+	p, err := newProbe(context.Background(), d.version, t, size)
+	if err != nil {
+		cfg.Close()
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	p.release = func() { cfg.Close() }
+	return p, nil
+}
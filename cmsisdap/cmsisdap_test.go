@@ -0,0 +1,272 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmsisdap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func newSimBulkProbe(t *testing.T, packetSize int) (*Probe, *simProbe) {
+	t.Helper()
+	sim := newSimProbe(packetSize, 0)
+	p, err := newProbe(context.Background(), 2, &bulkTransport{r: sim, w: sim, buf: make([]byte, maxPacketSize)}, maxPacketSize)
+	if err != nil {
+		t.Fatalf("newProbe(): %v", err)
+	}
+	sim.commands = nil
+	return p, sim
+}
+
+// newFakeSetReport returns a control endpoint that forwards the
+// SET_REPORT requests to the simulated probe.
+func newFakeSetReport(sim *simProbe) *fakeusb.Control {
+	return &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+		if r.RType != 0x21 || r.Request != reqSetReport || r.Val != 0x0200 || r.Idx != 3 {
+			return 0, gousb.ErrorPipe
+		}
+		return sim.WriteContext(context.Background(), data)
+	}}
+}
+
+func TestNewProbe(t *testing.T) {
+	ctx := context.Background()
+
+	p, sim := newSimBulkProbe(t, 512)
+	if p.PacketSize != 512 {
+		t.Errorf("v2 PacketSize: got %d, want 512", p.PacketSize)
+	}
+	if got := len(p.t.(*bulkTransport).buf); got != 512 {
+		t.Errorf("v2 read buffer: got %d bytes, want 512", got)
+	}
+	if _, err := p.Connect(ctx, PortSWD); err != nil {
+		t.Fatalf("Connect(): %v", err)
+	}
+	if want := [][]byte{{0x02, 0x01}}; !reflect.DeepEqual(sim.commands, want) {
+		t.Errorf("v2 commands: got % x, want % x", sim.commands, want)
+	}
+
+	// A v1 probe reporting a packet size larger than its reports.
+	sim = newSimProbe(1024, 64)
+	p, err := newProbe(ctx, 1, newHIDTransport(sim, sim, nil, 3, 64), 64)
+	if err != nil {
+		t.Fatalf("newProbe(): %v", err)
+	}
+	if p.PacketSize != 64 {
+		t.Errorf("v1 PacketSize: got %d, want 64", p.PacketSize)
+	}
+	if len(sim.commands) != 1 || len(sim.commands[0]) != 64 {
+		t.Errorf("v1 commands: got % x, want one report of 64 bytes", sim.commands)
+	}
+
+	// A v1 probe without interrupt OUT endpoint.
+	sim = newSimProbe(64, 64)
+	ctl := newFakeSetReport(sim)
+	p, err = newProbe(ctx, 1, newHIDTransport(sim, nil, ctl, 3, 64), 64)
+	if err != nil {
+		t.Fatalf("newProbe(): %v", err)
+	}
+	if got, err := p.Connect(ctx, PortDefault); err != nil || got != PortSWD {
+		t.Errorf("Connect(PortDefault): got %s, %v, want SWD, nil", got, err)
+	}
+	if got := len(ctl.Requests()); got != 2 {
+		t.Errorf("SET_REPORT requests: got %d, want 2", got)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	ctx := context.Background()
+	p, _ := newSimBulkProbe(t, 64)
+	for _, tc := range []struct {
+		id   InfoID
+		want string
+	}{
+		{InfoProduct, "Simulated CMSIS-DAP"},
+		{InfoProtocolVersion, "2.1.0"},
+		{InfoSerialNumber, ""},
+	} {
+		got, err := p.InfoString(ctx, tc.id)
+		if err != nil {
+			t.Errorf("InfoString(0x%02x): %v", tc.id, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("InfoString(0x%02x): got %q, want %q", tc.id, got, tc.want)
+		}
+	}
+	caps, err := p.Capabilities(ctx)
+	if err != nil {
+		t.Fatalf("Capabilities(): %v", err)
+	}
+	if want := CapSWD | CapJTAG; caps != want {
+		t.Errorf("Capabilities(): got 0x%02x, want 0x%02x", caps, want)
+	}
+}
+
+func TestResponses(t *testing.T) {
+	ctx := context.Background()
+	p, sim := newSimBulkProbe(t, 64)
+
+	// A response to an earlier command is skipped.
+	sim.responses <- []byte{byte(cmdSWJClock), statusOK}
+	if _, err := p.Connect(ctx, PortJTAG); err != nil {
+		t.Errorf("Connect(): %v", err)
+	}
+	if len(sim.responses) != 0 {
+		t.Errorf("responses left: got %d, want 0", len(sim.responses))
+	}
+
+	if _, err := p.do(ctx, []byte{0x7f}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("do(0x7f): got error %v, want %v", err, ErrUnsupported)
+	}
+	if _, err := p.do(ctx, make([]byte, 65)); err == nil {
+		t.Errorf("do() of 65 bytes: got nil error, want error")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	p, sim := newSimBulkProbe(t, 64)
+
+	if err := p.SetClock(ctx, 4000000); err != nil || sim.clock != 4000000 {
+		t.Errorf("SetClock(4MHz): got %v, probe clock %d", err, sim.clock)
+	}
+	if err := p.SWJSequence(ctx, 16, []byte{0x9e, 0xe7, 0xff}); err != nil {
+		t.Errorf("SWJSequence(): %v", err)
+	}
+	if err := p.SWJSequence(ctx, 257, make([]byte, 33)); err == nil {
+		t.Errorf("SWJSequence() of 257 bits: got nil error, want error")
+	}
+	if err := p.SWDConfigure(ctx, 2, true); err != nil {
+		t.Errorf("SWDConfigure(): %v", err)
+	}
+	in, err := p.SWDSequences(ctx, []SWDSequence{
+		{Cycles: 64, Data: bytes.Repeat([]byte{0xff}, 8)},
+		{Cycles: 12, Input: true},
+		{Cycles: 3, Data: []byte{0x05}},
+	})
+	if err != nil {
+		t.Fatalf("SWDSequences(): %v", err)
+	}
+	if want := [][]byte{{0xa5, 0xa5}}; !reflect.DeepEqual(in, want) {
+		t.Errorf("SWDSequences(): got % x, want % x", in, want)
+	}
+	tdo, err := p.JTAGSequences(ctx, []JTAGSequence{
+		{Cycles: 5, TMS: true, TDI: []byte{0x1f}},
+		{Cycles: 10, Capture: true, TDI: []byte{0x34, 0x02}},
+	})
+	if err != nil {
+		t.Fatalf("JTAGSequences(): %v", err)
+	}
+	if want := [][]byte{{0x34, 0x02}}; !reflect.DeepEqual(tdo, want) {
+		t.Errorf("JTAGSequences(): got % x, want % x", tdo, want)
+	}
+	if id, err := p.JTAGIDCode(ctx, 0); err != nil || id != sim.target.idcode {
+		t.Errorf("JTAGIDCode(0): got 0x%08x, %v, want 0x%08x, nil", id, err, sim.target.idcode)
+	}
+	if ok, err := p.ResetTarget(ctx); err != nil || !ok {
+		t.Errorf("ResetTarget(): got %v, %v, want true, nil", ok, err)
+	}
+
+	want := [][]byte{
+		{0x11, 0x00, 0x09, 0x3d, 0x00},
+		{0x12, 0x10, 0x9e, 0xe7},
+		{0x13, 0x05},
+		{0x1d, 0x03, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x8c, 0x03, 0x05},
+		{0x14, 0x02, 0x45, 0x1f, 0x8a, 0x34, 0x02},
+		{0x16, 0x00},
+		{0x0a},
+	}
+	if !reflect.DeepEqual(sim.commands, want) {
+		t.Errorf("commands: got % x, want % x", sim.commands, want)
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	bulk := func(addr int) gousb.EndpointDesc {
+		dir := gousb.EndpointDirectionOut
+		if addr&0x80 != 0 {
+			dir = gousb.EndpointDirectionIn
+		}
+		return gousb.EndpointDesc{Address: gousb.EndpointAddress(addr), Number: addr & 0xf, Direction: dir, TransferType: gousb.TransferTypeBulk}
+	}
+	intr := func(addr int) gousb.EndpointDesc {
+		ep := bulk(addr)
+		ep.TransferType = gousb.TransferTypeInterrupt
+		return ep
+	}
+	setting := func(num int, class gousb.Class, eps ...gousb.EndpointDesc) gousb.InterfaceDesc {
+		alt := gousb.InterfaceSetting{Number: num, Class: class, Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{}}
+		for _, ep := range eps {
+			alt.Endpoints[ep.Address] = ep
+		}
+		return gousb.InterfaceDesc{Number: num, AltSettings: []gousb.InterfaceSetting{alt}}
+	}
+	cfg := gousb.ConfigDesc{Interfaces: []gousb.InterfaceDesc{
+		setting(0, gousb.ClassHID, intr(0x81), intr(0x01)),
+		// The DAP endpoints of v2, and the SWO endpoint.
+		setting(1, gousb.ClassVendorSpec, bulk(0x02), bulk(0x83), bulk(0x84)),
+		setting(2, gousb.ClassComm, intr(0x85)),
+	}}
+	for _, tc := range []struct {
+		desc        string
+		product     string
+		names       map[int]string
+		wantNum     int
+		wantVersion int
+		wantErr     bool
+	}{
+		{
+			desc:        "v2",
+			names:       map[int]string{0: "CMSIS-DAP v1", 1: "Acme CMSIS-DAP v2"},
+			wantNum:     1,
+			wantVersion: 2,
+		},
+		{
+			desc:        "v1 named by the product",
+			product:     "Acme CMSIS-DAP",
+			names:       map[int]string{1: "Acme debug"},
+			wantNum:     0,
+			wantVersion: 1,
+		},
+		{
+			desc:    "no CMSIS-DAP interface",
+			product: "Acme",
+			names:   map[int]string{1: "Acme debug"},
+			wantErr: true,
+		},
+	} {
+		d, err := findInterface(cfg, tc.product, func(intf, alt int) string { return tc.names[intf] })
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: findInterface(): got error %v, want error %v", tc.desc, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if d.alt.Number != tc.wantNum || d.version != tc.wantVersion {
+			t.Errorf("%s: findInterface(): got interface %d v%d, want %d v%d", tc.desc, d.alt.Number, d.version, tc.wantNum, tc.wantVersion)
+		}
+		if tc.wantVersion == 2 && (d.in.Address != 0x83 || d.out.Address != 0x02) {
+			t.Errorf("%s: findInterface(): got endpoints %s %s, want 0x83 0x02", tc.desc, d.in.Address, d.out.Address)
+		}
+	}
+}
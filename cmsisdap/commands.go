@@ -0,0 +1,405 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmsisdap
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// command is the ID of a CMSIS-DAP command.
+type command uint8
+
+const (
+	cmdInfo              command = 0x00
+	cmdHostStatus        command = 0x01
+	cmdConnect           command = 0x02
+	cmdDisconnect        command = 0x03
+	cmdTransferConfigure command = 0x04
+	cmdTransfer          command = 0x05
+	cmdTransferBlock     command = 0x06
+	cmdResetTarget       command = 0x0a
+	cmdSWJClock          command = 0x11
+	cmdSWJSequence       command = 0x12
+	cmdSWDConfigure      command = 0x13
+	cmdJTAGSequence      command = 0x14
+	cmdJTAGConfigure     command = 0x15
+	cmdJTAGIDCode        command = 0x16
+	cmdSWDSequence       command = 0x1d
+	// cmdInvalid is the response to unsupported commands.
+	cmdInvalid = 0xff
+)
+
+var commandNames = map[command]string{
+	cmdInfo:              "DAP_Info",
+	cmdHostStatus:        "DAP_HostStatus",
+	cmdConnect:           "DAP_Connect",
+	cmdDisconnect:        "DAP_Disconnect",
+	cmdTransferConfigure: "DAP_TransferConfigure",
+	cmdTransfer:          "DAP_Transfer",
+	cmdTransferBlock:     "DAP_TransferBlock",
+	cmdResetTarget:       "DAP_ResetTarget",
+	cmdSWJClock:          "DAP_SWJ_Clock",
+	cmdSWJSequence:       "DAP_SWJ_Sequence",
+	cmdSWDConfigure:      "DAP_SWD_Configure",
+	cmdJTAGSequence:      "DAP_JTAG_Sequence",
+	cmdJTAGConfigure:     "DAP_JTAG_Configure",
+	cmdJTAGIDCode:        "DAP_JTAG_IDCODE",
+	cmdSWDSequence:       "DAP_SWD_Sequence",
+}
+
+func (c command) String() string {
+	if s, ok := commandNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("command 0x%02x", uint8(c))
+}
+
+// statusOK is the status of successful commands, DAP_ERROR otherwise.
+const statusOK = 0x00
+
+// maxPacketSize is the largest packet size a probe can report.
+const maxPacketSize = 0xffff
+
+// status sends a command whose response is a status, and checks it.
+func (p *Probe) status(ctx context.Context, cmd ...byte) ([]byte, error) {
+	resp, err := p.do(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("%s: response too short: %d bytes", command(cmd[0]), len(resp))
+	}
+	if resp[1] != statusOK {
+		return nil, fmt.Errorf("%s: failed with status 0x%02x", command(cmd[0]), resp[1])
+	}
+	return resp[2:], nil
+}
+
+// InfoID selects the information returned by Info.
+type InfoID uint8
+
+// Information about the probe.
+const (
+	InfoVendor                 InfoID = 0x01
+	InfoProduct                InfoID = 0x02
+	InfoSerialNumber           InfoID = 0x03
+	InfoProtocolVersion        InfoID = 0x04
+	InfoTargetDeviceVendor     InfoID = 0x05
+	InfoTargetDeviceName       InfoID = 0x06
+	InfoTargetBoardVendor      InfoID = 0x07
+	InfoTargetBoardName        InfoID = 0x08
+	InfoProductFirmwareVersion InfoID = 0x09
+	InfoCapabilities           InfoID = 0xf0
+	InfoTestDomainTimer        InfoID = 0xf1
+	InfoSWOTraceBufferSize     InfoID = 0xfd
+	InfoPacketCount            InfoID = 0xfe
+	InfoPacketSize             InfoID = 0xff
+)
+
+// Info returns the raw information about the probe, empty if the probe
+// doesn't provide it.
+func (p *Probe) Info(ctx context.Context, id InfoID) ([]byte, error) {
+	resp, err := p.do(ctx, []byte{byte(cmdInfo), byte(id)})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 || len(resp) < 2+int(resp[1]) {
+		return nil, fmt.Errorf("%s: invalid response of %d bytes", cmdInfo, len(resp))
+	}
+	return resp[2 : 2+int(resp[1])], nil
+}
+
+// InfoString returns the information about the probe given as a string,
+// such as InfoProduct or InfoProtocolVersion.
+func (p *Probe) InfoString(ctx context.Context, id InfoID) (string, error) {
+	b, err := p.Info(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	for len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+	return string(b), nil
+}
+
+func (p *Probe) infoUint16(ctx context.Context, id InfoID) (uint16, error) {
+	b, err := p.Info(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) < 2 {
+		return 0, nil
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+// Capabilities are the features of the probe.
+type Capabilities uint8
+
+// Capabilities.
+const (
+	CapSWD Capabilities = 1 << iota
+	CapJTAG
+	CapSWOUART
+	CapSWOManchester
+	CapAtomic
+	CapTestDomainTimer
+	CapSWOStreaming
+	CapUART
+)
+
+// Capabilities returns the features of the probe.
+func (p *Probe) Capabilities(ctx context.Context) (Capabilities, error) {
+	b, err := p.Info(ctx, InfoCapabilities)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	return Capabilities(b[0]), nil
+}
+
+// Port is the debug port of the target.
+type Port uint8
+
+// Ports.
+const (
+	// PortDefault selects the default port configured in the probe.
+	PortDefault Port = 0
+	PortSWD     Port = 1
+	PortJTAG    Port = 2
+)
+
+func (p Port) String() string {
+	switch p {
+	case PortDefault:
+		return "default"
+	case PortSWD:
+		return "SWD"
+	case PortJTAG:
+		return "JTAG"
+	}
+	return fmt.Sprintf("port %d", uint8(p))
+}
+
+// Connect configures the pins of the probe for the port, and returns the
+// port selected.
+func (p *Probe) Connect(ctx context.Context, port Port) (Port, error) {
+	resp, err := p.do(ctx, []byte{byte(cmdConnect), byte(port)})
+	if err != nil {
+		return 0, err
+	}
+	if len(resp) < 2 || resp[1] == 0 {
+		return 0, fmt.Errorf("%s: failed to connect the %s port", cmdConnect, port)
+	}
+	return Port(resp[1]), nil
+}
+
+// Disconnect releases the pins of the probe.
+func (p *Probe) Disconnect(ctx context.Context) error {
+	_, err := p.status(ctx, byte(cmdDisconnect))
+	return err
+}
+
+// Host status indicators.
+const (
+	StatusConnected = 0
+	StatusRunning   = 1
+)
+
+// HostStatus sets a status indicator of the probe, usually a LED.
+func (p *Probe) HostStatus(ctx context.Context, indicator int, on bool) error {
+	v := byte(0)
+	if on {
+		v = 1
+	}
+	_, err := p.status(ctx, byte(cmdHostStatus), byte(indicator), v)
+	return err
+}
+
+// ResetTarget executes the reset sequence of the target programmed in the
+// probe. It returns false if the probe has none.
+func (p *Probe) ResetTarget(ctx context.Context) (bool, error) {
+	resp, err := p.status(ctx, byte(cmdResetTarget))
+	if err != nil {
+		return false, err
+	}
+	return len(resp) > 0 && resp[0] == 1, nil
+}
+
+// SetClock sets the frequency of the SWD or JTAG clock, in Hz.
+func (p *Probe) SetClock(ctx context.Context, hz uint32) error {
+	cmd := []byte{byte(cmdSWJClock), 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(cmd[1:], hz)
+	_, err := p.status(ctx, cmd...)
+	return err
+}
+
+// bitBytes returns the number of bytes holding n bits.
+func bitBytes(n int) int {
+	return (n + 7) / 8
+}
+
+// SWJSequence clocks out bits, 1 to 256, on the SWDIO/TMS pin, least
+// significant bit of the first byte first. It's used to switch the debug
+// port between JTAG and SWD.
+func (p *Probe) SWJSequence(ctx context.Context, bits int, data []byte) error {
+	if bits < 1 || bits > 256 || len(data) < bitBytes(bits) {
+		return fmt.Errorf("%s: invalid sequence of %d bits with %d bytes", cmdSWJSequence, bits, len(data))
+	}
+	cmd := append([]byte{byte(cmdSWJSequence), byte(bits)}, data[:bitBytes(bits)]...)
+	_, err := p.status(ctx, cmd...)
+	return err
+}
+
+// SWDConfigure sets the turnaround period of SWD, 1 to 4 clock cycles, and
+// whether a data phase is generated on WAIT and FAULT acknowledges.
+func (p *Probe) SWDConfigure(ctx context.Context, turnaround int, dataPhase bool) error {
+	if turnaround < 1 || turnaround > 4 {
+		return fmt.Errorf("%s: invalid turnaround of %d cycles", cmdSWDConfigure, turnaround)
+	}
+	cfg := byte(turnaround - 1)
+	if dataPhase {
+		cfg |= 0x04
+	}
+	_, err := p.status(ctx, byte(cmdSWDConfigure), cfg)
+	return err
+}
+
+// maxSequenceCycles is the number of clock cycles of a SWD or JTAG
+// sequence.
+const maxSequenceCycles = 64
+
+// SWDSequence is a sequence of clock cycles on the SWDIO pin.
+type SWDSequence struct {
+	// Cycles is the number of clock cycles, 1 to 64.
+	Cycles int
+	// Input captures SWDIO instead of driving it.
+	Input bool
+	// Data are the bits driven, least significant bit of the first byte
+	// first.
+	Data []byte
+}
+
+// SWDSequences runs the sequences and returns the data captured by the
+// input sequences.
+func (p *Probe) SWDSequences(ctx context.Context, seqs []SWDSequence) ([][]byte, error) {
+	cmd := []byte{byte(cmdSWDSequence), byte(len(seqs))}
+	for i, s := range seqs {
+		if s.Cycles < 1 || s.Cycles > maxSequenceCycles || !s.Input && len(s.Data) < bitBytes(s.Cycles) {
+			return nil, fmt.Errorf("%s: invalid sequence %d of %d cycles", cmdSWDSequence, i, s.Cycles)
+		}
+		info := byte(s.Cycles % maxSequenceCycles)
+		if s.Input {
+			cmd = append(cmd, info|0x80)
+			continue
+		}
+		cmd = append(cmd, info)
+		cmd = append(cmd, s.Data[:bitBytes(s.Cycles)]...)
+	}
+	resp, err := p.status(ctx, cmd...)
+	if err != nil {
+		return nil, err
+	}
+	var in [][]byte
+	for _, s := range seqs {
+		if !s.Input {
+			continue
+		}
+		n := bitBytes(s.Cycles)
+		if len(resp) < n {
+			return nil, fmt.Errorf("%s: response too short", cmdSWDSequence)
+		}
+		in = append(in, resp[:n:n])
+		resp = resp[n:]
+	}
+	return in, nil
+}
+
+// JTAGSequence is a sequence of clock cycles on the JTAG port.
+type JTAGSequence struct {
+	// Cycles is the number of clock cycles, 1 to 64.
+	Cycles int
+	// TMS is the value of TMS during the sequence.
+	TMS bool
+	// Capture captures the data on TDO.
+	Capture bool
+	// TDI are the bits driven on TDI, least significant bit of the first
+	// byte first.
+	TDI []byte
+}
+
+// JTAGSequences runs the sequences and returns the data captured on TDO
+// by the sequences with Capture set.
+func (p *Probe) JTAGSequences(ctx context.Context, seqs []JTAGSequence) ([][]byte, error) {
+	cmd := []byte{byte(cmdJTAGSequence), byte(len(seqs))}
+	for i, s := range seqs {
+		if s.Cycles < 1 || s.Cycles > maxSequenceCycles || len(s.TDI) < bitBytes(s.Cycles) {
+			return nil, fmt.Errorf("%s: invalid sequence %d of %d cycles", cmdJTAGSequence, i, s.Cycles)
+		}
+		info := byte(s.Cycles % maxSequenceCycles)
+		if s.TMS {
+			info |= 0x40
+		}
+		if s.Capture {
+			info |= 0x80
+		}
+		cmd = append(cmd, info)
+		cmd = append(cmd, s.TDI[:bitBytes(s.Cycles)]...)
+	}
+	resp, err := p.status(ctx, cmd...)
+	if err != nil {
+		return nil, err
+	}
+	var tdo [][]byte
+	for _, s := range seqs {
+		if !s.Capture {
+			continue
+		}
+		n := bitBytes(s.Cycles)
+		if len(resp) < n {
+			return nil, fmt.Errorf("%s: response too short", cmdJTAGSequence)
+		}
+		tdo = append(tdo, resp[:n:n])
+		resp = resp[n:]
+	}
+	return tdo, nil
+}
+
+// JTAGConfigure sets the instruction register lengths of the devices of
+// the JTAG chain, the device closest to TDI first.
+func (p *Probe) JTAGConfigure(ctx context.Context, irLengths []int) error {
+	cmd := []byte{byte(cmdJTAGConfigure), byte(len(irLengths))}
+	for _, l := range irLengths {
+		cmd = append(cmd, byte(l))
+	}
+	_, err := p.status(ctx, cmd...)
+	return err
+}
+
+// JTAGIDCode returns the IDCODE of a device of the JTAG chain.
+func (p *Probe) JTAGIDCode(ctx context.Context, index int) (uint32, error) {
+	resp, err := p.status(ctx, byte(cmdJTAGIDCode), byte(index))
+	if err != nil {
+		return 0, err
+	}
+	if len(resp) < 4 {
+		return 0, fmt.Errorf("%s: response too short", cmdJTAGIDCode)
+	}
+	return binary.LittleEndian.Uint32(resp), nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmsisdap
+
+import (
+	"context"
+	"encoding/binary"
+)
+
+// simTarget is a simulated SWD target: a debug port (DP) and a MEM-AP
+// accessing a memory of words.
+type simTarget struct {
+	idcode   uint32
+	ctrlStat uint32
+	sel      uint32
+	csw, tar uint32
+	rdbuff   uint32
+	mem      map[uint32]uint32
+	// fault is an address whose accesses are acknowledged with FAULT.
+	fault uint32
+}
+
+func newSimTarget() *simTarget {
+	return &simTarget{idcode: 0x2ba01477, mem: map[uint32]uint32{}, fault: 0xffffffff}
+}
+
+// DP and AP registers.
+const (
+	dpIDCode   = 0x0
+	dpCtrlStat = 0x4
+	dpSelect   = 0x8
+	dpRdBuff   = 0xc
+	apCSW      = 0x00
+	apTAR      = 0x04
+	apDRW      = 0x0c
+	apIDR      = 0xfc
+	// ctrlStatPowerUp are the power-up requests of CTRL/STAT, acknowledged
+	// in the next bits.
+	ctrlStatPowerUp = 1<<30 | 1<<28
+	// cswIncrement is the single auto-increment mode of CSW.
+	cswIncrement = 0x10
+)
+
+// access executes a transfer and returns the value read and the
+// acknowledge.
+func (t *simTarget) access(ap, read bool, addr uint8, v uint32) (uint32, uint8) {
+	if !ap {
+		switch {
+		case read && addr == dpIDCode:
+			return t.idcode, AckOK
+		case addr == dpCtrlStat && read:
+			return t.ctrlStat, AckOK
+		case addr == dpCtrlStat:
+			t.ctrlStat = v | (v&ctrlStatPowerUp)<<1
+		case addr == dpSelect && !read:
+			t.sel = v
+		case addr == dpRdBuff && read:
+			return t.rdbuff, AckOK
+		}
+		return 0, AckOK
+	}
+	if t.ctrlStat&ctrlStatPowerUp != ctrlStatPowerUp {
+		return 0, AckFault
+	}
+	switch reg := t.sel&0xf0 | uint32(addr); {
+	case reg == apCSW:
+		if read {
+			t.rdbuff = t.csw
+		} else {
+			t.csw = v
+		}
+	case reg == apTAR:
+		if read {
+			t.rdbuff = t.tar
+		} else {
+			t.tar = v
+		}
+	case reg == apDRW:
+		if t.tar == t.fault {
+			return 0, AckFault
+		}
+		if read {
+			t.rdbuff = t.mem[t.tar]
+		} else {
+			t.mem[t.tar] = v
+		}
+		if t.csw&0x30 == cswIncrement {
+			t.tar += 4
+		}
+	case reg == apIDR:
+		t.rdbuff = 0x24770011
+	}
+	return t.rdbuff, AckOK
+}
+
+// simProbe is a simulated CMSIS-DAP probe driving a simTarget. It
+// implements the endpoints of the probe: commands are written to it, and
+// the responses read from it.
+type simProbe struct {
+	target     *simTarget
+	packetSize int
+	// reportSize pads the responses as HID reports if set.
+	reportSize int
+
+	port      Port
+	clock     uint32
+	matchMask uint32
+	// commands are the commands received.
+	commands  [][]byte
+	responses chan []byte
+}
+
+func newSimProbe(packetSize, reportSize int) *simProbe {
+	return &simProbe{
+		target:     newSimTarget(),
+		packetSize: packetSize,
+		reportSize: reportSize,
+		matchMask:  0xffffffff,
+		responses:  make(chan []byte, 16),
+	}
+}
+
+func (s *simProbe) WriteContext(ctx context.Context, cmd []byte) (int, error) {
+	s.commands = append(s.commands, append([]byte(nil), cmd...))
+	resp := s.handle(cmd)
+	if s.reportSize > 0 {
+		resp = append(resp, make([]byte, s.reportSize-len(resp))...)
+	}
+	s.responses <- resp
+	return len(cmd), nil
+}
+
+func (s *simProbe) ReadContext(ctx context.Context, p []byte) (int, error) {
+	select {
+	case resp := <-s.responses:
+		return copy(p, resp), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (s *simProbe) handle(cmd []byte) []byte {
+	le := binary.LittleEndian
+	resp := []byte{cmd[0]}
+	switch command(cmd[0]) {
+	case cmdInfo:
+		var info []byte
+		switch InfoID(cmd[1]) {
+		case InfoProduct:
+			info = []byte("Simulated CMSIS-DAP\x00")
+		case InfoProtocolVersion:
+			info = []byte("2.1.0\x00")
+		case InfoCapabilities:
+			info = []byte{byte(CapSWD | CapJTAG)}
+		case InfoPacketSize:
+			info = []byte{byte(s.packetSize), byte(s.packetSize >> 8)}
+		}
+		return append(append(resp, byte(len(info))), info...)
+	case cmdConnect:
+		s.port = Port(cmd[1])
+		if s.port == PortDefault {
+			s.port = PortSWD
+		}
+		return append(resp, byte(s.port))
+	case cmdSWJClock:
+		s.clock = le.Uint32(cmd[1:])
+		return append(resp, statusOK)
+	case cmdDisconnect, cmdSWJSequence, cmdSWDConfigure, cmdJTAGConfigure, cmdTransferConfigure, cmdHostStatus:
+		return append(resp, statusOK)
+	case cmdResetTarget:
+		return append(resp, statusOK, 1)
+	case cmdJTAGIDCode:
+		resp = append(resp, statusOK, 0, 0, 0, 0)
+		le.PutUint32(resp[2:], s.target.idcode)
+		return resp
+	case cmdSWDSequence:
+		resp = append(resp, statusOK)
+		b := cmd[2:]
+		for i := 0; i < int(cmd[1]); i++ {
+			n := bitBytes(int(b[0]&0x3f) + 64*btoi(b[0]&0x3f == 0))
+			if b[0]&0x80 != 0 {
+				// SWDIO reads as a pattern.
+				for j := 0; j < n; j++ {
+					resp = append(resp, 0xa5)
+				}
+				b = b[1:]
+			} else {
+				b = b[1+n:]
+			}
+		}
+		return resp
+	case cmdJTAGSequence:
+		resp = append(resp, statusOK)
+		b := cmd[2:]
+		for i := 0; i < int(cmd[1]); i++ {
+			n := bitBytes(int(b[0]&0x3f) + 64*btoi(b[0]&0x3f == 0))
+			if b[0]&0x80 != 0 {
+				// TDO is looped back to TDI.
+				resp = append(resp, b[1:1+n]...)
+			}
+			b = b[1+n:]
+		}
+		return resp
+	case cmdTransfer:
+		resp = append(resp, 0, 0)
+		b := cmd[3:]
+		done, ack := 0, uint8(AckOK)
+		for i := 0; i < int(cmd[2]); i++ {
+			req := b[0]
+			b = b[1:]
+			var v uint32
+			if req&reqRead == 0 || req&reqMatch != 0 {
+				v = le.Uint32(b)
+				b = b[4:]
+			}
+			if req&reqMatchMask != 0 {
+				s.matchMask = v
+				done++
+				continue
+			}
+			var got uint32
+			got, ack = s.target.access(req&reqAP != 0, req&reqRead != 0, req&reqAddrMask, v)
+			if ack != AckOK {
+				break
+			}
+			if req&reqMatch != 0 && got&s.matchMask != v {
+				ack |= respMismatch
+				break
+			}
+			if req&reqRead != 0 && req&reqMatch == 0 {
+				resp = append(resp, 0, 0, 0, 0)
+				le.PutUint32(resp[len(resp)-4:], got)
+			}
+			done++
+		}
+		resp[1], resp[2] = byte(done), ack
+		return resp
+	case cmdTransferBlock:
+		resp = append(resp, 0, 0, 0)
+		count, req := int(le.Uint16(cmd[2:])), cmd[4]
+		b := cmd[5:]
+		done, ack := 0, uint8(AckOK)
+		for ; done < count; done++ {
+			var v uint32
+			if req&reqRead == 0 {
+				v = le.Uint32(b)
+				b = b[4:]
+			}
+			var got uint32
+			if got, ack = s.target.access(req&reqAP != 0, req&reqRead != 0, req&reqAddrMask, v); ack != AckOK {
+				break
+			}
+			if req&reqRead != 0 {
+				resp = append(resp, 0, 0, 0, 0)
+				le.PutUint32(resp[len(resp)-4:], got)
+			}
+		}
+		le.PutUint16(resp[1:], uint16(done))
+		resp[3] = ack
+		return resp
+	}
+	return []byte{cmdInvalid}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
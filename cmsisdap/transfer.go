@@ -0,0 +1,308 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmsisdap
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// Acknowledges of the transfers.
+const (
+	AckOK    = 0x1
+	AckWait  = 0x2
+	AckFault = 0x4
+	// AckNoAck is returned when the target doesn't respond on SWD.
+	AckNoAck = 0x7
+)
+
+// Bits of the transfer responses.
+const (
+	respAckMask       = 0x07
+	respProtocolError = 0x08
+	respMismatch      = 0x10
+)
+
+// Bits of the transfer requests.
+const (
+	reqAP        = 0x01
+	reqRead      = 0x02
+	reqAddrMask  = 0x0c
+	reqMatch     = 0x10
+	reqMatchMask = 0x20
+)
+
+// Request is a read or write of a register of the debug port (DP) or of
+// the selected access port (AP) of the target.
+type Request struct {
+	// AP selects the access port, the debug port otherwise.
+	AP bool
+	// Read reads the register, it's written otherwise.
+	Read bool
+	// Addr is the address of the register: 0x0, 0x4, 0x8 or 0xc.
+	Addr uint8
+	// Value is the value written, or the value expected by Match.
+	Value uint32
+	// Match reads the register until its value, masked with the match
+	// mask, is Value.
+	Match bool
+	// MatchMask writes Value to the match mask of the probe instead of the
+	// register.
+	MatchMask bool
+}
+
+func (r *Request) byte() byte {
+	b := r.Addr & reqAddrMask
+	if r.AP {
+		b |= reqAP
+	}
+	if r.Read {
+		b |= reqRead
+	}
+	if r.Match {
+		b |= reqMatch | reqRead
+	}
+	if r.MatchMask {
+		b |= reqMatchMask
+	}
+	return b
+}
+
+// reads returns true if the request returns a value.
+func (r *Request) reads() bool {
+	return r.Read && !r.Match
+}
+
+// TransferError is returned when a transfer is not acknowledged with
+// AckOK.
+type TransferError struct {
+	// Index is the index of the request that failed.
+	Index int
+	// Ack is the acknowledge of the target.
+	Ack uint8
+	// ProtocolError is set on an SWD parity error.
+	ProtocolError bool
+	// Mismatch is set if a Match request timed out.
+	Mismatch bool
+}
+
+func (e *TransferError) Error() string {
+	var what string
+	switch {
+	case e.ProtocolError:
+		what = "SWD protocol error"
+	case e.Mismatch:
+		what = "value mismatch"
+	case e.Ack == AckWait:
+		what = "WAIT"
+	case e.Ack == AckFault:
+		what = "FAULT"
+	case e.Ack == AckNoAck:
+		what = "no acknowledge"
+	default:
+		what = fmt.Sprintf("acknowledge 0x%x", e.Ack)
+	}
+	return fmt.Sprintf("transfer %d failed: %s", e.Index, what)
+}
+
+// transferError returns the error of a transfer response, or nil.
+func transferError(index int, resp byte) error {
+	if resp&respAckMask == AckOK && resp&(respProtocolError|respMismatch) == 0 {
+		return nil
+	}
+	return &TransferError{
+		Index:         index,
+		Ack:           resp & respAckMask,
+		ProtocolError: resp&respProtocolError != 0,
+		Mismatch:      resp&respMismatch != 0,
+	}
+}
+
+// Transfer executes the requests on the debug port dap, 0 for SWD, the
+// index in the JTAG chain otherwise, and returns the values read. The
+// requests are sent in as many commands as needed. On failure, the values
+// read before the failed request are returned with a *TransferError.
+func (p *Probe) Transfer(ctx context.Context, dap uint8, reqs []Request) ([]uint32, error) {
+	var values []uint32
+	for start := 0; start < len(reqs); {
+		cmd := []byte{byte(cmdTransfer), dap, 0}
+		// Size of the response: command, count, response and the values.
+		respSize := 3
+		end := start
+		for ; end < len(reqs) && end-start < 0xff; end++ {
+			r := &reqs[end]
+			size := 1
+			if !r.reads() {
+				size += 4
+			}
+			if len(cmd)+size > p.PacketSize || r.reads() && respSize+4 > p.PacketSize {
+				break
+			}
+			cmd = append(cmd, r.byte())
+			if !r.reads() {
+				cmd = append(cmd, 0, 0, 0, 0)
+				binary.LittleEndian.PutUint32(cmd[len(cmd)-4:], r.Value)
+			}
+			if r.reads() {
+				respSize += 4
+			}
+		}
+		if end == start {
+			return values, fmt.Errorf("%s: packet size %d too small", cmdTransfer, p.PacketSize)
+		}
+		cmd[2] = byte(end - start)
+		resp, err := p.do(ctx, cmd)
+		if err != nil {
+			return values, err
+		}
+		if len(resp) < 3 {
+			return values, fmt.Errorf("%s: response too short: %d bytes", cmdTransfer, len(resp))
+		}
+		done, data := int(resp[1]), resp[3:]
+		for i := start; i < start+done && i < end; i++ {
+			if !reqs[i].reads() {
+				continue
+			}
+			if len(data) < 4 {
+				return values, fmt.Errorf("%s: response too short", cmdTransfer)
+			}
+			values = append(values, binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		}
+		if done < end-start {
+			if err := transferError(start+done, resp[2]); err != nil {
+				return values, err
+			}
+			return values, fmt.Errorf("%s: %d of %d transfers done", cmdTransfer, done, end-start)
+		}
+		if err := transferError(end-1, resp[2]); err != nil {
+			return values, err
+		}
+		start = end
+	}
+	return values, nil
+}
+
+// Sizes of the headers of DAP_TransferBlock.
+const (
+	blockCommandSize  = 5
+	blockResponseSize = 4
+)
+
+// ReadBlock reads a register n times, typically the DRW register of a
+// MEM-AP with address auto-increment. On failure, the values read are
+// returned with a *TransferError.
+func (p *Probe) ReadBlock(ctx context.Context, dap uint8, ap bool, addr uint8, n int) ([]uint32, error) {
+	r := Request{AP: ap, Read: true, Addr: addr}
+	maxCount := (p.PacketSize - blockResponseSize) / 4
+	if maxCount > 0xffff {
+		maxCount = 0xffff
+	}
+	values := make([]uint32, 0, n)
+	for len(values) < n {
+		count := n - len(values)
+		if count > maxCount {
+			count = maxCount
+		}
+		offset := len(values)
+		data, err := p.transferBlock(ctx, dap, r.byte(), count, nil)
+		for ; len(data) >= 4; data = data[4:] {
+			values = append(values, binary.LittleEndian.Uint32(data))
+		}
+		if err != nil {
+			return values, offsetError(err, offset)
+		}
+	}
+	return values, nil
+}
+
+// WriteBlock writes the values to a register, typically the DRW register
+// of a MEM-AP with address auto-increment. On failure, the index of the
+// *TransferError is the index of the value not written.
+func (p *Probe) WriteBlock(ctx context.Context, dap uint8, ap bool, addr uint8, values []uint32) error {
+	r := Request{AP: ap, Addr: addr}
+	maxCount := (p.PacketSize - blockCommandSize) / 4
+	if maxCount > 0xffff {
+		maxCount = 0xffff
+	}
+	for written := 0; written < len(values); {
+		count := len(values) - written
+		if count > maxCount {
+			count = maxCount
+		}
+		if _, err := p.transferBlock(ctx, dap, r.byte(), count, values[written:written+count]); err != nil {
+			return offsetError(err, written)
+		}
+		written += count
+	}
+	return nil
+}
+
+// offsetError adds the index of the first transfer of a command to the
+// index of a TransferError.
+func offsetError(err error, offset int) error {
+	if terr, ok := err.(*TransferError); ok {
+		terr.Index += offset
+	}
+	return err
+}
+
+// transferBlock sends a DAP_TransferBlock command and returns the data
+// read. The index of a TransferError is the index of the failed transfer
+// of the command.
+func (p *Probe) transferBlock(ctx context.Context, dap, req byte, count int, values []uint32) ([]byte, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("%s: packet size %d too small", cmdTransferBlock, p.PacketSize)
+	}
+	cmd := make([]byte, blockCommandSize, blockCommandSize+4*len(values))
+	cmd[0], cmd[1] = byte(cmdTransferBlock), dap
+	binary.LittleEndian.PutUint16(cmd[2:], uint16(count))
+	cmd[4] = req
+	for _, v := range values {
+		cmd = append(cmd, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(cmd[len(cmd)-4:], v)
+	}
+	resp, err := p.do(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < blockResponseSize {
+		return nil, fmt.Errorf("%s: response too short: %d bytes", cmdTransferBlock, len(resp))
+	}
+	done := int(binary.LittleEndian.Uint16(resp[1:]))
+	data := resp[blockResponseSize:]
+	if req&reqRead != 0 && len(data) > 4*done {
+		data = data[:4*done]
+	}
+	if done < count {
+		if err := transferError(done, resp[3]); err != nil {
+			return data, err
+		}
+		return data, fmt.Errorf("%s: %d of %d transfers done", cmdTransferBlock, done, count)
+	}
+	return data, transferError(count-1, resp[3])
+}
+
+// TransferConfigure sets the number of idle cycles after each transfer,
+// and the number of retries on WAIT acknowledges and of reads of Match
+// requests.
+func (p *Probe) TransferConfigure(ctx context.Context, idleCycles uint8, waitRetry, matchRetry uint16) error {
+	cmd := []byte{byte(cmdTransferConfigure), idleCycles, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(cmd[2:], waitRetry)
+	binary.LittleEndian.PutUint16(cmd[4:], matchRetry)
+	_, err := p.status(ctx, cmd...)
+	return err
+}
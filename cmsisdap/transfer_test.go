@@ -0,0 +1,144 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmsisdap
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// powerUp returns the requests powering up the debug port and configuring
+// the MEM-AP for word accesses with auto-increment at addr.
+func powerUp(addr uint32) []Request {
+	return []Request{
+		{Read: true, Addr: dpIDCode},
+		{Addr: dpCtrlStat, Value: ctrlStatPowerUp},
+		{MatchMask: true, Value: ctrlStatPowerUp << 1},
+		{Match: true, Addr: dpCtrlStat, Value: ctrlStatPowerUp << 1},
+		{Addr: dpSelect, Value: 0},
+		{AP: true, Addr: apCSW, Value: 0x23000012},
+		{AP: true, Addr: apTAR, Value: addr},
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	ctx := context.Background()
+	p, sim := newSimBulkProbe(t, 64)
+	reqs := append(powerUp(0x20000000),
+		Request{AP: true, Addr: apDRW, Value: 0xdeadbeef},
+		Request{AP: true, Addr: apDRW, Value: 0xcafef00d},
+		Request{AP: true, Addr: apTAR, Value: 0x20000000},
+		Request{AP: true, Read: true, Addr: apDRW},
+		Request{AP: true, Read: true, Addr: apDRW},
+	)
+	got, err := p.Transfer(ctx, 0, reqs)
+	if err != nil {
+		t.Fatalf("Transfer(): %v", err)
+	}
+	if want := []uint32{sim.target.idcode, 0xdeadbeef, 0xcafef00d}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Transfer(): got %08x, want %08x", got, want)
+	}
+	// 3+1+5+5+5+5+5+5+5+5+5 bytes of requests.
+	if len(sim.commands) != 1 {
+		t.Errorf("Transfer(): got %d commands, want 1", len(sim.commands))
+	}
+
+	// With a smaller packet size, the requests are split.
+	sim.commands = nil
+	p.PacketSize = 16
+	if _, err := p.Transfer(ctx, 0, reqs); err != nil {
+		t.Fatalf("Transfer() with packets of 16 bytes: %v", err)
+	}
+	for i, cmd := range sim.commands {
+		if len(cmd) > 16 {
+			t.Errorf("Transfer() with packets of 16 bytes: command %d of %d bytes", i, len(cmd))
+		}
+	}
+	if got, want := len(sim.commands), 5; got != want {
+		t.Errorf("Transfer() with packets of 16 bytes: got %d commands, want %d", got, want)
+	}
+}
+
+func TestTransferError(t *testing.T) {
+	ctx := context.Background()
+	p, sim := newSimBulkProbe(t, 64)
+
+	// The AP faults until the debug port is powered up.
+	got, err := p.Transfer(ctx, 0, []Request{
+		{Read: true, Addr: dpIDCode},
+		{AP: true, Read: true, Addr: apCSW},
+	})
+	var terr *TransferError
+	if !errors.As(err, &terr) || terr.Index != 1 || terr.Ack != AckFault {
+		t.Errorf("Transfer() before power-up: got error %v, want FAULT of transfer 1", err)
+	}
+	if want := []uint32{sim.target.idcode}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Transfer() before power-up: got %08x, want %08x", got, want)
+	}
+
+	_, err = p.Transfer(ctx, 0, []Request{
+		{Read: true, Addr: dpIDCode},
+		{Match: true, Addr: dpCtrlStat, Value: 0xf0000000},
+	})
+	if !errors.As(err, &terr) || terr.Index != 1 || !terr.Mismatch {
+		t.Errorf("Transfer() with a Match: got error %v, want mismatch of transfer 1", err)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	ctx := context.Background()
+	p, sim := newSimBulkProbe(t, 64)
+	if _, err := p.Transfer(ctx, 0, powerUp(0x1000)); err != nil {
+		t.Fatalf("Transfer(): %v", err)
+	}
+	values := make([]uint32, 40)
+	for i := range values {
+		values[i] = uint32(i * 3)
+	}
+	sim.commands = nil
+	if err := p.WriteBlock(ctx, 0, true, apDRW, values); err != nil {
+		t.Fatalf("WriteBlock(): %v", err)
+	}
+	// 14 words per command.
+	if got, want := len(sim.commands), 3; got != want {
+		t.Errorf("WriteBlock(): got %d commands, want %d", got, want)
+	}
+	if _, err := p.Transfer(ctx, 0, []Request{{AP: true, Addr: apTAR, Value: 0x1000}}); err != nil {
+		t.Fatalf("Transfer(): %v", err)
+	}
+	got, err := p.ReadBlock(ctx, 0, true, apDRW, len(values))
+	if err != nil {
+		t.Fatalf("ReadBlock(): %v", err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("ReadBlock(): got %v, want %v", got, values)
+	}
+
+	// A fault in the second command.
+	sim.target.fault = 0x1000 + 20*4
+	if _, err := p.Transfer(ctx, 0, []Request{{AP: true, Addr: apTAR, Value: 0x1000}}); err != nil {
+		t.Fatalf("Transfer(): %v", err)
+	}
+	got, err = p.ReadBlock(ctx, 0, true, apDRW, len(values))
+	var terr *TransferError
+	if !errors.As(err, &terr) || terr.Index != 20 || terr.Ack != AckFault {
+		t.Errorf("ReadBlock(): got error %v, want FAULT of transfer 20", err)
+	}
+	if !reflect.DeepEqual(got, values[:20]) {
+		t.Errorf("ReadBlock(): got %v, want %v", got, values[:20])
+	}
+}
@@ -75,17 +75,34 @@ type Config struct {
 
 	// Claimed interfaces
 	mu      sync.Mutex
-	claimed map[int]bool
+	claimed map[int]*Interface
 }
 
 // Close releases the underlying device, allowing the caller to switch the device to a different configuration.
+// The interfaces of the config that are still claimed are closed first,
+// cancelling their transfers, see Interface.Close.
 func (c *Config) Close() error {
+	c.mu.Lock()
 	if c.dev == nil {
+		c.mu.Unlock()
 		return nil
 	}
+	var intfs []*Interface
+	for _, intf := range c.claimed {
+		intfs = append(intfs, intf)
+	}
+	c.mu.Unlock()
+	for _, intf := range intfs {
+		intf.Close()
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.dev == nil {
+		return nil
+	}
 	if len(c.claimed) > 0 {
+		// Interfaces claimed concurrently with Close.
 		var ifs []int
 		for k := range c.claimed {
 			ifs = append(ifs, k)
@@ -108,6 +125,8 @@ func (c *Config) String() string {
 // num specifies the number of an interface to claim, and alt specifies the
 // alternate setting number for that interface.
 func (c *Config) Interface(num, alt int) (*Interface, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.dev == nil {
 		return nil, fmt.Errorf("Interface(%d, %d) called on %s after Close", num, alt, c)
 	}
@@ -121,9 +140,7 @@ func (c *Config) Interface(num, alt int) (*Interface, error) {
 		return nil, fmt.Errorf("descriptor of alternate setting %d of interface %d in %s: %v", alt, num, c, err)
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.claimed[num] {
+	if c.claimed[num] != nil {
 		return nil, fmt.Errorf("interface %d on %s is already claimed", num, c)
 	}
 
@@ -140,9 +157,12 @@ func (c *Config) Interface(num, alt int) (*Interface, error) {
 		}
 	}
 
-	c.claimed[num] = true
-	return &Interface{
-		Setting: *altInfo,
-		config:  c,
-	}, nil
+	i := &Interface{
+		Setting:   *altInfo,
+		config:    c,
+		transfers: newTransferSet(),
+		pool:      newTransferPool(),
+	}
+	c.claimed[num] = i
+	return i, nil
 }
@@ -154,7 +154,7 @@ func (d *Device) Config(cfgNum int) (*Config, error) {
 	cfg := &Config{
 		Desc:    *desc,
 		dev:     d,
-		claimed: make(map[int]bool),
+		claimed: make(map[int]*Interface),
 	}
 
 	if d.autodetach {
@@ -210,14 +210,25 @@ func (d *Device) Control(rType, request uint8, val, idx uint16, data []byte) (in
 	return d.ctx.libusb.control(d.handle, d.ControlTimeout, rType, request, val, idx, data)
 }
 
-// Close closes the device.
+// Close closes the device. The config of the device that is still claimed
+// is closed first, see Config.Close.
 func (d *Device) Close() error {
 	if d.handle == nil {
 		return nil
 	}
+	d.mu.Lock()
+	cfg := d.claimed
+	d.mu.Unlock()
+	if cfg != nil {
+		if err := cfg.Close(); err != nil {
+			return err
+		}
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	if d.claimed != nil {
+		// Config claimed concurrently with Close.
 		return fmt.Errorf("can't release the device %s, it has an open config %d", d, d.claimed.Desc.Number)
 	}
 	d.ctx.closeDev(d)
@@ -127,33 +127,22 @@ func TestClaimAndRelease(t *testing.T) {
 		t.Fatalf("%s.Interface(0, 0): got %v, want nil", cfg, err)
 	}
 
-	if err := cfg.Close(); err == nil {
-		t.Fatalf("%s.Close(): got nil, want non nil, because the Interfaces #1/2 was not released.", cfg)
-	}
-	if err := dev.Close(); err == nil {
-		t.Fatalf("%s.Close(): got nil, want non nil, because the Config was not released.", cfg)
-	}
-
 	intf.Close()
-	if err := cfg.Close(); err == nil {
-		t.Fatalf("%s.Close(): got nil, want non nil, because the Interface #2 was not released.", cfg)
-	}
-	if err := dev.Close(); err == nil {
-		t.Fatalf("%s.Close(): got nil, want non nil, because the Config was not released.", dev)
-	}
-
-	intf2.Close()
-	if err := dev.Close(); err == nil {
-		t.Fatalf("%s.Close(): got nil, want non nil, because the Config was not released.", dev)
+	if _, err := intf.InEndpoint(ep1Addr); err == nil {
+		t.Fatalf("%s.InEndpoint(%d): got nil, want non nil, because the Interface was closed.", intf, ep1Addr)
 	}
 
 	if err := dev.Reset(); err == nil {
 		t.Fatalf("%s.Reset(): got nil, want non nil, because Device is still has an active Config.", dev)
 	}
 
+	// Closing the config closes intf2, which is still claimed.
 	if err := cfg.Close(); err != nil {
 		t.Fatalf("%s.Close(): got error %v, want nil", cfg, err)
 	}
+	if _, err := intf2.OutEndpoint(1); err == nil {
+		t.Fatalf("%s.OutEndpoint(1): got nil, want non nil, because the Config was closed.", intf2)
+	}
 
 	if err := dev.Reset(); err != nil {
 		t.Fatalf("%s.Reset(): got error %v, want nil", dev, err)
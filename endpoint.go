@@ -18,6 +18,7 @@ package gousb
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 )
@@ -55,6 +56,11 @@ type EndpointDesc struct {
 	IsoSyncType IsoSyncType
 	// UsageType is the isochronous or interrupt endpoint usage type, as defined by USB spec.
 	UsageType UsageType
+	// Extra contains the raw class- or vendor-specific descriptors that
+	// follow the standard endpoint descriptor, e.g. the class-specific
+	// isochronous endpoint descriptor of an audio class device. Extra is
+	// empty for endpoints that don't define any additional descriptors.
+	Extra []byte
 }
 
 // String returns the human-readable description of the endpoint.
@@ -77,6 +83,21 @@ type endpoint struct {
 	InterfaceSetting
 	Desc EndpointDesc
 
+	// Timeout is the timeout of the transfers of Read and Write, and the
+	// default timeout of the transfers of streams created with NewStream.
+	// A transfer not finished within the timeout ends with
+	// TransferTimedOut. Zero means no timeout.
+	Timeout time.Duration
+
+	// pool keeps the transfers of Read and Write for reuse, nil if the
+	// endpoint doesn't belong to an interface.
+	pool *transferPool
+	// stats are the statistics of all the transfers of the endpoint.
+	stats transferStats
+	// transfers tracks the transfers in flight, nil if the endpoint
+	// doesn't belong to an interface.
+	transfers *transferSet
+
 	ctx *Context
 }
 
@@ -85,14 +106,59 @@ func (e *endpoint) String() string {
 	return e.Desc.String()
 }
 
-func (e *endpoint) transfer(ctx context.Context, buf []byte) (int, error) {
-	t, err := newUSBTransfer(e.ctx, e.h, &e.Desc, len(buf))
+// Stats returns the statistics of all the transfers of the endpoint,
+// including the transfers of its streams.
+func (e *endpoint) Stats() TransferStats {
+	return e.stats.snapshot()
+}
+
+// ClearHalt clears the halt condition of the endpoint, e.g. after
+// a transfer failed with TransferStall. ClearHalt must not be called while
+// transfers on the endpoint are in flight.
+func (e *endpoint) ClearHalt() error {
+	return e.ctx.libusb.clearHalt(e.h, uint8(e.Desc.Address))
+}
+
+// allocTransfer allocates a transfer on the endpoint, which is cancelled
+// if the interface is closed while in flight, and freed when the interface
+// is closed. Since the transfer can't outlive the device handle, its
+// buffer is allocated in the device memory if the endpoint belongs to an
+// interface.
+func (e *endpoint) allocTransfer(bufLen int, timeout time.Duration) (*usbTransfer, error) {
+	if e.transfers.isClosed() {
+		return nil, ErrInterfaceClosed
+	}
+	t, err := newUSBTransfer(e.ctx, e.h, &e.Desc, bufLen, timeout, e.transfers != nil)
 	if err != nil {
+		return nil, err
+	}
+	if err := e.transfers.own(t); err != nil {
+		t.free()
+		return nil, err
+	}
+	t.set = e.transfers
+	return t, nil
+}
+
+func (e *endpoint) transfer(ctx context.Context, buf []byte, zlp bool) (int, error) {
+	if err := e.transfers.begin(); err != nil {
 		return 0, err
 	}
-	defer t.free()
+	defer e.transfers.end()
+	t := e.pool.get(e.Desc.Address, len(buf), e.Timeout)
+	if t == nil {
+		var err error
+		if t, err = e.allocTransfer(len(buf), e.Timeout); err != nil {
+			return 0, err
+		}
+	}
+	defer e.pool.put(e.Desc.Address, t)
+	if t.stats == nil {
+		t.stats = []*transferStats{&e.stats}
+	}
 	if e.Desc.Direction == EndpointDirectionOut {
 		copy(t.data(), buf)
+		t.setZeroPacket(zlp)
 	}
 
 	if err := t.submit(); err != nil {
@@ -113,6 +179,9 @@ func (e *endpoint) transfer(ctx context.Context, buf []byte) (int, error) {
 // InEndpoint implements the io.Reader interface.
 // For high-throughput transfers, consider creating a buffered read stream
 // through InEndpoint.ReadStream.
+// The transfers of Read are kept for a while for reuse by subsequent reads
+// of the same size, within a limit shared by the endpoints of the
+// Interface.
 type InEndpoint struct {
 	*endpoint
 }
@@ -127,8 +196,10 @@ type InEndpoint struct {
 // If that happens, Read will return an error signaling an overflow.
 // See http://libusb.sourceforge.net/api-1.0/libusb_packetoverflow.html
 // for more details.
+// If the endpoint has a Timeout and the transfer times out, Read returns
+// the data received before the timeout and TransferTimedOut.
 func (e *InEndpoint) Read(buf []byte) (int, error) {
-	return e.transfer(context.Background(), buf)
+	return e.transfer(context.Background(), buf, false)
 }
 
 // ReadContext reads data from an IN endpoint. ReadContext returns number of
@@ -144,20 +215,34 @@ func (e *InEndpoint) Read(buf []byte) (int, error) {
 // If that happens, Read will return an error signaling an overflow.
 // See http://libusb.sourceforge.net/api-1.0/libusb_packetoverflow.html
 // for more details.
+// If the endpoint has a Timeout and the transfer times out, ReadContext
+// returns the data received before the timeout and TransferTimedOut.
 func (e *InEndpoint) ReadContext(ctx context.Context, buf []byte) (int, error) {
-	return e.transfer(ctx, buf)
+	return e.transfer(ctx, buf, false)
 }
 
 // OutEndpoint represents an OUT endpoint open for transfer.
+// The transfers of Write are kept for a while for reuse by subsequent
+// writes of the same size, within a limit shared by the endpoints of the
+// Interface.
 type OutEndpoint struct {
 	*endpoint
+
+	// ZeroLengthPacket makes Write and WriteContext end the transfer with
+	// a zero-length packet if the length of the data is a multiple of
+	// EndpointDesc.MaxPacketSize, so that the device can tell where the
+	// data ends. It is also the default of streams created with NewStream.
+	// Zero-length packets are only supported by libusb on some platforms,
+	// e.g. Linux, elsewhere the transfers fail with ErrorNotSupported.
+	ZeroLengthPacket bool
 }
 
 // Write writes data to an OUT endpoint. Write returns number of bytes comitted
 // to the endpoint. Write may return non-zero length even if the returned error
-// is not nil (partial write).
+// is not nil (partial write), e.g. TransferTimedOut if the endpoint has
+// a Timeout.
 func (e *OutEndpoint) Write(buf []byte) (int, error) {
-	return e.transfer(context.Background(), buf)
+	return e.transfer(context.Background(), buf, e.ZeroLengthPacket)
 }
 
 // WriteContext writes data to an OUT endpoint. WriteContext returns number of
@@ -167,5 +252,95 @@ func (e *OutEndpoint) Write(buf []byte) (int, error) {
 // the context is cancelled, WriteContext will cancel the underlying transfers,
 // resulting in TransferCancelled error.
 func (e *OutEndpoint) WriteContext(ctx context.Context, buf []byte) (int, error) {
-	return e.transfer(ctx, buf)
+	return e.transfer(ctx, buf, e.ZeroLengthPacket)
+}
+
+// readFromSize is the size of the transfers of OutEndpoint.ReadFrom.
+const readFromSize = 64 * 1024
+
+// ReadFrom implements io.ReaderFrom. ReadFrom reads data from r directly
+// into the buffer of a transfer and writes it to the endpoint, one
+// transfer at a time, until io.EOF. The data read counts as a single Write
+// for ZeroLengthPacket. Like the transfers of Write, the transfer is kept
+// for reuse. For higher throughput, consider using WriteStream.ReadFrom,
+// which keeps several transfers in flight.
+func (e *OutEndpoint) ReadFrom(r io.Reader) (int64, error) {
+	if err := e.transfers.begin(); err != nil {
+		return 0, err
+	}
+	defer e.transfers.end()
+	t := e.pool.get(e.Desc.Address, readFromSize, e.Timeout)
+	if t == nil {
+		var err error
+		if t, err = e.allocTransfer(readFromSize, e.Timeout); err != nil {
+			return 0, err
+		}
+	}
+	defer func() {
+		// The last transfer may have been shorter.
+		t.setLength(len(t.data()))
+		e.pool.put(e.Desc.Address, t)
+	}()
+	if t.stats == nil {
+		t.stats = []*transferStats{&e.stats}
+	}
+	var total int64
+	for {
+		n, rerr := io.ReadFull(r, t.data())
+		eof := rerr == io.EOF || rerr == io.ErrUnexpectedEOF
+		if n == 0 && !eof {
+			return total, rerr
+		}
+		if n == 0 {
+			// The last transfer was full, terminate it if needed.
+			mp := e.Desc.MaxPacketSize
+			if !(eof && e.ZeroLengthPacket && total > 0 && mp > 0 && len(t.data())%mp == 0) {
+				return total, nil
+			}
+		}
+		t.setLength(n)
+		t.setZeroPacket(eof && n > 0 && e.ZeroLengthPacket)
+		if err := t.submit(); err != nil {
+			return total, err
+		}
+		written, err := t.wait(context.Background())
+		total += int64(written)
+		switch {
+		case err != nil:
+			return total, err
+		case eof:
+			return total, nil
+		case rerr != nil:
+			return total, rerr
+		}
+	}
+}
+
+// WriteBuffers writes each of the buffers to the endpoint in a single
+// transfer, like WriteContext, and returns the total number of bytes
+// written. WriteBuffers stops at the first error.
+func (e *OutEndpoint) WriteBuffers(ctx context.Context, bufs [][]byte) (int, error) {
+	written := 0
+	for _, b := range bufs {
+		n, err := e.WriteContext(ctx, b)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// WriteContextZLP writes data to an OUT endpoint like WriteContext, and ends
+// the transfer with a zero-length packet if the length of the data is
+// a multiple of EndpointDesc.MaxPacketSize, regardless of ZeroLengthPacket.
+func (e *OutEndpoint) WriteContextZLP(ctx context.Context, buf []byte) (int, error) {
+	return e.transfer(ctx, buf, true)
+}
+
+// WriteZLP sends a zero-length packet to an OUT endpoint, e.g. to end data
+// previously written with a length multiple of EndpointDesc.MaxPacketSize.
+func (e *OutEndpoint) WriteZLP(ctx context.Context) error {
+	_, err := e.transfer(ctx, nil, false)
+	return err
 }
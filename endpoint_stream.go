@@ -14,19 +14,65 @@
 
 package gousb
 
-func (e *endpoint) newStream(size, count int) (*stream, error) {
-	var ts []transferIntf
+import "time"
+
+// StreamOptions configure the transfers of a stream.
+type StreamOptions struct {
+	// Timeout is the timeout of each transfer of the stream. Zero means
+	// no timeout.
+	// A read transfer that times out is resubmitted: the data received
+	// before the timeout is returned by the next Read, or Read returns
+	// TransferTimedOut if there was none, unless it's in SkipErrors, and
+	// the stream keeps reading.
+	// A write transfer that times out ends the stream with
+	// TransferTimedOut, since the data not sent would be lost.
+	Timeout time.Duration
+	// ZeroLengthPacket makes every Write to a write stream end with
+	// a zero-length packet if the length of the data is a multiple of
+	// EndpointDesc.MaxPacketSize. It is ignored by read streams.
+	ZeroLengthPacket bool
+	// SkipErrors are the transfer statuses that don't end a read stream.
+	// A read transfer that fails with one of them is passed to OnError,
+	// its data is dropped and it is resubmitted, and the stream keeps
+	// reading. Other errors end the stream as usual.
+	// SkipErrors are ignored by write streams, since the data not sent
+	// would be lost.
+	SkipErrors []TransferStatus
+	// ClearHalt makes a read stream recover from TransferStall: the halt
+	// condition of the endpoint is cleared, see ClearHalt, and the
+	// transfer is resubmitted. If the halt can't be cleared, the stream
+	// ends with the error of ClearHalt. ClearHalt is ignored by write
+	// streams.
+	ClearHalt bool
+	// OnError is called with the errors skipped by a read stream,
+	// including stalls recovered with ClearHalt. OnError is called by
+	// the goroutine reading from the stream.
+	OnError func(error)
+}
+
+func (e *endpoint) newStream(size, count int, opts StreamOptions) (*stream, error) {
+	s := &stream{
+		transfers: make(chan transferIntf, count),
+		skip:      opts.SkipErrors,
+		onError:   opts.OnError,
+		set:       e.transfers,
+	}
+	if opts.ClearHalt {
+		s.clearHalt = e.ClearHalt
+	}
 	for i := 0; i < count; i++ {
-		t, err := newUSBTransfer(e.ctx, e.h, &e.Desc, size)
+		t, err := e.allocTransfer(size, opts.Timeout)
 		if err != nil {
-			for _, t := range ts {
+			s.noMore()
+			for t := range s.transfers {
 				t.free()
 			}
 			return nil, err
 		}
-		ts = append(ts, t)
+		t.stats = []*transferStats{&e.stats, &s.stats}
+		s.transfers <- t
 	}
-	return newStream(ts), nil
+	return s, nil
 }
 
 // NewStream prepares a new read stream that will keep reading data from
@@ -38,8 +84,18 @@ func (e *endpoint) newStream(size, count int) (*stream, error) {
 // Similarly to InEndpoint.Read, the size of the buffer should be a multiple
 // of EndpointDesc.MaxPacketSize to avoid overflows, see documentation
 // in InEndpoint.Read for more details.
+// The buffers of the transfers are allocated in the device memory where
+// the platform supports it, and the data of a transfer is accessible
+// through ReadStream.NextTransfer without any copy.
+// The transfers of the stream use the Timeout of the endpoint.
 func (e *InEndpoint) NewStream(size, count int) (*ReadStream, error) {
-	s, err := e.newStream(size, count)
+	return e.NewStreamWithOptions(size, count, StreamOptions{Timeout: e.Timeout})
+}
+
+// NewStreamWithOptions prepares a new read stream like NewStream, with
+// the transfers configured by opts.
+func (e *InEndpoint) NewStreamWithOptions(size, count int, opts StreamOptions) (*ReadStream, error) {
+	s, err := e.newStream(size, count, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -52,10 +108,24 @@ func (e *InEndpoint) NewStream(size, count int) (*ReadStream, error) {
 // count defines how many transactions may be active at any time. By buffering
 // the writes, a Stream reduces the latency between subsequent transfers and
 // increases writing throughput.
+// The buffers of the transfers are allocated in the device memory where
+// the platform supports it, WriteStream.ReadFrom reads the data directly
+// into them.
+// The transfers of the stream use the Timeout and ZeroLengthPacket of
+// the endpoint.
 func (e *OutEndpoint) NewStream(size, count int) (*WriteStream, error) {
-	s, err := e.newStream(size, count)
+	return e.NewStreamWithOptions(size, count, StreamOptions{
+		Timeout:          e.Timeout,
+		ZeroLengthPacket: e.ZeroLengthPacket,
+	})
+}
+
+// NewStreamWithOptions prepares a new write stream like NewStream, with
+// the transfers configured by opts.
+func (e *OutEndpoint) NewStreamWithOptions(size, count int, opts StreamOptions) (*WriteStream, error) {
+	s, err := e.newStream(size, count, opts)
 	if err != nil {
 		return nil, err
 	}
-	return &WriteStream{s: s}, nil
+	return &WriteStream{s: s, zlp: opts.ZeroLengthPacket, maxPacket: e.Desc.MaxPacketSize}, nil
 }
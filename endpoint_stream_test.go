@@ -14,7 +14,12 @@
 
 package gousb
 
-import "testing"
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
 
 func TestEndpointReadStream(t *testing.T) {
 	t.Parallel()
@@ -82,6 +87,25 @@ func TestEndpointReadStream(t *testing.T) {
 	if got != want {
 		t.Errorf("stream.Read(): read %d bytes, want %d", got, want)
 	}
+	stats := stream.Stats()
+	// The fake transfers that fail report stale data lengths, only the
+	// bytes of the good transfers are known.
+	if got, want := stats.Bytes, int64(want); got < want {
+		t.Errorf("stream.Stats().Bytes: got %d, want at least %d", got, want)
+	}
+	if stats.Errors[TransferError] == 0 {
+		t.Errorf("stream.Stats().Errors: got %v, want at least one %s", stats.Errors, TransferError)
+	}
+	errs := 0
+	for _, n := range stats.Errors {
+		errs += n
+	}
+	if got, want := stats.Transfers, goodTransfers+errs; got != want {
+		t.Errorf("stream.Stats().Transfers: got %d, want %d", got, want)
+	}
+	if got := ep.Stats(); got.Transfers != stats.Transfers || got.Bytes != stats.Bytes {
+		t.Errorf("%s.Stats(): got %s, want the same as the stream: %s", ep, got, stats)
+	}
 	close(done)
 }
 
@@ -157,3 +181,211 @@ func TestEndpointWriteStream(t *testing.T) {
 		t.Errorf("received transfers: got %d, want %d", num, wantXfers)
 	}
 }
+
+func TestEndpointReadStreamTimeout(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+
+	done := make(chan struct{})
+	timeouts := make(chan time.Duration, 10)
+	go func() {
+		var num int
+		for {
+			xfr := lib.waitForSubmitted(done)
+			if xfr == nil {
+				return
+			}
+			timeouts <- xfr.timeout
+			switch num {
+			case 0:
+				xfr.setStatus(TransferTimedOut)
+			case 1:
+				xfr.setData(make([]byte, 100))
+				xfr.setStatus(TransferTimedOut)
+			default:
+				xfr.setData(make([]byte, len(xfr.buf)))
+				xfr.setStatus(TransferCompleted)
+			}
+			num++
+		}
+	}()
+
+	dev, err := ctx.OpenDeviceWithVIDPID(0x9999, 0x0001)
+	if err != nil {
+		t.Fatalf("OpenDeviceWithVIDPID(9999, 0001): %v", err)
+	}
+	defer dev.Close()
+	cfg, err := dev.Config(1)
+	if err != nil {
+		t.Fatalf("%s.Config(1): %v", dev, err)
+	}
+	defer cfg.Close()
+	intf, err := cfg.Interface(0, 0)
+	if err != nil {
+		t.Fatalf("%s.Interface(0, 0): %v", cfg, err)
+	}
+	defer intf.Close()
+	ep, err := intf.InEndpoint(2)
+	if err != nil {
+		t.Fatalf("%s.Endpoint(2): %v", intf, err)
+	}
+	ep.Timeout = time.Second
+	stream, err := ep.NewStreamWithOptions(512, 2, StreamOptions{Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("%s.NewStreamWithOptions(512, 2): %v", ep, err)
+	}
+	buf := make([]byte, 512)
+	for i, want := range []readRes{
+		{err: TransferTimedOut},
+		{n: 100},
+		{n: 512},
+		{n: 512},
+	} {
+		n, err := stream.Read(buf)
+		if got := (readRes{n: n, err: err}); got != want {
+			t.Errorf("stream.Read() #%d: got %v, want %v", i, got, want)
+		}
+	}
+	stream.Close()
+	for {
+		if _, err := stream.Read(buf); err != nil {
+			break
+		}
+	}
+	close(done)
+	for len(timeouts) > 0 {
+		if got, want := <-timeouts, 20*time.Millisecond; got != want {
+			t.Errorf("transfer timeout: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEndpointReadStreamPackets(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			xfr := lib.waitForSubmitted(done)
+			if xfr == nil {
+				return
+			}
+			xfr.setPackets([][]byte{{1, 2}, {}, {3}})
+			xfr.setStatus(TransferCompleted)
+		}
+	}()
+
+	dev, err := ctx.OpenDeviceWithVIDPID(0x8888, 0x0002)
+	if err != nil {
+		t.Fatalf("OpenDeviceWithVIDPID(8888, 0002): %v", err)
+	}
+	defer dev.Close()
+	cfg, err := dev.Config(1)
+	if err != nil {
+		t.Fatalf("%s.Config(1): %v", dev, err)
+	}
+	defer cfg.Close()
+	intf, err := cfg.Interface(1, 0)
+	if err != nil {
+		t.Fatalf("%s.Interface(1, 0): %v", cfg, err)
+	}
+	defer intf.Close()
+	ep, err := intf.InEndpoint(6)
+	if err != nil {
+		t.Fatalf("%s.InEndpoint(6): %v", intf, err)
+	}
+	stream, err := ep.NewStream(3*ep.Desc.MaxPacketSize, 2)
+	if err != nil {
+		t.Fatalf("%s.NewStream(): %v", ep, err)
+	}
+	pkts, err := stream.NextPackets(context.Background())
+	if err != nil {
+		t.Fatalf("NextPackets(): %v", err)
+	}
+	if want := [][]byte{{1, 2}, {}, {3}}; !reflect.DeepEqual(pkts, want) {
+		t.Errorf("NextPackets(): got %v, want %v", pkts, want)
+	}
+	stream.Close()
+	for {
+		if _, err := stream.NextPackets(context.Background()); err != nil {
+			break
+		}
+	}
+}
+
+func TestEndpointWriteStreamIso(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+
+	done := make(chan struct{})
+	sent := make(chan []int, 10)
+	go func() {
+		for {
+			xfr := lib.waitForSubmitted(done)
+			if xfr == nil {
+				return
+			}
+			sent <- xfr.isoLengths
+			xfr.setLength(xfr.maxLength)
+			xfr.setStatus(TransferCompleted)
+		}
+	}()
+	defer close(done)
+
+	dev, err := ctx.OpenDeviceWithVIDPID(0x8888, 0x0002)
+	if err != nil {
+		t.Fatalf("OpenDeviceWithVIDPID(8888, 0002): %v", err)
+	}
+	defer dev.Close()
+	cfg, err := dev.Config(1)
+	if err != nil {
+		t.Fatalf("%s.Config(1): %v", dev, err)
+	}
+	defer cfg.Close()
+	intf, err := cfg.Interface(1, 0)
+	if err != nil {
+		t.Fatalf("%s.Interface(1, 0): %v", cfg, err)
+	}
+	defer intf.Close()
+	ep, err := intf.OutEndpoint(5)
+	if err != nil {
+		t.Fatalf("%s.OutEndpoint(5): %v", intf, err)
+	}
+	stream, err := ep.NewStream(8000, 2)
+	if err != nil {
+		t.Fatalf("%s.NewStream(8000, 2): %v", ep, err)
+	}
+	if n, err := stream.Write(make([]byte, 10000)); n != 10000 || err != nil {
+		t.Fatalf("stream.Write(10000 bytes): got %d, %v, want 10000, nil", n, err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("stream.Close: got error %v", err)
+	}
+	close(sent)
+	var got [][]int
+	for s := range sent {
+		got = append(got, s)
+	}
+	if want := [][]int{{3072, 3072, 1856}, {2000}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("isochronous packets: got %v, want %v", got, want)
+	}
+}
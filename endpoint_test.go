@@ -15,7 +15,10 @@
 package gousb
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -106,7 +109,8 @@ func TestEndpoint(t *testing.T) {
 					fakeT.setStatus(tc.status)
 				}()
 			}
-			got, err := ep.transfer(context.TODO(), tc.buf)
+			got, err := ep.transfer(context.TODO(), tc.buf, false)
+			ep.pool.close()
 			if (err != nil) != tc.wantErr {
 				t.Errorf("%s, %s: ep.transfer(...): got err: %v, err != nil is %v, want %v", epData.ei, tc.desc, err, err != nil, tc.wantErr)
 				continue
@@ -121,6 +125,331 @@ func TestEndpoint(t *testing.T) {
 	}
 }
 
+func TestEndpointTimeout(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	desc := EndpointDesc{
+		Address:       0x82,
+		Number:        2,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}
+	ep := &InEndpoint{&endpoint{ctx: ctx, Desc: desc, Timeout: 50 * time.Millisecond}}
+	defer ep.pool.close()
+	timeout := make(chan time.Duration, 1)
+	go func() {
+		fakeT := lib.waitForSubmitted(nil)
+		timeout <- fakeT.timeout
+		fakeT.setData([]byte{1, 2, 3})
+		fakeT.setStatus(TransferTimedOut)
+	}()
+	buf := make([]byte, 512)
+	n, err := ep.Read(buf)
+	if n != 3 || err != TransferTimedOut {
+		t.Errorf("Read(): got %d, %v, want 3, %v", n, err, TransferTimedOut)
+	}
+	if got, want := buf[:3], []byte{1, 2, 3}; !bytes.Equal(got, want) {
+		t.Errorf("Read(): got data %v, want %v", got, want)
+	}
+	if got, want := <-timeout, 50*time.Millisecond; got != want {
+		t.Errorf("transfer timeout: got %v, want %v", got, want)
+	}
+	if got := ep.Stats(); got.Transfers != 1 || got.Bytes != 3 || got.Errors[TransferTimedOut] != 1 {
+		t.Errorf("Stats(): got %s, want 1 transfer of 3 bytes that timed out", got)
+	}
+}
+
+func TestEndpointZeroLengthPacket(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	desc := EndpointDesc{
+		Address:       0x01,
+		Number:        1,
+		Direction:     EndpointDirectionOut,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}
+	type sent struct {
+		len int
+		zlp bool
+	}
+	for _, tc := range []struct {
+		desc  string
+		auto  bool
+		write func(*OutEndpoint) error
+		want  sent
+	}{
+		{
+			desc: "Write",
+			write: func(ep *OutEndpoint) error {
+				_, err := ep.Write(make([]byte, 512))
+				return err
+			},
+			want: sent{len: 512},
+		},
+		{
+			desc: "Write with ZeroLengthPacket",
+			auto: true,
+			write: func(ep *OutEndpoint) error {
+				_, err := ep.Write(make([]byte, 512))
+				return err
+			},
+			want: sent{len: 512, zlp: true},
+		},
+		{
+			desc: "WriteContextZLP",
+			write: func(ep *OutEndpoint) error {
+				_, err := ep.WriteContextZLP(context.Background(), make([]byte, 512))
+				return err
+			},
+			want: sent{len: 512, zlp: true},
+		},
+		{
+			desc: "WriteZLP",
+			auto: true,
+			write: func(ep *OutEndpoint) error {
+				return ep.WriteZLP(context.Background())
+			},
+			want: sent{len: 0},
+		},
+	} {
+		ep := &OutEndpoint{endpoint: &endpoint{ctx: ctx, Desc: desc}, ZeroLengthPacket: tc.auto}
+		got := make(chan sent, 1)
+		go func() {
+			fakeT := lib.waitForSubmitted(nil)
+			got <- sent{len: len(fakeT.buf), zlp: fakeT.zeroPacket}
+			fakeT.setStatus(TransferCompleted)
+		}()
+		if err := tc.write(ep); err != nil {
+			t.Errorf("%s: got error %v, want nil", tc.desc, err)
+		}
+		ep.pool.close()
+		if got := <-got; got != tc.want {
+			t.Errorf("%s: sent %+v, want %+v", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestEndpointTransferPool(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	pool := &transferPool{maxBytes: 1024, idleTimeout: time.Hour}
+	set := newTransferSet()
+	newEP := func(addr EndpointAddress) *InEndpoint {
+		return &InEndpoint{&endpoint{ctx: ctx, pool: pool, transfers: set, Desc: EndpointDesc{
+			Address:       addr,
+			Number:        int(addr & 0x0f),
+			Direction:     EndpointDirectionIn,
+			MaxPacketSize: 512,
+			TransferType:  TransferTypeBulk,
+		}}}
+	}
+	ep, ep2 := newEP(0x82), newEP(0x83)
+	read := func(ep *InEndpoint, size int, timeout time.Duration) *fakeTransfer {
+		t.Helper()
+		ep.Timeout = timeout
+		submitted := make(chan *fakeTransfer, 1)
+		go func() {
+			fakeT := lib.waitForSubmitted(nil)
+			submitted <- fakeT
+			fakeT.setData(make([]byte, size))
+			fakeT.setStatus(TransferCompleted)
+		}()
+		if n, err := ep.Read(make([]byte, size)); n != size || err != nil {
+			t.Errorf("Read(%d bytes): got %d, %v, want %d, nil", size, n, err, size)
+		}
+		return <-submitted
+	}
+
+	first := read(ep, 512, 0)
+	if !first.devMem {
+		t.Errorf("Read(512 bytes) used a transfer allocated with malloc, want the device memory")
+	}
+	if got := read(ep, 512, 0); got != first {
+		t.Errorf("second Read(512 bytes) used a new transfer, want the transfer of the first Read")
+	}
+	if got := read(ep2, 512, 0); got == first {
+		t.Errorf("Read(512 bytes) on %s used the transfer of %s", ep2, ep)
+	}
+	if got := read(ep, 256, 0); got == first {
+		t.Errorf("Read(256 bytes) used the transfer of Read(512 bytes)")
+	}
+	// The pool holds 1024 bytes, the least recently used transfers were
+	// evicted.
+	if got, want := len(lib.ts), 2; got != want {
+		t.Errorf("got %d allocated transfers, want %d", got, want)
+	}
+	if got := read(ep, 512, 0); got == first {
+		t.Errorf("Read(512 bytes) used a transfer that should have been evicted from the pool")
+	}
+	if got := read(ep, 512, time.Second); got == first {
+		t.Errorf("Read(512 bytes) with a timeout used the transfer of a Read without a timeout")
+	}
+	pool.close()
+	if got := len(lib.ts); got != 0 {
+		t.Errorf("got %d allocated transfers after closing the pool, want 0", got)
+	}
+}
+
+func TestEndpointTransferPoolIdle(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	pool := &transferPool{maxBytes: 1024, idleTimeout: 10 * time.Millisecond}
+	defer pool.close()
+	ep := &InEndpoint{&endpoint{ctx: ctx, pool: pool, Desc: EndpointDesc{
+		Address:       0x82,
+		Number:        2,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}}}
+	go func() {
+		fakeT := lib.waitForSubmitted(nil)
+		fakeT.setData(make([]byte, 512))
+		fakeT.setStatus(TransferCompleted)
+	}()
+	if _, err := ep.Read(make([]byte, 512)); err != nil {
+		t.Fatalf("Read(512 bytes): %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lib.mu.Lock()
+		allocated := len(lib.ts)
+		lib.mu.Unlock()
+		if allocated == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d allocated transfers after the idle timeout, want 0", allocated)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEndpointClearHalt(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	ep := &InEndpoint{&endpoint{ctx: ctx, Desc: EndpointDesc{
+		Address:       0x82,
+		Number:        2,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}}}
+	if err := ep.ClearHalt(); err != nil {
+		t.Errorf("ClearHalt(): %v", err)
+	}
+	if got, want := lib.halts, []EndpointAddress{0x82}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cleared halts: got %v, want %v", got, want)
+	}
+}
+
+func TestEndpointReadFrom(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	type sent struct {
+		len int
+		zlp bool
+	}
+	done := make(chan struct{})
+	defer close(done)
+	submitted := make(chan sent, 10)
+	go func() {
+		for {
+			fakeT := lib.waitForSubmitted(done)
+			if fakeT == nil {
+				return
+			}
+			n := fakeT.maxLength
+			if l := len(fakeT.buf); n > l {
+				n = l
+			}
+			submitted <- sent{len: n, zlp: fakeT.zeroPacket}
+			fakeT.setLength(n)
+			fakeT.setStatus(TransferCompleted)
+		}
+	}()
+
+	ep := &OutEndpoint{endpoint: &endpoint{ctx: ctx, Desc: EndpointDesc{
+		Address:       0x01,
+		Number:        1,
+		Direction:     EndpointDirectionOut,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}}, ZeroLengthPacket: true}
+	for _, tc := range []struct {
+		size int
+		want []sent
+	}{
+		{size: 700, want: []sent{{512, false}, {188, true}}},
+		{size: 1024, want: []sent{{512, false}, {512, false}, {0, false}}},
+	} {
+		if n, err := ep.ReadFrom(bytes.NewReader(make([]byte, tc.size))); n != int64(tc.size) || err != nil {
+			t.Errorf("ReadFrom(%d bytes): got %d, %v, want %d, nil", tc.size, n, err, tc.size)
+		}
+		var got []sent
+		for len(submitted) > 0 {
+			got = append(got, <-submitted)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ReadFrom(%d bytes): sent %v, want %v", tc.size, got, tc.want)
+		}
+	}
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			<-submitted
+		}
+	}()
+	if n, err := ep.WriteBuffers(context.Background(), [][]byte{make([]byte, 100), make([]byte, 200)}); n != 300 || err != nil {
+		t.Errorf("WriteBuffers(): got %d, %v, want 300, nil", n, err)
+	}
+	ep.pool.close()
+}
+
 func TestEndpointInfo(t *testing.T) {
 	t.Parallel()
 	for _, tc := range []struct {
@@ -356,3 +685,93 @@ func TestReadContext(t *testing.T) {
 		t.Errorf("%s.Write: got %d bytes, want %d (partial write success)", oep, got, want)
 	}
 }
+
+// BenchmarkReadThroughput compares reading from an endpoint with Read,
+// which copies the data from the transfer buffer, with reading from
+// a stream with Read and with NextTransfer, which doesn't copy. The
+// transfers are completed by the fake backend as soon as they are
+// submitted.
+func BenchmarkReadThroughput(b *testing.B) {
+	for _, size := range []int{512, 16 * 1024, 256 * 1024} {
+		for _, tc := range []struct {
+			desc string
+			read func(*InEndpoint, []byte, *testing.B)
+		}{
+			{
+				desc: "Read",
+				read: func(ep *InEndpoint, buf []byte, b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						if _, err := ep.Read(buf); err != nil {
+							b.Fatalf("Read(): %v", err)
+						}
+					}
+				},
+			},
+			{
+				desc: "ReadStream.Read",
+				read: func(ep *InEndpoint, buf []byte, b *testing.B) {
+					rs, err := ep.NewStream(len(buf), 4)
+					if err != nil {
+						b.Fatalf("NewStream(): %v", err)
+					}
+					defer rs.Close()
+					for i := 0; i < b.N; i++ {
+						if _, err := rs.Read(buf); err != nil {
+							b.Fatalf("ReadStream.Read(): %v", err)
+						}
+					}
+				},
+			},
+			{
+				desc: "ReadStream.NextTransfer",
+				read: func(ep *InEndpoint, buf []byte, b *testing.B) {
+					rs, err := ep.NewStream(len(buf), 4)
+					if err != nil {
+						b.Fatalf("NewStream(): %v", err)
+					}
+					defer rs.Close()
+					for i := 0; i < b.N; i++ {
+						if _, err := rs.NextTransfer(context.Background()); err != nil {
+							b.Fatalf("ReadStream.NextTransfer(): %v", err)
+						}
+					}
+				},
+			},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", tc.desc, size), func(b *testing.B) {
+				lib := newFakeLibusb()
+				ctx := newContextWithImpl(lib)
+				defer func() {
+					if err := ctx.Close(); err != nil {
+						b.Errorf("Context.Close(): %v", err)
+					}
+				}()
+				done := make(chan struct{})
+				defer close(done)
+				go func() {
+					for ft := lib.waitForSubmitted(done); ft != nil; ft = lib.waitForSubmitted(done) {
+						ft.setLength(len(ft.buf))
+						ft.setStatus(TransferCompleted)
+					}
+				}()
+				set, pool := newTransferSet(), newTransferPool()
+				// The fake backend limits bulk transfers to a single
+				// packet.
+				ep := &InEndpoint{&endpoint{ctx: ctx, pool: pool, transfers: set, Desc: EndpointDesc{
+					Address:       0x82,
+					Number:        2,
+					Direction:     EndpointDirectionIn,
+					MaxPacketSize: size,
+					TransferType:  TransferTypeBulk,
+				}}}
+				buf := make([]byte, size)
+				b.SetBytes(int64(size))
+				b.ResetTimer()
+				tc.read(ep, buf, b)
+				b.StopTimer()
+				set.close()
+				pool.close()
+			})
+		}
+	}
+}
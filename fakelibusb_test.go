@@ -26,7 +26,7 @@ type fakeTransfer struct {
 	// finished.
 	// This is different from finished below - done is provided by the caller
 	// and is used to signal the caller.
-	done chan struct{}
+	done chan time.Time
 	// mu protects transfer data and status.
 	mu sync.Mutex
 	// buf is the slice for reading/writing data between the submit() and wait() returning.
@@ -44,6 +44,17 @@ type fakeTransfer struct {
 	isoPackets int
 	// maxLength is the maximum number of bytes this transfer could contain
 	maxLength int
+	// timeout is the timeout of the transfer.
+	timeout time.Duration
+	// zeroPacket is true if the transfer ends with a zero-length packet.
+	zeroPacket bool
+	// devMem is true if the buffer was requested from the device memory.
+	devMem bool
+	// packets are the lengths of the isochronous packets of the data.
+	packets []int
+	// isoLengths are the lengths of the isochronous packets that will be
+	// submitted, as set by setLength.
+	isoLengths []int
 }
 
 func (t *fakeTransfer) setData(d []byte) {
@@ -56,6 +67,22 @@ func (t *fakeTransfer) setData(d []byte) {
 	t.length = len(d)
 }
 
+// setPackets sets the data of an isochronous transfer, one slice per
+// packet.
+func (t *fakeTransfer) setPackets(pkts [][]byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		return
+	}
+	t.length, t.packets = 0, nil
+	for _, p := range pkts {
+		copy(t.buf[t.length:], p)
+		t.length += len(p)
+		t.packets = append(t.packets, len(p))
+	}
+}
+
 func (t *fakeTransfer) setLength(n int) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -73,7 +100,7 @@ func (t *fakeTransfer) setStatus(st TransferStatus) {
 	}
 	t.status = st
 	t.finished = true
-	t.done <- struct{}{}
+	t.done <- time.Now()
 }
 
 // fakeLibusb implements a fake libusb stack that pretends to have a number of
@@ -98,6 +125,8 @@ type fakeLibusb struct {
 	handles map[*libusbDevHandle]*libusbDevice
 	// claims is a map of devices to a set of claimed interfaces
 	claims map[*libusbDevice]map[uint8]bool
+	// halts records the endpoints passed to clearHalt.
+	halts []EndpointAddress
 }
 
 func (f *fakeLibusb) init() (*libusbContext, error)                       { return newContextPointer(), nil }
@@ -223,7 +252,14 @@ func (f *fakeLibusb) setAlt(d *libusbDevHandle, intf, alt uint8) error {
 	return nil
 }
 
-func (f *fakeLibusb) alloc(_ *libusbDevHandle, ep *EndpointDesc, isoPackets int, bufLen int, done chan struct{}) (*libusbTransfer, error) {
+func (f *fakeLibusb) clearHalt(d *libusbDevHandle, ep uint8) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.halts = append(f.halts, EndpointAddress(ep))
+	return nil
+}
+
+func (f *fakeLibusb) alloc(_ *libusbDevHandle, ep *EndpointDesc, timeout time.Duration, isoPackets int, bufLen int, devMem bool, done chan time.Time) (*libusbTransfer, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	maxLen := ep.MaxPacketSize
@@ -242,6 +278,8 @@ func (f *fakeLibusb) alloc(_ *libusbDevHandle, ep *EndpointDesc, isoPackets int,
 		ep:         ep,
 		isoPackets: isoPackets,
 		maxLength:  maxLen,
+		timeout:    timeout,
+		devMem:     devMem,
 		done:       done,
 	}
 	return t, nil
@@ -261,7 +299,11 @@ func (f *fakeLibusb) submit(t *libusbTransfer) error {
 	f.submitted <- ft
 	return nil
 }
-func (f *fakeLibusb) buffer(t *libusbTransfer) []byte { return f.ts[t].buf }
+func (f *fakeLibusb) buffer(t *libusbTransfer) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ts[t].buf
+}
 func (f *fakeLibusb) data(t *libusbTransfer) (int, TransferStatus) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -276,14 +318,31 @@ func (f *fakeLibusb) free(t *libusbTransfer) {
 	defer f.mu.Unlock()
 	delete(f.ts, t)
 }
-func (f *fakeLibusb) setIsoPacketLengths(t *libusbTransfer, length uint32) {
+
+func (f *fakeLibusb) packetLengths(t *libusbTransfer) []int {
+	f.mu.Lock()
+	ft := f.ts[t]
+	f.mu.Unlock()
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return ft.packets
+}
+
+func (f *fakeLibusb) setLength(t *libusbTransfer, length int, isoPackets []int) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	maxLen := f.ts[t].isoPackets * int(length)
-	if bufLen := len(f.ts[t].buf); maxLen > bufLen {
-		maxLen = bufLen
+	ft := f.ts[t]
+	if len(isoPackets) > ft.isoPackets {
+		panic(fmt.Sprintf("setLength(): %d isochronous packets, transfer was allocated with %d", len(isoPackets), ft.isoPackets))
 	}
-	f.ts[t].maxLength = maxLen
+	ft.maxLength = length
+	ft.isoLengths = isoPackets
+}
+
+func (f *fakeLibusb) setZeroPacket(t *libusbTransfer, zlp bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ts[t].zeroPacket = zlp
 }
 
 // waitForSubmitted can be used by tests to define custom behavior of the transfers submitted on the USB bus.
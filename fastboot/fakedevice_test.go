@@ -0,0 +1,202 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fastboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fakeDevice implements the device side of the fastboot protocol. Each
+// command written by the client queues the responses returned by the
+// following reads. Flashed images are stored in parts, with sparse images
+// expanded.
+type fakeDevice struct {
+	vars  map[string]string
+	parts map[string][]byte
+	// maxDownload is the largest download accepted.
+	maxDownload int
+
+	commands  []string
+	downloads []int
+	responses [][]byte
+	// pending is the number of bytes left in the current download.
+	pending int
+	buf     []byte
+	data    []byte
+}
+
+func newFakeDevice(maxDownload int) *fakeDevice {
+	return &fakeDevice{
+		vars: map[string]string{
+			"product":           "fake",
+			"max-download-size": fmt.Sprintf("0x%x", maxDownload),
+		},
+		parts:       map[string][]byte{},
+		maxDownload: maxDownload,
+	}
+}
+
+func (f *fakeDevice) reply(kind, msg string) {
+	f.responses = append(f.responses, []byte(kind+msg))
+}
+
+func (f *fakeDevice) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if len(f.responses) == 0 {
+		return 0, errors.New("no response queued")
+	}
+	r := f.responses[0]
+	f.responses = f.responses[1:]
+	if len(r) > len(p) {
+		return 0, errors.New("overflow")
+	}
+	return copy(p, r), nil
+}
+
+func (f *fakeDevice) WriteContext(ctx context.Context, p []byte) (int, error) {
+	if f.pending > 0 {
+		if len(p) > f.pending {
+			return 0, fmt.Errorf("%d bytes written with %d bytes left in the download", len(p), f.pending)
+		}
+		f.buf = append(f.buf, p...)
+		if f.pending -= len(p); f.pending == 0 {
+			f.data, f.buf = f.buf, nil
+			f.downloads = append(f.downloads, len(f.data))
+			f.reply("OKAY", "")
+		}
+		return len(p), nil
+	}
+	if len(p) > maxCommandSize {
+		return 0, errors.New("command too long")
+	}
+	cmd := string(p)
+	f.commands = append(f.commands, cmd)
+	switch {
+	case strings.HasPrefix(cmd, "getvar:"):
+		v, ok := f.vars[cmd[len("getvar:"):]]
+		if !ok {
+			f.reply("FAIL", "GetVar Variable Not found")
+			break
+		}
+		f.reply("OKAY", v)
+	case strings.HasPrefix(cmd, "download:"):
+		n, err := strconv.ParseUint(cmd[len("download:"):], 16, 32)
+		if err != nil || n == 0 {
+			f.reply("FAIL", "invalid size")
+			break
+		}
+		if int(n) > f.maxDownload {
+			f.reply("FAIL", "data too large")
+			break
+		}
+		f.pending = int(n)
+		f.reply("DATA", fmt.Sprintf("%08x", n))
+	case strings.HasPrefix(cmd, "flash:"):
+		name := cmd[len("flash:"):]
+		if f.data == nil {
+			f.reply("FAIL", "no image downloaded")
+			break
+		}
+		f.reply("INFO", "writing '"+name+"'")
+		if err := f.flash(name); err != nil {
+			f.reply("FAIL", err.Error())
+			break
+		}
+		f.reply("OKAY", "")
+	case strings.HasPrefix(cmd, "erase:"):
+		name := cmd[len("erase:"):]
+		if _, ok := f.parts[name]; !ok {
+			f.reply("FAIL", "partition does not exist")
+			break
+		}
+		delete(f.parts, name)
+		f.reply("OKAY", "")
+	case cmd == "reboot" || cmd == "reboot-bootloader":
+		f.reply("TEXT", "rebooting")
+		f.reply("OKAY", "")
+	default:
+		f.reply("FAIL", "unknown command")
+	}
+	return len(p), nil
+}
+
+// flash writes the downloaded data to a partition, expanding sparse images
+// over the current content of the partition.
+func (f *fakeDevice) flash(name string) error {
+	data := f.data
+	f.data = nil
+	r := bytes.NewReader(data)
+	if !isSparse(r) {
+		f.parts[name] = data
+		return nil
+	}
+	h, chunks, err := parseSparse(r, int64(len(data)))
+	if err != nil {
+		return err
+	}
+	out := f.parts[name]
+	if n := int(h.totalBlks) * int(h.blkSize); len(out) < n {
+		out = append(out, make([]byte, n-len(out))...)
+	}
+	for _, c := range chunks {
+		dst := out[int(c.start)*int(h.blkSize) : int(c.start+c.blocks)*int(h.blkSize)]
+		switch c.typ {
+		case chunkRaw:
+			copy(dst, data[c.off:c.off+c.size])
+		case chunkFill:
+			for i := 0; i < len(dst); i += 4 {
+				copy(dst[i:], data[c.off:c.off+4])
+			}
+		}
+	}
+	f.parts[name] = out
+	return nil
+}
+
+// sparseBuilder writes a sparse image.
+type sparseBuilder struct {
+	blkSize uint32
+	blocks  uint32
+	count   uint32
+	chunks  bytes.Buffer
+}
+
+func (b *sparseBuilder) chunk(typ uint16, blocks uint32, data []byte) {
+	var h [chunkHeaderSize]byte
+	binary.LittleEndian.PutUint16(h[0:], typ)
+	binary.LittleEndian.PutUint32(h[4:], blocks)
+	binary.LittleEndian.PutUint32(h[8:], uint32(chunkHeaderSize+len(data)))
+	b.chunks.Write(h[:])
+	b.chunks.Write(data)
+	b.blocks += blocks
+	b.count++
+}
+
+func (b *sparseBuilder) bytes() []byte {
+	var h [fileHeaderSize]byte
+	binary.LittleEndian.PutUint32(h[0:], sparseMagic)
+	binary.LittleEndian.PutUint16(h[4:], sparseMajor)
+	binary.LittleEndian.PutUint16(h[8:], fileHeaderSize)
+	binary.LittleEndian.PutUint16(h[10:], chunkHeaderSize)
+	binary.LittleEndian.PutUint32(h[12:], b.blkSize)
+	binary.LittleEndian.PutUint32(h[16:], b.blocks)
+	binary.LittleEndian.PutUint32(h[20:], b.count)
+	return append(h[:], b.chunks.Bytes()...)
+}
@@ -0,0 +1,353 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fastboot implements a client of the Android fastboot protocol,
+// used to flash and control devices in bootloader mode.
+//
+// Open claims the fastboot interface of a device. Commands are sent on
+// the bulk OUT endpoint and the device answers with INFO, TEXT, DATA, OKAY
+// or FAIL responses on the bulk IN endpoint:
+//
+//	c, err := fastboot.Open(dev)
+//	...
+//	defer c.Close()
+//	c.Info = func(msg string) { log.Print(msg) }
+//	v, err := c.GetVar(ctx, "version-bootloader")
+//	...
+//	f, err := os.Open("system.img")
+//	...
+//	st, err := f.Stat()
+//	...
+//	err = c.Flash(ctx, "system", f, st.Size())
+//
+// Flash splits sparse images larger than the max-download-size of
+// the device into several smaller sparse images.
+package fastboot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Class codes of the fastboot interface.
+const (
+	subClassFastboot gousb.Class    = 0x42
+	protocolFastboot gousb.Protocol = 0x03
+)
+
+const (
+	// maxCommandSize is the maximum length of a command.
+	maxCommandSize = 4096
+	// responseSize is the maximum size of a response packet.
+	responseSize = 256
+	// chunkSize is the size of a single write during download.
+	chunkSize = 1 << 20
+)
+
+// Error is a FAIL response from the device.
+type Error struct {
+	// Command is the command that failed.
+	Command string
+	// Message is the reason given by the device.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("fastboot %q failed: %s", e.Command, e.Message)
+}
+
+// reader receives the responses of the device, one per read.
+// *gousb.InEndpoint implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the commands and the data of downloads.
+// *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Client is a fastboot connection to a device.
+type Client struct {
+	// Info, if set, is called with the messages of INFO and TEXT responses
+	// sent by the device while it processes a command.
+	Info func(msg string)
+	// Progress, if set, is called after every chunk of data sent to
+	// the device, with the number of bytes sent so far and the total
+	// number of bytes of the current download.
+	Progress func(sent, total int64)
+
+	r       reader
+	w       writer
+	release func()
+}
+
+func isFastboot(s gousb.InterfaceSetting) bool {
+	return s.Class == gousb.ClassVendorSpec && s.SubClass == subClassFastboot && s.Protocol == protocolFastboot
+}
+
+// Match returns true if the device has a fastboot interface in any of its
+// configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		for _, intf := range cfg.Interfaces {
+			for _, alt := range intf.AltSettings {
+				if isFastboot(alt) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// findInterface returns the fastboot interface setting of the configuration
+// and its bulk endpoints.
+func findInterface(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if !isFastboot(alt) {
+				continue
+			}
+			var in, out *gousb.EndpointDesc
+			for _, ep := range alt.Endpoints {
+				if ep.TransferType != gousb.TransferTypeBulk {
+					continue
+				}
+				ep := ep
+				if ep.Direction == gousb.EndpointDirectionIn {
+					in = &ep
+				} else {
+					out = &ep
+				}
+			}
+			if in == nil || out == nil {
+				return nil, nil, nil, fmt.Errorf("fastboot interface %d alt %d has no bulk IN and OUT endpoints", alt.Number, alt.Alternate)
+			}
+			alt := alt
+			return &alt, in, out, nil
+		}
+	}
+	return nil, nil, nil, fmt.Errorf("%s has no fastboot interface", desc)
+}
+
+// Open claims the fastboot interface in the active configuration of
+// the device. The Client should be Close()d after use.
+func Open(dev *gousb.Device) (*Client, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, inDesc, outDesc, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	return &Client{r: in, w: out, release: func() { cfg.Close() }}, nil
+}
+
+// Close releases the fastboot interface.
+func (c *Client) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
+
+// send sends a command without waiting for the response.
+func (c *Client) send(ctx context.Context, cmd string) error {
+	if len(cmd) > maxCommandSize {
+		return fmt.Errorf("fastboot command of %d bytes exceeds the maximum of %d", len(cmd), maxCommandSize)
+	}
+	if _, err := c.w.WriteContext(ctx, []byte(cmd)); err != nil {
+		return fmt.Errorf("sending fastboot command %q: %v", cmd, err)
+	}
+	return nil
+}
+
+// response reads responses until the device sends OKAY, FAIL or DATA.
+// INFO and TEXT responses are passed to the Info callback. It returns
+// the response type and its payload.
+func (c *Client) response(ctx context.Context, cmd string) (string, string, error) {
+	buf := make([]byte, responseSize)
+	for {
+		n, err := c.r.ReadContext(ctx, buf)
+		if err != nil {
+			return "", "", fmt.Errorf("reading response to fastboot %q: %v", cmd, err)
+		}
+		if n < 4 {
+			return "", "", fmt.Errorf("fastboot %q: short response %q", cmd, buf[:n])
+		}
+		kind, payload := string(buf[:4]), string(buf[4:n])
+		switch kind {
+		case "INFO", "TEXT":
+			if c.Info != nil {
+				c.Info(payload)
+			}
+		case "OKAY", "DATA":
+			return kind, payload, nil
+		case "FAIL":
+			return "", "", &Error{Command: cmd, Message: payload}
+		default:
+			return "", "", fmt.Errorf("fastboot %q: unknown response %q", cmd, buf[:n])
+		}
+	}
+}
+
+// Command sends a raw command and waits for it to complete. It returns
+// the payload of the OKAY response.
+func (c *Client) Command(ctx context.Context, cmd string) (string, error) {
+	if err := c.send(ctx, cmd); err != nil {
+		return "", err
+	}
+	kind, payload, err := c.response(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+	if kind != "OKAY" {
+		return "", fmt.Errorf("fastboot %q: unexpected %s response", cmd, kind)
+	}
+	return payload, nil
+}
+
+// GetVar returns the value of a bootloader variable, e.g. "product" or
+// "max-download-size".
+func (c *Client) GetVar(ctx context.Context, name string) (string, error) {
+	return c.Command(ctx, "getvar:"+name)
+}
+
+// MaxDownloadSize returns the maximum size of a single download accepted
+// by the device.
+func (c *Client) MaxDownloadSize(ctx context.Context) (int64, error) {
+	v, err := c.GetVar(ctx, "max-download-size")
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 0, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid max-download-size %q", v)
+	}
+	return n, nil
+}
+
+// Download sends size bytes read from r to the device, to be used by
+// a following command such as flash or boot.
+func (c *Client) Download(ctx context.Context, r io.Reader, size int64) error {
+	if size <= 0 || size > 0xffffffff {
+		return fmt.Errorf("invalid download size %d", size)
+	}
+	cmd := fmt.Sprintf("download:%08x", size)
+	if err := c.send(ctx, cmd); err != nil {
+		return err
+	}
+	kind, payload, err := c.response(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if kind != "DATA" {
+		return fmt.Errorf("fastboot %q: got %s, want DATA", cmd, kind)
+	}
+	if n, err := strconv.ParseUint(payload, 16, 32); err != nil || int64(n) != size {
+		return fmt.Errorf("fastboot %q: device accepted %q bytes, want %d", cmd, payload, size)
+	}
+	buf := make([]byte, chunkSize)
+	var sent int64
+	for sent < size {
+		n := int64(len(buf))
+		if rem := size - sent; rem < n {
+			n = rem
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return fmt.Errorf("reading download data: %v", err)
+		}
+		if _, err := c.w.WriteContext(ctx, buf[:n]); err != nil {
+			return fmt.Errorf("sending download data: %v", err)
+		}
+		sent += n
+		if c.Progress != nil {
+			c.Progress(sent, size)
+		}
+	}
+	kind, _, err = c.response(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if kind != "OKAY" {
+		return fmt.Errorf("fastboot %q: got %s after data, want OKAY", cmd, kind)
+	}
+	return nil
+}
+
+// Flash writes an image to a partition. Sparse images larger than
+// the max-download-size of the device are split into smaller sparse
+// images, flashed one after another.
+func (c *Client) Flash(ctx context.Context, partition string, r io.ReaderAt, size int64) error {
+	max, err := c.MaxDownloadSize(ctx)
+	if err != nil {
+		return err
+	}
+	parts := []io.Reader{io.NewSectionReader(r, 0, size)}
+	sizes := []int64{size}
+	if size > max {
+		if !isSparse(r) {
+			return fmt.Errorf("image of %d bytes exceeds the max-download-size of %d and is not a sparse image", size, max)
+		}
+		if parts, sizes, err = splitSparse(r, size, max); err != nil {
+			return err
+		}
+	}
+	for i := range parts {
+		if err := c.Download(ctx, parts[i], sizes[i]); err != nil {
+			return err
+		}
+		if _, err := c.Command(ctx, "flash:"+partition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Erase erases a partition.
+func (c *Client) Erase(ctx context.Context, partition string) error {
+	_, err := c.Command(ctx, "erase:"+partition)
+	return err
+}
+
+// Reboot reboots the device. An empty target boots the system, other
+// targets like "bootloader", "fastboot" or "recovery" are appended
+// to the reboot command.
+func (c *Client) Reboot(ctx context.Context, target string) error {
+	cmd := "reboot"
+	if target != "" {
+		cmd += "-" + target
+	}
+	_, err := c.Command(ctx, cmd)
+	return err
+}
@@ -0,0 +1,229 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fastboot
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+func TestGetVar(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDevice(1 << 20)
+	c := &Client{r: f, w: f}
+	if got, err := c.GetVar(ctx, "product"); err != nil || got != "fake" {
+		t.Errorf("GetVar(product): got %q, %v, want \"fake\", nil", got, err)
+	}
+	if got, err := c.MaxDownloadSize(ctx); err != nil || got != 1<<20 {
+		t.Errorf("MaxDownloadSize(): got %d, %v, want %d, nil", got, err, 1<<20)
+	}
+	_, err := c.GetVar(ctx, "missing")
+	if fErr, ok := err.(*Error); !ok || fErr.Command != "getvar:missing" || fErr.Message != "GetVar Variable Not found" {
+		t.Errorf("GetVar(missing): got error %v, want a fastboot FAIL", err)
+	}
+	f.vars["max-download-size"] = "junk"
+	if _, err := c.MaxDownloadSize(ctx); err == nil {
+		t.Error("MaxDownloadSize() with an invalid value: got nil error, want non-nil")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	f := newFakeDevice(4 << 20)
+	var progress []int64
+	c := &Client{r: f, w: f, Progress: func(sent, total int64) { progress = append(progress, sent, total) }}
+	data := make([]byte, 2<<20+100)
+	rand.New(rand.NewSource(1)).Read(data)
+	if err := c.Download(context.Background(), bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("Download(): %v", err)
+	}
+	if !bytes.Equal(f.data, data) {
+		t.Error("Download(): data received by the device differs from the data sent")
+	}
+	total := int64(len(data))
+	if want := []int64{1 << 20, total, 2 << 20, total, total, total}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("Download(): got progress %v, want %v", progress, want)
+	}
+	if err := c.Download(context.Background(), bytes.NewReader(make([]byte, 5<<20)), 5<<20); err == nil {
+		t.Error("Download() larger than max-download-size: got nil error, want non-nil")
+	}
+	if err := c.Download(context.Background(), bytes.NewReader(data[:10]), 20); err == nil {
+		t.Error("Download() of a short reader: got nil error, want non-nil")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDevice(1 << 20)
+	var info []string
+	c := &Client{r: f, w: f, Info: func(msg string) { info = append(info, msg) }}
+	img := []byte("boot image")
+	if err := c.Flash(ctx, "boot", bytes.NewReader(img), int64(len(img))); err != nil {
+		t.Fatalf("Flash(boot): %v", err)
+	}
+	if got := f.parts["boot"]; !bytes.Equal(got, img) {
+		t.Errorf("Flash(boot): partition content %q, want %q", got, img)
+	}
+	if err := c.Erase(ctx, "boot"); err != nil {
+		t.Errorf("Erase(boot): %v", err)
+	}
+	if err := c.Erase(ctx, "boot"); err == nil {
+		t.Error("Erase() of a missing partition: got nil error, want non-nil")
+	}
+	if err := c.Reboot(ctx, "bootloader"); err != nil {
+		t.Errorf("Reboot(bootloader): %v", err)
+	}
+	if err := c.Reboot(ctx, ""); err != nil {
+		t.Errorf("Reboot(): %v", err)
+	}
+	if _, err := c.Command(ctx, "oem unlock"); err == nil {
+		t.Error("Command(oem unlock): got nil error, want non-nil")
+	}
+	wantCmds := []string{"getvar:max-download-size", "download:0000000a", "flash:boot", "erase:boot", "erase:boot", "reboot-bootloader", "reboot", "oem unlock"}
+	if !reflect.DeepEqual(f.commands, wantCmds) {
+		t.Errorf("commands: got %q, want %q", f.commands, wantCmds)
+	}
+	if want := []string{"writing 'boot'", "rebooting", "rebooting"}; !reflect.DeepEqual(info, want) {
+		t.Errorf("Info messages: got %q, want %q", info, want)
+	}
+	if _, err := c.Command(ctx, string(make([]byte, maxCommandSize+1))); err == nil {
+		t.Error("Command() too long: got nil error, want non-nil")
+	}
+}
+
+func TestFlashSparse(t *testing.T) {
+	const blk = 4096
+	rnd := rand.New(rand.NewSource(1))
+	raw1, raw2 := make([]byte, 10*blk), make([]byte, 30*blk)
+	rnd.Read(raw1)
+	rnd.Read(raw2)
+	b := &sparseBuilder{blkSize: blk}
+	b.chunk(chunkRaw, 10, raw1)
+	b.chunk(chunkFill, 20, []byte{1, 2, 3, 4})
+	b.chunk(chunkDontCare, 4, nil)
+	b.chunk(chunkRaw, 30, raw2)
+	b.chunk(chunkCRC32, 0, []byte{0, 0, 0, 0})
+	b.chunk(chunkDontCare, 2, nil)
+	img := b.bytes()
+
+	want := append([]byte(nil), raw1...)
+	want = append(want, bytes.Repeat([]byte{1, 2, 3, 4}, 20*blk/4)...)
+	want = append(want, make([]byte, 4*blk)...)
+	want = append(want, raw2...)
+	want = append(want, make([]byte, 2*blk)...)
+
+	for _, max := range []int{1 << 20, 64 << 10, 3*blk + 100} {
+		f := newFakeDevice(max)
+		c := &Client{r: f, w: f}
+		if err := c.Flash(context.Background(), "system", bytes.NewReader(img), int64(len(img))); err != nil {
+			t.Errorf("Flash(max-download-size %d): %v", max, err)
+			continue
+		}
+		if !bytes.Equal(f.parts["system"], want) {
+			t.Errorf("Flash(max-download-size %d): partition content differs from the image", max)
+		}
+		wantParts := (len(img) + max - 1) / max
+		if len(f.downloads) < wantParts {
+			t.Errorf("Flash(max-download-size %d): got %d downloads, want at least %d", max, len(f.downloads), wantParts)
+		}
+		for _, n := range f.downloads {
+			if n > max {
+				t.Errorf("Flash(max-download-size %d): download of %d bytes", max, n)
+			}
+		}
+	}
+
+	f := newFakeDevice(blk)
+	c := &Client{r: f, w: f}
+	if err := c.Flash(context.Background(), "system", bytes.NewReader(img), int64(len(img))); err == nil {
+		t.Error("Flash() with a max-download-size smaller than a block: got nil error, want non-nil")
+	}
+	f = newFakeDevice(1 << 10)
+	c = &Client{r: f, w: f}
+	if err := c.Flash(context.Background(), "system", bytes.NewReader(raw1), int64(len(raw1))); err == nil {
+		t.Error("Flash() of a large raw image: got nil error, want non-nil")
+	}
+}
+
+func TestParseSparseErrors(t *testing.T) {
+	b := &sparseBuilder{blkSize: 4096}
+	b.chunk(chunkRaw, 1, make([]byte, 4096))
+	good := b.bytes()
+	corrupt := func(off int, v ...byte) []byte {
+		img := append([]byte(nil), good...)
+		copy(img[off:], v)
+		return img
+	}
+	for _, tc := range []struct {
+		desc string
+		img  []byte
+	}{
+		{"bad magic", corrupt(0, 0)},
+		{"bad version", corrupt(4, 2)},
+		{"bad block size", corrupt(12, 1)},
+		{"too many blocks", corrupt(fileHeaderSize+4, 2)},
+		{"unknown chunk type", corrupt(fileHeaderSize, 0xc5)},
+		{"truncated", good[:len(good)-1]},
+		{"too many chunks", corrupt(20, 2)},
+	} {
+		if _, _, err := parseSparse(bytes.NewReader(tc.img), int64(len(tc.img))); err == nil {
+			t.Errorf("%s: parseSparse(): got nil error, want non-nil", tc.desc)
+		}
+	}
+	if _, _, err := parseSparse(bytes.NewReader(good), int64(len(good))); err != nil {
+		t.Errorf("parseSparse(): %v", err)
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	bulk := func(addr gousb.EndpointAddress) gousb.EndpointDesc {
+		dir := gousb.EndpointDirectionOut
+		if addr&0x80 != 0 {
+			dir = gousb.EndpointDirectionIn
+		}
+		return gousb.EndpointDesc{Address: addr, Number: int(addr & 0x0f), Direction: dir, TransferType: gousb.TransferTypeBulk, MaxPacketSize: 512}
+	}
+	fb := gousb.InterfaceSetting{
+		Number:    1,
+		Class:     gousb.ClassVendorSpec,
+		SubClass:  subClassFastboot,
+		Protocol:  protocolFastboot,
+		Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{0x81: bulk(0x81), 0x02: bulk(0x02)},
+	}
+	adb := fb
+	adb.Number, adb.Protocol = 0, 0x01
+	cfg := gousb.ConfigDesc{Number: 1, Interfaces: []gousb.InterfaceDesc{
+		{Number: 0, AltSettings: []gousb.InterfaceSetting{adb}},
+		{Number: 1, AltSettings: []gousb.InterfaceSetting{fb}},
+	}}
+	alt, in, out, err := findInterface(cfg)
+	if err != nil {
+		t.Fatalf("findInterface(): %v", err)
+	}
+	if alt.Number != 1 || in.Address != 0x81 || out.Address != 0x02 {
+		t.Errorf("findInterface(): got interface %d, endpoints %s and %s, want interface 1, endpoints 0x81 and 0x02", alt.Number, in.Address, out.Address)
+	}
+	if !Match(&gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: cfg}}) {
+		t.Error("Match(): got false, want true")
+	}
+	cfg.Interfaces = cfg.Interfaces[:1]
+	if _, _, _, err := findInterface(cfg); err == nil {
+		t.Error("findInterface() without a fastboot interface: got nil error, want non-nil")
+	}
+}
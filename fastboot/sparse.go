@@ -0,0 +1,226 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fastboot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Android sparse image format, as produced by img2simg and libsparse.
+const (
+	sparseMagic      = 0xed26ff3a
+	sparseMajor      = 1
+	fileHeaderSize   = 28
+	chunkHeaderSize  = 12
+	chunkRaw         = 0xcac1
+	chunkFill        = 0xcac2
+	chunkDontCare    = 0xcac3
+	chunkCRC32       = 0xcac4
+	fillDataSize     = 4
+	crc32DataSize    = 4
+	maxSparseBlkSize = 1 << 24
+)
+
+// sparseHeader is the file header of a sparse image.
+type sparseHeader struct {
+	blkSize   uint32
+	totalBlks uint32
+}
+
+// sparseChunk describes a chunk of a sparse image.
+type sparseChunk struct {
+	typ uint16
+	// start is the first output block covered by the chunk.
+	start uint32
+	// blocks is the number of output blocks covered by the chunk.
+	blocks uint32
+	// off and size locate the chunk data in the image.
+	off, size int64
+}
+
+// isSparse returns true if the image starts with the sparse image magic.
+func isSparse(r io.ReaderAt) bool {
+	var b [4]byte
+	if _, err := r.ReadAt(b[:], 0); err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint32(b[:]) == sparseMagic
+}
+
+// parseSparse reads the headers of a sparse image of the given size.
+func parseSparse(r io.ReaderAt, size int64) (*sparseHeader, []sparseChunk, error) {
+	var b [fileHeaderSize]byte
+	if _, err := r.ReadAt(b[:], 0); err != nil {
+		return nil, nil, fmt.Errorf("reading sparse image header: %v", err)
+	}
+	if m := binary.LittleEndian.Uint32(b[0:]); m != sparseMagic {
+		return nil, nil, fmt.Errorf("not a sparse image: magic 0x%08x", m)
+	}
+	if v := binary.LittleEndian.Uint16(b[4:]); v != sparseMajor {
+		return nil, nil, fmt.Errorf("unsupported sparse image version %d", v)
+	}
+	fileHdr := int64(binary.LittleEndian.Uint16(b[8:]))
+	chunkHdr := int64(binary.LittleEndian.Uint16(b[10:]))
+	h := &sparseHeader{
+		blkSize:   binary.LittleEndian.Uint32(b[12:]),
+		totalBlks: binary.LittleEndian.Uint32(b[16:]),
+	}
+	count := binary.LittleEndian.Uint32(b[20:])
+	if fileHdr < fileHeaderSize || chunkHdr < chunkHeaderSize {
+		return nil, nil, fmt.Errorf("invalid sparse header sizes %d and %d", fileHdr, chunkHdr)
+	}
+	if h.blkSize == 0 || h.blkSize%4 != 0 || h.blkSize > maxSparseBlkSize {
+		return nil, nil, fmt.Errorf("invalid sparse block size %d", h.blkSize)
+	}
+	var chunks []sparseChunk
+	off := fileHdr
+	var blk uint32
+	for i := uint32(0); i < count; i++ {
+		var c [chunkHeaderSize]byte
+		if _, err := r.ReadAt(c[:], off); err != nil {
+			return nil, nil, fmt.Errorf("reading header of sparse chunk %d: %v", i, err)
+		}
+		ch := sparseChunk{
+			typ:    binary.LittleEndian.Uint16(c[0:]),
+			start:  blk,
+			blocks: binary.LittleEndian.Uint32(c[4:]),
+			off:    off + chunkHdr,
+		}
+		total := int64(binary.LittleEndian.Uint32(c[8:]))
+		ch.size = total - chunkHdr
+		var want int64
+		switch ch.typ {
+		case chunkRaw:
+			want = int64(ch.blocks) * int64(h.blkSize)
+		case chunkFill:
+			want = fillDataSize
+		case chunkDontCare:
+			want = 0
+		case chunkCRC32:
+			want = crc32DataSize
+		default:
+			return nil, nil, fmt.Errorf("sparse chunk %d has unknown type 0x%04x", i, ch.typ)
+		}
+		if ch.size != want {
+			return nil, nil, fmt.Errorf("sparse chunk %d of type 0x%04x has %d bytes of data, want %d", i, ch.typ, ch.size, want)
+		}
+		if ch.off+ch.size > size {
+			return nil, nil, fmt.Errorf("sparse chunk %d extends beyond the end of the image", i)
+		}
+		if uint64(blk)+uint64(ch.blocks) > uint64(h.totalBlks) {
+			return nil, nil, fmt.Errorf("sparse chunk %d extends beyond the %d blocks of the image", i, h.totalBlks)
+		}
+		blk += ch.blocks
+		off = ch.off + ch.size
+		chunks = append(chunks, ch)
+	}
+	return h, chunks, nil
+}
+
+// splitSparse splits a sparse image into sparse images of at most max
+// bytes each. Each part covers the whole output with don't care chunks
+// around its data, so that flashing all parts in order writes the same
+// data as the original image. Raw chunks are split as needed, CRC32
+// chunks are dropped.
+func splitSparse(r io.ReaderAt, size, max int64) ([]io.Reader, []int64, error) {
+	h, chunks, err := parseSparse(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	blkSize := int64(h.blkSize)
+	// Every part has a file header and up to two don't care chunks.
+	const overhead = fileHeaderSize + 2*chunkHeaderSize
+
+	var groups [][]sparseChunk
+	var cur []sparseChunk
+	curSize := int64(overhead)
+	for _, c := range chunks {
+		if c.typ == chunkCRC32 {
+			continue
+		}
+		for {
+			if need := chunkHeaderSize + c.size; curSize+need <= max {
+				cur = append(cur, c)
+				curSize += need
+				break
+			}
+			if c.typ == chunkRaw {
+				if fit := (max - curSize - chunkHeaderSize) / blkSize; fit > 0 {
+					head := c
+					head.blocks = uint32(fit)
+					head.size = fit * blkSize
+					cur = append(cur, head)
+					c.start += uint32(fit)
+					c.blocks -= uint32(fit)
+					c.off += head.size
+					c.size -= head.size
+				}
+			}
+			if len(cur) == 0 {
+				return nil, nil, fmt.Errorf("max-download-size of %d is too small for sparse blocks of %d bytes", max, blkSize)
+			}
+			groups = append(groups, cur)
+			cur = nil
+			curSize = overhead
+		}
+	}
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+	}
+
+	var parts []io.Reader
+	var sizes []int64
+	for _, g := range groups {
+		var readers []io.Reader
+		var n int64
+		var count uint32
+		add := func(typ uint16, blocks uint32, data io.Reader, size int64) {
+			var c [chunkHeaderSize]byte
+			binary.LittleEndian.PutUint16(c[0:], typ)
+			binary.LittleEndian.PutUint32(c[4:], blocks)
+			binary.LittleEndian.PutUint32(c[8:], uint32(chunkHeaderSize+size))
+			readers = append(readers, bytes.NewReader(c[:]))
+			if data != nil {
+				readers = append(readers, data)
+			}
+			n += chunkHeaderSize + size
+			count++
+		}
+		if first := g[0].start; first > 0 {
+			add(chunkDontCare, first, nil, 0)
+		}
+		for _, c := range g {
+			add(c.typ, c.blocks, io.NewSectionReader(r, c.off, c.size), c.size)
+		}
+		last := g[len(g)-1]
+		if end := last.start + last.blocks; end < h.totalBlks {
+			add(chunkDontCare, h.totalBlks-end, nil, 0)
+		}
+		var fh [fileHeaderSize]byte
+		binary.LittleEndian.PutUint32(fh[0:], sparseMagic)
+		binary.LittleEndian.PutUint16(fh[4:], sparseMajor)
+		binary.LittleEndian.PutUint16(fh[8:], fileHeaderSize)
+		binary.LittleEndian.PutUint16(fh[10:], chunkHeaderSize)
+		binary.LittleEndian.PutUint32(fh[12:], h.blkSize)
+		binary.LittleEndian.PutUint32(fh[16:], h.totalBlks)
+		binary.LittleEndian.PutUint32(fh[20:], count)
+		parts = append(parts, io.MultiReader(append([]io.Reader{bytes.NewReader(fh[:])}, readers...)...))
+		sizes = append(sizes, fileHeaderSize+n)
+	}
+	return parts, sizes, nil
+}
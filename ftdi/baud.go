@@ -0,0 +1,134 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import "fmt"
+
+// Base clocks of the baud rate generators.
+const (
+	clockC = 48000000  // 48MHz, divided by 16
+	clockH = 120000000 // 120MHz, divided by 10, H chips only
+)
+
+// fracCode maps eighths of the divisor to the sub-integer divisor bits.
+var fracCode = [8]uint32{0, 3, 2, 4, 1, 5, 6, 7}
+
+// clkBits returns the encoded divisor closest to the baud rate for a base
+// clock divided by clkDiv, and the actual baud rate.
+func clkBits(baud, clk, clkDiv int) (uint32, int) {
+	switch {
+	case baud >= clk/clkDiv:
+		return 0, clk / clkDiv
+	case baud >= clk/(clkDiv+clkDiv/2):
+		return 1, clk / (clkDiv + clkDiv/2)
+	case baud >= clk/(2*clkDiv):
+		return 2, clk / (2 * clkDiv)
+	}
+	// Divide by 16 to keep 3 fractional bits and one bit for rounding.
+	div := clk * 16 / clkDiv / baud
+	best := div / 2
+	if div&1 != 0 {
+		best++
+	}
+	if best > 0x20000 {
+		best = 0x1ffff
+	}
+	actual := clk * 16 / clkDiv / best
+	if actual&1 != 0 {
+		actual = actual/2 + 1
+	} else {
+		actual /= 2
+	}
+	return uint32(best>>3) | fracCode[best&7]<<14, actual
+}
+
+// clkBitsAM is clkBits for the AM chips, which only support some of
+// the sub-integer divisors.
+func clkBitsAM(baud int) (uint32, int) {
+	adjustUp := [8]int{0, 0, 0, 1, 0, 3, 2, 1}
+	adjustDn := [8]int{0, 0, 0, 1, 0, 1, 2, 3}
+	div := 24000000 / baud
+	div -= adjustDn[div&7]
+	var best, bestBaud, bestDiff int
+	for i := 0; i < 2; i++ {
+		try := div + i
+		switch {
+		case try <= 8:
+			try = 8
+		case div < 16:
+			// AM chips don't support divisors 9 through 15.
+			try = 16
+		default:
+			try += adjustUp[try&7]
+			if try > 0x1fff8 {
+				try = 0x1fff8
+			}
+		}
+		est := (24000000 + try/2) / try
+		diff := est - baud
+		if diff < 0 {
+			diff = -diff
+		}
+		if i == 0 || diff < bestDiff {
+			best, bestBaud, bestDiff = try, est, diff
+			if diff == 0 {
+				break
+			}
+		}
+	}
+	enc := uint32(best>>3) | fracCode[best&7]<<14
+	switch enc {
+	case 1:
+		enc = 0 // 3000000 baud
+	case 0x4001:
+		enc = 1 // 2000000 baud
+	}
+	return enc, bestBaud
+}
+
+// baudDivisor returns the value and index of the set baud rate request
+// for the chip and port index, and the actual baud rate. Rates more than
+// 3% off the requested rate are rejected.
+func baudDivisor(chip Chip, index uint16, baud int) (uint16, uint16, int, error) {
+	if baud <= 0 {
+		return 0, 0, 0, fmt.Errorf("invalid baud rate %d", baud)
+	}
+	var enc uint32
+	var actual int
+	switch {
+	case chip.isH():
+		if baud*10 > clockH/0x3fff {
+			enc, actual = clkBits(baud, clockH, 10)
+			enc |= 0x20000 // use the 120MHz clock divided by 10
+		} else {
+			enc, actual = clkBits(baud, clockC, 16)
+		}
+	case chip == ChipAM:
+		enc, actual = clkBitsAM(baud)
+	default:
+		enc, actual = clkBits(baud, clockC, 16)
+	}
+	if diff := actual - baud; diff*100 > baud*3 || -diff*100 > baud*3 {
+		return 0, 0, 0, fmt.Errorf("baud rate %d not supported by %s, closest is %d", baud, chip, actual)
+	}
+	value := uint16(enc)
+	var idx uint16
+	if chip.indexedBaud() {
+		idx = uint16(enc>>8)&0xff00 | index
+	} else {
+		idx = uint16(enc >> 16)
+	}
+	return value, idx, actual, nil
+}
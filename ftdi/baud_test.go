@@ -0,0 +1,61 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import "testing"
+
+func TestBaudDivisor(t *testing.T) {
+	for _, tc := range []struct {
+		chip       Chip
+		baud       int
+		wantValue  uint16
+		wantIndex  uint16
+		wantActual int
+	}{
+		{ChipBM, 9600, 0x4138, 0, 9600},
+		{ChipBM, 115200, 0x001a, 0, 115385},
+		{ChipR, 3000000, 0, 0, 3000000},
+		{ChipR, 2000000, 1, 0, 2000000},
+		{ChipR, 300, 0x2710, 0, 300},
+		{ChipAM, 9600, 0x4138, 0, 9600},
+		{ChipAM, 115200, 0x001a, 0, 115385},
+		{Chip2232C, 9600, 0x4138, 1, 9600},
+		{Chip2232H, 3000000, 0x0004, 0x0201, 3000000},
+		{Chip232H, 12000000, 0, 0x0201, 12000000},
+		{Chip2232H, 460800, 0x001a, 0x0201, 461538},
+	} {
+		val, idx, actual, err := baudDivisor(tc.chip, 1, tc.baud)
+		if err != nil {
+			t.Errorf("baudDivisor(%s, %d): %v", tc.chip, tc.baud, err)
+			continue
+		}
+		if val != tc.wantValue || idx != tc.wantIndex || actual != tc.wantActual {
+			t.Errorf("baudDivisor(%s, %d): got value 0x%04x, index 0x%04x, rate %d, want 0x%04x, 0x%04x, %d", tc.chip, tc.baud, val, idx, actual, tc.wantValue, tc.wantIndex, tc.wantActual)
+		}
+	}
+	for _, tc := range []struct {
+		chip Chip
+		baud int
+	}{
+		{ChipR, 0},
+		{ChipR, 4000000},
+		{ChipR, 100},
+		{Chip2232H, 10000000},
+	} {
+		if _, _, _, err := baudDivisor(tc.chip, 1, tc.baud); err == nil {
+			t.Errorf("baudDivisor(%s, %d): got nil error, want non-nil", tc.chip, tc.baud)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+// Vendor requests of the FTDI chips.
+const (
+	reqReset           = 0x00
+	reqSetModemCtrl    = 0x01
+	reqSetFlowCtrl     = 0x02
+	reqSetBaudRate     = 0x03
+	reqSetData         = 0x04
+	reqPollModemStatus = 0x05
+	reqSetLatencyTimer = 0x09
+	reqGetLatencyTimer = 0x0a
+	reqSetBitMode      = 0x0b
+	reqReadPins        = 0x0c
+)
+
+// Values of the reset request.
+const (
+	resetSIO     = 0
+	resetPurgeRX = 1
+	resetPurgeTX = 2
+)
+
+// Bits of the set modem control request.
+const (
+	modemDTR     = 0x0001
+	modemRTS     = 0x0002
+	modemDTRMask = 0x0100
+	modemRTSMask = 0x0200
+)
+
+const (
+	rTypeOut = uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlDevice)
+	rTypeIn  = uint8(gousb.ControlIn | gousb.ControlVendor | gousb.ControlDevice)
+)
+
+// Parity is the parity mode of the line.
+type Parity int
+
+// Parity modes.
+const (
+	ParityNone Parity = iota
+	ParityOdd
+	ParityEven
+	ParityMark
+	ParitySpace
+)
+
+// StopBits is the number of stop bits of the line.
+type StopBits int
+
+// Stop bits.
+const (
+	StopBits1 StopBits = iota
+	StopBits15
+	StopBits2
+)
+
+// Flow is the flow control mode of the port.
+type Flow int
+
+// Flow control modes.
+const (
+	FlowNone    Flow = 0x00
+	FlowRTSCTS  Flow = 0x01
+	FlowDTRDSR  Flow = 0x02
+	FlowXonXoff Flow = 0x04
+)
+
+// Default XON and XOFF characters.
+const (
+	xon  = 0x11
+	xoff = 0x13
+)
+
+// BitMode is the mode of the pins of the port.
+type BitMode uint8
+
+// Bit modes.
+const (
+	// BitModeReset returns to the mode set by the EEPROM, usually
+	// serial.
+	BitModeReset BitMode = 0x00
+	// BitModeBitbang is the asynchronous bitbang mode: bytes written set
+	// the output pins at the baud rate, ReadPins returns the pins.
+	BitModeBitbang BitMode = 0x01
+	// BitModeMPSSE enables the MPSSE engine, see Port.MPSSE.
+	BitModeMPSSE BitMode = 0x02
+	// BitModeSyncBitbang is the synchronous bitbang mode: the pins are
+	// sampled and returned for every byte written.
+	BitModeSyncBitbang BitMode = 0x04
+	// BitModeMCU is the MCU host bus emulation mode.
+	BitModeMCU BitMode = 0x08
+	// BitModeOpto is the fast opto-isolated serial mode.
+	BitModeOpto BitMode = 0x10
+	// BitModeCBUS is the CBUS bitbang mode.
+	BitModeCBUS BitMode = 0x20
+	// BitModeSyncFIFO is the single channel synchronous FIFO mode.
+	BitModeSyncFIFO BitMode = 0x40
+)
+
+// ModemStatus holds the modem status in the low byte and the line status
+// in the high byte, as reported by the device.
+type ModemStatus uint16
+
+// Bits of the modem status.
+const (
+	StatusCTS ModemStatus = 0x0010
+	StatusDSR ModemStatus = 0x0020
+	StatusRI  ModemStatus = 0x0040
+	StatusDCD ModemStatus = 0x0080
+
+	StatusDataReady      ModemStatus = 0x0100
+	StatusOverrunError   ModemStatus = 0x0200
+	StatusParityError    ModemStatus = 0x0400
+	StatusFramingError   ModemStatus = 0x0800
+	StatusBreak          ModemStatus = 0x1000
+	StatusTxHoldingEmpty ModemStatus = 0x2000
+	StatusTxEmpty        ModemStatus = 0x4000
+	StatusFIFOError      ModemStatus = 0x8000
+)
+
+var statusNames = []struct {
+	bit  ModemStatus
+	name string
+}{
+	{StatusCTS, "CTS"},
+	{StatusDSR, "DSR"},
+	{StatusRI, "RI"},
+	{StatusDCD, "DCD"},
+	{StatusDataReady, "DR"},
+	{StatusOverrunError, "OE"},
+	{StatusParityError, "PE"},
+	{StatusFramingError, "FE"},
+	{StatusBreak, "BI"},
+	{StatusTxHoldingEmpty, "THRE"},
+	{StatusTxEmpty, "TEMT"},
+	{StatusFIFOError, "RCVRERR"},
+}
+
+func (s ModemStatus) String() string {
+	var names []string
+	for _, n := range statusNames {
+		if s&n.bit != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+func (p *Port) control(req uint8, val, idx uint16) error {
+	if _, err := p.ctl.Control(rTypeOut, req, val, idx, nil); err != nil {
+		return fmt.Errorf("FTDI request 0x%02x on port %d: %v", req, p.Number, err)
+	}
+	return nil
+}
+
+// Reset resets the port.
+func (p *Port) Reset() error {
+	return p.control(reqReset, resetSIO, p.index)
+}
+
+// Purge clears the receive and transmit buffers of the chip.
+func (p *Port) Purge(rx, tx bool) error {
+	if rx {
+		if err := p.control(reqReset, resetPurgeRX, p.index); err != nil {
+			return err
+		}
+	}
+	if tx {
+		if err := p.control(reqReset, resetPurgeTX, p.index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBaudRate sets the baud rate of the port and returns the actual
+// rate. Rates that can't be approximated within 3% are rejected. In the
+// asynchronous bitbang mode, the rate at which bytes are clocked out is
+// the baud rate.
+func (p *Port) SetBaudRate(baud int) (int, error) {
+	rate := baud
+	if p.bitbang {
+		rate *= 4
+	}
+	val, idx, actual, err := baudDivisor(p.Chip, p.index, rate)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.control(reqSetBaudRate, val, idx); err != nil {
+		return 0, err
+	}
+	if p.bitbang {
+		actual /= 4
+	}
+	return actual, nil
+}
+
+// SetLineProperties sets the number of data bits (7 or 8), the parity and
+// the stop bits of the line.
+func (p *Port) SetLineProperties(bits int, parity Parity, stop StopBits) error {
+	if bits != 7 && bits != 8 {
+		return fmt.Errorf("unsupported number of data bits %d", bits)
+	}
+	if parity < ParityNone || parity > ParitySpace {
+		return fmt.Errorf("invalid parity %d", parity)
+	}
+	if stop < StopBits1 || stop > StopBits2 {
+		return fmt.Errorf("invalid stop bits %d", stop)
+	}
+	val := uint16(bits) | uint16(parity)<<8 | uint16(stop)<<11 | p.lineValue&0x4000
+	if err := p.control(reqSetData, val, p.index); err != nil {
+		return err
+	}
+	p.lineValue = val
+	return nil
+}
+
+// SetBreak sets or clears the break condition on the line.
+func (p *Port) SetBreak(on bool) error {
+	val := p.lineValue &^ 0x4000
+	if on {
+		val |= 0x4000
+	}
+	if err := p.control(reqSetData, val, p.index); err != nil {
+		return err
+	}
+	p.lineValue = val
+	return nil
+}
+
+// SetFlowControl sets the flow control mode. FlowXonXoff uses the
+// standard XON (0x11) and XOFF (0x13) characters.
+func (p *Port) SetFlowControl(f Flow) error {
+	var val uint16
+	switch f {
+	case FlowNone, FlowRTSCTS, FlowDTRDSR:
+	case FlowXonXoff:
+		val = xon | xoff<<8
+	default:
+		return fmt.Errorf("invalid flow control mode %d", f)
+	}
+	return p.control(reqSetFlowCtrl, val, uint16(f)<<8|p.index)
+}
+
+// SetDTR sets the DTR line.
+func (p *Port) SetDTR(on bool) error {
+	val := uint16(modemDTRMask)
+	if on {
+		val |= modemDTR
+	}
+	return p.control(reqSetModemCtrl, val, p.index)
+}
+
+// SetRTS sets the RTS line.
+func (p *Port) SetRTS(on bool) error {
+	val := uint16(modemRTSMask)
+	if on {
+		val |= modemRTS
+	}
+	return p.control(reqSetModemCtrl, val, p.index)
+}
+
+// ModemStatus polls the modem and line status of the port.
+func (p *Port) ModemStatus() (ModemStatus, error) {
+	var b [2]byte
+	n, err := p.ctl.Control(rTypeIn, reqPollModemStatus, 0, p.index, b[:])
+	if err != nil {
+		return 0, fmt.Errorf("FTDI poll modem status on port %d: %v", p.Number, err)
+	}
+	if n != len(b) {
+		return 0, fmt.Errorf("FTDI poll modem status on port %d: got %d bytes, want %d", p.Number, n, len(b))
+	}
+	return ModemStatus(b[0]) | ModemStatus(b[1])<<8, nil
+}
+
+// SetLatencyTimer sets the time after which the chip sends the received
+// data, if less than a packet is available. The latency is rounded down
+// to milliseconds and must be between 1ms and 255ms.
+func (p *Port) SetLatencyTimer(d time.Duration) error {
+	ms := d / time.Millisecond
+	if ms < 1 || ms > 255 {
+		return fmt.Errorf("invalid latency timer %v, must be between 1ms and 255ms", d)
+	}
+	return p.control(reqSetLatencyTimer, uint16(ms), p.index)
+}
+
+// LatencyTimer returns the latency timer of the port.
+func (p *Port) LatencyTimer() (time.Duration, error) {
+	var b [1]byte
+	n, err := p.ctl.Control(rTypeIn, reqGetLatencyTimer, 0, p.index, b[:])
+	if err != nil {
+		return 0, fmt.Errorf("FTDI get latency timer on port %d: %v", p.Number, err)
+	}
+	if n != len(b) {
+		return 0, fmt.Errorf("FTDI get latency timer on port %d: got %d bytes, want %d", p.Number, n, len(b))
+	}
+	return time.Duration(b[0]) * time.Millisecond, nil
+}
+
+// SetBitMode sets the mode of the pins. The bits set in mask are outputs,
+// the others inputs. BitModeReset returns to the serial mode.
+func (p *Port) SetBitMode(mask byte, mode BitMode) error {
+	if mode == BitModeMPSSE && !p.Chip.hasMPSSE(p.Number) {
+		return fmt.Errorf("port %d of %s has no MPSSE engine", p.Number, p.Chip)
+	}
+	if err := p.control(reqSetBitMode, uint16(mode)<<8|uint16(mask), p.index); err != nil {
+		return err
+	}
+	p.bitbang = mode == BitModeBitbang
+	return nil
+}
+
+// ReadPins returns the current state of the pins in the bitbang modes.
+func (p *Port) ReadPins() (byte, error) {
+	var b [1]byte
+	n, err := p.ctl.Control(rTypeIn, reqReadPins, 0, p.index, b[:])
+	if err != nil {
+		return 0, fmt.Errorf("FTDI read pins on port %d: %v", p.Number, err)
+	}
+	if n != len(b) {
+		return 0, fmt.Errorf("FTDI read pins on port %d: got %d bytes, want %d", p.Number, n, len(b))
+	}
+	return b[0], nil
+}
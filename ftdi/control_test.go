@@ -0,0 +1,109 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func TestControl(t *testing.T) {
+	p, ctl, _ := newFakePort(Chip2232H, 1, map[uint8][]byte{
+		reqPollModemStatus: {0x31, 0x60},
+		reqGetLatencyTimer: {16},
+		reqReadPins:        {0xa5},
+	})
+	for _, f := range []func() error{
+		p.Reset,
+		func() error { return p.Purge(true, true) },
+		func() error { _, err := p.SetBaudRate(3000000); return err },
+		func() error { return p.SetLineProperties(7, ParityEven, StopBits2) },
+		func() error { return p.SetBreak(true) },
+		func() error { return p.SetLineProperties(8, ParityNone, StopBits1) },
+		func() error { return p.SetBreak(false) },
+		func() error { return p.SetFlowControl(FlowRTSCTS) },
+		func() error { return p.SetFlowControl(FlowXonXoff) },
+		func() error { return p.SetDTR(true) },
+		func() error { return p.SetRTS(false) },
+		func() error { return p.SetLatencyTimer(2 * time.Millisecond) },
+		func() error { return p.SetBitMode(0xf0, BitModeBitbang) },
+	} {
+		if err := f(); err != nil {
+			t.Fatalf("control request: %v", err)
+		}
+	}
+	want := []fakeusb.Request{
+		{RType: rTypeOut, Request: reqReset, Val: resetSIO, Idx: 2},
+		{RType: rTypeOut, Request: reqReset, Val: resetPurgeRX, Idx: 2},
+		{RType: rTypeOut, Request: reqReset, Val: resetPurgeTX, Idx: 2},
+		{RType: rTypeOut, Request: reqSetBaudRate, Val: 0x0004, Idx: 0x0202},
+		{RType: rTypeOut, Request: reqSetData, Val: 0x1207, Idx: 2},
+		{RType: rTypeOut, Request: reqSetData, Val: 0x5207, Idx: 2},
+		{RType: rTypeOut, Request: reqSetData, Val: 0x4008, Idx: 2},
+		{RType: rTypeOut, Request: reqSetData, Val: 0x0008, Idx: 2},
+		{RType: rTypeOut, Request: reqSetFlowCtrl, Idx: 0x0102},
+		{RType: rTypeOut, Request: reqSetFlowCtrl, Val: 0x1311, Idx: 0x0402},
+		{RType: rTypeOut, Request: reqSetModemCtrl, Val: 0x0101, Idx: 2},
+		{RType: rTypeOut, Request: reqSetModemCtrl, Val: 0x0200, Idx: 2},
+		{RType: rTypeOut, Request: reqSetLatencyTimer, Val: 2, Idx: 2},
+		{RType: rTypeOut, Request: reqSetBitMode, Val: 0x01f0, Idx: 2},
+	}
+	if got := ctl.Requests(); !reflect.DeepEqual(got, want) {
+		t.Errorf("requests:\ngot  %v\nwant %v", got, want)
+	}
+
+	if got, err := p.ModemStatus(); err != nil || got != StatusCTS|StatusDSR|0x01|StatusTxHoldingEmpty|StatusTxEmpty {
+		t.Errorf("ModemStatus(): got %s, %v, want [CTS DSR THRE TEMT], nil", got, err)
+	}
+	if got, err := p.LatencyTimer(); err != nil || got != 16*time.Millisecond {
+		t.Errorf("LatencyTimer(): got %v, %v, want 16ms, nil", got, err)
+	}
+	if got, err := p.ReadPins(); err != nil || got != 0xa5 {
+		t.Errorf("ReadPins(): got 0x%02x, %v, want 0xa5, nil", got, err)
+	}
+	// The bitbang clock is 4 times the baud rate.
+	n := len(ctl.Requests())
+	if got, err := p.SetBaudRate(9600); err != nil || got != 9600 {
+		t.Errorf("SetBaudRate(9600) in bitbang mode: got %d, %v, want 9600, nil", got, err)
+	}
+	want = []fakeusb.Request{{RType: rTypeOut, Request: reqSetBaudRate, Val: 0x4138, Idx: 0x0202}}
+	if got := ctl.Requests()[n:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("SetBaudRate(9600) in bitbang mode: got requests %v, want %v", got, want)
+	}
+}
+
+func TestControlErrors(t *testing.T) {
+	p, _, _ := newFakePort(ChipR, 0, nil)
+	for _, tc := range []struct {
+		desc string
+		f    func() error
+	}{
+		{"6 data bits", func() error { return p.SetLineProperties(6, ParityNone, StopBits1) }},
+		{"invalid parity", func() error { return p.SetLineProperties(8, ParitySpace+1, StopBits1) }},
+		{"invalid stop bits", func() error { return p.SetLineProperties(8, ParityNone, StopBits2+1) }},
+		{"invalid flow control", func() error { return p.SetFlowControl(Flow(3)) }},
+		{"latency 0", func() error { return p.SetLatencyTimer(0) }},
+		{"latency 1s", func() error { return p.SetLatencyTimer(time.Second) }},
+		{"MPSSE on FT232R", func() error { return p.SetBitMode(0, BitModeMPSSE) }},
+		{"unsupported baud rate", func() error { _, err := p.SetBaudRate(5000000); return err }},
+	} {
+		if err := tc.f(); err == nil {
+			t.Errorf("%s: got nil error, want non-nil", tc.desc)
+		}
+	}
+}
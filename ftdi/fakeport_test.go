@@ -0,0 +1,97 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+// newFakeControl returns a control endpoint that answers the IN requests
+// with the data in in, keyed by request.
+func newFakeControl(in map[uint8][]byte) *fakeusb.Control {
+	return &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+		if r.RType != rTypeIn {
+			return len(data), nil
+		}
+		v, ok := in[r.Request]
+		if !ok {
+			return 0, errors.New("unsupported request")
+		}
+		return copy(data, v), nil
+	}}
+}
+
+// fakeBulk records the data written and returns queued transfers on
+// reads. If respond is set, it is called with every write and its result
+// is queued as a transfer, with status bytes added to every packet.
+type fakeBulk struct {
+	maxPacket int
+	status    [2]byte
+	written   []byte
+	transfers [][]byte
+	respond   func([]byte) []byte
+}
+
+// queue adds a transfer with the payload split into packets, each with
+// status bytes.
+func (f *fakeBulk) queue(payload []byte) {
+	var t []byte
+	for {
+		n := f.maxPacket - statusSize
+		if n > len(payload) {
+			n = len(payload)
+		}
+		t = append(t, f.status[0], f.status[1])
+		t = append(t, payload[:n]...)
+		payload = payload[n:]
+		if len(payload) == 0 {
+			break
+		}
+	}
+	f.transfers = append(f.transfers, t)
+}
+
+func (f *fakeBulk) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if len(f.transfers) == 0 {
+		return 0, errors.New("no data queued")
+	}
+	t := f.transfers[0]
+	f.transfers = f.transfers[1:]
+	if len(t) > len(p) {
+		return 0, errors.New("overflow")
+	}
+	return copy(p, t), nil
+}
+
+func (f *fakeBulk) WriteContext(ctx context.Context, p []byte) (int, error) {
+	f.written = append(f.written, p...)
+	if f.respond != nil {
+		if resp := f.respond(p); len(resp) > 0 {
+			f.queue(resp)
+		}
+	}
+	return len(p), nil
+}
+
+// newFakePort returns a port with fake endpoints. The control endpoint
+// answers the IN requests with the data in in, keyed by request.
+func newFakePort(chip Chip, number int, in map[uint8][]byte) (*Port, *fakeusb.Control, *fakeBulk) {
+	ctl := newFakeControl(in)
+	bulk := &fakeBulk{maxPacket: 64, status: [2]byte{0x01, 0x60}}
+	return newPort(chip, number, ctl, bulk, bulk, 64), ctl, bulk
+}
@@ -0,0 +1,356 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ftdi implements the vendor protocol of FTDI USB serial
+// converters, such as the FT232R, FT2232H, FT4232H and FT232H.
+//
+// Open claims one port of a device and returns a Port, which reads and
+// writes serial data and configures the line:
+//
+//	dev, err := ctx.OpenDeviceWithVIDPID(ftdi.VendorFTDI, ftdi.ProductFT232)
+//	...
+//	defer dev.Close()
+//	p, err := ftdi.Open(dev, 0)
+//	...
+//	defer p.Close()
+//	if _, err := p.SetBaudRate(115200); err != nil {
+//		...
+//	}
+//	err = p.SetLineProperties(8, ftdi.ParityNone, ftdi.StopBits1)
+//
+// Ports of chips with an MPSSE engine can be switched to MPSSE mode to
+// drive SPI, I2C and JTAG buses, see Port.MPSSE. All ports support
+// asynchronous and synchronous bitbang modes, see Port.SetBitMode.
+package ftdi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Vendor and product IDs of FTDI devices.
+const (
+	VendorFTDI     gousb.ID = 0x0403
+	ProductFT232   gousb.ID = 0x6001 // FT232AM, FT232BM, FT232R
+	ProductFT2232  gousb.ID = 0x6010 // FT2232C, FT2232D, FT2232H
+	ProductFT4232H gousb.ID = 0x6011
+	ProductFT232H  gousb.ID = 0x6014
+	ProductFTX     gousb.ID = 0x6015 // FT230X, FT231X, FT234XD
+)
+
+// Chip is the type of the FTDI chip.
+type Chip int
+
+// Chip types, as identified by the device release number.
+const (
+	ChipUnknown Chip = iota
+	ChipAM
+	ChipBM
+	Chip2232C
+	ChipR
+	Chip2232H
+	Chip4232H
+	Chip232H
+	Chip230X
+)
+
+var chipNames = map[Chip]string{
+	ChipUnknown: "unknown FTDI chip",
+	ChipAM:      "FT8U232AM",
+	ChipBM:      "FT232BM",
+	Chip2232C:   "FT2232C",
+	ChipR:       "FT232R",
+	Chip2232H:   "FT2232H",
+	Chip4232H:   "FT4232H",
+	Chip232H:    "FT232H",
+	Chip230X:    "FT230X",
+}
+
+func (c Chip) String() string {
+	if s, ok := chipNames[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("FTDI chip %d", int(c))
+}
+
+// isH returns true for the high speed chips.
+func (c Chip) isH() bool {
+	return c == Chip2232H || c == Chip4232H || c == Chip232H
+}
+
+// indexedBaud returns true if the chip expects the port index in the low
+// byte of the index of the set baud rate request.
+func (c Chip) indexedBaud() bool {
+	return c == Chip2232C || c.isH()
+}
+
+// hasMPSSE returns true if the port of the chip has an MPSSE engine.
+func (c Chip) hasMPSSE(port int) bool {
+	switch c {
+	case Chip2232C, Chip2232H, Chip4232H:
+		return port <= 1
+	case Chip232H:
+		return port == 0
+	}
+	return false
+}
+
+// ChipType returns the type of an FTDI chip, derived from the device
+// release number.
+func ChipType(desc *gousb.DeviceDesc) Chip {
+	switch desc.Device {
+	case 0x0200:
+		return ChipAM
+	case 0x0400:
+		return ChipBM
+	case 0x0500:
+		return Chip2232C
+	case 0x0600:
+		return ChipR
+	case 0x0700:
+		return Chip2232H
+	case 0x0800:
+		return Chip4232H
+	case 0x0900:
+		return Chip232H
+	case 0x1000:
+		return Chip230X
+	}
+	return ChipUnknown
+}
+
+// Match returns true for FTDI serial converters. It can be used with
+// gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	if desc.Vendor != VendorFTDI {
+		return false
+	}
+	switch desc.Product {
+	case ProductFT232, ProductFT2232, ProductFT4232H, ProductFT232H, ProductFTX:
+		return true
+	}
+	return false
+}
+
+// findInterface returns the setting of the interface of the port and its
+// bulk endpoints.
+func findInterface(desc gousb.ConfigDesc, port int) (*gousb.InterfaceSetting, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		if intf.Number != port || len(intf.AltSettings) == 0 {
+			continue
+		}
+		alt := intf.AltSettings[0]
+		var in, out *gousb.EndpointDesc
+		for _, ep := range alt.Endpoints {
+			if ep.TransferType != gousb.TransferTypeBulk {
+				continue
+			}
+			ep := ep
+			if ep.Direction == gousb.EndpointDirectionIn {
+				in = &ep
+			} else {
+				out = &ep
+			}
+		}
+		if in == nil || out == nil {
+			return nil, nil, nil, fmt.Errorf("interface %d has no bulk IN and OUT endpoints", alt.Number)
+		}
+		return &alt, in, out, nil
+	}
+	return nil, nil, nil, fmt.Errorf("%s has no interface for port %d", desc, port)
+}
+
+// controller carries the vendor requests that configure the UART, the
+// modem lines and the bit modes of a port. *gousb.Device implements
+// controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader reads from the bulk IN endpoint. A single read must return the
+// data of a single transfer, packet boundaries are derived from the
+// maximum packet size. *gousb.ReadStream implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer carries the UART data and the MPSSE commands to the port.
+// *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+const (
+	// readPackets is the number of packets in a single read transfer.
+	readPackets = 8
+	// readTransfers is the number of read transfers in flight.
+	readTransfers = 4
+	// statusSize is the size of the status header of every IN packet.
+	statusSize = 2
+)
+
+// Port is a serial port of an FTDI chip.
+type Port struct {
+	// Chip is the type of the chip.
+	Chip Chip
+	// Number is the port number, starting at 0 for port A.
+	Number int
+
+	ctl controller
+	// index is the port index used in control requests, 1 for port A.
+	index uint16
+
+	// mu serializes reads.
+	mu        sync.Mutex
+	r         reader
+	buf       []byte
+	pending   []byte
+	maxPacket int
+	// status is the last ModemStatus received, accessed atomically.
+	status uint32
+
+	w writer
+
+	// lineValue holds the value of the last set data request, used to
+	// set and clear the break condition.
+	lineValue uint16
+	// bitbang is true in the asynchronous bitbang mode, where the baud
+	// rate is multiplied by 4.
+	bitbang bool
+	release func()
+}
+
+func newPort(chip Chip, number int, ctl controller, r reader, w writer, maxPacket int) *Port {
+	return &Port{
+		Chip:      chip,
+		Number:    number,
+		ctl:       ctl,
+		index:     uint16(number + 1),
+		r:         r,
+		buf:       make([]byte, readPackets*maxPacket),
+		maxPacket: maxPacket,
+		w:         w,
+		lineValue: 8,
+	}
+}
+
+// Open claims a port of an FTDI device in its active configuration. Port
+// numbers start at 0 for port A. The Port should be Close()d after use.
+func Open(dev *gousb.Device, port int) (*Port, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, inDesc, outDesc, err := findInterface(desc, port)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	rs, err := in.NewStream(readPackets*inDesc.MaxPacketSize, readTransfers)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	p := newPort(ChipType(dev.Desc), port, dev, rs, out, inDesc.MaxPacketSize)
+	p.release = func() {
+		rs.Close()
+		cfg.Close()
+	}
+	if err := p.Reset(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// strip removes the status bytes at the start of every packet of
+// a transfer, keeping the last status reported.
+func (p *Port) strip(data []byte) []byte {
+	out := data[:0]
+	for off := 0; off < len(data); off += p.maxPacket {
+		end := off + p.maxPacket
+		if end > len(data) {
+			end = len(data)
+		}
+		pkt := data[off:end]
+		if len(pkt) < statusSize {
+			continue
+		}
+		atomic.StoreUint32(&p.status, uint32(pkt[0])|uint32(pkt[1])<<8)
+		out = append(out, pkt[statusSize:]...)
+	}
+	return out
+}
+
+// Read reads data received on the port. Read blocks until at least one
+// byte is available.
+func (p *Port) Read(b []byte) (int, error) {
+	return p.ReadContext(context.Background(), b)
+}
+
+// ReadContext reads data received on the port. ReadContext blocks until
+// at least one byte is available or the context is done.
+func (p *Port) ReadContext(ctx context.Context, b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.pending) == 0 {
+		n, err := p.r.ReadContext(ctx, p.buf)
+		if err != nil {
+			return 0, err
+		}
+		p.pending = p.strip(p.buf[:n])
+	}
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+// Status returns the modem and line status reported in the last packet
+// received from the device.
+func (p *Port) Status() ModemStatus {
+	return ModemStatus(atomic.LoadUint32(&p.status))
+}
+
+// Write sends data on the port.
+func (p *Port) Write(b []byte) (int, error) {
+	return p.w.WriteContext(context.Background(), b)
+}
+
+// WriteContext sends data on the port. The context controls
+// the cancellation of this particular write.
+func (p *Port) WriteContext(ctx context.Context, b []byte) (int, error) {
+	return p.w.WriteContext(ctx, b)
+}
+
+// Close stops reading and releases the interface of the port.
+func (p *Port) Close() error {
+	if p.release != nil {
+		p.release()
+		p.release = nil
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+func TestRead(t *testing.T) {
+	p, _, bulk := newFakePort(ChipR, 0, nil)
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	// A transfer with status only, then 200 bytes in 4 packets.
+	bulk.queue(nil)
+	bulk.queue(data)
+	bulk.status = [2]byte{0x11, 0x62}
+	bulk.queue([]byte("end"))
+	got, err := io.ReadAll(io.LimitReader(p, 203))
+	if err != nil {
+		t.Fatalf("Read(): %v", err)
+	}
+	if want := append(data, "end"...); !bytes.Equal(got, want) {
+		t.Errorf("Read(): got %x, want %x", got, want)
+	}
+	if got, want := p.Status(), StatusCTS|0x01|StatusOverrunError|StatusTxHoldingEmpty|StatusTxEmpty; got != want {
+		t.Errorf("Status(): got %s, want %s", got, want)
+	}
+	// Reads smaller than a packet.
+	bulk.queue([]byte("hello"))
+	var b [2]byte
+	var out []byte
+	for len(out) < 5 {
+		n, err := p.Read(b[:])
+		if err != nil {
+			t.Fatalf("Read(): %v", err)
+		}
+		out = append(out, b[:n]...)
+	}
+	if string(out) != "hello" {
+		t.Errorf("Read() in 2 byte chunks: got %q, want \"hello\"", out)
+	}
+}
+
+func TestChipType(t *testing.T) {
+	for _, tc := range []struct {
+		bcd  gousb.BCD
+		want Chip
+	}{
+		{0x0400, ChipBM},
+		{0x0600, ChipR},
+		{0x0700, Chip2232H},
+		{0x0900, Chip232H},
+		{0x1000, Chip230X},
+		{0x1234, ChipUnknown},
+	} {
+		if got := ChipType(&gousb.DeviceDesc{Device: tc.bcd}); got != tc.want {
+			t.Errorf("ChipType(%s): got %s, want %s", tc.bcd, got, tc.want)
+		}
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	bulk := func(addr gousb.EndpointAddress) gousb.EndpointDesc {
+		dir := gousb.EndpointDirectionOut
+		if addr&0x80 != 0 {
+			dir = gousb.EndpointDirectionIn
+		}
+		return gousb.EndpointDesc{Address: addr, Number: int(addr & 0x0f), Direction: dir, TransferType: gousb.TransferTypeBulk, MaxPacketSize: 512}
+	}
+	port := func(n int, in, out gousb.EndpointAddress) gousb.InterfaceDesc {
+		return gousb.InterfaceDesc{Number: n, AltSettings: []gousb.InterfaceSetting{{
+			Number:    n,
+			Class:     gousb.ClassVendorSpec,
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{in: bulk(in), out: bulk(out)},
+		}}}
+	}
+	cfg := gousb.ConfigDesc{Number: 1, Interfaces: []gousb.InterfaceDesc{port(0, 0x81, 0x02), port(1, 0x83, 0x04)}}
+	alt, in, out, err := findInterface(cfg, 1)
+	if err != nil {
+		t.Fatalf("findInterface(1): %v", err)
+	}
+	if alt.Number != 1 || in.Address != 0x83 || out.Address != 0x04 {
+		t.Errorf("findInterface(1): got interface %d, endpoints %s and %s, want interface 1, endpoints 0x83 and 0x04", alt.Number, in.Address, out.Address)
+	}
+	if _, _, _, err := findInterface(cfg, 2); err == nil {
+		t.Error("findInterface(2) on a dual port chip: got nil error, want non-nil")
+	}
+}
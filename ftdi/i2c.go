@@ -0,0 +1,164 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoAck is returned when an I2C device does not acknowledge its
+// address or a byte written to it.
+var ErrNoAck = errors.New("I2C device did not acknowledge")
+
+// i2cHold is the number of times pin changes are repeated to meet the
+// I2C setup and hold times.
+const i2cHold = 4
+
+// I2C is an I2C bus driven by the MPSSE engine, with SK as SCL and DO and
+// DI connected together as SDA. SDA is released by switching DO to an
+// input, external pull-up resistors are required. Clock stretching is not
+// supported.
+type I2C struct {
+	m *MPSSE
+}
+
+// I2C configures the low byte pins for an I2C bus and sets the clock to
+// at most hz.
+func (m *MPSSE) I2C(ctx context.Context, hz int) (*I2C, error) {
+	// Three phase clocking holds the data for a third of the clock period
+	// after the falling edge, each bit takes 1.5 clock periods.
+	m.cmd(cmd3PhaseOn)
+	if _, err := m.SetClock(ctx, hz*3/2); err != nil {
+		return nil, err
+	}
+	i := &I2C{m: m}
+	i.pins(true, true)
+	if _, err := m.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// pins queues setting SCL and SDA. SCL is driven, SDA is driven low or
+// released.
+func (i *I2C) pins(scl, sda bool) {
+	value := i.m.low &^ (pinSK | pinDO)
+	dir := i.m.lowDir&^(pinDO|pinDI) | pinSK
+	if scl {
+		value |= pinSK
+	}
+	if !sda {
+		dir |= pinDO
+	}
+	for n := 0; n < i2cHold; n++ {
+		i.m.setLow(value, dir)
+	}
+}
+
+// driveSDA queues setting SCL low and SDA as an output, to shift data
+// out.
+func (i *I2C) driveSDA() {
+	i.m.setLow(i.m.low&^pinSK, i.m.lowDir&^pinDI|pinSK|pinDO)
+}
+
+func (i *I2C) start() {
+	i.pins(true, true)
+	i.pins(true, false)
+	i.pins(false, false)
+}
+
+func (i *I2C) stop() {
+	i.pins(false, false)
+	i.pins(true, false)
+	i.pins(true, true)
+}
+
+// writeByte queues writing a byte and reading the acknowledge bit.
+func (i *I2C) writeByte(b byte) {
+	i.driveSDA()
+	i.m.cmd(shiftWriteTDI|shiftWriteNeg, 0, 0, b)
+	i.pins(false, true)
+	i.m.cmd(shiftReadTDO|shiftBits, 0)
+	i.m.reads++
+}
+
+// readByte queues reading a byte and writing the acknowledge bit.
+func (i *I2C) readByte(ack bool) {
+	i.pins(false, true)
+	i.m.cmd(shiftReadTDO, 0, 0)
+	i.m.reads++
+	var bit byte = 0xff
+	if ack {
+		bit = 0
+	}
+	i.driveSDA()
+	i.m.cmd(shiftWriteTDI|shiftWriteNeg|shiftBits, 0, bit)
+	i.pins(false, true)
+}
+
+// Tx writes w to the device at the 7 bit address addr, then reads into r
+// after a repeated start condition. Either w or r may be empty. ErrNoAck
+// is returned if the device does not acknowledge its address or the data
+// written.
+func (i *I2C) Tx(ctx context.Context, addr uint16, w, r []byte) error {
+	if addr > 0x7f {
+		return fmt.Errorf("invalid I2C address 0x%x", addr)
+	}
+	i.start()
+	// acks holds the position of the acknowledge bits in the response,
+	// reads the position of the data read.
+	var acks []int
+	pos := 0
+	if len(w) > 0 || len(r) == 0 {
+		for _, b := range append([]byte{byte(addr << 1)}, w...) {
+			i.writeByte(b)
+			acks = append(acks, pos)
+			pos++
+		}
+		if len(r) > 0 {
+			i.pins(false, true)
+			i.start()
+		}
+	}
+	readPos := -1
+	if len(r) > 0 {
+		i.writeByte(byte(addr<<1) | 1)
+		acks = append(acks, pos)
+		pos++
+		readPos = pos
+		for n := range r {
+			i.readByte(n < len(r)-1)
+		}
+	}
+	i.stop()
+	got, err := i.m.Flush(ctx)
+	if err != nil {
+		return err
+	}
+	for n, p := range acks {
+		if got[p]&0x01 != 0 {
+			if n == 0 || p == readPos-1 {
+				return fmt.Errorf("I2C address 0x%02x: %w", addr, ErrNoAck)
+			}
+			return fmt.Errorf("I2C address 0x%02x, byte %d: %w", addr, n-1, ErrNoAck)
+		}
+	}
+	if readPos >= 0 {
+		copy(r, got[readPos:])
+	}
+	return nil
+}
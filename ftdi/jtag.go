@@ -0,0 +1,123 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"context"
+	"fmt"
+)
+
+// JTAG is a JTAG port driven by the MPSSE engine, with SK as TCK, DO as
+// TDI, DI as TDO and CS as TMS. Data is shifted least significant bit
+// first. Shift operations start and end in the Run-Test/Idle state.
+type JTAG struct {
+	m *MPSSE
+}
+
+// JTAG configures the low byte pins for a JTAG port, sets the clock to at
+// most hz and resets the TAP to the Run-Test/Idle state.
+func (m *MPSSE) JTAG(ctx context.Context, hz int) (*JTAG, error) {
+	if _, err := m.SetClock(ctx, hz); err != nil {
+		return nil, err
+	}
+	m.setLow(m.low&^(pinSK|pinDO)|pinCS, m.lowDir&^pinDI|pinSK|pinDO|pinCS)
+	j := &JTAG{m: m}
+	if err := j.Reset(ctx); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// tms queues clocking n bits (at most 7) of TMS, least significant bit
+// first, with TDI held at tdi. If read is true, TDO is sampled on every
+// bit.
+func (j *JTAG) tms(bits byte, n int, tdi, read bool) {
+	op := byte(shiftWriteTMS | shiftBits | shiftLSB | shiftWriteNeg)
+	if read {
+		op |= shiftReadTDO
+		j.m.reads++
+	}
+	b := bits & 0x7f
+	if tdi {
+		b |= 0x80
+	}
+	j.m.cmd(op, byte(n-1), b)
+}
+
+// Reset moves the TAP to Test-Logic-Reset with 5 clocks of TMS high, then
+// to Run-Test/Idle.
+func (j *JTAG) Reset(ctx context.Context) error {
+	j.tms(0x1f, 6, false, false)
+	_, err := j.m.Flush(ctx)
+	return err
+}
+
+// shift queues shifting bits of tdi while in a Shift state. The last bit is
+// shifted with TMS high, which moves the TAP to the Exit1 state. It returns
+// a function assembling the bits read from the response.
+func (j *JTAG) shift(tdi []byte, bits int) func([]byte) []byte {
+	full := (bits - 1) / 8
+	rem := (bits - 1) % 8
+	op := byte(shiftWriteTDI | shiftReadTDO | shiftLSB | shiftWriteNeg)
+	start := j.m.reads
+	j.m.shiftBytes(op, tdi, full)
+	if rem > 0 {
+		j.m.cmd(op|shiftBits, byte(rem-1), tdi[full])
+		j.m.reads++
+	}
+	last := tdi[(bits-1)/8]>>uint((bits-1)%8)&1 != 0
+	j.tms(0x01, 1, last, true)
+	return func(resp []byte) []byte {
+		resp = resp[start:]
+		out := make([]byte, (bits+7)/8)
+		copy(out, resp[:full])
+		if rem > 0 {
+			// Bits are shifted in from the top of the byte.
+			out[full] = resp[full] >> uint(8-rem)
+			resp = resp[1:]
+		}
+		out[(bits-1)/8] |= resp[full] >> 7 << uint((bits-1)%8)
+		return out
+	}
+}
+
+func (j *JTAG) shiftReg(ctx context.Context, toShift byte, n int, tdi []byte, bits int) ([]byte, error) {
+	if bits <= 0 || len(tdi) < (bits+7)/8 {
+		return nil, fmt.Errorf("JTAG shift of %d bits with %d bytes of data", bits, len(tdi))
+	}
+	j.tms(toShift, n, false, false)
+	assemble := j.shift(tdi, bits)
+	// Exit1 -> Update -> Run-Test/Idle
+	j.tms(0x01, 2, false, false)
+	resp, err := j.m.Flush(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return assemble(resp), nil
+}
+
+// ShiftIR shifts bits of tdi into the instruction register and returns
+// the bits shifted out.
+func (j *JTAG) ShiftIR(ctx context.Context, tdi []byte, bits int) ([]byte, error) {
+	// Run-Test/Idle -> Select-DR -> Select-IR -> Capture-IR -> Shift-IR
+	return j.shiftReg(ctx, 0x03, 4, tdi, bits)
+}
+
+// ShiftDR shifts bits of tdi into the data register and returns the bits
+// shifted out.
+func (j *JTAG) ShiftDR(ctx context.Context, tdi []byte, bits int) ([]byte, error) {
+	// Run-Test/Idle -> Select-DR -> Capture-DR -> Shift-DR
+	return j.shiftReg(ctx, 0x01, 3, tdi, bits)
+}
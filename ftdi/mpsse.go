@@ -0,0 +1,242 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Flags of the MPSSE data shifting commands.
+const (
+	shiftWriteNeg = 0x01 // change output on the falling clock edge
+	shiftBits     = 0x02 // shift bits instead of bytes
+	shiftReadNeg  = 0x04 // sample input on the falling clock edge
+	shiftLSB      = 0x08 // least significant bit first
+	shiftWriteTDI = 0x10 // shift data out on TDI/DO
+	shiftReadTDO  = 0x20 // shift data in from TDO/DI
+	shiftWriteTMS = 0x40 // shift data out on TMS/CS
+)
+
+// Other MPSSE commands.
+const (
+	cmdSetLow        = 0x80
+	cmdReadLow       = 0x81
+	cmdSetHigh       = 0x82
+	cmdReadHigh      = 0x83
+	cmdLoopbackOff   = 0x85
+	cmdSetDivisor    = 0x86
+	cmdSendImmediate = 0x87
+	cmdDisableDiv5   = 0x8a
+	cmd3PhaseOn      = 0x8c
+	cmd3PhaseOff     = 0x8d
+	cmdAdaptiveOff   = 0x97
+	// cmdBogus is an invalid command, echoed by the engine after
+	// respBadCommand.
+	cmdBogus       = 0xaa
+	respBadCommand = 0xfa
+)
+
+// Pins of the low byte used by the serial protocols.
+const (
+	pinSK = 0x01 // SK, TCK, SCL
+	pinDO = 0x02 // DO, TDI, MOSI, SDA out
+	pinDI = 0x04 // DI, TDO, MISO, SDA in
+	pinCS = 0x08 // CS, TMS
+)
+
+// maxShift is the maximum number of bytes shifted by a single command.
+const maxShift = 1 << 16
+
+// MPSSE is the Multi-Protocol Synchronous Serial Engine of a port.
+// Commands are queued and sent to the device together with the next
+// command that reads data.
+type MPSSE struct {
+	p *Port
+	// cmds holds the queued commands.
+	cmds []byte
+	// reads is the number of bytes returned by the queued commands.
+	reads int
+	// low and lowDir are the values and directions of the low byte pins,
+	// high and highDir of the high byte pins.
+	low, lowDir   byte
+	high, highDir byte
+}
+
+// MPSSE switches the port to MPSSE mode and checks that the engine is in
+// sync. All pins are inputs until configured.
+func (p *Port) MPSSE(ctx context.Context) (*MPSSE, error) {
+	if err := p.SetBitMode(0, BitModeReset); err != nil {
+		return nil, err
+	}
+	if err := p.SetBitMode(0, BitModeMPSSE); err != nil {
+		return nil, err
+	}
+	if err := p.SetLatencyTimer(time.Millisecond); err != nil {
+		return nil, err
+	}
+	if err := p.Purge(true, true); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.pending = nil
+	p.mu.Unlock()
+	m := &MPSSE{p: p}
+	// An invalid command is answered with respBadCommand and the command.
+	m.cmd(cmdBogus)
+	m.reads += 2
+	got, err := m.Flush(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if got[0] != respBadCommand || got[1] != cmdBogus {
+		return nil, fmt.Errorf("MPSSE on port %d out of sync: got %x in response to a bad command", p.Number, got)
+	}
+	m.cmd(cmdLoopbackOff, cmdAdaptiveOff, cmd3PhaseOff)
+	if p.Chip.isH() {
+		m.cmd(cmdDisableDiv5)
+	}
+	m.cmd(cmdSetLow, 0, 0, cmdSetHigh, 0, 0)
+	if _, err := m.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// cmd queues commands.
+func (m *MPSSE) cmd(b ...byte) {
+	m.cmds = append(m.cmds, b...)
+}
+
+// Flush sends the queued commands and returns the data read by them.
+func (m *MPSSE) Flush(ctx context.Context) ([]byte, error) {
+	if m.reads > 0 {
+		m.cmd(cmdSendImmediate)
+	}
+	cmds, reads := m.cmds, m.reads
+	m.cmds, m.reads = m.cmds[:0], 0
+	if len(cmds) > 0 {
+		if _, err := m.p.WriteContext(ctx, cmds); err != nil {
+			return nil, fmt.Errorf("sending MPSSE commands: %v", err)
+		}
+	}
+	out := make([]byte, reads)
+	for got := 0; got < reads; {
+		n, err := m.p.ReadContext(ctx, out[got:])
+		if err != nil {
+			return nil, fmt.Errorf("reading MPSSE response: %v", err)
+		}
+		got += n
+	}
+	return out, nil
+}
+
+// baseClock returns the clock of the engine, before the divisor.
+func (m *MPSSE) baseClock() int {
+	if m.p.Chip.isH() {
+		return 60000000
+	}
+	return 12000000
+}
+
+// SetClock sets the frequency of the clock to the highest frequency not
+// above hz and returns the actual frequency.
+func (m *MPSSE) SetClock(ctx context.Context, hz int) (int, error) {
+	if hz <= 0 {
+		return 0, fmt.Errorf("invalid clock frequency %d", hz)
+	}
+	base := m.baseClock()
+	div := (base/2+hz-1)/hz - 1
+	if div < 0 {
+		div = 0
+	}
+	if div > 0xffff {
+		div = 0xffff
+	}
+	m.cmd(cmdSetDivisor, byte(div), byte(div>>8))
+	if _, err := m.Flush(ctx); err != nil {
+		return 0, err
+	}
+	return base / (2 * (div + 1)), nil
+}
+
+func (m *MPSSE) setLow(value, dir byte) {
+	m.low, m.lowDir = value, dir
+	m.cmd(cmdSetLow, value, dir)
+}
+
+// SetLowPins sets the values and directions of the pins of the low byte
+// (ADBUS or BDBUS). The bits set in dir are outputs. The four lowest pins
+// are used by the SPI, I2C and JTAG helpers.
+func (m *MPSSE) SetLowPins(ctx context.Context, value, dir byte) error {
+	m.setLow(value, dir)
+	_, err := m.Flush(ctx)
+	return err
+}
+
+// SetHighPins sets the values and directions of the pins of the high byte
+// (ACBUS or BCBUS). The bits set in dir are outputs.
+func (m *MPSSE) SetHighPins(ctx context.Context, value, dir byte) error {
+	m.high, m.highDir = value, dir
+	m.cmd(cmdSetHigh, value, dir)
+	_, err := m.Flush(ctx)
+	return err
+}
+
+// ReadLowPins returns the state of the pins of the low byte.
+func (m *MPSSE) ReadLowPins(ctx context.Context) (byte, error) {
+	m.cmd(cmdReadLow)
+	m.reads++
+	b, err := m.Flush(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// ReadHighPins returns the state of the pins of the high byte.
+func (m *MPSSE) ReadHighPins(ctx context.Context) (byte, error) {
+	m.cmd(cmdReadHigh)
+	m.reads++
+	b, err := m.Flush(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// shiftBytes queues a command shifting whole bytes, split as needed.
+// For read only commands, data is nil and n is the number of bytes.
+func (m *MPSSE) shiftBytes(op byte, data []byte, n int) {
+	for off := 0; off < n; off += maxShift {
+		l := n - off
+		if l > maxShift {
+			l = maxShift
+		}
+		m.cmd(op, byte(l-1), byte((l-1)>>8))
+		if op&shiftWriteTDI != 0 {
+			m.cmd(data[off : off+l]...)
+		}
+		if op&shiftReadTDO != 0 {
+			m.reads += l
+		}
+	}
+}
+
+// Close returns the port to the serial mode.
+func (m *MPSSE) Close() error {
+	return m.p.SetBitMode(0, BitModeReset)
+}
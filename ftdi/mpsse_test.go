@@ -0,0 +1,351 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// mpsseCmd is a command parsed by fakeMPSSE.
+type mpsseCmd struct {
+	op   byte
+	n    int
+	data []byte
+}
+
+// fakeMPSSE parses MPSSE commands and answers the commands that read
+// data. Data read by shift commands is returned by tdo.
+type fakeMPSSE struct {
+	cmds []mpsseCmd
+	tdo  func(op byte, data []byte, n int) []byte
+}
+
+func (f *fakeMPSSE) respond(p []byte) []byte {
+	var resp []byte
+	for len(p) > 0 {
+		op := p[0]
+		p = p[1:]
+		switch {
+		case op == cmdSetLow || op == cmdSetHigh || op == cmdSetDivisor:
+			f.cmds = append(f.cmds, mpsseCmd{op: op, data: append([]byte(nil), p[:2]...)})
+			p = p[2:]
+		case op == cmdReadLow || op == cmdReadHigh:
+			f.cmds = append(f.cmds, mpsseCmd{op: op})
+			resp = append(resp, 0x5a)
+		case op&0x80 != 0:
+			if op == cmdBogus {
+				resp = append(resp, respBadCommand, op)
+			}
+			if op != cmdSendImmediate {
+				f.cmds = append(f.cmds, mpsseCmd{op: op})
+			}
+		default:
+			c := mpsseCmd{op: op}
+			if op&shiftBits != 0 {
+				c.n = int(p[0]) + 1
+				p = p[1:]
+				if op&(shiftWriteTDI|shiftWriteTMS) != 0 {
+					c.data = append([]byte(nil), p[0])
+					p = p[1:]
+				}
+			} else {
+				c.n = int(p[0]) | int(p[1])<<8 + 1
+				p = p[2:]
+				if op&shiftWriteTDI != 0 {
+					c.data = append([]byte(nil), p[:c.n]...)
+					p = p[c.n:]
+				}
+			}
+			f.cmds = append(f.cmds, c)
+			if op&shiftReadTDO != 0 {
+				resp = append(resp, f.tdo(op, c.data, c.n)...)
+			}
+		}
+	}
+	return resp
+}
+
+func newFakeMPSSE(t *testing.T, chip Chip) (*MPSSE, *fakeMPSSE) {
+	t.Helper()
+	p, _, bulk := newFakePort(chip, 0, nil)
+	f := &fakeMPSSE{tdo: func(op byte, data []byte, n int) []byte {
+		if op&shiftBits != 0 {
+			return []byte{0}
+		}
+		return make([]byte, n)
+	}}
+	bulk.respond = f.respond
+	m, err := p.MPSSE(context.Background())
+	if err != nil {
+		t.Fatalf("MPSSE(): %v", err)
+	}
+	f.cmds = nil
+	return m, f
+}
+
+func TestMPSSE(t *testing.T) {
+	ctx := context.Background()
+	m, f := newFakeMPSSE(t, Chip232H)
+	for _, tc := range []struct {
+		hz, want int
+		div      []byte
+	}{
+		{30000000, 30000000, []byte{0, 0}},
+		{1000000, 1000000, []byte{29, 0}},
+		{7000000, 6000000, []byte{4, 0}},
+		{100, 457, []byte{0xff, 0xff}},
+	} {
+		f.cmds = nil
+		got, err := m.SetClock(ctx, tc.hz)
+		if err != nil || got != tc.want {
+			t.Errorf("SetClock(%d): got %d, %v, want %d, nil", tc.hz, got, err, tc.want)
+		}
+		if want := []mpsseCmd{{op: cmdSetDivisor, data: tc.div}}; !reflect.DeepEqual(f.cmds, want) {
+			t.Errorf("SetClock(%d): got commands %v, want %v", tc.hz, f.cmds, want)
+		}
+	}
+	f.cmds = nil
+	if err := m.SetHighPins(ctx, 0x01, 0x03); err != nil {
+		t.Fatalf("SetHighPins(): %v", err)
+	}
+	if got, err := m.ReadLowPins(ctx); err != nil || got != 0x5a {
+		t.Errorf("ReadLowPins(): got 0x%02x, %v, want 0x5a, nil", got, err)
+	}
+	if want := []mpsseCmd{{op: cmdSetHigh, data: []byte{0x01, 0x03}}, {op: cmdReadLow}}; !reflect.DeepEqual(f.cmds, want) {
+		t.Errorf("pin commands: got %v, want %v", f.cmds, want)
+	}
+
+	p, _, bulk := newFakePort(Chip2232H, 0, nil)
+	bulk.respond = func([]byte) []byte { return []byte{0x00, 0x00} }
+	if _, err := p.MPSSE(ctx); err == nil {
+		t.Error("MPSSE() out of sync: got nil error, want non-nil")
+	}
+}
+
+func TestSPI(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		mode   int
+		op     byte
+		idleSK byte
+	}{
+		{0, shiftWriteNeg, 0},
+		{1, shiftReadNeg, 0},
+		{2, shiftReadNeg, pinSK},
+		{3, shiftWriteNeg, pinSK},
+	} {
+		m, f := newFakeMPSSE(t, Chip232H)
+		f.tdo = func(op byte, data []byte, n int) []byte {
+			out := make([]byte, n)
+			for i := range out {
+				out[i] = ^data[i]
+			}
+			return out
+		}
+		s, err := m.SPI(ctx, tc.mode, 10000000)
+		if err != nil {
+			t.Fatalf("SPI(mode %d): %v", tc.mode, err)
+		}
+		f.cmds = nil
+		r := make([]byte, 3)
+		if err := s.Tx(ctx, []byte{1, 2, 3}, r); err != nil {
+			t.Fatalf("mode %d: Tx(): %v", tc.mode, err)
+		}
+		if !bytes.Equal(r, []byte{0xfe, 0xfd, 0xfc}) {
+			t.Errorf("mode %d: Tx(): read %x, want fefdfc", tc.mode, r)
+		}
+		idle := pinCS | tc.idleSK
+		want := []mpsseCmd{
+			{op: cmdSetLow, data: []byte{idle &^ pinCS, 0x0b}},
+			{op: tc.op | shiftWriteTDI | shiftReadTDO, n: 3, data: []byte{1, 2, 3}},
+			{op: cmdSetLow, data: []byte{idle, 0x0b}},
+		}
+		if !reflect.DeepEqual(f.cmds, want) {
+			t.Errorf("mode %d: Tx(): got commands\n%v\nwant\n%v", tc.mode, f.cmds, want)
+		}
+	}
+	m, _ := newFakeMPSSE(t, Chip232H)
+	s, err := m.SPI(ctx, 0, 1000000)
+	if err != nil {
+		t.Fatalf("SPI(): %v", err)
+	}
+	if err := s.Tx(ctx, []byte{1, 2}, make([]byte, 3)); err == nil {
+		t.Error("Tx() with different lengths: got nil error, want non-nil")
+	}
+	if _, err := m.SPI(ctx, 4, 1000000); err == nil {
+		t.Error("SPI(mode 4): got nil error, want non-nil")
+	}
+}
+
+func TestI2C(t *testing.T) {
+	ctx := context.Background()
+	m, f := newFakeMPSSE(t, Chip232H)
+	var written []byte
+	nackAt := -1
+	f.tdo = func(op byte, data []byte, n int) []byte {
+		if op&shiftBits != 0 {
+			// acknowledge bit
+			ack := byte(0)
+			if len(written)-1 == nackAt {
+				ack = 1
+			}
+			return []byte{ack}
+		}
+		return []byte{0xc0 + byte(n)}
+	}
+	i, err := m.I2C(ctx, 100000)
+	if err != nil {
+		t.Fatalf("I2C(): %v", err)
+	}
+	record := func() {
+		written = nil
+		for _, c := range f.cmds {
+			if c.op == shiftWriteTDI|shiftWriteNeg {
+				written = append(written, c.data...)
+			}
+		}
+	}
+	// Record the bytes written as they are parsed, for the NACK position.
+	tdo := f.tdo
+	f.tdo = func(op byte, data []byte, n int) []byte {
+		record()
+		return tdo(op, data, n)
+	}
+
+	f.cmds = nil
+	r := make([]byte, 2)
+	if err := i.Tx(ctx, 0x50, []byte{0x10}, r); err != nil {
+		t.Fatalf("Tx(): %v", err)
+	}
+	record()
+	if want := []byte{0xa0, 0x10, 0xa1}; !bytes.Equal(written, want) {
+		t.Errorf("Tx(): wrote %x, want %x", written, want)
+	}
+	if !bytes.Equal(r, []byte{0xc1, 0xc1}) {
+		t.Errorf("Tx(): read %x, want c1c1", r)
+	}
+	var acks []byte
+	for _, c := range f.cmds {
+		if c.op == shiftWriteTDI|shiftWriteNeg|shiftBits {
+			acks = append(acks, c.data...)
+		}
+	}
+	if want := []byte{0x00, 0xff}; !bytes.Equal(acks, want) {
+		t.Errorf("Tx(): sent acknowledge bits %x, want %x", acks, want)
+	}
+
+	f.cmds = nil
+	for _, tc := range []struct {
+		nackAt int
+		w      []byte
+	}{
+		{0, nil},
+		{1, []byte{0x10, 0x20}},
+	} {
+		nackAt = tc.nackAt
+		if err := i.Tx(ctx, 0x50, tc.w, nil); !errors.Is(err, ErrNoAck) {
+			t.Errorf("Tx() with a NACK on byte %d: got %v, want ErrNoAck", tc.nackAt, err)
+		}
+		f.cmds = nil
+	}
+	if err := i.Tx(ctx, 0x80, nil, nil); err == nil {
+		t.Error("Tx() to address 0x80: got nil error, want non-nil")
+	}
+}
+
+func TestJTAG(t *testing.T) {
+	ctx := context.Background()
+	m, f := newFakeMPSSE(t, Chip2232H)
+	// Loop TDI back to TDO. Bits read least significant bit first end up
+	// in the top of the byte.
+	f.tdo = func(op byte, data []byte, n int) []byte {
+		switch {
+		case op&shiftWriteTMS != 0:
+			return []byte{data[0] & 0x80}
+		case op&shiftBits != 0:
+			return []byte{data[0] << uint(8-n)}
+		}
+		return data
+	}
+	j, err := m.JTAG(ctx, 1000000)
+	if err != nil {
+		t.Fatalf("JTAG(): %v", err)
+	}
+	tmsOp := byte(shiftWriteTMS | shiftBits | shiftLSB | shiftWriteNeg)
+	shiftOp := byte(shiftWriteTDI | shiftReadTDO | shiftLSB | shiftWriteNeg)
+	for _, tc := range []struct {
+		bits int
+		tdi  []byte
+		want []mpsseCmd
+	}{
+		{
+			bits: 13,
+			tdi:  []byte{0xa5, 0x13},
+			want: []mpsseCmd{
+				{op: tmsOp, n: 3, data: []byte{0x01}},
+				{op: shiftOp, n: 1, data: []byte{0xa5}},
+				{op: shiftOp | shiftBits, n: 4, data: []byte{0x13}},
+				{op: tmsOp | shiftReadTDO, n: 1, data: []byte{0x81}},
+				{op: tmsOp, n: 2, data: []byte{0x01}},
+			},
+		},
+		{
+			bits: 1,
+			tdi:  []byte{0x00},
+			want: []mpsseCmd{
+				{op: tmsOp, n: 3, data: []byte{0x01}},
+				{op: tmsOp | shiftReadTDO, n: 1, data: []byte{0x01}},
+				{op: tmsOp, n: 2, data: []byte{0x01}},
+			},
+		},
+		{
+			bits: 16,
+			tdi:  []byte{0x34, 0x92},
+			want: []mpsseCmd{
+				{op: tmsOp, n: 3, data: []byte{0x01}},
+				{op: shiftOp, n: 1, data: []byte{0x34}},
+				{op: shiftOp | shiftBits, n: 7, data: []byte{0x92}},
+				{op: tmsOp | shiftReadTDO, n: 1, data: []byte{0x81}},
+				{op: tmsOp, n: 2, data: []byte{0x01}},
+			},
+		},
+	} {
+		f.cmds = nil
+		got, err := j.ShiftDR(ctx, tc.tdi, tc.bits)
+		if err != nil {
+			t.Fatalf("ShiftDR(%d bits): %v", tc.bits, err)
+		}
+		if !bytes.Equal(got, tc.tdi) {
+			t.Errorf("ShiftDR(%d bits): got %x, want %x", tc.bits, got, tc.tdi)
+		}
+		if !reflect.DeepEqual(f.cmds, tc.want) {
+			t.Errorf("ShiftDR(%d bits): got commands\n%v\nwant\n%v", tc.bits, f.cmds, tc.want)
+		}
+	}
+	f.cmds = nil
+	if _, err := j.ShiftIR(ctx, []byte{0x05}, 4); err != nil {
+		t.Fatalf("ShiftIR(): %v", err)
+	}
+	if got, want := f.cmds[0], (mpsseCmd{op: tmsOp, n: 4, data: []byte{0x03}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ShiftIR(): got first command %v, want %v", got, want)
+	}
+	if _, err := j.ShiftDR(ctx, []byte{0x01}, 9); err == nil {
+		t.Error("ShiftDR() with missing data: got nil error, want non-nil")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdi
+
+import (
+	"context"
+	"fmt"
+)
+
+// SPI is an SPI bus driven by the MPSSE engine, with SK as the clock, DO
+// as MOSI, DI as MISO and CS as the active low chip select.
+type SPI struct {
+	m  *MPSSE
+	op byte
+}
+
+// SPI configures the low byte pins for an SPI bus in the given mode (0 to
+// 3) and sets the clock to at most hz.
+func (m *MPSSE) SPI(ctx context.Context, mode int, hz int) (*SPI, error) {
+	if mode < 0 || mode > 3 {
+		return nil, fmt.Errorf("invalid SPI mode %d", mode)
+	}
+	if _, err := m.SetClock(ctx, hz); err != nil {
+		return nil, err
+	}
+	// Modes 0 and 3 sample on the rising edge, 1 and 2 on the falling
+	// edge. Data is changed on the other edge.
+	s := &SPI{m: m, op: shiftWriteNeg}
+	if mode == 1 || mode == 2 {
+		s.op = shiftReadNeg
+	}
+	value := m.low&^(pinSK|pinDO) | pinCS
+	if mode >= 2 {
+		value |= pinSK
+	}
+	if err := m.SetLowPins(ctx, value, m.lowDir&^pinDI|pinSK|pinDO|pinCS); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Tx asserts the chip select, writes w and reads into r at the same time,
+// then deasserts the chip select. Either w or r may be nil, otherwise they
+// must have the same length.
+func (s *SPI) Tx(ctx context.Context, w, r []byte) error {
+	n := len(w)
+	if r != nil {
+		if w != nil && len(r) != len(w) {
+			return fmt.Errorf("SPI transfer with %d bytes to write and %d to read", len(w), len(r))
+		}
+		n = len(r)
+	}
+	if n == 0 {
+		return nil
+	}
+	op := s.op
+	if w != nil {
+		op |= shiftWriteTDI
+	}
+	if r != nil {
+		op |= shiftReadTDO
+	}
+	m := s.m
+	idle, dir := m.low, m.lowDir
+	m.setLow(idle&^pinCS, dir)
+	m.shiftBytes(op, w, n)
+	m.setLow(idle, dir)
+	got, err := m.Flush(ctx)
+	if err != nil {
+		return err
+	}
+	copy(r, got)
+	return nil
+}
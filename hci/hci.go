@@ -0,0 +1,615 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hci implements the USB transport of Bluetooth controllers, HCI
+// over USB, as used by Bluetooth dongles and combo chips.
+//
+// Open claims the HCI interface of a device and starts receiving events:
+//
+//	c, err := hci.Open(dev)
+//	...
+//	defer c.Close()
+//	if _, err := c.Command(ctx, hci.OpReset, nil); err != nil {
+//		...
+//	}
+//	ret, err := c.Command(ctx, hci.OpReadBDAddr, nil)
+//	...
+//	fmt.Printf("BD_ADDR %x\n", ret[1:7])
+//
+// Commands are sent as class requests on the control endpoint and events
+// are received on the interrupt endpoint. ACL data goes over the bulk
+// endpoints, and SCO data over the isochronous endpoints of the second
+// interface once enabled with SetSCO.
+package hci
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+const (
+	subClassRF        gousb.Class    = 0x01
+	protocolBluetooth gousb.Protocol = 0x01
+
+	reqCommand = 0x00
+	// rTypeCommand is the request type of commands: a class request to
+	// the device, sent to the first interface.
+	rTypeCommand = gousb.ControlOut | gousb.ControlClass | gousb.ControlDevice
+)
+
+const (
+	// maxQueuedEvents is the number of events queued for ReadEvent.
+	// Events received while the queue is full are dropped.
+	maxQueuedEvents = 256
+	// aclBufferSize is the size of the transfers of the bulk IN endpoint.
+	aclBufferSize = 4096
+	// scoPackets is the number of isochronous packets of the transfers of
+	// the isochronous IN endpoint.
+	scoPackets = 8
+)
+
+var (
+	// ErrClosed is returned by the methods of a closed Conn.
+	ErrClosed = errors.New("hci: connection closed")
+	// ErrNoSCO is returned by ReadSCO and WriteSCO if SCO data isn't
+	// enabled with SetSCO.
+	ErrNoSCO = errors.New("hci: SCO data not enabled")
+)
+
+// Error is the status of a failed command, returned in a Command Complete
+// or Command Status event.
+type Error struct {
+	Opcode Opcode
+	Status uint8
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("HCI command %s failed with status 0x%02x", e.Opcode, e.Status)
+}
+
+// controller carries the HCI command packets, sent as class requests to
+// the device. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the HCI event, ACL or SCO packets of one endpoint, a
+// packet possibly spanning several transfers. *gousb.InEndpoint implements
+// reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the ACL or SCO packets of one endpoint.
+// *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// SCOSetting is an alternate setting of the isochronous interface. The
+// settings carry increasing numbers of SCO channels: setting N usually
+// carries N 8kHz 16-bit voice channels.
+type SCOSetting struct {
+	Alternate int
+	// MaxPacketSize is the maximum packet size of the isochronous
+	// endpoints.
+	MaxPacketSize int
+}
+
+// Conn is an open HCI interface.
+type Conn struct {
+	// SCOSettings are the alternate settings of the isochronous interface,
+	// empty if the device has none.
+	SCOSettings []SCOSetting
+
+	ctl  controller
+	intf uint16
+
+	// cmdMu serializes the commands.
+	cmdMu    sync.Mutex
+	complete chan *Event
+
+	// mu protects the fields below.
+	mu      sync.Mutex
+	waiting bool
+	pending Opcode
+	events  []*Event
+	ready   chan struct{}
+	rerr    error
+
+	aclInMu  sync.Mutex
+	aclIn    reader
+	aclBuf   []byte
+	acl      assembler
+	aclOutMu sync.Mutex
+	aclOut   writer
+
+	scoMu   sync.Mutex
+	sco     *scoLink
+	openSCO func(alt int) (*scoLink, error)
+
+	cancel  context.CancelFunc
+	done    chan struct{}
+	release func()
+}
+
+// scoLink is the isochronous interface in an alternate setting other than
+// 0.
+type scoLink struct {
+	rmu sync.Mutex
+	in  reader
+	buf []byte
+	asm assembler
+
+	wmu sync.Mutex
+	out writer
+
+	release func()
+}
+
+func newSCOLink(in reader, out writer, maxPacket int) *scoLink {
+	return &scoLink{
+		in:  in,
+		buf: make([]byte, scoPackets*maxPacket),
+		asm: assembler{headerSize: scoHeaderSize, length: scoLength},
+		out: out,
+	}
+}
+
+func newConn(ctl controller, intf int, aclIn reader, aclOut writer, aclMaxPacket int) *Conn {
+	size := aclBufferSize
+	if aclMaxPacket > 0 {
+		size = (size + aclMaxPacket - 1) / aclMaxPacket * aclMaxPacket
+	}
+	return &Conn{
+		ctl:      ctl,
+		intf:     uint16(intf),
+		complete: make(chan *Event, 1),
+		ready:    make(chan struct{}, 1),
+		aclIn:    aclIn,
+		aclBuf:   make([]byte, size),
+		acl:      assembler{headerSize: aclHeaderSize, length: aclLength},
+		aclOut:   aclOut,
+		done:     make(chan struct{}),
+	}
+}
+
+// start receives the events of the interrupt endpoint until the context
+// is done.
+func (c *Conn) start(ctx context.Context, r reader, maxPacket int) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(c.done)
+		buf := make([]byte, maxPacket)
+		asm := assembler{headerSize: eventHeaderSize, length: eventLength}
+		for {
+			n, err := r.ReadContext(ctx, buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					err = ErrClosed
+				}
+				c.fail(err)
+				return
+			}
+			asm.add(buf[:n])
+			for p := asm.next(); p != nil; p = asm.next() {
+				if ev, err := parseEvent(p); err == nil {
+					c.dispatch(ev)
+				}
+			}
+		}
+	}()
+}
+
+// dispatch hands the completion of the pending command to Command, and
+// queues the other events for ReadEvent.
+func (c *Conn) dispatch(ev *Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.waiting {
+		var op Opcode
+		switch {
+		case ev.Code == EventCommandComplete && len(ev.Params) >= 3:
+			op = Opcode(binary.LittleEndian.Uint16(ev.Params[1:]))
+		case ev.Code == EventCommandStatus && len(ev.Params) >= 4:
+			op = Opcode(binary.LittleEndian.Uint16(ev.Params[2:]))
+		}
+		if op != 0 && op == c.pending {
+			c.waiting = false
+			c.complete <- ev
+			return
+		}
+	}
+	if len(c.events) >= maxQueuedEvents {
+		return
+	}
+	c.events = append(c.events, ev)
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rerr = err
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Command sends a command and waits for its Command Complete or Command
+// Status event. It returns the return parameters of Command Complete, the
+// first of which is the status, or nil for Command Status. A non-zero
+// status is returned as an *Error, along with the return parameters.
+// Commands are sent one at a time.
+func (c *Conn) Command(ctx context.Context, op Opcode, params []byte) ([]byte, error) {
+	pkt, err := commandPacket(op, params)
+	if err != nil {
+		return nil, err
+	}
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+	// Drop the completion of a previous command given up on.
+	select {
+	case <-c.complete:
+	default:
+	}
+	c.mu.Lock()
+	if c.rerr != nil {
+		err := c.rerr
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.waiting, c.pending = true, op
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.waiting = false
+		c.mu.Unlock()
+	}()
+	if _, err := c.ctl.Control(rTypeCommand, reqCommand, 0, c.intf, pkt); err != nil {
+		return nil, fmt.Errorf("command %s: %v", op, err)
+	}
+	select {
+	case ev := <-c.complete:
+		return commandResult(op, ev)
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, c.rerr
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// commandResult returns the return parameters and the status of a
+// Command Complete or Command Status event.
+func commandResult(op Opcode, ev *Event) ([]byte, error) {
+	if ev.Code == EventCommandStatus {
+		if status := ev.Params[0]; status != 0 {
+			return nil, &Error{Opcode: op, Status: status}
+		}
+		return nil, nil
+	}
+	ret := ev.Params[3:]
+	if len(ret) > 0 && ret[0] != 0 {
+		return ret, &Error{Opcode: op, Status: ret[0]}
+	}
+	return ret, nil
+}
+
+// ReadEvent returns the next event other than the completions of the
+// commands. Once the interrupt endpoint fails or the Conn is closed, the
+// events left are returned, followed by the error.
+func (c *Conn) ReadEvent(ctx context.Context) (*Event, error) {
+	for {
+		c.mu.Lock()
+		if len(c.events) > 0 {
+			ev := c.events[0]
+			c.events = c.events[1:]
+			c.mu.Unlock()
+			return ev, nil
+		}
+		err := c.rerr
+		c.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-c.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// ReadACL returns the next ACL data packet received.
+func (c *Conn) ReadACL(ctx context.Context) (*ACLPacket, error) {
+	c.aclInMu.Lock()
+	defer c.aclInMu.Unlock()
+	for {
+		if p := c.acl.next(); p != nil {
+			return parseACL(p), nil
+		}
+		n, err := c.aclIn.ReadContext(ctx, c.aclBuf)
+		c.acl.add(c.aclBuf[:n])
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteACL sends an ACL data packet. The packet should fit in the ACL
+// buffers of the controller, as returned by OpReadBufferSize.
+func (c *Conn) WriteACL(ctx context.Context, p *ACLPacket) error {
+	if len(p.Data) > 0xffff {
+		return fmt.Errorf("ACL packet of %d bytes, the maximum is 65535", len(p.Data))
+	}
+	c.aclOutMu.Lock()
+	defer c.aclOutMu.Unlock()
+	_, err := c.aclOut.WriteContext(ctx, p.marshal())
+	return err
+}
+
+// SetSCO selects an alternate setting of the isochronous interface,
+// enabling ReadSCO and WriteSCO, or disables SCO data with 0. The setting
+// should match the number and the air mode of the SCO connections.
+// SetSCO must not be called while ReadSCO or WriteSCO are in progress.
+func (c *Conn) SetSCO(alt int) error {
+	c.scoMu.Lock()
+	defer c.scoMu.Unlock()
+	if c.sco != nil {
+		c.sco.release()
+		c.sco = nil
+	}
+	if alt == 0 {
+		return nil
+	}
+	if c.openSCO == nil {
+		return errors.New("hci: device has no isochronous interface")
+	}
+	l, err := c.openSCO(alt)
+	if err != nil {
+		return err
+	}
+	c.sco = l
+	return nil
+}
+
+func (c *Conn) scoLink() (*scoLink, error) {
+	c.scoMu.Lock()
+	defer c.scoMu.Unlock()
+	if c.sco == nil {
+		return nil, ErrNoSCO
+	}
+	return c.sco, nil
+}
+
+// ReadSCO returns the next SCO data packet received. Isochronous packets
+// lost by the bus corrupt the stream of SCO packets.
+func (c *Conn) ReadSCO(ctx context.Context) (*SCOPacket, error) {
+	l, err := c.scoLink()
+	if err != nil {
+		return nil, err
+	}
+	l.rmu.Lock()
+	defer l.rmu.Unlock()
+	for {
+		if p := l.asm.next(); p != nil {
+			return parseSCO(p), nil
+		}
+		n, err := l.in.ReadContext(ctx, l.buf)
+		l.asm.add(l.buf[:n])
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteSCO sends a SCO data packet.
+func (c *Conn) WriteSCO(ctx context.Context, p *SCOPacket) error {
+	b, err := p.marshal()
+	if err != nil {
+		return err
+	}
+	l, err := c.scoLink()
+	if err != nil {
+		return err
+	}
+	l.wmu.Lock()
+	defer l.wmu.Unlock()
+	_, err = l.out.WriteContext(ctx, b)
+	return err
+}
+
+// Close stops receiving events and releases the interfaces.
+func (c *Conn) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+		<-c.done
+		c.cancel = nil
+	}
+	c.fail(ErrClosed)
+	c.SetSCO(0)
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
+
+// interfaces are the interfaces of a Bluetooth controller.
+type interfaces struct {
+	hci                  *gousb.InterfaceSetting
+	event, aclIn, aclOut *gousb.EndpointDesc
+	// sco is the isochronous interface, nil if absent.
+	sco *gousb.InterfaceDesc
+}
+
+func bluetooth(alt gousb.InterfaceSetting) bool {
+	return alt.Class == gousb.ClassWireless && alt.SubClass == subClassRF && alt.Protocol == protocolBluetooth
+}
+
+// isoEndpoints returns the isochronous endpoints of an interface setting.
+func isoEndpoints(alt gousb.InterfaceSetting) (in, out *gousb.EndpointDesc) {
+	for _, ep := range alt.Endpoints {
+		if ep.TransferType != gousb.TransferTypeIsochronous {
+			continue
+		}
+		ep := ep
+		if ep.Direction == gousb.EndpointDirectionIn {
+			in = &ep
+		} else {
+			out = &ep
+		}
+	}
+	return in, out
+}
+
+// findInterface returns the HCI interface of the configuration, with its
+// interrupt and bulk endpoints, and the isochronous interface following
+// it.
+func findInterface(desc gousb.ConfigDesc) (*interfaces, error) {
+	var found *interfaces
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if !bluetooth(alt) {
+				continue
+			}
+			f := &interfaces{}
+			for _, ep := range alt.Endpoints {
+				ep := ep
+				switch {
+				case ep.TransferType == gousb.TransferTypeInterrupt && ep.Direction == gousb.EndpointDirectionIn:
+					f.event = &ep
+				case ep.TransferType == gousb.TransferTypeBulk && ep.Direction == gousb.EndpointDirectionIn:
+					f.aclIn = &ep
+				case ep.TransferType == gousb.TransferTypeBulk:
+					f.aclOut = &ep
+				}
+			}
+			if f.event != nil && f.aclIn != nil && f.aclOut != nil {
+				alt := alt
+				f.hci = &alt
+				found = f
+				break
+			}
+		}
+		if found != nil {
+			break
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s has no Bluetooth HCI interface", desc)
+	}
+	for i, intf := range desc.Interfaces {
+		if intf.Number != found.hci.Number+1 || len(intf.AltSettings) == 0 || !bluetooth(intf.AltSettings[0]) {
+			continue
+		}
+		found.sco = &desc.Interfaces[i]
+	}
+	return found, nil
+}
+
+// scoSettings returns the alternate settings of the isochronous interface.
+func scoSettings(intf *gousb.InterfaceDesc) []SCOSetting {
+	if intf == nil {
+		return nil
+	}
+	var s []SCOSetting
+	for _, alt := range intf.AltSettings {
+		setting := SCOSetting{Alternate: alt.Alternate}
+		if in, _ := isoEndpoints(alt); in != nil {
+			setting.MaxPacketSize = in.MaxPacketSize
+		}
+		s = append(s, setting)
+	}
+	return s
+}
+
+// Match returns true if the device has a Bluetooth HCI interface in any of
+// its configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if _, err := findInterface(cfg); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Open claims the HCI interface in the active configuration of the device
+// and starts receiving events. The Conn should be Close()d after use.
+func Open(dev *gousb.Device) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	f, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, f.hci)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := intf.InEndpoint(f.event.Number)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	aclIn, aclOut, err := claim.Endpoints(intf, f.aclIn, f.aclOut)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	c := newConn(dev, f.hci.Number, aclIn, aclOut, f.aclIn.MaxPacketSize)
+	if f.sco != nil {
+		c.SCOSettings = scoSettings(f.sco)
+		num := f.sco.Number
+		c.openSCO = func(alt int) (*scoLink, error) {
+			// The setting changes with the SCO channels, the interface
+			// is released by the link rather than with cfg.
+			intf, err := cfg.Interface(num, alt)
+			if err != nil {
+				return nil, err
+			}
+			inDesc, outDesc := isoEndpoints(intf.Setting)
+			if inDesc == nil || outDesc == nil || inDesc.MaxPacketSize == 0 {
+				intf.Close()
+				return nil, fmt.Errorf("alternate setting %d of interface %d has no isochronous endpoints", alt, num)
+			}
+			in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+			if err != nil {
+				intf.Close()
+				return nil, err
+			}
+			l := newSCOLink(in, out, inDesc.MaxPacketSize)
+			l.release = intf.Close
+			return l, nil
+		}
+	}
+	c.release = func() { cfg.Close() }
+	c.start(context.Background(), ev, f.event.MaxPacketSize)
+	return c, nil
+}
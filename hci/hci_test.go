@@ -0,0 +1,334 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+// newFakeController returns a control endpoint that answers the commands
+// with the events of reply, queued on the interrupt endpoint events.
+func newFakeController(events *fakeusb.In, reply func(pkt []byte) [][]byte) *fakeusb.Control {
+	return &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+		if r.RType != 0x20 || r.Request != reqCommand || r.Val != 0 || r.Idx != 0 {
+			return 0, gousb.ErrorPipe
+		}
+		events.Queue(reply(data)...)
+		return len(data), nil
+	}}
+}
+
+func commandComplete(op Opcode, ret ...byte) []byte {
+	return append([]byte{byte(EventCommandComplete), byte(3 + len(ret)), 1, byte(op), byte(op >> 8)}, ret...)
+}
+
+func commandStatus(op Opcode, status byte) []byte {
+	return []byte{byte(EventCommandStatus), 4, status, 1, byte(op), byte(op >> 8)}
+}
+
+func newTestConn(reply func(pkt []byte) [][]byte) (*Conn, *fakeusb.Control, *fakeusb.In, *fakeusb.Out) {
+	events := fakeusb.NewIn()
+	ctl := newFakeController(events, reply)
+	aclIn, aclOut := fakeusb.NewIn(), &fakeusb.Out{}
+	c := newConn(ctl, 0, aclIn, aclOut, 64)
+	c.start(context.Background(), events, 16)
+	return c, ctl, aclIn, aclOut
+}
+
+func TestCommand(t *testing.T) {
+	addr := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+	c, ctl, _, _ := newTestConn(func(pkt []byte) [][]byte {
+		switch Opcode(pkt[0]) | Opcode(pkt[1])<<8 {
+		case OpReadBDAddr:
+			// An unrelated event first, and the completion split across
+			// two interrupt transfers.
+			ev := commandComplete(OpReadBDAddr, addr...)
+			return [][]byte{{byte(EventHardwareError), 1, 0x42}, ev[:6], ev[6:]}
+		case OpLESetScanEnable:
+			return [][]byte{commandComplete(OpLESetScanEnable, 0x0c)}
+		case OpLESetScanParameters:
+			// A completion for another opcode isn't the one awaited.
+			return [][]byte{commandComplete(OpReset, 0), commandStatus(OpLESetScanParameters, 0)}
+		case 0x0405:
+			return [][]byte{commandStatus(0x0405, 0x0b)}
+		}
+		return nil
+	})
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ret, err := c.Command(ctx, OpReadBDAddr, nil)
+	if err != nil {
+		t.Fatalf("Command(OpReadBDAddr): %v", err)
+	}
+	if !bytes.Equal(ret, addr) {
+		t.Errorf("Command(OpReadBDAddr): got % x, want % x", ret, addr)
+	}
+
+	ret, err = c.Command(ctx, OpLESetScanEnable, []byte{1, 0})
+	var herr *Error
+	if !errors.As(err, &herr) || herr.Status != 0x0c || herr.Opcode != OpLESetScanEnable {
+		t.Errorf("Command(OpLESetScanEnable): got error %v, want status 0x0c", err)
+	}
+	if want := []byte{0x0c}; !bytes.Equal(ret, want) {
+		t.Errorf("Command(OpLESetScanEnable): got % x, want % x", ret, want)
+	}
+
+	if ret, err := c.Command(ctx, OpLESetScanParameters, make([]byte, 7)); err != nil || ret != nil {
+		t.Errorf("Command(OpLESetScanParameters): got % x, %v, want nil, nil", ret, err)
+	}
+
+	if _, err := c.Command(ctx, 0x0405, make([]byte, 13)); !errors.As(err, &herr) || herr.Status != 0x0b {
+		t.Errorf("Command(0x0405): got error %v, want status 0x0b", err)
+	}
+
+	wantCommands := [][]byte{
+		{0x09, 0x10, 0x00},
+		{0x0c, 0x20, 0x02, 0x01, 0x00},
+		{0x0b, 0x20, 0x07, 0, 0, 0, 0, 0, 0, 0},
+		{0x05, 0x04, 0x0d, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	var commands [][]byte
+	for _, r := range ctl.Requests() {
+		commands = append(commands, []byte(r.Data))
+	}
+	if !reflect.DeepEqual(commands, wantCommands) {
+		t.Errorf("commands: got % x, want % x", commands, wantCommands)
+	}
+
+	// The events other than the completions are left for ReadEvent.
+	for _, want := range []*Event{
+		{Code: EventHardwareError, Params: []byte{0x42}},
+		{Code: EventCommandComplete, Params: []byte{1, 0x03, 0x0c, 0}},
+	} {
+		ev, err := c.ReadEvent(ctx)
+		if err != nil {
+			t.Fatalf("ReadEvent(): %v", err)
+		}
+		if !reflect.DeepEqual(ev, want) {
+			t.Errorf("ReadEvent(): got %+v, want %+v", ev, want)
+		}
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	late := make(chan []byte, 1)
+	events := fakeusb.NewIn()
+	c := newConn(newFakeController(events, func(pkt []byte) [][]byte {
+		if Opcode(pkt[0])|Opcode(pkt[1])<<8 == OpReset {
+			late <- commandComplete(OpReset, 0)
+			return nil
+		}
+		return [][]byte{commandComplete(OpReadLocalVersion, 0, 9)}
+	}), 0, fakeusb.NewIn(), &fakeusb.Out{}, 64)
+	c.start(context.Background(), events, 16)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Command(ctx, OpReset, nil); err != context.DeadlineExceeded {
+		t.Errorf("Command(OpReset): got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	// The late completion goes to ReadEvent.
+	events.Queue(<-late)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ret, err := c.Command(ctx, OpReadLocalVersion, nil)
+	if want := []byte{0, 9}; err != nil || !bytes.Equal(ret, want) {
+		t.Errorf("Command(OpReadLocalVersion): got % x, %v, want % x, nil", ret, err, want)
+	}
+	if ev, err := c.ReadEvent(ctx); err != nil || ev.Code != EventCommandComplete {
+		t.Errorf("ReadEvent(): got %+v, %v, want the completion of OpReset", ev, err)
+	}
+}
+
+func TestEventEndpointFailure(t *testing.T) {
+	events := fakeusb.NewIn()
+	c := newConn(newFakeController(events, func([]byte) [][]byte { return nil }), 0, fakeusb.NewIn(), &fakeusb.Out{}, 64)
+	c.start(context.Background(), events, 16)
+	events.Queue([]byte{byte(EventHardwareError), 1, 0})
+	events.Close(gousb.ErrorNoDevice)
+	<-c.done
+	ctx := context.Background()
+	if ev, err := c.ReadEvent(ctx); err != nil || ev.Code != EventHardwareError {
+		t.Errorf("ReadEvent(): got %+v, %v, want the hardware error event", ev, err)
+	}
+	if _, err := c.ReadEvent(ctx); err != gousb.ErrorNoDevice {
+		t.Errorf("ReadEvent(): got error %v, want %v", err, gousb.ErrorNoDevice)
+	}
+	if _, err := c.Command(ctx, OpReset, nil); err != gousb.ErrorNoDevice {
+		t.Errorf("Command(): got error %v, want %v", err, gousb.ErrorNoDevice)
+	}
+	c.Close()
+	if _, err := c.ReadEvent(ctx); err != ErrClosed {
+		t.Errorf("ReadEvent() after Close(): got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestACL(t *testing.T) {
+	c, _, aclIn, aclOut := newTestConn(nil)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p := &ACLPacket{Handle: 0x40, PacketBoundary: PBFirstNonFlushable, Data: bytes.Repeat([]byte{7}, 100)}
+	if err := c.WriteACL(ctx, p); err != nil {
+		t.Fatalf("WriteACL(): %v", err)
+	}
+	if got, want := aclOut.Transfers(), [][]byte{p.marshal()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteACL(): got transfers % x, want % x", got, want)
+	}
+
+	second := &ACLPacket{Handle: 0x40, PacketBoundary: PBContinuing, Data: []byte{1, 2}}
+	b := append(p.marshal(), second.marshal()...)
+	aclIn.Queue(b[:64], b[64:])
+	for _, want := range []*ACLPacket{p, second} {
+		got, err := c.ReadACL(ctx)
+		if err != nil {
+			t.Fatalf("ReadACL(): %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadACL(): got %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestSCO(t *testing.T) {
+	c, _, _, _ := newTestConn(nil)
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := c.ReadSCO(ctx); err != ErrNoSCO {
+		t.Errorf("ReadSCO() without SetSCO(): got error %v, want %v", err, ErrNoSCO)
+	}
+	if err := c.SetSCO(1); err == nil {
+		t.Errorf("SetSCO(1) without isochronous interface: got nil error, want error")
+	}
+
+	var released []int
+	in, out := fakeusb.NewIn(), &fakeusb.Out{}
+	c.openSCO = func(alt int) (*scoLink, error) {
+		l := newSCOLink(in, out, 9)
+		l.release = func() { released = append(released, alt) }
+		return l, nil
+	}
+	if err := c.SetSCO(2); err != nil {
+		t.Fatalf("SetSCO(2): %v", err)
+	}
+	if got, want := len(c.sco.buf), 72; got != want {
+		t.Errorf("SCO read buffer: got %d bytes, want %d", got, want)
+	}
+	p := &SCOPacket{Handle: 0x06, Data: bytes.Repeat([]byte{0x55}, 48)}
+	if err := c.WriteSCO(ctx, p); err != nil {
+		t.Fatalf("WriteSCO(): %v", err)
+	}
+	if written := out.Transfers(); len(written) != 1 || len(written[0]) != 51 {
+		t.Errorf("WriteSCO(): got transfers % x, want one of 51 bytes", written)
+	}
+	b, _ := p.marshal()
+	in.Queue(b[:27], b[27:])
+	got, err := c.ReadSCO(ctx)
+	if err != nil {
+		t.Fatalf("ReadSCO(): %v", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("ReadSCO(): got %+v, want %+v", got, p)
+	}
+	if err := c.SetSCO(0); err != nil {
+		t.Errorf("SetSCO(0): %v", err)
+	}
+	if want := []int{2}; !reflect.DeepEqual(released, want) {
+		t.Errorf("released settings: got %v, want %v", released, want)
+	}
+	if err := c.WriteSCO(ctx, p); err != ErrNoSCO {
+		t.Errorf("WriteSCO() after SetSCO(0): got error %v, want %v", err, ErrNoSCO)
+	}
+}
+
+func endpoint(addr int, tt gousb.TransferType, maxPacket int) gousb.EndpointDesc {
+	dir := gousb.EndpointDirectionOut
+	if addr&0x80 != 0 {
+		dir = gousb.EndpointDirectionIn
+	}
+	return gousb.EndpointDesc{
+		Address:       gousb.EndpointAddress(addr),
+		Number:        addr & 0x0f,
+		Direction:     dir,
+		TransferType:  tt,
+		MaxPacketSize: maxPacket,
+	}
+}
+
+func btSetting(num, alt int, eps ...gousb.EndpointDesc) gousb.InterfaceSetting {
+	s := gousb.InterfaceSetting{
+		Number:    num,
+		Alternate: alt,
+		Class:     gousb.ClassWireless,
+		SubClass:  subClassRF,
+		Protocol:  protocolBluetooth,
+		Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{},
+	}
+	for _, ep := range eps {
+		s.Endpoints[ep.Address] = ep
+	}
+	return s
+}
+
+func TestFindInterface(t *testing.T) {
+	hci := btSetting(0, 0,
+		endpoint(0x81, gousb.TransferTypeInterrupt, 16),
+		endpoint(0x82, gousb.TransferTypeBulk, 64),
+		endpoint(0x02, gousb.TransferTypeBulk, 64))
+	var scoAlts []gousb.InterfaceSetting
+	for i, size := range []int{0, 9, 17, 25} {
+		scoAlts = append(scoAlts, btSetting(1, i,
+			endpoint(0x83, gousb.TransferTypeIsochronous, size),
+			endpoint(0x03, gousb.TransferTypeIsochronous, size)))
+	}
+	cfg := gousb.ConfigDesc{Interfaces: []gousb.InterfaceDesc{
+		{Number: 0, AltSettings: []gousb.InterfaceSetting{hci}},
+		{Number: 1, AltSettings: scoAlts},
+		{Number: 2, AltSettings: []gousb.InterfaceSetting{{Number: 2, Class: gousb.ClassApplication, SubClass: 1}}},
+	}}
+	f, err := findInterface(cfg)
+	if err != nil {
+		t.Fatalf("findInterface(): %v", err)
+	}
+	if f.hci.Number != 0 || f.event.Address != 0x81 || f.aclIn.Address != 0x82 || f.aclOut.Address != 0x02 {
+		t.Errorf("findInterface(): got interface %d, endpoints %s %s %s, want 0, 0x81 0x82 0x02", f.hci.Number, f.event.Address, f.aclIn.Address, f.aclOut.Address)
+	}
+	if f.sco == nil || f.sco.Number != 1 {
+		t.Fatalf("findInterface(): got isochronous interface %v, want interface 1", f.sco)
+	}
+	wantSettings := []SCOSetting{{0, 0}, {1, 9}, {2, 17}, {3, 25}}
+	if got := scoSettings(f.sco); !reflect.DeepEqual(got, wantSettings) {
+		t.Errorf("scoSettings(): got %v, want %v", got, wantSettings)
+	}
+
+	// Without the interrupt endpoint, it's not an HCI interface.
+	delete(hci.Endpoints, 0x81)
+	cfg.Interfaces[0].AltSettings[0] = hci
+	if _, err := findInterface(cfg); err == nil {
+		t.Errorf("findInterface() without interrupt endpoint: got nil error, want error")
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hci
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Opcode identifies an HCI command: a 6-bit opcode group field (OGF) and a
+// 10-bit opcode command field (OCF).
+type Opcode uint16
+
+// NewOpcode returns the opcode of a command from its group and command
+// fields.
+func NewOpcode(ogf uint8, ocf uint16) Opcode {
+	return Opcode(uint16(ogf)<<10 | ocf&0x3ff)
+}
+
+// OGF returns the opcode group field.
+func (o Opcode) OGF() uint8 {
+	return uint8(o >> 10)
+}
+
+// OCF returns the opcode command field.
+func (o Opcode) OCF() uint16 {
+	return uint16(o) & 0x3ff
+}
+
+func (o Opcode) String() string {
+	return fmt.Sprintf("0x%04x (OGF 0x%02x, OCF 0x%03x)", uint16(o), o.OGF(), o.OCF())
+}
+
+// Common commands.
+const (
+	OpSetEventMask               Opcode = 0x0c01
+	OpReset                      Opcode = 0x0c03
+	OpWriteScanEnable            Opcode = 0x0c1a
+	OpReadLocalVersion           Opcode = 0x1001
+	OpReadLocalSupportedCommands Opcode = 0x1002
+	OpReadLocalSupportedFeatures Opcode = 0x1003
+	OpReadBufferSize             Opcode = 0x1005
+	OpReadBDAddr                 Opcode = 0x1009
+	OpLESetEventMask             Opcode = 0x2001
+	OpLEReadBufferSize           Opcode = 0x2002
+	OpLESetScanParameters        Opcode = 0x200b
+	OpLESetScanEnable            Opcode = 0x200c
+)
+
+// EventCode identifies an HCI event.
+type EventCode uint8
+
+// Common events.
+const (
+	EventInquiryComplete         EventCode = 0x01
+	EventInquiryResult           EventCode = 0x02
+	EventConnectionComplete      EventCode = 0x03
+	EventConnectionRequest       EventCode = 0x04
+	EventDisconnectionComplete   EventCode = 0x05
+	EventCommandComplete         EventCode = 0x0e
+	EventCommandStatus           EventCode = 0x0f
+	EventHardwareError           EventCode = 0x10
+	EventNumberOfCompletedPkts   EventCode = 0x13
+	EventSynchronousConnComplete EventCode = 0x2c
+	EventLEMeta                  EventCode = 0x3e
+	EventVendor                  EventCode = 0xff
+)
+
+// Sizes of the packet headers.
+const (
+	commandHeaderSize = 3
+	eventHeaderSize   = 2
+	aclHeaderSize     = 4
+	scoHeaderSize     = 3
+)
+
+// maxCommandParams is the maximum length of the parameters of a command.
+const maxCommandParams = 255
+
+// Event is an HCI event sent by the controller.
+type Event struct {
+	Code   EventCode
+	Params []byte
+}
+
+// parseEvent parses an event packet.
+func parseEvent(b []byte) (*Event, error) {
+	if len(b) < eventHeaderSize || len(b) != eventHeaderSize+int(b[1]) {
+		return nil, fmt.Errorf("invalid event packet of %d bytes", len(b))
+	}
+	return &Event{Code: EventCode(b[0]), Params: b[eventHeaderSize:]}, nil
+}
+
+// commandPacket returns the packet of a command.
+func commandPacket(op Opcode, params []byte) ([]byte, error) {
+	if len(params) > maxCommandParams {
+		return nil, fmt.Errorf("command %s: %d bytes of parameters, the maximum is %d", op, len(params), maxCommandParams)
+	}
+	b := make([]byte, commandHeaderSize, commandHeaderSize+len(params))
+	binary.LittleEndian.PutUint16(b, uint16(op))
+	b[2] = uint8(len(params))
+	return append(b, params...), nil
+}
+
+// Packet boundary flags of ACL packets.
+const (
+	// PBFirstNonFlushable starts a non-automatically-flushable L2CAP
+	// packet.
+	PBFirstNonFlushable = 0x0
+	// PBContinuing continues an L2CAP packet.
+	PBContinuing = 0x1
+	// PBFirstFlushable starts an automatically-flushable L2CAP packet.
+	PBFirstFlushable = 0x2
+)
+
+// ACLPacket is an HCI ACL data packet.
+type ACLPacket struct {
+	// Handle is the 12-bit connection handle.
+	Handle uint16
+	// PacketBoundary is one of the PB constants.
+	PacketBoundary uint8
+	// Broadcast is the 2-bit broadcast flag.
+	Broadcast uint8
+	Data      []byte
+}
+
+func (p *ACLPacket) marshal() []byte {
+	b := make([]byte, aclHeaderSize, aclHeaderSize+len(p.Data))
+	binary.LittleEndian.PutUint16(b, p.Handle&0xfff|uint16(p.PacketBoundary&3)<<12|uint16(p.Broadcast&3)<<14)
+	binary.LittleEndian.PutUint16(b[2:], uint16(len(p.Data)))
+	return append(b, p.Data...)
+}
+
+func parseACL(b []byte) *ACLPacket {
+	h := binary.LittleEndian.Uint16(b)
+	return &ACLPacket{
+		Handle:         h & 0xfff,
+		PacketBoundary: uint8(h>>12) & 3,
+		Broadcast:      uint8(h >> 14),
+		Data:           b[aclHeaderSize:],
+	}
+}
+
+// SCOPacket is an HCI synchronous data packet.
+type SCOPacket struct {
+	// Handle is the 12-bit connection handle.
+	Handle uint16
+	// Status is the 2-bit packet status flag of received packets: 0 for
+	// correctly received data, 1 for possibly invalid data, 2 for no data
+	// and 3 for partially lost data.
+	Status uint8
+	Data   []byte
+}
+
+func (p *SCOPacket) marshal() ([]byte, error) {
+	if len(p.Data) > 0xff {
+		return nil, fmt.Errorf("SCO packet of %d bytes, the maximum is 255", len(p.Data))
+	}
+	return append([]byte{
+		byte(p.Handle),
+		byte(p.Handle>>8)&0x0f | (p.Status&3)<<4,
+		byte(len(p.Data)),
+	}, p.Data...), nil
+}
+
+func parseSCO(b []byte) *SCOPacket {
+	h := binary.LittleEndian.Uint16(b)
+	return &SCOPacket{
+		Handle: h & 0xfff,
+		Status: uint8(h>>12) & 3,
+		Data:   b[scoHeaderSize:],
+	}
+}
+
+// eventLength, aclLength and scoLength return the length of a packet from
+// its header.
+func eventLength(h []byte) int { return eventHeaderSize + int(h[1]) }
+func aclLength(h []byte) int   { return aclHeaderSize + int(binary.LittleEndian.Uint16(h[2:])) }
+func scoLength(h []byte) int   { return scoHeaderSize + int(h[2]) }
+
+// assembler splits the stream of data received from an endpoint into
+// packets. A packet may span several transfers, and a transfer may hold
+// several packets.
+type assembler struct {
+	headerSize int
+	length     func(header []byte) int
+	buf        []byte
+}
+
+// add appends the data of a transfer.
+func (a *assembler) add(b []byte) {
+	a.buf = append(a.buf, b...)
+}
+
+// next returns the next complete packet, or nil. The data of the packets
+// returned is never overwritten.
+func (a *assembler) next() []byte {
+	if len(a.buf) < a.headerSize {
+		return nil
+	}
+	l := a.length(a.buf)
+	if len(a.buf) < l {
+		return nil
+	}
+	p := a.buf[:l:l]
+	a.buf = a.buf[l:]
+	return p
+}
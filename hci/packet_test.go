@@ -0,0 +1,111 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hci
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestOpcode(t *testing.T) {
+	for _, tc := range []struct {
+		ogf  uint8
+		ocf  uint16
+		want Opcode
+	}{
+		{0x03, 0x003, OpReset},
+		{0x04, 0x009, OpReadBDAddr},
+		{0x08, 0x00c, OpLESetScanEnable},
+		{0x3f, 0x3ff, 0xffff},
+	} {
+		op := NewOpcode(tc.ogf, tc.ocf)
+		if op != tc.want {
+			t.Errorf("NewOpcode(0x%02x, 0x%03x): got 0x%04x, want 0x%04x", tc.ogf, tc.ocf, uint16(op), uint16(tc.want))
+		}
+		if op.OGF() != tc.ogf || op.OCF() != tc.ocf {
+			t.Errorf("0x%04x: got OGF 0x%02x OCF 0x%03x, want 0x%02x 0x%03x", uint16(op), op.OGF(), op.OCF(), tc.ogf, tc.ocf)
+		}
+	}
+}
+
+func TestCommandPacket(t *testing.T) {
+	got, err := commandPacket(OpLESetScanEnable, []byte{1, 0})
+	if err != nil {
+		t.Fatalf("commandPacket(): %v", err)
+	}
+	if want := []byte{0x0c, 0x20, 0x02, 0x01, 0x00}; !bytes.Equal(got, want) {
+		t.Errorf("commandPacket(): got % x, want % x", got, want)
+	}
+	if _, err := commandPacket(OpReset, make([]byte, 256)); err == nil {
+		t.Errorf("commandPacket() with 256 bytes of parameters: got nil error, want error")
+	}
+}
+
+func TestACLPacket(t *testing.T) {
+	p := &ACLPacket{Handle: 0x0ab, PacketBoundary: PBFirstFlushable, Broadcast: 1, Data: []byte{1, 2, 3}}
+	b := p.marshal()
+	if want := []byte{0xab, 0x60, 0x03, 0x00, 1, 2, 3}; !bytes.Equal(b, want) {
+		t.Errorf("marshal(): got % x, want % x", b, want)
+	}
+	if got := parseACL(b); !reflect.DeepEqual(got, p) {
+		t.Errorf("parseACL(% x): got %+v, want %+v", b, got, p)
+	}
+}
+
+func TestSCOPacket(t *testing.T) {
+	p := &SCOPacket{Handle: 0x123, Status: 2, Data: []byte{9, 8}}
+	b, err := p.marshal()
+	if err != nil {
+		t.Fatalf("marshal(): %v", err)
+	}
+	if want := []byte{0x23, 0x21, 0x02, 9, 8}; !bytes.Equal(b, want) {
+		t.Errorf("marshal(): got % x, want % x", b, want)
+	}
+	if got := parseSCO(b); !reflect.DeepEqual(got, p) {
+		t.Errorf("parseSCO(% x): got %+v, want %+v", b, got, p)
+	}
+	if _, err := (&SCOPacket{Data: make([]byte, 256)}).marshal(); err == nil {
+		t.Errorf("marshal() of 256 bytes: got nil error, want error")
+	}
+}
+
+func TestAssembler(t *testing.T) {
+	a := &assembler{headerSize: eventHeaderSize, length: eventLength}
+	var got [][]byte
+	for _, transfer := range [][]byte{
+		// An event spanning two transfers.
+		{0x0e, 0x04, 0x01},
+		{0x03, 0x0c, 0x00},
+		// Two events in a transfer, the second starting a third one.
+		{0x13, 0x00, 0x05, 0x01, 0x00, 0x3e},
+		{0x01},
+		{0xff},
+	} {
+		a.add(transfer)
+		for p := a.next(); p != nil; p = a.next() {
+			got = append(got, p)
+		}
+	}
+	want := [][]byte{
+		{0x0e, 0x04, 0x01, 0x03, 0x0c, 0x00},
+		{0x13, 0x00},
+		{0x05, 0x01, 0x00},
+		{0x3e, 0x01, 0xff},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("packets: got % x, want % x", got, want)
+	}
+}
@@ -16,10 +16,17 @@
 package gousb
 
 import (
+	"errors"
 	"fmt"
 	"sort"
+	"sync"
 )
 
+// ErrInterfaceClosed is returned by transfers on the endpoints of an
+// interface, including streams and Transfers, that were in flight or
+// submitted after the interface was closed.
+var ErrInterfaceClosed = errors.New("gousb: interface was closed")
+
 // InterfaceDesc contains information about a USB interface, extracted from
 // the descriptor.
 type InterfaceDesc struct {
@@ -62,6 +69,11 @@ type InterfaceSetting struct {
 	// Endpoints enumerates the endpoints available on this interface with
 	// this alternate setting.
 	Endpoints map[EndpointAddress]EndpointDesc
+	// Extra contains the raw class- or vendor-specific descriptors that
+	// follow the standard interface descriptor, e.g. the VideoControl
+	// descriptors of a video class device. Extra is empty for interfaces
+	// that don't define any additional descriptors.
+	Extra []byte
 
 	iInterface int // index of a string descriptor describing this interface.
 }
@@ -84,26 +96,59 @@ func (a InterfaceSetting) String() string {
 // Interface is a representation of a claimed interface with a particular setting.
 // To access device endpoints use InEndpoint() and OutEndpoint() methods.
 // The interface should be Close()d after use.
+//
+// The methods of an Interface and of its endpoints are safe for concurrent
+// use by multiple goroutines, and so are Transfers. A stream must be used
+// by a single goroutine at a time, except for its Stats method.
+// Close can be called at any time from any goroutine, it interrupts the
+// transfers in flight, see Close.
 type Interface struct {
 	Setting InterfaceSetting
 
 	config *Config
+	// transfers tracks the transfers in flight on the endpoints.
+	transfers *transferSet
+	// pool keeps the transfers of Read and Write of the endpoints for
+	// reuse.
+	pool *transferPool
+
+	// mu protects the fields below.
+	mu sync.Mutex
+	// closed is true after Close.
+	closed bool
+	// endpoints are the endpoints opened on the interface, by address.
+	endpoints map[EndpointAddress]*endpoint
 }
 
 func (i *Interface) String() string {
 	return fmt.Sprintf("%s,if=%d,alt=%d", i.config, i.Setting.Number, i.Setting.Alternate)
 }
 
-// Close releases the interface.
+// Close releases the interface. The transfers in flight on its endpoints,
+// including the transfers of streams and Transfers, are cancelled and
+// Close waits for their completion. Close also waits for the pending
+// reads and writes on the endpoints and streams to return. The pending
+// and subsequent transfers fail with ErrInterfaceClosed. Close then frees
+// all the transfers of the endpoints, streams and Transfers, in flight or
+// not, since their buffers may be allocated in the device memory. The
+// data returned by ReadStream.NextTransfer and the buffers of Transfers
+// must not be used after Close. Streams should still be closed.
 func (i *Interface) Close() {
-	if i.config == nil {
+	// mu is held until the interface is released, so that a concurrent
+	// Close returns after the interface is released.
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.closed {
 		return
 	}
+	i.closed = true
+	i.transfers.close()
+	i.pool.close()
+	i.endpoints = nil
 	i.config.dev.ctx.libusb.release(i.config.dev.handle, uint8(i.Setting.Number))
 	i.config.mu.Lock()
-	defer i.config.mu.Unlock()
 	delete(i.config.claimed, i.Setting.Number)
-	i.config = nil
+	i.config.mu.Unlock()
 }
 
 func (i *Interface) openEndpoint(epAddr EndpointAddress) (*endpoint, error) {
@@ -112,17 +157,39 @@ func (i *Interface) openEndpoint(epAddr EndpointAddress) (*endpoint, error) {
 	if !ok {
 		return nil, fmt.Errorf("%s does not have endpoint with address %s. Available endpoints: %v", i, epAddr, i.Setting.sortedEndpointIds())
 	}
-	return &endpoint{
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.closed {
+		return nil, ErrInterfaceClosed
+	}
+	if e := i.endpoints[epAddr]; e != nil {
+		return e, nil
+	}
+	e := &endpoint{
 		InterfaceSetting: i.Setting,
 		Desc:             ep,
 		h:                i.config.dev.handle,
 		ctx:              i.config.dev.ctx,
-	}, nil
+		pool:             i.pool,
+		transfers:        i.transfers,
+	}
+	if i.endpoints == nil {
+		i.endpoints = make(map[EndpointAddress]*endpoint)
+	}
+	i.endpoints[epAddr] = e
+	return e, nil
+}
+
+func (i *Interface) isClosed() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.closed
 }
 
-// InEndpoint prepares an IN endpoint for transfer.
+// InEndpoint prepares an IN endpoint for transfer. The InEndpoints of
+// the same endpoint number share their Timeout and statistics.
 func (i *Interface) InEndpoint(epNum int) (*InEndpoint, error) {
-	if i.config == nil {
+	if i.isClosed() {
 		return nil, fmt.Errorf("InEndpoint(%d) called on %s after Close", epNum, i)
 	}
 	ep, err := i.openEndpoint(EndpointAddress(0x80 | epNum))
@@ -134,9 +201,10 @@ func (i *Interface) InEndpoint(epNum int) (*InEndpoint, error) {
 	}, nil
 }
 
-// OutEndpoint prepares an OUT endpoint for transfer.
+// OutEndpoint prepares an OUT endpoint for transfer. The OutEndpoints of
+// the same endpoint number share their Timeout and statistics.
 func (i *Interface) OutEndpoint(epNum int) (*OutEndpoint, error) {
-	if i.config == nil {
+	if i.isClosed() {
 		return nil, fmt.Errorf("OutEndpoint(%d) called on %s after Close", epNum, i)
 	}
 	ep, err := i.openEndpoint(EndpointAddress(epNum))
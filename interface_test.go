@@ -0,0 +1,425 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openFakeInterface opens interface 0 of the fake device 9999:0001, with
+// a bulk OUT endpoint 0x01 and a bulk IN endpoint 0x82.
+func openFakeInterface(t *testing.T, ctx *Context) (*Device, *Config, *Interface) {
+	t.Helper()
+	dev, err := ctx.OpenDeviceWithVIDPID(0x9999, 0x0001)
+	if err != nil {
+		t.Fatalf("OpenDeviceWithVIDPID(9999, 0001): %v", err)
+	}
+	cfg, err := dev.Config(1)
+	if err != nil {
+		t.Fatalf("%s.Config(1): %v", dev, err)
+	}
+	intf, err := cfg.Interface(0, 0)
+	if err != nil {
+		t.Fatalf("%s.Interface(0, 0): %v", cfg, err)
+	}
+	return dev, cfg, intf
+}
+
+func TestInterfaceConcurrentTransfers(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			xfr := lib.waitForSubmitted(done)
+			if xfr == nil {
+				return
+			}
+			if xfr.ep.Direction == EndpointDirectionIn {
+				xfr.setData(bytes.Repeat([]byte{0xaa}, len(xfr.buf)))
+			} else {
+				xfr.setLength(len(xfr.buf))
+			}
+			xfr.setStatus(TransferCompleted)
+		}
+	}()
+
+	dev, cfg, intf := openFakeInterface(t, ctx)
+	defer dev.Close()
+	defer cfg.Close()
+	defer intf.Close()
+
+	const goroutines, transfers = 8, 20
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(2)
+		// Endpoints opened concurrently, the calls for the same address
+		// return the same endpoint.
+		go func() {
+			defer wg.Done()
+			ep, err := intf.InEndpoint(2)
+			if err != nil {
+				t.Errorf("%s.InEndpoint(2): %v", intf, err)
+				return
+			}
+			buf := make([]byte, 512)
+			for j := 0; j < transfers; j++ {
+				if n, err := ep.Read(buf); n != len(buf) || err != nil {
+					t.Errorf("%s.Read(): got %d, %v, want %d, nil", ep, n, err, len(buf))
+					return
+				}
+				ep.Stats()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			ep, err := intf.OutEndpoint(1)
+			if err != nil {
+				t.Errorf("%s.OutEndpoint(1): %v", intf, err)
+				return
+			}
+			buf := make([]byte, 512)
+			for j := 0; j < transfers; j++ {
+				if n, err := ep.Write(buf); n != len(buf) || err != nil {
+					t.Errorf("%s.Write(): got %d, %v, want %d, nil", ep, n, err, len(buf))
+					return
+				}
+			}
+		}()
+	}
+
+	// A single endpoint shared by several goroutines.
+	ep, err := intf.InEndpoint(2)
+	if err != nil {
+		t.Fatalf("%s.InEndpoint(2): %v", intf, err)
+	}
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 1024)
+			for j := 0; j < transfers; j++ {
+				if _, err := ep.ReadContext(context.Background(), buf); err != nil {
+					t.Errorf("%s.ReadContext(): %v", ep, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// The reads of the goroutines that opened endpoint 2 count too.
+	if got, want := ep.Stats().Transfers, 2*goroutines*transfers; got != want {
+		t.Errorf("%s.Stats().Transfers: got %d, want %d", ep, got, want)
+	}
+}
+
+func TestInterfaceCloseCancelsTransfers(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+
+	// The device never completes the transfers, only Close can.
+	submitted := make(chan struct{}, 100)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for lib.waitForSubmitted(done) != nil {
+			submitted <- struct{}{}
+		}
+	}()
+
+	dev, cfg, intf := openFakeInterface(t, ctx)
+	defer dev.Close()
+	defer cfg.Close()
+	defer intf.Close()
+	in, err := intf.InEndpoint(2)
+	if err != nil {
+		t.Fatalf("%s.InEndpoint(2): %v", intf, err)
+	}
+	out, err := intf.OutEndpoint(1)
+	if err != nil {
+		t.Fatalf("%s.OutEndpoint(1): %v", intf, err)
+	}
+	rs, err := in.NewStream(512, 3)
+	if err != nil {
+		t.Fatalf("%s.NewStream(512, 3): %v", in, err)
+	}
+	defer rs.Close()
+	ws, err := out.NewStream(512, 2)
+	if err != nil {
+		t.Fatalf("%s.NewStream(512, 2): %v", out, err)
+	}
+	defer ws.Close()
+	xfer, err := in.NewTransfer(512)
+	if err != nil {
+		t.Fatalf("%s.NewTransfer(512): %v", in, err)
+	}
+	defer xfer.Free()
+
+	errs := make(chan error, 5)
+	go func() {
+		_, err := in.Read(make([]byte, 512))
+		errs <- err
+	}()
+	go func() {
+		_, err := out.Write(make([]byte, 512))
+		errs <- err
+	}()
+	go func() {
+		_, err := rs.Read(make([]byte, 512))
+		errs <- err
+	}()
+	go func() {
+		// The stream has two transfers, the third write waits for
+		// the completion of the first one.
+		for i := 0; i < 3; i++ {
+			if _, err := ws.Write(make([]byte, 512)); err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+	if err := xfer.Submit(nil); err != nil {
+		t.Fatalf("Transfer.Submit(): %v", err)
+	}
+	go func() {
+		_, err := xfer.Wait(context.Background())
+		errs <- err
+	}()
+
+	// 1 Read, 1 Write, 3 ReadStream, 2 WriteStream and 1 Transfer.
+	for i := 0; i < 8; i++ {
+		select {
+		case <-submitted:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d submitted transfers, want 8", i)
+		}
+	}
+	intf.Close()
+	for i := 0; i < cap(errs); i++ {
+		select {
+		case err := <-errs:
+			if err != ErrInterfaceClosed {
+				t.Errorf("transfer interrupted by %s.Close(): got error %v, want %v", intf, err, ErrInterfaceClosed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%d transfers still blocked after %s.Close()", cap(errs)-i, intf)
+		}
+	}
+
+	if _, err := in.Read(make([]byte, 512)); err != ErrInterfaceClosed {
+		t.Errorf("%s.Read() after Close: got error %v, want %v", in, err, ErrInterfaceClosed)
+	}
+	if err := xfer.Submit(nil); err != ErrInterfaceClosed {
+		t.Errorf("Transfer.Submit() after Close: got error %v, want %v", err, ErrInterfaceClosed)
+	}
+	if _, err := in.NewStream(512, 1); err != ErrInterfaceClosed {
+		t.Errorf("%s.NewStream() after Close: got error %v, want %v", in, err, ErrInterfaceClosed)
+	}
+}
+
+// blockingReader signals reading on the first Read and blocks it until
+// release is closed.
+type blockingReader struct {
+	reading, release chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	close(r.reading)
+	<-r.release
+	return len(p), nil
+}
+
+func TestInterfaceCloseWaitsForReadFrom(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+
+	dev, cfg, intf := openFakeInterface(t, ctx)
+	defer dev.Close()
+	defer cfg.Close()
+	out, err := intf.OutEndpoint(1)
+	if err != nil {
+		t.Fatalf("%s.OutEndpoint(1): %v", intf, err)
+	}
+	r := &blockingReader{reading: make(chan struct{}), release: make(chan struct{})}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := out.ReadFrom(r)
+		errs <- err
+	}()
+	<-r.reading
+	closed := make(chan struct{})
+	go func() {
+		intf.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatalf("%s.Close() returned while %s.ReadFrom() was reading into its transfer", intf, out)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(r.release)
+	<-closed
+	if err := <-errs; err != ErrInterfaceClosed {
+		t.Errorf("%s.ReadFrom() interrupted by %s.Close(): got error %v, want %v", out, intf, err, ErrInterfaceClosed)
+	}
+}
+
+func TestDeviceCloseCancelsTransfers(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	submitted := make(chan struct{}, 10)
+	go func() {
+		for lib.waitForSubmitted(done) != nil {
+			submitted <- struct{}{}
+		}
+	}()
+
+	dev, cfg, intf := openFakeInterface(t, ctx)
+	in, err := intf.InEndpoint(2)
+	if err != nil {
+		t.Fatalf("%s.InEndpoint(2): %v", intf, err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := in.Read(make([]byte, 512))
+		errs <- err
+	}()
+	<-submitted
+	if err := dev.Close(); err != nil {
+		t.Fatalf("%s.Close(): %v", dev, err)
+	}
+	select {
+	case err := <-errs:
+		if err != ErrInterfaceClosed {
+			t.Errorf("%s.Read() interrupted by %s.Close(): got error %v, want %v", in, dev, err, ErrInterfaceClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s.Read() still blocked after %s.Close()", in, dev)
+	}
+	if _, err := intf.InEndpoint(2); err == nil {
+		t.Errorf("%s.InEndpoint(2) after %s.Close(): got nil error, want non nil", intf, dev)
+	}
+	if _, err := cfg.Interface(0, 0); err == nil {
+		t.Errorf("%s.Interface(0, 0) after %s.Close(): got nil error, want non nil", cfg, dev)
+	}
+}
+
+func TestDeviceCloseWithOpenStream(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close: %v", err)
+		}
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// Only the first transfer completes, the others stay in flight.
+		ft := lib.waitForSubmitted(done)
+		if ft == nil {
+			return
+		}
+		ft.setData(make([]byte, 512))
+		ft.setStatus(TransferCompleted)
+		for lib.waitForSubmitted(done) != nil {
+		}
+	}()
+
+	dev, _, intf := openFakeInterface(t, ctx)
+	in, err := intf.InEndpoint(2)
+	if err != nil {
+		t.Fatalf("%s.InEndpoint(2): %v", intf, err)
+	}
+	rs, err := in.NewStream(512, 3)
+	if err != nil {
+		t.Fatalf("%s.NewStream(512, 3): %v", in, err)
+	}
+	// The data of the first transfer is held, the transfer is not in
+	// flight.
+	if _, err := rs.NextTransfer(context.Background()); err != nil {
+		t.Fatalf("ReadStream.NextTransfer(): %v", err)
+	}
+	out, err := intf.OutEndpoint(1)
+	if err != nil {
+		t.Fatalf("%s.OutEndpoint(1): %v", intf, err)
+	}
+	xfer, err := out.NewTransfer(512)
+	if err != nil {
+		t.Fatalf("%s.NewTransfer(512): %v", out, err)
+	}
+	lib.mu.Lock()
+	for _, ft := range lib.ts {
+		if !ft.devMem {
+			t.Errorf("transfer of %s allocated with malloc, want the device memory", ft.ep)
+		}
+	}
+	lib.mu.Unlock()
+	if err := dev.Close(); err != nil {
+		t.Fatalf("%s.Close(): %v", dev, err)
+	}
+	lib.mu.Lock()
+	allocated := len(lib.ts)
+	lib.mu.Unlock()
+	if allocated != 0 {
+		t.Errorf("%s.Close(): %d transfers of the open stream and Transfer still allocated, want 0", dev, allocated)
+	}
+	if got := xfer.Buffer(); got != nil {
+		t.Errorf("Transfer.Buffer() after %s.Close(): got %d bytes, want nil", dev, len(got))
+	}
+	if err := xfer.Free(); err != nil {
+		t.Errorf("Transfer.Free() after %s.Close(): %v", dev, err)
+	}
+	if _, err := rs.Read(make([]byte, 512)); err != ErrInterfaceClosed {
+		t.Errorf("ReadStream.Read() after %s.Close(): got error %v, want %v", dev, err, ErrInterfaceClosed)
+	}
+	if err := rs.Close(); err != nil {
+		t.Errorf("ReadStream.Close() after %s.Close(): %v", dev, err)
+	}
+}
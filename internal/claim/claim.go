@@ -0,0 +1,114 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package claim implements the steps shared by the device class packages
+// to start using a device: finding the active configuration, claiming
+// the interfaces of the class and opening their endpoints.
+package claim
+
+import (
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// ActiveConfig returns the number and the descriptor of the active
+// configuration of dev.
+func ActiveConfig(dev *gousb.Device) (int, gousb.ConfigDesc, error) {
+	num, err := dev.ActiveConfigNum()
+	if err != nil {
+		return 0, gousb.ConfigDesc{}, fmt.Errorf("failed to get active config number of device %s: %v", dev, err)
+	}
+	desc, ok := dev.Desc.Configs[num]
+	if !ok {
+		return 0, gousb.ConfigDesc{}, fmt.Errorf("device %s has no descriptor for the active config %d", dev, num)
+	}
+	return num, desc, nil
+}
+
+// Config is an open configuration of a device and the interfaces claimed
+// through it.
+type Config struct {
+	*gousb.Config
+
+	intfs []*gousb.Interface
+}
+
+// Open opens the configuration num of dev. The Config should be Close()d
+// after use.
+func Open(dev *gousb.Device, num int) (*Config, error) {
+	cfg, err := dev.Config(num)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Config: cfg}, nil
+}
+
+// Claim claims an alternate setting of an interface. The interface stays
+// claimed until the Config is closed. Interfaces that need to be released
+// earlier can be claimed with Config.Interface instead.
+func (c *Config) Claim(alt *gousb.InterfaceSetting) (*gousb.Interface, error) {
+	intf, err := c.Interface(alt.Number, alt.Alternate)
+	if err != nil {
+		return nil, err
+	}
+	c.intfs = append(c.intfs, intf)
+	return intf, nil
+}
+
+// Close releases the claimed interfaces, in the reverse order of Claim,
+// and closes the configuration.
+func (c *Config) Close() error {
+	for i := len(c.intfs) - 1; i >= 0; i-- {
+		c.intfs[i].Close()
+	}
+	c.intfs = nil
+	return c.Config.Close()
+}
+
+// OpenInterface opens the configuration num of dev and claims a single
+// alternate setting in it. The Config should be Close()d after use.
+func OpenInterface(dev *gousb.Device, num int, alt *gousb.InterfaceSetting) (*Config, *gousb.Interface, error) {
+	c, err := Open(dev, num)
+	if err != nil {
+		return nil, nil, err
+	}
+	intf, err := c.Claim(alt)
+	if err != nil {
+		c.Close()
+		return nil, nil, err
+	}
+	return c, intf, nil
+}
+
+// Endpoints opens the endpoints of intf described by in and out. A nil
+// descriptor is skipped and the corresponding endpoint is nil.
+func Endpoints(intf *gousb.Interface, in, out *gousb.EndpointDesc) (*gousb.InEndpoint, *gousb.OutEndpoint, error) {
+	var (
+		inEP  *gousb.InEndpoint
+		outEP *gousb.OutEndpoint
+		err   error
+	)
+	if in != nil {
+		if inEP, err = intf.InEndpoint(in.Number); err != nil {
+			return nil, nil, err
+		}
+	}
+	if out != nil {
+		if outEP, err = intf.OutEndpoint(out.Number); err != nil {
+			return nil, nil, err
+		}
+	}
+	return inEP, outEP, nil
+}
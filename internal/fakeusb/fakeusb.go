@@ -0,0 +1,153 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeusb implements fake endpoints for the tests of the device
+// class packages. The fakes implement the ReadContext, WriteContext and
+// Control methods of gousb.InEndpoint, gousb.OutEndpoint and gousb.Device.
+package fakeusb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/gousb"
+)
+
+// In is a fake IN endpoint. ReadContext returns the queued transfers, one
+// per read. With no transfer queued, ReadContext returns the error set by
+// Close, or blocks until a transfer is queued or the context is done.
+type In struct {
+	mu        sync.Mutex
+	transfers [][]byte
+	err       error
+	// ready is closed and replaced when a transfer is queued or
+	// the endpoint is closed.
+	ready chan struct{}
+}
+
+// NewIn returns an IN endpoint with the given transfers queued.
+func NewIn(transfers ...[]byte) *In {
+	return &In{transfers: transfers, ready: make(chan struct{})}
+}
+
+// Queue adds transfers to the queue.
+func (f *In) Queue(transfers ...[]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transfers = append(f.transfers, transfers...)
+	f.wake()
+}
+
+// Close makes ReadContext return err once the queue is empty, instead of
+// blocking.
+func (f *In) Close(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+	f.wake()
+}
+
+func (f *In) wake() {
+	close(f.ready)
+	f.ready = make(chan struct{})
+}
+
+// ReadContext copies the next queued transfer to p.
+func (f *In) ReadContext(ctx context.Context, p []byte) (int, error) {
+	for {
+		f.mu.Lock()
+		if len(f.transfers) > 0 {
+			t := f.transfers[0]
+			f.transfers = f.transfers[1:]
+			f.mu.Unlock()
+			return copy(p, t), nil
+		}
+		if f.err != nil {
+			err := f.err
+			f.mu.Unlock()
+			return 0, err
+		}
+		ready := f.ready
+		f.mu.Unlock()
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// Out is a fake OUT endpoint that records the transfers written to it.
+type Out struct {
+	mu        sync.Mutex
+	transfers [][]byte
+}
+
+// WriteContext records a copy of p as a transfer.
+func (f *Out) WriteContext(ctx context.Context, p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transfers = append(f.transfers, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// Transfers returns the transfers written so far.
+func (f *Out) Transfers() [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]byte(nil), f.transfers...)
+}
+
+// Request is a control request received by Control.
+type Request struct {
+	RType, Request uint8
+	Val, Idx       uint16
+	// Data is the data of an OUT request, empty for IN requests.
+	Data string
+}
+
+// Control is a fake control endpoint that records the requests. If Handle
+// is set, it answers the requests. Otherwise OUT requests succeed and IN
+// requests fail with gousb.ErrorPipe.
+type Control struct {
+	Handle func(r Request, data []byte) (int, error)
+
+	mu   sync.Mutex
+	reqs []Request
+}
+
+// Control records a request and answers it.
+func (f *Control) Control(rType, request uint8, val, idx uint16, data []byte) (int, error) {
+	r := Request{RType: rType, Request: request, Val: val, Idx: idx}
+	if rType&gousb.ControlIn == 0 {
+		r.Data = string(data)
+	}
+	f.mu.Lock()
+	f.reqs = append(f.reqs, r)
+	f.mu.Unlock()
+	if f.Handle != nil {
+		return f.Handle(r, data)
+	}
+	if rType&gousb.ControlIn != 0 {
+		return 0, gousb.ErrorPipe
+	}
+	return len(data), nil
+}
+
+// Requests returns the requests received so far.
+func (f *Control) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.reqs...)
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeusb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+func TestIn(t *testing.T) {
+	in := NewIn([]byte{1, 2})
+	buf := make([]byte, 4)
+	if n, err := in.ReadContext(context.Background(), buf); n != 2 || err != nil {
+		t.Fatalf("ReadContext(): got %d, %v, want 2, nil", n, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := in.ReadContext(ctx, buf); err != context.DeadlineExceeded {
+		t.Fatalf("ReadContext() of an empty queue: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go in.Queue([]byte{3})
+	if n, err := in.ReadContext(context.Background(), buf); n != 1 || err != nil || buf[0] != 3 {
+		t.Fatalf("ReadContext() of a transfer queued while blocked: got %d, %v, %v, want 1, nil, [3]", n, err, buf[:n])
+	}
+	errDone := errors.New("done")
+	in.Queue([]byte{4})
+	in.Close(errDone)
+	if n, err := in.ReadContext(context.Background(), buf); n != 1 || err != nil {
+		t.Fatalf("ReadContext() after Close: got %d, %v, want the queued transfer", n, err)
+	}
+	if _, err := in.ReadContext(context.Background(), buf); err != errDone {
+		t.Fatalf("ReadContext() after Close: got %v, want %v", err, errDone)
+	}
+}
+
+func TestOut(t *testing.T) {
+	out := &Out{}
+	b := []byte{1, 2}
+	out.WriteContext(context.Background(), b)
+	b[0] = 5
+	out.WriteContext(context.Background(), nil)
+	if got, want := out.Transfers(), [][]byte{{1, 2}, nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Transfers(): got %v, want %v", got, want)
+	}
+}
+
+func TestControl(t *testing.T) {
+	ctl := &Control{}
+	if n, err := ctl.Control(0x21, 0x01, 2, 3, []byte("ab")); n != 2 || err != nil {
+		t.Errorf("Control(OUT): got %d, %v, want 2, nil", n, err)
+	}
+	if _, err := ctl.Control(0xa1, 0x02, 0, 0, make([]byte, 4)); err != gousb.ErrorPipe {
+		t.Errorf("Control(IN): got %v, want %v", err, gousb.ErrorPipe)
+	}
+	want := []Request{{0x21, 0x01, 2, 3, "ab"}, {0xa1, 0x02, 0, 0, ""}}
+	if got := ctl.Requests(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Requests(): got %+v, want %+v", got, want)
+	}
+
+	ctl = &Control{Handle: func(r Request, data []byte) (int, error) {
+		return copy(data, "xyz"), nil
+	}}
+	buf := make([]byte, 3)
+	if n, err := ctl.Control(0xa1, 0x02, 0, 0, buf); n != 3 || err != nil || string(buf) != "xyz" {
+		t.Errorf("Control(IN) with Handle: got %d, %v, %q, want 3, nil, \"xyz\"", n, err, buf)
+	}
+}
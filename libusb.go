@@ -28,8 +28,10 @@ import (
 #include <libusb.h>
 
 int gousb_compact_iso_data(struct libusb_transfer *xfer, unsigned char *status);
-struct libusb_transfer *gousb_alloc_transfer_and_buffer(int bufLen, int numIsoPackets);
+int gousb_iso_packet_lengths(struct libusb_transfer *xfer, int *lens);
+struct libusb_transfer *gousb_alloc_transfer_and_buffer(libusb_device_handle *dev, int bufLen, int numIsoPackets);
 void gousb_free_transfer_and_buffer(struct libusb_transfer *xfer);
+void gousb_set_iso_packet_lengths(struct libusb_transfer *xfer, int *lens, int n);
 int submit(struct libusb_transfer *xfer);
 void gousb_set_debug(libusb_context *ctx, int lvl);
 */
@@ -66,6 +68,9 @@ func (ep libusbEndpoint) endpointDesc(dev *DeviceDesc) EndpointDesc {
 			ei.UsageType = IsoUsageTypeImplicit
 		}
 	}
+	if ep.extra_length > 0 {
+		ei.Extra = C.GoBytes(unsafe.Pointer(ep.extra), ep.extra_length)
+	}
 	switch {
 	// If the device conforms to USB1.x:
 	//   Interval for polling endpoint for data transfers. Expressed in
@@ -160,14 +165,19 @@ type libusbIntf interface {
 	release(*libusbDevHandle, uint8)
 	setAlt(*libusbDevHandle, uint8, uint8) error
 
+	// endpoint
+	clearHalt(*libusbDevHandle, uint8) error
+
 	// transfer
-	alloc(*libusbDevHandle, *EndpointDesc, int, int, chan struct{}) (*libusbTransfer, error)
+	alloc(*libusbDevHandle, *EndpointDesc, time.Duration, int, int, bool, chan time.Time) (*libusbTransfer, error)
 	cancel(*libusbTransfer) error
 	submit(*libusbTransfer) error
 	buffer(*libusbTransfer) []byte
 	data(*libusbTransfer) (int, TransferStatus)
 	free(*libusbTransfer)
-	setIsoPacketLengths(*libusbTransfer, uint32)
+	packetLengths(*libusbTransfer) []int
+	setLength(*libusbTransfer, int, []int)
+	setZeroPacket(*libusbTransfer, bool)
 }
 
 // libusbImpl is an implementation of libusbIntf using real CGo-wrapped libusb.
@@ -336,6 +346,9 @@ func (libusbImpl) getDeviceDesc(d *libusbDevice) (*DeviceDesc, error) {
 					Protocol:   Protocol(alt.bInterfaceProtocol),
 					iInterface: int(alt.iInterface),
 				}
+				if alt.extra_length > 0 {
+					i.Extra = C.GoBytes(unsafe.Pointer(alt.extra), alt.extra_length)
+				}
 
 				if hasIntf[i.Number][i.Alternate] {
 					log.Printf("Device on bus %d address %d offered a descriptor for config %d with two different entries with the same interface number (%d) and the same alternate setting number (%d). gousb will use only the first one.", dev.Bus, dev.Address, c.Number, i.Number, i.Alternate)
@@ -470,8 +483,17 @@ func (libusbImpl) setAlt(d *libusbDevHandle, iface, setup uint8) error {
 	return fromErrNo(C.libusb_set_interface_alt_setting((*C.libusb_device_handle)(d), C.int(iface), C.int(setup)))
 }
 
-func (libusbImpl) alloc(d *libusbDevHandle, ep *EndpointDesc, isoPackets int, bufLen int, done chan struct{}) (*libusbTransfer, error) {
-	xfer := C.gousb_alloc_transfer_and_buffer(C.int(bufLen), C.int(isoPackets))
+func (libusbImpl) clearHalt(d *libusbDevHandle, ep uint8) error {
+	return fromErrNo(C.libusb_clear_halt((*C.libusb_device_handle)(d), C.uchar(ep)))
+}
+
+func (libusbImpl) alloc(d *libusbDevHandle, ep *EndpointDesc, timeout time.Duration, isoPackets int, bufLen int, devMem bool, done chan time.Time) (*libusbTransfer, error) {
+	// Without a device handle the buffer is allocated with malloc.
+	var memDev *C.libusb_device_handle
+	if devMem {
+		memDev = (*C.libusb_device_handle)(d)
+	}
+	xfer := C.gousb_alloc_transfer_and_buffer(memDev, C.int(bufLen), C.int(isoPackets))
 	if xfer == nil {
 		return nil, fmt.Errorf("gousb_alloc_transfer_and_buffer(%d, %d) failed", bufLen, isoPackets)
 	}
@@ -482,6 +504,7 @@ func (libusbImpl) alloc(d *libusbDevHandle, ep *EndpointDesc, isoPackets int, bu
 	xfer.endpoint = C.uchar(ep.Address)
 	xfer._type = C.uchar(ep.TransferType)
 	xfer.num_iso_packets = C.int(isoPackets)
+	xfer.timeout = C.uint(timeoutMillis(timeout))
 	ret := (*libusbTransfer)(xfer)
 	xferDoneMap.Lock()
 	xferDoneMap.m[ret] = done
@@ -489,6 +512,15 @@ func (libusbImpl) alloc(d *libusbDevHandle, ep *EndpointDesc, isoPackets int, bu
 	return ret, nil
 }
 
+// timeoutMillis returns a transfer timeout in milliseconds. Timeouts shorter
+// than a millisecond are rounded up, since 0 means no timeout for libusb.
+func timeoutMillis(timeout time.Duration) uint {
+	if timeout <= 0 {
+		return 0
+	}
+	return uint((timeout + time.Millisecond - 1) / time.Millisecond)
+}
+
 func (libusbImpl) cancel(t *libusbTransfer) error {
 	return fromErrNo(C.libusb_cancel_transfer((*C.struct_libusb_transfer)(t)))
 }
@@ -525,24 +557,56 @@ func (libusbImpl) free(t *libusbTransfer) {
 	C.gousb_free_transfer_and_buffer((*C.struct_libusb_transfer)(t))
 }
 
-func (libusbImpl) setIsoPacketLengths(t *libusbTransfer, length uint32) {
-	C.libusb_set_iso_packet_lengths((*C.struct_libusb_transfer)(t), C.uint(length))
+func (libusbImpl) packetLengths(t *libusbTransfer) []int {
+	if TransferType(t._type) != TransferTypeIsochronous || t.num_iso_packets == 0 {
+		return nil
+	}
+	lens := make([]C.int, t.num_iso_packets)
+	n := int(C.gousb_iso_packet_lengths((*C.struct_libusb_transfer)(t), &lens[0]))
+	ret := make([]int, n)
+	for i := range ret {
+		ret[i] = int(lens[i])
+	}
+	return ret
+}
+
+func (libusbImpl) setLength(t *libusbTransfer, length int, isoPackets []int) {
+	t.length = C.int(length)
+	if len(isoPackets) == 0 {
+		return
+	}
+	lens := make([]C.int, len(isoPackets))
+	for i, l := range isoPackets {
+		lens[i] = C.int(l)
+	}
+	C.gousb_set_iso_packet_lengths((*C.struct_libusb_transfer)(t), &lens[0], C.int(len(lens)))
+}
+
+func (libusbImpl) setZeroPacket(t *libusbTransfer, zlp bool) {
+	if zlp {
+		t.flags |= C.LIBUSB_TRANSFER_ADD_ZERO_PACKET
+	} else {
+		t.flags &^= C.LIBUSB_TRANSFER_ADD_ZERO_PACKET
+	}
 }
 
 // xferDoneMap keeps a map of done callback channels for all allocated transfers.
 var xferDoneMap = struct {
-	m map[*libusbTransfer]chan struct{}
+	m map[*libusbTransfer]chan time.Time
 	sync.RWMutex
 }{
-	m: make(map[*libusbTransfer]chan struct{}),
+	m: make(map[*libusbTransfer]chan time.Time),
 }
 
+// xferCallback signals the completion of a transfer with its time, the
+// transfer statistics measure the latency up to it.
+//
 //export xferCallback
 func xferCallback(xfer *C.struct_libusb_transfer) {
 	xferDoneMap.RLock()
 	ch := xferDoneMap.m[(*libusbTransfer)(xfer)]
 	xferDoneMap.RUnlock()
-	ch <- struct{}{}
+	ch <- time.Now()
 }
 
 // for benchmarking of method on implementation vs vanilla function.
@@ -1,6 +1,9 @@
 package gousb
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func BenchmarkCGo(b *testing.B) {
 	for _, bc := range []struct {
@@ -45,3 +48,78 @@ func BenchmarkCGo(b *testing.B) {
 		})
 	}
 }
+
+// openBenchmarkDevice opens the first USB device that can be opened,
+// skipping the benchmark if there is none.
+func openBenchmarkDevice(b *testing.B) (*libusbContext, *libusbDevHandle) {
+	b.Helper()
+	impl := libusbImpl{}
+	ctx, err := impl.init()
+	if err != nil {
+		b.Skipf("libusb_init() failed: %v", err)
+	}
+	devs, err := impl.getDevices(ctx)
+	if err != nil {
+		impl.exit(ctx)
+		b.Skipf("getDevices(): %v", err)
+	}
+	var handle *libusbDevHandle
+	for _, d := range devs {
+		if handle == nil {
+			if h, err := impl.open(d); err == nil {
+				handle = h
+			}
+		}
+		impl.dereference(d)
+	}
+	if handle == nil {
+		impl.exit(ctx)
+		b.Skip("no USB device can be opened")
+	}
+	return ctx, handle
+}
+
+// BenchmarkTransferAlloc compares allocating a transfer for every read or
+// write, with a buffer from malloc or from the device memory, with reusing
+// the transfers of a pool. The device memory is only available with
+// a device handle, so the benchmark needs a USB device that can be opened.
+func BenchmarkTransferAlloc(b *testing.B) {
+	libCtx, handle := openBenchmarkDevice(b)
+	defer libusbImpl{}.exit(libCtx)
+	defer libusbImpl{}.close(handle)
+	ctx := &Context{libusb: libusbImpl{}}
+	desc := &EndpointDesc{
+		Address:       0x82,
+		Number:        2,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}
+	for _, size := range []int{512, 16 * 1024, 1024 * 1024} {
+		for _, devMem := range []bool{false, true} {
+			b.Run(fmt.Sprintf("new transfer/devmem=%v/%d", devMem, size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					t, err := newUSBTransfer(ctx, handle, desc, size, 0, devMem)
+					if err != nil {
+						b.Fatalf("newUSBTransfer(): %v", err)
+					}
+					t.free()
+				}
+			})
+		}
+		b.Run(fmt.Sprintf("pooled transfer/%d", size), func(b *testing.B) {
+			p := newTransferPool()
+			defer p.close()
+			for i := 0; i < b.N; i++ {
+				t := p.get(desc.Address, size, 0)
+				if t == nil {
+					var err error
+					if t, err = newUSBTransfer(ctx, handle, desc, size, 0, true); err != nil {
+						b.Fatalf("newUSBTransfer(): %v", err)
+					}
+				}
+				p.put(desc.Address, t)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// subClassMIDIStreaming is the subclass of MIDIStreaming interfaces.
+const subClassMIDIStreaming gousb.Class = 0x03
+
+// Descriptor types and subtypes.
+const (
+	dtInterface   = 0x04
+	dtEndpoint    = 0x05
+	dtCSInterface = 0x24
+	dtCSEndpoint  = 0x25
+
+	msHeader  = 0x01
+	inJack    = 0x02
+	outJack   = 0x03
+	msGeneral = 0x01
+)
+
+// version2 is the version of MIDIStreaming interfaces carrying MIDI 2.0
+// universal MIDI packets rather than USB-MIDI event packets.
+const version2 = 0x0200
+
+// JackType tells whether a jack is connected to the USB endpoints or to
+// the outside of the device.
+type JackType uint8
+
+// Jack types.
+const (
+	// JackEmbedded jacks are connected to the USB endpoints.
+	JackEmbedded JackType = 0x01
+	// JackExternal jacks are the physical connectors of the device.
+	JackExternal JackType = 0x02
+)
+
+// String returns a human-readable name of the jack type.
+func (t JackType) String() string {
+	switch t {
+	case JackEmbedded:
+		return "embedded"
+	case JackExternal:
+		return "external"
+	}
+	return fmt.Sprintf("jack type %d", uint8(t))
+}
+
+// InJack is a MIDI IN jack, where MIDI data enters the device's function.
+type InJack struct {
+	ID   int
+	Type JackType
+	// StringIndex is the index of the string descriptor naming the jack,
+	// or 0.
+	StringIndex int
+}
+
+// Source is an input pin of a MIDI OUT jack, connected to the output pin
+// of another jack or element.
+type Source struct {
+	ID, Pin int
+}
+
+// OutJack is a MIDI OUT jack, where MIDI data leaves the device's
+// function.
+type OutJack struct {
+	ID      int
+	Type    JackType
+	Sources []Source
+	// StringIndex is the index of the string descriptor naming the jack,
+	// or 0.
+	StringIndex int
+}
+
+// Streaming holds the class-specific descriptors of a MIDIStreaming
+// interface.
+type Streaming struct {
+	// Version is the version of the MIDIStreaming specification.
+	Version  gousb.BCD
+	InJacks  []InJack
+	OutJacks []OutJack
+}
+
+// ParseStreaming parses the class-specific descriptors of a
+// MIDIStreaming interface, as available in gousb.InterfaceSetting.Extra.
+// Elements are skipped.
+func ParseStreaming(extra []byte) (*Streaming, error) {
+	var s *Streaming
+	for len(extra) > 0 {
+		l := int(extra[0])
+		if l < 3 || l > len(extra) {
+			return nil, fmt.Errorf("invalid descriptor length %d with %d bytes left", l, len(extra))
+		}
+		d := extra[:l]
+		extra = extra[l:]
+		if d[1] != dtCSInterface {
+			continue
+		}
+		switch d[2] {
+		case msHeader:
+			if l < 7 {
+				return nil, fmt.Errorf("MIDIStreaming header too short: %d bytes", l)
+			}
+			s = &Streaming{Version: gousb.BCD(binary.LittleEndian.Uint16(d[3:]))}
+		case inJack:
+			if s == nil {
+				return nil, fmt.Errorf("MIDI IN jack before the MIDIStreaming header")
+			}
+			if l < 6 {
+				return nil, fmt.Errorf("MIDI IN jack too short: %d bytes", l)
+			}
+			s.InJacks = append(s.InJacks, InJack{ID: int(d[4]), Type: JackType(d[3]), StringIndex: int(d[5])})
+		case outJack:
+			if s == nil {
+				return nil, fmt.Errorf("MIDI OUT jack before the MIDIStreaming header")
+			}
+			if l < 6 || l < 7+2*int(d[5]) {
+				return nil, fmt.Errorf("MIDI OUT jack too short: %d bytes", l)
+			}
+			j := OutJack{ID: int(d[4]), Type: JackType(d[3])}
+			pins := int(d[5])
+			for i := 0; i < pins; i++ {
+				j.Sources = append(j.Sources, Source{ID: int(d[6+2*i]), Pin: int(d[7+2*i])})
+			}
+			j.StringIndex = int(d[6+2*pins])
+			s.OutJacks = append(s.OutJacks, j)
+		}
+	}
+	if s == nil {
+		return nil, fmt.Errorf("no MIDIStreaming header")
+	}
+	return s, nil
+}
+
+// embeddedJacks returns the IDs of the embedded MIDI IN jacks, fed by the
+// OUT endpoint, and of the embedded MIDI OUT jacks, feeding the IN
+// endpoint, in the order of their descriptors.
+func (s *Streaming) embeddedJacks() (in, out []int) {
+	for _, j := range s.InJacks {
+		if j.Type == JackEmbedded {
+			in = append(in, j.ID)
+		}
+	}
+	for _, j := range s.OutJacks {
+		if j.Type == JackEmbedded {
+			out = append(out, j.ID)
+		}
+	}
+	return in, out
+}
+
+// endpointJacks returns the embedded jacks associated with the endpoints
+// of an interface setting, from the class-specific endpoint descriptors
+// found in a full configuration descriptor. The jack of cable n of an
+// endpoint is at index n.
+func endpointJacks(config []byte, intf, alt int) (map[gousb.EndpointAddress][]int, error) {
+	jacks := map[gousb.EndpointAddress][]int{}
+	inSetting := false
+	var ep gousb.EndpointAddress
+	for len(config) > 0 {
+		l := int(config[0])
+		if l < 2 || l > len(config) {
+			return nil, fmt.Errorf("invalid descriptor length %d with %d bytes left", l, len(config))
+		}
+		d := config[:l]
+		config = config[l:]
+		switch d[1] {
+		case dtInterface:
+			if l < 4 {
+				return nil, fmt.Errorf("interface descriptor too short: %d bytes", l)
+			}
+			inSetting = int(d[2]) == intf && int(d[3]) == alt
+			ep = 0
+		case dtEndpoint:
+			if l < 3 {
+				return nil, fmt.Errorf("endpoint descriptor too short: %d bytes", l)
+			}
+			ep = gousb.EndpointAddress(d[2])
+		case dtCSEndpoint:
+			if !inSetting || ep == 0 || l < 4 || d[2] != msGeneral {
+				continue
+			}
+			n := int(d[3])
+			if l < 4+n {
+				return nil, fmt.Errorf("MIDIStreaming endpoint descriptor too short: %d bytes for %d jacks", l, n)
+			}
+			var ids []int
+			for _, id := range d[4 : 4+n] {
+				ids = append(ids, int(id))
+			}
+			jacks[ep] = ids
+		}
+	}
+	return jacks, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+// streamingDesc describes a keyboard with one DIN output: embedded IN
+// jack 1 feeds external OUT jack 3, external IN jack 2 feeds embedded OUT
+// jack 4, and embedded IN jack 5 goes nowhere.
+var streamingDesc = []byte{
+	0x07, 0x24, 0x01, 0x00, 0x01, 0x41, 0x00,
+	0x06, 0x24, 0x02, 0x01, 0x01, 0x00,
+	0x06, 0x24, 0x02, 0x02, 0x02, 0x07,
+	0x09, 0x24, 0x03, 0x02, 0x03, 0x01, 0x01, 0x01, 0x00,
+	0x09, 0x24, 0x03, 0x01, 0x04, 0x01, 0x02, 0x01, 0x00,
+	0x06, 0x24, 0x02, 0x01, 0x05, 0x00,
+}
+
+// configDesc is the configuration descriptor of the keyboard, with an
+// audio control interface and the MIDIStreaming interface. The OUT
+// endpoint carries jack 5 on cable 0 and jack 1 on cable 1.
+var configDesc = append(append([]byte{
+	0x09, 0x02, 0x00, 0x00, 0x02, 0x01, 0x00, 0x80, 0x32,
+	0x09, 0x04, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00,
+	0x09, 0x24, 0x01, 0x00, 0x01, 0x09, 0x00, 0x01, 0x01,
+	0x09, 0x04, 0x01, 0x00, 0x02, 0x01, 0x03, 0x00, 0x00,
+}, streamingDesc...),
+	0x09, 0x05, 0x01, 0x02, 0x40, 0x00, 0x00, 0x00, 0x00,
+	0x06, 0x25, 0x01, 0x02, 0x05, 0x01,
+	0x09, 0x05, 0x81, 0x02, 0x40, 0x00, 0x00, 0x00, 0x00,
+	0x05, 0x25, 0x01, 0x01, 0x04,
+)
+
+func TestParseStreaming(t *testing.T) {
+	got, err := ParseStreaming(streamingDesc)
+	if err != nil {
+		t.Fatalf("ParseStreaming(): %v", err)
+	}
+	want := &Streaming{
+		Version: 0x0100,
+		InJacks: []InJack{
+			{ID: 1, Type: JackEmbedded},
+			{ID: 2, Type: JackExternal, StringIndex: 7},
+			{ID: 5, Type: JackEmbedded},
+		},
+		OutJacks: []OutJack{
+			{ID: 3, Type: JackExternal, Sources: []Source{{ID: 1, Pin: 1}}},
+			{ID: 4, Type: JackEmbedded, Sources: []Source{{ID: 2, Pin: 1}}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseStreaming(): got %+v, want %+v", got, want)
+	}
+
+	for _, tc := range []struct {
+		desc  string
+		extra []byte
+	}{
+		{"empty", nil},
+		{"no header", streamingDesc[7:]},
+		{"truncated", streamingDesc[:30]},
+		{"short OUT jack", []byte{0x07, 0x24, 0x01, 0x00, 0x01, 0x41, 0x00, 0x07, 0x24, 0x03, 0x01, 0x04, 0x01, 0x02}},
+	} {
+		if _, err := ParseStreaming(tc.extra); err == nil {
+			t.Errorf("%s: ParseStreaming(): got nil error, want an error", tc.desc)
+		}
+	}
+}
+
+func TestEndpointJacks(t *testing.T) {
+	got, err := endpointJacks(configDesc, 1, 0)
+	if err != nil {
+		t.Fatalf("endpointJacks(): %v", err)
+	}
+	want := map[gousb.EndpointAddress][]int{0x01: {5, 1}, 0x81: {4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("endpointJacks(): got %v, want %v", got, want)
+	}
+	if got, err := endpointJacks(configDesc, 0, 0); err != nil || len(got) != 0 {
+		t.Errorf("endpointJacks() of the audio control interface: got %v, %v, want no jacks", got, err)
+	}
+	if _, err := endpointJacks(configDesc[:len(configDesc)-2], 1, 0); err == nil {
+		t.Error("endpointJacks() of a truncated descriptor: got nil error, want an error")
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package midi implements USB MIDI 1.0 devices: keyboards, synthesizers,
+// interfaces with DIN MIDI ports and the like.
+//
+// Open claims the MIDIStreaming interface of a device. Each cable of the
+// IN endpoint is an Input, an io.Reader of the MIDI byte stream, and each
+// cable of the OUT endpoint is an Output, an io.Writer:
+//
+//	c, err := midi.Open(dev)
+//	...
+//	defer c.Close()
+//	// Note on, middle C, on channel 1 of the first output.
+//	_, err = c.Outputs[0].Write([]byte{0x90, 60, 100})
+//	...
+//	buf := make([]byte, 256)
+//	n, err := c.Inputs[0].Read(buf)
+//
+// The bytes written are converted to USB-MIDI event packets, and the
+// packets read back to bytes, with Encoder and Decode. System exclusive
+// messages span as many packets as needed.
+package midi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+const (
+	// readTransfers and writeTransfers are the number of transfers of the
+	// streams of the endpoints.
+	readTransfers  = 4
+	writeTransfers = 4
+	// writeSize is the size of the transfers of the OUT endpoint.
+	writeSize = 512
+	// maxBuffered is the number of bytes buffered for each Input. Data
+	// received for an Input whose buffer is full is dropped.
+	maxBuffered = 64 << 10
+)
+
+const (
+	reqGetDescriptor = 0x06
+	dtConfig         = 0x02
+	configHeaderSize = 9
+	// rTypeDescriptor is a standard request, type 0.
+	rTypeDescriptor = gousb.ControlIn | gousb.ControlDevice
+)
+
+// ErrClosed is returned by the Inputs and Outputs of a closed Conn.
+var ErrClosed = errors.New("midi: connection closed")
+
+// controller fetches the raw configuration descriptor, which holds the
+// jack associations of the endpoints. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the USB-MIDI event packets of all the input cables.
+// *gousb.ReadStream implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the USB-MIDI event packets of all the output cables.
+// *gousb.WriteStream implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Conn is an open MIDIStreaming interface.
+type Conn struct {
+	// Streaming holds the descriptors of the interface.
+	Streaming *Streaming
+	// Inputs are the cables of the IN endpoint, indexed by cable number.
+	Inputs []*Input
+	// Outputs are the cables of the OUT endpoint, indexed by cable
+	// number.
+	Outputs []*Output
+
+	// mu protects the buffers of the Inputs and rerr.
+	mu   sync.Mutex
+	rerr error
+
+	wmu sync.Mutex
+	w   writer
+
+	cancel  context.CancelFunc
+	done    chan struct{}
+	release func() error
+}
+
+// Input is a cable of the IN endpoint. Input implements io.Reader.
+type Input struct {
+	// Jack is the ID of the embedded MIDI OUT jack sending to the cable.
+	Jack int
+
+	c     *Conn
+	buf   []byte
+	ready chan struct{}
+}
+
+// Output is a cable of the OUT endpoint. Output implements io.Writer.
+type Output struct {
+	// Jack is the ID of the embedded MIDI IN jack receiving from the
+	// cable.
+	Jack int
+
+	c       *Conn
+	enc     *Encoder
+	packets []Packet
+	buf     []byte
+}
+
+func newConn(s *Streaming, inJacks, outJacks []int, w writer) *Conn {
+	c := &Conn{Streaming: s, w: w, done: make(chan struct{})}
+	for _, j := range inJacks {
+		c.Inputs = append(c.Inputs, &Input{Jack: j, c: c, ready: make(chan struct{}, 1)})
+	}
+	for i, j := range outJacks {
+		c.Outputs = append(c.Outputs, &Output{Jack: j, c: c, enc: NewEncoder(i)})
+	}
+	return c
+}
+
+// start receives the packets of the IN endpoint until the context is
+// done.
+func (c *Conn) start(ctx context.Context, r reader, size int) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(c.done)
+		buf := make([]byte, size)
+		for {
+			n, err := r.ReadContext(ctx, buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					err = ErrClosed
+				}
+				c.fail(err)
+				return
+			}
+			c.dispatch(buf[:n])
+		}
+	}()
+}
+
+// dispatch appends the MIDI bytes of the packets to the buffers of their
+// Inputs.
+func (c *Conn) dispatch(b []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ; len(b) >= len(Packet{}); b = b[len(Packet{}):] {
+		var p Packet
+		copy(p[:], b)
+		cable := p.Cable()
+		if cable >= len(c.Inputs) {
+			continue
+		}
+		in := c.Inputs[cable]
+		msg := p.Message()
+		if len(msg) == 0 || len(in.buf)+len(msg) > maxBuffered {
+			continue
+		}
+		in.buf = append(in.buf, msg...)
+		select {
+		case in.ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rerr = err
+	for _, in := range c.Inputs {
+		select {
+		case in.ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Read reads MIDI bytes received on the cable.
+func (in *Input) Read(p []byte) (int, error) {
+	return in.ReadContext(context.Background(), p)
+}
+
+// ReadContext reads MIDI bytes received on the cable. Once the IN
+// endpoint fails or the Conn is closed, the bytes left are returned,
+// followed by the error.
+func (in *Input) ReadContext(ctx context.Context, p []byte) (int, error) {
+	c := in.c
+	for {
+		c.mu.Lock()
+		if len(in.buf) > 0 {
+			n := copy(p, in.buf)
+			in.buf = in.buf[n:]
+			c.mu.Unlock()
+			return n, nil
+		}
+		err := c.rerr
+		c.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+		select {
+		case <-in.ready:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// Write sends MIDI bytes on the cable.
+func (o *Output) Write(p []byte) (int, error) {
+	return o.WriteContext(context.Background(), p)
+}
+
+// WriteContext sends MIDI bytes on the cable. The complete messages are
+// queued on the OUT endpoint, the end of an incomplete message is
+// expected in the next write. A nil error doesn't mean that the device
+// received the messages, Close returns the errors of the last transfers.
+func (o *Output) WriteContext(ctx context.Context, p []byte) (int, error) {
+	c := o.c
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.w == nil {
+		return 0, ErrClosed
+	}
+	o.packets = o.enc.Encode(o.packets[:0], p)
+	if len(o.packets) == 0 {
+		return len(p), nil
+	}
+	o.buf = o.buf[:0]
+	for _, pkt := range o.packets {
+		o.buf = append(o.buf, pkt[:]...)
+	}
+	if _, err := c.w.WriteContext(ctx, o.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Match returns true if the device has a USB MIDI 1.0 interface in any of
+// its configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if _, _, _, _, err := findInterface(cfg); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findInterface returns the first MIDIStreaming interface setting of the
+// configuration using USB-MIDI event packets, with its descriptors and
+// its bulk endpoints. One of the endpoints may be missing.
+func findInterface(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, *Streaming, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if alt.Class != gousb.ClassAudio || alt.SubClass != subClassMIDIStreaming {
+				continue
+			}
+			s, err := ParseStreaming(alt.Extra)
+			if err != nil || s.Version >= version2 {
+				continue
+			}
+			var in, out *gousb.EndpointDesc
+			for _, ep := range alt.Endpoints {
+				if ep.TransferType != gousb.TransferTypeBulk && ep.TransferType != gousb.TransferTypeInterrupt {
+					continue
+				}
+				ep := ep
+				if ep.Direction == gousb.EndpointDirectionIn {
+					in = &ep
+				} else {
+					out = &ep
+				}
+			}
+			if in == nil && out == nil {
+				continue
+			}
+			alt := alt
+			return &alt, s, in, out, nil
+		}
+	}
+	return nil, nil, nil, nil, fmt.Errorf("%s has no MIDIStreaming interface", desc)
+}
+
+// configDescriptor reads the full descriptor of a configuration, with the
+// class-specific endpoint descriptors not available in gousb.ConfigDesc.
+func configDescriptor(ctl controller, num, numConfigs int) ([]byte, error) {
+	head := make([]byte, configHeaderSize)
+	for i := 0; i < numConfigs; i++ {
+		n, err := ctl.Control(rTypeDescriptor, reqGetDescriptor, dtConfig<<8|uint16(i), 0, head)
+		if err != nil {
+			return nil, err
+		}
+		if n < configHeaderSize || int(head[5]) != num {
+			continue
+		}
+		b := make([]byte, binary.LittleEndian.Uint16(head[2:]))
+		if n, err = ctl.Control(rTypeDescriptor, reqGetDescriptor, dtConfig<<8|uint16(i), 0, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+	return nil, fmt.Errorf("no descriptor for config %d", num)
+}
+
+// cables returns the jacks of the cables of the interface endpoints. The
+// associations of the class-specific endpoint descriptors are used if
+// available, otherwise the cables follow the order of the jack
+// descriptors.
+func cables(s *Streaming, config []byte, alt *gousb.InterfaceSetting, in, out *gousb.EndpointDesc) (inJacks, outJacks []int) {
+	outJacks, inJacks = s.embeddedJacks()
+	if config != nil {
+		if jacks, err := endpointJacks(config, alt.Number, alt.Alternate); err == nil {
+			if in != nil && len(jacks[in.Address]) > 0 {
+				inJacks = jacks[in.Address]
+			}
+			if out != nil && len(jacks[out.Address]) > 0 {
+				outJacks = jacks[out.Address]
+			}
+		}
+	}
+	// An endpoint has at least one cable.
+	switch {
+	case in == nil:
+		inJacks = nil
+	case len(inJacks) == 0:
+		inJacks = []int{0}
+	}
+	switch {
+	case out == nil:
+		outJacks = nil
+	case len(outJacks) == 0:
+		outJacks = []int{0}
+	}
+	return inJacks, outJacks
+}
+
+// Open claims the MIDIStreaming interface in the active configuration of
+// the device and starts receiving from its IN endpoint. The Conn should be
+// Close()d after use.
+func Open(dev *gousb.Device) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, s, inDesc, outDesc, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	// The cables fall back to the order of the jacks if the descriptor
+	// can't be read.
+	raw, _ := configDescriptor(dev, cfgNum, len(dev.Desc.Configs))
+	inJacks, outJacks := cables(s, raw, alt, inDesc, outDesc)
+
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	var rs *gousb.ReadStream
+	var ws *gousb.WriteStream
+	c := newConn(s, inJacks, outJacks, nil)
+	release := func() error {
+		if rs != nil {
+			rs.Close()
+		}
+		var err error
+		c.wmu.Lock()
+		if ws != nil {
+			err = ws.Close()
+		}
+		c.w = nil
+		c.wmu.Unlock()
+		cfg.Close()
+		return err
+	}
+	if out != nil {
+		if ws, err = out.NewStream(writeSize, writeTransfers); err != nil {
+			release()
+			return nil, err
+		}
+		c.w = ws
+	}
+	if in != nil {
+		if rs, err = in.NewStream(inDesc.MaxPacketSize, readTransfers); err != nil {
+			release()
+			return nil, err
+		}
+		c.start(context.Background(), rs, inDesc.MaxPacketSize)
+	}
+	c.release = release
+	return c, nil
+}
+
+// Close stops receiving, waits for the messages written to be sent and
+// releases the interface. Close returns the first error of the OUT
+// endpoint.
+func (c *Conn) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+		<-c.done
+		c.cancel = nil
+	}
+	c.fail(ErrClosed)
+	var err error
+	if c.release != nil {
+		err = c.release()
+		c.release = nil
+	}
+	c.wmu.Lock()
+	c.w = nil
+	c.wmu.Unlock()
+	return err
+}
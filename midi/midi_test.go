@@ -0,0 +1,227 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func TestInputs(t *testing.T) {
+	in := fakeusb.NewIn()
+	c := newConn(&Streaming{}, []int{4, 6}, nil, nil)
+	c.start(context.Background(), in, 64)
+	in.Queue([]byte{
+		0x09, 0x90, 0x3c, 0x64,
+		0x14, 0xf0, 0x7e, 0x7f,
+		// Cable 2 doesn't exist.
+		0x29, 0x90, 0x3c, 0x64,
+		0x15, 0xf7, 0x00, 0x00,
+		0x08, 0x80, 0x3c, 0x00,
+		// A trailing partial packet is ignored.
+		0x0f, 0xf8,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, tc := range []struct {
+		cable int
+		want  []byte
+	}{
+		{0, []byte{0x90, 0x3c, 0x64, 0x80, 0x3c, 0x00}},
+		{1, []byte{0xf0, 0x7e, 0x7f, 0xf7}},
+	} {
+		var got []byte
+		buf := make([]byte, 4)
+		for len(got) < len(tc.want) {
+			n, err := c.Inputs[tc.cable].ReadContext(ctx, buf)
+			if err != nil {
+				t.Fatalf("Inputs[%d].ReadContext(): %v", tc.cable, err)
+			}
+			got = append(got, buf[:n]...)
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("Inputs[%d].ReadContext(): got % x, want % x", tc.cable, got, tc.want)
+		}
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	if _, err := c.Inputs[0].ReadContext(short, make([]byte, 4)); err != context.DeadlineExceeded {
+		t.Errorf("Inputs[0].ReadContext() without data: got error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	in.Queue([]byte{0x0c, 0xc0, 0x05, 0x00})
+	// Wait for the packet to be received before closing.
+	buf := make([]byte, 1)
+	if n, err := c.Inputs[0].ReadContext(ctx, buf); n != 1 || err != nil {
+		t.Fatalf("Inputs[0].ReadContext(): got %d, %v, want 1 byte", n, err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	// The data received before Close is still returned.
+	if n, err := c.Inputs[0].ReadContext(ctx, buf); n != 1 || err != nil || buf[0] != 0x05 {
+		t.Errorf("Inputs[0].ReadContext() after Close(): got %d, %v, want the byte left", n, err)
+	}
+	if _, err := c.Inputs[0].ReadContext(ctx, buf); err != ErrClosed {
+		t.Errorf("Inputs[0].ReadContext() after Close(): got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestInputError(t *testing.T) {
+	errFail := errors.New("pipe error")
+	c := newConn(&Streaming{}, []int{1}, nil, nil)
+	in := fakeusb.NewIn()
+	in.Close(errFail)
+	c.start(context.Background(), in, 64)
+	defer c.Close()
+	if _, err := c.Inputs[0].Read(make([]byte, 4)); err != errFail {
+		t.Errorf("Inputs[0].Read(): got error %v, want %v", err, errFail)
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	out := &fakeusb.Out{}
+	c := newConn(&Streaming{}, nil, []int{1, 5}, out)
+	for _, w := range []struct {
+		cable int
+		b     []byte
+	}{
+		{0, []byte{0x90, 0x3c, 0x64, 0x3e}},
+		{1, []byte{0xf0, 0x01, 0x02, 0x03, 0xf7}},
+		{0, []byte{0x64}},
+	} {
+		if n, err := c.Outputs[w.cable].Write(w.b); n != len(w.b) || err != nil {
+			t.Errorf("Outputs[%d].Write(% x): got %d, %v, want %d, nil", w.cable, w.b, n, err, len(w.b))
+		}
+	}
+	want := [][]byte{
+		{0x09, 0x90, 0x3c, 0x64},
+		{0x14, 0xf0, 0x01, 0x02, 0x16, 0x03, 0xf7, 0x00},
+		{0x09, 0x90, 0x3e, 0x64},
+	}
+	if got := out.Transfers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("transfers: got % x, want % x", got, want)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	if _, err := c.Outputs[0].Write([]byte{0xf8}); err != ErrClosed {
+		t.Errorf("Outputs[0].Write() after Close(): got error %v, want %v", err, ErrClosed)
+	}
+}
+
+// newFakeControl returns a control endpoint that answers GET_DESCRIPTOR
+// with the configuration descriptors.
+func newFakeControl(configs ...[]byte) *fakeusb.Control {
+	return &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+		i := int(r.Val & 0xff)
+		if r.RType != rTypeDescriptor || r.Request != reqGetDescriptor || r.Val>>8 != dtConfig || i >= len(configs) {
+			return 0, errors.New("pipe error")
+		}
+		return copy(data, configs[i]), nil
+	}}
+}
+
+func TestCables(t *testing.T) {
+	raw := append([]byte(nil), configDesc...)
+	binary.LittleEndian.PutUint16(raw[2:], uint16(len(raw)))
+	other := []byte{0x09, 0x02, 0x09, 0x00, 0x00, 0x02, 0x00, 0x80, 0x32}
+	ctl := newFakeControl(other, raw)
+	got, err := configDescriptor(ctl, 1, 2)
+	if err != nil {
+		t.Fatalf("configDescriptor(): %v", err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Errorf("configDescriptor(): got % x, want % x", got, raw)
+	}
+	if _, err := configDescriptor(ctl, 3, 2); err == nil {
+		t.Error("configDescriptor() of a missing config: got nil error, want an error")
+	}
+
+	s, err := ParseStreaming(streamingDesc)
+	if err != nil {
+		t.Fatalf("ParseStreaming(): %v", err)
+	}
+	alt := &gousb.InterfaceSetting{Number: 1}
+	in := &gousb.EndpointDesc{Address: 0x81}
+	out := &gousb.EndpointDesc{Address: 0x01}
+	for _, tc := range []struct {
+		desc          string
+		config        []byte
+		in, out       *gousb.EndpointDesc
+		wantIn, wantO []int
+	}{
+		{"endpoint descriptors", raw, in, out, []int{4}, []int{5, 1}},
+		{"jack order", nil, in, out, []int{4}, []int{1, 5}},
+		{"output only", raw, nil, out, nil, []int{5, 1}},
+		{"no jacks", other, in, nil, []int{4}, nil},
+	} {
+		gotIn, gotOut := cables(s, tc.config, alt, tc.in, tc.out)
+		if !reflect.DeepEqual(gotIn, tc.wantIn) || !reflect.DeepEqual(gotOut, tc.wantO) {
+			t.Errorf("%s: cables(): got %v, %v, want %v, %v", tc.desc, gotIn, gotOut, tc.wantIn, tc.wantO)
+		}
+	}
+	if gotIn, _ := cables(&Streaming{}, nil, alt, in, nil); !reflect.DeepEqual(gotIn, []int{0}) {
+		t.Errorf("cables() without jacks: got %v, want [0]", gotIn)
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	bulkIn := gousb.EndpointDesc{Address: 0x81, Number: 1, Direction: gousb.EndpointDirectionIn, TransferType: gousb.TransferTypeBulk}
+	bulkOut := gousb.EndpointDesc{Address: 0x01, Number: 1, Direction: gousb.EndpointDirectionOut, TransferType: gousb.TransferTypeBulk}
+	midi2 := append([]byte(nil), streamingDesc...)
+	midi2[4] = 0x02
+	ms := func(extra []byte, eps ...gousb.EndpointDesc) gousb.InterfaceSetting {
+		m := map[gousb.EndpointAddress]gousb.EndpointDesc{}
+		for _, ep := range eps {
+			m[ep.Address] = ep
+		}
+		return gousb.InterfaceSetting{Number: 1, Class: gousb.ClassAudio, SubClass: subClassMIDIStreaming, Extra: extra, Endpoints: m}
+	}
+	for _, tc := range []struct {
+		desc string
+		alts []gousb.InterfaceSetting
+		ok   bool
+	}{
+		{"keyboard", []gousb.InterfaceSetting{ms(streamingDesc, bulkIn, bulkOut)}, true},
+		{"input only", []gousb.InterfaceSetting{ms(streamingDesc, bulkIn)}, true},
+		{"no endpoints", []gousb.InterfaceSetting{ms(streamingDesc)}, false},
+		{"MIDI 2.0 only", []gousb.InterfaceSetting{ms(midi2, bulkIn, bulkOut)}, false},
+		{"MIDI 2.0 and 1.0", []gousb.InterfaceSetting{ms(midi2, bulkIn, bulkOut), ms(streamingDesc, bulkIn, bulkOut)}, true},
+		{"no descriptors", []gousb.InterfaceSetting{ms(nil, bulkIn, bulkOut)}, false},
+	} {
+		cfg := gousb.ConfigDesc{Number: 1, Interfaces: []gousb.InterfaceDesc{{Number: 1, AltSettings: tc.alts}}}
+		_, s, _, _, err := findInterface(cfg)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: findInterface(): got error %v, want ok %v", tc.desc, err, tc.ok)
+			continue
+		}
+		if got := Match(&gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: cfg}}); got != tc.ok {
+			t.Errorf("%s: Match(): got %v, want %v", tc.desc, got, tc.ok)
+		}
+		if err == nil && s.Version != 0x0100 {
+			t.Errorf("%s: findInterface(): got version %s, want 1.00", tc.desc, s.Version)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+// Packet is a USB-MIDI event packet: the cable number and code index
+// number (CIN) in the first byte, followed by up to 3 bytes of a MIDI
+// message.
+type Packet [4]byte
+
+// Code index numbers.
+const (
+	cinSystemCommon2 = 0x2
+	cinSystemCommon3 = 0x3
+	cinSysExStart    = 0x4
+	cinSysExEnd1     = 0x5
+	cinSysExEnd2     = 0x6
+	cinSysExEnd3     = 0x7
+	cinSingleByte    = 0xf
+)
+
+// cinSize is the number of MIDI bytes in a packet of each CIN. The
+// reserved CINs 0 and 1 carry no data.
+var cinSize = [16]int{0, 0, 2, 3, 3, 1, 2, 3, 3, 3, 3, 3, 2, 2, 3, 1}
+
+// Cable returns the cable number of the packet.
+func (p Packet) Cable() int {
+	return int(p[0] >> 4)
+}
+
+// CIN returns the code index number of the packet, which gives the kind of
+// the MIDI message.
+func (p Packet) CIN() uint8 {
+	return p[0] & 0x0f
+}
+
+// Message returns the MIDI bytes of the packet.
+func (p Packet) Message() []byte {
+	return p[1 : 1+cinSize[p.CIN()]]
+}
+
+// Encoder converts a MIDI byte stream to USB-MIDI event packets for a
+// cable. Messages can be split across calls to Encode, running status is
+// expanded and system exclusive messages are sent in as many packets as
+// needed. Real-time messages are sent as soon as they're seen, even in the
+// middle of another message.
+type Encoder struct {
+	cable uint8
+	// status is the running status, or 0.
+	status byte
+	// data holds the data bytes of the current message.
+	data [2]byte
+	n    int
+	// sysex is set in a system exclusive message, whose pending bytes
+	// are held in data.
+	sysex bool
+}
+
+// NewEncoder returns an Encoder for the cable, 0 to 15.
+func NewEncoder(cable int) *Encoder {
+	return &Encoder{cable: uint8(cable) << 4}
+}
+
+// dataSize returns the number of data bytes following a status byte, or
+// -1 for statuses that don't start a message with data bytes.
+func dataSize(status byte) int {
+	switch {
+	case status < 0xc0 || status >= 0xe0 && status < 0xf0:
+		return 2
+	case status < 0xe0:
+		return 1
+	case status == 0xf1 || status == 0xf3:
+		return 1
+	case status == 0xf2:
+		return 2
+	}
+	return -1
+}
+
+func (e *Encoder) packet(cin uint8, b ...byte) Packet {
+	p := Packet{e.cable | cin}
+	copy(p[1:], b)
+	return p
+}
+
+// Encode appends the packets of the complete messages of b to dst.
+// Incomplete messages are kept until the following bytes are encoded.
+func (e *Encoder) Encode(dst []Packet, b []byte) []Packet {
+	for _, c := range b {
+		switch {
+		case c >= 0xf8:
+			dst = append(dst, e.packet(cinSingleByte, c))
+		case c == 0xf0:
+			e.status, e.n, e.sysex = 0, 1, true
+			e.data[0] = c
+		case c == 0xf7:
+			if !e.sysex {
+				continue
+			}
+			dst = append(dst, e.packet(cinSysExEnd1+uint8(e.n), append(e.data[:e.n:e.n], c)...))
+			e.n, e.sysex = 0, false
+		case e.sysex && c < 0x80:
+			if e.n < 2 {
+				e.data[e.n] = c
+				e.n++
+				continue
+			}
+			dst = append(dst, e.packet(cinSysExStart, e.data[0], e.data[1], c))
+			e.n = 0
+		case c >= 0x80:
+			// A status byte ends an unterminated system exclusive
+			// message, which is dropped.
+			e.sysex, e.n = false, 0
+			e.status = 0
+			if c == 0xf6 {
+				dst = append(dst, e.packet(cinSysExEnd1, c))
+			} else if dataSize(c) > 0 {
+				e.status = c
+			}
+		case e.status != 0:
+			e.data[e.n] = c
+			e.n++
+			if e.n < dataSize(e.status) {
+				continue
+			}
+			cin := e.status >> 4
+			if e.status >= 0xf0 {
+				// System common messages have no running status.
+				cin = cinSystemCommon2 + uint8(e.n) - 1
+			}
+			dst = append(dst, e.packet(cin, append([]byte{e.status}, e.data[:e.n]...)...))
+			if e.status >= 0xf0 {
+				e.status = 0
+			}
+			e.n = 0
+		}
+	}
+	return dst
+}
+
+// Decode appends the MIDI bytes of the packets to dst.
+func Decode(dst []byte, packets ...Packet) []byte {
+	for _, p := range packets {
+		dst = append(dst, p.Message()...)
+	}
+	return dst
+}
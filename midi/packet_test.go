@@ -0,0 +1,155 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package midi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		cable int
+		in    [][]byte
+		want  []Packet
+	}{
+		{
+			desc: "note on and off",
+			in:   [][]byte{{0x90, 0x3c, 0x64, 0x80, 0x3c, 0x00}},
+			want: []Packet{{0x09, 0x90, 0x3c, 0x64}, {0x08, 0x80, 0x3c, 0x00}},
+		},
+		{
+			desc:  "running status on cable 3",
+			cable: 3,
+			in:    [][]byte{{0x91, 0x3c, 0x64, 0x3e, 0x64, 0x40}, {0x00}},
+			want:  []Packet{{0x39, 0x91, 0x3c, 0x64}, {0x39, 0x91, 0x3e, 0x64}, {0x39, 0x91, 0x40, 0x00}},
+		},
+		{
+			desc: "program change and channel pressure",
+			in:   [][]byte{{0xc0, 0x05, 0x06, 0xd1, 0x7f}},
+			want: []Packet{{0x0c, 0xc0, 0x05, 0x00}, {0x0c, 0xc0, 0x06, 0x00}, {0x0d, 0xd1, 0x7f, 0x00}},
+		},
+		{
+			desc: "pitch bend split across writes",
+			in:   [][]byte{{0xe0}, {0x00}, {0x40}},
+			want: []Packet{{0x0e, 0xe0, 0x00, 0x40}},
+		},
+		{
+			desc: "clock in the middle of a message",
+			in:   [][]byte{{0xb0, 0x07, 0xf8, 0x7f}},
+			want: []Packet{{0x0f, 0xf8, 0x00, 0x00}, {0x0b, 0xb0, 0x07, 0x7f}},
+		},
+		{
+			desc: "system common",
+			in:   [][]byte{{0xf1, 0x23, 0xf2, 0x10, 0x20, 0xf3, 0x01, 0x02, 0xf6}},
+			want: []Packet{{0x02, 0xf1, 0x23, 0x00}, {0x03, 0xf2, 0x10, 0x20}, {0x02, 0xf3, 0x01, 0x00}, {0x05, 0xf6, 0x00, 0x00}},
+		},
+		{
+			desc: "system exclusive ending with 1 byte",
+			in:   [][]byte{{0xf0, 0x7e, 0x7f, 0x06, 0x01, 0x02, 0xf7}},
+			want: []Packet{{0x04, 0xf0, 0x7e, 0x7f}, {0x04, 0x06, 0x01, 0x02}, {0x05, 0xf7, 0x00, 0x00}},
+		},
+		{
+			desc: "system exclusive ending with 2 bytes",
+			in:   [][]byte{{0xf0, 0x7e, 0x7f, 0x06, 0xf7}},
+			want: []Packet{{0x04, 0xf0, 0x7e, 0x7f}, {0x06, 0x06, 0xf7, 0x00}},
+		},
+		{
+			desc: "system exclusive ending with 3 bytes",
+			in:   [][]byte{{0xf0, 0x7e, 0xf7}},
+			want: []Packet{{0x07, 0xf0, 0x7e, 0xf7}},
+		},
+		{
+			desc: "empty system exclusive",
+			in:   [][]byte{{0xf0, 0xf7}},
+			want: []Packet{{0x06, 0xf0, 0xf7, 0x00}},
+		},
+		{
+			desc: "system exclusive split across writes with active sensing",
+			in:   [][]byte{{0xf0, 0x43}, {0x10, 0xfe, 0x4c}, {0x00, 0x00, 0x7e, 0x00, 0xf7}},
+			want: []Packet{{0x04, 0xf0, 0x43, 0x10}, {0x0f, 0xfe, 0x00, 0x00}, {0x04, 0x4c, 0x00, 0x00}, {0x07, 0x7e, 0x00, 0xf7}},
+		},
+		{
+			desc: "unterminated system exclusive",
+			in:   [][]byte{{0xf0, 0x43, 0x10, 0x4c, 0x00, 0x90, 0x3c, 0x64}},
+			want: []Packet{{0x04, 0xf0, 0x43, 0x10}, {0x09, 0x90, 0x3c, 0x64}},
+		},
+		{
+			desc: "stray data, end of exclusive and undefined statuses",
+			in:   [][]byte{{0x3c, 0x64, 0xf7, 0xf4, 0x01, 0xf5, 0x02}},
+		},
+		{
+			desc: "no running status after system common",
+			in:   [][]byte{{0xf3, 0x01, 0x02}},
+			want: []Packet{{0x02, 0xf3, 0x01, 0x00}},
+		},
+	} {
+		e := NewEncoder(tc.cable)
+		var got []Packet
+		for _, b := range tc.in {
+			got = e.Encode(got, b)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: Encode(): got % x, want % x", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	packets := []Packet{
+		{0x19, 0x90, 0x3c, 0x64},
+		{0x1c, 0xc0, 0x05, 0x00},
+		{0x00, 0x00, 0x00, 0x00},
+		{0x14, 0xf0, 0x7e, 0x7f},
+		{0x1f, 0xf8, 0x00, 0x00},
+		{0x16, 0x06, 0xf7, 0x00},
+	}
+	want := []byte{0x90, 0x3c, 0x64, 0xc0, 0x05, 0xf0, 0x7e, 0x7f, 0xf8, 0x06, 0xf7}
+	if got := Decode(nil, packets...); !bytes.Equal(got, want) {
+		t.Errorf("Decode(): got % x, want % x", got, want)
+	}
+	if got := packets[0].Cable(); got != 1 {
+		t.Errorf("Cable(): got %d, want 1", got)
+	}
+	if got := packets[1].CIN(); got != 0xc {
+		t.Errorf("CIN(): got %x, want c", got)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	// A long system exclusive message survives the round trip.
+	msg := []byte{0xf0, 0x00, 0x20, 0x29}
+	for i := 0; i < 1000; i++ {
+		msg = append(msg, byte(i%128))
+	}
+	msg = append(msg, 0xf7)
+	for split := 1; split < 8; split++ {
+		e := NewEncoder(0)
+		var packets []Packet
+		for b := msg; len(b) > 0; {
+			n := split
+			if n > len(b) {
+				n = len(b)
+			}
+			packets = e.Encode(packets, b[:n])
+			b = b[n:]
+		}
+		if got := Decode(nil, packets...); !bytes.Equal(got, msg) {
+			t.Errorf("Decode(Encode()) in writes of %d bytes: got %d bytes, want %d", split, len(got), len(msg))
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"context"
+	"time"
+)
+
+// pollTransfers is the number of transfers kept in flight by Poll.
+const pollTransfers = 3
+
+// Report is a report read from an endpoint by Poll.
+type Report struct {
+	// Data is the data of the report. It is only valid until the
+	// callback of Poll returns.
+	Data []byte
+	// Time is the time of the completion of the transfer carrying the
+	// report, see Transfer.CompletionTime. The times of subsequent reports
+	// never decrease, since the transfers of an endpoint complete in
+	// order.
+	Time time.Time
+}
+
+// Poll continuously reads reports of up to EndpointDesc.MaxPacketSize
+// bytes from the endpoint, usually an interrupt endpoint, and calls f with
+// each report, in order, until ctx is done or a transfer fails. A few
+// transfers are kept in flight, so that the device is still polled while
+// f runs. The polling interval is EndpointDesc.PollInterval, as scheduled
+// by the host controller, not by Poll. Transfers that time out, see
+// Timeout, are resubmitted.
+// Poll returns the error of the context or of the failed transfer.
+func (e *InEndpoint) Poll(ctx context.Context, f func(Report)) error {
+	var ts []*Transfer
+	defer func() {
+		for _, t := range ts {
+			t.Cancel()
+			t.Wait(context.Background())
+			t.Free()
+		}
+	}()
+	for i := 0; i < pollTransfers; i++ {
+		t, err := e.NewTransfer(e.Desc.MaxPacketSize)
+		if err != nil {
+			return err
+		}
+		ts = append(ts, t)
+		if err := t.Submit(nil); err != nil {
+			return err
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Transfers on an endpoint complete in the order of submission.
+		t := ts[0]
+		n, err := t.Wait(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && err != TransferTimedOut {
+			return err
+		}
+		if n > 0 || err == nil {
+			f(Report{Data: t.Data(), Time: t.CompletionTime()})
+		}
+		if err := t.Submit(nil); err != nil {
+			return err
+		}
+		ts = append(ts[1:], t)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoll(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	// completed are the times around the completion of each report.
+	var mu sync.Mutex
+	completed := map[byte][2]time.Time{}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 0; ; i++ {
+			xfr := lib.waitForSubmitted(done)
+			if xfr == nil {
+				return
+			}
+			switch {
+			case i == 3:
+				xfr.setData(nil)
+				xfr.setStatus(TransferTimedOut)
+			case i < 5:
+				xfr.setData([]byte{byte(i)})
+				before := time.Now()
+				xfr.setStatus(TransferCompleted)
+				mu.Lock()
+				completed[byte(i)] = [2]time.Time{before, time.Now()}
+				mu.Unlock()
+			case i == 5:
+				xfr.setStatus(TransferStall)
+			}
+			// Later transfers stay in flight until Poll cancels them.
+		}
+	}()
+
+	ep := &InEndpoint{&endpoint{ctx: ctx, Desc: EndpointDesc{
+		Address:       0x81,
+		Number:        1,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 8,
+		TransferType:  TransferTypeInterrupt,
+	}}}
+	var got [][]byte
+	var times []time.Time
+	err := ep.Poll(context.Background(), func(r Report) {
+		got = append(got, append([]byte(nil), r.Data...))
+		times = append(times, r.Time)
+	})
+	if err != TransferStall {
+		t.Errorf("Poll(): got %v, want %v", err, TransferStall)
+	}
+	if want := [][]byte{{0}, {1}, {2}, {4}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Poll() reports: got %v, want %v", got, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, r := range got {
+		c := completed[r[0]]
+		if times[i].Before(c[0]) || times[i].After(c[1]) {
+			t.Errorf("report %v: got time %v, want the time of the completion, between %v and %v", r, times[i], c[0], c[1])
+		}
+	}
+}
+
+func TestPollCancel(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			xfr := lib.waitForSubmitted(done)
+			if xfr == nil {
+				return
+			}
+			xfr.setData([]byte{1, 2, 3})
+			xfr.setStatus(TransferCompleted)
+		}
+	}()
+
+	ep := &InEndpoint{&endpoint{ctx: ctx, Desc: EndpointDesc{
+		Address:       0x81,
+		Number:        1,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 8,
+		TransferType:  TransferTypeInterrupt,
+	}}}
+	pollCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var reports int
+	err := ep.Poll(pollCtx, func(r Report) {
+		if got, want := r.Data, []byte{1, 2, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("report: got %v, want %v", got, want)
+		}
+		if reports++; reports == 2 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("Poll(): got %v, want %v", err, context.Canceled)
+	}
+	if reports != 2 {
+		t.Errorf("Poll(): got %d reports, want 2", reports)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package printer
+
+import (
+	"sort"
+	"strings"
+)
+
+// DeviceID is the IEEE 1284 device ID of a printer, a list of key/value
+// pairs such as "MFG:EPSON;CMD:ESC/POS;MDL:TM-T20;CLS:PRINTER;".
+type DeviceID struct {
+	// Manufacturer is the value of the MFG or MANUFACTURER key.
+	Manufacturer string
+	// Model is the value of the MDL or MODEL key.
+	Model string
+	// CommandSet lists the languages of the CMD or COMMAND SET key.
+	CommandSet []string
+	// Class is the value of the CLS or CLASS key, usually PRINTER.
+	Class string
+	// Description is the value of the DES or DESCRIPTION key.
+	Description string
+	// Fields holds all the pairs, with the keys as sent by the printer.
+	Fields map[string]string
+}
+
+// ParseDeviceID parses an IEEE 1284 device ID. Keys are matched without
+// regard to case and surrounding spaces, and pairs without a colon are
+// ignored.
+func ParseDeviceID(s string) *DeviceID {
+	id := &DeviceID{Fields: map[string]string{}}
+	for _, pair := range strings.Split(s, ";") {
+		i := strings.Index(pair, ":")
+		if i < 0 {
+			continue
+		}
+		key, value := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if key == "" {
+			continue
+		}
+		id.Fields[key] = value
+		switch strings.ToUpper(key) {
+		case "MFG", "MANUFACTURER":
+			id.Manufacturer = value
+		case "MDL", "MODEL":
+			id.Model = value
+		case "CMD", "COMMAND SET":
+			id.CommandSet = nil
+			for _, c := range strings.Split(value, ",") {
+				if c = strings.TrimSpace(c); c != "" {
+					id.CommandSet = append(id.CommandSet, c)
+				}
+			}
+		case "CLS", "CLASS":
+			id.Class = value
+		case "DES", "DESCRIPTION":
+			id.Description = value
+		}
+	}
+	return id
+}
+
+// String returns the device ID in the IEEE 1284 format, with the keys
+// sorted.
+func (id *DeviceID) String() string {
+	keys := make([]string, 0, len(id.Fields))
+	for k := range id.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(id.Fields[k])
+		b.WriteByte(';')
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package printer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDeviceID(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want *DeviceID
+	}{
+		{
+			in: "MFG:EPSON;CMD:ESC/POS;MDL:TM-T20;CLS:PRINTER;DES:EPSON TM-T20;",
+			want: &DeviceID{
+				Manufacturer: "EPSON",
+				Model:        "TM-T20",
+				CommandSet:   []string{"ESC/POS"},
+				Class:        "PRINTER",
+				Description:  "EPSON TM-T20",
+				Fields: map[string]string{
+					"MFG": "EPSON",
+					"CMD": "ESC/POS",
+					"MDL": "TM-T20",
+					"CLS": "PRINTER",
+					"DES": "EPSON TM-T20",
+				},
+			},
+		},
+		{
+			in: "Manufacturer:Hewlett-Packard; Command Set: PJL, PCLXL ,POSTSCRIPT;model:LaserJet 4000;junk;",
+			want: &DeviceID{
+				Manufacturer: "Hewlett-Packard",
+				Model:        "LaserJet 4000",
+				CommandSet:   []string{"PJL", "PCLXL", "POSTSCRIPT"},
+				Fields: map[string]string{
+					"Manufacturer": "Hewlett-Packard",
+					"Command Set":  "PJL, PCLXL ,POSTSCRIPT",
+					"model":        "LaserJet 4000",
+				},
+			},
+		},
+		{
+			// A value may contain colons, and the last pair may lack the
+			// semicolon.
+			in: "MFG:Acme;URF:W8,SRGB24:DM3",
+			want: &DeviceID{
+				Manufacturer: "Acme",
+				Fields: map[string]string{
+					"MFG": "Acme",
+					"URF": "W8,SRGB24:DM3",
+				},
+			},
+		},
+		{
+			in:   "",
+			want: &DeviceID{Fields: map[string]string{}},
+		},
+	} {
+		if got := ParseDeviceID(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseDeviceID(%q): got %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestDeviceIDString(t *testing.T) {
+	id := ParseDeviceID("MFG:EPSON;MDL:TM-T20;CMD:ESC/POS;")
+	if got, want := id.String(), "CMD:ESC/POS;MDL:TM-T20;MFG:EPSON;"; got != want {
+		t.Errorf("String(): got %q, want %q", got, want)
+	}
+	if got := ParseDeviceID(id.String()); !reflect.DeepEqual(got, id) {
+		t.Errorf("ParseDeviceID(%q): got %+v, want %+v", id.String(), got, id)
+	}
+}
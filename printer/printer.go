@@ -0,0 +1,477 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package printer implements the USB printer class, without the usblp
+// kernel driver.
+//
+// Open claims the printer interface of a device. The Conn returned is an
+// io.Writer of the raw print data, and an io.Reader of the data sent back
+// by bidirectional printers:
+//
+//	p, err := printer.Open(dev)
+//	...
+//	defer p.Close()
+//	id, err := p.DeviceID()
+//	...
+//	fmt.Println("printing to", id.Manufacturer, id.Model)
+//	// ESC/POS: initialize, print, feed and cut.
+//	_, err = p.Write([]byte("\x1b@Hello, world!\n\x1dV\x41\x03"))
+//
+// Printers implementing IPP over USB expose several interfaces, each
+// carrying an HTTP connection. OpenIPP claims them all.
+package printer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// subClassPrinter is the subclass of printer interfaces.
+const subClassPrinter gousb.Class = 0x01
+
+// Interface protocols of printers.
+const (
+	ProtocolUnidirectional gousb.Protocol = 0x01
+	ProtocolBidirectional  gousb.Protocol = 0x02
+	Protocol1284_4         gousb.Protocol = 0x03
+	ProtocolIPP            gousb.Protocol = 0x04
+)
+
+// Class requests.
+const (
+	reqGetDeviceID   = 0x00
+	reqGetPortStatus = 0x01
+	reqSoftReset     = 0x02
+
+	rTypeIn       = gousb.ControlIn | gousb.ControlClass | gousb.ControlInterface
+	rTypeOut      = gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface
+	rTypeOutOther = gousb.ControlOut | gousb.ControlClass | gousb.ControlOther
+)
+
+// Port status bits.
+const (
+	statusNotError   = 0x08
+	statusSelected   = 0x10
+	statusPaperEmpty = 0x20
+)
+
+const (
+	// deviceIDSize is the size of the buffer of GET_DEVICE_ID.
+	deviceIDSize = 1024
+	// readBufferSize is the size of the transfers of the IN endpoint.
+	readBufferSize = 4096
+	// writeSize is the maximum size of the transfers of the OUT
+	// endpoint.
+	writeSize = 64 << 10
+)
+
+// ErrUnidirectional is returned by Read on unidirectional printers.
+var ErrUnidirectional = errors.New("printer: unidirectional interface")
+
+// controller carries the printer class requests: GET_DEVICE_ID,
+// GET_PORT_STATUS and SOFT_RESET. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the status and replies sent back by a bidirectional
+// printer. *gousb.InEndpoint implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the print data. *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Conn is an open printer interface.
+type Conn struct {
+	// Protocol is the protocol of the interface. Read is only available
+	// for the bidirectional protocols.
+	Protocol gousb.Protocol
+
+	ctl       controller
+	cfgIndex  uint16
+	intf, alt uint8
+	r         reader
+	w         writer
+	buf       []byte
+	// pending holds the data read but not returned yet.
+	pending []byte
+
+	release func()
+}
+
+func newConn(ctl controller, cfgIndex int, alt *gousb.InterfaceSetting, r reader, w writer, maxPacket int) *Conn {
+	c := &Conn{
+		Protocol: alt.Protocol,
+		ctl:      ctl,
+		cfgIndex: uint16(cfgIndex),
+		intf:     uint8(alt.Number),
+		alt:      uint8(alt.Alternate),
+		r:        r,
+		w:        w,
+	}
+	if r != nil {
+		size := readBufferSize
+		if maxPacket > 0 {
+			size = (size + maxPacket - 1) / maxPacket * maxPacket
+		}
+		c.buf = make([]byte, size)
+	}
+	return c
+}
+
+// DeviceID returns the IEEE 1284 device ID of the printer.
+func (c *Conn) DeviceID() (*DeviceID, error) {
+	s, err := c.RawDeviceID()
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeviceID(s), nil
+}
+
+// RawDeviceID returns the IEEE 1284 device ID string of the printer.
+func (c *Conn) RawDeviceID() (string, error) {
+	b := make([]byte, deviceIDSize)
+	n, err := c.ctl.Control(rTypeIn, reqGetDeviceID, c.cfgIndex, uint16(c.intf)<<8|uint16(c.alt), b)
+	if err != nil {
+		return "", fmt.Errorf("GET_DEVICE_ID: %v", err)
+	}
+	return parseDeviceIDResponse(b[:n])
+}
+
+// parseDeviceIDResponse returns the string of a GET_DEVICE_ID response,
+// preceded by its big-endian length, which includes the 2 length bytes.
+// Some printers send the length in little-endian order.
+func parseDeviceIDResponse(b []byte) (string, error) {
+	if len(b) < 2 {
+		return "", fmt.Errorf("GET_DEVICE_ID: response too short: %d bytes", len(b))
+	}
+	l := int(binary.BigEndian.Uint16(b))
+	if l < 2 || l > len(b) {
+		l = int(binary.LittleEndian.Uint16(b))
+	}
+	if l < 2 || l > len(b) {
+		l = len(b)
+	}
+	return string(b[2:l]), nil
+}
+
+// PortStatus is the status of the printer returned by GET_PORT_STATUS.
+type PortStatus struct {
+	PaperEmpty bool
+	Selected   bool
+	// Error is set if the printer reports an error.
+	Error bool
+}
+
+// PortStatus returns the status of the printer.
+func (c *Conn) PortStatus() (PortStatus, error) {
+	b := make([]byte, 1)
+	n, err := c.ctl.Control(rTypeIn, reqGetPortStatus, 0, uint16(c.intf), b)
+	if err != nil {
+		return PortStatus{}, fmt.Errorf("GET_PORT_STATUS: %v", err)
+	}
+	if n < 1 {
+		return PortStatus{}, fmt.Errorf("GET_PORT_STATUS: empty response")
+	}
+	return PortStatus{
+		PaperEmpty: b[0]&statusPaperEmpty != 0,
+		Selected:   b[0]&statusSelected != 0,
+		Error:      b[0]&statusNotError == 0,
+	}, nil
+}
+
+// SoftReset flushes the buffers of the printer and resets its endpoints.
+func (c *Conn) SoftReset() error {
+	// The printer class specification addresses the request to "other",
+	// some printers only accept it addressed to the interface.
+	if _, err := c.ctl.Control(rTypeOutOther, reqSoftReset, 0, uint16(c.intf), nil); err != nil {
+		if _, err := c.ctl.Control(rTypeOut, reqSoftReset, 0, uint16(c.intf), nil); err != nil {
+			return fmt.Errorf("SOFT_RESET: %v", err)
+		}
+	}
+	c.pending = nil
+	return nil
+}
+
+// Read reads data sent by a bidirectional printer, such as status
+// responses.
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.ReadContext(context.Background(), p)
+}
+
+// ReadContext reads data sent by a bidirectional printer, such as status
+// responses. ReadContext blocks until the printer sends data or the
+// context is done.
+func (c *Conn) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if c.r == nil {
+		return 0, ErrUnidirectional
+	}
+	for len(c.pending) == 0 {
+		n, err := c.r.ReadContext(ctx, c.buf)
+		if err != nil {
+			return 0, err
+		}
+		c.pending = c.buf[:n]
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write sends print data to the printer.
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.WriteContext(context.Background(), p)
+}
+
+// WriteContext sends print data to the printer. Printers stall the
+// transfers when they're busy or out of paper, WriteContext returns the
+// number of bytes sent when the context is done.
+func (c *Conn) WriteContext(ctx context.Context, p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := p[written:]
+		if len(chunk) > writeSize {
+			chunk = chunk[:writeSize]
+		}
+		n, err := c.w.WriteContext(ctx, chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Close releases the interface. The Conns of an IPP are released by
+// IPP.Close.
+func (c *Conn) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
+
+// endpoints returns the bulk endpoints of an interface setting.
+func endpoints(alt gousb.InterfaceSetting) (in, out *gousb.EndpointDesc) {
+	for _, ep := range alt.Endpoints {
+		if ep.TransferType != gousb.TransferTypeBulk {
+			continue
+		}
+		ep := ep
+		if ep.Direction == gousb.EndpointDirectionIn {
+			in = &ep
+		} else {
+			out = &ep
+		}
+	}
+	return in, out
+}
+
+// rank orders the printer protocols by preference. The IEEE 1284.4
+// protocol multiplexes channels on the endpoints, it's only used without
+// the others, as a bidirectional stream.
+var rank = map[gousb.Protocol]int{
+	ProtocolBidirectional:  3,
+	ProtocolUnidirectional: 2,
+	Protocol1284_4:         1,
+}
+
+// findInterface returns the printer interface setting of the
+// configuration with the preferred protocol, and its bulk endpoints. The
+// IN endpoint is nil for unidirectional interfaces.
+func findInterface(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	var best *gousb.InterfaceSetting
+	var bestIn, bestOut *gousb.EndpointDesc
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if alt.Class != gousb.ClassPrinter || alt.SubClass != subClassPrinter || rank[alt.Protocol] == 0 {
+				continue
+			}
+			in, out := endpoints(alt)
+			if out == nil || in == nil && alt.Protocol != ProtocolUnidirectional {
+				continue
+			}
+			if best == nil || rank[alt.Protocol] > rank[best.Protocol] {
+				alt := alt
+				best, bestIn, bestOut = &alt, in, out
+			}
+		}
+	}
+	if best == nil {
+		return nil, nil, nil, fmt.Errorf("%s has no printer interface", desc)
+	}
+	if best.Protocol == ProtocolUnidirectional {
+		bestIn = nil
+	}
+	return best, bestIn, bestOut, nil
+}
+
+// IPPInterfaces returns the IPP over USB interface settings of the
+// configuration, with bulk IN and OUT endpoints.
+func IPPInterfaces(desc gousb.ConfigDesc) []gousb.InterfaceSetting {
+	var ipp []gousb.InterfaceSetting
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if alt.Class != gousb.ClassPrinter || alt.SubClass != subClassPrinter || alt.Protocol != ProtocolIPP {
+				continue
+			}
+			if in, out := endpoints(alt); in != nil && out != nil {
+				ipp = append(ipp, alt)
+				break
+			}
+		}
+	}
+	return ipp
+}
+
+// Match returns true if the device has a printer interface in any of its
+// configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if _, _, _, err := findInterface(cfg); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchIPP returns true if the device implements IPP over USB in any of
+// its configurations. It can be used with gousb.Context.OpenDevices.
+func MatchIPP(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if len(IPPInterfaces(cfg)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// configIndex returns the index of a configuration, used by
+// GET_DEVICE_ID, in the order of the configuration values.
+func configIndex(desc *gousb.DeviceDesc, num int) int {
+	var nums []int
+	for n := range desc.Configs {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	for i, n := range nums {
+		if n == num {
+			return i
+		}
+	}
+	return 0
+}
+
+// openConn claims an interface setting and opens its endpoints. The
+// interface is released when cfg is closed.
+func openConn(dev *gousb.Device, cfg *claim.Config, cfgIndex int, alt *gousb.InterfaceSetting, inDesc, outDesc *gousb.EndpointDesc) (*Conn, error) {
+	intf, err := cfg.Claim(alt)
+	if err != nil {
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		return nil, err
+	}
+	var r reader
+	maxPacket := 0
+	if in != nil {
+		r, maxPacket = in, inDesc.MaxPacketSize
+	}
+	return newConn(dev, cfgIndex, alt, r, out, maxPacket), nil
+}
+
+// Open claims the printer interface in the active configuration of the
+// device, preferring the bidirectional protocol. The Conn should be
+// Close()d after use.
+func Open(dev *gousb.Device) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, inDesc, outDesc, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, err := claim.Open(dev, cfgNum)
+	if err != nil {
+		return nil, err
+	}
+	c, err := openConn(dev, cfg, configIndex(dev.Desc, cfgNum), alt, inDesc, outDesc)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	c.release = func() { cfg.Close() }
+	return c, nil
+}
+
+// IPP is an open IPP over USB function.
+type IPP struct {
+	// Conns are the IPP interfaces. Each carries one HTTP connection at a
+	// time: a request followed by its response.
+	Conns []*Conn
+
+	release func()
+}
+
+// OpenIPP claims the IPP over USB interfaces in the active configuration of
+// the device. The IPP should be Close()d after use.
+func OpenIPP(dev *gousb.Device) (*IPP, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alts := IPPInterfaces(desc)
+	if len(alts) == 0 {
+		return nil, fmt.Errorf("device %s: %s has no IPP over USB interface", dev, desc)
+	}
+	cfg, err := claim.Open(dev, cfgNum)
+	if err != nil {
+		return nil, err
+	}
+	// The interfaces are released together by IPP.Close.
+	p := &IPP{release: func() { cfg.Close() }}
+	for i := range alts {
+		alt := &alts[i]
+		in, out := endpoints(*alt)
+		c, err := openConn(dev, cfg, configIndex(dev.Desc, cfgNum), alt, in, out)
+		if err != nil {
+			cfg.Close()
+			return nil, err
+		}
+		p.Conns = append(p.Conns, c)
+	}
+	return p, nil
+}
+
+// Close releases the IPP interfaces.
+func (p *IPP) Close() error {
+	if p.release != nil {
+		p.release()
+		p.release = nil
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func TestDeviceIDRequest(t *testing.T) {
+	const id = "MFG:Acme;MDL:Laser 9;"
+	for _, tc := range []struct {
+		desc string
+		resp []byte
+		want string
+	}{
+		{
+			desc: "big-endian length",
+			resp: append([]byte{0, byte(len(id) + 2)}, id...),
+			want: id,
+		},
+		{
+			desc: "little-endian length",
+			resp: append([]byte{byte(len(id) + 2), 0}, id...),
+			want: id,
+		},
+		{
+			desc: "length beyond the response",
+			resp: append([]byte{0x10, 0x10}, id...),
+			want: id,
+		},
+		{
+			desc: "length shorter than the response",
+			resp: append([]byte{0, 6}, id...),
+			want: "MFG:",
+		},
+	} {
+		ctl := &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+			return copy(data, tc.resp), nil
+		}}
+		c := newConn(ctl, 1, &gousb.InterfaceSetting{Number: 2, Alternate: 1, Protocol: ProtocolBidirectional}, fakeusb.NewIn(), &fakeusb.Out{}, 64)
+		got, err := c.RawDeviceID()
+		if err != nil {
+			t.Errorf("%s: RawDeviceID(): %v", tc.desc, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: RawDeviceID(): got %q, want %q", tc.desc, got, tc.want)
+		}
+		wantReq := []fakeusb.Request{{RType: 0xa1, Request: reqGetDeviceID, Val: 1, Idx: 0x0201}}
+		if got := ctl.Requests(); !reflect.DeepEqual(got, wantReq) {
+			t.Errorf("%s: requests: got %+v, want %+v", tc.desc, got, wantReq)
+		}
+	}
+}
+
+func TestPortStatus(t *testing.T) {
+	for _, tc := range []struct {
+		status byte
+		want   PortStatus
+	}{
+		{0x18, PortStatus{Selected: true}},
+		{0x38, PortStatus{PaperEmpty: true, Selected: true}},
+		{0x00, PortStatus{Error: true}},
+	} {
+		ctl := &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+			data[0] = tc.status
+			return 1, nil
+		}}
+		c := newConn(ctl, 0, &gousb.InterfaceSetting{Number: 3, Protocol: ProtocolUnidirectional}, nil, &fakeusb.Out{}, 0)
+		got, err := c.PortStatus()
+		if err != nil {
+			t.Fatalf("PortStatus(): %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("PortStatus() with status 0x%02x: got %+v, want %+v", tc.status, got, tc.want)
+		}
+		want := []fakeusb.Request{{RType: 0xa1, Request: reqGetPortStatus, Idx: 3}}
+		if got := ctl.Requests(); !reflect.DeepEqual(got, want) {
+			t.Errorf("requests: got %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestSoftReset(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		refuse  uint8
+		want    []fakeusb.Request
+		wantErr bool
+	}{
+		{
+			desc: "addressed to other",
+			want: []fakeusb.Request{{RType: 0x23, Request: reqSoftReset, Idx: 1}},
+		},
+		{
+			desc:   "addressed to the interface",
+			refuse: 0x23,
+			want:   []fakeusb.Request{{RType: 0x23, Request: reqSoftReset, Idx: 1}, {RType: 0x21, Request: reqSoftReset, Idx: 1}},
+		},
+		{
+			desc:    "refused",
+			refuse:  0xff,
+			want:    []fakeusb.Request{{RType: 0x23, Request: reqSoftReset, Idx: 1}, {RType: 0x21, Request: reqSoftReset, Idx: 1}},
+			wantErr: true,
+		},
+	} {
+		ctl := &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+			if tc.refuse == 0xff || r.RType == tc.refuse {
+				return 0, gousb.ErrorPipe
+			}
+			return 0, nil
+		}}
+		c := newConn(ctl, 0, &gousb.InterfaceSetting{Number: 1, Protocol: ProtocolBidirectional}, fakeusb.NewIn(), &fakeusb.Out{}, 64)
+		if err := c.SoftReset(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: SoftReset(): got error %v, want error %v", tc.desc, err, tc.wantErr)
+		}
+		if got := ctl.Requests(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: requests: got %+v, want %+v", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	in := fakeusb.NewIn([]byte("@PJL INFO"), []byte{}, []byte(" STATUS\r\n"))
+	in.Close(errors.New("no more transfers"))
+	out := &fakeusb.Out{}
+	c := newConn(&fakeusb.Control{}, 0, &gousb.InterfaceSetting{Protocol: ProtocolBidirectional}, in, out, 512)
+	if got, want := len(c.buf), 4096; got != want {
+		t.Errorf("read buffer: got %d bytes, want %d", got, want)
+	}
+	var got []byte
+	buf := make([]byte, 4)
+	for len(got) < len("@PJL INFO STATUS\r\n") {
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatalf("Read(): %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if want := "@PJL INFO STATUS\r\n"; string(got) != want {
+		t.Errorf("Read(): got %q, want %q", got, want)
+	}
+
+	data := bytes.Repeat([]byte{0x1b}, writeSize+10)
+	n, err := c.Write(data)
+	if err != nil || n != len(data) {
+		t.Errorf("Write(): got %d, %v, want %d, nil", n, err, len(data))
+	}
+	written := out.Transfers()
+	if got, want := len(written), 2; got != want {
+		t.Fatalf("Write(): got %d transfers, want %d", got, want)
+	}
+	if got, want := len(written[1]), 10; got != want {
+		t.Errorf("Write(): got last transfer of %d bytes, want %d", got, want)
+	}
+}
+
+func TestReadUnidirectional(t *testing.T) {
+	c := newConn(&fakeusb.Control{}, 0, &gousb.InterfaceSetting{Protocol: ProtocolUnidirectional}, nil, &fakeusb.Out{}, 0)
+	if _, err := c.Read(make([]byte, 16)); err != ErrUnidirectional {
+		t.Errorf("Read(): got error %v, want %v", err, ErrUnidirectional)
+	}
+}
+
+func printerAlt(num int, protocol gousb.Protocol, endpoints ...gousb.EndpointDirection) gousb.InterfaceSetting {
+	alt := gousb.InterfaceSetting{
+		Number:    num,
+		Class:     gousb.ClassPrinter,
+		SubClass:  subClassPrinter,
+		Protocol:  protocol,
+		Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{},
+	}
+	for i, dir := range endpoints {
+		addr := gousb.EndpointAddress(num*2 + i + 1)
+		if dir == gousb.EndpointDirectionIn {
+			addr |= 0x80
+		}
+		alt.Endpoints[addr] = gousb.EndpointDesc{
+			Address:       addr,
+			Number:        num*2 + i + 1,
+			Direction:     dir,
+			TransferType:  gousb.TransferTypeBulk,
+			MaxPacketSize: 512,
+		}
+	}
+	return alt
+}
+
+func TestFindInterface(t *testing.T) {
+	in, out := gousb.EndpointDirectionIn, gousb.EndpointDirectionOut
+	for _, tc := range []struct {
+		desc    string
+		alts    [][]gousb.InterfaceSetting
+		wantNum int
+		wantAlt int
+		wantIn  bool
+		wantErr bool
+	}{
+		{
+			desc:    "unidirectional",
+			alts:    [][]gousb.InterfaceSetting{{printerAlt(0, ProtocolUnidirectional, out)}},
+			wantNum: 0,
+		},
+		{
+			desc: "bidirectional preferred",
+			alts: [][]gousb.InterfaceSetting{{
+				printerAlt(0, ProtocolUnidirectional, out),
+				printerAlt(0, ProtocolBidirectional, out, in),
+				printerAlt(0, Protocol1284_4, out, in),
+			}},
+			wantAlt: 1,
+			wantIn:  true,
+		},
+		{
+			desc: "unidirectional use of a bidirectional interface",
+			alts: [][]gousb.InterfaceSetting{{
+				printerAlt(0, ProtocolUnidirectional, out, in),
+			}},
+		},
+		{
+			desc: "IPP only",
+			alts: [][]gousb.InterfaceSetting{
+				{printerAlt(0, ProtocolIPP, out, in)},
+				{printerAlt(1, ProtocolIPP, out, in)},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "printer next to IPP",
+			alts: [][]gousb.InterfaceSetting{
+				{printerAlt(0, ProtocolIPP, out, in)},
+				{printerAlt(1, ProtocolBidirectional, out, in)},
+			},
+			wantNum: 1,
+			wantIn:  true,
+		},
+		{
+			desc:    "bidirectional without IN endpoint",
+			alts:    [][]gousb.InterfaceSetting{{printerAlt(0, ProtocolBidirectional, out)}},
+			wantErr: true,
+		},
+	} {
+		var cfg gousb.ConfigDesc
+		for i, alts := range tc.alts {
+			for j := range alts {
+				alts[j].Alternate = j
+			}
+			cfg.Interfaces = append(cfg.Interfaces, gousb.InterfaceDesc{Number: i, AltSettings: alts})
+		}
+		alt, inDesc, outDesc, err := findInterface(cfg)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: findInterface(): got error %v, want error %v", tc.desc, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if alt.Number != tc.wantNum || alt.Alternate != tc.wantAlt {
+			t.Errorf("%s: findInterface(): got interface %d alt %d, want %d alt %d", tc.desc, alt.Number, alt.Alternate, tc.wantNum, tc.wantAlt)
+		}
+		if (inDesc != nil) != tc.wantIn || outDesc == nil {
+			t.Errorf("%s: findInterface(): got endpoints %v, %v, want IN %v and OUT", tc.desc, inDesc, outDesc, tc.wantIn)
+		}
+	}
+}
+
+func TestIPPInterfaces(t *testing.T) {
+	in, out := gousb.EndpointDirectionIn, gousb.EndpointDirectionOut
+	cfg := gousb.ConfigDesc{Interfaces: []gousb.InterfaceDesc{
+		{Number: 0, AltSettings: []gousb.InterfaceSetting{
+			printerAlt(0, ProtocolBidirectional, out, in),
+			printerAlt(0, ProtocolIPP, out, in),
+		}},
+		{Number: 1, AltSettings: []gousb.InterfaceSetting{printerAlt(1, ProtocolIPP, out, in)}},
+		// Without IN endpoint.
+		{Number: 2, AltSettings: []gousb.InterfaceSetting{printerAlt(2, ProtocolIPP, out)}},
+		{Number: 3, AltSettings: []gousb.InterfaceSetting{printerAlt(3, ProtocolIPP, out, in)}},
+	}}
+	var got []int
+	for _, alt := range IPPInterfaces(cfg) {
+		got = append(got, alt.Number)
+	}
+	if want := []int{0, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IPPInterfaces(): got interfaces %v, want %v", got, want)
+	}
+	if !MatchIPP(&gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: cfg}}) {
+		t.Errorf("MatchIPP(): got false, want true")
+	}
+}
+
+func TestConfigIndex(t *testing.T) {
+	desc := &gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: {}, 2: {}, 5: {}}}
+	for num, want := range map[int]int{1: 0, 2: 1, 5: 2} {
+		if got := configIndex(desc, num); got != want {
+			t.Errorf("configIndex(%d): got %d, want %d", num, got, want)
+		}
+	}
+}
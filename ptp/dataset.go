@@ -0,0 +1,410 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// decoder reads the little-endian fields of a PTP dataset. The first
+// error is kept and subsequent reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.b) < n {
+		d.err = fmt.Errorf("dataset truncated: need %d bytes, got %d", n, len(d.b))
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) u8() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) u16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) u32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) u64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// count reads the number of elements of an array of elements of size
+// bytes, and checks that they are present.
+func (d *decoder) count(size int) int {
+	n := d.u32()
+	if d.err == nil && uint64(n)*uint64(size) > uint64(len(d.b)) {
+		d.err = fmt.Errorf("array of %d elements longer than the %d bytes left", n, len(d.b))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) u16s() []uint16 {
+	n := d.count(2)
+	if n == 0 {
+		return nil
+	}
+	v := make([]uint16, n)
+	for i := range v {
+		v[i] = d.u16()
+	}
+	return v
+}
+
+func (d *decoder) u32s() []uint32 {
+	n := d.count(4)
+	if n == 0 {
+		return nil
+	}
+	v := make([]uint32, n)
+	for i := range v {
+		v[i] = d.u32()
+	}
+	return v
+}
+
+// string reads a PTP string: the number of UTF-16 code units including the
+// terminating null, followed by the code units.
+func (d *decoder) string() string {
+	n := int(d.u8())
+	if n == 0 {
+		return ""
+	}
+	b := d.next(2 * n)
+	if b == nil {
+		return ""
+	}
+	u := make([]uint16, n)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	for len(u) > 0 && u[len(u)-1] == 0 {
+		u = u[:len(u)-1]
+	}
+	return string(utf16.Decode(u))
+}
+
+// encoder writes the little-endian fields of a PTP dataset.
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) u8(v uint8) {
+	e.b = append(e.b, v)
+}
+
+func (e *encoder) u16(v uint16) {
+	e.b = append(e.b, byte(v), byte(v>>8))
+}
+
+func (e *encoder) u32(v uint32) {
+	e.b = append(e.b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func (e *encoder) u64(v uint64) {
+	e.u32(uint32(v))
+	e.u32(uint32(v >> 32))
+}
+
+// maxStringLength is the maximum number of UTF-16 code units in a PTP
+// string, including the terminating null.
+const maxStringLength = 255
+
+func (e *encoder) string(s string) {
+	if s == "" {
+		e.u8(0)
+		return
+	}
+	u := utf16.Encode([]rune(s))
+	if len(u) > maxStringLength-1 {
+		u = u[:maxStringLength-1]
+	}
+	e.u8(uint8(len(u) + 1))
+	for _, c := range u {
+		e.u16(c)
+	}
+	e.u16(0)
+}
+
+// dateTimeLayout is the layout of PTP date strings, optionally followed
+// by tenths of seconds and a time zone.
+const dateTimeLayout = "20060102T150405"
+
+// parseDateTime parses a PTP date string. It returns the zero time for
+// empty or invalid strings, which some devices report.
+func parseDateTime(s string) time.Time {
+	if len(s) < len(dateTimeLayout) {
+		return time.Time{}
+	}
+	loc := time.Local
+	rest := s[len(dateTimeLayout):]
+	if strings.HasPrefix(rest, ".") && len(rest) >= 2 {
+		rest = rest[2:]
+	}
+	switch {
+	case rest == "Z":
+		loc = time.UTC
+	case len(rest) == 5 && (rest[0] == '+' || rest[0] == '-'):
+		t, err := time.Parse(dateTimeLayout+"-0700", s[:len(dateTimeLayout)]+rest)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	case rest != "":
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, s[:len(dateTimeLayout)], loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func formatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateTimeLayout)
+}
+
+// ObjectFormat is the format code of an object.
+type ObjectFormat uint16
+
+// Common object formats.
+const (
+	FormatUndefined   ObjectFormat = 0x3000
+	FormatAssociation ObjectFormat = 0x3001
+	FormatText        ObjectFormat = 0x3004
+	FormatMP3         ObjectFormat = 0x3009
+	FormatMPEG        ObjectFormat = 0x300b
+	FormatEXIFJPEG    ObjectFormat = 0x3801
+	FormatTIFFEP      ObjectFormat = 0x3802
+	FormatBMP         ObjectFormat = 0x3804
+	FormatGIF         ObjectFormat = 0x3807
+	FormatPNG         ObjectFormat = 0x380b
+	FormatTIFF        ObjectFormat = 0x380d
+	FormatMP4         ObjectFormat = 0xb982
+)
+
+// DeviceInfo is the dataset returned by GetDeviceInfo.
+type DeviceInfo struct {
+	StandardVersion        uint16
+	VendorExtensionID      uint32
+	VendorExtensionVersion uint16
+	VendorExtensionDesc    string
+	FunctionalMode         uint16
+	Operations             []OpCode
+	Events                 []EventCode
+	DeviceProperties       []uint16
+	CaptureFormats         []ObjectFormat
+	PlaybackFormats        []ObjectFormat
+	Manufacturer           string
+	Model                  string
+	DeviceVersion          string
+	SerialNumber           string
+}
+
+// vendorMicrosoft is the vendor extension ID of MTP devices.
+const vendorMicrosoft = 0x00000006
+
+// MTP returns true if the device implements the MTP extensions.
+func (d *DeviceInfo) MTP() bool {
+	return d.VendorExtensionID == vendorMicrosoft || strings.Contains(d.VendorExtensionDesc, "microsoft.com")
+}
+
+// Supports returns true if the device supports the operation.
+func (d *DeviceInfo) Supports(op OpCode) bool {
+	for _, o := range d.Operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDeviceInfo(b []byte) (*DeviceInfo, error) {
+	d := &decoder{b: b}
+	info := &DeviceInfo{
+		StandardVersion:        d.u16(),
+		VendorExtensionID:      d.u32(),
+		VendorExtensionVersion: d.u16(),
+		VendorExtensionDesc:    d.string(),
+		FunctionalMode:         d.u16(),
+	}
+	for _, o := range d.u16s() {
+		info.Operations = append(info.Operations, OpCode(o))
+	}
+	for _, e := range d.u16s() {
+		info.Events = append(info.Events, EventCode(e))
+	}
+	info.DeviceProperties = d.u16s()
+	for _, f := range d.u16s() {
+		info.CaptureFormats = append(info.CaptureFormats, ObjectFormat(f))
+	}
+	for _, f := range d.u16s() {
+		info.PlaybackFormats = append(info.PlaybackFormats, ObjectFormat(f))
+	}
+	info.Manufacturer = d.string()
+	info.Model = d.string()
+	info.DeviceVersion = d.string()
+	info.SerialNumber = d.string()
+	if d.err != nil {
+		return nil, fmt.Errorf("DeviceInfo: %v", d.err)
+	}
+	return info, nil
+}
+
+// StorageInfo is the dataset returned by GetStorageInfo.
+type StorageInfo struct {
+	Type             uint16
+	FilesystemType   uint16
+	AccessCapability uint16
+	MaxCapacity      uint64
+	FreeSpace        uint64
+	// FreeObjects is the number of objects that can still be stored, or
+	// 0xffffffff if unknown.
+	FreeObjects uint32
+	Description string
+	VolumeLabel string
+}
+
+func parseStorageInfo(b []byte) (*StorageInfo, error) {
+	d := &decoder{b: b}
+	info := &StorageInfo{
+		Type:             d.u16(),
+		FilesystemType:   d.u16(),
+		AccessCapability: d.u16(),
+		MaxCapacity:      d.u64(),
+		FreeSpace:        d.u64(),
+		FreeObjects:      d.u32(),
+		Description:      d.string(),
+		VolumeLabel:      d.string(),
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("StorageInfo: %v", d.err)
+	}
+	return info, nil
+}
+
+// ObjectInfo is the dataset describing an object, returned by
+// GetObjectInfo and sent by SendObjectInfo.
+type ObjectInfo struct {
+	StorageID        uint32
+	Format           ObjectFormat
+	ProtectionStatus uint16
+	// CompressedSize is the size of the object, or 0xffffffff if it's
+	// larger than 4GiB.
+	CompressedSize      uint32
+	ThumbFormat         ObjectFormat
+	ThumbCompressedSize uint32
+	ThumbWidth          uint32
+	ThumbHeight         uint32
+	ImageWidth          uint32
+	ImageHeight         uint32
+	ImageBitDepth       uint32
+	// Parent is the handle of the parent association (folder), or 0 for
+	// objects in the root.
+	Parent           uint32
+	AssociationType  uint16
+	AssociationDesc  uint32
+	SequenceNumber   uint32
+	Filename         string
+	CaptureDate      time.Time
+	ModificationDate time.Time
+	Keywords         string
+}
+
+func parseObjectInfo(b []byte) (*ObjectInfo, error) {
+	d := &decoder{b: b}
+	info := &ObjectInfo{
+		StorageID:           d.u32(),
+		Format:              ObjectFormat(d.u16()),
+		ProtectionStatus:    d.u16(),
+		CompressedSize:      d.u32(),
+		ThumbFormat:         ObjectFormat(d.u16()),
+		ThumbCompressedSize: d.u32(),
+		ThumbWidth:          d.u32(),
+		ThumbHeight:         d.u32(),
+		ImageWidth:          d.u32(),
+		ImageHeight:         d.u32(),
+		ImageBitDepth:       d.u32(),
+		Parent:              d.u32(),
+		AssociationType:     d.u16(),
+		AssociationDesc:     d.u32(),
+		SequenceNumber:      d.u32(),
+		Filename:            d.string(),
+		CaptureDate:         parseDateTime(d.string()),
+		ModificationDate:    parseDateTime(d.string()),
+		Keywords:            d.string(),
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("ObjectInfo: %v", d.err)
+	}
+	return info, nil
+}
+
+func (info *ObjectInfo) marshal() []byte {
+	e := &encoder{}
+	e.u32(info.StorageID)
+	e.u16(uint16(info.Format))
+	e.u16(info.ProtectionStatus)
+	e.u32(info.CompressedSize)
+	e.u16(uint16(info.ThumbFormat))
+	e.u32(info.ThumbCompressedSize)
+	e.u32(info.ThumbWidth)
+	e.u32(info.ThumbHeight)
+	e.u32(info.ImageWidth)
+	e.u32(info.ImageHeight)
+	e.u32(info.ImageBitDepth)
+	e.u32(info.Parent)
+	e.u16(info.AssociationType)
+	e.u32(info.AssociationDesc)
+	e.u32(info.SequenceNumber)
+	e.string(info.Filename)
+	e.string(formatDateTime(info.CaptureDate))
+	e.string(formatDateTime(info.ModificationDate))
+	e.string(info.Keywords)
+	return e.b
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// deviceInfoDataset returns the DeviceInfo dataset of an MTP phone.
+func deviceInfoDataset() []byte {
+	e := &encoder{}
+	e.u16(100)
+	e.u32(vendorMicrosoft)
+	e.u16(100)
+	e.string("microsoft.com: 1.0; android.com: 1.0;")
+	e.u16(0)
+	u16s := func(v ...uint16) {
+		e.u32(uint32(len(v)))
+		for _, x := range v {
+			e.u16(x)
+		}
+	}
+	u16s(0x1001, 0x1002, 0x1003, 0x1004, 0x9805)
+	u16s(0x4002, 0x4003)
+	u16s()
+	u16s()
+	u16s(0x3000, 0x3801)
+	e.string("Acme")
+	e.string("Phone")
+	e.string("1.0")
+	e.string("0123456789")
+	return e.b
+}
+
+func TestParseDeviceInfo(t *testing.T) {
+	got, err := parseDeviceInfo(deviceInfoDataset())
+	if err != nil {
+		t.Fatalf("parseDeviceInfo(): %v", err)
+	}
+	want := &DeviceInfo{
+		StandardVersion:        100,
+		VendorExtensionID:      vendorMicrosoft,
+		VendorExtensionVersion: 100,
+		VendorExtensionDesc:    "microsoft.com: 1.0; android.com: 1.0;",
+		Operations:             []OpCode{OpGetDeviceInfo, OpOpenSession, OpCloseSession, OpGetStorageIDs, OpGetObjectPropList},
+		Events:                 []EventCode{EventObjectAdded, EventObjectRemoved},
+		PlaybackFormats:        []ObjectFormat{FormatUndefined, FormatEXIFJPEG},
+		Manufacturer:           "Acme",
+		Model:                  "Phone",
+		DeviceVersion:          "1.0",
+		SerialNumber:           "0123456789",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDeviceInfo(): got %+v, want %+v", got, want)
+	}
+	if !got.MTP() {
+		t.Error("MTP(): got false, want true")
+	}
+	if !got.Supports(OpGetObjectPropList) || got.Supports(OpSendObject) {
+		t.Errorf("Supports(): got %v/%v for %s/%s, want true/false", got.Supports(OpGetObjectPropList), got.Supports(OpSendObject), OpGetObjectPropList, OpSendObject)
+	}
+
+	b := deviceInfoDataset()
+	for _, n := range []int{0, 5, 20, len(b) - 1} {
+		if _, err := parseDeviceInfo(b[:n]); err == nil {
+			t.Errorf("parseDeviceInfo(%d of %d bytes): got nil error, want an error", n, len(b))
+		}
+	}
+}
+
+func TestObjectInfoRoundTrip(t *testing.T) {
+	for _, info := range []*ObjectInfo{
+		{},
+		{
+			StorageID:        0x10001,
+			Format:           FormatEXIFJPEG,
+			CompressedSize:   123456,
+			ThumbFormat:      FormatEXIFJPEG,
+			ImageWidth:       4000,
+			ImageHeight:      3000,
+			ImageBitDepth:    24,
+			Parent:           12,
+			SequenceNumber:   3,
+			Filename:         "IMG_0001.JPG",
+			CaptureDate:      time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local),
+			ModificationDate: time.Date(2024, 5, 6, 7, 8, 10, 0, time.Local),
+			Keywords:         "holiday",
+		},
+		{Format: FormatAssociation, AssociationType: 1, Filename: "Fotos 📷 été"},
+	} {
+		got, err := parseObjectInfo(info.marshal())
+		if err != nil {
+			t.Errorf("parseObjectInfo(marshal(%+v)): %v", info, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, info) {
+			t.Errorf("parseObjectInfo(marshal()): got %+v, want %+v", got, info)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want []byte
+	}{
+		{"", []byte{0}},
+		{"ab", []byte{3, 'a', 0, 'b', 0, 0, 0}},
+		{"é", []byte{2, 0xe9, 0, 0, 0}},
+		{"😀", []byte{3, 0x3d, 0xd8, 0x00, 0xde, 0, 0}},
+	} {
+		e := &encoder{}
+		e.string(tc.s)
+		if !reflect.DeepEqual(e.b, tc.want) {
+			t.Errorf("encoder.string(%q): got % x, want % x", tc.s, e.b, tc.want)
+		}
+		d := &decoder{b: tc.want}
+		if got := d.string(); got != tc.s || d.err != nil {
+			t.Errorf("decoder.string(% x): got %q, %v, want %q", tc.want, got, d.err, tc.s)
+		}
+	}
+
+	d := &decoder{b: []byte{3, 'a', 0}}
+	if d.string(); d.err == nil {
+		t.Error("decoder.string() of a truncated string: got nil error, want an error")
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want time.Time
+	}{
+		{"20240506T070809", time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)},
+		{"20240506T070809.5", time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)},
+		{"20240506T070809Z", time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)},
+		{"20240506T070809.0Z", time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)},
+		{"20240506T070809+0200", time.Date(2024, 5, 6, 5, 8, 9, 0, time.UTC)},
+		{"", time.Time{}},
+		{"2024", time.Time{}},
+		{"20241306T070809", time.Time{}},
+		{"20240506T070809 junk", time.Time{}},
+	} {
+		if got := parseDateTime(tc.s); !got.Equal(tc.want) {
+			t.Errorf("parseDateTime(%q): got %v, want %v", tc.s, got, tc.want)
+		}
+	}
+}
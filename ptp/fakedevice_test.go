@@ -0,0 +1,182 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+)
+
+// fakeResult is the answer of the fake device to an operation.
+type fakeResult struct {
+	data   []byte
+	code   ResponseCode
+	params []uint32
+	// silent results are never sent, the host waits for the response.
+	silent bool
+}
+
+// fakeDevice is a PTP responder. Commands and data written by the host are
+// parsed, and the data and response containers are queued as transfers
+// of at most chunk bytes.
+type fakeDevice struct {
+	mu sync.Mutex
+	// handle returns the answer to an operation, with the data sent by
+	// the host.
+	handle func(op OpCode, params []uint32, data []byte) fakeResult
+	// dataOut lists the operations with a data phase sent by the host.
+	dataOut map[OpCode]bool
+	// chunk is the maximum size of a transfer read by the host.
+	chunk     int
+	maxPacket int
+	// merge sends the response in the same transfer as the end of the
+	// data phase.
+	merge bool
+	// lengthUnknown sends data containers with the length 0xffffffff,
+	// as for objects larger than 4GiB.
+	lengthUnknown bool
+
+	in      []byte
+	pending *fakeCommand
+	tids    []uint32
+	writes  []int
+	queue   [][]byte
+	ready   chan struct{}
+	cancels [][]byte
+}
+
+type fakeCommand struct {
+	op     OpCode
+	tid    uint32
+	params []uint32
+}
+
+func newFakeDevice(handle func(OpCode, []uint32, []byte) fakeResult) *fakeDevice {
+	return &fakeDevice{
+		handle:    handle,
+		dataOut:   map[OpCode]bool{OpSendObjectInfo: true, OpSendObject: true, OpSetObjectPropValue: true},
+		chunk:     4096,
+		maxPacket: 512,
+		ready:     make(chan struct{}, 1),
+	}
+}
+
+func (f *fakeDevice) WriteContext(ctx context.Context, p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes = append(f.writes, len(p))
+	f.in = append(f.in, p...)
+	le := binary.LittleEndian
+	for len(f.in) >= headerSize {
+		l := int(le.Uint32(f.in))
+		if len(f.in) < l {
+			break
+		}
+		c := f.in[:l]
+		f.in = f.in[l:]
+		switch le.Uint16(c[4:]) {
+		case typeCommand:
+			cmd := &fakeCommand{op: OpCode(le.Uint16(c[6:])), tid: le.Uint32(c[8:])}
+			for b := c[headerSize:]; len(b) >= 4; b = b[4:] {
+				cmd.params = append(cmd.params, le.Uint32(b))
+			}
+			f.tids = append(f.tids, cmd.tid)
+			if f.dataOut[cmd.op] {
+				f.pending = cmd
+			} else {
+				f.answer(cmd, nil)
+			}
+		case typeData:
+			cmd := f.pending
+			f.pending = nil
+			f.answer(cmd, append([]byte(nil), c[headerSize:]...))
+		}
+	}
+	return len(p), nil
+}
+
+func (f *fakeDevice) answer(cmd *fakeCommand, data []byte) {
+	r := f.handle(cmd.op, cmd.params, data)
+	if r.silent {
+		return
+	}
+	if r.code == 0 {
+		r.code = RespOK
+	}
+	var out []byte
+	if r.data != nil {
+		c := make([]byte, headerSize, headerSize+len(r.data))
+		putHeader(c, headerSize+len(r.data), typeData, uint16(cmd.op), cmd.tid)
+		c = append(c, r.data...)
+		if f.lengthUnknown {
+			binary.LittleEndian.PutUint32(c, lengthUnknown)
+		}
+		for len(c) > f.chunk {
+			f.queue = append(f.queue, c[:f.chunk])
+			c = c[f.chunk:]
+		}
+		if f.merge {
+			out = c
+		} else {
+			f.queue = append(f.queue, c)
+			if len(c)%f.maxPacket == 0 {
+				f.queue = append(f.queue, nil)
+			}
+		}
+	}
+	resp := make([]byte, headerSize+4*len(r.params))
+	putHeader(resp, len(resp), typeResponse, uint16(r.code), cmd.tid)
+	for i, p := range r.params {
+		binary.LittleEndian.PutUint32(resp[headerSize+4*i:], p)
+	}
+	f.queue = append(f.queue, append(out, resp...))
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (f *fakeDevice) ReadContext(ctx context.Context, p []byte) (int, error) {
+	for {
+		f.mu.Lock()
+		if len(f.queue) > 0 {
+			b := f.queue[0]
+			n := copy(p, b)
+			if n < len(b) {
+				f.queue[0] = b[n:]
+			} else {
+				f.queue = f.queue[1:]
+			}
+			f.mu.Unlock()
+			return n, nil
+		}
+		f.mu.Unlock()
+		select {
+		case <-f.ready:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func (f *fakeDevice) Control(rType, req uint8, val, idx uint16, data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rType == rTypeCancel && req == reqCancel {
+		f.cancels = append(f.cancels, append([]byte(nil), data...))
+	}
+	return len(data), nil
+}
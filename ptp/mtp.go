@@ -0,0 +1,296 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// MTP operations.
+const (
+	OpGetObjectPropsSupported OpCode = 0x9801
+	OpGetObjectPropDesc       OpCode = 0x9802
+	OpGetObjectPropValue      OpCode = 0x9803
+	OpSetObjectPropValue      OpCode = 0x9804
+	OpGetObjectPropList       OpCode = 0x9805
+)
+
+// ObjectPropCode identifies an MTP object property.
+type ObjectPropCode uint16
+
+// Common MTP object properties.
+const (
+	PropStorageID        ObjectPropCode = 0xdc01
+	PropObjectFormat     ObjectPropCode = 0xdc02
+	PropProtectionStatus ObjectPropCode = 0xdc03
+	PropObjectSize       ObjectPropCode = 0xdc04
+	PropObjectFileName   ObjectPropCode = 0xdc07
+	PropDateCreated      ObjectPropCode = 0xdc08
+	PropDateModified     ObjectPropCode = 0xdc09
+	PropParentObject     ObjectPropCode = 0xdc0b
+	PropPersistentUID    ObjectPropCode = 0xdc41
+	PropName             ObjectPropCode = 0xdc44
+)
+
+// PropAll selects all the properties in GetObjectPropList.
+const PropAll = 0xffffffff
+
+// DataType is the type of a property value.
+type DataType uint16
+
+// PTP data types. Array types are the element type with the TypeArray
+// bit set.
+const (
+	TypeInt8    DataType = 0x0001
+	TypeUint8   DataType = 0x0002
+	TypeInt16   DataType = 0x0003
+	TypeUint16  DataType = 0x0004
+	TypeInt32   DataType = 0x0005
+	TypeUint32  DataType = 0x0006
+	TypeInt64   DataType = 0x0007
+	TypeUint64  DataType = 0x0008
+	TypeInt128  DataType = 0x0009
+	TypeUint128 DataType = 0x000a
+	TypeArray   DataType = 0x4000
+	TypeString  DataType = 0xffff
+)
+
+// size returns the size of a scalar integer type, 0 for others.
+func (t DataType) size() int {
+	switch t {
+	case TypeInt8, TypeUint8:
+		return 1
+	case TypeInt16, TypeUint16:
+		return 2
+	case TypeInt32, TypeUint32:
+		return 4
+	case TypeInt64, TypeUint64:
+		return 8
+	case TypeInt128, TypeUint128:
+		return 16
+	}
+	return 0
+}
+
+func (t DataType) signed() bool {
+	return t&1 == 1
+}
+
+// decodeValue reads a value of type t. Integers are returned as int64 or
+// uint64, 128-bit integers as 16 little-endian bytes, arrays as []int64,
+// []uint64 or [][]byte and strings as string.
+func decodeValue(d *decoder, t DataType) (interface{}, error) {
+	if t == TypeString {
+		return d.string(), d.err
+	}
+	elem := t &^ TypeArray
+	size := elem.size()
+	if size == 0 {
+		return nil, fmt.Errorf("unsupported data type 0x%04x", uint16(t))
+	}
+	if t&TypeArray == 0 {
+		return decodeScalar(d, elem), d.err
+	}
+	n := d.count(size)
+	switch {
+	case size == 16:
+		v := make([][]byte, n)
+		for i := range v {
+			v[i] = decodeScalar(d, elem).([]byte)
+		}
+		return v, d.err
+	case elem.signed():
+		v := make([]int64, n)
+		for i := range v {
+			v[i] = decodeScalar(d, elem).(int64)
+		}
+		return v, d.err
+	default:
+		v := make([]uint64, n)
+		for i := range v {
+			v[i] = decodeScalar(d, elem).(uint64)
+		}
+		return v, d.err
+	}
+}
+
+func decodeScalar(d *decoder, t DataType) interface{} {
+	var u uint64
+	switch t.size() {
+	case 1:
+		u = uint64(d.u8())
+		if t.signed() {
+			return int64(int8(u))
+		}
+	case 2:
+		u = uint64(d.u16())
+		if t.signed() {
+			return int64(int16(u))
+		}
+	case 4:
+		u = uint64(d.u32())
+		if t.signed() {
+			return int64(int32(u))
+		}
+	case 8:
+		u = d.u64()
+		if t.signed() {
+			return int64(u)
+		}
+	case 16:
+		return append([]byte(nil), d.next(16)...)
+	}
+	return u
+}
+
+// encodeValue writes a scalar or string value of type t. Integer values
+// can be of any Go integer type.
+func encodeValue(e *encoder, t DataType, v interface{}) error {
+	if t == TypeString {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("got %T for a string value", v)
+		}
+		e.string(s)
+		return nil
+	}
+	var u uint64
+	switch v := v.(type) {
+	case int:
+		u = uint64(v)
+	case int8:
+		u = uint64(v)
+	case int16:
+		u = uint64(v)
+	case int32:
+		u = uint64(v)
+	case int64:
+		u = uint64(v)
+	case uint:
+		u = uint64(v)
+	case uint8:
+		u = uint64(v)
+	case uint16:
+		u = uint64(v)
+	case uint32:
+		u = uint64(v)
+	case uint64:
+		u = v
+	default:
+		return fmt.Errorf("unsupported value %T for data type 0x%04x", v, uint16(t))
+	}
+	switch t.size() {
+	case 1:
+		e.u8(uint8(u))
+	case 2:
+		e.u16(uint16(u))
+	case 4:
+		e.u32(uint32(u))
+	case 8:
+		e.u64(u)
+	default:
+		return fmt.Errorf("unsupported data type 0x%04x", uint16(t))
+	}
+	return nil
+}
+
+// GetObjectPropsSupported returns the properties supported by the device
+// for objects of the format.
+func (c *Conn) GetObjectPropsSupported(ctx context.Context, format ObjectFormat) ([]ObjectPropCode, error) {
+	b, err := c.dataIn(ctx, OpGetObjectPropsSupported, uint32(format))
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{b: b}
+	var props []ObjectPropCode
+	for _, p := range d.u16s() {
+		props = append(props, ObjectPropCode(p))
+	}
+	return props, d.err
+}
+
+// GetObjectPropValue returns the value of a property of an object. The
+// data type of the property is given by GetObjectPropDesc or the MTP
+// specification. The value has one of the types listed for ObjectProp.Value.
+func (c *Conn) GetObjectPropValue(ctx context.Context, handle uint32, prop ObjectPropCode, t DataType) (interface{}, error) {
+	b, err := c.dataIn(ctx, OpGetObjectPropValue, handle, uint32(prop))
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(&decoder{b: b}, t)
+}
+
+// SetObjectPropValue sets the value of a property of an object.
+func (c *Conn) SetObjectPropValue(ctx context.Context, handle uint32, prop ObjectPropCode, t DataType, v interface{}) error {
+	e := &encoder{}
+	if err := encodeValue(e, t, v); err != nil {
+		return err
+	}
+	_, err := c.Do(ctx, &Operation{
+		Code:        OpSetObjectPropValue,
+		Params:      []uint32{handle, uint32(prop)},
+		DataOut:     bytes.NewReader(e.b),
+		DataOutSize: int64(len(e.b)),
+	})
+	return err
+}
+
+// ObjectProp is an element of an object property list.
+type ObjectProp struct {
+	Handle uint32
+	Code   ObjectPropCode
+	Type   DataType
+	// Value is an int64, uint64, []byte, []int64, []uint64, [][]byte or
+	// string depending on Type.
+	Value interface{}
+}
+
+// GetObjectPropList returns the properties of an object, and of its
+// descendants up to depth levels below it. prop selects a property, or
+// PropAll for all properties. format filters the objects, 0 for all.
+// Handle 0xffffffff with depth 0 selects all the objects of the device.
+func (c *Conn) GetObjectPropList(ctx context.Context, handle uint32, format ObjectFormat, prop uint32, depth uint32) ([]ObjectProp, error) {
+	b, err := c.dataIn(ctx, OpGetObjectPropList, handle, uint32(format), prop, 0, depth)
+	if err != nil {
+		return nil, err
+	}
+	return parsePropList(b)
+}
+
+func parsePropList(b []byte) ([]ObjectProp, error) {
+	d := &decoder{b: b}
+	// Every element takes at least 8 bytes.
+	n := d.count(8)
+	props := make([]ObjectProp, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		p := ObjectProp{
+			Handle: d.u32(),
+			Code:   ObjectPropCode(d.u16()),
+			Type:   DataType(d.u16()),
+		}
+		v, err := decodeValue(d, p.Type)
+		if err != nil {
+			return nil, fmt.Errorf("property 0x%04x of object %d: %v", uint16(p.Code), p.Handle, err)
+		}
+		p.Value = v
+		props = append(props, p)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("ObjectPropList: %v", d.err)
+	}
+	return props, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	for _, tc := range []struct {
+		t     DataType
+		value interface{}
+		data  []byte
+		// decoded is the decoded value, if different from value.
+		decoded interface{}
+	}{
+		{t: TypeInt8, value: int64(-2), data: []byte{0xfe}},
+		{t: TypeUint8, value: uint64(0xfe), data: []byte{0xfe}},
+		{t: TypeInt16, value: int64(-300), data: []byte{0xd4, 0xfe}},
+		{t: TypeUint16, value: uint16(0x3801), data: []byte{0x01, 0x38}, decoded: uint64(0x3801)},
+		{t: TypeInt32, value: -1, data: []byte{0xff, 0xff, 0xff, 0xff}, decoded: int64(-1)},
+		{t: TypeUint32, value: uint32(0x10001), data: []byte{0x01, 0x00, 0x01, 0x00}, decoded: uint64(0x10001)},
+		{t: TypeUint64, value: uint64(1 << 40), data: []byte{0, 0, 0, 0, 0, 1, 0, 0}},
+		{t: TypeString, value: "IMG.JPG", data: []byte{8, 'I', 0, 'M', 0, 'G', 0, '.', 0, 'J', 0, 'P', 0, 'G', 0, 0, 0}},
+	} {
+		e := &encoder{}
+		if err := encodeValue(e, tc.t, tc.value); err != nil {
+			t.Errorf("encodeValue(0x%04x, %v): %v", uint16(tc.t), tc.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(e.b, tc.data) {
+			t.Errorf("encodeValue(0x%04x, %v): got % x, want % x", uint16(tc.t), tc.value, e.b, tc.data)
+		}
+		want := tc.decoded
+		if want == nil {
+			want = tc.value
+		}
+		got, err := decodeValue(&decoder{b: tc.data}, tc.t)
+		if err != nil {
+			t.Errorf("decodeValue(% x, 0x%04x): %v", tc.data, uint16(tc.t), err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("decodeValue(% x, 0x%04x): got %#v, want %#v", tc.data, uint16(tc.t), got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		t     DataType
+		value interface{}
+	}{
+		{TypeString, 1},
+		{TypeUint32, "1"},
+		{TypeUint128, 1},
+		{TypeArray | TypeUint8, 1},
+	} {
+		if err := encodeValue(&encoder{}, tc.t, tc.value); err == nil {
+			t.Errorf("encodeValue(0x%04x, %#v): got nil error, want an error", uint16(tc.t), tc.value)
+		}
+	}
+}
+
+func TestDecodeArrays(t *testing.T) {
+	u128 := make([]byte, 16)
+	u128[0] = 1
+	for _, tc := range []struct {
+		t    DataType
+		data []byte
+		want interface{}
+	}{
+		{TypeArray | TypeUint16, []byte{2, 0, 0, 0, 1, 0, 2, 0}, []uint64{1, 2}},
+		{TypeArray | TypeInt8, []byte{2, 0, 0, 0, 0xff, 1}, []int64{-1, 1}},
+		{TypeArray | TypeUint8, []byte{0, 0, 0, 0}, []uint64{}},
+		{TypeArray | TypeUint128, append([]byte{1, 0, 0, 0}, u128...), [][]byte{u128}},
+		{TypeUint128, u128, u128},
+	} {
+		got, err := decodeValue(&decoder{b: tc.data}, tc.t)
+		if err != nil {
+			t.Errorf("decodeValue(% x, 0x%04x): %v", tc.data, uint16(tc.t), err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("decodeValue(% x, 0x%04x): got %#v, want %#v", tc.data, uint16(tc.t), got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		t    DataType
+		data []byte
+	}{
+		{TypeArray | TypeUint32, []byte{2, 0, 0, 0, 1, 0, 0, 0}},
+		{TypeArray | TypeUint32, []byte{0xff, 0xff, 0xff, 0xff}},
+		{TypeUint64, []byte{1, 2, 3}},
+		{0x0100, []byte{1}},
+	} {
+		if _, err := decodeValue(&decoder{b: tc.data}, tc.t); err == nil {
+			t.Errorf("decodeValue(% x, 0x%04x): got nil error, want an error", tc.data, uint16(tc.t))
+		}
+	}
+}
+
+func propList() []byte {
+	e := &encoder{}
+	e.u32(3)
+	e.u32(1)
+	e.u16(uint16(PropObjectFileName))
+	e.u16(uint16(TypeString))
+	e.string("a.txt")
+	e.u32(1)
+	e.u16(uint16(PropObjectSize))
+	e.u16(uint16(TypeUint64))
+	e.u64(1234)
+	e.u32(2)
+	e.u16(uint16(PropParentObject))
+	e.u16(uint16(TypeUint32))
+	e.u32(1)
+	return e.b
+}
+
+func TestParsePropList(t *testing.T) {
+	got, err := parsePropList(propList())
+	if err != nil {
+		t.Fatalf("parsePropList(): %v", err)
+	}
+	want := []ObjectProp{
+		{Handle: 1, Code: PropObjectFileName, Type: TypeString, Value: "a.txt"},
+		{Handle: 1, Code: PropObjectSize, Type: TypeUint64, Value: uint64(1234)},
+		{Handle: 2, Code: PropParentObject, Type: TypeUint32, Value: uint64(1)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePropList(): got %+v, want %+v", got, want)
+	}
+
+	b := propList()
+	for _, n := range []int{2, 10, len(b) - 1} {
+		if _, err := parsePropList(b[:n]); err == nil {
+			t.Errorf("parsePropList(%d of %d bytes): got nil error, want an error", n, len(b))
+		}
+	}
+}
+
+func TestPropOperations(t *testing.T) {
+	var setData []byte
+	var listParams []uint32
+	dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+		switch op {
+		case OpGetObjectPropList:
+			listParams = params
+			return fakeResult{data: propList()}
+		case OpGetObjectPropValue:
+			return fakeResult{data: []byte{0xd2, 0x04, 0, 0, 0, 0, 0, 0}}
+		case OpSetObjectPropValue:
+			setData = data
+		case OpGetObjectPropsSupported:
+			return fakeResult{data: []byte{2, 0, 0, 0, 0x07, 0xdc, 0x04, 0xdc}}
+		}
+		return fakeResult{}
+	})
+	c := newFakeConn(dev, nil)
+	ctx := context.Background()
+
+	props, err := c.GetObjectPropList(ctx, 1, 0, PropAll, 1)
+	if err != nil {
+		t.Fatalf("GetObjectPropList(): %v", err)
+	}
+	if len(props) != 3 {
+		t.Errorf("GetObjectPropList(): got %d properties, want 3", len(props))
+	}
+	if want := []uint32{1, 0, PropAll, 0, 1}; !reflect.DeepEqual(listParams, want) {
+		t.Errorf("GetObjectPropList() params: got %x, want %x", listParams, want)
+	}
+
+	v, err := c.GetObjectPropValue(ctx, 1, PropObjectSize, TypeUint64)
+	if err != nil {
+		t.Fatalf("GetObjectPropValue(): %v", err)
+	}
+	if v != uint64(1234) {
+		t.Errorf("GetObjectPropValue(): got %#v, want uint64(1234)", v)
+	}
+
+	if err := c.SetObjectPropValue(ctx, 1, PropName, TypeString, "b"); err != nil {
+		t.Fatalf("SetObjectPropValue(): %v", err)
+	}
+	if want := []byte{2, 'b', 0, 0, 0}; !reflect.DeepEqual(setData, want) {
+		t.Errorf("SetObjectPropValue() data: got % x, want % x", setData, want)
+	}
+
+	supported, err := c.GetObjectPropsSupported(ctx, FormatText)
+	if err != nil {
+		t.Fatalf("GetObjectPropsSupported(): %v", err)
+	}
+	if want := []ObjectPropCode{PropObjectFileName, PropObjectSize}; !reflect.DeepEqual(supported, want) {
+		t.Errorf("GetObjectPropsSupported(): got %v, want %v", supported, want)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Special values of the GetObjectHandles parameters.
+const (
+	// StorageAll selects the objects of all storages.
+	StorageAll = 0xffffffff
+	// ParentAny selects objects regardless of their parent.
+	ParentAny = 0x00000000
+	// ParentRoot selects the objects in the root of a storage.
+	ParentRoot = 0xffffffff
+)
+
+// sessionID is the ID of the sessions opened by OpenSession.
+const sessionID = 1
+
+// dataIn executes an operation with a data phase sent by the device and
+// returns the data.
+func (c *Conn) dataIn(ctx context.Context, code OpCode, params ...uint32) ([]byte, error) {
+	var b bytes.Buffer
+	if _, err := c.Do(ctx, &Operation{Code: code, Params: params, DataIn: &b}); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// GetDeviceInfo returns the description of the device. It doesn't need an
+// open session.
+func (c *Conn) GetDeviceInfo(ctx context.Context) (*DeviceInfo, error) {
+	b, err := c.dataIn(ctx, OpGetDeviceInfo)
+	if err != nil {
+		return nil, err
+	}
+	return parseDeviceInfo(b)
+}
+
+// OpenSession opens a session. A session already open on the device, e.g.
+// left by a previous process, is reused.
+func (c *Conn) OpenSession(ctx context.Context) error {
+	c.mu.Lock()
+	c.session = 0
+	c.mu.Unlock()
+	_, err := c.Do(ctx, &Operation{Code: OpOpenSession, Params: []uint32{sessionID}})
+	var perr *Error
+	if err != nil && !(errors.As(err, &perr) && perr.Code == RespSessionAlreadyOpen) {
+		return err
+	}
+	c.mu.Lock()
+	c.session, c.tid = sessionID, 1
+	c.mu.Unlock()
+	return nil
+}
+
+// CloseSession closes the session.
+func (c *Conn) CloseSession(ctx context.Context) error {
+	_, err := c.Do(ctx, &Operation{Code: OpCloseSession})
+	c.mu.Lock()
+	c.session = 0
+	c.mu.Unlock()
+	return err
+}
+
+func parseUint32s(b []byte) ([]uint32, error) {
+	d := &decoder{b: b}
+	v := d.u32s()
+	return v, d.err
+}
+
+// GetStorageIDs returns the IDs of the storages of the device.
+func (c *Conn) GetStorageIDs(ctx context.Context) ([]uint32, error) {
+	b, err := c.dataIn(ctx, OpGetStorageIDs)
+	if err != nil {
+		return nil, err
+	}
+	return parseUint32s(b)
+}
+
+// GetStorageInfo returns the description of a storage.
+func (c *Conn) GetStorageInfo(ctx context.Context, storage uint32) (*StorageInfo, error) {
+	b, err := c.dataIn(ctx, OpGetStorageInfo, storage)
+	if err != nil {
+		return nil, err
+	}
+	return parseStorageInfo(b)
+}
+
+// GetObjectHandles returns the handles of the objects of a storage, or of
+// all storages with StorageAll. The objects can be filtered by format,
+// 0 for all formats, and by parent, ParentAny for all objects or
+// ParentRoot for the objects in the root.
+func (c *Conn) GetObjectHandles(ctx context.Context, storage uint32, format ObjectFormat, parent uint32) ([]uint32, error) {
+	b, err := c.dataIn(ctx, OpGetObjectHandles, storage, uint32(format), parent)
+	if err != nil {
+		return nil, err
+	}
+	return parseUint32s(b)
+}
+
+// GetObjectInfo returns the description of an object.
+func (c *Conn) GetObjectInfo(ctx context.Context, handle uint32) (*ObjectInfo, error) {
+	b, err := c.dataIn(ctx, OpGetObjectInfo, handle)
+	if err != nil {
+		return nil, err
+	}
+	return parseObjectInfo(b)
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// GetObject writes the data of an object to w and returns its size.
+func (c *Conn) GetObject(ctx context.Context, handle uint32, w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	_, err := c.Do(ctx, &Operation{Code: OpGetObject, Params: []uint32{handle}, DataIn: cw})
+	return cw.n, err
+}
+
+// GetPartialObject writes up to size bytes of an object, starting at
+// offset, to w and returns the number of bytes written.
+func (c *Conn) GetPartialObject(ctx context.Context, handle, offset, size uint32, w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	_, err := c.Do(ctx, &Operation{Code: OpGetPartialObject, Params: []uint32{handle, offset, size}, DataIn: cw})
+	return cw.n, err
+}
+
+// GetThumb writes the thumbnail of an object to w and returns its size.
+func (c *Conn) GetThumb(ctx context.Context, handle uint32, w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	_, err := c.Do(ctx, &Operation{Code: OpGetThumb, Params: []uint32{handle}, DataIn: cw})
+	return cw.n, err
+}
+
+// DeleteObject deletes an object. The format filters the objects deleted
+// if handle is 0xffffffff, for all objects, and is 0 otherwise.
+func (c *Conn) DeleteObject(ctx context.Context, handle uint32, format ObjectFormat) error {
+	_, err := c.Do(ctx, &Operation{Code: OpDeleteObject, Params: []uint32{handle, uint32(format)}})
+	return err
+}
+
+// SendObjectInfo announces an object to be sent with SendObject, in the
+// storage and parent association given, 0 letting the device choose.
+// It returns the storage, parent and handle of the new object.
+func (c *Conn) SendObjectInfo(ctx context.Context, storage, parent uint32, info *ObjectInfo) (newStorage, newParent, handle uint32, err error) {
+	b := info.marshal()
+	params, err := c.Do(ctx, &Operation{
+		Code:        OpSendObjectInfo,
+		Params:      []uint32{storage, parent},
+		DataOut:     bytes.NewReader(b),
+		DataOutSize: int64(len(b)),
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(params) < 3 {
+		return 0, 0, 0, fmt.Errorf("%s: got %d response parameters, want 3", OpSendObjectInfo, len(params))
+	}
+	return params[0], params[1], params[2], nil
+}
+
+// SendObject sends the data of the object announced by the last
+// SendObjectInfo. size bytes are read from r.
+func (c *Conn) SendObject(ctx context.Context, r io.Reader, size int64) error {
+	_, err := c.Do(ctx, &Operation{Code: OpSendObject, DataOut: r, DataOutSize: size})
+	return err
+}
+
+// EventCode identifies an event sent by the device.
+type EventCode uint16
+
+// Standard PTP events.
+const (
+	EventCancelTransaction     EventCode = 0x4001
+	EventObjectAdded           EventCode = 0x4002
+	EventObjectRemoved         EventCode = 0x4003
+	EventStoreAdded            EventCode = 0x4004
+	EventStoreRemoved          EventCode = 0x4005
+	EventDevicePropChanged     EventCode = 0x4006
+	EventObjectInfoChanged     EventCode = 0x4007
+	EventDeviceInfoChanged     EventCode = 0x4008
+	EventRequestObjectTransfer EventCode = 0x4009
+	EventStoreFull             EventCode = 0x400a
+	EventDeviceReset           EventCode = 0x400b
+	EventStorageInfoChanged    EventCode = 0x400c
+	EventCaptureComplete       EventCode = 0x400d
+	EventUnreportedStatus      EventCode = 0x400e
+)
+
+// Event is an event sent by the device on the interrupt endpoint.
+type Event struct {
+	Code          EventCode
+	TransactionID uint32
+	// Params are the parameters of the event, up to 3.
+	Params []uint32
+}
+
+// ReadEvent waits for the next event sent by the device. ReadEvent can be
+// called concurrently with the other methods.
+func (c *Conn) ReadEvent(ctx context.Context) (*Event, error) {
+	c.evMu.Lock()
+	defer c.evMu.Unlock()
+	if c.ev == nil {
+		return nil, fmt.Errorf("device has no event endpoint")
+	}
+	buf := make([]byte, eventBufferSize)
+	le := binary.LittleEndian
+	for {
+		if len(c.evBuf) >= headerSize {
+			l := int(le.Uint32(c.evBuf))
+			if l < headerSize || l > eventBufferSize {
+				c.evBuf = nil
+				return nil, fmt.Errorf("invalid event length %d", l)
+			}
+			if len(c.evBuf) >= l {
+				b := c.evBuf[:l]
+				c.evBuf = c.evBuf[l:]
+				if typ := le.Uint16(b[4:]); typ != typeEvent {
+					return nil, fmt.Errorf("unexpected container type %d on the event endpoint", typ)
+				}
+				e := &Event{Code: EventCode(le.Uint16(b[6:])), TransactionID: le.Uint32(b[8:])}
+				for p := b[headerSize:]; len(p) >= 4; p = p[4:] {
+					e.Params = append(e.Params, le.Uint32(p))
+				}
+				return e, nil
+			}
+		}
+		n, err := c.ev.ReadContext(ctx, buf)
+		if err != nil {
+			return nil, err
+		}
+		c.evBuf = append(c.evBuf, buf[:n]...)
+	}
+}
@@ -0,0 +1,576 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ptp implements a Picture Transfer Protocol (PIMA 15740) client
+// for USB still image devices, and the Media Transfer Protocol extensions
+// used by Android phones.
+//
+// Open claims the still image interface of a device. Most operations need
+// an open session:
+//
+//	c, err := ptp.Open(dev)
+//	...
+//	defer c.Close()
+//	if err := c.OpenSession(ctx); err != nil {
+//		...
+//	}
+//	ids, err := c.GetStorageIDs(ctx)
+//	...
+//	handles, err := c.GetObjectHandles(ctx, ids[0], 0, ptp.ParentAny)
+//	...
+//	info, err := c.GetObjectInfo(ctx, handles[0])
+//	...
+//	_, err = c.GetObject(ctx, handles[0], f)
+//
+// Operations not covered by the Conn methods are executed with Do.
+package ptp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Class codes of the still image interface.
+const (
+	subClassStillImage gousb.Class    = 0x01
+	protocolPTP        gousb.Protocol = 0x01
+)
+
+// Class-specific requests of the still image class.
+const (
+	reqCancel    = 0x64
+	rTypeCancel  = gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface
+	cancelCode   = 0x4001
+	cancelLength = 6
+)
+
+// Container types.
+const (
+	typeCommand  = 1
+	typeData     = 2
+	typeResponse = 3
+	typeEvent    = 4
+)
+
+const (
+	headerSize = 12
+	// maxParams is the maximum number of parameters of a command or
+	// response.
+	maxParams = 5
+	// lengthUnknown is the container length of data phases longer
+	// than 4GiB, terminated by a short packet.
+	lengthUnknown = 0xffffffff
+	// bufferSize is the size of the bulk transfers.
+	bufferSize = 64 * 1024
+	// eventBufferSize is the size of the interrupt transfers.
+	eventBufferSize = 64
+)
+
+// OpCode identifies a PTP operation.
+type OpCode uint16
+
+// Standard PTP operations.
+const (
+	OpGetDeviceInfo    OpCode = 0x1001
+	OpOpenSession      OpCode = 0x1002
+	OpCloseSession     OpCode = 0x1003
+	OpGetStorageIDs    OpCode = 0x1004
+	OpGetStorageInfo   OpCode = 0x1005
+	OpGetNumObjects    OpCode = 0x1006
+	OpGetObjectHandles OpCode = 0x1007
+	OpGetObjectInfo    OpCode = 0x1008
+	OpGetObject        OpCode = 0x1009
+	OpGetThumb         OpCode = 0x100a
+	OpDeleteObject     OpCode = 0x100b
+	OpSendObjectInfo   OpCode = 0x100c
+	OpSendObject       OpCode = 0x100d
+	OpInitiateCapture  OpCode = 0x100e
+	OpGetPartialObject OpCode = 0x101b
+)
+
+// String returns the name of the operation.
+func (o OpCode) String() string {
+	if s, ok := opNames[o]; ok {
+		return s
+	}
+	return fmt.Sprintf("operation 0x%04x", uint16(o))
+}
+
+var opNames = map[OpCode]string{
+	OpGetDeviceInfo:           "GetDeviceInfo",
+	OpOpenSession:             "OpenSession",
+	OpCloseSession:            "CloseSession",
+	OpGetStorageIDs:           "GetStorageIDs",
+	OpGetStorageInfo:          "GetStorageInfo",
+	OpGetNumObjects:           "GetNumObjects",
+	OpGetObjectHandles:        "GetObjectHandles",
+	OpGetObjectInfo:           "GetObjectInfo",
+	OpGetObject:               "GetObject",
+	OpGetThumb:                "GetThumb",
+	OpDeleteObject:            "DeleteObject",
+	OpSendObjectInfo:          "SendObjectInfo",
+	OpSendObject:              "SendObject",
+	OpInitiateCapture:         "InitiateCapture",
+	OpGetPartialObject:        "GetPartialObject",
+	OpGetObjectPropsSupported: "GetObjectPropsSupported",
+	OpGetObjectPropDesc:       "GetObjectPropDesc",
+	OpGetObjectPropValue:      "GetObjectPropValue",
+	OpSetObjectPropValue:      "SetObjectPropValue",
+	OpGetObjectPropList:       "GetObjectPropList",
+}
+
+// ResponseCode is the result of an operation.
+type ResponseCode uint16
+
+// Standard PTP response codes.
+const (
+	RespOK                     ResponseCode = 0x2001
+	RespGeneralError           ResponseCode = 0x2002
+	RespSessionNotOpen         ResponseCode = 0x2003
+	RespInvalidTransactionID   ResponseCode = 0x2004
+	RespOperationNotSupported  ResponseCode = 0x2005
+	RespParameterNotSupported  ResponseCode = 0x2006
+	RespIncompleteTransfer     ResponseCode = 0x2007
+	RespInvalidStorageID       ResponseCode = 0x2008
+	RespInvalidObjectHandle    ResponseCode = 0x2009
+	RespInvalidObjectFormat    ResponseCode = 0x200b
+	RespStoreFull              ResponseCode = 0x200c
+	RespObjectWriteProtected   ResponseCode = 0x200d
+	RespStoreReadOnly          ResponseCode = 0x200e
+	RespAccessDenied           ResponseCode = 0x200f
+	RespNoThumbnailPresent     ResponseCode = 0x2010
+	RespDeviceBusy             ResponseCode = 0x2019
+	RespInvalidParentObject    ResponseCode = 0x201a
+	RespInvalidParameter       ResponseCode = 0x201d
+	RespSessionAlreadyOpen     ResponseCode = 0x201e
+	RespTransactionCancelled   ResponseCode = 0x201f
+	RespInvalidObjectPropCode  ResponseCode = 0xa801
+	RespObjectPropNotSupported ResponseCode = 0xa80a
+)
+
+// String returns the name of the response code.
+func (r ResponseCode) String() string {
+	if s, ok := respNames[r]; ok {
+		return s
+	}
+	return fmt.Sprintf("response 0x%04x", uint16(r))
+}
+
+var respNames = map[ResponseCode]string{
+	RespOK:                     "OK",
+	RespGeneralError:           "general error",
+	RespSessionNotOpen:         "session not open",
+	RespInvalidTransactionID:   "invalid transaction ID",
+	RespOperationNotSupported:  "operation not supported",
+	RespParameterNotSupported:  "parameter not supported",
+	RespIncompleteTransfer:     "incomplete transfer",
+	RespInvalidStorageID:       "invalid storage ID",
+	RespInvalidObjectHandle:    "invalid object handle",
+	RespInvalidObjectFormat:    "invalid object format code",
+	RespStoreFull:              "store full",
+	RespObjectWriteProtected:   "object write-protected",
+	RespStoreReadOnly:          "store read-only",
+	RespAccessDenied:           "access denied",
+	RespNoThumbnailPresent:     "no thumbnail present",
+	RespDeviceBusy:             "device busy",
+	RespInvalidParentObject:    "invalid parent object",
+	RespInvalidParameter:       "invalid parameter",
+	RespSessionAlreadyOpen:     "session already open",
+	RespTransactionCancelled:   "transaction cancelled",
+	RespInvalidObjectPropCode:  "invalid object property code",
+	RespObjectPropNotSupported: "object property not supported",
+}
+
+// Error is a response other than RespOK.
+type Error struct {
+	Op   OpCode
+	Code ResponseCode
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ptp: %s: %s", e.Op, e.Code)
+}
+
+// controller carries the class requests of the still image interface,
+// e.g. the cancellation of a transaction. *gousb.Device implements
+// controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the data and response containers of the transactions,
+// and the event containers of the interrupt endpoint.
+// *gousb.InEndpoint implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the command and data containers of the transactions.
+// *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Conn is a connection to a PTP or MTP device.
+type Conn struct {
+	ctl       controller
+	intf      uint16
+	r         reader
+	w         writer
+	maxPacket int
+
+	// mu serializes the transactions.
+	mu      sync.Mutex
+	session uint32
+	tid     uint32
+	buf     []byte
+	// carry holds data read past the end of a container.
+	carry []byte
+
+	evMu sync.Mutex
+	ev   reader
+	// evBuf holds the part of an event read from the interrupt endpoint.
+	evBuf []byte
+
+	release func()
+}
+
+func newConn(ctl controller, intf int, r reader, w writer, ev reader, maxPacket int) *Conn {
+	return &Conn{
+		ctl:       ctl,
+		intf:      uint16(intf),
+		r:         r,
+		w:         w,
+		ev:        ev,
+		maxPacket: maxPacket,
+		buf:       make([]byte, bufferSize),
+	}
+}
+
+func isPTP(s gousb.InterfaceSetting) bool {
+	return s.Class == gousb.ClassImage && s.SubClass == subClassStillImage && s.Protocol == protocolPTP
+}
+
+// Match returns true if the device has a still image interface in any of
+// its configurations. It can be used with gousb.Context.OpenDevices.
+// MTP devices that only expose a vendor-specific MTP interface are not
+// matched.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if _, _, _, _, err := findInterface(cfg); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findInterface returns the still image interface setting of the
+// configuration with its bulk endpoints and its interrupt endpoint, if any.
+func findInterface(desc gousb.ConfigDesc) (*gousb.InterfaceSetting, *gousb.EndpointDesc, *gousb.EndpointDesc, *gousb.EndpointDesc, error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if !isPTP(alt) {
+				continue
+			}
+			var in, out, ev *gousb.EndpointDesc
+			for _, ep := range alt.Endpoints {
+				ep := ep
+				switch {
+				case ep.TransferType == gousb.TransferTypeBulk && ep.Direction == gousb.EndpointDirectionIn:
+					in = &ep
+				case ep.TransferType == gousb.TransferTypeBulk:
+					out = &ep
+				case ep.TransferType == gousb.TransferTypeInterrupt && ep.Direction == gousb.EndpointDirectionIn:
+					ev = &ep
+				}
+			}
+			if in == nil || out == nil {
+				return nil, nil, nil, nil, fmt.Errorf("still image interface %d alt %d has no bulk IN and OUT endpoints", alt.Number, alt.Alternate)
+			}
+			alt := alt
+			return &alt, in, out, ev, nil
+		}
+	}
+	return nil, nil, nil, nil, fmt.Errorf("%s has no still image interface", desc)
+}
+
+// Open claims the still image interface in the active configuration of the
+// device. The Conn should be Close()d after use.
+func Open(dev *gousb.Device) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, inDesc, outDesc, evDesc, err := findInterface(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(intf, inDesc, outDesc)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	var ev reader
+	if evDesc != nil {
+		if ev, err = intf.InEndpoint(evDesc.Number); err != nil {
+			cfg.Close()
+			return nil, err
+		}
+	}
+	c := newConn(dev, alt.Number, in, out, ev, outDesc.MaxPacketSize)
+	c.release = func() { cfg.Close() }
+	return c, nil
+}
+
+// Close releases the interface. An open session should be closed with
+// CloseSession first.
+func (c *Conn) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
+
+// Operation describes a transaction executed by Do.
+type Operation struct {
+	Code OpCode
+	// Params are the parameters of the command, up to 5.
+	Params []uint32
+	// DataOut is sent to the device in the data phase of the transaction.
+	// DataOutSize bytes are read from it.
+	DataOut     io.Reader
+	DataOutSize int64
+	// DataIn receives the data sent by the device in the data phase.
+	// The data is discarded if DataIn is nil.
+	DataIn io.Writer
+}
+
+// Do executes a transaction and returns the parameters of the response.
+// A response other than RespOK is returned as an *Error.
+//
+// If the context is done during the transaction, the transaction is
+// cancelled with the Cancel class request.
+func (c *Conn) Do(ctx context.Context, op *Operation) ([]uint32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(op.Params) > maxParams {
+		return nil, fmt.Errorf("%s: %d parameters, at most %d allowed", op.Code, len(op.Params), maxParams)
+	}
+	// Operations outside of a session use the transaction ID 0.
+	var tid uint32
+	if c.session != 0 {
+		tid = c.tid
+		if c.tid++; c.tid == 0 || c.tid == lengthUnknown {
+			c.tid = 1
+		}
+	}
+	params, err := c.transaction(ctx, tid, op)
+	if err != nil && ctx.Err() != nil {
+		c.cancel(tid)
+		c.carry = nil
+	}
+	return params, err
+}
+
+func (c *Conn) transaction(ctx context.Context, tid uint32, op *Operation) ([]uint32, error) {
+	// Data left from a previous transaction is stale, and c.buf is
+	// reused by the data phase.
+	c.carry = nil
+	cmd := make([]byte, headerSize+4*len(op.Params))
+	putHeader(cmd, len(cmd), typeCommand, uint16(op.Code), tid)
+	for i, p := range op.Params {
+		binary.LittleEndian.PutUint32(cmd[headerSize+4*i:], p)
+	}
+	if _, err := c.w.WriteContext(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("%s: command phase: %v", op.Code, err)
+	}
+	if op.DataOut != nil {
+		if err := c.sendData(ctx, tid, op); err != nil {
+			return nil, fmt.Errorf("%s: data phase: %v", op.Code, err)
+		}
+	}
+	for {
+		typ, code, rtid, payload, err := c.readContainer(ctx, op.DataIn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op.Code, err)
+		}
+		if rtid != tid {
+			return nil, fmt.Errorf("%s: got transaction ID %d, want %d", op.Code, rtid, tid)
+		}
+		if typ == typeData {
+			// The data phase was written to DataIn, the response follows.
+			continue
+		}
+		if typ != typeResponse {
+			return nil, fmt.Errorf("%s: unexpected container type %d", op.Code, typ)
+		}
+		if ResponseCode(code) != RespOK {
+			return nil, &Error{Op: op.Code, Code: ResponseCode(code)}
+		}
+		var params []uint32
+		for len(payload) >= 4 && len(params) < maxParams {
+			params = append(params, binary.LittleEndian.Uint32(payload))
+			payload = payload[4:]
+		}
+		return params, nil
+	}
+}
+
+func putHeader(b []byte, length int, typ, code uint16, tid uint32) {
+	le := binary.LittleEndian
+	le.PutUint32(b, uint32(length))
+	le.PutUint16(b[4:], typ)
+	le.PutUint16(b[6:], code)
+	le.PutUint32(b[8:], tid)
+}
+
+// sendData sends the data phase of the transaction. The data is sent in
+// transfers of a multiple of the max packet size, and a zero length packet
+// ends a data phase that fills its last packet.
+func (c *Conn) sendData(ctx context.Context, tid uint32, op *Operation) error {
+	total := headerSize + op.DataOutSize
+	length := int(total)
+	if total > lengthUnknown-1 {
+		length = lengthUnknown
+	}
+	putHeader(c.buf, length, typeData, uint16(op.Code), tid)
+	b := c.buf[headerSize:]
+	left := op.DataOutSize
+	for first := true; first || left > 0; first = false {
+		n := len(b)
+		if int64(n) > left {
+			n = int(left)
+		}
+		if _, err := io.ReadFull(op.DataOut, b[:n]); err != nil {
+			return fmt.Errorf("failed to read the data: %v", err)
+		}
+		left -= int64(n)
+		if first {
+			n += headerSize
+		}
+		if _, err := c.w.WriteContext(ctx, c.buf[:n]); err != nil {
+			return err
+		}
+		b = c.buf
+	}
+	if c.maxPacket > 0 && total%int64(c.maxPacket) == 0 {
+		if _, err := c.w.WriteContext(ctx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read returns the data left from the previous read, or reads a transfer.
+// Zero length packets are skipped.
+func (c *Conn) read(ctx context.Context) ([]byte, error) {
+	if len(c.carry) > 0 {
+		b := c.carry
+		c.carry = nil
+		return b, nil
+	}
+	for {
+		n, err := c.r.ReadContext(ctx, c.buf)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			return c.buf[:n], nil
+		}
+	}
+}
+
+// readContainer reads a data or response container. The payload of a
+// data container is written to w, or discarded if w is nil. The payload
+// of a response container is returned.
+func (c *Conn) readContainer(ctx context.Context, w io.Writer) (typ, code uint16, tid uint32, payload []byte, err error) {
+	b, err := c.read(ctx)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	if len(b) < headerSize {
+		return 0, 0, 0, nil, fmt.Errorf("container too short: %d bytes", len(b))
+	}
+	le := binary.LittleEndian
+	length := le.Uint32(b)
+	typ, code, tid = le.Uint16(b[4:]), le.Uint16(b[6:]), le.Uint32(b[8:])
+	if typ != typeData {
+		if length < headerSize || int64(length) > int64(len(b)) {
+			return 0, 0, 0, nil, fmt.Errorf("invalid container length %d with %d bytes", length, len(b))
+		}
+		c.carry = b[length:]
+		return typ, code, tid, b[headerSize:length], nil
+	}
+	if w == nil {
+		w = ioutil.Discard
+	}
+	if length == lengthUnknown {
+		// Read until the end of the transfer that isn't full.
+		if _, err := w.Write(b[headerSize:]); err != nil {
+			return 0, 0, 0, nil, err
+		}
+		for len(b) == len(c.buf) {
+			n, err := c.r.ReadContext(ctx, c.buf)
+			if err != nil {
+				return 0, 0, 0, nil, err
+			}
+			b = c.buf[:n]
+			if _, err := w.Write(b); err != nil {
+				return 0, 0, 0, nil, err
+			}
+		}
+		return typ, code, tid, nil, nil
+	}
+	if length < headerSize {
+		return 0, 0, 0, nil, fmt.Errorf("invalid container length %d", length)
+	}
+	left := int64(length) - headerSize
+	b = b[headerSize:]
+	for {
+		n := int64(len(b))
+		if n > left {
+			n = left
+			c.carry = b[n:]
+		}
+		if _, err := w.Write(b[:n]); err != nil {
+			return 0, 0, 0, nil, err
+		}
+		if left -= n; left == 0 {
+			return typ, code, tid, nil, nil
+		}
+		if b, err = c.read(ctx); err != nil {
+			return 0, 0, 0, nil, err
+		}
+	}
+}
+
+// cancel asks the device to cancel the transaction.
+func (c *Conn) cancel(tid uint32) {
+	b := make([]byte, cancelLength)
+	binary.LittleEndian.PutUint16(b, cancelCode)
+	binary.LittleEndian.PutUint32(b[2:], tid)
+	c.ctl.Control(rTypeCancel, reqCancel, 0, c.intf, b)
+}
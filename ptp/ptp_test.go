@@ -0,0 +1,378 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ptp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func newFakeConn(dev *fakeDevice, ev reader) *Conn {
+	return newConn(dev, 0, dev, dev, ev, dev.maxPacket)
+}
+
+func uint32s(v ...uint32) []byte {
+	e := &encoder{}
+	e.u32(uint32(len(v)))
+	for _, x := range v {
+		e.u32(x)
+	}
+	return e.b
+}
+
+func TestTransactionIDs(t *testing.T) {
+	dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+		switch op {
+		case OpGetDeviceInfo:
+			return fakeResult{data: deviceInfoDataset()}
+		case OpGetStorageIDs:
+			return fakeResult{data: uint32s(0x10001, 0x20001)}
+		}
+		return fakeResult{}
+	})
+	c := newFakeConn(dev, nil)
+	ctx := context.Background()
+	if _, err := c.GetDeviceInfo(ctx); err != nil {
+		t.Fatalf("GetDeviceInfo(): %v", err)
+	}
+	if err := c.OpenSession(ctx); err != nil {
+		t.Fatalf("OpenSession(): %v", err)
+	}
+	ids, err := c.GetStorageIDs(ctx)
+	if err != nil {
+		t.Fatalf("GetStorageIDs(): %v", err)
+	}
+	if want := []uint32{0x10001, 0x20001}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("GetStorageIDs(): got %x, want %x", ids, want)
+	}
+	if err := c.CloseSession(ctx); err != nil {
+		t.Fatalf("CloseSession(): %v", err)
+	}
+	if _, err := c.GetDeviceInfo(ctx); err != nil {
+		t.Fatalf("GetDeviceInfo(): %v", err)
+	}
+	if want := []uint32{0, 0, 1, 2, 0}; !reflect.DeepEqual(dev.tids, want) {
+		t.Errorf("transaction IDs: got %v, want %v", dev.tids, want)
+	}
+}
+
+func TestOpenSessionAlreadyOpen(t *testing.T) {
+	dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+		if op == OpOpenSession {
+			return fakeResult{code: RespSessionAlreadyOpen}
+		}
+		return fakeResult{data: uint32s()}
+	})
+	c := newFakeConn(dev, nil)
+	ctx := context.Background()
+	if err := c.OpenSession(ctx); err != nil {
+		t.Fatalf("OpenSession(): %v", err)
+	}
+	if _, err := c.GetStorageIDs(ctx); err != nil {
+		t.Fatalf("GetStorageIDs(): %v", err)
+	}
+	if want := []uint32{0, 1}; !reflect.DeepEqual(dev.tids, want) {
+		t.Errorf("transaction IDs: got %v, want %v", dev.tids, want)
+	}
+}
+
+func TestGetObject(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		size  int
+		chunk int
+		merge bool
+	}{
+		{desc: "short", size: 100, chunk: 4096},
+		{desc: "many transfers", size: 200000, chunk: 4096},
+		{desc: "packet boundary", size: 4*512 - headerSize, chunk: 4096},
+		{desc: "transfer boundary", size: 4096 - headerSize, chunk: 4096},
+		{desc: "merged response", size: 1000, chunk: 4096, merge: true},
+		{desc: "merged response after many transfers", size: 70000, chunk: 16384, merge: true},
+		{desc: "empty", size: 0, chunk: 4096},
+	} {
+		obj := make([]byte, tc.size)
+		for i := range obj {
+			obj[i] = byte(i * 7)
+		}
+		var gotParams []uint32
+		dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+			switch op {
+			case OpGetObject:
+				gotParams = params
+				return fakeResult{data: obj}
+			case OpGetStorageIDs:
+				return fakeResult{data: uint32s(1)}
+			}
+			return fakeResult{}
+		})
+		dev.chunk = tc.chunk
+		dev.merge = tc.merge
+		c := newFakeConn(dev, nil)
+		ctx := context.Background()
+		var buf bytes.Buffer
+		n, err := c.GetObject(ctx, 42, &buf)
+		if err != nil {
+			t.Errorf("%s: GetObject(): %v", tc.desc, err)
+			continue
+		}
+		if n != int64(tc.size) || !bytes.Equal(buf.Bytes(), obj) {
+			t.Errorf("%s: GetObject(): got %d bytes, want %d", tc.desc, n, tc.size)
+		}
+		if want := []uint32{42}; !reflect.DeepEqual(gotParams, want) {
+			t.Errorf("%s: GetObject() params: got %v, want %v", tc.desc, gotParams, want)
+		}
+		// The next transaction must not see the end of the data phase.
+		ids, err := c.GetStorageIDs(ctx)
+		if err != nil {
+			t.Errorf("%s: GetStorageIDs() after GetObject(): %v", tc.desc, err)
+		} else if want := []uint32{1}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("%s: GetStorageIDs() after GetObject(): got %v, want %v", tc.desc, ids, want)
+		}
+	}
+}
+
+func TestGetObjectLengthUnknown(t *testing.T) {
+	obj := bytes.Repeat([]byte{0xa5}, 10000)
+	dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+		return fakeResult{data: obj}
+	})
+	dev.lengthUnknown = true
+	dev.chunk = bufferSize
+	c := newFakeConn(dev, nil)
+	var buf bytes.Buffer
+	n, err := c.GetObject(context.Background(), 1, &buf)
+	if err != nil {
+		t.Fatalf("GetObject(): %v", err)
+	}
+	if n != int64(len(obj)) || !bytes.Equal(buf.Bytes(), obj) {
+		t.Errorf("GetObject(): got %d bytes, want %d", n, len(obj))
+	}
+}
+
+func TestSendObject(t *testing.T) {
+	for _, size := range []int{0, 100, 512 - headerSize, 3*512 - headerSize, bufferSize - headerSize, 3 * bufferSize} {
+		obj := make([]byte, size)
+		for i := range obj {
+			obj[i] = byte(i)
+		}
+		var gotInfo *ObjectInfo
+		var gotObj []byte
+		dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+			switch op {
+			case OpSendObjectInfo:
+				info, err := parseObjectInfo(data)
+				if err != nil {
+					t.Errorf("size %d: SendObjectInfo data: %v", size, err)
+				}
+				gotInfo = info
+				return fakeResult{params: []uint32{0x10001, params[1], 7}}
+			case OpSendObject:
+				gotObj = data
+			}
+			return fakeResult{}
+		})
+		c := newFakeConn(dev, nil)
+		ctx := context.Background()
+		info := &ObjectInfo{Format: FormatText, CompressedSize: uint32(size), Filename: "a.txt"}
+		storage, parent, handle, err := c.SendObjectInfo(ctx, 0, ParentRoot, info)
+		if err != nil {
+			t.Fatalf("size %d: SendObjectInfo(): %v", size, err)
+		}
+		if storage != 0x10001 || parent != ParentRoot || handle != 7 {
+			t.Errorf("size %d: SendObjectInfo(): got %x, %x, %d, want 10001, %x, 7", size, storage, parent, handle, uint32(ParentRoot))
+		}
+		if !reflect.DeepEqual(gotInfo, info) {
+			t.Errorf("size %d: SendObjectInfo() sent %+v, want %+v", size, gotInfo, info)
+		}
+		dev.writes = nil
+		if err := c.SendObject(ctx, bytes.NewReader(obj), int64(size)); err != nil {
+			t.Fatalf("size %d: SendObject(): %v", size, err)
+		}
+		if !bytes.Equal(gotObj, obj) {
+			t.Errorf("size %d: SendObject() sent %d bytes, want %d", size, len(gotObj), size)
+		}
+		// The command, then the data phase in transfers of whole packets,
+		// terminated by a short packet.
+		writes := dev.writes[1:]
+		for i, n := range writes[:len(writes)-1] {
+			if n == 0 || n%dev.maxPacket != 0 {
+				t.Errorf("size %d: SendObject() write %d: got %d bytes, want a non-zero multiple of %d", size, i, n, dev.maxPacket)
+			}
+		}
+		if last := writes[len(writes)-1]; last%dev.maxPacket == 0 && last != 0 {
+			t.Errorf("size %d: SendObject() last write: got %d bytes, want a short packet", size, last)
+		}
+	}
+}
+
+func TestSendObjectShortReader(t *testing.T) {
+	dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+		return fakeResult{}
+	})
+	c := newFakeConn(dev, nil)
+	if err := c.SendObject(context.Background(), bytes.NewReader(make([]byte, 10)), 20); err == nil {
+		t.Error("SendObject() with a short reader: got nil error, want an error")
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+		return fakeResult{code: RespInvalidObjectHandle}
+	})
+	c := newFakeConn(dev, nil)
+	_, err := c.GetObjectInfo(context.Background(), 5)
+	var perr *Error
+	if !errors.As(err, &perr) {
+		t.Fatalf("GetObjectInfo(): got error %v, want an *Error", err)
+	}
+	if perr.Op != OpGetObjectInfo || perr.Code != RespInvalidObjectHandle {
+		t.Errorf("GetObjectInfo(): got %s %s, want %s %s", perr.Op, perr.Code, OpGetObjectInfo, RespInvalidObjectHandle)
+	}
+	if got, want := perr.Error(), "ptp: GetObjectInfo: invalid object handle"; got != want {
+		t.Errorf("Error(): got %q, want %q", got, want)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	dev := newFakeDevice(func(op OpCode, params []uint32, data []byte) fakeResult {
+		if op == OpGetObject {
+			return fakeResult{silent: true}
+		}
+		return fakeResult{}
+	})
+	c := newFakeConn(dev, nil)
+	if err := c.OpenSession(context.Background()); err != nil {
+		t.Fatalf("OpenSession(): %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetObject(ctx, 1, &bytes.Buffer{}); err == nil {
+		t.Fatal("GetObject() on a silent device: got nil error, want a context error")
+	}
+	want := [][]byte{{0x01, 0x40, 0x01, 0x00, 0x00, 0x00}}
+	if !reflect.DeepEqual(dev.cancels, want) {
+		t.Errorf("cancel requests: got % x, want % x", dev.cancels, want)
+	}
+}
+
+func TestReadEvent(t *testing.T) {
+	ev := fakeusb.NewIn(
+		// ObjectAdded, split over two packets.
+		[]byte{0x10, 0x00, 0x00, 0x00, 0x04, 0x00, 0x02, 0x40},
+		[]byte{0x05, 0x00, 0x00, 0x00, 0x2a, 0x00, 0x00, 0x00},
+		// StoreFull and DeviceReset in one transfer.
+		[]byte{
+			0x10, 0x00, 0x00, 0x00, 0x04, 0x00, 0x0a, 0x40, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+			0x0c, 0x00, 0x00, 0x00, 0x04, 0x00, 0x0b, 0x40, 0x00, 0x00, 0x00, 0x00,
+		},
+	)
+	c := newFakeConn(newFakeDevice(nil), ev)
+	ctx := context.Background()
+	for _, want := range []*Event{
+		{Code: EventObjectAdded, TransactionID: 5, Params: []uint32{42}},
+		{Code: EventStoreFull, Params: []uint32{0x10001}},
+		{Code: EventDeviceReset},
+	} {
+		got, err := c.ReadEvent(ctx)
+		if err != nil {
+			t.Fatalf("ReadEvent(): %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadEvent(): got %+v, want %+v", got, want)
+		}
+	}
+
+	c = newFakeConn(newFakeDevice(nil), nil)
+	if _, err := c.ReadEvent(ctx); err == nil {
+		t.Error("ReadEvent() without an event endpoint: got nil error, want an error")
+	}
+}
+
+func TestFindInterface(t *testing.T) {
+	bulkIn := gousb.EndpointDesc{Number: 1, Direction: gousb.EndpointDirectionIn, TransferType: gousb.TransferTypeBulk}
+	bulkOut := gousb.EndpointDesc{Number: 2, Direction: gousb.EndpointDirectionOut, TransferType: gousb.TransferTypeBulk}
+	intr := gousb.EndpointDesc{Number: 3, Direction: gousb.EndpointDirectionIn, TransferType: gousb.TransferTypeInterrupt}
+	image := func(num int, eps ...gousb.EndpointDesc) gousb.InterfaceDesc {
+		m := map[gousb.EndpointAddress]gousb.EndpointDesc{}
+		for _, ep := range eps {
+			addr := gousb.EndpointAddress(ep.Number)
+			if ep.Direction == gousb.EndpointDirectionIn {
+				addr |= 0x80
+			}
+			m[addr] = ep
+		}
+		return gousb.InterfaceDesc{Number: num, AltSettings: []gousb.InterfaceSetting{{
+			Number: num, Class: gousb.ClassImage, SubClass: subClassStillImage, Protocol: protocolPTP, Endpoints: m,
+		}}}
+	}
+	for _, tc := range []struct {
+		desc   string
+		intfs  []gousb.InterfaceDesc
+		num    int
+		events bool
+		ok     bool
+	}{
+		{
+			desc:   "camera",
+			intfs:  []gousb.InterfaceDesc{image(0, bulkIn, bulkOut, intr)},
+			events: true,
+			ok:     true,
+		},
+		{
+			desc: "second interface without events",
+			intfs: []gousb.InterfaceDesc{
+				{Number: 0, AltSettings: []gousb.InterfaceSetting{{Number: 0, Class: gousb.ClassMassStorage}}},
+				image(1, bulkIn, bulkOut),
+			},
+			num: 1,
+			ok:  true,
+		},
+		{
+			desc:  "no bulk out",
+			intfs: []gousb.InterfaceDesc{image(0, bulkIn, intr)},
+		},
+		{
+			desc:  "no image interface",
+			intfs: []gousb.InterfaceDesc{{Number: 0, AltSettings: []gousb.InterfaceSetting{{Number: 0, Class: gousb.ClassVendorSpec}}}},
+		},
+	} {
+		cfg := gousb.ConfigDesc{Number: 1, Interfaces: tc.intfs}
+		alt, in, out, ev, err := findInterface(cfg)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: findInterface(): got error %v, want ok %v", tc.desc, err, tc.ok)
+			continue
+		}
+		if got := Match(&gousb.DeviceDesc{Configs: map[int]gousb.ConfigDesc{1: cfg}}); got != tc.ok {
+			t.Errorf("%s: Match(): got %v, want %v", tc.desc, got, tc.ok)
+		}
+		if err != nil {
+			continue
+		}
+		if alt.Number != tc.num || in.Number != 1 || out.Number != 2 {
+			t.Errorf("%s: findInterface(): got interface %d, endpoints %d/%d, want %d, 1/2", tc.desc, alt.Number, in.Number, out.Number, tc.num)
+		}
+		if (ev != nil) != tc.events {
+			t.Errorf("%s: findInterface(): got event endpoint %v, want %v", tc.desc, ev, tc.events)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rndis
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// RNDIS message types.
+const (
+	msgPacket          = 0x00000001
+	msgInit            = 0x00000002
+	msgHalt            = 0x00000003
+	msgQuery           = 0x00000004
+	msgSet             = 0x00000005
+	msgReset           = 0x00000006
+	msgIndicateStatus  = 0x00000007
+	msgKeepAlive       = 0x00000008
+	msgInitCmplt       = 0x80000002
+	msgQueryCmplt      = 0x80000004
+	msgSetCmplt        = 0x80000005
+	msgResetCmplt      = 0x80000006
+	msgKeepAliveCmplt  = 0x80000008
+	msgHeaderSize      = 8
+	packetHeaderSize   = 44
+	initCmpltSize      = 52
+	queryHeaderSize    = 28
+	queryCmpltSize     = 24
+	keepAliveSize      = 12
+	statusHeaderSize   = 20
+	resetCmpltSize     = 16
+	requestCmpltSize   = 16
+	majorVersion       = 1
+	minorVersion       = 0
+	medium802_3        = 0
+	bufferOffsetOrigin = 8
+)
+
+// Status is the status code of an RNDIS completion or status indication.
+// Status values other than StatusSuccess returned by the device are
+// wrapped in the errors returned by Conn methods and can be checked with
+// errors.Is.
+type Status uint32
+
+// Status codes used by RNDIS devices.
+const (
+	StatusSuccess         Status = 0x00000000
+	StatusFailure         Status = 0xc0000001
+	StatusInvalidData     Status = 0xc0010015
+	StatusNotSupported    Status = 0xc00000bb
+	StatusMediaConnect    Status = 0x4001000b
+	StatusMediaDisconnect Status = 0x4001000c
+)
+
+// String returns a human-readable name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusSuccess:
+		return "success"
+	case StatusFailure:
+		return "failure"
+	case StatusInvalidData:
+		return "invalid data"
+	case StatusNotSupported:
+		return "not supported"
+	case StatusMediaConnect:
+		return "media connect"
+	case StatusMediaDisconnect:
+		return "media disconnect"
+	}
+	return fmt.Sprintf("status 0x%08x", uint32(s))
+}
+
+// Error implements the error interface.
+func (s Status) Error() string {
+	return "rndis: " + s.String()
+}
+
+// OID identifies an object queried or set on the device.
+type OID uint32
+
+// Common NDIS object identifiers.
+const (
+	OIDSupportedList       OID = 0x00010101
+	OIDMaximumFrameSize    OID = 0x00010106
+	OIDLinkSpeed           OID = 0x00010107
+	OIDCurrentPacketFilter OID = 0x0001010e
+	OIDMaximumTotalSize    OID = 0x00010111
+	OIDMediaConnectStatus  OID = 0x00010114
+	OIDPermanentAddress    OID = 0x01010101
+	OIDCurrentAddress      OID = 0x01010102
+	OIDMulticastList       OID = 0x01010103
+	OIDMaximumListSize     OID = 0x01010104
+)
+
+var le = binary.LittleEndian
+
+// newMessage returns a message of the given type with fields following
+// the header and the buffer appended after them.
+func newMessage(typ uint32, fields []uint32, buf []byte) []byte {
+	b := make([]byte, msgHeaderSize+4*len(fields), msgHeaderSize+4*len(fields)+len(buf))
+	le.PutUint32(b, typ)
+	for i, f := range fields {
+		le.PutUint32(b[msgHeaderSize+4*i:], f)
+	}
+	b = append(b, buf...)
+	le.PutUint32(b[4:], uint32(len(b)))
+	return b
+}
+
+// marshalInit returns an INITIALIZE message. maxTransferSize is the size
+// of the largest transfer accepted by the host.
+func marshalInit(id uint32, maxTransferSize int) []byte {
+	return newMessage(msgInit, []uint32{id, majorVersion, minorVersion, uint32(maxTransferSize)}, nil)
+}
+
+// marshalHalt returns a HALT message.
+func marshalHalt(id uint32) []byte {
+	return newMessage(msgHalt, []uint32{id}, nil)
+}
+
+// marshalOID returns a QUERY or SET message for the object, with the
+// information buffer.
+func marshalOID(typ, id uint32, oid OID, buf []byte) []byte {
+	off := uint32(0)
+	if len(buf) > 0 {
+		off = queryHeaderSize - bufferOffsetOrigin
+	}
+	return newMessage(typ, []uint32{id, uint32(oid), uint32(len(buf)), off, 0}, buf)
+}
+
+// marshalReset returns a RESET message.
+func marshalReset() []byte {
+	return newMessage(msgReset, []uint32{0}, nil)
+}
+
+// marshalKeepAlive returns a KEEPALIVE message.
+func marshalKeepAlive(id uint32) []byte {
+	return newMessage(msgKeepAlive, []uint32{id}, nil)
+}
+
+// marshalKeepAliveCmplt returns the completion of a KEEPALIVE message
+// sent by the device.
+func marshalKeepAliveCmplt(id uint32, s Status) []byte {
+	return newMessage(msgKeepAliveCmplt, []uint32{id, uint32(s)}, nil)
+}
+
+// deviceInfo holds the fields of INITIALIZE_CMPLT.
+type deviceInfo struct {
+	major, minor          int
+	flags                 uint32
+	medium                uint32
+	maxPacketsPerTransfer int
+	maxTransferSize       int
+	alignment             int
+}
+
+// response is a message received on the control channel.
+type response struct {
+	typ       uint32
+	requestID uint32
+	status    Status
+	// buf is the information buffer of QUERY_CMPLT or the status buffer
+	// of INDICATE_STATUS.
+	buf []byte
+	// info is set for INITIALIZE_CMPLT.
+	info *deviceInfo
+	// addressingReset is set by RESET_CMPLT if the packet filter and
+	// multicast list must be restored.
+	addressingReset bool
+}
+
+// responseSize is the minimum size of the messages sent by the device on
+// the control channel.
+var responseSize = map[uint32]int{
+	msgInitCmplt:      initCmpltSize,
+	msgQueryCmplt:     queryCmpltSize,
+	msgSetCmplt:       requestCmpltSize,
+	msgResetCmplt:     resetCmpltSize,
+	msgKeepAliveCmplt: requestCmpltSize,
+	msgKeepAlive:      keepAliveSize,
+	msgIndicateStatus: statusHeaderSize,
+}
+
+// parseResponse parses a message received with GET_ENCAPSULATED_RESPONSE.
+func parseResponse(b []byte) (*response, error) {
+	if len(b) < msgHeaderSize {
+		return nil, fmt.Errorf("message too short: %d bytes", len(b))
+	}
+	typ, l := le.Uint32(b), int(le.Uint32(b[4:]))
+	if l < msgHeaderSize || l > len(b) {
+		return nil, fmt.Errorf("invalid length %d of message 0x%08x with %d bytes", l, typ, len(b))
+	}
+	b = b[:l]
+	n, ok := responseSize[typ]
+	if !ok {
+		return nil, fmt.Errorf("unexpected message 0x%08x", typ)
+	}
+	if l < n {
+		return nil, fmt.Errorf("message 0x%08x too short: %d bytes, want at least %d", typ, l, n)
+	}
+	r := &response{typ: typ}
+	switch typ {
+	case msgResetCmplt:
+		r.status = Status(le.Uint32(b[8:]))
+		r.addressingReset = le.Uint32(b[12:]) != 0
+		return r, nil
+	case msgKeepAlive:
+		r.requestID = le.Uint32(b[8:])
+		return r, nil
+	case msgIndicateStatus:
+		r.status = Status(le.Uint32(b[8:]))
+		var err error
+		r.buf, err = buffer(b, le.Uint32(b[12:]), le.Uint32(b[16:]))
+		return r, err
+	}
+	r.requestID = le.Uint32(b[8:])
+	r.status = Status(le.Uint32(b[12:]))
+	switch typ {
+	case msgInitCmplt:
+		r.info = &deviceInfo{
+			major:                 int(le.Uint32(b[16:])),
+			minor:                 int(le.Uint32(b[20:])),
+			flags:                 le.Uint32(b[24:]),
+			medium:                le.Uint32(b[28:]),
+			maxPacketsPerTransfer: int(le.Uint32(b[32:])),
+			maxTransferSize:       int(le.Uint32(b[36:])),
+			alignment:             1 << (le.Uint32(b[40:]) & 0x1f),
+		}
+	case msgQueryCmplt:
+		var err error
+		r.buf, err = buffer(b, le.Uint32(b[16:]), le.Uint32(b[20:]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// buffer returns the buffer of length l at offset off from the request ID
+// of the message.
+func buffer(b []byte, l, off uint32) ([]byte, error) {
+	if l == 0 {
+		return nil, nil
+	}
+	start := uint64(off) + bufferOffsetOrigin
+	if start+uint64(l) > uint64(len(b)) {
+		return nil, fmt.Errorf("buffer at offset %d with length %d outside of the %d bytes message", off, l, len(b))
+	}
+	end := start + uint64(l)
+	return b[start:end:end], nil
+}
+
+// appendPacket appends a PACKET_MSG holding the frame to b. The message
+// is padded with pad zero bytes.
+func appendPacket(b, frame []byte, pad int) []byte {
+	l := packetHeaderSize + len(frame) + pad
+	var h [packetHeaderSize]byte
+	le.PutUint32(h[0:], msgPacket)
+	le.PutUint32(h[4:], uint32(l))
+	le.PutUint32(h[8:], packetHeaderSize-bufferOffsetOrigin)
+	le.PutUint32(h[12:], uint32(len(frame)))
+	b = append(b, h[:]...)
+	b = append(b, frame...)
+	for i := 0; i < pad; i++ {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// parsePackets returns the frames of the PACKET_MSGs in a transfer
+// received from the device. The frames are slices of b.
+func parsePackets(b []byte) ([][]byte, error) {
+	var frames [][]byte
+	// Ignore the padding that may follow the last message.
+	for len(b) >= msgHeaderSize {
+		typ, l := le.Uint32(b), int(le.Uint32(b[4:]))
+		if typ != msgPacket {
+			if typ == 0 && l == 0 {
+				break
+			}
+			return frames, fmt.Errorf("unexpected message 0x%08x on the data channel", typ)
+		}
+		if l < packetHeaderSize || l > len(b) {
+			return frames, fmt.Errorf("invalid packet length %d with %d bytes left", l, len(b))
+		}
+		f, err := buffer(b[:l], le.Uint32(b[12:]), le.Uint32(b[8:]))
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, f)
+		b = b[l:]
+	}
+	return frames, nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rndis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		got  []byte
+		want []byte
+	}{
+		{
+			desc: "INITIALIZE",
+			got:  marshalInit(1, 0x4000),
+			want: []byte{
+				0x02, 0x00, 0x00, 0x00, 0x18, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x00, 0x00,
+			},
+		},
+		{
+			desc: "HALT",
+			got:  marshalHalt(7),
+			want: []byte{0x03, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x00, 0x00},
+		},
+		{
+			desc: "QUERY",
+			got:  marshalOID(msgQuery, 2, OIDPermanentAddress, nil),
+			want: []byte{
+				0x04, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x01, 0x01, 0x01, 0x01,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			desc: "SET",
+			got:  marshalOID(msgSet, 3, OIDCurrentPacketFilter, []byte{0x0d, 0x00, 0x00, 0x00}),
+			want: []byte{
+				0x05, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00,
+				0x03, 0x00, 0x00, 0x00, 0x0e, 0x01, 0x01, 0x00,
+				0x04, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			desc: "RESET",
+			got:  marshalReset(),
+			want: []byte{0x06, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			desc: "KEEPALIVE",
+			got:  marshalKeepAlive(4),
+			want: []byte{0x08, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00},
+		},
+		{
+			desc: "KEEPALIVE_CMPLT",
+			got:  marshalKeepAliveCmplt(9, StatusSuccess),
+			want: []byte{
+				0x08, 0x00, 0x00, 0x80, 0x10, 0x00, 0x00, 0x00,
+				0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			desc: "PACKET_MSG",
+			got:  appendPacket([]byte{0xff}, []byte{0xaa, 0xbb}, 2),
+			want: []byte{
+				0xff,
+				0x01, 0x00, 0x00, 0x00, 0x30, 0x00, 0x00, 0x00,
+				0x24, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0xaa, 0xbb, 0x00, 0x00,
+			},
+		},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.want) {
+			t.Errorf("%s:\ngot  % x\nwant % x", tc.desc, tc.got, tc.want)
+		}
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		b       []byte
+		want    *response
+		wantErr bool
+	}{
+		{
+			desc: "INITIALIZE_CMPLT",
+			b: []byte{
+				0x02, 0x00, 0x00, 0x80, 0x34, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x0a, 0x00, 0x00, 0x00, 0x58, 0x06, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			want: &response{
+				typ:       msgInitCmplt,
+				requestID: 1,
+				info: &deviceInfo{
+					major:                 1,
+					flags:                 1,
+					maxPacketsPerTransfer: 10,
+					maxTransferSize:       1624,
+					alignment:             4,
+				},
+			},
+		},
+		{
+			desc: "QUERY_CMPLT",
+			b: []byte{
+				0x04, 0x00, 0x00, 0x80, 0x1e, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x5e, 0x10, 0x00, 0x0a,
+			},
+			want: &response{typ: msgQueryCmplt, requestID: 2, buf: []byte{0x02, 0x00, 0x5e, 0x10, 0x00, 0x0a}},
+		},
+		{
+			desc: "QUERY_CMPLT not supported",
+			b: []byte{
+				0x04, 0x00, 0x00, 0x80, 0x18, 0x00, 0x00, 0x00,
+				0x03, 0x00, 0x00, 0x00, 0xbb, 0x00, 0x00, 0xc0,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			want: &response{typ: msgQueryCmplt, requestID: 3, status: StatusNotSupported},
+		},
+		{
+			desc: "SET_CMPLT",
+			b: []byte{
+				0x05, 0x00, 0x00, 0x80, 0x10, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			want: &response{typ: msgSetCmplt, requestID: 4},
+		},
+		{
+			desc: "RESET_CMPLT",
+			b: []byte{
+				0x06, 0x00, 0x00, 0x80, 0x10, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00,
+			},
+			want: &response{typ: msgResetCmplt, addressingReset: true},
+		},
+		{
+			desc: "INDICATE_STATUS",
+			b: []byte{
+				0x07, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00,
+				0x0c, 0x00, 0x01, 0x40, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			want: &response{typ: msgIndicateStatus, status: StatusMediaDisconnect},
+		},
+		{
+			desc: "KEEPALIVE",
+			b:    []byte{0x08, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00},
+			want: &response{typ: msgKeepAlive, requestID: 5},
+		},
+		{
+			desc:    "short header",
+			b:       []byte{0x05, 0x00, 0x00, 0x80},
+			wantErr: true,
+		},
+		{
+			desc:    "length beyond data",
+			b:       []byte{0x05, 0x00, 0x00, 0x80, 0x20, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			wantErr: true,
+		},
+		{
+			desc:    "truncated SET_CMPLT",
+			b:       []byte{0x05, 0x00, 0x00, 0x80, 0x0c, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00},
+			wantErr: true,
+		},
+		{
+			desc: "QUERY_CMPLT buffer out of bounds",
+			b: []byte{
+				0x04, 0x00, 0x00, 0x80, 0x18, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00,
+			},
+			wantErr: true,
+		},
+		{
+			desc:    "unknown message",
+			b:       []byte{0x09, 0x00, 0x00, 0x80, 0x08, 0x00, 0x00, 0x00},
+			wantErr: true,
+		},
+	} {
+		got, err := parseResponse(tc.b)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: parseResponse(): got error %v, want error: %v", tc.desc, err, tc.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: parseResponse():\ngot  %+v\nwant %+v", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestParsePackets(t *testing.T) {
+	two := appendPacket(appendPacket(nil, []byte("first"), 3), []byte("second"), 0)
+	for _, tc := range []struct {
+		desc    string
+		b       []byte
+		want    [][]byte
+		wantErr bool
+	}{
+		{"two packets", two, [][]byte{[]byte("first"), []byte("second")}, false},
+		{"trailing padding", append(append([]byte(nil), two...), 0), [][]byte{[]byte("first"), []byte("second")}, false},
+		{"trailing zero header", append(append([]byte(nil), two...), make([]byte, 8)...), [][]byte{[]byte("first"), []byte("second")}, false},
+		{"truncated second packet", two[:len(two)-1], [][]byte{[]byte("first")}, true},
+		{"control message", marshalKeepAlive(1), nil, true},
+	} {
+		got, err := parsePackets(tc.b)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: parsePackets(): got error %v, want error: %v", tc.desc, err, tc.wantErr)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: parsePackets(): got %q, want %q", tc.desc, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,723 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rndis implements the host side of the Remote NDIS protocol,
+// used for USB tethering by Android phones and by Linux USB gadgets.
+//
+// Open claims the RNDIS control and data interfaces, initializes the
+// device and returns a Conn, which sends and receives Ethernet frames:
+//
+//	c, err := rndis.Open(ctx, dev, rndis.Config{})
+//	...
+//	defer c.Close()
+//	fmt.Println("device MAC address:", c.MAC)
+//	buf := make([]byte, c.MaxFrameSize+14)
+//	for {
+//		n, err := c.ReadFrame(ctx, buf)
+//		...
+//		// handle the frame in buf[:n]
+//	}
+//
+// Control messages are sent with SEND_ENCAPSULATED_COMMAND. Their
+// completions are read with GET_ENCAPSULATED_RESPONSE when the device
+// signals them on the notification endpoint. Ethernet frames are wrapped
+// in RNDIS packet messages on the bulk endpoints.
+package rndis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Class codes of RNDIS control interfaces.
+const (
+	subClassRF          gousb.Class    = 0x01
+	protocolRNDIS       gousb.Protocol = 0x03
+	subClassACM         gousb.Class    = 0x02
+	protocolVendor      gousb.Protocol = 0xff
+	subClassMiscCDC     gousb.Class    = 0x04
+	protocolMiscRNDIS   gousb.Protocol = 0x01
+	subClassUnion                      = 0x06
+	dtCSInterface                      = 0x24
+	reqSendEncapsulated                = 0x00
+	reqGetEncapsulated                 = 0x01
+
+	rTypeOut = gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface
+	rTypeIn  = gousb.ControlIn | gousb.ControlClass | gousb.ControlInterface
+)
+
+const (
+	// maxTransferSize is the size of the largest transfer the host
+	// accepts from the device.
+	maxTransferSize = 16384
+	// responseBufferSize is the size of the buffer used to read control
+	// responses.
+	responseBufferSize = 4096
+	// readTransfers is the number of bulk IN transfers kept in flight.
+	readTransfers = 4
+	// defaultFrameSize is the maximum payload of a frame used if the
+	// device doesn't report it.
+	defaultFrameSize = 1500
+	// ethernetHeaderSize is the size of the Ethernet header, not included
+	// in OIDMaximumFrameSize.
+	ethernetHeaderSize = 14
+)
+
+// ErrClosed is returned by requests pending or issued after Close. Requests
+// pending or issued after a failure of the notification endpoint return
+// the error of the endpoint instead.
+var ErrClosed = errors.New("rndis: connection closed")
+
+// PacketFilter selects the frames forwarded by the device to the host.
+type PacketFilter uint32
+
+// NDIS packet filter bits, set with OIDCurrentPacketFilter.
+const (
+	FilterDirected     PacketFilter = 0x00000001
+	FilterMulticast    PacketFilter = 0x00000002
+	FilterAllMulticast PacketFilter = 0x00000004
+	FilterBroadcast    PacketFilter = 0x00000008
+	FilterPromiscuous  PacketFilter = 0x00000020
+)
+
+// DefaultPacketFilter is the packet filter used if Config.PacketFilter is
+// not set.
+const DefaultPacketFilter = FilterDirected | FilterBroadcast | FilterAllMulticast
+
+// Config holds the options of Open.
+type Config struct {
+	// PacketFilter is the packet filter set after initialization.
+	// DefaultPacketFilter is used if it's 0.
+	PacketFilter PacketFilter
+}
+
+// controller carries the encapsulated RNDIS messages, sent with
+// SEND_ENCAPSULATED_COMMAND and fetched with GET_ENCAPSULATED_RESPONSE.
+// *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the RESPONSE_AVAILABLE notifications, and the data
+// transfers packing the received frames. *gousb.ReadStream and
+// *gousb.InEndpoint implement reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the data transfers packing the frames to send.
+// *gousb.OutEndpoint implements writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Conn is an initialized RNDIS device.
+type Conn struct {
+	// MAC is the permanent Ethernet address of the device.
+	MAC net.HardwareAddr
+	// MaxFrameSize is the maximum size of a frame payload, without the
+	// Ethernet header.
+	MaxFrameSize int
+	// Version is the RNDIS protocol version of the device.
+	Version gousb.BCD
+	// MaxPacketsPerTransfer and MaxTransferSize limit the number of
+	// frames and the size of transfers sent to the device.
+	MaxPacketsPerTransfer, MaxTransferSize int
+
+	ctl  controller
+	intf uint16
+
+	mu        sync.Mutex
+	nextID    uint32
+	pending   map[uint32]chan *response
+	connected bool
+	filter    PacketFilter
+	// err is the error that stopped the poller, ErrClosed after Close.
+	err error
+
+	rmu  sync.Mutex
+	r    reader
+	rbuf []byte
+	// frames holds the frames of the last transfer not returned yet.
+	frames [][]byte
+
+	wmu       sync.Mutex
+	w         writer
+	wbuf      []byte
+	alignment int
+	maxPacket int
+
+	cancel  context.CancelFunc
+	done    chan struct{}
+	release func()
+}
+
+func newConn(ctl controller, intf int) *Conn {
+	return &Conn{
+		ctl:       ctl,
+		intf:      uint16(intf),
+		nextID:    1,
+		pending:   map[uint32]chan *response{},
+		connected: true,
+		alignment: 1,
+		done:      make(chan struct{}),
+	}
+}
+
+func isRNDIS(s gousb.InterfaceSetting) bool {
+	switch {
+	case s.Class == gousb.ClassWireless && s.SubClass == subClassRF && s.Protocol == protocolRNDIS:
+	case s.Class == gousb.ClassComm && s.SubClass == subClassACM && s.Protocol == protocolVendor:
+	case s.Class == gousb.ClassMiscellaneous && s.SubClass == subClassMiscCDC && s.Protocol == protocolMiscRNDIS:
+	default:
+		return false
+	}
+	return true
+}
+
+// Match returns true if the device has an RNDIS control interface in any
+// of its configurations. It can be used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	for _, cfg := range desc.Configs {
+		if _, _, _, _, _, err := findInterfaces(cfg); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dataInterface returns the data interface of the union descriptor in
+// the extra descriptors of the control interface, or -1.
+func dataInterface(extra []byte) int {
+	for len(extra) >= 3 {
+		l := int(extra[0])
+		if l < 3 || l > len(extra) {
+			break
+		}
+		if extra[1] == dtCSInterface && extra[2] == subClassUnion && l >= 5 {
+			return int(extra[4])
+		}
+		extra = extra[l:]
+	}
+	return -1
+}
+
+// findInterfaces returns the RNDIS control interface setting with its
+// notification endpoint, and the data interface setting with its bulk
+// endpoints. The data interface is named by the union descriptor, or
+// follows the control interface.
+func findInterfaces(desc gousb.ConfigDesc) (ctl *gousb.InterfaceSetting, notify *gousb.EndpointDesc, data *gousb.InterfaceSetting, in, out *gousb.EndpointDesc, err error) {
+	for _, intf := range desc.Interfaces {
+		for _, alt := range intf.AltSettings {
+			if !isRNDIS(alt) {
+				continue
+			}
+			for _, ep := range alt.Endpoints {
+				if ep.TransferType == gousb.TransferTypeInterrupt && ep.Direction == gousb.EndpointDirectionIn {
+					ep := ep
+					notify = &ep
+				}
+			}
+			if notify == nil {
+				return nil, nil, nil, nil, nil, fmt.Errorf("RNDIS interface %d has no notification endpoint", alt.Number)
+			}
+			alt := alt
+			ctl = &alt
+			break
+		}
+		if ctl != nil {
+			break
+		}
+	}
+	if ctl == nil {
+		return nil, nil, nil, nil, nil, fmt.Errorf("%s has no RNDIS interface", desc)
+	}
+	num := dataInterface(ctl.Extra)
+	if num < 0 {
+		num = ctl.Number + 1
+	}
+	for _, intf := range desc.Interfaces {
+		if intf.Number != num {
+			continue
+		}
+		for _, alt := range intf.AltSettings {
+			in, out = nil, nil
+			for _, ep := range alt.Endpoints {
+				if ep.TransferType != gousb.TransferTypeBulk {
+					continue
+				}
+				ep := ep
+				if ep.Direction == gousb.EndpointDirectionIn {
+					in = &ep
+				} else {
+					out = &ep
+				}
+			}
+			if in != nil && out != nil {
+				alt := alt
+				return ctl, notify, &alt, in, out, nil
+			}
+		}
+	}
+	return nil, nil, nil, nil, nil, fmt.Errorf("%s has no RNDIS data interface %d with bulk IN and OUT endpoints", desc, num)
+}
+
+// Open claims the RNDIS interfaces in the active configuration of the
+// device, initializes it, reads its MAC address and sets the packet
+// filter. The context bounds the initialization. The Conn should be
+// Close()d after use.
+func Open(ctx context.Context, dev *gousb.Device, config Config) (*Conn, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	ctlAlt, notifyDesc, dataAlt, inDesc, outDesc, err := findInterfaces(desc)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, ctlIntf, err := claim.OpenInterface(dev, cfgNum, ctlAlt)
+	if err != nil {
+		return nil, err
+	}
+	c := newConn(dev, ctlAlt.Number)
+	c.maxPacket = outDesc.MaxPacketSize
+	var rs *gousb.ReadStream
+	release := func() {
+		if c.cancel != nil {
+			c.cancel()
+			<-c.done
+		}
+		if rs != nil {
+			rs.Close()
+		}
+		cfg.Close()
+	}
+	notify, _, err := claim.Endpoints(ctlIntf, notifyDesc, nil)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	c.poll(notify, notifyDesc.MaxPacketSize)
+
+	if err := c.init(ctx, config); err != nil {
+		release()
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+
+	dataIntf, err := cfg.Claim(dataAlt)
+	if err != nil {
+		c.halt()
+		release()
+		return nil, err
+	}
+	in, out, err := claim.Endpoints(dataIntf, inDesc, outDesc)
+	if err != nil {
+		c.halt()
+		release()
+		return nil, err
+	}
+	c.rbuf = make([]byte, maxTransferSize)
+	rs, err = in.NewStream(maxTransferSize, readTransfers)
+	if err != nil {
+		c.halt()
+		release()
+		return nil, err
+	}
+	c.r, c.w = rs, out
+	c.release = release
+	return c, nil
+}
+
+// init initializes the device and reads its parameters.
+func (c *Conn) init(ctx context.Context, config Config) error {
+	r, err := c.request(ctx, msgInitCmplt, func(id uint32) []byte { return marshalInit(id, maxTransferSize) })
+	if err != nil {
+		return fmt.Errorf("INITIALIZE: %w", err)
+	}
+	info := r.info
+	if info.medium != medium802_3 {
+		return fmt.Errorf("unsupported medium %d", info.medium)
+	}
+	c.Version = gousb.Version(uint8(info.major), uint8(info.minor))
+	c.MaxPacketsPerTransfer = info.maxPacketsPerTransfer
+	if c.MaxPacketsPerTransfer < 1 {
+		c.MaxPacketsPerTransfer = 1
+	}
+	c.MaxTransferSize = info.maxTransferSize
+	c.alignment = info.alignment
+
+	mac, err := c.Query(ctx, OIDPermanentAddress)
+	if err != nil {
+		return err
+	}
+	if len(mac) != 6 {
+		return fmt.Errorf("invalid MAC address %x", mac)
+	}
+	c.MAC = net.HardwareAddr(mac)
+	c.MaxFrameSize = defaultFrameSize
+	if v, err := c.queryUint32(ctx, OIDMaximumFrameSize); err == nil && v > 0 {
+		c.MaxFrameSize = int(v)
+	}
+	// 0 is connected, 1 disconnected. Devices that don't report the
+	// state are assumed connected.
+	if v, err := c.queryUint32(ctx, OIDMediaConnectStatus); err == nil {
+		c.mu.Lock()
+		c.connected = v == 0
+		c.mu.Unlock()
+	}
+	filter := config.PacketFilter
+	if filter == 0 {
+		filter = DefaultPacketFilter
+	}
+	return c.SetPacketFilter(ctx, filter)
+}
+
+// send sends a control message to the device.
+func (c *Conn) send(msg []byte) error {
+	if _, err := c.ctl.Control(rTypeOut, reqSendEncapsulated, 0, c.intf, msg); err != nil {
+		return fmt.Errorf("SEND_ENCAPSULATED_COMMAND: %v", err)
+	}
+	return nil
+}
+
+// request sends the message built for a new request ID and waits for its
+// completion of type typ. The completion status is returned as an error
+// if it's not StatusSuccess.
+func (c *Conn) request(ctx context.Context, typ uint32, build func(id uint32) []byte) (*response, error) {
+	ch := make(chan *response, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, err
+	}
+	var id uint32
+	if typ != msgResetCmplt {
+		// RESET_CMPLT has no request ID, it's registered as 0.
+		id = c.nextID
+		if c.nextID++; c.nextID == 0 {
+			c.nextID = 1
+		}
+	}
+	if _, ok := c.pending[id]; ok {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("request %d already in progress", id)
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(build(id)); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-ch:
+		if r.typ != typ {
+			return nil, fmt.Errorf("got message 0x%08x in response to request %d, want 0x%08x", r.typ, id, typ)
+		}
+		if r.status != StatusSuccess {
+			return nil, r.status
+		}
+		return r, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, c.err
+	}
+}
+
+// poll reads the notification endpoint until the Conn is closed. Each
+// RESPONSE_AVAILABLE notification is followed by a GET_ENCAPSULATED_RESPONSE
+// and the response is dispatched. If reading the notification endpoint
+// fails, the error is returned by the pending and all later requests.
+func (c *Conn) poll(r reader, size int) {
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	go func() {
+		defer close(c.done)
+		buf := make([]byte, size)
+		resp := make([]byte, responseBufferSize)
+		for {
+			if _, err := r.ReadContext(ctx, buf); err != nil {
+				if ctx.Err() != nil {
+					err = ErrClosed
+				}
+				c.mu.Lock()
+				c.err = err
+				c.mu.Unlock()
+				return
+			}
+			n, err := c.ctl.Control(rTypeIn, reqGetEncapsulated, 0, c.intf, resp)
+			if err != nil || n == 0 {
+				continue
+			}
+			m, err := parseResponse(resp[:n])
+			if err != nil {
+				// Drop malformed responses, the pending request
+				// fails when its context is done.
+				continue
+			}
+			c.dispatch(m)
+		}
+	}()
+}
+
+// dispatch handles a message received from the device.
+func (c *Conn) dispatch(m *response) {
+	switch m.typ {
+	case msgKeepAlive:
+		c.send(marshalKeepAliveCmplt(m.requestID, StatusSuccess))
+		return
+	case msgIndicateStatus:
+		c.mu.Lock()
+		switch m.status {
+		case StatusMediaConnect:
+			c.connected = true
+		case StatusMediaDisconnect:
+			c.connected = false
+		}
+		c.mu.Unlock()
+		return
+	case msgResetCmplt:
+		m.requestID = 0
+	}
+	// Copy the buffer, resp is reused for the next response.
+	m.buf = append([]byte(nil), m.buf...)
+	c.mu.Lock()
+	ch, ok := c.pending[m.requestID]
+	c.mu.Unlock()
+	if ok {
+		select {
+		case ch <- m:
+		default:
+		}
+	}
+}
+
+// Query returns the value of an object of the device.
+func (c *Conn) Query(ctx context.Context, oid OID) ([]byte, error) {
+	r, err := c.request(ctx, msgQueryCmplt, func(id uint32) []byte { return marshalOID(msgQuery, id, oid, nil) })
+	if err != nil {
+		return nil, fmt.Errorf("QUERY 0x%08x: %w", uint32(oid), err)
+	}
+	return r.buf, nil
+}
+
+func (c *Conn) queryUint32(ctx context.Context, oid OID) (uint32, error) {
+	b, err := c.Query(ctx, oid)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) < 4 {
+		return 0, fmt.Errorf("QUERY 0x%08x: got %d bytes, want 4", uint32(oid), len(b))
+	}
+	return le.Uint32(b), nil
+}
+
+// Set sets the value of an object of the device.
+func (c *Conn) Set(ctx context.Context, oid OID, value []byte) error {
+	if _, err := c.request(ctx, msgSetCmplt, func(id uint32) []byte { return marshalOID(msgSet, id, oid, value) }); err != nil {
+		return fmt.Errorf("SET 0x%08x: %w", uint32(oid), err)
+	}
+	return nil
+}
+
+// SetPacketFilter selects the frames forwarded by the device.
+func (c *Conn) SetPacketFilter(ctx context.Context, f PacketFilter) error {
+	b := make([]byte, 4)
+	le.PutUint32(b, uint32(f))
+	if err := c.Set(ctx, OIDCurrentPacketFilter, b); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.filter = f
+	c.mu.Unlock()
+	return nil
+}
+
+// LinkSpeed returns the link speed reported by the device, in bits per
+// second.
+func (c *Conn) LinkSpeed(ctx context.Context) (int, error) {
+	// The speed is reported in units of 100bps.
+	v, err := c.queryUint32(ctx, OIDLinkSpeed)
+	return int(v) * 100, err
+}
+
+// Connected returns the last media state reported by the device.
+func (c *Conn) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+// Reset performs a soft reset of the device. If the device lost its
+// addressing information, the packet filter is restored.
+func (c *Conn) Reset(ctx context.Context) error {
+	r, err := c.request(ctx, msgResetCmplt, func(uint32) []byte { return marshalReset() })
+	if err != nil {
+		return fmt.Errorf("RESET: %w", err)
+	}
+	if r.addressingReset {
+		c.mu.Lock()
+		f := c.filter
+		c.mu.Unlock()
+		return c.SetPacketFilter(ctx, f)
+	}
+	return nil
+}
+
+// KeepAlive checks that the device is responsive.
+func (c *Conn) KeepAlive(ctx context.Context) error {
+	if _, err := c.request(ctx, msgKeepAliveCmplt, marshalKeepAlive); err != nil {
+		return fmt.Errorf("KEEPALIVE: %w", err)
+	}
+	return nil
+}
+
+// halt tells the device that the host stops using it. HALT has no
+// completion.
+func (c *Conn) halt() error {
+	c.mu.Lock()
+	id := c.nextID
+	c.mu.Unlock()
+	return c.send(marshalHalt(id))
+}
+
+// ReadFrame reads the next Ethernet frame received from the device into p
+// and returns its length. If p is too short, the frame is truncated and
+// io.ErrShortBuffer is returned. Malformed transfers are dropped.
+//
+// The context controls the cancellation of this read. Canceling a read
+// stops the reception of frames, subsequent reads return an error.
+func (c *Conn) ReadFrame(ctx context.Context, p []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+	for {
+		if len(c.frames) > 0 {
+			f := c.frames[0]
+			c.frames = c.frames[1:]
+			n := copy(p, f)
+			if n < len(f) {
+				return n, io.ErrShortBuffer
+			}
+			return n, nil
+		}
+		n, err := c.r.ReadContext(ctx, c.rbuf)
+		if err != nil {
+			return 0, err
+		}
+		// Keep the frames preceding a malformed message.
+		c.frames, _ = parsePackets(c.rbuf[:n])
+	}
+}
+
+// WriteFrame sends an Ethernet frame to the device.
+func (c *Conn) WriteFrame(ctx context.Context, frame []byte) error {
+	_, err := c.WriteFrames(ctx, [][]byte{frame})
+	return err
+}
+
+// WriteFrames sends Ethernet frames to the device and returns the number
+// of frames sent. Frames are packed into transfers within the limits
+// reported by the device.
+func (c *Conn) WriteFrames(ctx context.Context, frames [][]byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	sent := 0
+	for sent < len(frames) {
+		b, n, err := c.pack(c.wbuf[:0], frames[sent:])
+		if err != nil {
+			return sent, err
+		}
+		c.wbuf = b
+		if _, err := c.w.WriteContext(ctx, b); err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+// pack appends to b a transfer holding the first frames and returns it
+// with the number of frames it holds.
+func (c *Conn) pack(b []byte, frames [][]byte) ([]byte, int, error) {
+	maxSize := c.MaxTransferSize
+	if maxSize == 0 {
+		maxSize = packetHeaderSize + ethernetHeaderSize + c.MaxFrameSize
+	}
+	// sizes are the lengths of the messages, including the padding
+	// that aligns the next one.
+	var sizes []int
+	total := 0
+	for _, f := range frames {
+		if c.MaxPacketsPerTransfer > 0 && len(sizes) == c.MaxPacketsPerTransfer {
+			break
+		}
+		start := roundUp(total, c.alignment)
+		end := start + packetHeaderSize + len(f)
+		if end > maxSize {
+			if len(sizes) == 0 {
+				return nil, 0, fmt.Errorf("frame of %d bytes doesn't fit in a transfer of at most %d bytes", len(f), maxSize)
+			}
+			break
+		}
+		if len(sizes) > 0 {
+			sizes[len(sizes)-1] += start - total
+		}
+		sizes = append(sizes, packetHeaderSize+len(f))
+		total = end
+	}
+	if c.maxPacket > 0 && total%c.maxPacket == 0 && total < maxSize {
+		// Pad the last message so that the transfer ends with a short
+		// packet. The padding is part of the message length, and unlike
+		// a zero-length packet it never takes another transfer.
+		sizes[len(sizes)-1]++
+	}
+	for i, s := range sizes {
+		b = appendPacket(b, frames[i], s-packetHeaderSize-len(frames[i]))
+	}
+	return b, len(sizes), nil
+}
+
+func roundUp(n, m int) int {
+	if m <= 1 {
+		return n
+	}
+	return (n + m - 1) / m * m
+}
+
+// Close halts the device, stops the reception of frames and releases the
+// interfaces.
+func (c *Conn) Close() error {
+	if c.release == nil {
+		return nil
+	}
+	err := c.halt()
+	c.release()
+	c.release = nil
+	return err
+}
@@ -0,0 +1,390 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rndis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+// fakeDevice implements the control channel of an RNDIS device. Responses
+// to the messages sent by the host are queued and signaled on the
+// notification endpoint.
+type fakeDevice struct {
+	mu        sync.Mutex
+	sent      [][]byte
+	responses [][]byte
+	// silent devices don't answer requests.
+	silent bool
+	notify chan struct{}
+}
+
+func newFakeDevice() *fakeDevice {
+	return &fakeDevice{notify: make(chan struct{}, 16)}
+}
+
+func (f *fakeDevice) Control(rType, req uint8, val, idx uint16, data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case rType == rTypeOut && req == reqSendEncapsulated:
+		f.sent = append(f.sent, append([]byte(nil), data...))
+		if !f.silent {
+			if r := respond(data); r != nil {
+				f.queue(r)
+			}
+		}
+		return len(data), nil
+	case rType == rTypeIn && req == reqGetEncapsulated:
+		if len(f.responses) == 0 {
+			return 0, nil
+		}
+		n := copy(data, f.responses[0])
+		f.responses = f.responses[1:]
+		return n, nil
+	}
+	return 0, gousb.ErrorPipe
+}
+
+func (f *fakeDevice) queue(r []byte) {
+	f.responses = append(f.responses, r)
+	f.notify <- struct{}{}
+}
+
+// inject queues a message sent by the device on its own.
+func (f *fakeDevice) inject(m []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queue(m)
+}
+
+// ReadContext implements the notification endpoint.
+func (f *fakeDevice) ReadContext(ctx context.Context, p []byte) (int, error) {
+	select {
+	case <-f.notify:
+		return copy(p, []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (f *fakeDevice) sentTypes() []uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var types []uint32
+	for _, m := range f.sent {
+		types = append(types, le.Uint32(m))
+	}
+	return types
+}
+
+func respond(m []byte) []byte {
+	typ, id := le.Uint32(m), le.Uint32(m[8:])
+	u32 := func(v uint32) []byte {
+		b := make([]byte, 4)
+		le.PutUint32(b, v)
+		return b
+	}
+	switch typ {
+	case msgInit:
+		return newMessage(msgInitCmplt, []uint32{id, 0, 1, 0, 1, medium802_3, 2, 1600, 2, 0, 0}, nil)
+	case msgQuery:
+		var buf []byte
+		switch OID(le.Uint32(m[12:])) {
+		case OIDPermanentAddress:
+			buf = []byte{0x02, 0x00, 0x5e, 0x10, 0x00, 0x0a}
+		case OIDMaximumFrameSize:
+			buf = u32(1500)
+		case OIDMediaConnectStatus:
+			buf = u32(0)
+		case OIDLinkSpeed:
+			buf = u32(1000000)
+		default:
+			return newMessage(msgQueryCmplt, []uint32{id, uint32(StatusNotSupported), 0, 0}, nil)
+		}
+		return newMessage(msgQueryCmplt, []uint32{id, 0, uint32(len(buf)), 16}, buf)
+	case msgSet:
+		return newMessage(msgSetCmplt, []uint32{id, 0}, nil)
+	case msgReset:
+		return newMessage(msgResetCmplt, []uint32{0, 1}, nil)
+	case msgKeepAlive:
+		return newMessage(msgKeepAliveCmplt, []uint32{id, 0}, nil)
+	}
+	return nil
+}
+
+// waitFor polls cond until it's true or a few seconds passed.
+func waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+func TestControl(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDevice()
+	c := newConn(f, 0)
+	c.poll(f, 8)
+	defer func() {
+		c.cancel()
+		<-c.done
+	}()
+
+	if err := c.init(ctx, Config{}); err != nil {
+		t.Fatalf("init(): %v", err)
+	}
+	if got, want := c.MAC.String(), "02:00:5e:10:00:0a"; got != want {
+		t.Errorf("MAC: got %s, want %s", got, want)
+	}
+	if c.MaxFrameSize != 1500 || c.MaxPacketsPerTransfer != 2 || c.MaxTransferSize != 1600 || c.alignment != 4 {
+		t.Errorf("init(): got frame size %d, %d packets per transfer, transfer size %d, alignment %d, want 1500, 2, 1600, 4",
+			c.MaxFrameSize, c.MaxPacketsPerTransfer, c.MaxTransferSize, c.alignment)
+	}
+	if c.Version != gousb.Version(1, 0) {
+		t.Errorf("Version: got %s, want 1.00", c.Version)
+	}
+	if !c.Connected() {
+		t.Error("Connected(): got false, want true")
+	}
+	want := []uint32{msgInit, msgQuery, msgQuery, msgQuery, msgSet}
+	if got := f.sentTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("messages sent by init(): got %x, want %x", got, want)
+	}
+	if got := f.sent[4][28:]; !reflect.DeepEqual(got, []byte{0x0d, 0x00, 0x00, 0x00}) {
+		t.Errorf("packet filter: got % x, want 0d 00 00 00", got)
+	}
+
+	if got, err := c.LinkSpeed(ctx); err != nil || got != 100000000 {
+		t.Errorf("LinkSpeed(): got %d, %v, want 100000000, nil", got, err)
+	}
+	if _, err := c.Query(ctx, OIDMulticastList); !errors.Is(err, StatusNotSupported) {
+		t.Errorf("Query() of an unsupported OID: got %v, want %v", err, StatusNotSupported)
+	}
+	if err := c.KeepAlive(ctx); err != nil {
+		t.Errorf("KeepAlive(): %v", err)
+	}
+	// The device lost its addressing, the packet filter is restored.
+	if err := c.Reset(ctx); err != nil {
+		t.Errorf("Reset(): %v", err)
+	}
+	types := f.sentTypes()
+	if got := types[len(types)-2:]; !reflect.DeepEqual(got, []uint32{msgReset, msgSet}) {
+		t.Errorf("messages sent by Reset(): got %x, want %x", got, []uint32{msgReset, msgSet})
+	}
+
+	f.inject(newMessage(msgIndicateStatus, []uint32{uint32(StatusMediaDisconnect), 0, 0}, nil))
+	if !waitFor(func() bool { return !c.Connected() }) {
+		t.Error("Connected() after a media disconnect indication: got true, want false")
+	}
+	f.inject(marshalKeepAlive(77))
+	if !waitFor(func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		last := f.sent[len(f.sent)-1]
+		return le.Uint32(last) == msgKeepAliveCmplt && le.Uint32(last[8:]) == 77
+	}) {
+		t.Error("KEEPALIVE sent by the device was not completed")
+	}
+
+	f.mu.Lock()
+	f.silent = true
+	f.mu.Unlock()
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Query(tctx, OIDLinkSpeed); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Query() without a response: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	c.cancel()
+	<-c.done
+	if _, err := c.Query(ctx, OIDLinkSpeed); !errors.Is(err, ErrClosed) {
+		t.Errorf("Query() after the poller stopped: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestPollError(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDevice()
+	f.silent = true
+	notify := fakeusb.NewIn()
+	c := newConn(f, 0)
+	c.poll(notify, 8)
+	defer func() {
+		c.cancel()
+		<-c.done
+	}()
+
+	errc := make(chan error)
+	go func() {
+		_, err := c.Query(ctx, OIDLinkSpeed)
+		errc <- err
+	}()
+	if !waitFor(func() bool { return len(f.sentTypes()) == 1 }) {
+		t.Fatal("QUERY was not sent")
+	}
+	notify.Close(gousb.ErrorNoDevice)
+	if err := <-errc; !errors.Is(err, gousb.ErrorNoDevice) {
+		t.Errorf("pending Query() after a notification error: got %v, want %v", err, gousb.ErrorNoDevice)
+	}
+	if _, err := c.Query(ctx, OIDLinkSpeed); !errors.Is(err, gousb.ErrorNoDevice) {
+		t.Errorf("Query() after a notification error: got %v, want %v", err, gousb.ErrorNoDevice)
+	}
+	if got := len(f.sentTypes()); got != 1 {
+		t.Errorf("messages sent after a notification error: got %d, want none", got-1)
+	}
+}
+
+func TestFrames(t *testing.T) {
+	ctx := context.Background()
+	in := fakeusb.NewIn(
+		appendPacket(appendPacket(nil, []byte("one"), 1), []byte("two"), 0),
+		[]byte("garbage"),
+		appendPacket(nil, []byte("three"), 0),
+	)
+	in.Close(io.EOF)
+	out := &fakeusb.Out{}
+	c := newConn(nil, 0)
+	c.r, c.w, c.rbuf = in, out, make([]byte, maxTransferSize)
+	c.MaxPacketsPerTransfer, c.MaxTransferSize, c.alignment, c.maxPacket = 2, 200, 4, 64
+
+	buf := make([]byte, 16)
+	var got []string
+	for {
+		n, err := c.ReadFrame(ctx, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadFrame(): %v", err)
+		}
+		got = append(got, string(buf[:n]))
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadFrame(): got %q, want %q", got, want)
+	}
+
+	frames := [][]byte{make([]byte, 5), make([]byte, 6), make([]byte, 84)}
+	if n, err := c.WriteFrames(ctx, frames); err != nil || n != 3 {
+		t.Fatalf("WriteFrames(): got %d, %v, want 3, nil", n, err)
+	}
+	// Two frames per transfer, the second message aligned on 4 bytes.
+	// The 128 bytes transfer is padded to end with a short packet.
+	var sizes []int
+	var written [][]byte
+	for _, b := range out.Transfers() {
+		sizes = append(sizes, len(b))
+		p, err := parsePackets(b)
+		if err != nil {
+			t.Fatalf("parsePackets() of a written transfer: %v", err)
+		}
+		written = append(written, p...)
+	}
+	if want := []int{52 + 50, 129}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("written transfer sizes: got %v, want %v", sizes, want)
+	}
+	if !reflect.DeepEqual(written, frames) {
+		t.Errorf("written frames: got %d frames, want %d identical frames", len(written), len(frames))
+	}
+	if err := c.WriteFrame(ctx, make([]byte, 200)); err == nil {
+		t.Error("WriteFrame() of a frame larger than MaxTransferSize: got nil error, want non-nil")
+	}
+}
+
+func TestFindInterfaces(t *testing.T) {
+	ep := func(addr gousb.EndpointAddress, tt gousb.TransferType) gousb.EndpointDesc {
+		dir := gousb.EndpointDirectionOut
+		if addr&0x80 != 0 {
+			dir = gousb.EndpointDirectionIn
+		}
+		return gousb.EndpointDesc{Address: addr, Number: int(addr & 0x0f), Direction: dir, TransferType: tt, MaxPacketSize: 64}
+	}
+	data := func(num int) gousb.InterfaceDesc {
+		return gousb.InterfaceDesc{Number: num, AltSettings: []gousb.InterfaceSetting{{
+			Number: num,
+			Class:  gousb.ClassData,
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{
+				0x82: ep(0x82, gousb.TransferTypeBulk),
+				0x02: ep(0x02, gousb.TransferTypeBulk),
+			},
+		}}}
+	}
+	control := func(num int, class gousb.Class, sub gousb.Class, proto gousb.Protocol, extra []byte) gousb.InterfaceDesc {
+		return gousb.InterfaceDesc{Number: num, AltSettings: []gousb.InterfaceSetting{{
+			Number:    num,
+			Class:     class,
+			SubClass:  sub,
+			Protocol:  proto,
+			Extra:     extra,
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{0x81: ep(0x81, gousb.TransferTypeInterrupt)},
+		}}}
+	}
+	for _, tc := range []struct {
+		desc     string
+		intfs    []gousb.InterfaceDesc
+		wantData int
+		wantErr  bool
+	}{
+		{
+			desc:     "Android tethering",
+			intfs:    []gousb.InterfaceDesc{control(0, gousb.ClassWireless, subClassRF, protocolRNDIS, nil), data(1)},
+			wantData: 1,
+		},
+		{
+			desc: "Linux gadget with a union descriptor",
+			intfs: []gousb.InterfaceDesc{
+				control(0, gousb.ClassComm, subClassACM, protocolVendor, []byte{0x05, 0x24, 0x06, 0x00, 0x02}),
+				{Number: 1, AltSettings: []gousb.InterfaceSetting{{Number: 1, Class: gousb.ClassHID}}},
+				data(2),
+			},
+			wantData: 2,
+		},
+		{
+			desc:    "no data interface",
+			intfs:   []gousb.InterfaceDesc{control(0, gousb.ClassMiscellaneous, subClassMiscCDC, protocolMiscRNDIS, nil)},
+			wantErr: true,
+		},
+		{
+			desc:    "CDC-ACM serial",
+			intfs:   []gousb.InterfaceDesc{control(0, gousb.ClassComm, subClassACM, 0x01, nil), data(1)},
+			wantErr: true,
+		},
+	} {
+		cfg := gousb.ConfigDesc{Number: 1, Interfaces: tc.intfs}
+		_, notify, d, in, out, err := findInterfaces(cfg)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: findInterfaces(): got error %v, want error: %v", tc.desc, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if d.Number != tc.wantData || notify.Address != 0x81 || in.Address != 0x82 || out.Address != 0x02 {
+			t.Errorf("%s: findInterfaces(): got data interface %d, endpoints %s, %s, %s, want %d, 0x81, 0x82, 0x02",
+				tc.desc, d.Number, notify.Address, in.Address, out.Address, tc.wantData)
+		}
+	}
+}
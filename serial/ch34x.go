@@ -0,0 +1,295 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// Requests of the CH340 and CH341 chips.
+const (
+	chReadVersion = 0x5f
+	chReadReg     = 0x95
+	chWriteReg    = 0x9a
+	chSerialInit  = 0xa1
+	chModemCtrl   = 0xa4
+)
+
+// Registers of the CH34x chips.
+const (
+	chRegBreak     = 0x05
+	chRegStatus    = 0x06
+	chRegStatus2   = 0x07
+	chRegPrescaler = 0x12
+	chRegDivisor   = 0x13
+	chRegLCR       = 0x18
+	chRegLCR2      = 0x25
+	chRegFlowCtl   = 0x27
+)
+
+// Bits of the line control register.
+const (
+	chLCREnableRX  = 0x80
+	chLCREnableTX  = 0x40
+	chLCRMarkSpace = 0x20
+	chLCRParEven   = 0x10
+	chLCREnablePar = 0x08
+	chLCRStopBits2 = 0x04
+)
+
+// Bits of the modem control request and the status register. Both are
+// active low.
+const (
+	chDTR        = 0x20
+	chRTS        = 0x40
+	chCTS        = 0x01
+	chDSR        = 0x02
+	chRI         = 0x04
+	chDCD        = 0x08
+	chNBreak     = 0x01
+	chFlowRTSCTS = 0x01
+)
+
+// chClock is the clock of the baud rate generator, divided by a prescaler
+// and a divisor between 2 and 255.
+const chClock = 48000000
+
+func chClockDiv(ps, fact uint) int {
+	return 1 << (12 - 3*ps - fact)
+}
+
+func chMinRate(ps uint) int {
+	return chClock / (chClockDiv(ps, 1) * 512)
+}
+
+var (
+	chMinBaudRate = chMinRate(0)
+	chMaxBaudRate = chClock / (chClockDiv(3, 0) * 2)
+)
+
+var ch34xDriver = &Driver{Name: "ch34x", Open: openCH34x}
+
+func init() {
+	register(ch34xDriver,
+		vidPID{0x1a86, 0x7523}, // CH340
+		vidPID{0x1a86, 0x7522}, // CH340K
+		vidPID{0x1a86, 0x5523}, // CH341 in serial mode
+		vidPID{0x4348, 0x5523},
+	)
+}
+
+// ch34x drives a WCH CH340 or CH341 chip.
+type ch34x struct {
+	bulkPort
+	ctl controller
+	// lines holds the state of the DTR and RTS lines.
+	lines uint8
+}
+
+func openCH34x(dev *gousb.Device, port int) (Port, error) {
+	if port != 0 {
+		return nil, fmt.Errorf("CH34x has a single port, got port %d", port)
+	}
+	u, err := openInterface(dev, 0)
+	if err != nil {
+		return nil, err
+	}
+	p := &ch34x{bulkPort: u.bulkPort, ctl: dev}
+	if err := p.init(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *ch34x) out(req uint8, val, idx uint16) error {
+	rType := uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlDevice)
+	if _, err := p.ctl.Control(rType, req, val, idx, nil); err != nil {
+		return fmt.Errorf("CH34x request 0x%02x: %v", req, err)
+	}
+	return nil
+}
+
+func (p *ch34x) in(req uint8, val, idx uint16, data []byte) error {
+	rType := uint8(gousb.ControlIn | gousb.ControlVendor | gousb.ControlDevice)
+	n, err := p.ctl.Control(rType, req, val, idx, data)
+	if err != nil {
+		return fmt.Errorf("CH34x request 0x%02x: %v", req, err)
+	}
+	if n != len(data) {
+		return fmt.Errorf("CH34x request 0x%02x: got %d bytes, want %d", req, n, len(data))
+	}
+	return nil
+}
+
+// writeReg writes two registers at once.
+func (p *ch34x) writeReg(reg1, reg2, val1, val2 uint8) error {
+	return p.out(chWriteReg, uint16(reg2)<<8|uint16(reg1), uint16(val2)<<8|uint16(val1))
+}
+
+// readReg reads two registers at once.
+func (p *ch34x) readReg(reg1, reg2 uint8) (uint8, uint8, error) {
+	var b [2]byte
+	if err := p.in(chReadReg, uint16(reg2)<<8|uint16(reg1), 0, b[:]); err != nil {
+		return 0, 0, err
+	}
+	return b[0], b[1], nil
+}
+
+func (p *ch34x) init() error {
+	var v [2]byte
+	if err := p.in(chReadVersion, 0, 0, v[:]); err != nil {
+		return err
+	}
+	if err := p.out(chSerialInit, 0, 0); err != nil {
+		return err
+	}
+	if err := p.SetMode(Mode{BaudRate: 9600}); err != nil {
+		return err
+	}
+	return p.setLines()
+}
+
+// chDivisor returns the value of the prescaler and divisor registers for
+// the baud rate.
+func chDivisor(baud int) (uint16, error) {
+	if baud < chMinBaudRate || baud > chMaxBaudRate {
+		return 0, fmt.Errorf("baud rate %d out of the supported range %d to %d", baud, chMinBaudRate, chMaxBaudRate)
+	}
+	// Start with the highest base clock (fact 1) that gives a divisor
+	// below 512.
+	fact := uint(1)
+	ps := 3
+	for ; ps >= 0; ps-- {
+		if baud > chMinRate(uint(ps)) {
+			break
+		}
+	}
+	clkDiv := chClockDiv(uint(ps), fact)
+	div := chClock / (clkDiv * baud)
+	// Halve the base clock if required.
+	if div < 9 || div > 255 {
+		div /= 2
+		clkDiv *= 2
+		fact = 0
+	}
+	if div < 2 {
+		return 0, fmt.Errorf("baud rate %d not supported", baud)
+	}
+	// Pick the next divisor if the resulting rate is closer, scaled up to
+	// avoid rounding errors at low rates.
+	if 16*chClock/(clkDiv*div)-16*baud >= 16*baud-16*chClock/(clkDiv*(div+1)) {
+		div++
+	}
+	// Prefer the lower base clock with an even divisor.
+	if fact == 1 && div%2 == 0 {
+		div /= 2
+		fact = 0
+	}
+	return uint16(0x100-div)<<8 | uint16(fact)<<2 | uint16(ps), nil
+}
+
+func (p *ch34x) SetMode(m Mode) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	div, err := chDivisor(m.BaudRate)
+	if err != nil {
+		return err
+	}
+	lcr := uint8(chLCREnableRX | chLCREnableTX | (m.dataBits() - 5))
+	switch m.Parity {
+	case ParityOdd:
+		lcr |= chLCREnablePar
+	case ParityEven:
+		lcr |= chLCREnablePar | chLCRParEven
+	case ParityMark:
+		lcr |= chLCREnablePar | chLCRMarkSpace
+	case ParitySpace:
+		lcr |= chLCREnablePar | chLCRMarkSpace | chLCRParEven
+	}
+	switch m.StopBits {
+	case StopBits15:
+		return fmt.Errorf("CH34x does not support 1.5 stop bits")
+	case StopBits2:
+		lcr |= chLCRStopBits2
+	}
+	// Without bit 7 of the prescaler, the chip buffers received data until
+	// a full packet is available.
+	if err := p.writeReg(chRegPrescaler, chRegDivisor, uint8(div)|0x80, uint8(div>>8)); err != nil {
+		return err
+	}
+	return p.writeReg(chRegLCR, chRegLCR2, lcr, 0)
+}
+
+func (p *ch34x) SetFlowControl(f Flow) error {
+	var v uint8
+	switch f {
+	case FlowNone:
+	case FlowRTSCTS:
+		v = chFlowRTSCTS
+	default:
+		return fmt.Errorf("CH34x does not support flow control mode %d", f)
+	}
+	return p.writeReg(chRegFlowCtl, chRegFlowCtl, v, v)
+}
+
+func (p *ch34x) setLines() error {
+	return p.out(chModemCtrl, uint16(^p.lines), 0)
+}
+
+func (p *ch34x) SetDTR(on bool) error {
+	if on {
+		p.lines |= chDTR
+	} else {
+		p.lines &^= chDTR
+	}
+	return p.setLines()
+}
+
+func (p *ch34x) SetRTS(on bool) error {
+	if on {
+		p.lines |= chRTS
+	} else {
+		p.lines &^= chRTS
+	}
+	return p.setLines()
+}
+
+func (p *ch34x) SetBreak(on bool) error {
+	brk, lcr, err := p.readReg(chRegBreak, chRegLCR)
+	if err != nil {
+		return err
+	}
+	if on {
+		brk &^= chNBreak
+		lcr &^= chLCREnableTX
+	} else {
+		brk |= chNBreak
+		lcr |= chLCREnableTX
+	}
+	return p.writeReg(chRegBreak, chRegLCR, brk, lcr)
+}
+
+func (p *ch34x) ModemStatus() (ModemStatus, error) {
+	st, _, err := p.readReg(chRegStatus, chRegStatus2)
+	if err != nil {
+		return 0, err
+	}
+	// The status bits are active low and match the order of ModemStatus.
+	return ModemStatus(^st & (chCTS | chDSR | chRI | chDCD)), nil
+}
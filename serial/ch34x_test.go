@@ -0,0 +1,101 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func TestCH34xDivisor(t *testing.T) {
+	for _, tc := range []struct {
+		baud int
+		want uint16
+	}{
+		{50, 0x1600},
+		{9600, 0xb202},
+		{115200, 0xcc03},
+		{921600, 0xf307},
+		{2000000, 0xfd03},
+	} {
+		if got, err := chDivisor(tc.baud); err != nil || got != tc.want {
+			t.Errorf("chDivisor(%d): got 0x%04x, %v, want 0x%04x, nil", tc.baud, got, err, tc.want)
+		}
+	}
+	for _, baud := range []int{40, 4000000} {
+		if _, err := chDivisor(baud); err == nil {
+			t.Errorf("chDivisor(%d): got nil error, want non-nil", baud)
+		}
+	}
+}
+
+func TestCH34x(t *testing.T) {
+	ctl := newFakeControl(map[[2]uint16][]byte{
+		{chReadVersion, 0}:  {0x31, 0x00},
+		{chReadReg, 0x0706}: {0xfe, 0xee},
+		{chReadReg, 0x1805}: {0x01, 0xc3},
+	})
+	p := &ch34x{ctl: ctl}
+	if err := p.init(); err != nil {
+		t.Fatalf("init(): %v", err)
+	}
+	n := len(ctl.Requests())
+	if err := p.SetMode(Mode{BaudRate: 115200, DataBits: 7, Parity: ParityOdd, StopBits: StopBits2}); err != nil {
+		t.Fatalf("SetMode(): %v", err)
+	}
+	if err := p.SetDTR(true); err != nil {
+		t.Fatalf("SetDTR(): %v", err)
+	}
+	if err := p.SetRTS(true); err != nil {
+		t.Fatalf("SetRTS(): %v", err)
+	}
+	if err := p.SetFlowControl(FlowRTSCTS); err != nil {
+		t.Fatalf("SetFlowControl(): %v", err)
+	}
+	if err := p.SetBreak(true); err != nil {
+		t.Fatalf("SetBreak(): %v", err)
+	}
+	out := uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlDevice)
+	in := uint8(gousb.ControlIn | gousb.ControlVendor | gousb.ControlDevice)
+	want := []fakeusb.Request{
+		{RType: out, Request: chWriteReg, Val: 0x1312, Idx: 0xcc83},
+		{RType: out, Request: chWriteReg, Val: 0x2518, Idx: 0x00ce},
+		{RType: out, Request: chModemCtrl, Val: 0xdf},
+		{RType: out, Request: chModemCtrl, Val: 0x9f},
+		{RType: out, Request: chWriteReg, Val: 0x2727, Idx: 0x0101},
+		{RType: in, Request: chReadReg, Val: 0x1805},
+		{RType: out, Request: chWriteReg, Val: 0x1805, Idx: 0x8300},
+	}
+	if got := ctl.Requests()[n:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("requests:\ngot  %v\nwant %v", got, want)
+	}
+	if got, err := p.ModemStatus(); err != nil || got != CTS {
+		t.Errorf("ModemStatus(): got %s, %v, want [CTS], nil", got, err)
+	}
+	for _, tc := range []struct {
+		desc string
+		f    func() error
+	}{
+		{"1.5 stop bits", func() error { return p.SetMode(Mode{BaudRate: 9600, StopBits: StopBits15}) }},
+		{"DTR/DSR flow control", func() error { return p.SetFlowControl(FlowDTRDSR) }},
+	} {
+		if err := tc.f(); err == nil {
+			t.Errorf("%s: got nil error, want non-nil", tc.desc)
+		}
+	}
+}
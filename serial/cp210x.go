@@ -0,0 +1,208 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// Requests of the CP210x chips, see Silicon Labs AN571.
+const (
+	cpIfcEnable   = 0x00
+	cpSetLineCtl  = 0x03
+	cpSetBreak    = 0x05
+	cpSetMHS      = 0x07
+	cpGetMdmSts   = 0x08
+	cpPurge       = 0x12
+	cpSetFlow     = 0x13
+	cpSetChars    = 0x19
+	cpSetBaudRate = 0x1e
+)
+
+// Bits of the modem handshaking and status.
+const (
+	cpDTR     = 0x0001
+	cpRTS     = 0x0002
+	cpDTRMask = 0x0100
+	cpRTSMask = 0x0200
+	cpCTS     = 0x10
+	cpDSR     = 0x20
+	cpRI      = 0x40
+	cpDCD     = 0x80
+)
+
+// Bits of the flow control settings.
+const (
+	cpDTRActive       = 0x01 // ulControlHandshake: DTR held active
+	cpDTRFlow         = 0x02 // ulControlHandshake: DTR flow control
+	cpCTSHandshake    = 0x08
+	cpDSRHandshake    = 0x10
+	cpAutoTransmit    = 0x01 // ulFlowReplace: XON/XOFF on transmit
+	cpAutoReceive     = 0x02 // ulFlowReplace: XON/XOFF on receive
+	cpRTSActive       = 0x40
+	cpRTSFlow         = 0x80
+	cpXonXoffLimit    = 128
+	cpFlowSettingSize = 16
+)
+
+const (
+	cpMaxBaudRate = 3000000
+	// cpPurgeAll clears the receive and transmit queues.
+	cpPurgeAll = 0x0f
+)
+
+var cp210xDriver = &Driver{Name: "cp210x", Open: openCP210x}
+
+func init() {
+	register(cp210xDriver,
+		vidPID{0x10c4, 0xea60}, // CP2102, CP2102N, CP2104
+		vidPID{0x10c4, 0xea61},
+		vidPID{0x10c4, 0xea63},
+		vidPID{0x10c4, 0xea70}, // CP2105
+		vidPID{0x10c4, 0xea71}, // CP2108
+		vidPID{0x10c4, 0xea7a},
+		vidPID{0x10c4, 0xea7b},
+	)
+}
+
+// cp210x drives a port of a Silicon Labs CP210x chip. Each port has its
+// own interface, the requests are addressed to the interface.
+type cp210x struct {
+	bulkPort
+	ctl  controller
+	intf uint16
+}
+
+func openCP210x(dev *gousb.Device, port int) (Port, error) {
+	u, err := openInterface(dev, port)
+	if err != nil {
+		return nil, err
+	}
+	p := &cp210x{bulkPort: u.bulkPort, ctl: dev, intf: uint16(port)}
+	if err := p.init(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *cp210x) out(req uint8, val uint16, data []byte) error {
+	rType := uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlInterface)
+	if _, err := p.ctl.Control(rType, req, val, p.intf, data); err != nil {
+		return fmt.Errorf("CP210x request 0x%02x: %v", req, err)
+	}
+	return nil
+}
+
+func (p *cp210x) init() error {
+	if err := p.out(cpIfcEnable, 1, nil); err != nil {
+		return err
+	}
+	return p.out(cpPurge, cpPurgeAll, nil)
+}
+
+func (p *cp210x) SetMode(m Mode) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if m.BaudRate > cpMaxBaudRate {
+		return fmt.Errorf("baud rate %d above the maximum of %d", m.BaudRate, cpMaxBaudRate)
+	}
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(m.BaudRate))
+	if err := p.out(cpSetBaudRate, 0, b[:]); err != nil {
+		return err
+	}
+	return p.out(cpSetLineCtl, uint16(m.StopBits)|uint16(m.Parity)<<4|uint16(m.dataBits())<<8, nil)
+}
+
+func (p *cp210x) SetFlowControl(f Flow) error {
+	var hs, repl uint32
+	switch f {
+	case FlowNone:
+		hs, repl = cpDTRActive, cpRTSActive
+	case FlowRTSCTS:
+		hs, repl = cpDTRActive|cpCTSHandshake, cpRTSFlow
+	case FlowDTRDSR:
+		hs, repl = cpDTRFlow|cpDSRHandshake, cpRTSActive
+	case FlowXonXoff:
+		hs, repl = cpDTRActive, cpRTSActive|cpAutoTransmit|cpAutoReceive
+		// Special characters: EOF, error, break, event, XON, XOFF.
+		if err := p.out(cpSetChars, 0, []byte{0, 0, 0, 0, xon, xoff}); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid flow control mode %d", f)
+	}
+	var b [cpFlowSettingSize]byte
+	binary.LittleEndian.PutUint32(b[0:], hs)
+	binary.LittleEndian.PutUint32(b[4:], repl)
+	binary.LittleEndian.PutUint32(b[8:], cpXonXoffLimit)
+	binary.LittleEndian.PutUint32(b[12:], cpXonXoffLimit)
+	return p.out(cpSetFlow, 0, b[:])
+}
+
+func (p *cp210x) SetDTR(on bool) error {
+	val := uint16(cpDTRMask)
+	if on {
+		val |= cpDTR
+	}
+	return p.out(cpSetMHS, val, nil)
+}
+
+func (p *cp210x) SetRTS(on bool) error {
+	val := uint16(cpRTSMask)
+	if on {
+		val |= cpRTS
+	}
+	return p.out(cpSetMHS, val, nil)
+}
+
+func (p *cp210x) SetBreak(on bool) error {
+	var val uint16
+	if on {
+		val = 1
+	}
+	return p.out(cpSetBreak, val, nil)
+}
+
+func (p *cp210x) ModemStatus() (ModemStatus, error) {
+	rType := uint8(gousb.ControlIn | gousb.ControlVendor | gousb.ControlInterface)
+	var b [1]byte
+	if _, err := p.ctl.Control(rType, cpGetMdmSts, 0, p.intf, b[:]); err != nil {
+		return 0, fmt.Errorf("CP210x get modem status: %v", err)
+	}
+	var s ModemStatus
+	for _, m := range []struct {
+		bit byte
+		s   ModemStatus
+	}{{cpCTS, CTS}, {cpDSR, DSR}, {cpRI, RI}, {cpDCD, DCD}} {
+		if b[0]&m.bit != 0 {
+			s |= m.s
+		}
+	}
+	return s, nil
+}
+
+func (p *cp210x) Close() error {
+	if p.release == nil {
+		return nil
+	}
+	p.out(cpIfcEnable, 0, nil)
+	return p.bulkPort.Close()
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func TestCP210x(t *testing.T) {
+	ctl := newFakeControl(map[[2]uint16][]byte{{cpGetMdmSts, 0}: {0x31}})
+	p := &cp210x{ctl: ctl, intf: 1}
+	if err := p.init(); err != nil {
+		t.Fatalf("init(): %v", err)
+	}
+	if err := p.SetMode(Mode{BaudRate: 115200, DataBits: 7, Parity: ParityEven, StopBits: StopBits2}); err != nil {
+		t.Fatalf("SetMode(): %v", err)
+	}
+	if err := p.SetFlowControl(FlowRTSCTS); err != nil {
+		t.Fatalf("SetFlowControl(): %v", err)
+	}
+	if err := p.SetDTR(true); err != nil {
+		t.Fatalf("SetDTR(): %v", err)
+	}
+	if err := p.SetRTS(false); err != nil {
+		t.Fatalf("SetRTS(): %v", err)
+	}
+	if err := p.SetBreak(true); err != nil {
+		t.Fatalf("SetBreak(): %v", err)
+	}
+	out := uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlInterface)
+	want := []fakeusb.Request{
+		{RType: out, Request: cpIfcEnable, Val: 1, Idx: 1},
+		{RType: out, Request: cpPurge, Val: cpPurgeAll, Idx: 1},
+		{RType: out, Request: cpSetBaudRate, Idx: 1, Data: "\x00\xc2\x01\x00"},
+		{RType: out, Request: cpSetLineCtl, Val: 0x0722, Idx: 1},
+		{RType: out, Request: cpSetFlow, Idx: 1, Data: "\x09\x00\x00\x00\x80\x00\x00\x00\x80\x00\x00\x00\x80\x00\x00\x00"},
+		{RType: out, Request: cpSetMHS, Val: 0x0101, Idx: 1},
+		{RType: out, Request: cpSetMHS, Val: 0x0200, Idx: 1},
+		{RType: out, Request: cpSetBreak, Val: 1, Idx: 1},
+	}
+	if got := ctl.Requests(); !reflect.DeepEqual(got, want) {
+		t.Errorf("requests:\ngot  %q\nwant %q", got, want)
+	}
+	if got, err := p.ModemStatus(); err != nil || got != CTS|DSR {
+		t.Errorf("ModemStatus(): got %s, %v, want [CTS DSR], nil", got, err)
+	}
+	if err := p.SetMode(Mode{BaudRate: 4000000}); err == nil {
+		t.Error("SetMode(4000000 baud): got nil error, want non-nil")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"errors"
+
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+// newFakeControl returns a control endpoint that answers the IN requests
+// with the data in in, keyed by request and value.
+func newFakeControl(in map[[2]uint16][]byte) *fakeusb.Control {
+	return &fakeusb.Control{Handle: func(r fakeusb.Request, data []byte) (int, error) {
+		if r.RType&0x80 == 0 {
+			return len(data), nil
+		}
+		v, ok := in[[2]uint16{uint16(r.Request), r.Val}]
+		if !ok {
+			return 0, errors.New("unsupported request")
+		}
+		return copy(data, v), nil
+	}}
+}
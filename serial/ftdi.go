@@ -0,0 +1,90 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"fmt"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/ftdi"
+)
+
+var ftdiDriver = &Driver{Name: "ftdi", Open: openFTDI}
+
+func init() {
+	for _, pid := range []gousb.ID{ftdi.ProductFT232, ftdi.ProductFT2232, ftdi.ProductFT4232H, ftdi.ProductFT232H, ftdi.ProductFTX} {
+		Register(ftdi.VendorFTDI, pid, ftdiDriver)
+	}
+}
+
+// ftdiPort adapts an ftdi.Port to the Port interface.
+type ftdiPort struct {
+	*ftdi.Port
+}
+
+func openFTDI(dev *gousb.Device, port int) (Port, error) {
+	p, err := ftdi.Open(dev, port)
+	if err != nil {
+		return nil, err
+	}
+	return ftdiPort{p}, nil
+}
+
+func (p ftdiPort) SetMode(m Mode) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if b := m.dataBits(); b != 7 && b != 8 {
+		return fmt.Errorf("FTDI does not support %d data bits", b)
+	}
+	if _, err := p.SetBaudRate(m.BaudRate); err != nil {
+		return err
+	}
+	return p.SetLineProperties(m.dataBits(), ftdi.Parity(m.Parity), ftdi.StopBits(m.StopBits))
+}
+
+func (p ftdiPort) SetFlowControl(f Flow) error {
+	var ff ftdi.Flow
+	switch f {
+	case FlowNone:
+		ff = ftdi.FlowNone
+	case FlowRTSCTS:
+		ff = ftdi.FlowRTSCTS
+	case FlowDTRDSR:
+		ff = ftdi.FlowDTRDSR
+	case FlowXonXoff:
+		ff = ftdi.FlowXonXoff
+	default:
+		return fmt.Errorf("invalid flow control mode %d", f)
+	}
+	return p.Port.SetFlowControl(ff)
+}
+
+func (p ftdiPort) ModemStatus() (ModemStatus, error) {
+	st, err := p.Port.ModemStatus()
+	if err != nil {
+		return 0, err
+	}
+	var s ModemStatus
+	for _, m := range []struct {
+		bit ftdi.ModemStatus
+		s   ModemStatus
+	}{{ftdi.StatusCTS, CTS}, {ftdi.StatusDSR, DSR}, {ftdi.StatusRI, RI}, {ftdi.StatusDCD, DCD}} {
+		if st&m.bit != 0 {
+			s |= m.s
+		}
+	}
+	return s, nil
+}
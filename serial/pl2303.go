@@ -0,0 +1,324 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/google/gousb"
+)
+
+// Requests of the PL2303 chips. Line settings use CDC ACM class requests,
+// the chip setup uses vendor requests.
+const (
+	plVendorRead  = 0x01
+	plVendorWrite = 0x01
+	plSetLine     = 0x20
+	plSetControl  = 0x22
+	plBreak       = 0x23
+)
+
+// Bits of the control lines and of the UART state notification.
+const (
+	plDTR = 0x01
+	plRTS = 0x02
+
+	plStateIndex = 8
+	plDCD        = 0x01
+	plDSR        = 0x02
+	plRI         = 0x08
+	plCTS        = 0x80
+)
+
+// Values written to vendor register 0 for flow control.
+const (
+	plFlowNone         = 0x00
+	plFlowRTSCTSLegacy = 0x41
+	plFlowRTSCTS       = 0x61
+	plFlowXonXoff      = 0xc0
+)
+
+const (
+	plMaxBaudRateLegacy = 1228800
+	plMaxBaudRate       = 6000000
+)
+
+// plBaudRates are the baud rates set directly in the line coding, other
+// rates are encoded with a divisor.
+var plBaudRates = []int{
+	75, 150, 300, 600, 1200, 1800, 2400, 3600, 4800, 7200, 9600, 14400,
+	19200, 28800, 38400, 57600, 115200, 230400, 460800, 614400, 921600,
+	1228800, 2457600, 3000000, 6000000,
+}
+
+var pl2303Driver = &Driver{Name: "pl2303", Open: openPL2303}
+
+func init() {
+	register(pl2303Driver,
+		vidPID{0x067b, 0x2303}, // PL2303, PL2303HX
+		vidPID{0x067b, 0x04bb},
+		vidPID{0x0557, 0x2008}, // ATEN UC-232A
+	)
+}
+
+// pl2303 drives a Prolific PL2303 or PL2303HX chip. The newer PL2303G
+// (HXN) chips use a different protocol and are not supported.
+type pl2303 struct {
+	bulkPort
+	ctl controller
+	// legacy is true for the original PL2303, false for the PL2303HX.
+	legacy bool
+	lines  uint16
+	// status is the ModemStatus of the last UART state notification,
+	// accessed atomically.
+	status uint32
+	// cancel and done stop the goroutine reading notifications.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func openPL2303(dev *gousb.Device, port int) (Port, error) {
+	if port != 0 {
+		return nil, fmt.Errorf("PL2303 has a single port, got port %d", port)
+	}
+	u, err := openInterface(dev, 0)
+	if err != nil {
+		return nil, err
+	}
+	// The original chips are CDC class devices or have a control endpoint
+	// smaller than 64 bytes.
+	legacy := dev.Desc.Class == gousb.ClassComm || dev.Desc.MaxControlPacketSize != 64
+	p := &pl2303{bulkPort: u.bulkPort, ctl: dev, legacy: legacy}
+	if err := p.init(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	if u.status != nil {
+		p.readStatus(u.status, u.statusDesc.MaxPacketSize)
+	}
+	return p, nil
+}
+
+func (p *pl2303) vendorRead(val uint16) error {
+	rType := uint8(gousb.ControlIn | gousb.ControlVendor | gousb.ControlDevice)
+	var b [1]byte
+	if _, err := p.ctl.Control(rType, plVendorRead, val, 0, b[:]); err != nil {
+		return fmt.Errorf("PL2303 vendor read 0x%04x: %v", val, err)
+	}
+	return nil
+}
+
+func (p *pl2303) vendorWrite(val, idx uint16) error {
+	rType := uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlDevice)
+	if _, err := p.ctl.Control(rType, plVendorWrite, val, idx, nil); err != nil {
+		return fmt.Errorf("PL2303 vendor write 0x%04x: %v", val, err)
+	}
+	return nil
+}
+
+func (p *pl2303) class(req uint8, val uint16, data []byte) error {
+	rType := uint8(gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface)
+	if _, err := p.ctl.Control(rType, req, val, 0, data); err != nil {
+		return fmt.Errorf("PL2303 request 0x%02x: %v", req, err)
+	}
+	return nil
+}
+
+// init runs the initialization sequence of the chip, which has no public
+// documentation and follows the vendor driver.
+func (p *pl2303) init() error {
+	type step struct {
+		read     bool
+		val, idx uint16
+	}
+	last := step{val: 2, idx: 0x44}
+	if p.legacy {
+		last.idx = 0x24
+	}
+	for _, s := range []step{
+		{read: true, val: 0x8484},
+		{val: 0x0404, idx: 0},
+		{read: true, val: 0x8484},
+		{read: true, val: 0x8383},
+		{read: true, val: 0x8484},
+		{val: 0x0404, idx: 1},
+		{read: true, val: 0x8484},
+		{read: true, val: 0x8383},
+		{val: 0, idx: 1},
+		{val: 1, idx: 0},
+		last,
+	} {
+		var err error
+		if s.read {
+			err = p.vendorRead(s.val)
+		} else {
+			err = p.vendorWrite(s.val, s.idx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readStatus reads the UART state notifications from the interrupt
+// endpoint until Close.
+func (p *pl2303) readStatus(r reader, size int) {
+	var ctx context.Context
+	ctx, p.cancel = context.WithCancel(context.Background())
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		buf := make([]byte, size)
+		for {
+			n, err := r.ReadContext(ctx, buf)
+			if err != nil {
+				return
+			}
+			if n > plStateIndex {
+				atomic.StoreUint32(&p.status, uint32(plModemStatus(buf[plStateIndex])))
+			}
+		}
+	}()
+}
+
+func plModemStatus(state byte) ModemStatus {
+	var s ModemStatus
+	for _, m := range []struct {
+		bit byte
+		s   ModemStatus
+	}{{plCTS, CTS}, {plDSR, DSR}, {plRI, RI}, {plDCD, DCD}} {
+		if state&m.bit != 0 {
+			s |= m.s
+		}
+	}
+	return s
+}
+
+// plEncodeBaudRate encodes the baud rate in the first 4 bytes of the line
+// coding. Standard rates are set directly, others with a divisor of the
+// 12MHz clock.
+func plEncodeBaudRate(b []byte, baud int) {
+	i := 0
+	for i < len(plBaudRates) && plBaudRates[i] < baud {
+		i++
+	}
+	if i < len(plBaudRates) && plBaudRates[i] == baud {
+		binary.LittleEndian.PutUint32(b, uint32(baud))
+		return
+	}
+	// baud = 12MHz * 32 / (mantissa * 4^exponent)
+	const baseline = 12000000 * 32
+	mantissa := baseline / baud
+	if mantissa == 0 {
+		mantissa = 1
+	}
+	exponent := 0
+	for mantissa >= 512 {
+		if exponent < 7 {
+			mantissa >>= 2
+			exponent++
+		} else {
+			mantissa = 511
+			break
+		}
+	}
+	b[3] = 0x80
+	b[2] = 0
+	b[1] = byte(exponent<<1 | mantissa>>8)
+	b[0] = byte(mantissa)
+}
+
+func (p *pl2303) SetMode(m Mode) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	max := plMaxBaudRate
+	if p.legacy {
+		max = plMaxBaudRateLegacy
+	}
+	if m.BaudRate > max {
+		return fmt.Errorf("baud rate %d above the maximum of %d", m.BaudRate, max)
+	}
+	var b [7]byte
+	plEncodeBaudRate(b[:4], m.BaudRate)
+	b[4] = byte(m.StopBits)
+	b[5] = byte(m.Parity)
+	b[6] = byte(m.dataBits())
+	return p.class(plSetLine, 0, b[:])
+}
+
+func (p *pl2303) SetFlowControl(f Flow) error {
+	var v uint16
+	switch {
+	case f == FlowNone:
+		v = plFlowNone
+	case f == FlowRTSCTS && p.legacy:
+		v = plFlowRTSCTSLegacy
+	case f == FlowRTSCTS:
+		v = plFlowRTSCTS
+	case f == FlowXonXoff && !p.legacy:
+		v = plFlowXonXoff
+	default:
+		return fmt.Errorf("PL2303 does not support flow control mode %d", f)
+	}
+	return p.vendorWrite(0, v)
+}
+
+func (p *pl2303) setLines(bit uint16, on bool) error {
+	lines := p.lines &^ bit
+	if on {
+		lines |= bit
+	}
+	if err := p.class(plSetControl, lines, nil); err != nil {
+		return err
+	}
+	p.lines = lines
+	return nil
+}
+
+func (p *pl2303) SetDTR(on bool) error {
+	return p.setLines(plDTR, on)
+}
+
+func (p *pl2303) SetRTS(on bool) error {
+	return p.setLines(plRTS, on)
+}
+
+func (p *pl2303) SetBreak(on bool) error {
+	var val uint16
+	if on {
+		val = 0xffff
+	}
+	return p.class(plBreak, val, nil)
+}
+
+// ModemStatus returns the state of the modem lines reported in the last
+// notification of the device.
+func (p *pl2303) ModemStatus() (ModemStatus, error) {
+	return ModemStatus(atomic.LoadUint32(&p.status)), nil
+}
+
+func (p *pl2303) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+		p.cancel = nil
+	}
+	return p.bulkPort.Close()
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/fakeusb"
+)
+
+func TestPL2303Init(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		ctl := newFakeControl(map[[2]uint16][]byte{
+			{plVendorRead, 0x8484}: {0},
+			{plVendorRead, 0x8383}: {0},
+		})
+		p := &pl2303{ctl: ctl, legacy: legacy}
+		if err := p.init(); err != nil {
+			t.Fatalf("legacy %v: init(): %v", legacy, err)
+		}
+		reqs := ctl.Requests()
+		if got := len(reqs); got != 11 {
+			t.Errorf("legacy %v: init(): got %d requests, want 11", legacy, got)
+			continue
+		}
+		want := fakeusb.Request{RType: uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlDevice), Request: plVendorWrite, Val: 2, Idx: 0x44}
+		if legacy {
+			want.Idx = 0x24
+		}
+		if got := reqs[10]; got != want {
+			t.Errorf("legacy %v: init(): last request %v, want %v", legacy, got, want)
+		}
+	}
+}
+
+func TestPL2303EncodeBaudRate(t *testing.T) {
+	for _, tc := range []struct {
+		baud int
+		want []byte
+	}{
+		{9600, []byte{0x80, 0x25, 0x00, 0x00}},
+		{6000000, []byte{0x80, 0x8d, 0x5b, 0x00}},
+		{500000, []byte{0xc0, 0x02, 0x00, 0x80}},
+		{250000, []byte{0x80, 0x03, 0x00, 0x80}},
+	} {
+		b := make([]byte, 4)
+		plEncodeBaudRate(b, tc.baud)
+		if !reflect.DeepEqual(b, tc.want) {
+			t.Errorf("plEncodeBaudRate(%d): got %x, want %x", tc.baud, b, tc.want)
+		}
+	}
+}
+
+func TestPL2303(t *testing.T) {
+	ctl := newFakeControl(nil)
+	p := &pl2303{ctl: ctl}
+	if err := p.SetMode(Mode{BaudRate: 115200, Parity: ParityEven, StopBits: StopBits15}); err != nil {
+		t.Fatalf("SetMode(): %v", err)
+	}
+	if err := p.SetDTR(true); err != nil {
+		t.Fatalf("SetDTR(): %v", err)
+	}
+	if err := p.SetRTS(true); err != nil {
+		t.Fatalf("SetRTS(): %v", err)
+	}
+	if err := p.SetFlowControl(FlowRTSCTS); err != nil {
+		t.Fatalf("SetFlowControl(): %v", err)
+	}
+	class := uint8(gousb.ControlOut | gousb.ControlClass | gousb.ControlInterface)
+	want := []fakeusb.Request{
+		{RType: class, Request: plSetLine, Data: "\x00\xc2\x01\x00\x01\x02\x08"},
+		{RType: class, Request: plSetControl, Val: plDTR},
+		{RType: class, Request: plSetControl, Val: plDTR | plRTS},
+		{RType: uint8(gousb.ControlOut | gousb.ControlVendor | gousb.ControlDevice), Request: plVendorWrite, Idx: plFlowRTSCTS},
+	}
+	if got := ctl.Requests(); !reflect.DeepEqual(got, want) {
+		t.Errorf("requests:\ngot  %q\nwant %q", got, want)
+	}
+	p.legacy = true
+	if err := p.SetMode(Mode{BaudRate: 3000000}); err == nil {
+		t.Error("SetMode(3000000 baud) on a legacy chip: got nil error, want non-nil")
+	}
+	if err := p.SetFlowControl(FlowXonXoff); err == nil {
+		t.Error("SetFlowControl(XON/XOFF) on a legacy chip: got nil error, want non-nil")
+	}
+}
+
+func TestPL2303Status(t *testing.T) {
+	p := &pl2303{}
+	p.readStatus(fakeusb.NewIn(
+		[]byte{0xa1, 0x20, 0, 0, 0, 0, 0x02, 0, plCTS | plDCD, 0},
+	), 10)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st, err := p.ModemStatus()
+		if err != nil {
+			t.Fatalf("ModemStatus(): %v", err)
+		}
+		if st == CTS|DCD {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ModemStatus(): got %s, want [CTS DCD]", st)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Close(): %v", err)
+	}
+}
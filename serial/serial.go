@@ -0,0 +1,365 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package serial provides drivers for USB serial converters behind a
+// common Port interface. Supported chips are the Silicon Labs CP210x,
+// the WCH CH340 and CH341, the Prolific PL2303 and the FTDI converters
+// supported by package ftdi.
+//
+// The driver is selected from the vendor and product IDs of the device:
+//
+//	devs, err := ctx.OpenDevices(serial.Match)
+//	...
+//	p, err := serial.Open(devs[0], 0)
+//	...
+//	defer p.Close()
+//	err = p.SetMode(serial.Mode{BaudRate: 115200, DataBits: 8})
+//	...
+//	_, err = p.Write([]byte("AT\r\n"))
+//
+// Drivers for other devices using one of the supported chips can be added
+// with Register.
+package serial
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+// Parity is the parity mode of the line.
+type Parity int
+
+// Parity modes.
+const (
+	ParityNone Parity = iota
+	ParityOdd
+	ParityEven
+	ParityMark
+	ParitySpace
+)
+
+// StopBits is the number of stop bits of the line.
+type StopBits int
+
+// Stop bits.
+const (
+	StopBits1 StopBits = iota
+	StopBits15
+	StopBits2
+)
+
+// Mode is the configuration of the line.
+type Mode struct {
+	// BaudRate is the rate of the line in bits per second.
+	BaudRate int
+	// DataBits is the number of data bits, 5 to 8. Zero means 8.
+	DataBits int
+	Parity   Parity
+	StopBits StopBits
+}
+
+func (m Mode) dataBits() int {
+	if m.DataBits == 0 {
+		return 8
+	}
+	return m.DataBits
+}
+
+// validate checks the common constraints on the mode.
+func (m Mode) validate() error {
+	if m.BaudRate <= 0 {
+		return fmt.Errorf("invalid baud rate %d", m.BaudRate)
+	}
+	if b := m.dataBits(); b < 5 || b > 8 {
+		return fmt.Errorf("invalid number of data bits %d", b)
+	}
+	if m.Parity < ParityNone || m.Parity > ParitySpace {
+		return fmt.Errorf("invalid parity %d", m.Parity)
+	}
+	if m.StopBits < StopBits1 || m.StopBits > StopBits2 {
+		return fmt.Errorf("invalid stop bits %d", m.StopBits)
+	}
+	return nil
+}
+
+// Flow is the flow control mode of the port.
+type Flow int
+
+// Flow control modes.
+const (
+	FlowNone Flow = iota
+	FlowRTSCTS
+	FlowDTRDSR
+	FlowXonXoff
+)
+
+// Default XON and XOFF characters.
+const (
+	xon  = 0x11
+	xoff = 0x13
+)
+
+// ModemStatus holds the state of the modem input lines.
+type ModemStatus uint8
+
+// Modem input lines.
+const (
+	CTS ModemStatus = 1 << iota
+	DSR
+	RI
+	DCD
+)
+
+func (s ModemStatus) String() string {
+	var names []string
+	for i, n := range []string{"CTS", "DSR", "RI", "DCD"} {
+		if s&(1<<uint(i)) != 0 {
+			names = append(names, n)
+		}
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// Port is a serial port of a USB serial converter.
+type Port interface {
+	// Read reads data received on the port. Read blocks until at least
+	// one byte is available.
+	Read(p []byte) (int, error)
+	// Write sends data on the port.
+	Write(p []byte) (int, error)
+	// Close releases the port.
+	Close() error
+
+	// SetMode sets the baud rate and framing of the line.
+	SetMode(Mode) error
+	// SetFlowControl sets the flow control mode. Not every chip supports
+	// every mode.
+	SetFlowControl(Flow) error
+	// SetDTR sets the DTR output line.
+	SetDTR(bool) error
+	// SetRTS sets the RTS output line.
+	SetRTS(bool) error
+	// SetBreak sets or clears the break condition.
+	SetBreak(bool) error
+	// ModemStatus returns the state of the modem input lines.
+	ModemStatus() (ModemStatus, error)
+}
+
+// Driver opens the serial ports of a family of chips.
+type Driver struct {
+	// Name identifies the driver, e.g. "cp210x".
+	Name string
+	// Open opens the port of the device with the given number, starting
+	// at 0.
+	Open func(dev *gousb.Device, port int) (Port, error)
+}
+
+type vidPID struct {
+	vid, pid gousb.ID
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[vidPID]*Driver{}
+)
+
+// Register associates the driver with a vendor and product ID. It replaces
+// any driver previously registered for the same IDs.
+func Register(vid, pid gousb.ID, d *Driver) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[vidPID{vid, pid}] = d
+}
+
+func register(d *Driver, ids ...vidPID) {
+	for _, id := range ids {
+		Register(id.vid, id.pid, d)
+	}
+}
+
+// Lookup returns the driver registered for the device, or nil.
+func Lookup(desc *gousb.DeviceDesc) *Driver {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[vidPID{desc.Vendor, desc.Product}]
+}
+
+// Match returns true if a driver is registered for the device. It can be
+// used with gousb.Context.OpenDevices.
+func Match(desc *gousb.DeviceDesc) bool {
+	return Lookup(desc) != nil
+}
+
+// Open opens a serial port of the device with the driver registered for
+// its vendor and product ID. Ports are numbered from 0, most devices have
+// a single port.
+func Open(dev *gousb.Device, port int) (Port, error) {
+	d := Lookup(dev.Desc)
+	if d == nil {
+		return nil, fmt.Errorf("no serial driver for device %s", dev)
+	}
+	p, err := d.Open(dev, port)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", d.Name, err)
+	}
+	return p, nil
+}
+
+// controller carries the vendor or class requests that set the line
+// parameters and the modem lines of a port. *gousb.Device implements
+// controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// reader receives the serial data of a port, which the drivers pass on
+// as is. *gousb.ReadStream implements reader.
+type reader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// writer sends the serial data of a port. *gousb.OutEndpoint implements
+// writer.
+type writer interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Parameters of the read stream of the bulk IN endpoint.
+const (
+	readPackets   = 8
+	readTransfers = 4
+)
+
+// bulkPort implements the data transfer of the drivers on a pair of bulk
+// endpoints.
+type bulkPort struct {
+	r       reader
+	w       writer
+	release func()
+}
+
+// Read reads data received on the port.
+func (p *bulkPort) Read(b []byte) (int, error) {
+	return p.r.ReadContext(context.Background(), b)
+}
+
+// ReadContext reads data received on the port. The context controls
+// the cancellation of this particular read.
+func (p *bulkPort) ReadContext(ctx context.Context, b []byte) (int, error) {
+	return p.r.ReadContext(ctx, b)
+}
+
+// Write sends data on the port.
+func (p *bulkPort) Write(b []byte) (int, error) {
+	return p.w.WriteContext(context.Background(), b)
+}
+
+// WriteContext sends data on the port. The context controls
+// the cancellation of this particular write.
+func (p *bulkPort) WriteContext(ctx context.Context, b []byte) (int, error) {
+	return p.w.WriteContext(ctx, b)
+}
+
+// Close releases the interface of the port.
+func (p *bulkPort) Close() error {
+	if p.release != nil {
+		p.release()
+		p.release = nil
+	}
+	return nil
+}
+
+// usbPort holds the claimed interface of a port.
+type usbPort struct {
+	bulkPort
+	// intf is the number of the interface.
+	intf int
+	// status is the interrupt IN endpoint of the interface, if any.
+	status *gousb.InEndpoint
+	// statusDesc describes the interrupt IN endpoint.
+	statusDesc *gousb.EndpointDesc
+}
+
+// openInterface claims the interface with the given number in the active
+// configuration and opens its bulk endpoints, and its interrupt IN endpoint
+// if present.
+func openInterface(dev *gousb.Device, num int) (*usbPort, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	alt, in, out, intr, err := findEndpoints(desc, num)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	cfg, intf, err := claim.OpenInterface(dev, cfgNum, alt)
+	if err != nil {
+		return nil, err
+	}
+	inEP, outEP, err := claim.Endpoints(intf, in, out)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	p := &usbPort{intf: num, statusDesc: intr}
+	if intr != nil {
+		if p.status, err = intf.InEndpoint(intr.Number); err != nil {
+			cfg.Close()
+			return nil, err
+		}
+	}
+	rs, err := inEP.NewStream(readPackets*in.MaxPacketSize, readTransfers)
+	if err != nil {
+		cfg.Close()
+		return nil, err
+	}
+	p.r, p.w = rs, outEP
+	p.release = func() {
+		rs.Close()
+		cfg.Close()
+	}
+	return p, nil
+}
+
+// findEndpoints returns the first setting of the interface, its bulk
+// endpoints and its interrupt IN endpoint, if any.
+func findEndpoints(desc gousb.ConfigDesc, num int) (alt *gousb.InterfaceSetting, in, out, intr *gousb.EndpointDesc, err error) {
+	for _, intf := range desc.Interfaces {
+		if intf.Number != num || len(intf.AltSettings) == 0 {
+			continue
+		}
+		alt = &intf.AltSettings[0]
+		for _, ep := range alt.Endpoints {
+			ep := ep
+			switch {
+			case ep.TransferType == gousb.TransferTypeBulk && ep.Direction == gousb.EndpointDirectionIn:
+				in = &ep
+			case ep.TransferType == gousb.TransferTypeBulk:
+				out = &ep
+			case ep.TransferType == gousb.TransferTypeInterrupt && ep.Direction == gousb.EndpointDirectionIn:
+				intr = &ep
+			}
+		}
+		if in == nil || out == nil {
+			return nil, nil, nil, nil, fmt.Errorf("interface %d has no bulk IN and OUT endpoints", num)
+		}
+		return alt, in, out, intr, nil
+	}
+	return nil, nil, nil, nil, fmt.Errorf("%s has no interface %d", desc, num)
+}
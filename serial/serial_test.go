@@ -0,0 +1,104 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serial
+
+import (
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+func TestLookup(t *testing.T) {
+	for _, tc := range []struct {
+		vid, pid gousb.ID
+		want     *Driver
+	}{
+		{0x10c4, 0xea60, cp210xDriver},
+		{0x1a86, 0x7523, ch34xDriver},
+		{0x067b, 0x2303, pl2303Driver},
+		{0x0403, 0x6001, ftdiDriver},
+		{0x0403, 0x6014, ftdiDriver},
+		{0x1234, 0x5678, nil},
+	} {
+		desc := &gousb.DeviceDesc{Vendor: tc.vid, Product: tc.pid}
+		if got := Lookup(desc); got != tc.want {
+			t.Errorf("Lookup(%s:%s): got %v, want %v", tc.vid, tc.pid, got, tc.want)
+		}
+		if got := Match(desc); got != (tc.want != nil) {
+			t.Errorf("Match(%s:%s): got %v, want %v", tc.vid, tc.pid, got, tc.want != nil)
+		}
+	}
+	d := &Driver{Name: "custom"}
+	Register(0x1234, 0x5678, d)
+	defer func() {
+		registryMu.Lock()
+		delete(registry, vidPID{0x1234, 0x5678})
+		registryMu.Unlock()
+	}()
+	if got := Lookup(&gousb.DeviceDesc{Vendor: 0x1234, Product: 0x5678}); got != d {
+		t.Errorf("Lookup() of a registered device: got %v, want %v", got, d)
+	}
+}
+
+func TestModeValidate(t *testing.T) {
+	for _, tc := range []struct {
+		m       Mode
+		wantErr bool
+	}{
+		{Mode{BaudRate: 9600}, false},
+		{Mode{BaudRate: 9600, DataBits: 5, Parity: ParitySpace, StopBits: StopBits2}, false},
+		{Mode{}, true},
+		{Mode{BaudRate: 9600, DataBits: 9}, true},
+		{Mode{BaudRate: 9600, Parity: ParitySpace + 1}, true},
+		{Mode{BaudRate: 9600, StopBits: -1}, true},
+	} {
+		if err := tc.m.validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%+v.validate(): got error %v, want error: %v", tc.m, err, tc.wantErr)
+		}
+	}
+}
+
+func TestFindEndpoints(t *testing.T) {
+	ep := func(addr gousb.EndpointAddress, tt gousb.TransferType) gousb.EndpointDesc {
+		dir := gousb.EndpointDirectionOut
+		if addr&0x80 != 0 {
+			dir = gousb.EndpointDirectionIn
+		}
+		return gousb.EndpointDesc{Address: addr, Number: int(addr & 0x0f), Direction: dir, TransferType: tt, MaxPacketSize: 64}
+	}
+	cfg := gousb.ConfigDesc{Number: 1, Interfaces: []gousb.InterfaceDesc{{
+		Number: 0,
+		AltSettings: []gousb.InterfaceSetting{{
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{
+				0x81: ep(0x81, gousb.TransferTypeInterrupt),
+				0x02: ep(0x02, gousb.TransferTypeBulk),
+				0x83: ep(0x83, gousb.TransferTypeBulk),
+			},
+		}},
+	}}}
+	alt, in, out, intr, err := findEndpoints(cfg, 0)
+	if err != nil {
+		t.Fatalf("findEndpoints(): %v", err)
+	}
+	if in.Address != 0x83 || out.Address != 0x02 || intr == nil || intr.Address != 0x81 {
+		t.Errorf("findEndpoints(): got %s, %s, %v, want 0x83, 0x02, 0x81", in.Address, out.Address, intr)
+	}
+	if alt.Number != 0 || alt.Alternate != 0 {
+		t.Errorf("findEndpoints(): got setting %d/%d, want 0/0", alt.Number, alt.Alternate)
+	}
+	if _, _, _, _, err := findEndpoints(cfg, 1); err == nil {
+		t.Error("findEndpoints(1): got nil error, want non-nil")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the buckets of the latency
+// histogram, the last bucket has no upper bound.
+var latencyBounds = [...]time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	time.Second,
+}
+
+// LatencyBucket is a bucket of a latency histogram.
+type LatencyBucket struct {
+	// UpTo is the upper bound of the latencies counted in the bucket.
+	// It is math.MaxInt64 for the last bucket.
+	UpTo time.Duration
+	// Count is the number of transfers in the bucket.
+	Count int
+}
+
+// TransferStats are the statistics of the transfers of an endpoint or
+// a stream, counted since it was opened.
+type TransferStats struct {
+	// Transfers is the number of completed transfers, including the
+	// transfers that ended with an error.
+	Transfers int
+	// Bytes is the number of bytes read or written.
+	Bytes int64
+	// ShortTransfers is the number of transfers completed successfully
+	// with less data than the length of the transfer.
+	ShortTransfers int
+	// Errors is the number of transfers that ended with each status other
+	// than TransferCompleted, e.g. TransferCancelled.
+	Errors map[TransferStatus]int
+	// Stalled is the total time spent waiting for transfers to complete,
+	// e.g. in ReadStream.Read waiting for data, or in WriteStream.Write
+	// waiting for a free transfer.
+	Stalled time.Duration
+	// Latency is the histogram of the time between the submission of the
+	// transfers and their completion, in increasing order of UpTo. The
+	// completion is timed when libusb reports it, not when the result
+	// is collected.
+	Latency []LatencyBucket
+}
+
+// String returns a human-readable summary of the statistics.
+func (s TransferStats) String() string {
+	ret := []string{fmt.Sprintf("%d transfers, %d bytes, %d short, stalled %v", s.Transfers, s.Bytes, s.ShortTransfers, s.Stalled)}
+	for st := TransferCompleted; st <= TransferOverflow; st++ {
+		if n := s.Errors[st]; n > 0 {
+			ret = append(ret, fmt.Sprintf("%d %s", n, st))
+		}
+	}
+	return strings.Join(ret, ", ")
+}
+
+// transferStats collects the statistics of transfers. The zero value is
+// ready to use.
+type transferStats struct {
+	mu        sync.Mutex
+	transfers int
+	bytes     int64
+	short     int
+	errors    map[TransferStatus]int
+	stalled   time.Duration
+	latency   [len(latencyBounds) + 1]int
+}
+
+// add records a completed transfer of length bytes that read or wrote
+// n bytes, with the given status, after latency since its submission,
+// of which the caller was blocked for stalled.
+func (s *transferStats) add(n, length int, status TransferStatus, latency, stalled time.Duration) {
+	b := 0
+	for b < len(latencyBounds) && latency > latencyBounds[b] {
+		b++
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transfers++
+	s.bytes += int64(n)
+	if status != TransferCompleted {
+		if s.errors == nil {
+			s.errors = make(map[TransferStatus]int)
+		}
+		s.errors[status]++
+	} else if n < length {
+		s.short++
+	}
+	s.stalled += stalled
+	s.latency[b]++
+}
+
+// snapshot returns a copy of the statistics.
+func (s *transferStats) snapshot() TransferStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := TransferStats{
+		Transfers:      s.transfers,
+		Bytes:          s.bytes,
+		ShortTransfers: s.short,
+		Errors:         make(map[TransferStatus]int, len(s.errors)),
+		Stalled:        s.stalled,
+		Latency:        make([]LatencyBucket, len(s.latency)),
+	}
+	for st, n := range s.errors {
+		ret.Errors[st] = n
+	}
+	for i, n := range s.latency {
+		upTo := time.Duration(math.MaxInt64)
+		if i < len(latencyBounds) {
+			upTo = latencyBounds[i]
+		}
+		ret.Latency[i] = LatencyBucket{UpTo: upTo, Count: n}
+	}
+	return ret
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTransferStats(t *testing.T) {
+	var s transferStats
+	for _, tc := range []struct {
+		n, length        int
+		status           TransferStatus
+		latency, stalled time.Duration
+	}{
+		{n: 512, length: 512, latency: 50 * time.Microsecond},
+		{n: 100, length: 512, latency: 100 * time.Microsecond, stalled: 100 * time.Microsecond},
+		{n: 512, length: 512, latency: 3 * time.Millisecond, stalled: time.Millisecond},
+		{n: 10, length: 512, status: TransferTimedOut, latency: time.Second, stalled: time.Second},
+		{status: TransferCancelled, latency: time.Minute},
+	} {
+		s.add(tc.n, tc.length, tc.status, tc.latency, tc.stalled)
+	}
+	got := s.snapshot()
+	latency := make([]int, len(got.Latency))
+	for i, b := range got.Latency {
+		latency[i] = b.Count
+	}
+	if got, want := latency, []int{2, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Latency counts: got %v, want %v", got, want)
+	}
+	if got, want := got.Latency[0].UpTo, 100*time.Microsecond; got != want {
+		t.Errorf("Latency[0].UpTo: got %v, want %v", got, want)
+	}
+	if got, want := got.Latency[len(got.Latency)-1].UpTo, time.Duration(math.MaxInt64); got != want {
+		t.Errorf("last Latency bucket UpTo: got %v, want %v", got, want)
+	}
+	got.Latency = nil
+	want := TransferStats{
+		Transfers:      5,
+		Bytes:          1134,
+		ShortTransfers: 1,
+		Errors:         map[TransferStatus]int{TransferTimedOut: 1, TransferCancelled: 1},
+		Stalled:        time.Second + 1100*time.Microsecond,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot(): got %+v, want %+v", got, want)
+	}
+	if got, want := got.String(), "5 transfers, 1134 bytes, 1 short, stalled 1.0011s, 1 transfer timed out, 1 transfer was cancelled"; got != want {
+		t.Errorf("String(): got %q, want %q", got, want)
+	}
+}
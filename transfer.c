@@ -14,6 +14,7 @@
 // limitations under the License.
 
 #include <libusb.h>
+#include <stdint.h>
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
@@ -76,13 +77,45 @@ int gousb_compact_iso_data(struct libusb_transfer *xfer, unsigned char *status)
 	return sum;
 }
 
-// allocates a libusb transfer and a buffer for packet data.
-struct libusb_transfer *gousb_alloc_transfer_and_buffer(int bufLen, int isoPackets) {
+// fills lens with the actual lengths of the packets of an isochronous
+// transfer compacted by gousb_compact_iso_data, i.e. of the packets up to
+// the first one with an error, and returns the number of these packets.
+int gousb_iso_packet_lengths(struct libusb_transfer *xfer, int *lens) {
+	int i;
+	for (i = 0; i < xfer->num_iso_packets; i++) {
+		struct libusb_iso_packet_descriptor pkt = xfer->iso_packet_desc[i];
+		if (pkt.status != 0) {
+			break;
+		}
+		lens[i] = pkt.actual_length;
+	}
+	return i;
+}
+
+// allocates a libusb transfer and a buffer for packet data. If the device
+// supports it, the buffer is allocated with libusb_dev_mem_alloc, letting
+// the kernel transfer the data directly from and to the buffer. The length
+// of such a buffer is kept in user_data, to be passed to
+// libusb_dev_mem_free.
+struct libusb_transfer *gousb_alloc_transfer_and_buffer(libusb_device_handle *dev, int bufLen, int isoPackets) {
         struct libusb_transfer *xfer = libusb_alloc_transfer(isoPackets);
         if (xfer == NULL) {
                 return NULL;
         }
-        xfer->buffer = (unsigned char*)malloc(bufLen);
+        xfer->buffer = NULL;
+        xfer->user_data = NULL;
+#if LIBUSB_API_VERSION >= 0x01000105
+        if (dev != NULL && bufLen > 0) {
+                xfer->buffer = libusb_dev_mem_alloc(dev, bufLen);
+                if (xfer->buffer != NULL) {
+                        xfer->user_data = (void*)(intptr_t)bufLen;
+                }
+        }
+#endif
+        if (xfer->buffer == NULL) {
+                // malloc(0) may return NULL, zero-length transfers still need a buffer.
+                xfer->buffer = (unsigned char*)malloc(bufLen > 0 ? bufLen : 1);
+        }
         if (xfer->buffer == NULL) {
                 libusb_free_transfer(xfer);
                 return NULL;
@@ -91,9 +124,26 @@ struct libusb_transfer *gousb_alloc_transfer_and_buffer(int bufLen, int isoPacke
         return xfer;
 }
 
+// sets the lengths of the first n packets of an isochronous transfer and
+// submits only these packets. n must not exceed the number of packets
+// the transfer was allocated with.
+void gousb_set_iso_packet_lengths(struct libusb_transfer *xfer, int *lens, int n) {
+	int i;
+	for (i = 0; i < n; i++) {
+		xfer->iso_packet_desc[i].length = lens[i];
+	}
+	xfer->num_iso_packets = n;
+}
+
 // frees a libusb transfer and its buffer. The buffer of the given
 // libusb_transfer must have been allocated with alloc_transfer_and_buffer.
 void gousb_free_transfer_and_buffer(struct libusb_transfer *xfer) {
+#if LIBUSB_API_VERSION >= 0x01000105
+        if (xfer->user_data != NULL) {
+                libusb_dev_mem_free(xfer->dev_handle, xfer->buffer, (size_t)(intptr_t)xfer->user_data);
+                xfer->buffer = NULL;
+        }
+#endif
         free(xfer->buffer);
         xfer->length = 0;
         libusb_free_transfer(xfer);
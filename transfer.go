@@ -17,8 +17,10 @@ package gousb
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime"
 	"sync"
+	"time"
 )
 
 type usbTransfer struct {
@@ -30,13 +32,32 @@ type usbTransfer struct {
 	// is allocated by the C code, both buf and xfer.buffer point to the same
 	// memory.
 	buf []byte
-	// done is blocking until the transfer is complete and data and transfer
-	// status are available.
-	done chan struct{}
+	// done receives the time of the completion of the transfer, once
+	// data and transfer status are available.
+	done chan time.Time
 	// submitted is true if submit() was called on this transfer.
 	submitted bool
 	// ctx is the Context that created this transfer.
 	ctx *Context
+	// isoPackets and isoPktSize describe the packet layout of an
+	// isochronous transfer buffer.
+	isoPackets, isoPktSize int
+	// timeout is the timeout the transfer was allocated with.
+	timeout time.Duration
+	// length is the number of bytes to be read or written by the transfer.
+	length int
+	// packets are the lengths of the packets of the data of the last
+	// completed isochronous transfer.
+	packets []int
+	// stats are updated when the transfer completes.
+	stats []*transferStats
+	// submitTime is the time of the last submit(), if stats are set.
+	submitTime time.Time
+	// completed is the time of the last completion collected by wait().
+	completed time.Time
+	// set tracks the transfer until it's freed, nil if the transfer
+	// doesn't belong to an interface.
+	set *transferSet
 }
 
 // submits the transfer. After submit() the transfer is in flight and is owned by libusb.
@@ -48,9 +69,20 @@ func (t *usbTransfer) submit() error {
 	if t.submitted {
 		return errors.New("transfer was already submitted and is not finished yet")
 	}
+	if err := t.set.add(t); err != nil {
+		return err
+	}
+	if t.xfer == nil {
+		t.set.remove(t)
+		return errors.New("submit() called on a freed transfer")
+	}
 	if err := t.ctx.libusb.submit(t.xfer); err != nil {
+		t.set.remove(t)
 		return err
 	}
+	if len(t.stats) > 0 {
+		t.submitTime = time.Now()
+	}
 	t.submitted = true
 	return nil
 }
@@ -60,26 +92,55 @@ func (t *usbTransfer) submit() error {
 // via t.buf. The number returned by wait indicates how many bytes
 // of the buffer were read or written by libusb, and it can be
 // smaller than the length of t.buf.
+// If the interface of the transfer is closed, the transfer is cancelled
+// and wait returns ErrInterfaceClosed.
 func (t *usbTransfer) wait(ctx context.Context) (n int, err error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	if !t.submitted {
+		if t.set.isClosed() {
+			return 0, ErrInterfaceClosed
+		}
 		return 0, nil
 	}
+	var start, completed time.Time
+	if len(t.stats) > 0 {
+		start = time.Now()
+	}
 	select {
 	case <-ctx.Done():
 		t.ctx.libusb.cancel(t.xfer)
 		// after the transfer is cancelled, it will run a callback
 		// that triggers the activation of t.done.
-		<-t.done
-	case <-t.done:
+		completed = <-t.done
+	case <-t.set.closing():
+		t.ctx.libusb.cancel(t.xfer)
+		completed = <-t.done
+		err = ErrInterfaceClosed
+	case completed = <-t.done:
 	}
 	t.submitted = false
+	t.completed = completed
+	t.set.remove(t)
 	n, status := t.ctx.libusb.data(t.xfer)
+	if t.isoPackets > 0 {
+		t.packets = t.ctx.libusb.packetLengths(t.xfer)
+	}
+	if len(t.stats) > 0 {
+		// The latency ends with the completion, which may precede
+		// the call to wait by a long time.
+		stalled := time.Since(start)
+		for _, s := range t.stats {
+			s.add(n, t.length, status, completed.Sub(t.submitTime), stalled)
+		}
+	}
+	if err != nil {
+		return n, err
+	}
 	if status != TransferCompleted {
 		return n, status
 	}
-	return n, err
+	return n, nil
 }
 
 // cancel aborts a submitted transfer. The transfer is cancelled
@@ -98,6 +159,22 @@ func (t *usbTransfer) cancel() error {
 	return err
 }
 
+// abort cancels a submitted transfer whose completion is not collected
+// yet and waits for the completion. The next wait() of the owner then
+// returns ErrInterfaceClosed. A transfer that is not submitted is left
+// as is.
+func (t *usbTransfer) abort() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.submitted {
+		return
+	}
+	t.ctx.libusb.cancel(t.xfer)
+	<-t.done
+	t.submitted = false
+	t.set.remove(t)
+}
+
 // free releases the memory allocated for the transfer.
 // free should be called only if the transfer is not used by libusb,
 // i.e. it should not be called after submit() and before wait() returns.
@@ -107,53 +184,131 @@ func (t *usbTransfer) free() error {
 	if t.submitted {
 		return errors.New("free() cannot be called on a submitted transfer until wait() returns")
 	}
+	t.freeLocked()
+	return nil
+}
+
+func (t *usbTransfer) freeLocked() {
 	if t.xfer == nil {
-		return nil
+		return
 	}
 	t.ctx.libusb.free(t.xfer)
 	t.xfer = nil
 	t.buf = nil
 	t.done = nil
-	return nil
+	t.set.disown(t)
+}
+
+// setLength sets the number of bytes of the buffer that will be sent
+// by the next submit() of an OUT transfer. Isochronous transfers are split
+// into as many packets as needed to carry n bytes.
+func (t *usbTransfer) setLength(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.submitted || t.xfer == nil {
+		return
+	}
+	t.length = n
+	var pkts []int
+	if t.isoPackets > 0 {
+		pkts = isoPacketLengths(n, t.isoPktSize)
+	}
+	t.ctx.libusb.setLength(t.xfer, n, pkts)
+}
+
+// isoPacketLengths returns the lengths of the isochronous packets carrying
+// n bytes in packets of pktSize bytes. The last packet carries the rest of
+// the data, n == 0 results in a single zero-length packet.
+func isoPacketLengths(n, pktSize int) []int {
+	if n == 0 {
+		return []int{0}
+	}
+	ret := make([]int, 0, (n+pktSize-1)/pktSize)
+	for ; n > pktSize; n -= pktSize {
+		ret = append(ret, pktSize)
+	}
+	return append(ret, n)
+}
+
+// setZeroPacket sets whether the next submit() of an OUT transfer ends
+// with a zero-length packet if its length is a multiple of the maximum
+// packet size of the endpoint.
+func (t *usbTransfer) setZeroPacket(zlp bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.submitted || t.xfer == nil {
+		return
+	}
+	t.ctx.libusb.setZeroPacket(t.xfer, zlp)
+}
+
+// completionTime returns the time of the completion of the transfer
+// collected by the last wait(), as received by the completion callback.
+func (t *usbTransfer) completionTime() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.completed
+}
+
+// packetLengths returns the lengths of the packets of the data of the last
+// completed isochronous transfer, nil for other transfers.
+func (t *usbTransfer) packetLengths() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.packets
 }
 
 // data returns the slice containing transfer buffer.
 func (t *usbTransfer) data() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.buf
 }
 
 // newUSBTransfer allocates a new transfer structure and a new buffer for
-// communication with a given device/endpoint.
-func newUSBTransfer(ctx *Context, dev *libusbDevHandle, ei *EndpointDesc, bufLen int) (*usbTransfer, error) {
+// communication with a given device/endpoint. A transfer not finished
+// within the timeout ends with TransferTimedOut, 0 means no timeout.
+// If devMem is true and the platform supports it, the buffer is allocated
+// in the device memory, see libusb_dev_mem_alloc. Such a transfer must be
+// freed before the device is closed.
+func newUSBTransfer(ctx *Context, dev *libusbDevHandle, ei *EndpointDesc, bufLen int, timeout time.Duration, devMem bool) (*usbTransfer, error) {
 	var isoPackets, isoPktSize int
 	if ei.TransferType == TransferTypeIsochronous {
 		isoPktSize = ei.MaxPacketSize
 		if bufLen < isoPktSize {
 			isoPktSize = bufLen
 		}
-		if isoPktSize > 0 {
-			isoPackets = bufLen / isoPktSize
-		} else {
+		switch {
+		case bufLen == 0:
 			isoPackets = 1
+		case isoPktSize <= 0:
+			return nil, fmt.Errorf("isochronous transfer of %d bytes on endpoint with max packet size %d", bufLen, ei.MaxPacketSize)
+		default:
+			isoPackets = (bufLen + isoPktSize - 1) / isoPktSize
 		}
-		debug.Printf("New isochronous transfer - buffer length %d, using %d packets of %d bytes each", bufLen, isoPackets, isoPktSize)
+		debug.Printf("New isochronous transfer - buffer length %d, using %d packets of up to %d bytes each", bufLen, isoPackets, isoPktSize)
 	}
 
-	done := make(chan struct{}, 1)
-	xfer, err := ctx.libusb.alloc(dev, ei, isoPackets, bufLen, done)
+	done := make(chan time.Time, 1)
+	xfer, err := ctx.libusb.alloc(dev, ei, timeout, isoPackets, bufLen, devMem, done)
 	if err != nil {
 		return nil, err
 	}
 
-	if ei.TransferType == TransferTypeIsochronous {
-		ctx.libusb.setIsoPacketLengths(xfer, uint32(isoPktSize))
+	if isoPackets > 0 {
+		ctx.libusb.setLength(xfer, bufLen, isoPacketLengths(bufLen, isoPktSize))
 	}
 
+	buf := ctx.libusb.buffer(xfer)
 	t := &usbTransfer{
-		xfer: xfer,
-		buf:  ctx.libusb.buffer(xfer),
-		done: done,
-		ctx:  ctx,
+		xfer:       xfer,
+		buf:        buf,
+		done:       done,
+		ctx:        ctx,
+		isoPackets: isoPackets,
+		isoPktSize: isoPktSize,
+		timeout:    timeout,
+		length:     len(buf),
 	}
 	runtime.SetFinalizer(t, func(t *usbTransfer) {
 		t.cancel()
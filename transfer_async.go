@@ -0,0 +1,245 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Transfer is a transfer on an endpoint that is submitted asynchronously,
+// allowing many transfers on many endpoints to be in flight at the same
+// time, e.g. managed from a single goroutine. A Transfer is allocated once
+// with NewTransfer and can be submitted any number of times, a new
+// submission can be made as soon as the previous one is complete, also
+// from the completion callback.
+// The transfer should be freed with Free once no longer needed. Closing
+// the interface of the endpoint frees the transfer as well, its buffer
+// must not be used afterwards.
+type Transfer struct {
+	ep *endpoint
+	t  *usbTransfer
+
+	// mu protects the fields below.
+	mu sync.Mutex
+	// pending is true between Submit and the completion of the transfer.
+	pending bool
+	// cancel cancels the pending transfer.
+	cancel context.CancelFunc
+	// finished is closed when the pending transfer is complete.
+	finished chan struct{}
+	// n, err and completed are the result of the last completed transfer.
+	n         int
+	err       error
+	completed time.Time
+	// freed is true after Free.
+	freed bool
+}
+
+func (e *endpoint) newTransfer(size int) (*Transfer, error) {
+	t, err := e.allocTransfer(size, e.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	t.stats = []*transferStats{&e.stats}
+	return &Transfer{ep: e, t: t}, nil
+}
+
+// NewTransfer allocates a transfer reading up to size bytes from the
+// endpoint. The transfer uses the Timeout of the endpoint.
+func (e *InEndpoint) NewTransfer(size int) (*Transfer, error) {
+	return e.newTransfer(size)
+}
+
+// NewTransfer allocates a transfer writing up to size bytes to the
+// endpoint. The transfer uses the Timeout of the endpoint.
+func (e *OutEndpoint) NewTransfer(size int) (*Transfer, error) {
+	return e.newTransfer(size)
+}
+
+// errTransferFreed is returned by the methods of a freed Transfer.
+var errTransferFreed = errors.New("transfer was freed")
+
+// errTransferPending is returned by the methods of a Transfer that are
+// not allowed while it's in flight.
+var errTransferPending = errors.New("transfer was already submitted and is not finished yet")
+
+// Buffer returns the buffer of the transfer. The data to write is placed
+// in the buffer before Submit, and the data read can be retrieved from it
+// after the transfer is complete, see also Data. The buffer is allocated
+// in the device memory where the platform supports it, so that the data
+// doesn't need to be copied. The buffer must not be accessed while the
+// transfer is in flight, nor after the transfer was freed, and Buffer
+// returns nil then.
+func (t *Transfer) Buffer() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.freed {
+		return nil
+	}
+	return t.t.data()
+}
+
+// SetLength sets the number of bytes of the buffer to be written or read
+// by the next submission, at most the size of the transfer.
+func (t *Transfer) SetLength(n int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.freed:
+		return errTransferFreed
+	case t.pending:
+		return errTransferPending
+	case t.ep.transfers.isClosed():
+		return ErrInterfaceClosed
+	case n < 0 || n > len(t.t.data()):
+		return errors.New("transfer length out of range")
+	}
+	t.t.setLength(n)
+	return nil
+}
+
+// Submit submits the transfer and returns immediately. Once the transfer
+// is complete, the Transfer is sent on done, if not nil. The send blocks
+// until it is received, so done should be buffered or continuously
+// drained.
+func (t *Transfer) Submit(done chan<- *Transfer) error {
+	return t.SubmitFunc(func(t *Transfer) {
+		if done != nil {
+			done <- t
+		}
+	})
+}
+
+// SubmitFunc submits the transfer and returns immediately. Once the
+// transfer is complete, f is called with the Transfer in a separate
+// goroutine. f can inspect the result and resubmit the transfer.
+func (t *Transfer) SubmitFunc(f func(*Transfer)) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.freed {
+		return errTransferFreed
+	}
+	if t.pending {
+		return errTransferPending
+	}
+	if err := t.t.submit(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	t.pending, t.cancel, t.finished = true, cancel, finished
+	t.n, t.err, t.completed = 0, nil, time.Time{}
+	go func() {
+		n, err := t.t.wait(ctx)
+		cancel()
+		t.mu.Lock()
+		t.n, t.err, t.completed = n, err, t.t.completionTime()
+		t.pending = false
+		close(finished)
+		t.mu.Unlock()
+		if f != nil {
+			f(t)
+		}
+	}()
+	return nil
+}
+
+// Cancel aborts the transfer in flight. The transfer completes as usual,
+// with TransferCancelled unless it finished in the meantime. Cancel does
+// nothing if the transfer is not in flight.
+func (t *Transfer) Cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending {
+		t.cancel()
+	}
+}
+
+// Wait waits for the transfer in flight to complete and returns its
+// result, like Result. If ctx is done first, the transfer is cancelled
+// and Wait returns once the cancellation is complete.
+func (t *Transfer) Wait(ctx context.Context) (int, error) {
+	t.mu.Lock()
+	finished := t.finished
+	t.mu.Unlock()
+	if finished != nil {
+		select {
+		case <-finished:
+		case <-ctx.Done():
+			t.Cancel()
+			<-finished
+		}
+	}
+	return t.Result()
+}
+
+// Result returns the result of the last completed submission of the
+// transfer: the number of bytes read or written and the error, e.g.
+// the TransferStatus if the transfer didn't complete successfully.
+// The number of bytes can be non-zero even if the error is not nil.
+func (t *Transfer) Result() (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.n, t.err
+}
+
+// CompletionTime returns the time of the completion of the last completed
+// submission of the transfer, taken when libusb reported the completion,
+// before the callback of SubmitFunc is scheduled.
+func (t *Transfer) CompletionTime() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.completed
+}
+
+// Data returns the part of the buffer read by the last completed
+// submission of the transfer.
+func (t *Transfer) Data() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.freed || t.pending {
+		return nil
+	}
+	b := t.t.data()
+	if b == nil {
+		// Freed by the close of the interface.
+		return nil
+	}
+	return b[:t.n]
+}
+
+// Endpoint returns the description of the endpoint of the transfer.
+func (t *Transfer) Endpoint() EndpointDesc {
+	return t.ep.Desc
+}
+
+// Free releases the memory of the transfer. It must not be called while
+// the transfer is in flight. Free does nothing if the transfer was already
+// freed by the close of the interface.
+func (t *Transfer) Free() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.freed {
+		return nil
+	}
+	if t.pending {
+		return errTransferPending
+	}
+	t.freed = true
+	return t.t.free()
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestTransferAsync(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	in := &InEndpoint{&endpoint{ctx: ctx, Desc: EndpointDesc{
+		Address:       0x82,
+		Number:        2,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}}}
+	out := &OutEndpoint{endpoint: &endpoint{ctx: ctx, Desc: EndpointDesc{
+		Address:       0x01,
+		Number:        1,
+		Direction:     EndpointDirectionOut,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}}}
+	rx, err := in.NewTransfer(512)
+	if err != nil {
+		t.Fatalf("%s.NewTransfer(512): %v", in, err)
+	}
+	tx, err := out.NewTransfer(512)
+	if err != nil {
+		t.Fatalf("%s.NewTransfer(512): %v", out, err)
+	}
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			fakeT := lib.waitForSubmitted(nil)
+			if fakeT.ep.Direction == EndpointDirectionIn {
+				fakeT.setData([]byte{1, 2, 3})
+			} else {
+				fakeT.setLength(len(fakeT.buf))
+			}
+			fakeT.setStatus(TransferCompleted)
+		}
+	}()
+
+	// Both transfers in flight, completions delivered on one channel.
+	done := make(chan *Transfer, 2)
+	if err := tx.SetLength(10); err != nil {
+		t.Fatalf("tx.SetLength(10): %v", err)
+	}
+	if err := tx.Submit(done); err != nil {
+		t.Fatalf("tx.Submit(): %v", err)
+	}
+	if err := rx.Submit(done); err != nil {
+		t.Fatalf("rx.Submit(): %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		switch xfr := <-done; xfr {
+		case rx:
+			if n, err := xfr.Result(); n != 3 || err != nil {
+				t.Errorf("rx.Result(): got %d, %v, want 3, nil", n, err)
+			}
+			if got, want := xfr.Data(), []byte{1, 2, 3}; !bytes.Equal(got, want) {
+				t.Errorf("rx.Data(): got %v, want %v", got, want)
+			}
+		case tx:
+			if n, err := xfr.Result(); n != 10 || err != nil {
+				t.Errorf("tx.Result(): got %d, %v, want 10, nil", n, err)
+			}
+		}
+	}
+
+	// Resubmission from the completion callback.
+	calls := make(chan int, 2)
+	var count int
+	var resubmit func(*Transfer)
+	resubmit = func(xfr *Transfer) {
+		count++
+		calls <- count
+		if count == 1 {
+			if err := xfr.SubmitFunc(resubmit); err != nil {
+				t.Errorf("SubmitFunc() in the callback: %v", err)
+			}
+		}
+	}
+	go func() {
+		for i := 0; i < 2; i++ {
+			fakeT := lib.waitForSubmitted(nil)
+			fakeT.setData([]byte{9})
+			fakeT.setStatus(TransferCompleted)
+		}
+	}()
+	if err := rx.SubmitFunc(resubmit); err != nil {
+		t.Fatalf("rx.SubmitFunc(): %v", err)
+	}
+	<-calls
+	<-calls
+	if n, err := rx.Wait(context.Background()); n != 1 || err != nil {
+		t.Errorf("rx.Wait(): got %d, %v, want 1, nil", n, err)
+	}
+
+	if err := rx.Free(); err != nil {
+		t.Errorf("rx.Free(): %v", err)
+	}
+	if err := tx.Free(); err != nil {
+		t.Errorf("tx.Free(): %v", err)
+	}
+	if err := rx.Submit(nil); err != errTransferFreed {
+		t.Errorf("rx.Submit() after Free(): got %v, want %v", err, errTransferFreed)
+	}
+}
+
+func TestTransferAsyncCancel(t *testing.T) {
+	t.Parallel()
+	lib := newFakeLibusb()
+	ctx := newContextWithImpl(lib)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	in := &InEndpoint{&endpoint{ctx: ctx, Desc: EndpointDesc{
+		Address:       0x82,
+		Number:        2,
+		Direction:     EndpointDirectionIn,
+		MaxPacketSize: 512,
+		TransferType:  TransferTypeBulk,
+	}}}
+	xfr, err := in.NewTransfer(512)
+	if err != nil {
+		t.Fatalf("%s.NewTransfer(512): %v", in, err)
+	}
+	defer xfr.Free()
+
+	if err := xfr.SetLength(1024); err == nil {
+		t.Errorf("SetLength(1024) of a 512 byte transfer: got nil error, want non-nil")
+	}
+	if err := xfr.Submit(nil); err != nil {
+		t.Fatalf("Submit(): %v", err)
+	}
+	lib.waitForSubmitted(nil)
+	if err := xfr.Submit(nil); err != errTransferPending {
+		t.Errorf("second Submit(): got %v, want %v", err, errTransferPending)
+	}
+	if err := xfr.Free(); err != errTransferPending {
+		t.Errorf("Free() of a pending transfer: got %v, want %v", err, errTransferPending)
+	}
+	xfr.Cancel()
+	if _, err := xfr.Wait(context.Background()); err != TransferCancelled {
+		t.Errorf("Wait() after Cancel(): got %v, want %v", err, TransferCancelled)
+	}
+
+	if err := xfr.Submit(nil); err != nil {
+		t.Fatalf("Submit(): %v", err)
+	}
+	lib.waitForSubmitted(nil)
+	waitCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := xfr.Wait(waitCtx); err != TransferCancelled {
+		t.Errorf("Wait() with a cancelled context: got %v, want %v", err, TransferCancelled)
+	}
+}
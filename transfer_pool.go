@@ -0,0 +1,149 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxPooledBytes is the total size of the idle transfers kept by
+	// the pool of an interface.
+	maxPooledBytes = 1 << 20
+	// poolIdleTimeout is how long a pooled transfer is kept unused
+	// before it's freed.
+	poolIdleTimeout = 10 * time.Second
+)
+
+// pooledTransfer is an idle transfer of a pool.
+type pooledTransfer struct {
+	t *usbTransfer
+	// addr is the address of the endpoint of the transfer.
+	addr EndpointAddress
+	// since is when the transfer was put in the pool.
+	since time.Time
+}
+
+// transferPool keeps the idle transfers of the endpoints of an interface,
+// so that subsequent reads and writes of the same size don't need to
+// allocate a new libusb transfer and buffer. The buffers may be allocated
+// in the device memory, a pool holds at most maxBytes of them, and frees
+// the transfers unused for idleTimeout. A nil pool keeps nothing.
+type transferPool struct {
+	maxBytes    int
+	idleTimeout time.Duration
+
+	mu sync.Mutex
+	// idle are the idle transfers, the least recently used first.
+	idle []pooledTransfer
+	// bytes is the total size of the buffers of idle.
+	bytes int
+	// timer frees the transfers that are idle for too long.
+	timer  *time.Timer
+	closed bool
+}
+
+func newTransferPool() *transferPool {
+	return &transferPool{
+		maxBytes:    maxPooledBytes,
+		idleTimeout: poolIdleTimeout,
+	}
+}
+
+// get returns an idle transfer of the endpoint with the given buffer
+// length and timeout, or nil if there is none.
+func (p *transferPool) get(addr EndpointAddress, bufLen int, timeout time.Duration) *usbTransfer {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, pt := range p.idle {
+		if pt.addr == addr && len(pt.t.buf) == bufLen && pt.t.timeout == timeout {
+			p.idle = append(p.idle[:i], p.idle[i+1:]...)
+			p.bytes -= bufLen
+			return pt.t
+		}
+	}
+	return nil
+}
+
+// put returns a transfer of the endpoint that is no longer in flight to
+// the pool. The least recently used transfers are freed to keep the pool
+// within maxBytes, and the transfer itself if it doesn't fit or the pool
+// was closed.
+func (p *transferPool) put(addr EndpointAddress, t *usbTransfer) {
+	if p == nil {
+		t.free()
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	size := len(t.data())
+	if p.closed || size > p.maxBytes {
+		t.free()
+		return
+	}
+	p.idle = append(p.idle, pooledTransfer{t: t, addr: addr, since: time.Now()})
+	p.bytes += size
+	for p.bytes > p.maxBytes {
+		p.bytes -= len(p.idle[0].t.buf)
+		p.idle[0].t.free()
+		p.idle = p.idle[1:]
+	}
+	if p.timer == nil {
+		p.timer = time.AfterFunc(p.idleTimeout, p.expire)
+	}
+}
+
+// expire frees the transfers that are idle for longer than idleTimeout.
+func (p *transferPool) expire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timer = nil
+	if p.closed {
+		return
+	}
+	now := time.Now()
+	for len(p.idle) > 0 && now.Sub(p.idle[0].since) >= p.idleTimeout {
+		p.bytes -= len(p.idle[0].t.buf)
+		p.idle[0].t.free()
+		p.idle = p.idle[1:]
+	}
+	if len(p.idle) > 0 {
+		p.timer = time.AfterFunc(p.idleTimeout-now.Sub(p.idle[0].since), p.expire)
+	}
+}
+
+// close frees the idle transfers. Transfers put in the pool afterwards
+// are freed immediately.
+func (p *transferPool) close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	for _, pt := range p.idle {
+		pt.t.free()
+	}
+	p.idle = nil
+	p.bytes = 0
+	p.closed = true
+}
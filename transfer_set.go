@@ -0,0 +1,181 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gousb
+
+import (
+	"sync"
+)
+
+// transferSet tracks the transfers allocated on the endpoints of an
+// interface, so that they can be cancelled and freed when the interface is
+// closed, before the device handle their buffers may be allocated with.
+// A nil transferSet tracks nothing and is never closed.
+type transferSet struct {
+	mu sync.Mutex
+	// owned are all the transfers not freed yet.
+	owned    map[*usbTransfer]bool
+	inFlight map[*usbTransfer]bool
+	// active is the number of calls between begin and end.
+	active int
+	// idle is signalled when active drops to zero.
+	idle   *sync.Cond
+	closed bool
+	// done is closed by close, waking up the waiting transfers.
+	done chan struct{}
+}
+
+func newTransferSet() *transferSet {
+	s := &transferSet{
+		owned:    make(map[*usbTransfer]bool),
+		inFlight: make(map[*usbTransfer]bool),
+		done:     make(chan struct{}),
+	}
+	s.idle = sync.NewCond(&s.mu)
+	return s
+}
+
+// own records a newly allocated transfer, which close frees. own fails
+// with ErrInterfaceClosed once the set is closed.
+func (s *transferSet) own(t *usbTransfer) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrInterfaceClosed
+	}
+	s.owned[t] = true
+	return nil
+}
+
+// disown forgets a transfer that was freed.
+func (s *transferSet) disown(t *usbTransfer) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.owned, t)
+}
+
+// add records a transfer about to be submitted. add fails with
+// ErrInterfaceClosed once the set is closed.
+func (s *transferSet) add(t *usbTransfer) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrInterfaceClosed
+	}
+	s.inFlight[t] = true
+	return nil
+}
+
+// remove forgets a transfer that is no longer in flight.
+func (s *transferSet) remove(t *usbTransfer) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, t)
+}
+
+// begin registers a call that uses the transfers kept by the interface,
+// which close waits for before the transfers are freed. begin fails with
+// ErrInterfaceClosed once the set is closed.
+func (s *transferSet) begin() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrInterfaceClosed
+	}
+	s.active++
+	return nil
+}
+
+// end marks the end of a call registered with begin.
+func (s *transferSet) end() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	if s.active == 0 {
+		s.idle.Broadcast()
+	}
+}
+
+// closing returns a channel that is closed when the set is closed.
+func (s *transferSet) closing() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.done
+}
+
+func (s *transferSet) isClosed() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// close cancels the transfers in flight and waits for their completion,
+// see abort, then waits for the calls registered with begin to end and
+// frees all the transfers of the set, whether in flight or not.
+func (s *transferSet) close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	var ts []*usbTransfer
+	for t := range s.inFlight {
+		ts = append(ts, t)
+	}
+	s.mu.Unlock()
+	for _, t := range ts {
+		t.abort()
+	}
+	s.mu.Lock()
+	for s.active > 0 {
+		s.idle.Wait()
+	}
+	ts = ts[:0]
+	for t := range s.owned {
+		ts = append(ts, t)
+	}
+	s.mu.Unlock()
+	// No transfer is in flight anymore, the set is closed to new
+	// submissions.
+	for _, t := range ts {
+		t.free()
+	}
+}
@@ -16,6 +16,7 @@ package gousb
 
 import (
 	"context"
+	"fmt"
 	"io"
 )
 
@@ -25,6 +26,9 @@ type transferIntf interface {
 	wait(context.Context) (int, error)
 	free() error
 	data() []byte
+	packetLengths() []int
+	setLength(int)
+	setZeroPacket(bool)
 }
 
 type stream struct {
@@ -34,6 +38,38 @@ type stream struct {
 	err error
 	// finished is true if transfers has been already closed.
 	finished bool
+	// stats are the statistics of the transfers of the stream.
+	stats transferStats
+	// skip are the statuses of the failed read transfers that are
+	// resubmitted instead of ending the stream.
+	skip []TransferStatus
+	// clearHalt, if not nil, clears the halt condition of the endpoint
+	// after a stall, which is then resubmitted like skip.
+	clearHalt func() error
+	// onError is called with the errors skipped.
+	onError func(error)
+	// set registers the calls using the transfer buffers, so that
+	// closing the interface waits for them before freeing the buffers.
+	// nil if the stream doesn't belong to an interface.
+	set *transferSet
+}
+
+// transient returns true if a read stream continues after a transfer
+// failed with err.
+func (s *stream) transient(err error) bool {
+	st, ok := err.(TransferStatus)
+	if !ok {
+		return false
+	}
+	if st == TransferStall && s.clearHalt != nil {
+		return true
+	}
+	for _, sk := range s.skip {
+		if st == sk {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *stream) gotError(err error) {
@@ -101,7 +137,8 @@ type ReadStream struct {
 // The data will come from at most a single transfer, so the returned number
 // might be smaller than the length of p.
 // After a non-nil error is returned, all subsequent attempts to read will
-// return io.ErrClosedPipe.
+// return io.ErrClosedPipe, except after TransferTimedOut, see
+// StreamOptions.Timeout.
 // Read cannot be called concurrently with other Read, ReadContext
 // or Close.
 func (r *ReadStream) Read(p []byte) (int, error) {
@@ -112,17 +149,148 @@ func (r *ReadStream) Read(p []byte) (int, error) {
 // The data will come from at most a single transfer, so the returned number
 // might be smaller than the length of p.
 // After a non-nil error is returned, all subsequent attempts to read will
-// return io.ErrClosedPipe.
+// return io.ErrClosedPipe, except after TransferTimedOut, see
+// StreamOptions.Timeout.
 // ReadContext cannot be called concurrently with other Read, ReadContext
 // or Close.
 // The context passed controls the cancellation of this particular read
 // operation within the stream. The semantics is identical to
 // Endpoint.ReadContext.
 func (r *ReadStream) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if err := r.s.set.begin(); err != nil {
+		return 0, err
+	}
+	defer r.s.set.end()
+	if n, err := r.next(ctx); err != nil {
+		return n, err
+	}
+	use := r.total - r.used
+	if use > len(p) {
+		use = len(p)
+	}
+	copy(p, r.current.data()[r.used:r.used+use])
+	r.used += use
+	if r.used == r.total {
+		r.recycle(r.current)
+		r.current = nil
+	}
+	return use, nil
+}
+
+// ReadMessage reads the data of a single transfer from the stream, so that
+// the boundaries of the transfers, e.g. the short packets ending messages
+// of the device, are preserved. If a Read consumed only part of the
+// transfer, ReadMessage returns the rest of it.
+// If p is too short for the data, ReadMessage returns the data that fits
+// and io.ErrShortBuffer, and the rest of the transfer is discarded.
+// Otherwise the errors are the same as for ReadContext.
+// ReadMessage cannot be called concurrently with other methods of the
+// stream.
+func (r *ReadStream) ReadMessage(ctx context.Context, p []byte) (int, error) {
+	if err := r.s.set.begin(); err != nil {
+		return 0, err
+	}
+	defer r.s.set.end()
+	if n, err := r.next(ctx); err != nil {
+		return n, err
+	}
+	n := copy(p, r.current.data()[r.used:r.total])
+	short := n < r.total-r.used
+	r.used = r.total
+	r.Release()
+	if short {
+		return n, io.ErrShortBuffer
+	}
+	return n, nil
+}
+
+// NextTransfer returns the data of the next transfer of the stream, like
+// ReadMessage, without copying it. The returned slice points to the buffer
+// of the transfer, which is resubmitted only after the data is handed
+// back by a call to Release. Calling another method of the stream
+// releases the data as well. The slice must not be used after it was
+// released, nor after the interface of the endpoint was closed, which
+// frees the buffer.
+// NextTransfer cannot be called concurrently with other methods of the
+// stream.
+func (r *ReadStream) NextTransfer(ctx context.Context) ([]byte, error) {
+	if err := r.s.set.begin(); err != nil {
+		return nil, err
+	}
+	defer r.s.set.end()
+	if _, err := r.next(ctx); err != nil {
+		return nil, err
+	}
+	b := r.current.data()[r.used:r.total]
+	r.used = r.total
+	return b, nil
+}
+
+// NextPackets returns the data of the next transfer of the stream like
+// NextTransfer, split into the packets of an isochronous transfer, so that
+// the boundaries of the packets are preserved, e.g. when every packet
+// starts with a header. The data of other transfers is returned as
+// a single packet. The packets are released like the data of NextTransfer.
+// A packet with an error ends the data of an isochronous transfer, and the
+// transfer fails with the status of the packet.
+// NextPackets cannot be called concurrently with other methods of the
+// stream.
+func (r *ReadStream) NextPackets(ctx context.Context) ([][]byte, error) {
+	if err := r.s.set.begin(); err != nil {
+		return nil, err
+	}
+	defer r.s.set.end()
+	if _, err := r.next(ctx); err != nil {
+		return nil, err
+	}
+	b := r.current.data()[:r.total]
+	lens := r.current.packetLengths()
+	if lens == nil {
+		lens = []int{r.total}
+	}
+	var pkts [][]byte
+	off := 0
+	for _, l := range lens {
+		end := off + l
+		if end > r.total {
+			end = r.total
+		}
+		// Skip the data consumed by Read.
+		if end > r.used {
+			start := off
+			if start < r.used {
+				start = r.used
+			}
+			pkts = append(pkts, b[start:end])
+		}
+		off = end
+	}
+	r.used = r.total
+	return pkts, nil
+}
+
+// Release hands back the data returned by NextTransfer, so that the
+// transfer can be resubmitted. Release does nothing if there is no data
+// to hand back.
+func (r *ReadStream) Release() {
+	if r.current != nil && r.used == r.total {
+		r.recycle(r.current)
+		r.current = nil
+	}
+}
+
+// next waits for the next transfer of the stream, unless the current one
+// still has data to read.
+func (r *ReadStream) next(ctx context.Context) (int, error) {
 	if r.s.transfers == nil {
 		return 0, io.ErrClosedPipe
 	}
-	if r.current == nil {
+	// Hand back the transfer of the last NextTransfer, if any.
+	r.Release()
+	if r.current != nil {
+		return 0, nil
+	}
+	for {
 		t, ok := <-r.s.transfers
 		if !ok {
 			// no more transfers in flight
@@ -130,6 +298,30 @@ func (r *ReadStream) ReadContext(ctx context.Context, p []byte) (int, error) {
 			return 0, r.s.err
 		}
 		n, err := t.wait(ctx)
+		if err == TransferTimedOut && n > 0 {
+			// The transfer timed out as configured, return the data
+			// received before the timeout and keep reading.
+			err = nil
+		}
+		if err != nil && ctx.Err() == nil && r.s.transient(err) {
+			// The data of the failed transfer is dropped.
+			if r.s.onError != nil {
+				r.s.onError(err)
+			}
+			if err != TransferStall || r.s.clearHalt == nil {
+				r.recycle(t)
+				continue
+			}
+			if err = r.recoverHalt(t); err == nil {
+				continue
+			}
+		}
+		if err == TransferTimedOut {
+			// Nothing was received before the timeout, the timeout is
+			// returned and the stream keeps reading.
+			r.recycle(t)
+			return 0, err
+		}
 		if err != nil {
 			// wait error aborts immediately, all remaining data is invalid.
 			t.free()
@@ -140,29 +332,92 @@ func (r *ReadStream) ReadContext(ctx context.Context, p []byte) (int, error) {
 		r.current = t
 		r.total = n
 		r.used = 0
+		return 0, nil
 	}
-	use := r.total - r.used
-	if use > len(p) {
-		use = len(p)
+}
+
+// recoverHalt clears the halt of the endpoint after t stalled and
+// resubmits t and the transfers that were queued after it.
+// The transfers in flight are cancelled first, since they can't complete
+// before the halt is cleared.
+func (r *ReadStream) recoverHalt(t transferIntf) error {
+	var queued []transferIntf
+	for i := len(r.s.transfers); i > 0; i-- {
+		q := <-r.s.transfers
+		q.cancel()
+		q.wait(context.Background())
+		queued = append(queued, q)
 	}
-	copy(p, r.current.data()[r.used:r.used+use])
-	r.used += use
-	if r.used == r.total {
-		if r.s.err == nil {
-			if err := r.current.submit(); err == nil {
-				// guaranteed to not block, len(transfers) == number of allocated transfers
-				r.s.transfers <- r.current
-			} else {
-				r.s.gotError(err)
-				r.s.noMore()
-			}
+	if err := r.s.clearHalt(); err != nil {
+		for _, q := range queued {
+			q.free()
 		}
-		if r.s.err != nil {
-			r.current.free()
+		return err
+	}
+	r.recycle(t)
+	for _, q := range queued {
+		r.recycle(q)
+	}
+	return nil
+}
+
+// recycle resubmits a transfer whose data was consumed, or frees it if the
+// stream is ending.
+func (r *ReadStream) recycle(t transferIntf) {
+	if r.s.err == nil {
+		err := t.submit()
+		if err == nil {
+			// guaranteed to not block, len(transfers) == number of allocated transfers
+			r.s.transfers <- t
+			return
 		}
-		r.current = nil
+		r.s.gotError(err)
+		r.s.noMore()
 	}
-	return use, nil
+	t.free()
+}
+
+// WriteTo implements io.WriterTo. WriteTo writes the data of each transfer
+// to w directly from the transfer buffer, until the stream ends.
+// Transfers that time out without data, see StreamOptions.Timeout, are
+// skipped. WriteTo returns a nil error if the stream ended with io.EOF,
+// after Close, which can be called by w.Write.
+// WriteTo cannot be called concurrently with other methods of the stream,
+// except Stats.
+func (r *ReadStream) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for {
+		// Closing the interface waits for w.Write to return, since it
+		// uses the buffer of the transfer.
+		if err := r.s.set.begin(); err != nil {
+			return total, err
+		}
+		b, err := r.NextTransfer(context.Background())
+		switch {
+		case err == TransferTimedOut:
+			r.s.set.end()
+			continue
+		case err == io.EOF:
+			r.s.set.end()
+			return total, nil
+		case err != nil:
+			r.s.set.end()
+			return total, err
+		}
+		n, err := w.Write(b)
+		total += int64(n)
+		r.Release()
+		r.s.set.end()
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Stats returns the statistics of the transfers of the stream. Stats can
+// be called concurrently with the other methods.
+func (r *ReadStream) Stats() TransferStats {
+	return r.s.stats.snapshot()
 }
 
 // Close signals that the transfer should stop. After Close is called,
@@ -184,6 +439,11 @@ func (r *ReadStream) Close() error {
 type WriteStream struct {
 	s     *stream
 	total int
+	// zlp is true if every Write ends with a zero-length packet.
+	zlp bool
+	// maxPacket is the maximum packet size of the endpoint, used to tell
+	// whether ReadFrom needs to end the data with a zero-length packet.
+	maxPacket int
 }
 
 // Write sends the data to the endpoint. Write returning a nil error doesn't
@@ -223,19 +483,49 @@ func (w *WriteStream) WriteContext(ctx context.Context, p []byte) (int, error) {
 	if w.s.transfers == nil || w.s.err != nil {
 		return 0, io.ErrClosedPipe
 	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return w.write(ctx, p, w.zlp)
+}
+
+// WriteContextZLP sends the data to the endpoint like WriteContext, and ends
+// the last USB transfer of this write with a zero-length packet if its
+// length is a multiple of EndpointDesc.MaxPacketSize, regardless of
+// StreamOptions.ZeroLengthPacket.
+func (w *WriteStream) WriteContextZLP(ctx context.Context, p []byte) (int, error) {
+	if w.s.transfers == nil || w.s.err != nil {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return w.write(ctx, p, true)
+}
+
+// WriteZLP queues a zero-length packet after the data already written to
+// the stream. Like WriteContext, WriteZLP doesn't wait for the packet
+// to be sent.
+func (w *WriteStream) WriteZLP(ctx context.Context) error {
+	if w.s.transfers == nil || w.s.err != nil {
+		return io.ErrClosedPipe
+	}
+	_, err := w.write(ctx, nil, false)
+	return err
+}
+
+// write submits p in as many transfers as needed, at least one. If zlp is
+// true, the last transfer ends with a zero-length packet.
+func (w *WriteStream) write(ctx context.Context, p []byte, zlp bool) (int, error) {
+	if err := w.s.set.begin(); err != nil {
+		return 0, err
+	}
+	defer w.s.set.end()
 	written := 0
 	all := len(p)
-	for written < all {
-		t := <-w.s.transfers
-		n, err := t.wait(ctx) // unsubmitted transfers will return 0 bytes and no error
-		w.total += n
+	for {
+		t, err := w.acquire(ctx)
 		if err != nil {
-			t.free()
-			w.s.gotError(err)
-			// This branch is used only after all the transfers were set in flight.
-			// That means all transfers left in the queue are in flight.
-			// They must be ignored, since this wait() failed.
-			w.s.flushRemaining()
 			return written, err
 		}
 		use := all - written
@@ -243,17 +533,126 @@ func (w *WriteStream) WriteContext(ctx context.Context, p []byte) (int, error) {
 			use = max
 		}
 		copy(t.data(), p[written:written+use])
-		if err := t.submit(); err != nil {
-			t.free()
-			w.s.gotError(err)
-			// Even though this submit failed, all the transfers in flight are still valid.
-			// Don't flush remaining transfers.
-			// We won't submit any more transfers.
-			w.s.noMore()
+		if err := w.send(t, use, zlp && written+use == all); err != nil {
 			return written, err
 		}
 		written += use
-		w.s.transfers <- t // guaranteed non blocking
+		if written == all {
+			return written, nil
+		}
+	}
+}
+
+// acquire waits for the oldest transfer of the stream to finish, so that
+// it can be filled with new data.
+func (w *WriteStream) acquire(ctx context.Context) (transferIntf, error) {
+	t := <-w.s.transfers
+	n, err := t.wait(ctx) // unsubmitted transfers will return 0 bytes and no error
+	w.total += n
+	if err != nil {
+		t.free()
+		w.s.gotError(err)
+		// This branch is used only after all the transfers were set in flight.
+		// That means all transfers left in the queue are in flight.
+		// They must be ignored, since this wait() failed.
+		w.s.flushRemaining()
+		return nil, err
+	}
+	return t, nil
+}
+
+// send submits the first n bytes of the buffer of a transfer returned by
+// acquire.
+func (w *WriteStream) send(t transferIntf, n int, zlp bool) error {
+	t.setLength(n)
+	t.setZeroPacket(zlp)
+	if err := t.submit(); err != nil {
+		t.free()
+		w.s.gotError(err)
+		// Even though this submit failed, all the transfers in flight are still valid.
+		// Don't flush remaining transfers.
+		// We won't submit any more transfers.
+		w.s.noMore()
+		return err
+	}
+	w.s.transfers <- t // guaranteed non blocking
+	return nil
+}
+
+// ReadFrom implements io.ReaderFrom. ReadFrom reads data from r directly
+// into the transfer buffers until io.EOF, sending each buffer once full.
+// Like Write, ReadFrom doesn't wait for the data to be sent. The data
+// read counts as a single Write for StreamOptions.ZeroLengthPacket.
+// ReadFrom cannot be called concurrently with other methods of the
+// stream, except Stats.
+func (w *WriteStream) ReadFrom(r io.Reader) (int64, error) {
+	if w.s.transfers == nil || w.s.err != nil {
+		return 0, io.ErrClosedPipe
+	}
+	if err := w.s.set.begin(); err != nil {
+		return 0, err
+	}
+	defer w.s.set.end()
+	var total int64
+	for {
+		t, err := w.acquire(context.Background())
+		if err != nil {
+			return total, err
+		}
+		n, rerr := io.ReadFull(r, t.data())
+		eof := rerr == io.EOF || rerr == io.ErrUnexpectedEOF
+		if n == 0 {
+			// Nothing to send, return the transfer unused, unless the
+			// last transfer was full and needs to be terminated.
+			zlp := eof && w.zlp && total > 0 && w.maxPacket > 0 && len(t.data())%w.maxPacket == 0
+			if !zlp {
+				w.s.transfers <- t
+			} else if err := w.send(t, 0, false); err != nil {
+				return total, err
+			}
+		} else if err := w.send(t, n, eof && w.zlp); err != nil {
+			return total, err
+		}
+		total += int64(n)
+		switch {
+		case eof:
+			return total, nil
+		case rerr != nil:
+			return total, rerr
+		}
+	}
+}
+
+// WriteBuffers sends each of the buffers to the endpoint in a single
+// transfer, like WriteContext does for buffers that fit in a transfer.
+// WriteBuffers returns an error if a buffer is longer than the transfer
+// size of the stream, after sending the buffers before it. An empty
+// buffer is sent as a zero-length packet.
+// WriteBuffers cannot be called concurrently with other methods of the
+// stream, except Stats.
+func (w *WriteStream) WriteBuffers(ctx context.Context, bufs [][]byte) (int, error) {
+	if w.s.transfers == nil || w.s.err != nil {
+		return 0, io.ErrClosedPipe
+	}
+	if err := w.s.set.begin(); err != nil {
+		return 0, err
+	}
+	defer w.s.set.end()
+	written := 0
+	for i, b := range bufs {
+		t, err := w.acquire(ctx)
+		if err != nil {
+			return written, err
+		}
+		if size := len(t.data()); len(b) > size {
+			w.s.transfers <- t
+			return written, fmt.Errorf("buffer %d has %d bytes, more than the transfer size %d", i, len(b), size)
+		}
+		copy(t.data(), b)
+		if err := w.send(t, len(b), w.zlp); err != nil {
+			return written, err
+		}
+		written += len(b)
 	}
 	return written, nil
 }
@@ -297,6 +696,12 @@ func (w *WriteStream) CloseContext(ctx context.Context) error {
 	return w.s.err
 }
 
+// Stats returns the statistics of the transfers of the stream. Stats can
+// be called concurrently with the other methods.
+func (w *WriteStream) Stats() TransferStats {
+	return w.s.stats.snapshot()
+}
+
 // Written returns the number of bytes successfully written by the stream.
 // Written may be called only after Close() or CloseContext()
 // has been called and returned.
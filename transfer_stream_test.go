@@ -29,6 +29,7 @@ var fakeTransferBuf = make([]byte, 1500)
 
 type fakeStreamResult struct {
 	n         int
+	packets   []int
 	waitErr   error
 	submitErr error
 }
@@ -37,6 +38,12 @@ type fakeStreamTransfer struct {
 	res      []fakeStreamResult
 	inFlight bool
 	released bool
+	// lengths records the lengths set before each submit.
+	lengths []int
+	// zlps records the zero-length packet flags set before each submit.
+	zlps []bool
+	// packets are the packet lengths of the last completed transfer.
+	packets []int
 }
 
 func (f *fakeStreamTransfer) submit() error {
@@ -70,11 +77,8 @@ func (f *fakeStreamTransfer) wait(ctx context.Context) (int, error) {
 	}
 	f.inFlight = false
 	res := f.res[0]
-	if res.waitErr == nil {
-		f.res = f.res[1:]
-	} else {
-		f.res = nil
-	}
+	f.res = f.res[1:]
+	f.packets = res.packets
 	return res.n, res.waitErr
 }
 
@@ -86,8 +90,13 @@ func (f *fakeStreamTransfer) free() error {
 	return nil
 }
 
-func (f *fakeStreamTransfer) cancel() error { return nil }
-func (f *fakeStreamTransfer) data() []byte  { return fakeTransferBuf }
+func (f *fakeStreamTransfer) cancel() error        { return nil }
+func (f *fakeStreamTransfer) data() []byte         { return fakeTransferBuf }
+func (f *fakeStreamTransfer) packetLengths() []int { return f.packets }
+func (f *fakeStreamTransfer) setLength(n int)      { f.lengths = append(f.lengths, n) }
+func (f *fakeStreamTransfer) setZeroPacket(zlp bool) {
+	f.zlps = append(f.zlps, zlp)
+}
 
 var errSentinel = errors.New("sentinel error")
 
@@ -200,6 +209,23 @@ func TestTransferReadStream(t *testing.T) {
 				{err: io.ErrClosedPipe},
 			},
 		},
+		{
+			desc:        "timed out transfers are resubmitted",
+			closeBefore: 4,
+			transfers: [][]fakeStreamResult{
+				{{n: 400}, {n: 100, waitErr: TransferTimedOut}, {n: 400}},
+				{{waitErr: TransferTimedOut}, {n: 400}},
+			},
+			want: []readRes{
+				{n: 400},
+				{err: TransferTimedOut},
+				{n: 100},
+				{n: 400},
+				{n: 400},
+				{err: io.EOF},
+				{err: io.ErrClosedPipe},
+			},
+		},
 		{
 			desc: "fail quickly",
 			transfers: [][]fakeStreamResult{
@@ -355,3 +381,371 @@ func TestTransferWriteStream(t *testing.T) {
 		})
 	}
 }
+
+func TestTransferWriteStreamLength(t *testing.T) {
+	t.Parallel()
+	ft1 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 1500}, {n: 10}}}
+	ft2 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 700}}}
+	s := WriteStream{s: newStream([]transferIntf{ft1, ft2})}
+	for _, w := range []int{2200, 10} {
+		if got, err := s.Write(make([]byte, w)); got != w || err != nil {
+			t.Errorf("WriteStream.Write(%d bytes): got %d, %v, want %d, nil", w, got, err, w)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("WriteStream.Close(): got %v, want nil", err)
+	}
+	if got, want := ft1.lengths, []int{1500, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first transfer submitted with lengths %v, want %v", got, want)
+	}
+	if got, want := ft2.lengths, []int{700}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second transfer submitted with lengths %v, want %v", got, want)
+	}
+	if got, want := s.Written(), 2210; got != want {
+		t.Errorf("WriteStream.Written(): got %d, want %d", got, want)
+	}
+}
+
+func TestTransferWriteStreamZeroLengthPacket(t *testing.T) {
+	t.Parallel()
+	ft1 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 1500}, {n: 0}}}
+	ft2 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 500}, {n: 10}}}
+	s := WriteStream{s: newStream([]transferIntf{ft1, ft2}), zlp: true}
+	if got, err := s.Write(make([]byte, 2000)); got != 2000 || err != nil {
+		t.Errorf("WriteStream.Write(2000 bytes): got %d, %v, want 2000, nil", got, err)
+	}
+	if err := s.WriteZLP(context.Background()); err != nil {
+		t.Errorf("WriteStream.WriteZLP(): got %v, want nil", err)
+	}
+	s.zlp = false
+	if got, err := s.WriteContextZLP(context.Background(), make([]byte, 10)); got != 10 || err != nil {
+		t.Errorf("WriteStream.WriteContextZLP(10 bytes): got %d, %v, want 10, nil", got, err)
+	}
+	if got, err := s.Write(nil); got != 0 || err != nil {
+		t.Errorf("WriteStream.Write(nil): got %d, %v, want 0, nil", got, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("WriteStream.Close(): got %v, want nil", err)
+	}
+	if got, want := ft1.lengths, []int{1500, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first transfer submitted with lengths %v, want %v", got, want)
+	}
+	if got, want := ft1.zlps, []bool{false, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first transfer submitted with zero-length packets %v, want %v", got, want)
+	}
+	if got, want := ft2.lengths, []int{500, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second transfer submitted with lengths %v, want %v", got, want)
+	}
+	if got, want := ft2.zlps, []bool{true, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second transfer submitted with zero-length packets %v, want %v", got, want)
+	}
+	if got, want := s.Written(), 2010; got != want {
+		t.Errorf("WriteStream.Written(): got %d, want %d", got, want)
+	}
+}
+
+func TestTransferReadStreamMessages(t *testing.T) {
+	t.Parallel()
+	ft1 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 400}, {n: 0}, {n: 300}}}
+	ft2 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 1500}, {n: 100}}}
+	s := newStream([]transferIntf{ft1, ft2})
+	s.submitAll()
+	r := ReadStream{s: s}
+	ctx := context.Background()
+	p := make([]byte, 1500)
+
+	read := func(desc string, f func() (int, error), want readRes) {
+		t.Helper()
+		n, err := f()
+		if got := (readRes{n: n, err: err}); got != want {
+			t.Errorf("%s: got %+v, want %+v", desc, got, want)
+		}
+	}
+	next := func(want readRes) {
+		t.Helper()
+		read("NextTransfer", func() (int, error) {
+			b, err := r.NextTransfer(ctx)
+			return len(b), err
+		}, want)
+	}
+	read("Read(100 bytes)", func() (int, error) { return r.Read(p[:100]) }, readRes{n: 100})
+	read("ReadMessage", func() (int, error) { return r.ReadMessage(ctx, p) }, readRes{n: 300})
+	next(readRes{n: 1500})
+	next(readRes{n: 0})
+	r.Release()
+	r.Close()
+	read("ReadMessage(50 bytes)", func() (int, error) { return r.ReadMessage(ctx, p[:50]) }, readRes{n: 50, err: io.ErrShortBuffer})
+	next(readRes{n: 300})
+	read("ReadMessage", func() (int, error) { return r.ReadMessage(ctx, p) }, readRes{err: io.EOF})
+	next(readRes{err: io.ErrClosedPipe})
+
+	for i, ft := range []*fakeStreamTransfer{ft1, ft2} {
+		if !ft.released {
+			t.Errorf("transfer %d was not freed", i+1)
+		}
+	}
+}
+
+func TestTransferReadStreamPackets(t *testing.T) {
+	t.Parallel()
+	ft1 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 8, packets: []int{3, 0, 5}}, {n: 8, packets: []int{3, 0, 5}}}}
+	ft2 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 10}, {waitErr: TransferError}}}
+	s := newStream([]transferIntf{ft1, ft2})
+	s.submitAll()
+	r := ReadStream{s: s}
+	ctx := context.Background()
+
+	packets := func(desc string, want []int) {
+		t.Helper()
+		pkts, err := r.NextPackets(ctx)
+		if err != nil {
+			t.Fatalf("%s: NextPackets(): %v", desc, err)
+		}
+		var got []int
+		for _, p := range pkts {
+			got = append(got, len(p))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: NextPackets(): got packets of %v bytes, want %v", desc, got, want)
+		}
+	}
+	packets("isochronous transfer", []int{3, 0, 5})
+	packets("bulk transfer", []int{10})
+	if n, err := r.Read(make([]byte, 4)); n != 4 || err != nil {
+		t.Fatalf("Read(4 bytes): got %d, %v, want 4, nil", n, err)
+	}
+	packets("rest of an isochronous transfer", []int{4})
+	if _, err := r.NextPackets(ctx); err != TransferError {
+		t.Errorf("NextPackets(): got error %v, want %v", err, TransferError)
+	}
+	r.Close()
+}
+
+func TestTransferReadStreamErrorPolicy(t *testing.T) {
+	t.Parallel()
+	errHalt := errors.New("clear halt failed")
+	for _, tc := range []struct {
+		desc      string
+		skip      []TransferStatus
+		clearHalt error
+		cancel    bool
+		transfers [][]fakeStreamResult
+		want      []readRes
+		wantErrs  []error
+		wantHalts int
+	}{
+		{
+			desc: "skipped errors and a stall",
+			skip: []TransferStatus{TransferOverflow},
+			transfers: [][]fakeStreamResult{
+				{{n: 100}, {waitErr: TransferOverflow}, {waitErr: TransferStall}, {n: 300}, {n: 0}},
+				{{n: 200}, {n: 400}, {waitErr: TransferCancelled}, {waitErr: TransferError}},
+			},
+			want: []readRes{
+				{n: 100},
+				{n: 200},
+				{n: 400},
+				{n: 300},
+				{err: TransferError},
+				{err: io.ErrClosedPipe},
+			},
+			wantErrs:  []error{TransferOverflow, TransferStall},
+			wantHalts: 1,
+		},
+		{
+			desc: "skipped timeout",
+			skip: []TransferStatus{TransferTimedOut},
+			transfers: [][]fakeStreamResult{
+				{{waitErr: TransferTimedOut}, {n: 300}},
+				{{n: 200}, {waitErr: TransferError}},
+			},
+			want: []readRes{
+				{n: 200},
+				{n: 300},
+				{err: TransferError},
+				{err: io.ErrClosedPipe},
+			},
+			wantErrs: []error{TransferTimedOut},
+		},
+		{
+			desc:      "clear halt fails",
+			clearHalt: errHalt,
+			transfers: [][]fakeStreamResult{
+				{{waitErr: TransferStall}},
+				{{waitErr: TransferCancelled}},
+			},
+			want: []readRes{
+				{err: errHalt},
+				{err: io.ErrClosedPipe},
+			},
+			wantErrs:  []error{TransferStall},
+			wantHalts: 1,
+		},
+		{
+			desc:   "cancelled read",
+			skip:   []TransferStatus{TransferCancelled},
+			cancel: true,
+			transfers: [][]fakeStreamResult{
+				{{waitErr: TransferCancelled}},
+				{{n: 500}},
+			},
+			want: []readRes{
+				{err: TransferCancelled},
+				{err: io.ErrClosedPipe},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var fts []*fakeStreamTransfer
+			var tt []transferIntf
+			for _, res := range tc.transfers {
+				ft := &fakeStreamTransfer{res: res}
+				fts = append(fts, ft)
+				tt = append(tt, ft)
+			}
+			s := newStream(tt)
+			var gotErrs []error
+			var halts int
+			s.skip = tc.skip
+			s.onError = func(err error) { gotErrs = append(gotErrs, err) }
+			s.clearHalt = func() error {
+				halts++
+				return tc.clearHalt
+			}
+			s.submitAll()
+			r := ReadStream{s: s}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				cancel()
+			}
+			buf := make([]byte, 1500)
+			for i, want := range tc.want {
+				n, err := r.ReadContext(ctx, buf)
+				if got := (readRes{n: n, err: err}); got != want {
+					t.Errorf("ReadContext #%d: got %+v, want %+v", i, got, want)
+				}
+			}
+			if !reflect.DeepEqual(gotErrs, tc.wantErrs) {
+				t.Errorf("OnError got %v, want %v", gotErrs, tc.wantErrs)
+			}
+			if halts != tc.wantHalts {
+				t.Errorf("got %d calls to clear halt, want %d", halts, tc.wantHalts)
+			}
+			for i, ft := range fts {
+				if !ft.released {
+					t.Errorf("transfer %d was not freed", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestTransferWriteStreamReadFrom(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		desc       string
+		size       int
+		zlp        bool
+		wantLength [][]int
+		wantZLP    [][]bool
+	}{
+		{
+			desc:       "partial last transfer",
+			size:       3500,
+			zlp:        true,
+			wantLength: [][]int{{1500, 500}, {1500}},
+			wantZLP:    [][]bool{{false, true}, {false}},
+		},
+		{
+			desc:       "full last transfer",
+			size:       3000,
+			wantLength: [][]int{{1500}, {1500}},
+			wantZLP:    [][]bool{{false}, {false}},
+		},
+		{
+			desc:       "full last transfer with zero-length packet",
+			size:       3000,
+			zlp:        true,
+			wantLength: [][]int{{1500, 0}, {1500}},
+			wantZLP:    [][]bool{{false, false}, {false}},
+		},
+	} {
+		ft1 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 1500}, {n: 500}}}
+		ft2 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 1500}}}
+		s := WriteStream{s: newStream([]transferIntf{ft1, ft2}), zlp: tc.zlp, maxPacket: 500}
+		if got, err := s.ReadFrom(bytes.NewReader(make([]byte, tc.size))); got != int64(tc.size) || err != nil {
+			t.Errorf("%s: ReadFrom(): got %d, %v, want %d, nil", tc.desc, got, err, tc.size)
+		}
+		if err := s.Close(); err != nil {
+			t.Errorf("%s: Close(): got %v, want nil", tc.desc, err)
+		}
+		for i, ft := range []*fakeStreamTransfer{ft1, ft2} {
+			if got, want := ft.lengths, tc.wantLength[i]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: transfer %d submitted with lengths %v, want %v", tc.desc, i+1, got, want)
+			}
+			if got, want := ft.zlps, tc.wantZLP[i]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: transfer %d submitted with zero-length packets %v, want %v", tc.desc, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestTransferWriteStreamWriteBuffers(t *testing.T) {
+	t.Parallel()
+	ft1 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 10}, {n: 1500}}}
+	ft2 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 0}}}
+	s := WriteStream{s: newStream([]transferIntf{ft1, ft2})}
+	bufs := [][]byte{make([]byte, 10), nil, make([]byte, 1500), make([]byte, 1501)}
+	if got, err := s.WriteBuffers(context.Background(), bufs); got != 1510 || err == nil {
+		t.Errorf("WriteBuffers(): got %d, %v, want 1510 and an error for the last buffer", got, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close(): got %v, want nil", err)
+	}
+	if got, want := ft1.lengths, []int{10, 1500}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first transfer submitted with lengths %v, want %v", got, want)
+	}
+	if got, want := ft2.lengths, []int{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second transfer submitted with lengths %v, want %v", got, want)
+	}
+	if got, want := s.Written(), 1510; got != want {
+		t.Errorf("Written(): got %d, want %d", got, want)
+	}
+}
+
+// closingWriter closes a stream once it got enough data.
+type closingWriter struct {
+	bytes.Buffer
+	r     *ReadStream
+	limit int
+}
+
+func (w *closingWriter) Write(p []byte) (int, error) {
+	n, err := w.Buffer.Write(p)
+	if w.Len() >= w.limit {
+		w.r.Close()
+	}
+	return n, err
+}
+
+func TestTransferReadStreamWriteTo(t *testing.T) {
+	t.Parallel()
+	ft1 := &fakeStreamTransfer{res: []fakeStreamResult{{n: 100}, {n: 200}, {n: 50}}}
+	ft2 := &fakeStreamTransfer{res: []fakeStreamResult{{waitErr: TransferTimedOut}, {n: 300}}}
+	s := newStream([]transferIntf{ft1, ft2})
+	s.submitAll()
+	r := &ReadStream{s: s}
+	w := &closingWriter{r: r, limit: 600}
+	if got, err := r.WriteTo(w); got != 650 || err != nil {
+		t.Errorf("WriteTo(): got %d, %v, want 650, nil", got, err)
+	}
+	if got := w.Len(); got != 650 {
+		t.Errorf("WriteTo() wrote %d bytes, want 650", got)
+	}
+	for i, ft := range []*fakeStreamTransfer{ft1, ft2} {
+		if !ft.released {
+			t.Errorf("transfer %d was not freed", i+1)
+		}
+	}
+}
@@ -16,7 +16,9 @@ package gousb
 
 import (
 	"context"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewTransfer(t *testing.T) {
@@ -34,9 +36,10 @@ func TestNewTransfer(t *testing.T) {
 		tt          TransferType
 		maxPkt      int
 		buf         int
-		wantIso     int
+		wantIso     []int
 		wantLength  int
 		wantTimeout int
+		wantErr     bool
 	}{
 		{
 			desc:       "bulk in transfer, 512B packets",
@@ -52,7 +55,8 @@ func TestNewTransfer(t *testing.T) {
 			tt:         TransferTypeIsochronous,
 			maxPkt:     3 * 1024,
 			buf:        10000,
-			wantLength: 9216,
+			wantIso:    []int{3072, 3072, 3072, 784},
+			wantLength: 10000,
 		},
 		{
 			desc:       "iso out transfer, 512B packets",
@@ -60,22 +64,104 @@ func TestNewTransfer(t *testing.T) {
 			tt:         TransferTypeIsochronous,
 			maxPkt:     512,
 			buf:        2048,
+			wantIso:    []int{512, 512, 512, 512},
 			wantLength: 2048,
 		},
+		{
+			desc:       "iso in transfer, empty buffer",
+			dir:        EndpointDirectionIn,
+			tt:         TransferTypeIsochronous,
+			maxPkt:     512,
+			buf:        0,
+			wantIso:    []int{0},
+			wantLength: 0,
+		},
+		{
+			desc:    "iso out transfer, no bandwidth",
+			dir:     EndpointDirectionOut,
+			tt:      TransferTypeIsochronous,
+			maxPkt:  0,
+			buf:     1024,
+			wantErr: true,
+		},
 	} {
 		xfer, err := newUSBTransfer(ctx, nil, &EndpointDesc{
 			Number:        2,
 			Direction:     tc.dir,
 			TransferType:  tc.tt,
 			MaxPacketSize: tc.maxPkt,
-		}, tc.buf)
+		}, tc.buf, 0, false)
 
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%s: newUSBTransfer(): got error %v, want error: %v", tc.desc, err, tc.wantErr)
+		}
 		if err != nil {
-			t.Fatalf("newUSBTransfer(): %v", err)
+			continue
 		}
 		defer xfer.free()
 		if got, want := len(xfer.data()), tc.wantLength; got != want {
-			t.Errorf("xfer.buf: got %d bytes, want %d", got, want)
+			t.Errorf("%s: xfer.buf: got %d bytes, want %d", tc.desc, got, want)
+		}
+		if got := fakeIsoLengths(ctx, xfer); !reflect.DeepEqual(got, tc.wantIso) {
+			t.Errorf("%s: isochronous packets: got %v, want %v", tc.desc, got, tc.wantIso)
+		}
+	}
+}
+
+// fakeIsoLengths returns the lengths of the isochronous packets set up
+// for the next submission of a transfer allocated by fakeLibusb.
+func fakeIsoLengths(ctx *Context, t *usbTransfer) []int {
+	lib := ctx.libusb.(*fakeLibusb)
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	return lib.ts[t.xfer].isoLengths
+}
+
+func TestTransferSetLength(t *testing.T) {
+	t.Parallel()
+	ctx := newContextWithImpl(newFakeLibusb())
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	iso, err := newUSBTransfer(ctx, nil, &EndpointDesc{
+		Number:        5,
+		Direction:     EndpointDirectionOut,
+		TransferType:  TransferTypeIsochronous,
+		MaxPacketSize: 1024,
+	}, 3000, 0, false)
+	if err != nil {
+		t.Fatalf("newUSBTransfer(): %v", err)
+	}
+	defer iso.free()
+	bulk, err := newUSBTransfer(ctx, nil, &EndpointDesc{
+		Number:        1,
+		Direction:     EndpointDirectionOut,
+		TransferType:  TransferTypeBulk,
+		MaxPacketSize: 512,
+	}, 512, 0, false)
+	if err != nil {
+		t.Fatalf("newUSBTransfer(): %v", err)
+	}
+	defer bulk.free()
+
+	for _, tc := range []struct {
+		desc    string
+		xfer    *usbTransfer
+		n       int
+		wantIso []int
+	}{
+		{desc: "iso, whole buffer", xfer: iso, n: 3000, wantIso: []int{1024, 1024, 952}},
+		{desc: "iso, full packets", xfer: iso, n: 2048, wantIso: []int{1024, 1024}},
+		{desc: "iso, partial packet", xfer: iso, n: 10, wantIso: []int{10}},
+		{desc: "iso, no data", xfer: iso, n: 0, wantIso: []int{0}},
+		{desc: "bulk", xfer: bulk, n: 100},
+	} {
+		tc.xfer.setLength(tc.n)
+		if got := fakeIsoLengths(ctx, tc.xfer); !reflect.DeepEqual(got, tc.wantIso) {
+			t.Errorf("%s: setLength(%d): isochronous packets: got %v, want %v", tc.desc, tc.n, got, tc.wantIso)
 		}
 	}
 }
@@ -98,7 +184,7 @@ func TestTransferProtocol(t *testing.T) {
 			Direction:     EndpointDirectionIn,
 			TransferType:  TransferTypeBulk,
 			MaxPacketSize: 512,
-		}, 10240)
+		}, 10240, 0, false)
 		if err != nil {
 			t.Fatalf("newUSBTransfer: %v", err)
 		}
@@ -154,6 +240,59 @@ func TestTransferProtocol(t *testing.T) {
 	}
 }
 
+func TestTransferLatency(t *testing.T) {
+	t.Parallel()
+	f := newFakeLibusb()
+	ctx := newContextWithImpl(f)
+	defer func() {
+		if err := ctx.Close(); err != nil {
+			t.Errorf("Context.Close(): %v", err)
+		}
+	}()
+
+	xfer, err := newUSBTransfer(ctx, nil, &EndpointDesc{
+		Number:        6,
+		Direction:     EndpointDirectionIn,
+		TransferType:  TransferTypeBulk,
+		MaxPacketSize: 512,
+	}, 512, 0, false)
+	if err != nil {
+		t.Fatalf("newUSBTransfer: %v", err)
+	}
+	defer xfer.free()
+	var stats transferStats
+	xfer.stats = []*transferStats{&stats}
+
+	completed := make(chan struct{})
+	go func() {
+		ft := f.waitForSubmitted(nil)
+		ft.setData(make([]byte, 512))
+		ft.setStatus(TransferCompleted)
+		close(completed)
+	}()
+	if err := xfer.submit(); err != nil {
+		t.Fatalf("xfer.submit(): %v", err)
+	}
+	<-completed
+	// The result is collected long after the completion.
+	time.Sleep(300 * time.Millisecond)
+	if _, err := xfer.wait(context.Background()); err != nil {
+		t.Fatalf("xfer.wait(): %v", err)
+	}
+	got := stats.snapshot()
+	for _, b := range got.Latency {
+		if b.Count == 0 {
+			continue
+		}
+		if b.UpTo > 250*time.Millisecond {
+			t.Errorf("Latency: got a transfer in the bucket up to %v, want the latency of the completion, not of wait()", b.UpTo)
+		}
+	}
+	if got.Stalled > 250*time.Millisecond {
+		t.Errorf("Stalled: got %v, want the time blocked in wait() only", got.Stalled)
+	}
+}
+
 func BenchmarkSubSlice(b *testing.B) {
 	x := make([]byte, 512)
 	start, len := 50, 50
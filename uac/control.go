@@ -0,0 +1,220 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uac
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// Class-specific requests. UAC1 uses separate requests for each attribute,
+// UAC2 only has CUR and RANGE, with the direction given by the request type.
+const (
+	reqSetCur  = 0x01
+	reqGetCur  = 0x81
+	reqGetMin  = 0x82
+	reqGetMax  = 0x83
+	reqGetRes  = 0x84
+	req2Cur    = 0x01
+	req2Range  = 0x02
+	csSamFreq  = 0x01 // sampling frequency control of an endpoint (UAC1) or clock source (UAC2)
+	fuMute     = 0x01
+	fuVolume   = 0x02
+	volumeUnit = 256 // volume controls use 1/256 dB steps
+)
+
+// controller carries the requests to the endpoints, clock sources and
+// feature units of the audio function. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// RateRange is a range of sample rates supported by a clock source.
+// A discrete rate is reported as a range with Min == Max.
+type RateRange struct {
+	Min, Max, Res int
+}
+
+// controls implements the class-specific requests of an audio function.
+type controls struct {
+	ctl controller
+	// intf is the number of the AudioControl interface.
+	intf int
+	v    Version
+}
+
+// entity issues a request addressed to an entity (unit, terminal
+// or clock) of the AudioControl interface.
+func (c *controls) entity(in bool, req uint8, id, sel, ch int, data []byte) error {
+	rType := uint8(gousb.ControlClass | gousb.ControlInterface)
+	if in {
+		rType |= gousb.ControlIn
+	} else {
+		rType |= gousb.ControlOut
+	}
+	n, err := c.ctl.Control(rType, req, uint16(sel)<<8|uint16(ch), uint16(id)<<8|uint16(c.intf), data)
+	if err != nil {
+		return fmt.Errorf("request 0x%02x for control %d of entity %d: %v", req, sel, id, err)
+	}
+	if in && n < len(data) {
+		return fmt.Errorf("request 0x%02x for control %d of entity %d: got %d bytes, want %d", req, sel, id, n, len(data))
+	}
+	return nil
+}
+
+// getCur reads the current value of a control. UAC1 and UAC2 share
+// the request number of SET_CUR/CUR, but UAC1 uses a different number
+// for GET_CUR.
+func (c *controls) getCur(id, sel, ch int, data []byte) error {
+	req := uint8(reqGetCur)
+	if c.v == UAC2 {
+		req = req2Cur
+	}
+	return c.entity(true, req, id, sel, ch, data)
+}
+
+func (c *controls) setCur(id, sel, ch int, data []byte) error {
+	return c.entity(false, reqSetCur, id, sel, ch, data)
+}
+
+// setEndpointRate sets the sampling frequency through the endpoint control
+// of a UAC1 streaming endpoint.
+func (c *controls) setEndpointRate(ep gousb.EndpointAddress, rate int) error {
+	data := []byte{byte(rate), byte(rate >> 8), byte(rate >> 16)}
+	if _, err := c.ctl.Control(gousb.ControlOut|gousb.ControlClass|gousb.ControlEndpoint, reqSetCur, csSamFreq<<8, uint16(ep), data); err != nil {
+		return fmt.Errorf("SET_CUR sampling frequency of endpoint %s: %v", ep, err)
+	}
+	return nil
+}
+
+// endpointRate reads the sampling frequency of a UAC1 streaming endpoint.
+func (c *controls) endpointRate(ep gousb.EndpointAddress) (int, error) {
+	data := make([]byte, 3)
+	n, err := c.ctl.Control(gousb.ControlIn|gousb.ControlClass|gousb.ControlEndpoint, reqGetCur, csSamFreq<<8, uint16(ep), data)
+	if err != nil {
+		return 0, fmt.Errorf("GET_CUR sampling frequency of endpoint %s: %v", ep, err)
+	}
+	if n < 3 {
+		return 0, fmt.Errorf("GET_CUR sampling frequency of endpoint %s: got %d bytes, want 3", ep, n)
+	}
+	return le24(data), nil
+}
+
+// setClockRate sets the sampling frequency of a UAC2 clock source.
+func (c *controls) setClockRate(clock, rate int) error {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, uint32(rate))
+	return c.setCur(clock, csSamFreq, 0, data)
+}
+
+// clockRate reads the sampling frequency of a UAC2 clock source.
+func (c *controls) clockRate(clock int) (int, error) {
+	data := make([]byte, 4)
+	if err := c.getCur(clock, csSamFreq, 0, data); err != nil {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint32(data)), nil
+}
+
+// clockRates reads the RANGE attribute of the sampling frequency control
+// of a UAC2 clock source.
+func (c *controls) clockRates(clock int) ([]RateRange, error) {
+	// The number of subranges determines the length of the response,
+	// so read it first.
+	hdr := make([]byte, 2)
+	if err := c.entity(true, req2Range, clock, csSamFreq, 0, hdr); err != nil {
+		return nil, err
+	}
+	n := int(binary.LittleEndian.Uint16(hdr))
+	data := make([]byte, 2+12*n)
+	if err := c.entity(true, req2Range, clock, csSamFreq, 0, data); err != nil {
+		return nil, err
+	}
+	ret := make([]RateRange, n)
+	for i := range ret {
+		b := data[2+12*i:]
+		ret[i] = RateRange{
+			Min: int(binary.LittleEndian.Uint32(b)),
+			Max: int(binary.LittleEndian.Uint32(b[4:])),
+			Res: int(binary.LittleEndian.Uint32(b[8:])),
+		}
+	}
+	return ret, nil
+}
+
+func (c *controls) mute(unit, ch int) (bool, error) {
+	data := make([]byte, 1)
+	if err := c.getCur(unit, fuMute, ch, data); err != nil {
+		return false, err
+	}
+	return data[0] != 0, nil
+}
+
+func (c *controls) setMute(unit, ch int, mute bool) error {
+	data := []byte{0}
+	if mute {
+		data[0] = 1
+	}
+	return c.setCur(unit, fuMute, ch, data)
+}
+
+func (c *controls) volume(unit, ch int) (float64, error) {
+	data := make([]byte, 2)
+	if err := c.getCur(unit, fuVolume, ch, data); err != nil {
+		return 0, err
+	}
+	return toDecibels(data), nil
+}
+
+func (c *controls) setVolume(unit, ch int, db float64) error {
+	data := make([]byte, 2)
+	binary.LittleEndian.PutUint16(data, uint16(int16(db*volumeUnit)))
+	return c.setCur(unit, fuVolume, ch, data)
+}
+
+func (c *controls) volumeRange(unit, ch int) (min, max, res float64, err error) {
+	if c.v == UAC1 {
+		var vals [3]float64
+		for i, req := range []uint8{reqGetMin, reqGetMax, reqGetRes} {
+			data := make([]byte, 2)
+			if err := c.entity(true, req, unit, fuVolume, ch, data); err != nil {
+				return 0, 0, 0, err
+			}
+			vals[i] = toDecibels(data)
+		}
+		return vals[0], vals[1], vals[2], nil
+	}
+	hdr := make([]byte, 2)
+	if err := c.entity(true, req2Range, unit, fuVolume, ch, hdr); err != nil {
+		return 0, 0, 0, err
+	}
+	n := int(binary.LittleEndian.Uint16(hdr))
+	if n == 0 {
+		return 0, 0, 0, fmt.Errorf("volume control of unit %d reports no ranges", unit)
+	}
+	data := make([]byte, 2+6*n)
+	if err := c.entity(true, req2Range, unit, fuVolume, ch, data); err != nil {
+		return 0, 0, 0, err
+	}
+	// Subranges are sorted in ascending order.
+	first, last := data[2:], data[2+6*(n-1):]
+	return toDecibels(first), toDecibels(last[2:]), toDecibels(first[4:]), nil
+}
+
+func toDecibels(b []byte) float64 {
+	return float64(int16(binary.LittleEndian.Uint16(b))) / volumeUnit
+}
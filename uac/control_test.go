@@ -0,0 +1,153 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uac
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+type ctlKey struct {
+	recipient uint8
+	req       uint8
+	val, idx  uint16
+}
+
+// fakeAudio implements class-specific requests by storing the data of
+// SET requests and returning it for the matching GET requests. Values of
+// other GET requests can be preset in vals.
+type fakeAudio struct {
+	v    Version
+	vals map[ctlKey][]byte
+}
+
+func (f *fakeAudio) Control(rType, request uint8, val, idx uint16, data []byte) (int, error) {
+	if rType&0x60 != gousb.ControlClass {
+		return 0, errors.New("not a class request")
+	}
+	k := ctlKey{rType & 0x1f, request, val, idx}
+	if rType&gousb.ControlIn == 0 {
+		// SET_CUR and CUR share the number, GET_CUR is 0x81 in UAC1.
+		if f.v == UAC1 {
+			k.req = reqGetCur
+		}
+		f.vals[k] = append([]byte(nil), data...)
+		return len(data), nil
+	}
+	v, ok := f.vals[k]
+	if !ok {
+		return 0, gousb.ErrorPipe
+	}
+	return copy(data, v), nil
+}
+
+func TestVolumeAndMute(t *testing.T) {
+	for _, v := range []Version{UAC1, UAC2} {
+		f := &fakeAudio{v: v, vals: map[ctlKey][]byte{}}
+		c := &controls{ctl: f, intf: 0, v: v}
+		if err := c.setMute(2, 0, true); err != nil {
+			t.Fatalf("%s: setMute(): %v", v, err)
+		}
+		if got, err := c.mute(2, 0); err != nil || !got {
+			t.Errorf("%s: mute(): got %v, %v, want true, nil", v, got, err)
+		}
+		if err := c.setVolume(2, 1, -12.5); err != nil {
+			t.Fatalf("%s: setVolume(): %v", v, err)
+		}
+		want := ctlKey{uint8(gousb.ControlInterface), reqGetCur, fuVolume<<8 | 1, 2 << 8}
+		if v == UAC2 {
+			want.req = req2Cur
+		}
+		if got := f.vals[want]; !reflect.DeepEqual(got, []byte{0x80, 0xf3}) {
+			t.Errorf("%s: setVolume(-12.5) sent %x, want 80f3", v, got)
+		}
+		if got, err := c.volume(2, 1); err != nil || got != -12.5 {
+			t.Errorf("%s: volume(): got %v, %v, want -12.5, nil", v, got, err)
+		}
+		if _, err := c.volume(7, 1); err == nil {
+			t.Errorf("%s: volume() of a missing unit: got nil error, want non-nil", v)
+		}
+	}
+}
+
+func TestVolumeRange(t *testing.T) {
+	key := func(req uint8) ctlKey { return ctlKey{uint8(gousb.ControlInterface), req, fuVolume << 8, 2<<8 | 3} }
+	uac1 := &fakeAudio{v: UAC1, vals: map[ctlKey][]byte{
+		key(reqGetMin): {0x00, 0xc4}, // -60dB
+		key(reqGetMax): {0x00, 0x00},
+		key(reqGetRes): {0x80, 0x00}, // 0.5dB
+	}}
+	uac2 := &fakeAudio{v: UAC2, vals: map[ctlKey][]byte{
+		// two subranges: -60..-20 in 1dB steps, -20..6 in 0.5dB steps
+		key(req2Range): {0x02, 0x00, 0x00, 0xc4, 0x00, 0xec, 0x00, 0x01, 0x00, 0xec, 0x00, 0x06, 0x80, 0x00},
+	}}
+	for _, tc := range []struct {
+		f                *fakeAudio
+		wantMin, wantMax float64
+		wantRes          float64
+	}{
+		{uac1, -60, 0, 0.5},
+		{uac2, -60, 6, 1},
+	} {
+		c := &controls{ctl: tc.f, intf: 3, v: tc.f.v}
+		min, max, res, err := c.volumeRange(2, 0)
+		if err != nil {
+			t.Errorf("%s: volumeRange(): %v", tc.f.v, err)
+			continue
+		}
+		if min != tc.wantMin || max != tc.wantMax || res != tc.wantRes {
+			t.Errorf("%s: volumeRange(): got %v, %v, %v, want %v, %v, %v", tc.f.v, min, max, res, tc.wantMin, tc.wantMax, tc.wantRes)
+		}
+	}
+}
+
+func TestSampleRateControls(t *testing.T) {
+	f := &fakeAudio{v: UAC1, vals: map[ctlKey][]byte{}}
+	c := &controls{ctl: f, v: UAC1}
+	if err := c.setEndpointRate(0x01, 44100); err != nil {
+		t.Fatalf("setEndpointRate(): %v", err)
+	}
+	if got := f.vals[ctlKey{uint8(gousb.ControlEndpoint), reqGetCur, csSamFreq << 8, 0x01}]; !reflect.DeepEqual(got, []byte{0x44, 0xac, 0x00}) {
+		t.Errorf("setEndpointRate(44100) sent %x, want 44ac00", got)
+	}
+	if got, err := c.endpointRate(0x01); err != nil || got != 44100 {
+		t.Errorf("endpointRate(): got %d, %v, want 44100, nil", got, err)
+	}
+
+	f = &fakeAudio{v: UAC2, vals: map[ctlKey][]byte{
+		{uint8(gousb.ControlInterface), req2Range, csSamFreq << 8, 0x10 << 8}: {
+			0x02, 0x00,
+			0x44, 0xac, 0x00, 0x00, 0x44, 0xac, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x80, 0xbb, 0x00, 0x00, 0x00, 0x77, 0x01, 0x00, 0x80, 0xbb, 0x00, 0x00,
+		},
+	}}
+	c = &controls{ctl: f, v: UAC2}
+	rates, err := c.clockRates(0x10)
+	if err != nil {
+		t.Fatalf("clockRates(): %v", err)
+	}
+	if want := []RateRange{{44100, 44100, 0}, {48000, 96000, 48000}}; !reflect.DeepEqual(rates, want) {
+		t.Errorf("clockRates(): got %v, want %v", rates, want)
+	}
+	if err := c.setClockRate(0x10, 96000); err != nil {
+		t.Fatalf("setClockRate(): %v", err)
+	}
+	if got, err := c.clockRate(0x10); err != nil || got != 96000 {
+		t.Errorf("clockRate(): got %d, %v, want 96000, nil", got, err)
+	}
+}
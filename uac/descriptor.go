@@ -0,0 +1,614 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uac
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/gousb"
+)
+
+// Interface subclasses of the audio class.
+const (
+	subClassAudioControl   gousb.Class = 0x01
+	subClassAudioStreaming gousb.Class = 0x02
+)
+
+// protocolUAC2 is the interface protocol of UAC2 interfaces. UAC1
+// interfaces use protocol 0.
+const protocolUAC2 gousb.Protocol = 0x20
+
+// dtCSInterface is the descriptor type of class-specific interface descriptors.
+const dtCSInterface = 0x24
+
+// dtCSEndpoint is the descriptor type of class-specific endpoint descriptors.
+const dtCSEndpoint = 0x25
+
+// epGeneral is the subtype of the class-specific isochronous audio data
+// endpoint descriptor.
+const epGeneral = 0x01
+
+// AudioControl interface descriptor subtypes. Subtypes up to the feature
+// unit are shared by UAC1 and UAC2.
+const (
+	acHeader         = 0x01
+	acInputTerminal  = 0x02
+	acOutputTerminal = 0x03
+	acMixerUnit      = 0x04
+	acSelectorUnit   = 0x05
+	acFeatureUnit    = 0x06
+	ac2ClockSource   = 0x0a
+	ac2ClockSelector = 0x0b
+	ac2ClockMultiply = 0x0c
+)
+
+// AudioStreaming interface descriptor subtypes.
+const (
+	asGeneral    = 0x01
+	asFormatType = 0x02
+	formatTypeI  = 0x01
+)
+
+// Version is the version of the USB Audio Class spec implemented by
+// an audio function.
+type Version int
+
+// Supported audio class versions.
+const (
+	UAC1 Version = 1
+	UAC2 Version = 2
+)
+
+// String returns a human-readable name of the version.
+func (v Version) String() string {
+	return fmt.Sprintf("UAC%d", int(v))
+}
+
+func versionOf(p gousb.Protocol) Version {
+	if p == protocolUAC2 {
+		return UAC2
+	}
+	return UAC1
+}
+
+// TerminalType identifies the kind of an input or output terminal.
+type TerminalType uint16
+
+// A selection of terminal types defined by the USB Audio Terminal Types spec.
+const (
+	TerminalUSBStreaming TerminalType = 0x0101
+	TerminalMicrophone   TerminalType = 0x0201
+	TerminalSpeaker      TerminalType = 0x0301
+	TerminalHeadphones   TerminalType = 0x0302
+	TerminalHeadset      TerminalType = 0x0402
+	TerminalLineConn     TerminalType = 0x0603
+	TerminalSPDIF        TerminalType = 0x0605
+)
+
+// String returns a human-readable name of the terminal type.
+func (t TerminalType) String() string {
+	switch t {
+	case TerminalUSBStreaming:
+		return "USB streaming"
+	case TerminalMicrophone:
+		return "microphone"
+	case TerminalSpeaker:
+		return "speaker"
+	case TerminalHeadphones:
+		return "headphones"
+	case TerminalHeadset:
+		return "headset"
+	case TerminalLineConn:
+		return "line connector"
+	case TerminalSPDIF:
+		return "S/PDIF"
+	}
+	return fmt.Sprintf("terminal type 0x%04x", uint16(t))
+}
+
+// Terminal describes an input or output terminal of the audio function.
+type Terminal struct {
+	// ID identifies the terminal within the audio function.
+	ID int
+	// Input is true for input terminals and false for output terminals.
+	Input bool
+	// Type is the terminal type.
+	Type TerminalType
+	// AssocTerminal is the ID of the associated terminal, or 0.
+	AssocTerminal int
+	// SourceID is the ID of the unit or terminal connected to an output
+	// terminal. It is 0 for input terminals.
+	SourceID int
+	// ClockSourceID is the ID of the clock entity the terminal is
+	// connected to (UAC2 only).
+	ClockSourceID int
+	// Channels is the number of logical channels of an input terminal.
+	Channels int
+}
+
+// FeatureControls is the set of controls supported by one channel
+// of a feature unit.
+type FeatureControls struct {
+	Mute   bool
+	Volume bool
+}
+
+// FeatureUnit describes a feature unit, which provides volume and mute
+// controls for the audio channels passing through it.
+type FeatureUnit struct {
+	// ID identifies the unit within the audio function.
+	ID int
+	// SourceID is the ID of the unit or terminal connected to the input.
+	SourceID int
+	// Controls lists the controls available for each channel. Controls[0]
+	// is the master channel, Controls[1] is the first logical channel etc.
+	Controls []FeatureControls
+}
+
+// ClockKind identifies the type of a UAC2 clock entity.
+type ClockKind uint8
+
+// Clock entity kinds.
+const (
+	ClockSource     ClockKind = ac2ClockSource
+	ClockSelector   ClockKind = ac2ClockSelector
+	ClockMultiplier ClockKind = ac2ClockMultiply
+)
+
+// Clock describes a UAC2 clock source, selector or multiplier.
+type Clock struct {
+	// ID identifies the clock entity within the audio function.
+	ID int
+	// Kind is the type of the clock entity.
+	Kind ClockKind
+	// Sources lists the IDs of the clock entities connected to the inputs
+	// of a clock selector or multiplier.
+	Sources []int
+	// Programmable is true if the sampling frequency of a clock source
+	// can be set by the host.
+	Programmable bool
+}
+
+// AudioControl contains the information parsed from the class-specific
+// descriptors of the AudioControl interface.
+type AudioControl struct {
+	// Interface is the number of the AudioControl interface.
+	Interface int
+	// Version is the audio class version implemented by the function.
+	Version Version
+	// ADC is the version of the audio device class spec.
+	ADC gousb.BCD
+	// StreamingInterfaces lists the interface numbers of the
+	// AudioStreaming interfaces belonging to this function. It's only
+	// populated for UAC1 functions, UAC2 uses interface association
+	// descriptors instead.
+	StreamingInterfaces []int
+	// Terminals lists the input and output terminals.
+	Terminals []Terminal
+	// FeatureUnits lists the feature units.
+	FeatureUnits []FeatureUnit
+	// Clocks lists the clock entities of a UAC2 function.
+	Clocks []Clock
+}
+
+// Terminal returns the terminal with the given ID.
+func (ac *AudioControl) Terminal(id int) (*Terminal, error) {
+	for i := range ac.Terminals {
+		if ac.Terminals[i].ID == id {
+			return &ac.Terminals[i], nil
+		}
+	}
+	return nil, fmt.Errorf("audio function has no terminal with ID %d", id)
+}
+
+// clockSource follows the clock entities starting from id until it finds
+// a clock source. Selectors are followed through their first input.
+func (ac *AudioControl) clockSource(id int) (*Clock, error) {
+	for hops := 0; hops < len(ac.Clocks); hops++ {
+		var c *Clock
+		for i := range ac.Clocks {
+			if ac.Clocks[i].ID == id {
+				c = &ac.Clocks[i]
+			}
+		}
+		if c == nil {
+			return nil, fmt.Errorf("audio function has no clock entity with ID %d", id)
+		}
+		if c.Kind == ClockSource {
+			return c, nil
+		}
+		if len(c.Sources) == 0 {
+			return nil, fmt.Errorf("clock entity %d has no inputs", id)
+		}
+		id = c.Sources[0]
+	}
+	return nil, fmt.Errorf("loop in clock entities starting at ID %d", id)
+}
+
+// FormatI describes a Type I (PCM) audio format.
+type FormatI struct {
+	// Channels is the number of interleaved audio channels.
+	Channels int
+	// SubframeSize is the number of bytes occupied by a single sample
+	// of a single channel.
+	SubframeSize int
+	// BitResolution is the number of bits used in a subframe.
+	BitResolution int
+	// SampleRates lists the discrete sample rates supported by a UAC1
+	// format. If empty, MinRate and MaxRate describe a continuous range.
+	// UAC2 formats don't carry sample rates, see Device.SampleRates.
+	SampleRates []int
+	// MinRate and MaxRate bound a continuous sample rate range.
+	MinRate, MaxRate int
+}
+
+// FrameSize returns the number of bytes of a single audio frame, i.e.
+// one sample of every channel.
+func (f FormatI) FrameSize() int {
+	return f.Channels * f.SubframeSize
+}
+
+// SupportsRate returns true if the format can be streamed at a given rate
+// according to its descriptor. It always returns true for UAC2 formats.
+func (f FormatI) SupportsRate(rate int) bool {
+	if len(f.SampleRates) > 0 {
+		for _, r := range f.SampleRates {
+			if r == rate {
+				return true
+			}
+		}
+		return false
+	}
+	if f.MinRate == 0 && f.MaxRate == 0 {
+		return true
+	}
+	return rate >= f.MinRate && rate <= f.MaxRate
+}
+
+// StreamingSetting describes an alternate setting of an AudioStreaming
+// interface, carrying audio in a particular format.
+type StreamingSetting struct {
+	// Interface and Alternate identify the alternate setting.
+	Interface, Alternate int
+	// TerminalLink is the ID of the terminal connected to this interface.
+	TerminalLink int
+	// Format is the audio format.
+	Format FormatI
+	// Endpoint is the isochronous data endpoint.
+	Endpoint gousb.EndpointDesc
+	// Feedback is the explicit feedback endpoint of an asynchronous sink,
+	// or nil.
+	Feedback *gousb.EndpointDesc
+	// SampleRateControl is true if the data endpoint of a UAC1 setting
+	// implements the sampling frequency control. It's always false for
+	// UAC2 settings, where the rate is set on the clock source.
+	SampleRateControl bool
+}
+
+// String returns a human-readable description of the streaming setting.
+func (s StreamingSetting) String() string {
+	return fmt.Sprintf("interface %d alt %d: %s %d channels, %d bits", s.Interface, s.Alternate, s.Endpoint.Direction, s.Format.Channels, s.Format.BitResolution)
+}
+
+func splitDescriptors(b []byte) ([][]byte, error) {
+	var ret [][]byte
+	for len(b) > 0 {
+		l := int(b[0])
+		if l < 2 || l > len(b) {
+			return nil, fmt.Errorf("malformed descriptor: bLength %d with %d bytes remaining", l, len(b))
+		}
+		ret = append(ret, b[:l])
+		b = b[l:]
+	}
+	return ret, nil
+}
+
+func le16(b []byte) int { return int(binary.LittleEndian.Uint16(b)) }
+func le24(b []byte) int { return int(b[0]) | int(b[1])<<8 | int(b[2])<<16 }
+
+// ParseAudioControl parses the class-specific descriptors of an AudioControl
+// interface, as available in gousb.InterfaceSetting.Extra. The version
+// determines the layout of the descriptors.
+func ParseAudioControl(extra []byte, v Version) (*AudioControl, error) {
+	descs, err := splitDescriptors(extra)
+	if err != nil {
+		return nil, err
+	}
+	ac := &AudioControl{Version: v}
+	var seenHeader bool
+	for _, d := range descs {
+		if d[1] != dtCSInterface || len(d) < 3 {
+			continue
+		}
+		switch d[2] {
+		case acHeader:
+			if len(d) < 8 {
+				return nil, fmt.Errorf("AC header descriptor too short: %d bytes", len(d))
+			}
+			seenHeader = true
+			ac.ADC = gousb.BCD(le16(d[3:]))
+			if v == UAC1 {
+				n := int(d[7])
+				if len(d) < 8+n {
+					return nil, fmt.Errorf("AC header descriptor too short for %d interfaces: %d bytes", n, len(d))
+				}
+				for _, i := range d[8 : 8+n] {
+					ac.StreamingInterfaces = append(ac.StreamingInterfaces, int(i))
+				}
+			}
+		case acInputTerminal:
+			t, err := parseInputTerminal(d, v)
+			if err != nil {
+				return nil, err
+			}
+			ac.Terminals = append(ac.Terminals, *t)
+		case acOutputTerminal:
+			if (v == UAC1 && len(d) < 9) || (v == UAC2 && len(d) < 12) {
+				return nil, fmt.Errorf("output terminal descriptor too short: %d bytes", len(d))
+			}
+			t := Terminal{
+				ID:            int(d[3]),
+				Type:          TerminalType(le16(d[4:])),
+				AssocTerminal: int(d[6]),
+				SourceID:      int(d[7]),
+			}
+			if v == UAC2 {
+				t.ClockSourceID = int(d[8])
+			}
+			ac.Terminals = append(ac.Terminals, t)
+		case acFeatureUnit:
+			u, err := parseFeatureUnit(d, v)
+			if err != nil {
+				return nil, err
+			}
+			ac.FeatureUnits = append(ac.FeatureUnits, *u)
+		case ac2ClockSource, ac2ClockSelector, ac2ClockMultiply:
+			if v != UAC2 {
+				continue
+			}
+			c, err := parseClock(d)
+			if err != nil {
+				return nil, err
+			}
+			ac.Clocks = append(ac.Clocks, *c)
+		}
+	}
+	if !seenHeader {
+		return nil, fmt.Errorf("AC header descriptor not found")
+	}
+	return ac, nil
+}
+
+func parseInputTerminal(d []byte, v Version) (*Terminal, error) {
+	if (v == UAC1 && len(d) < 12) || (v == UAC2 && len(d) < 17) {
+		return nil, fmt.Errorf("input terminal descriptor too short: %d bytes", len(d))
+	}
+	t := &Terminal{
+		ID:            int(d[3]),
+		Input:         true,
+		Type:          TerminalType(le16(d[4:])),
+		AssocTerminal: int(d[6]),
+	}
+	if v == UAC2 {
+		t.ClockSourceID = int(d[7])
+		t.Channels = int(d[8])
+	} else {
+		t.Channels = int(d[7])
+	}
+	return t, nil
+}
+
+func parseFeatureUnit(d []byte, v Version) (*FeatureUnit, error) {
+	if len(d) < 6 {
+		return nil, fmt.Errorf("feature unit descriptor too short: %d bytes", len(d))
+	}
+	u := &FeatureUnit{ID: int(d[3]), SourceID: int(d[4])}
+	if v == UAC1 {
+		// bLength = 7 + (channels+1) * bControlSize
+		size := int(d[5])
+		if size == 0 || len(d) < 7+size {
+			return nil, fmt.Errorf("feature unit %d: invalid control size %d", u.ID, size)
+		}
+		for off := 6; off+size <= len(d)-1; off += size {
+			u.Controls = append(u.Controls, FeatureControls{
+				Mute:   d[off]&0x01 != 0,
+				Volume: d[off]&0x02 != 0,
+			})
+		}
+		return u, nil
+	}
+	// UAC2: bLength = 6 + (channels+1) * 4, two bits per control.
+	for off := 5; off+4 <= len(d)-1; off += 4 {
+		u.Controls = append(u.Controls, FeatureControls{
+			Mute:   d[off]&0x03 == 0x03,
+			Volume: d[off]&0x0c == 0x0c,
+		})
+	}
+	return u, nil
+}
+
+func parseClock(d []byte) (*Clock, error) {
+	if len(d) < 5 {
+		return nil, fmt.Errorf("clock entity descriptor too short: %d bytes", len(d))
+	}
+	c := &Clock{ID: int(d[3]), Kind: ClockKind(d[2])}
+	switch c.Kind {
+	case ClockSource:
+		if len(d) < 8 {
+			return nil, fmt.Errorf("clock source %d descriptor too short: %d bytes", c.ID, len(d))
+		}
+		c.Programmable = d[5]&0x03 == 0x03
+	case ClockSelector:
+		n := int(d[4])
+		if len(d) < 5+n {
+			return nil, fmt.Errorf("clock selector %d descriptor too short: %d bytes", c.ID, len(d))
+		}
+		for _, s := range d[5 : 5+n] {
+			c.Sources = append(c.Sources, int(s))
+		}
+	case ClockMultiplier:
+		c.Sources = []int{int(d[4])}
+	}
+	return c, nil
+}
+
+// ParseAudioStreaming parses the class-specific descriptors of an
+// alternate setting of an AudioStreaming interface. It returns the terminal
+// link and the audio format. Only Type I (PCM) formats are supported.
+func ParseAudioStreaming(extra []byte, v Version) (terminalLink int, format *FormatI, err error) {
+	descs, err := splitDescriptors(extra)
+	if err != nil {
+		return 0, nil, err
+	}
+	var seenGeneral bool
+	for _, d := range descs {
+		if d[1] != dtCSInterface || len(d) < 3 {
+			continue
+		}
+		switch d[2] {
+		case asGeneral:
+			if (v == UAC1 && len(d) < 7) || (v == UAC2 && len(d) < 16) {
+				return 0, nil, fmt.Errorf("AS_GENERAL descriptor too short: %d bytes", len(d))
+			}
+			seenGeneral = true
+			terminalLink = int(d[3])
+			if v == UAC2 {
+				if d[5] != formatTypeI {
+					return 0, nil, fmt.Errorf("unsupported format type %d", d[5])
+				}
+				if format == nil {
+					format = &FormatI{}
+				}
+				format.Channels = int(d[10])
+			}
+		case asFormatType:
+			if len(d) < 4 || d[3] != formatTypeI {
+				return 0, nil, fmt.Errorf("unsupported format type descriptor")
+			}
+			if format == nil {
+				format = &FormatI{}
+			}
+			if err := parseFormatI(d, v, format); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	if !seenGeneral {
+		return 0, nil, fmt.Errorf("AS_GENERAL descriptor not found")
+	}
+	if format == nil || format.SubframeSize == 0 {
+		return 0, nil, fmt.Errorf("FORMAT_TYPE descriptor not found")
+	}
+	return terminalLink, format, nil
+}
+
+func parseFormatI(d []byte, v Version, f *FormatI) error {
+	if v == UAC2 {
+		if len(d) < 6 {
+			return fmt.Errorf("FORMAT_TYPE_I descriptor too short: %d bytes", len(d))
+		}
+		f.SubframeSize = int(d[4])
+		f.BitResolution = int(d[5])
+		return nil
+	}
+	if len(d) < 8 {
+		return fmt.Errorf("FORMAT_TYPE_I descriptor too short: %d bytes", len(d))
+	}
+	f.Channels = int(d[4])
+	f.SubframeSize = int(d[5])
+	f.BitResolution = int(d[6])
+	n := int(d[7])
+	if n == 0 {
+		if len(d) < 14 {
+			return fmt.Errorf("FORMAT_TYPE_I descriptor too short for a continuous range: %d bytes", len(d))
+		}
+		f.MinRate, f.MaxRate = le24(d[8:]), le24(d[11:])
+		return nil
+	}
+	if len(d) < 8+3*n {
+		return fmt.Errorf("FORMAT_TYPE_I descriptor too short for %d sample rates: %d bytes", n, len(d))
+	}
+	for i := 0; i < n; i++ {
+		f.SampleRates = append(f.SampleRates, le24(d[8+3*i:]))
+	}
+	return nil
+}
+
+// sampleRateControl reports whether the class-specific isochronous endpoint
+// descriptor of a UAC1 data endpoint, found in the extra descriptors of
+// the endpoint, declares the sampling frequency control.
+func sampleRateControl(extra []byte) (bool, error) {
+	descs, err := splitDescriptors(extra)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range descs {
+		if d[1] != dtCSEndpoint || len(d) < 3 || d[2] != epGeneral {
+			continue
+		}
+		if len(d) < 7 {
+			return false, fmt.Errorf("EP_GENERAL descriptor too short: %d bytes", len(d))
+		}
+		// bmAttributes D0: Sampling Frequency control.
+		return d[3]&0x01 != 0, nil
+	}
+	return false, nil
+}
+
+// parseStreamingInterface returns the streaming settings of all alternate
+// settings of an AudioStreaming interface that carry a PCM format.
+func parseStreamingInterface(intf gousb.InterfaceDesc, v Version) ([]StreamingSetting, error) {
+	var ret []StreamingSetting
+	for _, alt := range intf.AltSettings {
+		if len(alt.Endpoints) == 0 {
+			// zero-bandwidth setting
+			continue
+		}
+		link, f, err := ParseAudioStreaming(alt.Extra, v)
+		if err != nil {
+			return nil, fmt.Errorf("AudioStreaming interface %d alt %d: %v", alt.Number, alt.Alternate, err)
+		}
+		s := StreamingSetting{
+			Interface:    alt.Number,
+			Alternate:    alt.Alternate,
+			TerminalLink: link,
+			Format:       *f,
+		}
+		var found bool
+		for _, ep := range alt.Endpoints {
+			if ep.TransferType != gousb.TransferTypeIsochronous {
+				continue
+			}
+			if ep.UsageType == gousb.IsoUsageTypeFeedback {
+				ep := ep
+				s.Feedback = &ep
+				continue
+			}
+			s.Endpoint = ep
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("AudioStreaming interface %d alt %d has no isochronous data endpoint", alt.Number, alt.Alternate)
+		}
+		if v == UAC1 {
+			if s.SampleRateControl, err = sampleRateControl(s.Endpoint.Extra); err != nil {
+				return nil, fmt.Errorf("AudioStreaming interface %d alt %d endpoint %s: %v", alt.Number, alt.Alternate, s.Endpoint.Address, err)
+			}
+		}
+		ret = append(ret, s)
+	}
+	return ret, nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uac
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+// testAC1 is the class-specific part of the AudioControl interface
+// descriptor of a UAC1 headset: USB streaming -> feature unit -> speaker
+// and microphone -> USB streaming.
+var testAC1 = []byte{
+	// HEADER: ADC 1.00, streaming interfaces 1 and 2.
+	0x0a, 0x24, 0x01, 0x00, 0x01, 0x3e, 0x00, 0x02, 0x01, 0x02,
+	// INPUT_TERMINAL: ID 1, USB streaming, 2 channels.
+	0x0c, 0x24, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x03, 0x00, 0x00, 0x00,
+	// FEATURE_UNIT: ID 2, source 1, master mute, volume on channels 1 and 2.
+	0x0a, 0x24, 0x06, 0x02, 0x01, 0x01, 0x01, 0x02, 0x02, 0x00,
+	// OUTPUT_TERMINAL: ID 3, speaker, source 2.
+	0x09, 0x24, 0x03, 0x03, 0x01, 0x03, 0x00, 0x02, 0x00,
+	// INPUT_TERMINAL: ID 4, microphone, 1 channel.
+	0x0c, 0x24, 0x02, 0x04, 0x01, 0x02, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	// OUTPUT_TERMINAL: ID 5, USB streaming, source 4.
+	0x09, 0x24, 0x03, 0x05, 0x01, 0x01, 0x00, 0x04, 0x00,
+}
+
+// testAS1Out is an AudioStreaming setting of a UAC1 device: 2 channels,
+// 16 bits at 44.1 or 48kHz, linked to the USB streaming input terminal.
+var testAS1Out = []byte{
+	0x07, 0x24, 0x01, 0x01, 0x01, 0x01, 0x00,
+	0x0e, 0x24, 0x02, 0x01, 0x02, 0x02, 0x10, 0x02, 0x44, 0xac, 0x00, 0x80, 0xbb, 0x00,
+}
+
+// testAS1In is an AudioStreaming setting of a UAC1 device: 1 channel,
+// 16 bits at 8 to 48kHz, linked to the USB streaming output terminal.
+var testAS1In = []byte{
+	0x07, 0x24, 0x01, 0x05, 0x01, 0x01, 0x00,
+	0x0e, 0x24, 0x02, 0x01, 0x01, 0x02, 0x10, 0x00, 0x40, 0x1f, 0x00, 0x80, 0xbb, 0x00,
+}
+
+// testAC2 is the class-specific part of the AudioControl interface
+// descriptor of UAC2 speakers with a programmable clock behind a clock
+// selector.
+var testAC2 = []byte{
+	// HEADER: ADC 2.00.
+	0x09, 0x24, 0x01, 0x00, 0x02, 0x08, 0x3c, 0x00, 0x00,
+	// CLOCK_SOURCE: ID 0x10, programmable frequency.
+	0x08, 0x24, 0x0a, 0x10, 0x03, 0x07, 0x00, 0x00,
+	// CLOCK_SELECTOR: ID 0x11, input 0x10.
+	0x08, 0x24, 0x0b, 0x11, 0x01, 0x10, 0x03, 0x00,
+	// INPUT_TERMINAL: ID 1, USB streaming, clock 0x11, 2 channels.
+	0x11, 0x24, 0x02, 0x01, 0x01, 0x01, 0x00, 0x11, 0x02, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	// FEATURE_UNIT: ID 2, source 1, master mute and volume, volume on
+	// channel 1, read-only volume on channel 2.
+	0x12, 0x24, 0x06, 0x02, 0x01, 0x0f, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00,
+	// OUTPUT_TERMINAL: ID 3, speaker, source 2, clock 0x11.
+	0x0c, 0x24, 0x03, 0x03, 0x01, 0x03, 0x00, 0x02, 0x11, 0x00, 0x00, 0x00,
+}
+
+// testAS2 is an AudioStreaming setting of a UAC2 device: 2 channels,
+// 24 bits in 3 byte subslots.
+var testAS2 = []byte{
+	0x10, 0x24, 0x01, 0x01, 0x00, 0x01, 0x01, 0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x00, 0x00, 0x00,
+	0x06, 0x24, 0x02, 0x01, 0x03, 0x18,
+}
+
+func TestParseAudioControl(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		extra []byte
+		v     Version
+		want  *AudioControl
+	}{
+		{
+			desc:  "UAC1",
+			extra: testAC1,
+			v:     UAC1,
+			want: &AudioControl{
+				Version:             UAC1,
+				ADC:                 gousb.Version(1, 0),
+				StreamingInterfaces: []int{1, 2},
+				Terminals: []Terminal{
+					{ID: 1, Input: true, Type: TerminalUSBStreaming, Channels: 2},
+					{ID: 3, Type: TerminalSpeaker, SourceID: 2},
+					{ID: 4, Input: true, Type: TerminalMicrophone, Channels: 1},
+					{ID: 5, Type: TerminalUSBStreaming, SourceID: 4},
+				},
+				FeatureUnits: []FeatureUnit{{
+					ID:       2,
+					SourceID: 1,
+					Controls: []FeatureControls{{Mute: true}, {Volume: true}, {Volume: true}},
+				}},
+			},
+		},
+		{
+			desc:  "UAC2",
+			extra: testAC2,
+			v:     UAC2,
+			want: &AudioControl{
+				Version: UAC2,
+				ADC:     gousb.Version(2, 0),
+				Terminals: []Terminal{
+					{ID: 1, Input: true, Type: TerminalUSBStreaming, ClockSourceID: 0x11, Channels: 2},
+					{ID: 3, Type: TerminalSpeaker, SourceID: 2, ClockSourceID: 0x11},
+				},
+				FeatureUnits: []FeatureUnit{{
+					ID:       2,
+					SourceID: 1,
+					Controls: []FeatureControls{{Mute: true, Volume: true}, {Volume: true}, {}},
+				}},
+				Clocks: []Clock{
+					{ID: 0x10, Kind: ClockSource, Programmable: true},
+					{ID: 0x11, Kind: ClockSelector, Sources: []int{0x10}},
+				},
+			},
+		},
+	} {
+		got, err := ParseAudioControl(tc.extra, tc.v)
+		if err != nil {
+			t.Errorf("%s: ParseAudioControl(): %v", tc.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: ParseAudioControl():\ngot  %+v\nwant %+v", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestParseAudioStreaming(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		extra    []byte
+		v        Version
+		wantLink int
+		want     FormatI
+	}{
+		{
+			desc:     "UAC1 discrete rates",
+			extra:    testAS1Out,
+			v:        UAC1,
+			wantLink: 1,
+			want:     FormatI{Channels: 2, SubframeSize: 2, BitResolution: 16, SampleRates: []int{44100, 48000}},
+		},
+		{
+			desc:     "UAC1 continuous rates",
+			extra:    testAS1In,
+			v:        UAC1,
+			wantLink: 5,
+			want:     FormatI{Channels: 1, SubframeSize: 2, BitResolution: 16, MinRate: 8000, MaxRate: 48000},
+		},
+		{
+			desc:     "UAC2",
+			extra:    testAS2,
+			v:        UAC2,
+			wantLink: 1,
+			want:     FormatI{Channels: 2, SubframeSize: 3, BitResolution: 24},
+		},
+	} {
+		link, got, err := ParseAudioStreaming(tc.extra, tc.v)
+		if err != nil {
+			t.Errorf("%s: ParseAudioStreaming(): %v", tc.desc, err)
+			continue
+		}
+		if link != tc.wantLink {
+			t.Errorf("%s: ParseAudioStreaming(): got terminal link %d, want %d", tc.desc, link, tc.wantLink)
+		}
+		if !reflect.DeepEqual(*got, tc.want) {
+			t.Errorf("%s: ParseAudioStreaming(): got %+v, want %+v", tc.desc, *got, tc.want)
+		}
+	}
+}
+
+func TestParseDescriptorErrors(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		parse func([]byte) error
+		extra []byte
+	}{
+		{
+			desc:  "AC without header",
+			parse: func(b []byte) error { _, err := ParseAudioControl(b, UAC1); return err },
+			extra: testAC1[10:],
+		},
+		{
+			desc:  "AC truncated",
+			parse: func(b []byte) error { _, err := ParseAudioControl(b, UAC1); return err },
+			extra: testAC1[:15],
+		},
+		{
+			desc:  "UAC1 terminal parsed as UAC2",
+			parse: func(b []byte) error { _, err := ParseAudioControl(b, UAC2); return err },
+			extra: testAC1,
+		},
+		{
+			desc:  "AS without format",
+			parse: func(b []byte) error { _, _, err := ParseAudioStreaming(b, UAC1); return err },
+			extra: testAS1Out[:7],
+		},
+		{
+			desc:  "AS without AS_GENERAL",
+			parse: func(b []byte) error { _, _, err := ParseAudioStreaming(b, UAC1); return err },
+			extra: testAS1Out[7:],
+		},
+		{
+			desc:  "AS with a format type II",
+			parse: func(b []byte) error { _, _, err := ParseAudioStreaming(b, UAC1); return err },
+			extra: append(append([]byte(nil), testAS1Out[:7]...), 0x09, 0x24, 0x02, 0x02, 0x00, 0x01, 0x00, 0x04, 0x00),
+		},
+	} {
+		if err := tc.parse(tc.extra); err == nil {
+			t.Errorf("%s: got nil error, want non-nil", tc.desc)
+		}
+	}
+}
+
+func TestFormatSupportsRate(t *testing.T) {
+	discrete := FormatI{SampleRates: []int{44100, 48000}}
+	continuous := FormatI{MinRate: 8000, MaxRate: 48000}
+	for _, tc := range []struct {
+		f    FormatI
+		rate int
+		want bool
+	}{
+		{discrete, 44100, true},
+		{discrete, 32000, false},
+		{continuous, 8000, true},
+		{continuous, 96000, false},
+		{FormatI{}, 192000, true},
+	} {
+		if got := tc.f.SupportsRate(tc.rate); got != tc.want {
+			t.Errorf("%+v.SupportsRate(%d): got %v, want %v", tc.f, tc.rate, got, tc.want)
+		}
+	}
+}
+
+func TestClockSource(t *testing.T) {
+	ac, err := ParseAudioControl(testAC2, UAC2)
+	if err != nil {
+		t.Fatalf("ParseAudioControl(): %v", err)
+	}
+	c, err := ac.clockSource(0x11)
+	if err != nil {
+		t.Fatalf("clockSource(0x11): %v", err)
+	}
+	if c.ID != 0x10 {
+		t.Errorf("clockSource(0x11): got clock %d, want 16", c.ID)
+	}
+	if _, err := ac.clockSource(0x12); err == nil {
+		t.Error("clockSource(0x12): got nil error, want non-nil")
+	}
+	ac.Clocks[0] = Clock{ID: 0x10, Kind: ClockSelector, Sources: []int{0x11}}
+	if _, err := ac.clockSource(0x11); err == nil {
+		t.Error("clockSource() with a loop: got nil error, want non-nil")
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uac
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pacer computes the number of audio frames carried by each packet of
+// a playback stream. Frame counts are kept in frames times nanoseconds per
+// packet, so that rates that are not a multiple of the packet rate (e.g.
+// 44.1kHz at 1000 packets per second) are spread evenly across packets.
+type pacer struct {
+	mu sync.Mutex
+	// nominal is the number of frames per packet derived from the
+	// sample rate.
+	nominal uint64
+	// cur is the number of frames per packet requested by the device
+	// through the feedback endpoint, or nominal.
+	cur uint64
+	// acc accumulates the fractional frames.
+	acc uint64
+	// maxFrames is the number of frames that fit in a packet.
+	maxFrames int
+}
+
+func newPacer(rate int, period time.Duration, maxFrames int) *pacer {
+	nominal := uint64(rate) * uint64(period)
+	return &pacer{nominal: nominal, cur: nominal, maxFrames: maxFrames}
+}
+
+// next returns the number of frames to send in the next packet.
+func (p *pacer) next() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.acc += p.cur
+	n := int(p.acc / uint64(time.Second))
+	p.acc %= uint64(time.Second)
+	if n > p.maxFrames {
+		n = p.maxFrames
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// feedback updates the packet rate with a value reported by the device,
+// in frames per packet in 16.16 format. Values further than 1/8 off the
+// nominal rate are ignored.
+func (p *pacer) feedback(v uint32) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cur := uint64(v) * uint64(time.Second) >> 16
+	if cur < p.nominal-p.nominal/8 || cur > p.nominal+p.nominal/8 {
+		return false
+	}
+	p.cur = cur
+	return true
+}
+
+// parseFeedback converts a value read from a feedback endpoint into frames
+// per data packet in 16.16 format. Full speed devices report frames per 1ms
+// frame in 10.14 format on 3 bytes, high speed devices report frames per
+// 125us microframe in 16.16 format on 4 bytes. unit is the frame or
+// microframe duration and period is the interval between data packets.
+func parseFeedback(b []byte, highSpeed bool, unit, period time.Duration) (uint32, bool) {
+	var v uint64
+	switch {
+	case highSpeed && len(b) >= 4:
+		v = uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24
+	case !highSpeed && len(b) >= 3:
+		v = uint64(le24(b)) << 2
+	default:
+		return 0, false
+	}
+	return uint32(v * uint64(period) / uint64(unit)), true
+}
+
+// packetReader reads from an isochronous IN endpoint. *gousb.ReadStream
+// implements packetReader.
+type packetReader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+// packetWriter writes to an isochronous OUT endpoint. A single write
+// must result in a single packet. *gousb.WriteStream created with a
+// transfer size of one packet implements packetWriter.
+type packetWriter interface {
+	WriteContext(context.Context, []byte) (int, error)
+}
+
+// Capture is a stream of PCM audio frames recorded from the device.
+// Data is interleaved, with Setting.Format.FrameSize() bytes per frame,
+// in the little-endian byte order of the USB audio class.
+type Capture struct {
+	// Setting is the streaming setting used by the capture.
+	Setting StreamingSetting
+	// Rate is the sample rate reported by the device.
+	Rate int
+
+	r       packetReader
+	release func()
+}
+
+// Read reads recorded audio data.
+func (c *Capture) Read(p []byte) (int, error) {
+	return c.r.ReadContext(context.Background(), p)
+}
+
+// ReadContext reads recorded audio data. The context controls
+// the cancellation of this particular read.
+func (c *Capture) ReadContext(ctx context.Context, p []byte) (int, error) {
+	return c.r.ReadContext(ctx, p)
+}
+
+// Close stops the capture and releases the AudioStreaming interface.
+func (c *Capture) Close() error {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	return nil
+}
+
+// Playback is a stream of PCM audio frames sent to the device.
+// Data is interleaved, with Setting.Format.FrameSize() bytes per frame,
+// in the little-endian byte order of the USB audio class.
+//
+// Playback splits the data into packets matching the sample rate. For
+// asynchronous sinks with a feedback endpoint, the packet sizes follow the
+// rate reported by the device.
+type Playback struct {
+	// Setting is the streaming setting used by the playback.
+	Setting StreamingSetting
+	// Rate is the sample rate reported by the device.
+	Rate int
+
+	w         packetWriter
+	pacer     *pacer
+	frameSize int
+	// pending holds the data not yet sent in a packet.
+	pending []byte
+	// next is the size of the next packet in bytes, or 0 if not
+	// determined yet.
+	next int
+
+	// cancel and done stop the feedback goroutine.
+	cancel  context.CancelFunc
+	done    chan struct{}
+	release func() error
+}
+
+func newPlayback(w packetWriter, p *pacer, frameSize int) *Playback {
+	return &Playback{
+		w:         w,
+		pacer:     p,
+		frameSize: frameSize,
+	}
+}
+
+// readFeedback reads the feedback endpoint until ctx is done and updates
+// the pacer with the values reported by the device.
+func (pb *Playback) readFeedback(ctx context.Context, r packetReader, size int, highSpeed bool, unit, period time.Duration) {
+	pb.done = make(chan struct{})
+	ctx, pb.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(pb.done)
+		buf := make([]byte, size)
+		for {
+			n, err := r.ReadContext(ctx, buf)
+			if err != nil {
+				// Without feedback, playback continues at the nominal rate.
+				return
+			}
+			if v, ok := parseFeedback(buf[:n], highSpeed, unit, period); ok {
+				pb.pacer.feedback(v)
+			}
+		}
+	}()
+}
+
+// Write queues audio data for playback. Complete packets are sent
+// immediately, the remaining data is kept until the next Write or Close.
+func (pb *Playback) Write(p []byte) (int, error) {
+	return pb.WriteContext(context.Background(), p)
+}
+
+// WriteContext queues audio data for playback. The context controls
+// the cancellation of this particular write.
+func (pb *Playback) WriteContext(ctx context.Context, p []byte) (int, error) {
+	pb.pending = append(pb.pending, p...)
+	sent := 0
+	for {
+		if pb.next == 0 {
+			pb.next = pb.pacer.next() * pb.frameSize
+		}
+		if len(pb.pending)-sent < pb.next {
+			break
+		}
+		if _, err := pb.w.WriteContext(ctx, pb.pending[sent:sent+pb.next]); err != nil {
+			pb.pending = nil
+			return 0, err
+		}
+		sent += pb.next
+		pb.next = 0
+	}
+	pb.pending = append(pb.pending[:0], pb.pending[sent:]...)
+	return len(p), nil
+}
+
+// Close sends the remaining data, waits for the playback to finish
+// and releases the AudioStreaming interface.
+func (pb *Playback) Close() error {
+	var err error
+	if len(pb.pending) > 0 {
+		_, err = pb.w.WriteContext(context.Background(), pb.pending)
+		pb.pending = nil
+	}
+	if pb.cancel != nil {
+		pb.cancel()
+		<-pb.done
+		pb.cancel = nil
+	}
+	if pb.release != nil {
+		if rErr := pb.release(); err == nil {
+			err = rErr
+		}
+		pb.release = nil
+	}
+	return err
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uac
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPacer(t *testing.T) {
+	for _, tc := range []struct {
+		rate      int
+		period    time.Duration
+		maxFrames int
+		want      []int
+	}{
+		{48000, time.Millisecond, 100, []int{48, 48, 48}},
+		{48000, 125 * time.Microsecond, 100, []int{6, 6, 6}},
+		{44100, time.Millisecond, 100, []int{44, 44, 44, 44, 44, 44, 44, 44, 44, 45}},
+		{96000, time.Millisecond, 64, []int{64, 64}},
+	} {
+		p := newPacer(tc.rate, tc.period, tc.maxFrames)
+		var got []int
+		for range tc.want {
+			got = append(got, p.next())
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("pacer(%dHz, %v): got packets %v, want %v", tc.rate, tc.period, got, tc.want)
+		}
+	}
+}
+
+func TestPacerFeedback(t *testing.T) {
+	p := newPacer(48000, time.Millisecond, 100)
+	if p.feedback(40 << 16) {
+		t.Error("feedback(40 frames): accepted a value far from the nominal rate")
+	}
+	if !p.feedback(48<<16 + 1<<15) {
+		t.Fatal("feedback(48.5 frames): value rejected")
+	}
+	if got, want := []int{p.next(), p.next()}, []int{48, 49}; !reflect.DeepEqual(got, want) {
+		t.Errorf("packets after feedback: got %v, want %v", got, want)
+	}
+}
+
+func TestParseFeedback(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		b         []byte
+		highSpeed bool
+		unit      time.Duration
+		period    time.Duration
+		want      uint32
+		wantOK    bool
+	}{
+		{
+			desc:   "full speed 10.14, 44.1 frames per ms",
+			b:      []byte{0x66, 0x06, 0x0b},
+			unit:   time.Millisecond,
+			period: time.Millisecond,
+			want:   0x2c1998,
+			wantOK: true,
+		},
+		{
+			desc:      "high speed 16.16, 6 frames per microframe, 1ms packets",
+			b:         []byte{0x00, 0x00, 0x06, 0x00},
+			highSpeed: true,
+			unit:      125 * time.Microsecond,
+			period:    time.Millisecond,
+			want:      48 << 16,
+			wantOK:    true,
+		},
+		{
+			desc:      "high speed, short value",
+			b:         []byte{0x00, 0x00, 0x06},
+			highSpeed: true,
+			unit:      125 * time.Microsecond,
+			period:    time.Millisecond,
+		},
+	} {
+		got, ok := parseFeedback(tc.b, tc.highSpeed, tc.unit, tc.period)
+		if ok != tc.wantOK || got != tc.want {
+			t.Errorf("%s: parseFeedback(): got 0x%x, %v, want 0x%x, %v", tc.desc, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+// fakePacketWriter records the size of every packet written.
+type fakePacketWriter struct {
+	packets []int
+	err     error
+}
+
+func (f *fakePacketWriter) WriteContext(ctx context.Context, p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.packets = append(f.packets, len(p))
+	return len(p), nil
+}
+
+func TestPlayback(t *testing.T) {
+	w := &fakePacketWriter{}
+	// 44.1kHz, 2 channels, 16 bits
+	pb := newPlayback(w, newPacer(44100, time.Millisecond, 48), 4)
+	// 10ms of audio and 2 more frames in uneven writes
+	for _, n := range []int{100, 1000, 672} {
+		if got, err := pb.Write(make([]byte, n)); got != n || err != nil {
+			t.Fatalf("Write(%d bytes): got %d, %v, want %d, nil", n, got, err, n)
+		}
+	}
+	want := []int{176, 176, 176, 176, 176, 176, 176, 176, 176, 180}
+	if !reflect.DeepEqual(w.packets, want) {
+		t.Errorf("packets after Write: got %v, want %v", w.packets, want)
+	}
+	if err := pb.Close(); err != nil {
+		t.Errorf("Close(): %v", err)
+	}
+	if want = append(want, 8); !reflect.DeepEqual(w.packets, want) {
+		t.Errorf("packets after Close: got %v, want %v", w.packets, want)
+	}
+
+	errDisconnected := errors.New("device disconnected")
+	pb = newPlayback(&fakePacketWriter{err: errDisconnected}, newPacer(48000, time.Millisecond, 48), 4)
+	if _, err := pb.Write(make([]byte, 192)); err != errDisconnected {
+		t.Errorf("Write() to a failed endpoint: got %v, want %v", err, errDisconnected)
+	}
+}
+
+// fakeFeedback returns a single feedback value, then blocks.
+type fakeFeedback struct {
+	val  []byte
+	read chan struct{}
+}
+
+func (f *fakeFeedback) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if f.val != nil {
+		n := copy(p, f.val)
+		f.val = nil
+		return n, nil
+	}
+	close(f.read)
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestPlaybackFeedback(t *testing.T) {
+	w := &fakePacketWriter{}
+	p := newPacer(48000, time.Millisecond, 64)
+	pb := newPlayback(w, p, 4)
+	// 48.5 frames per packet in 10.14 format
+	fb := &fakeFeedback{val: []byte{0x00, 0x20, 0x0c}, read: make(chan struct{})}
+	pb.readFeedback(context.Background(), fb, 3, false, time.Millisecond, time.Millisecond)
+	<-fb.read
+	if _, err := pb.Write(make([]byte, 4*97)); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	if want := []int{4 * 48, 4 * 49}; !reflect.DeepEqual(w.packets, want) {
+		t.Errorf("packets with feedback: got %v, want %v", w.packets, want)
+	}
+	if err := pb.Close(); err != nil {
+		t.Errorf("Close(): %v", err)
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package uac implements PCM audio streaming for USB Audio Class 1 and 2
+// devices.
+//
+// A Device is opened on top of a gousb.Device. It parses the class-specific
+// AudioControl and AudioStreaming descriptors, which describe the terminals,
+// feature units and clock sources of the audio function and the formats
+// available on each streaming interface:
+//
+//	ad, err := uac.Open(dev)
+//	...
+//	defer ad.Close()
+//	s, err := ad.FindStream(gousb.EndpointDirectionIn, 2, 16, 48000)
+//	...
+//	c, err := ad.OpenCapture(s, 48000)
+//	...
+//	defer c.Close()
+//	io.Copy(w, c)
+//
+// OpenCapture and OpenPlayback select the alternate setting of the streaming
+// interface and set the sample rate, through the endpoint control on UAC1
+// devices or the clock source control on UAC2 devices. Playback paces the
+// packets sent to the device according to the sample rate, following the
+// explicit feedback endpoint of asynchronous sinks if there is one.
+package uac
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+const (
+	// capturePackets is the number of isochronous packets in a single
+	// capture transfer.
+	capturePackets = 8
+	// captureTransfers is the number of capture transfers kept in flight.
+	captureTransfers = 8
+	// playbackTransfers is the number of playback packets queued on
+	// the device. Each playback transfer carries a single packet, see
+	// Device.OpenPlayback.
+	playbackTransfers = 32
+	// feedbackTransfers is the number of feedback transfers kept in flight.
+	feedbackTransfers = 4
+)
+
+// streamOptions returns the options of the capture and feedback streams of
+// an endpoint. An isochronous transfer with a lost packet is dropped and
+// the stream keeps reading: the recording has a gap instead of ending.
+func streamOptions(in *gousb.InEndpoint) gousb.StreamOptions {
+	return gousb.StreamOptions{
+		Timeout:    in.Timeout,
+		SkipErrors: []gousb.TransferStatus{gousb.TransferError, gousb.TransferOverflow},
+	}
+}
+
+// Device is a USB audio device with a claimed configuration.
+type Device struct {
+	// Control describes the AudioControl interface of the device.
+	Control *AudioControl
+	// Streams lists the alternate settings of all AudioStreaming
+	// interfaces of the device that carry PCM audio.
+	Streams []StreamingSetting
+
+	dev *gousb.Device
+	cfg *gousb.Config
+	ctl *controls
+}
+
+// Open claims the active configuration of the device and parses its audio
+// class descriptors. The Device should be Close()d after use.
+func Open(dev *gousb.Device) (*Device, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{dev: dev}
+	if d.Control, d.Streams, err = parseConfig(desc); err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	d.ctl = &controls{ctl: dev, intf: d.Control.Interface, v: d.Control.Version}
+	if d.cfg, err = dev.Config(cfgNum); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// parseConfig finds the AudioControl interface in the configuration and
+// parses its descriptors and the descriptors of the AudioStreaming
+// interfaces. UAC1 functions list their streaming interfaces in the
+// AudioControl header, for UAC2 functions all AudioStreaming interfaces
+// of the configuration are used.
+func parseConfig(desc gousb.ConfigDesc) (*AudioControl, []StreamingSetting, error) {
+	var ac *AudioControl
+	for _, intf := range desc.Interfaces {
+		alt := intf.AltSettings[0]
+		if alt.Class != gousb.ClassAudio || alt.SubClass != subClassAudioControl {
+			continue
+		}
+		var err error
+		if ac, err = ParseAudioControl(alt.Extra, versionOf(alt.Protocol)); err != nil {
+			return nil, nil, fmt.Errorf("AudioControl interface %d: %v", intf.Number, err)
+		}
+		ac.Interface = intf.Number
+		break
+	}
+	if ac == nil {
+		return nil, nil, fmt.Errorf("%s has no AudioControl interface", desc)
+	}
+	nums := ac.StreamingInterfaces
+	if ac.Version == UAC2 {
+		for _, intf := range desc.Interfaces {
+			if alt := intf.AltSettings[0]; alt.Class == gousb.ClassAudio && alt.SubClass == subClassAudioStreaming {
+				nums = append(nums, intf.Number)
+			}
+		}
+	}
+	var streams []StreamingSetting
+	for _, num := range nums {
+		intf, err := findInterface(desc, num)
+		if err != nil {
+			return nil, nil, err
+		}
+		if alt := intf.AltSettings[0]; alt.Class != gousb.ClassAudio || alt.SubClass != subClassAudioStreaming {
+			return nil, nil, fmt.Errorf("interface %d is not an AudioStreaming interface", num)
+		}
+		ss, err := parseStreamingInterface(*intf, ac.Version)
+		if err != nil {
+			return nil, nil, err
+		}
+		streams = append(streams, ss...)
+	}
+	if len(streams) == 0 {
+		return nil, nil, fmt.Errorf("%s has no AudioStreaming interface with a PCM format", desc)
+	}
+	return ac, streams, nil
+}
+
+func findInterface(desc gousb.ConfigDesc, num int) (*gousb.InterfaceDesc, error) {
+	for i := range desc.Interfaces {
+		if desc.Interfaces[i].Number == num {
+			return &desc.Interfaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no interface %d", desc, num)
+}
+
+// Close releases the configuration claimed by the device. All captures
+// and playbacks must be closed before calling Close.
+func (d *Device) Close() error {
+	return d.cfg.Close()
+}
+
+// FindStream returns the first streaming setting in the given direction
+// with the requested number of channels and bit resolution that supports
+// the sample rate. Direction In is used for capture and Out for playback.
+func (d *Device) FindStream(dir gousb.EndpointDirection, channels, bits, rate int) (*StreamingSetting, error) {
+	for i := range d.Streams {
+		s := &d.Streams[i]
+		if s.Endpoint.Direction == dir && s.Format.Channels == channels && s.Format.BitResolution == bits && s.Format.SupportsRate(rate) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s stream with %d channels, %d bits at %dHz", dir, channels, bits, rate)
+}
+
+// clock returns the clock source driving the terminal linked to
+// a UAC2 streaming setting.
+func (d *Device) clock(s *StreamingSetting) (*Clock, error) {
+	t, err := d.Control.Terminal(s.TerminalLink)
+	if err != nil {
+		return nil, err
+	}
+	return d.Control.clockSource(t.ClockSourceID)
+}
+
+// SampleRates returns the sample rates supported by a streaming setting.
+// UAC1 rates come from the format descriptor, UAC2 rates are read from
+// the clock source.
+func (d *Device) SampleRates(s *StreamingSetting) ([]RateRange, error) {
+	if d.Control.Version == UAC1 {
+		if len(s.Format.SampleRates) == 0 {
+			return []RateRange{{Min: s.Format.MinRate, Max: s.Format.MaxRate}}, nil
+		}
+		var ret []RateRange
+		for _, r := range s.Format.SampleRates {
+			ret = append(ret, RateRange{Min: r, Max: r})
+		}
+		return ret, nil
+	}
+	c, err := d.clock(s)
+	if err != nil {
+		return nil, err
+	}
+	return d.ctl.clockRates(c.ID)
+}
+
+// SampleRate returns the current sample rate of a streaming setting.
+// UAC1 devices only report the rate while the setting is active.
+func (d *Device) SampleRate(s *StreamingSetting) (int, error) {
+	if d.Control.Version == UAC1 {
+		return d.ctl.endpointRate(s.Endpoint.Address)
+	}
+	c, err := d.clock(s)
+	if err != nil {
+		return 0, err
+	}
+	return d.ctl.clockRate(c.ID)
+}
+
+// setSampleRate programs the sample rate for a streaming setting and
+// returns the rate reported by the device afterwards.
+func (d *Device) setSampleRate(s *StreamingSetting, rate int) (int, error) {
+	if d.Control.Version == UAC1 {
+		if !s.Format.SupportsRate(rate) {
+			return 0, fmt.Errorf("%s does not support %dHz", s, rate)
+		}
+		if !s.SampleRateControl {
+			// The endpoint runs at the only rate of the format.
+			if len(s.Format.SampleRates) > 1 || len(s.Format.SampleRates) == 0 && s.Format.MinRate != s.Format.MaxRate {
+				return 0, fmt.Errorf("%s has no sampling frequency control to select %dHz", s, rate)
+			}
+			return rate, nil
+		}
+		if err := d.ctl.setEndpointRate(s.Endpoint.Address, rate); err != nil {
+			return 0, err
+		}
+		if got, err := d.ctl.endpointRate(s.Endpoint.Address); err == nil {
+			return got, nil
+		}
+		return rate, nil
+	}
+	c, err := d.clock(s)
+	if err != nil {
+		return 0, err
+	}
+	if c.Programmable {
+		if err := d.ctl.setClockRate(c.ID, rate); err != nil {
+			return 0, err
+		}
+	}
+	got, err := d.ctl.clockRate(c.ID)
+	if err != nil {
+		return 0, err
+	}
+	if got != rate {
+		return 0, fmt.Errorf("clock source %d runs at %dHz, want %dHz", c.ID, got, rate)
+	}
+	return got, nil
+}
+
+// Mute returns the state of the mute control of a feature unit channel.
+// Channel 0 is the master channel.
+func (d *Device) Mute(unit, channel int) (bool, error) {
+	return d.ctl.mute(unit, channel)
+}
+
+// SetMute mutes or unmutes a feature unit channel.
+func (d *Device) SetMute(unit, channel int, mute bool) error {
+	return d.ctl.setMute(unit, channel, mute)
+}
+
+// Volume returns the volume of a feature unit channel in dB.
+func (d *Device) Volume(unit, channel int) (float64, error) {
+	return d.ctl.volume(unit, channel)
+}
+
+// SetVolume sets the volume of a feature unit channel in dB. The value is
+// rounded down to the 1/256 dB precision of the control.
+func (d *Device) SetVolume(unit, channel int, db float64) error {
+	return d.ctl.setVolume(unit, channel, db)
+}
+
+// VolumeRange returns the minimum, maximum and resolution of the volume
+// control of a feature unit channel in dB.
+func (d *Device) VolumeRange(unit, channel int) (min, max, res float64, err error) {
+	return d.ctl.volumeRange(unit, channel)
+}
+
+// activate claims the streaming setting and sets the sample rate.
+// The returned function switches the interface back to the zero-bandwidth
+// setting.
+func (d *Device) activate(s *StreamingSetting, rate int) (*gousb.Interface, int, func(), error) {
+	intf, err := d.cfg.Interface(s.Interface, s.Alternate)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	release := func() {
+		intf.Close()
+		if idle, err := d.cfg.Interface(s.Interface, 0); err == nil {
+			idle.Close()
+		}
+	}
+	got, err := d.setSampleRate(s, rate)
+	if err != nil {
+		release()
+		return nil, 0, nil, err
+	}
+	return intf, got, release, nil
+}
+
+// packetPeriod returns the interval between isochronous packets of
+// the endpoint.
+func packetPeriod(ep gousb.EndpointDesc) time.Duration {
+	if ep.PollInterval <= 0 {
+		return time.Millisecond
+	}
+	return ep.PollInterval
+}
+
+// OpenCapture starts recording from an IN streaming setting at a given
+// sample rate. The data of a transfer with a lost packet is dropped, so
+// the recording skips the corresponding frames. The Capture must be closed
+// before the interface can be used again.
+func (d *Device) OpenCapture(s *StreamingSetting, rate int) (*Capture, error) {
+	if s.Endpoint.Direction != gousb.EndpointDirectionIn {
+		return nil, fmt.Errorf("%s is not a capture stream", s)
+	}
+	intf, got, release, err := d.activate(s, rate)
+	if err != nil {
+		return nil, err
+	}
+	in, err := intf.InEndpoint(s.Endpoint.Number)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	rs, err := in.NewStreamWithOptions(s.Endpoint.MaxPacketSize*capturePackets, captureTransfers, streamOptions(in))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &Capture{
+		Setting: *s,
+		Rate:    got,
+		r:       rs,
+		release: func() {
+			rs.Close()
+			release()
+		},
+	}, nil
+}
+
+// OpenPlayback starts playback on an OUT streaming setting at a given
+// sample rate. Each transfer carries a single isochronous packet, so that
+// the size of every packet can follow the sample rate. The Playback must be
+// closed before the interface can be used again.
+func (d *Device) OpenPlayback(s *StreamingSetting, rate int) (*Playback, error) {
+	if s.Endpoint.Direction != gousb.EndpointDirectionOut {
+		return nil, fmt.Errorf("%s is not a playback stream", s)
+	}
+	intf, got, release, err := d.activate(s, rate)
+	if err != nil {
+		return nil, err
+	}
+	out, err := intf.OutEndpoint(s.Endpoint.Number)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	ws, err := out.NewStream(s.Endpoint.MaxPacketSize, playbackTransfers)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	frameSize := s.Format.FrameSize()
+	period := packetPeriod(s.Endpoint)
+	pb := newPlayback(ws, newPacer(got, period, s.Endpoint.MaxPacketSize/frameSize), frameSize)
+	pb.Setting, pb.Rate = *s, got
+
+	var fb *gousb.ReadStream
+	if s.Feedback != nil {
+		fbIn, err := intf.InEndpoint(s.Feedback.Number)
+		if err != nil {
+			ws.Close()
+			release()
+			return nil, err
+		}
+		if fb, err = fbIn.NewStreamWithOptions(s.Feedback.MaxPacketSize, feedbackTransfers, streamOptions(fbIn)); err != nil {
+			ws.Close()
+			release()
+			return nil, err
+		}
+		highSpeed := d.dev.Desc.Speed >= gousb.SpeedHigh
+		unit := time.Millisecond
+		if highSpeed {
+			unit = 125 * time.Microsecond
+		}
+		pb.readFeedback(context.Background(), fb, s.Feedback.MaxPacketSize, highSpeed, unit, period)
+	}
+	pb.release = func() error {
+		err := ws.Close()
+		if fb != nil {
+			fb.Close()
+		}
+		release()
+		return err
+	}
+	return pb, nil
+}
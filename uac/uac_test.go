@@ -0,0 +1,212 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uac
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+func audioInterface(num int, sub gousb.Class, proto gousb.Protocol, alts ...gousb.InterfaceSetting) gousb.InterfaceDesc {
+	zero := gousb.InterfaceSetting{Number: num, Class: gousb.ClassAudio, SubClass: sub, Protocol: proto}
+	intf := gousb.InterfaceDesc{Number: num, AltSettings: []gousb.InterfaceSetting{zero}}
+	for i, a := range alts {
+		a.Number, a.Alternate, a.Class, a.SubClass, a.Protocol = num, i+1, gousb.ClassAudio, sub, proto
+		intf.AltSettings = append(intf.AltSettings, a)
+	}
+	return intf
+}
+
+func isoEndpoint(addr gousb.EndpointAddress, size int, usage gousb.UsageType) gousb.EndpointDesc {
+	dir := gousb.EndpointDirectionOut
+	if addr&0x80 != 0 {
+		dir = gousb.EndpointDirectionIn
+	}
+	return gousb.EndpointDesc{
+		Address:       addr,
+		Number:        int(addr & 0x0f),
+		Direction:     dir,
+		MaxPacketSize: size,
+		TransferType:  gousb.TransferTypeIsochronous,
+		UsageType:     usage,
+		PollInterval:  time.Millisecond,
+	}
+}
+
+var testConfigUAC1 = gousb.ConfigDesc{
+	Number: 1,
+	Interfaces: []gousb.InterfaceDesc{
+		func() gousb.InterfaceDesc {
+			i := audioInterface(0, subClassAudioControl, 0)
+			i.AltSettings[0].Extra = testAC1
+			return i
+		}(),
+		audioInterface(1, subClassAudioStreaming, 0, gousb.InterfaceSetting{
+			Extra: testAS1Out,
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{0x01: func() gousb.EndpointDesc {
+				ep := isoEndpoint(0x01, 192, gousb.IsoUsageTypeData)
+				// EP_GENERAL: sampling frequency control.
+				ep.Extra = []byte{0x07, 0x25, 0x01, 0x01, 0x00, 0x00, 0x00}
+				return ep
+			}()},
+		}),
+		audioInterface(2, subClassAudioStreaming, 0, gousb.InterfaceSetting{
+			Extra: testAS1In,
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{0x82: func() gousb.EndpointDesc {
+				ep := isoEndpoint(0x82, 96, gousb.IsoUsageTypeData)
+				// EP_GENERAL: no controls.
+				ep.Extra = []byte{0x07, 0x25, 0x01, 0x00, 0x00, 0x00, 0x00}
+				return ep
+			}()},
+		}),
+		{Number: 3, AltSettings: []gousb.InterfaceSetting{{Number: 3, Class: gousb.ClassHID}}},
+	},
+}
+
+var testConfigUAC2 = gousb.ConfigDesc{
+	Number: 1,
+	Interfaces: []gousb.InterfaceDesc{
+		func() gousb.InterfaceDesc {
+			i := audioInterface(0, subClassAudioControl, protocolUAC2)
+			i.AltSettings[0].Extra = testAC2
+			return i
+		}(),
+		audioInterface(1, subClassAudioStreaming, protocolUAC2, gousb.InterfaceSetting{
+			Extra: testAS2,
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{
+				0x01: isoEndpoint(0x01, 294, gousb.IsoUsageTypeData),
+				0x81: isoEndpoint(0x81, 4, gousb.IsoUsageTypeFeedback),
+			},
+		}),
+	},
+}
+
+func TestParseConfig(t *testing.T) {
+	ac, streams, err := parseConfig(testConfigUAC1)
+	if err != nil {
+		t.Fatalf("parseConfig(UAC1): %v", err)
+	}
+	if ac.Version != UAC1 || len(streams) != 2 {
+		t.Fatalf("parseConfig(UAC1): got %s with %d streams, want UAC1 with 2 streams", ac.Version, len(streams))
+	}
+	if s := streams[1]; s.Interface != 2 || s.Alternate != 1 || s.Endpoint.Address != 0x82 || s.Feedback != nil {
+		t.Errorf("parseConfig(UAC1): second stream %s, want interface 2 alt 1 on endpoint 0x82 without feedback", s)
+	}
+	if !streams[0].SampleRateControl || streams[1].SampleRateControl {
+		t.Errorf("parseConfig(UAC1): sampling frequency control %t, %t, want true, false", streams[0].SampleRateControl, streams[1].SampleRateControl)
+	}
+
+	ac, streams, err = parseConfig(testConfigUAC2)
+	if err != nil {
+		t.Fatalf("parseConfig(UAC2): %v", err)
+	}
+	if ac.Version != UAC2 || len(streams) != 1 {
+		t.Fatalf("parseConfig(UAC2): got %s with %d streams, want UAC2 with 1 stream", ac.Version, len(streams))
+	}
+	if s := streams[0]; s.Endpoint.Address != 0x01 || s.Feedback == nil || s.Feedback.Address != 0x81 {
+		t.Errorf("parseConfig(UAC2): got stream %s with feedback %v, want endpoint 0x01 with feedback 0x81", s, s.Feedback)
+	}
+
+	noAC := testConfigUAC1
+	noAC.Interfaces = testConfigUAC1.Interfaces[1:]
+	if _, _, err := parseConfig(noAC); err == nil {
+		t.Error("parseConfig() without an AudioControl interface: got nil error, want non-nil")
+	}
+	noAS := testConfigUAC2
+	noAS.Interfaces = testConfigUAC2.Interfaces[:1]
+	if _, _, err := parseConfig(noAS); err == nil {
+		t.Error("parseConfig() without an AudioStreaming interface: got nil error, want non-nil")
+	}
+}
+
+func TestFindStream(t *testing.T) {
+	ac, streams, err := parseConfig(testConfigUAC1)
+	if err != nil {
+		t.Fatalf("parseConfig(): %v", err)
+	}
+	d := &Device{Control: ac, Streams: streams}
+	for _, tc := range []struct {
+		dir      gousb.EndpointDirection
+		channels int
+		rate     int
+		wantIntf int
+		wantErr  bool
+	}{
+		{gousb.EndpointDirectionOut, 2, 44100, 1, false},
+		{gousb.EndpointDirectionOut, 2, 32000, 0, true},
+		{gousb.EndpointDirectionIn, 1, 16000, 2, false},
+		{gousb.EndpointDirectionIn, 2, 16000, 0, true},
+	} {
+		s, err := d.FindStream(tc.dir, tc.channels, 16, tc.rate)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("FindStream(%s, %d, 16, %d): got error %v, want error: %v", tc.dir, tc.channels, tc.rate, err, tc.wantErr)
+			continue
+		}
+		if err == nil && s.Interface != tc.wantIntf {
+			t.Errorf("FindStream(%s, %d, 16, %d): got interface %d, want %d", tc.dir, tc.channels, tc.rate, s.Interface, tc.wantIntf)
+		}
+	}
+}
+
+func TestSetSampleRateUAC1(t *testing.T) {
+	ac, streams, err := parseConfig(testConfigUAC1)
+	if err != nil {
+		t.Fatalf("parseConfig(): %v", err)
+	}
+	f := &fakeAudio{v: UAC1, vals: map[ctlKey][]byte{}}
+	d := &Device{Control: ac, Streams: streams, ctl: &controls{ctl: f, v: UAC1}}
+	got, err := d.setSampleRate(&d.Streams[0], 48000)
+	if err != nil || got != 48000 {
+		t.Fatalf("setSampleRate(OUT, 48000): got %d, %v, want 48000, nil", got, err)
+	}
+	if got := f.vals[ctlKey{uint8(gousb.ControlEndpoint), reqGetCur, csSamFreq << 8, 0x01}]; !reflect.DeepEqual(got, []byte{0x80, 0xbb, 0x00}) {
+		t.Errorf("sampling frequency of endpoint 0x01: got %v, want 48000Hz", got)
+	}
+	// The IN endpoint supports a range of rates without the control.
+	if got, err := d.setSampleRate(&d.Streams[1], 16000); err == nil {
+		t.Errorf("setSampleRate(IN, 16000): got %d, nil, want an error", got)
+	}
+	single := d.Streams[1]
+	single.Format.SampleRates = []int{16000}
+	if got, err := d.setSampleRate(&single, 16000); err != nil || got != 16000 {
+		t.Errorf("setSampleRate(IN with a single rate, 16000): got %d, %v, want 16000, nil", got, err)
+	}
+	if len(f.vals) != 1 {
+		t.Errorf("got %d controls set, want only the sampling frequency of endpoint 0x01", len(f.vals))
+	}
+}
+
+func TestSetSampleRate(t *testing.T) {
+	ac, streams, err := parseConfig(testConfigUAC2)
+	if err != nil {
+		t.Fatalf("parseConfig(): %v", err)
+	}
+	f := &fakeAudio{v: UAC2, vals: map[ctlKey][]byte{}}
+	d := &Device{Control: ac, Streams: streams, ctl: &controls{ctl: f, v: UAC2}}
+	got, err := d.setSampleRate(&d.Streams[0], 48000)
+	if err != nil || got != 48000 {
+		t.Fatalf("setSampleRate(48000): got %d, %v, want 48000, nil", got, err)
+	}
+	if got, err := d.SampleRate(&d.Streams[0]); err != nil || got != 48000 {
+		t.Errorf("SampleRate(): got %d, %v, want 48000, nil", got, err)
+	}
+	// The clock was set through the selector on clock source 0x10.
+	if _, ok := f.vals[ctlKey{uint8(gousb.ControlInterface), req2Cur, csSamFreq << 8, 0x10 << 8}]; !ok {
+		t.Error("sample rate was not set on clock source 0x10")
+	}
+}
@@ -0,0 +1,560 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uvc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+// Interface subclasses of the video class.
+const (
+	subClassVideoControl   gousb.Class = 0x01
+	subClassVideoStreaming gousb.Class = 0x02
+)
+
+// dtCSInterface is the descriptor type of class-specific interface descriptors.
+const dtCSInterface = 0x24
+
+// VideoControl interface descriptor subtypes.
+const (
+	vcHeader         = 0x01
+	vcInputTerminal  = 0x02
+	vcOutputTerminal = 0x03
+	vcSelectorUnit   = 0x04
+	vcProcessingUnit = 0x05
+	vcExtensionUnit  = 0x06
+	vcEncodingUnit   = 0x07
+)
+
+// VideoStreaming interface descriptor subtypes.
+const (
+	vsInputHeader        = 0x01
+	vsOutputHeader       = 0x02
+	vsStillImageFrame    = 0x03
+	vsFormatUncompressed = 0x04
+	vsFrameUncompressed  = 0x05
+	vsFormatMJPEG        = 0x06
+	vsFrameMJPEG         = 0x07
+	vsFormatMPEG2TS      = 0x0a
+	vsFormatDV           = 0x0c
+	vsColorFormat        = 0x0d
+	vsFormatFrameBased   = 0x10
+	vsFrameFrameBased    = 0x11
+	vsFormatStreamBased  = 0x12
+	vsFormatH264         = 0x13
+	vsFrameH264          = 0x14
+)
+
+const (
+	// frame intervals are expressed in 100ns units.
+	frameIntervalUnit = 100 * time.Nanosecond
+	// minFrameDescriptorSize is the size of a frame descriptor up to and
+	// including bFrameIntervalType.
+	minFrameDescriptorSize = 26
+)
+
+// TerminalType identifies the kind of an input or output terminal.
+type TerminalType uint16
+
+// Terminal types defined by the UVC spec.
+const (
+	TerminalVendorSpecific TerminalType = 0x0100
+	TerminalStreaming      TerminalType = 0x0101
+	TerminalCamera         TerminalType = 0x0201
+	TerminalMediaTransport TerminalType = 0x0202
+	TerminalDisplay        TerminalType = 0x0301
+)
+
+// String returns a human-readable name of the terminal type.
+func (t TerminalType) String() string {
+	switch t {
+	case TerminalVendorSpecific:
+		return "vendor-specific"
+	case TerminalStreaming:
+		return "streaming"
+	case TerminalCamera:
+		return "camera"
+	case TerminalMediaTransport:
+		return "media transport"
+	case TerminalDisplay:
+		return "display"
+	}
+	return fmt.Sprintf("terminal type 0x%04x", uint16(t))
+}
+
+// Terminal describes an input or output terminal of the video function.
+type Terminal struct {
+	// ID identifies the terminal within the video function.
+	ID int
+	// Type is the terminal type.
+	Type TerminalType
+	// AssocTerminal is the ID of the associated terminal, or 0.
+	AssocTerminal int
+	// SourceID is the ID of the unit or terminal connected to an output
+	// terminal. It is 0 for input terminals.
+	SourceID int
+	// Controls is the bitmap of supported camera controls, only present
+	// for camera input terminals.
+	Controls []byte
+}
+
+// UnitKind identifies the kind of a unit in the video function.
+type UnitKind uint8
+
+// Unit kinds defined by the UVC spec.
+const (
+	UnitSelector   UnitKind = vcSelectorUnit
+	UnitProcessing UnitKind = vcProcessingUnit
+	UnitExtension  UnitKind = vcExtensionUnit
+	UnitEncoding   UnitKind = vcEncodingUnit
+)
+
+// String returns a human-readable name of the unit kind.
+func (k UnitKind) String() string {
+	switch k {
+	case UnitSelector:
+		return "selector"
+	case UnitProcessing:
+		return "processing"
+	case UnitExtension:
+		return "extension"
+	case UnitEncoding:
+		return "encoding"
+	}
+	return fmt.Sprintf("unit kind 0x%02x", uint8(k))
+}
+
+// Unit describes a unit of the video function.
+type Unit struct {
+	// ID identifies the unit within the video function.
+	ID int
+	// Kind is the type of the unit.
+	Kind UnitKind
+	// Sources lists the IDs of the units or terminals connected to the
+	// inputs of this unit.
+	Sources []int
+	// GUID is the extension code of an extension unit.
+	GUID [16]byte
+	// Controls is the bitmap of controls supported by the unit.
+	Controls []byte
+}
+
+// VideoControl contains the information parsed from the class-specific
+// descriptors of the VideoControl interface.
+type VideoControl struct {
+	// Interface is the number of the VideoControl interface.
+	Interface int
+	// UVC is the version of the UVC spec implemented by the device.
+	UVC gousb.BCD
+	// ClockFrequency is the device clock frequency in Hz. It is used to
+	// interpret the SCR and PTS values of the payload headers.
+	ClockFrequency uint32
+	// StreamingInterfaces is a list of interface numbers of the
+	// VideoStreaming interfaces belonging to this function.
+	StreamingInterfaces []int
+	// Terminals lists the input and output terminals.
+	Terminals []Terminal
+	// Units lists the units.
+	Units []Unit
+}
+
+// FormatKind identifies the payload format of a video format.
+type FormatKind uint8
+
+// Format kinds defined by the UVC spec.
+const (
+	FormatUncompressed FormatKind = vsFormatUncompressed
+	FormatMJPEG        FormatKind = vsFormatMJPEG
+	FormatMPEG2TS      FormatKind = vsFormatMPEG2TS
+	FormatDV           FormatKind = vsFormatDV
+	FormatFrameBased   FormatKind = vsFormatFrameBased
+	FormatStreamBased  FormatKind = vsFormatStreamBased
+	FormatH264         FormatKind = vsFormatH264
+)
+
+// String returns a human-readable name of the format kind.
+func (k FormatKind) String() string {
+	switch k {
+	case FormatUncompressed:
+		return "uncompressed"
+	case FormatMJPEG:
+		return "MJPEG"
+	case FormatMPEG2TS:
+		return "MPEG-2 TS"
+	case FormatDV:
+		return "DV"
+	case FormatFrameBased:
+		return "frame-based"
+	case FormatStreamBased:
+		return "stream-based"
+	case FormatH264:
+		return "H.264"
+	}
+	return fmt.Sprintf("format 0x%02x", uint8(k))
+}
+
+// Format describes a video format offered by a VideoStreaming interface.
+type Format struct {
+	// Index is the format index used in probe and commit requests.
+	Index int
+	// Kind is the payload format.
+	Kind FormatKind
+	// GUID identifies the pixel format of uncompressed and frame-based
+	// formats, e.g. YUY2 or NV12.
+	GUID [16]byte
+	// BitsPerPixel is the number of bits per pixel of uncompressed and
+	// frame-based formats.
+	BitsPerPixel int
+	// DefaultFrame is the index of the default frame descriptor.
+	DefaultFrame int
+	// Frames lists the frame sizes available for this format.
+	Frames []Frame
+}
+
+// String returns a human-readable description of the format.
+func (f Format) String() string {
+	if f.Kind == FormatUncompressed || f.Kind == FormatFrameBased {
+		return fmt.Sprintf("format #%d %s %q (%d frame sizes)", f.Index, f.Kind, fourCC(f.GUID), len(f.Frames))
+	}
+	return fmt.Sprintf("format #%d %s (%d frame sizes)", f.Index, f.Kind, len(f.Frames))
+}
+
+// Frame returns the frame descriptor with the given index.
+func (f *Format) Frame(index int) (*Frame, error) {
+	for i := range f.Frames {
+		if f.Frames[i].Index == index {
+			return &f.Frames[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no frame with index %d", f, index)
+}
+
+// Frame describes a single frame size of a video format.
+type Frame struct {
+	// Index is the frame index used in probe and commit requests.
+	Index int
+	// Width and Height are the frame dimensions in pixels.
+	Width, Height int
+	// MinBitRate and MaxBitRate are the bit rate bounds in bits per second.
+	MinBitRate, MaxBitRate uint32
+	// MaxFrameSize is the maximum number of bytes of a single frame.
+	// It is 0 for frame-based formats, which negotiate it during probing.
+	MaxFrameSize uint32
+	// DefaultInterval is the default frame interval.
+	DefaultInterval time.Duration
+	// Intervals lists the supported frame intervals if the device
+	// supports a discrete set of intervals.
+	Intervals []time.Duration
+	// MinInterval, MaxInterval and IntervalStep describe the supported
+	// frame intervals if the device supports a continuous range.
+	// They're all zero if Intervals is not empty.
+	MinInterval, MaxInterval, IntervalStep time.Duration
+}
+
+// String returns a human-readable description of the frame.
+func (f Frame) String() string {
+	return fmt.Sprintf("frame #%d %dx%d", f.Index, f.Width, f.Height)
+}
+
+// SupportsInterval returns true if the frame can be streamed with the given
+// frame interval.
+func (f Frame) SupportsInterval(d time.Duration) bool {
+	if len(f.Intervals) > 0 {
+		for _, i := range f.Intervals {
+			if i == d {
+				return true
+			}
+		}
+		return false
+	}
+	if d < f.MinInterval || d > f.MaxInterval {
+		return false
+	}
+	return f.IntervalStep == 0 || (d-f.MinInterval)%f.IntervalStep == 0
+}
+
+// StreamingInterface contains the information parsed from the class-specific
+// descriptors of a VideoStreaming interface.
+type StreamingInterface struct {
+	// Interface is the number of the VideoStreaming interface.
+	Interface int
+	// Endpoint is the address of the video data endpoint.
+	Endpoint gousb.EndpointAddress
+	// TerminalLink is the ID of the output terminal connected to this
+	// interface.
+	TerminalLink int
+	// Formats lists the available formats.
+	Formats []Format
+}
+
+// Format returns the format with the given index.
+func (s *StreamingInterface) Format(index int) (*Format, error) {
+	for i := range s.Formats {
+		if s.Formats[i].Index == index {
+			return &s.Formats[i], nil
+		}
+	}
+	return nil, fmt.Errorf("VideoStreaming interface %d has no format with index %d", s.Interface, index)
+}
+
+// splitDescriptors splits a blob of concatenated descriptors into individual
+// descriptors, using the bLength field of each.
+func splitDescriptors(b []byte) ([][]byte, error) {
+	var ret [][]byte
+	for len(b) > 0 {
+		l := int(b[0])
+		if l < 2 || l > len(b) {
+			return nil, fmt.Errorf("malformed descriptor: bLength %d with %d bytes remaining", l, len(b))
+		}
+		ret = append(ret, b[:l])
+		b = b[l:]
+	}
+	return ret, nil
+}
+
+func le16(b []byte) int    { return int(binary.LittleEndian.Uint16(b)) }
+func le32(b []byte) uint32 { return binary.LittleEndian.Uint32(b) }
+
+func interval(b []byte) time.Duration {
+	return time.Duration(le32(b)) * frameIntervalUnit
+}
+
+// ParseVideoControl parses the class-specific descriptors of a VideoControl
+// interface, as available in gousb.InterfaceSetting.Extra.
+func ParseVideoControl(extra []byte) (*VideoControl, error) {
+	descs, err := splitDescriptors(extra)
+	if err != nil {
+		return nil, err
+	}
+	vc := &VideoControl{}
+	var seenHeader bool
+	for _, d := range descs {
+		if d[1] != dtCSInterface || len(d) < 3 {
+			continue
+		}
+		switch d[2] {
+		case vcHeader:
+			if len(d) < 12 || len(d) < 12+int(d[11]) {
+				return nil, fmt.Errorf("VC_HEADER descriptor too short: %d bytes", len(d))
+			}
+			seenHeader = true
+			vc.UVC = gousb.BCD(le16(d[3:]))
+			vc.ClockFrequency = le32(d[7:])
+			for _, n := range d[12 : 12+int(d[11])] {
+				vc.StreamingInterfaces = append(vc.StreamingInterfaces, int(n))
+			}
+		case vcInputTerminal:
+			if len(d) < 8 {
+				return nil, fmt.Errorf("VC_INPUT_TERMINAL descriptor too short: %d bytes", len(d))
+			}
+			t := Terminal{
+				ID:            int(d[3]),
+				Type:          TerminalType(le16(d[4:])),
+				AssocTerminal: int(d[6]),
+			}
+			if t.Type == TerminalCamera && len(d) >= 15 && len(d) >= 15+int(d[14]) {
+				t.Controls = append([]byte(nil), d[15:15+int(d[14])]...)
+			}
+			vc.Terminals = append(vc.Terminals, t)
+		case vcOutputTerminal:
+			if len(d) < 9 {
+				return nil, fmt.Errorf("VC_OUTPUT_TERMINAL descriptor too short: %d bytes", len(d))
+			}
+			vc.Terminals = append(vc.Terminals, Terminal{
+				ID:            int(d[3]),
+				Type:          TerminalType(le16(d[4:])),
+				AssocTerminal: int(d[6]),
+				SourceID:      int(d[7]),
+			})
+		case vcSelectorUnit:
+			if len(d) < 5 || len(d) < 5+int(d[4]) {
+				return nil, fmt.Errorf("VC_SELECTOR_UNIT descriptor too short: %d bytes", len(d))
+			}
+			u := Unit{ID: int(d[3]), Kind: UnitSelector}
+			for _, s := range d[5 : 5+int(d[4])] {
+				u.Sources = append(u.Sources, int(s))
+			}
+			vc.Units = append(vc.Units, u)
+		case vcProcessingUnit:
+			if len(d) < 8 || len(d) < 8+int(d[7]) {
+				return nil, fmt.Errorf("VC_PROCESSING_UNIT descriptor too short: %d bytes", len(d))
+			}
+			vc.Units = append(vc.Units, Unit{
+				ID:       int(d[3]),
+				Kind:     UnitProcessing,
+				Sources:  []int{int(d[4])},
+				Controls: append([]byte(nil), d[8:8+int(d[7])]...),
+			})
+		case vcExtensionUnit:
+			if len(d) < 22 {
+				return nil, fmt.Errorf("VC_EXTENSION_UNIT descriptor too short: %d bytes", len(d))
+			}
+			u := Unit{ID: int(d[3]), Kind: UnitExtension}
+			copy(u.GUID[:], d[4:20])
+			nPins := int(d[21])
+			if len(d) < 23+nPins || len(d) < 23+nPins+int(d[22+nPins]) {
+				return nil, fmt.Errorf("VC_EXTENSION_UNIT descriptor too short: %d bytes", len(d))
+			}
+			for _, s := range d[22 : 22+nPins] {
+				u.Sources = append(u.Sources, int(s))
+			}
+			ctlSize := int(d[22+nPins])
+			u.Controls = append([]byte(nil), d[23+nPins:23+nPins+ctlSize]...)
+			vc.Units = append(vc.Units, u)
+		case vcEncodingUnit:
+			if len(d) < 5 {
+				return nil, fmt.Errorf("VC_ENCODING_UNIT descriptor too short: %d bytes", len(d))
+			}
+			vc.Units = append(vc.Units, Unit{
+				ID:      int(d[3]),
+				Kind:    UnitEncoding,
+				Sources: []int{int(d[4])},
+			})
+		}
+	}
+	if !seenHeader {
+		return nil, fmt.Errorf("VC_HEADER descriptor not found")
+	}
+	return vc, nil
+}
+
+// ParseVideoStreaming parses the class-specific descriptors of a
+// VideoStreaming interface, as available in gousb.InterfaceSetting.Extra
+// of its first alternate setting.
+func ParseVideoStreaming(extra []byte) (*StreamingInterface, error) {
+	descs, err := splitDescriptors(extra)
+	if err != nil {
+		return nil, err
+	}
+	vs := &StreamingInterface{}
+	var seenHeader bool
+	var cur *Format
+	for _, d := range descs {
+		if d[1] != dtCSInterface || len(d) < 3 {
+			continue
+		}
+		switch d[2] {
+		case vsInputHeader:
+			if len(d) < 13 {
+				return nil, fmt.Errorf("VS_INPUT_HEADER descriptor too short: %d bytes", len(d))
+			}
+			seenHeader = true
+			vs.Endpoint = gousb.EndpointAddress(d[6])
+			vs.TerminalLink = int(d[8])
+		case vsOutputHeader:
+			return nil, fmt.Errorf("VideoStreaming OUT interfaces are not supported")
+		case vsFormatUncompressed, vsFormatFrameBased:
+			if len(d) < 27 {
+				return nil, fmt.Errorf("format descriptor (subtype 0x%02x) too short: %d bytes", d[2], len(d))
+			}
+			f := Format{
+				Index:        int(d[3]),
+				Kind:         FormatKind(d[2]),
+				BitsPerPixel: int(d[21]),
+				DefaultFrame: int(d[22]),
+			}
+			copy(f.GUID[:], d[5:21])
+			vs.Formats = append(vs.Formats, f)
+			cur = &vs.Formats[len(vs.Formats)-1]
+		case vsFormatMJPEG:
+			if len(d) < 11 {
+				return nil, fmt.Errorf("VS_FORMAT_MJPEG descriptor too short: %d bytes", len(d))
+			}
+			vs.Formats = append(vs.Formats, Format{
+				Index:        int(d[3]),
+				Kind:         FormatMJPEG,
+				DefaultFrame: int(d[6]),
+			})
+			cur = &vs.Formats[len(vs.Formats)-1]
+		case vsFormatMPEG2TS, vsFormatDV, vsFormatStreamBased, vsFormatH264:
+			vs.Formats = append(vs.Formats, Format{
+				Index: int(d[3]),
+				Kind:  FormatKind(d[2]),
+			})
+			cur = &vs.Formats[len(vs.Formats)-1]
+		case vsFrameUncompressed, vsFrameMJPEG, vsFrameFrameBased:
+			if cur == nil {
+				return nil, fmt.Errorf("frame descriptor (subtype 0x%02x) without a preceding format descriptor", d[2])
+			}
+			fr, err := parseFrame(d)
+			if err != nil {
+				return nil, err
+			}
+			cur.Frames = append(cur.Frames, *fr)
+		}
+	}
+	if !seenHeader {
+		return nil, fmt.Errorf("VS_INPUT_HEADER descriptor not found")
+	}
+	return vs, nil
+}
+
+func parseFrame(d []byte) (*Frame, error) {
+	if len(d) < minFrameDescriptorSize {
+		return nil, fmt.Errorf("frame descriptor (subtype 0x%02x) too short: %d bytes", d[2], len(d))
+	}
+	f := &Frame{
+		Index:      int(d[3]),
+		Width:      le16(d[5:]),
+		Height:     le16(d[7:]),
+		MinBitRate: le32(d[9:]),
+		MaxBitRate: le32(d[13:]),
+	}
+	// Frame-based frame descriptors don't carry dwMaxVideoFrameBufferSize,
+	// but have dwBytesPerLine after bFrameIntervalType instead.
+	var nIntervals int
+	var rest []byte
+	if d[2] == vsFrameFrameBased {
+		f.DefaultInterval = interval(d[17:])
+		nIntervals = int(d[21])
+		rest = d[26:]
+	} else {
+		f.MaxFrameSize = le32(d[17:])
+		f.DefaultInterval = interval(d[21:])
+		nIntervals = int(d[25])
+		rest = d[26:]
+	}
+	if nIntervals == 0 {
+		if len(rest) < 12 {
+			return nil, fmt.Errorf("frame descriptor #%d: continuous frame intervals truncated", f.Index)
+		}
+		f.MinInterval = interval(rest)
+		f.MaxInterval = interval(rest[4:])
+		f.IntervalStep = interval(rest[8:])
+		return f, nil
+	}
+	if len(rest) < 4*nIntervals {
+		return nil, fmt.Errorf("frame descriptor #%d: %d discrete frame intervals truncated", f.Index, nIntervals)
+	}
+	for i := 0; i < nIntervals; i++ {
+		f.Intervals = append(f.Intervals, interval(rest[4*i:]))
+	}
+	return f, nil
+}
+
+// fourCC returns the four character code embedded in the first four bytes
+// of the format GUID, e.g. "YUY2" or "NV12".
+func fourCC(guid [16]byte) string {
+	for _, c := range guid[:4] {
+		if c < 0x20 || c > 0x7e {
+			return fmt.Sprintf("%x", guid[:4])
+		}
+	}
+	return string(guid[:4])
+}
@@ -0,0 +1,231 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uvc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+// testVC is the class-specific part of a VideoControl interface descriptor
+// of a webcam with a camera terminal, a processing unit, an extension unit
+// and a streaming output terminal.
+var testVC = []byte{
+	// VC_HEADER: UVC 1.00, 30MHz clock, streaming interface 1.
+	0x0d, 0x24, 0x01, 0x00, 0x01, 0x4d, 0x00, 0x80, 0xc3, 0xc9, 0x01, 0x01, 0x01,
+	// VC_INPUT_TERMINAL: ID 1, camera.
+	0x12, 0x24, 0x02, 0x01, 0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x0e, 0x00, 0x00,
+	// VC_PROCESSING_UNIT: ID 3, source 1.
+	0x0b, 0x24, 0x05, 0x03, 0x01, 0x00, 0x00, 0x02, 0x7f, 0x17, 0x00,
+	// VC_EXTENSION_UNIT: ID 4, source 3.
+	0x1b, 0x24, 0x06, 0x04,
+	0x82, 0x06, 0x61, 0x63, 0x70, 0x50, 0xab, 0x49, 0xb8, 0xcc, 0xb3, 0x85, 0x5e, 0x8d, 0x22, 0x1d,
+	0x08, 0x01, 0x03, 0x02, 0xff, 0x00, 0x00,
+	// VC_OUTPUT_TERMINAL: ID 2, streaming, source 4.
+	0x09, 0x24, 0x03, 0x02, 0x01, 0x01, 0x00, 0x04, 0x00,
+}
+
+// testVS is the class-specific part of a VideoStreaming interface descriptor
+// with an uncompressed YUY2 format with two frames and an MJPEG format with
+// one frame.
+var testVS = []byte{
+	// VS_INPUT_HEADER: 2 formats, endpoint 0x81, terminal link 2.
+	0x0f, 0x24, 0x01, 0x02, 0x00, 0x00, 0x81, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	// VS_FORMAT_UNCOMPRESSED: index 1, 2 frames, YUY2, 16bpp, default frame 1.
+	0x1b, 0x24, 0x04, 0x01, 0x02,
+	0x59, 0x55, 0x59, 0x32, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71,
+	0x10, 0x01, 0x00, 0x00, 0x00, 0x00,
+	// VS_FRAME_UNCOMPRESSED: index 1, 640x480, 2 discrete intervals.
+	0x22, 0x24, 0x05, 0x01, 0x00, 0x80, 0x02, 0xe0, 0x01,
+	0x00, 0x00, 0x65, 0x04, 0x00, 0x00, 0xca, 0x08, 0x00, 0x60, 0x09, 0x00, 0x15, 0x16, 0x05, 0x00,
+	0x02, 0x15, 0x16, 0x05, 0x00, 0x2a, 0x2c, 0x0a, 0x00,
+	// VS_FRAME_UNCOMPRESSED: index 2, 320x240, continuous intervals.
+	0x26, 0x24, 0x05, 0x02, 0x00, 0x40, 0x01, 0xf0, 0x00,
+	0x00, 0x00, 0x65, 0x04, 0x00, 0x00, 0xca, 0x08, 0x00, 0x58, 0x02, 0x00, 0x15, 0x16, 0x05, 0x00,
+	0x00, 0x15, 0x16, 0x05, 0x00, 0x80, 0x84, 0x1e, 0x00, 0x15, 0x16, 0x05, 0x00,
+	// VS_FORMAT_MJPEG: index 2, 1 frame, default frame 1.
+	0x0b, 0x24, 0x06, 0x02, 0x01, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00,
+	// VS_FRAME_MJPEG: index 1, 1280x720, 1 discrete interval.
+	0x1e, 0x24, 0x07, 0x01, 0x00, 0x00, 0x05, 0xd0, 0x02,
+	0x00, 0x00, 0x65, 0x04, 0x00, 0x00, 0xca, 0x08, 0x00, 0x20, 0x1c, 0x00, 0x15, 0x16, 0x05, 0x00,
+	0x01, 0x15, 0x16, 0x05, 0x00,
+	// VS_COLORFORMAT
+	0x06, 0x24, 0x0d, 0x01, 0x01, 0x04,
+}
+
+const (
+	fps30 = 33333300 * time.Nanosecond
+	fps15 = 66666600 * time.Nanosecond
+)
+
+func TestParseVideoControl(t *testing.T) {
+	got, err := ParseVideoControl(testVC)
+	if err != nil {
+		t.Fatalf("ParseVideoControl(): %v", err)
+	}
+	want := &VideoControl{
+		UVC:                 gousb.Version(1, 0),
+		ClockFrequency:      30000000,
+		StreamingInterfaces: []int{1},
+		Terminals: []Terminal{
+			{ID: 1, Type: TerminalCamera, Controls: []byte{0x0e, 0x00, 0x00}},
+			{ID: 2, Type: TerminalStreaming, SourceID: 4},
+		},
+		Units: []Unit{
+			{ID: 3, Kind: UnitProcessing, Sources: []int{1}, Controls: []byte{0x7f, 0x17}},
+			{
+				ID:       4,
+				Kind:     UnitExtension,
+				Sources:  []int{3},
+				GUID:     [16]byte{0x82, 0x06, 0x61, 0x63, 0x70, 0x50, 0xab, 0x49, 0xb8, 0xcc, 0xb3, 0x85, 0x5e, 0x8d, 0x22, 0x1d},
+				Controls: []byte{0xff, 0x00},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseVideoControl():\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseVideoStreaming(t *testing.T) {
+	got, err := ParseVideoStreaming(testVS)
+	if err != nil {
+		t.Fatalf("ParseVideoStreaming(): %v", err)
+	}
+	want := &StreamingInterface{
+		Endpoint:     0x81,
+		TerminalLink: 2,
+		Formats: []Format{
+			{
+				Index:        1,
+				Kind:         FormatUncompressed,
+				GUID:         [16]byte{'Y', 'U', 'Y', '2', 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71},
+				BitsPerPixel: 16,
+				DefaultFrame: 1,
+				Frames: []Frame{
+					{
+						Index:           1,
+						Width:           640,
+						Height:          480,
+						MinBitRate:      73728000,
+						MaxBitRate:      147456000,
+						MaxFrameSize:    614400,
+						DefaultInterval: fps30,
+						Intervals:       []time.Duration{fps30, fps15},
+					},
+					{
+						Index:           2,
+						Width:           320,
+						Height:          240,
+						MinBitRate:      73728000,
+						MaxBitRate:      147456000,
+						MaxFrameSize:    153600,
+						DefaultInterval: fps30,
+						MinInterval:     fps30,
+						MaxInterval:     200 * time.Millisecond,
+						IntervalStep:    fps30,
+					},
+				},
+			},
+			{
+				Index:        2,
+				Kind:         FormatMJPEG,
+				DefaultFrame: 1,
+				Frames: []Frame{{
+					Index:           1,
+					Width:           1280,
+					Height:          720,
+					MinBitRate:      73728000,
+					MaxBitRate:      147456000,
+					MaxFrameSize:    1843200,
+					DefaultInterval: fps30,
+					Intervals:       []time.Duration{fps30},
+				}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseVideoStreaming():\ngot  %+v\nwant %+v", got, want)
+	}
+	if got, want := got.Formats[0].String(), `format #1 uncompressed "YUY2" (2 frame sizes)`; got != want {
+		t.Errorf("Format.String(): got %q, want %q", got, want)
+	}
+}
+
+func TestParseDescriptorErrors(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		parse func([]byte) error
+		extra []byte
+	}{
+		{
+			desc:  "VC without header",
+			parse: func(b []byte) error { _, err := ParseVideoControl(b); return err },
+			extra: testVC[13:],
+		},
+		{
+			desc:  "VC truncated",
+			parse: func(b []byte) error { _, err := ParseVideoControl(b); return err },
+			extra: testVC[:20],
+		},
+		{
+			desc:  "VS without header",
+			parse: func(b []byte) error { _, err := ParseVideoStreaming(b); return err },
+			extra: testVS[15:],
+		},
+		{
+			desc:  "VS frame without format",
+			parse: func(b []byte) error { _, err := ParseVideoStreaming(b); return err },
+			extra: append(append([]byte(nil), testVS[:15]...), testVS[42:76]...),
+		},
+		{
+			desc:  "zero length descriptor",
+			parse: func(b []byte) error { _, err := ParseVideoStreaming(b); return err },
+			extra: []byte{0x00, 0x24, 0x01},
+		},
+	} {
+		if err := tc.parse(tc.extra); err == nil {
+			t.Errorf("%s: got nil error, want non-nil", tc.desc)
+		}
+	}
+}
+
+func TestFrameSupportsInterval(t *testing.T) {
+	vs, err := ParseVideoStreaming(testVS)
+	if err != nil {
+		t.Fatalf("ParseVideoStreaming(): %v", err)
+	}
+	discrete, continuous := vs.Formats[0].Frames[0], vs.Formats[0].Frames[1]
+	for _, tc := range []struct {
+		f    Frame
+		d    time.Duration
+		want bool
+	}{
+		{discrete, fps30, true},
+		{discrete, fps15, true},
+		{discrete, 100 * time.Millisecond, false},
+		{continuous, fps30, true},
+		{continuous, 3 * fps30, true},
+		{continuous, 40 * time.Millisecond, false},
+		{continuous, time.Second, false},
+	} {
+		if got := tc.f.SupportsInterval(tc.d); got != tc.want {
+			t.Errorf("%s.SupportsInterval(%v): got %v, want %v", tc.f, tc.d, got, tc.want)
+		}
+	}
+}
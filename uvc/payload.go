@@ -0,0 +1,188 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uvc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Bits of the bmHeaderInfo field of the payload header.
+const (
+	headerFID = 1 << 0
+	headerEOF = 1 << 1
+	headerPTS = 1 << 2
+	headerSCR = 1 << 3
+	headerSTI = 1 << 5
+	headerERR = 1 << 6
+	headerEOH = 1 << 7
+)
+
+// SCR is the source clock reference, sampled by the device when the first
+// payload of a frame was transmitted.
+type SCR struct {
+	// STC is the source time clock in device clock units.
+	STC uint32
+	// SOF is the 11-bit USB frame number.
+	SOF uint16
+}
+
+// payloadHeader is the header of a single UVC payload transfer.
+type payloadHeader struct {
+	length int
+	fid    bool
+	eof    bool
+	still  bool
+	err    bool
+	pts    uint32
+	hasPTS bool
+	scr    SCR
+	hasSCR bool
+}
+
+func parsePayloadHeader(b []byte) (*payloadHeader, error) {
+	if len(b) < 2 {
+		return nil, fmt.Errorf("payload of %d bytes is too short for a payload header", len(b))
+	}
+	h := &payloadHeader{length: int(b[0])}
+	if h.length < 2 || h.length > len(b) {
+		return nil, fmt.Errorf("invalid payload header length %d in a payload of %d bytes", h.length, len(b))
+	}
+	info := b[1]
+	h.fid = info&headerFID != 0
+	h.eof = info&headerEOF != 0
+	h.still = info&headerSTI != 0
+	h.err = info&headerERR != 0
+	off := 2
+	if info&headerPTS != 0 {
+		if off+4 > h.length {
+			return nil, fmt.Errorf("payload header of %d bytes too short for PTS", h.length)
+		}
+		h.pts = binary.LittleEndian.Uint32(b[off:])
+		h.hasPTS = true
+		off += 4
+	}
+	if info&headerSCR != 0 {
+		if off+6 > h.length {
+			return nil, fmt.Errorf("payload header of %d bytes too short for SCR", h.length)
+		}
+		h.scr = SCR{
+			STC: binary.LittleEndian.Uint32(b[off:]),
+			SOF: binary.LittleEndian.Uint16(b[off+4:]) & 0x7ff,
+		}
+		h.hasSCR = true
+	}
+	return h, nil
+}
+
+// VideoFrame is a single complete video frame, reassembled from payloads.
+type VideoFrame struct {
+	// Data is the frame payload data, with the payload headers removed.
+	Data []byte
+	// FID is the value of the frame ID bit of the payloads making up
+	// this frame.
+	FID bool
+	// PTS is the presentation time stamp in device clock units, valid
+	// if HasPTS is true.
+	PTS    uint32
+	HasPTS bool
+	// SCR is the source clock reference, valid if HasSCR is true.
+	SCR    SCR
+	HasSCR bool
+	// Still is true if the frame is a still image.
+	Still bool
+	// Error is true if the device signaled an error while transmitting
+	// any of the payloads of the frame. The frame data is likely corrupt.
+	Error bool
+}
+
+// assembler reassembles payloads into video frames.
+type assembler struct {
+	// maxFrameSize is the maximum expected frame size, used as the
+	// initial capacity of the frame buffer.
+	maxFrameSize int
+	// cur is the frame being assembled, nil if no payload was received
+	// since the last complete frame.
+	cur *VideoFrame
+	// dropping is true after a payload was lost, until the start of the
+	// next frame.
+	dropping bool
+	// resync is true if no payload was received since the loss.
+	resync bool
+	// dropFID is the FID of the frame being dropped.
+	dropFID bool
+}
+
+// drop discards the frame being assembled after a payload was lost, e.g.
+// an isochronous packet with an error. The frame of the next payload is
+// dropped too, since it may be the frame whose start was lost.
+func (a *assembler) drop() {
+	a.cur = nil
+	a.dropping = true
+	a.resync = true
+}
+
+// push adds a payload to the current frame. It returns the completed frames,
+// if any. A payload can complete at most two frames: the previous frame,
+// if the payload's FID doesn't match it, and the current one if it carries
+// an EOF flag.
+func (a *assembler) push(payload []byte) ([]*VideoFrame, error) {
+	if len(payload) == 0 {
+		// Empty isochronous packets carry no header.
+		return nil, nil
+	}
+	h, err := parsePayloadHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+	if a.dropping {
+		if a.resync {
+			a.dropFID = h.fid
+			a.resync = false
+		}
+		if h.fid == a.dropFID {
+			a.dropping = !h.eof
+			return nil, nil
+		}
+		a.dropping = false
+	}
+	var ret []*VideoFrame
+	if a.cur != nil && a.cur.FID != h.fid {
+		// FID toggled without an EOF in the previous frame.
+		ret = append(ret, a.cur)
+		a.cur = nil
+	}
+	if a.cur == nil {
+		a.cur = &VideoFrame{
+			Data: make([]byte, 0, a.maxFrameSize),
+			FID:  h.fid,
+		}
+	}
+	f := a.cur
+	f.Data = append(f.Data, payload[h.length:]...)
+	if h.hasPTS && !f.HasPTS {
+		f.PTS, f.HasPTS = h.pts, true
+	}
+	if h.hasSCR && !f.HasSCR {
+		f.SCR, f.HasSCR = h.scr, true
+	}
+	f.Still = f.Still || h.still
+	f.Error = f.Error || h.err
+	if h.eof {
+		ret = append(ret, f)
+		a.cur = nil
+	}
+	return ret, nil
+}
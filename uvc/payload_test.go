@@ -0,0 +1,234 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uvc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// payload builds a payload with a 12 byte header carrying PTS and SCR.
+func payload(info byte, pts uint32, data ...byte) []byte {
+	h := []byte{
+		12, info | headerEOH | headerPTS | headerSCR,
+		byte(pts), byte(pts >> 8), byte(pts >> 16), byte(pts >> 24),
+		0x10, 0x20, 0x30, 0x40, 0x01, 0x02,
+	}
+	return append(h, data...)
+}
+
+func TestParsePayloadHeader(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		b       []byte
+		want    payloadHeader
+		wantErr bool
+	}{
+		{
+			desc: "minimal header",
+			b:    []byte{0x02, headerEOH | headerFID | headerEOF, 0xaa},
+			want: payloadHeader{length: 2, fid: true, eof: true},
+		},
+		{
+			desc: "PTS and SCR",
+			b:    payload(headerERR, 0x01020304),
+			want: payloadHeader{length: 12, err: true, pts: 0x01020304, hasPTS: true, scr: SCR{STC: 0x40302010, SOF: 0x201}, hasSCR: true},
+		},
+		{
+			desc:    "too short",
+			b:       []byte{0x02},
+			wantErr: true,
+		},
+		{
+			desc:    "header longer than payload",
+			b:       []byte{0x0c, headerEOH, 0x00},
+			wantErr: true,
+		},
+		{
+			desc:    "PTS flag in a short header",
+			b:       []byte{0x04, headerPTS, 0x00, 0x00},
+			wantErr: true,
+		},
+	} {
+		got, err := parsePayloadHeader(tc.b)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: parsePayloadHeader(): got error %v, want error: %v", tc.desc, err, tc.wantErr)
+			continue
+		}
+		if err == nil && *got != tc.want {
+			t.Errorf("%s: parsePayloadHeader(): got %+v, want %+v", tc.desc, *got, tc.want)
+		}
+	}
+}
+
+// testPayloads is a replay of payloads received from a camera: an EOF
+// terminated frame, a frame terminated only by an FID toggle, an empty
+// isochronous packet and a frame with the error bit set.
+var testPayloads = [][]byte{
+	payload(0, 100, 1, 2, 3),
+	payload(0, 100, 4, 5),
+	payload(headerEOF, 100, 6),
+	payload(headerFID, 200, 7, 8),
+	payload(headerFID, 200, 9),
+	{},
+	payload(0, 300, 10),
+	payload(headerERR, 300, 11),
+	payload(headerEOF, 300),
+}
+
+var wantFrames = []VideoFrame{
+	{Data: []byte{1, 2, 3, 4, 5, 6}, PTS: 100, HasPTS: true, SCR: SCR{STC: 0x40302010, SOF: 0x201}, HasSCR: true},
+	{Data: []byte{7, 8, 9}, FID: true, PTS: 200, HasPTS: true, SCR: SCR{STC: 0x40302010, SOF: 0x201}, HasSCR: true},
+	{Data: []byte{10, 11}, PTS: 300, HasPTS: true, SCR: SCR{STC: 0x40302010, SOF: 0x201}, HasSCR: true, Error: true},
+}
+
+func checkFrames(t *testing.T, got []*VideoFrame) {
+	t.Helper()
+	if len(got) != len(wantFrames) {
+		t.Fatalf("got %d frames, want %d", len(got), len(wantFrames))
+	}
+	for i, f := range got {
+		want := wantFrames[i]
+		if !reflect.DeepEqual(*f, want) {
+			t.Errorf("frame #%d: got %+v, want %+v", i, *f, want)
+		}
+	}
+}
+
+func TestAssembler(t *testing.T) {
+	a := &assembler{maxFrameSize: 16}
+	var got []*VideoFrame
+	for i, p := range testPayloads {
+		fs, err := a.push(p)
+		if err != nil {
+			t.Fatalf("push(payload #%d): %v", i, err)
+		}
+		got = append(got, fs...)
+	}
+	checkFrames(t, got)
+	if _, err := a.push([]byte{0x20, 0x00}); err == nil {
+		t.Error("push(malformed payload): got nil error, want non-nil")
+	}
+}
+
+// fakePayloadReader replays a list of transfers, one per read, then
+// returns err. A nil transfer is lost: it's reported to onError and
+// skipped, like a transfer skipped by a gousb.ReadStream.
+type fakePayloadReader struct {
+	transfers [][][]byte
+	err       error
+	onError   func(error)
+}
+
+func (f *fakePayloadReader) NextPackets(ctx context.Context) ([][]byte, error) {
+	for len(f.transfers) > 0 {
+		pkts := f.transfers[0]
+		f.transfers = f.transfers[1:]
+		if pkts != nil {
+			return pkts, nil
+		}
+		f.onError(errors.New("packet lost"))
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// transfers splits payloads into transfers of n packets.
+func transfers(payloads [][]byte, n int) [][][]byte {
+	var ret [][][]byte
+	for len(payloads) > n {
+		ret = append(ret, payloads[:n])
+		payloads = payloads[n:]
+	}
+	return append(ret, payloads)
+}
+
+func TestStream(t *testing.T) {
+	errDisconnected := errors.New("device disconnected")
+	payloads := append([][]byte{{0xff, 0x00}}, testPayloads...) // a malformed payload is skipped
+	s := newStream(&fakePayloadReader{transfers: transfers(payloads, 2), err: errDisconnected}, &assembler{maxFrameSize: 16})
+	var got []*VideoFrame
+	for f := range s.Frames() {
+		got = append(got, f)
+	}
+	checkFrames(t, got)
+	if err := s.Close(); err != errDisconnected {
+		t.Errorf("Close(): got %v, want %v", err, errDisconnected)
+	}
+}
+
+func TestStreamLostPackets(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		transfers [][][]byte
+		want      []VideoFrame
+	}{
+		{
+			desc:      "lost in the middle of a frame",
+			transfers: [][][]byte{testPayloads[:2], nil, testPayloads[2:]},
+			want:      wantFrames[1:],
+		},
+		{
+			// The lost transfer might have carried the start of the
+			// next frame.
+			desc:      "lost after the end of a frame",
+			transfers: [][][]byte{testPayloads[:3], nil, testPayloads[3:]},
+			want:      []VideoFrame{wantFrames[0], wantFrames[2]},
+		},
+		{
+			desc:      "lost twice",
+			transfers: [][][]byte{testPayloads[:2], nil, testPayloads[2:4], nil, testPayloads[4:]},
+			want:      wantFrames[2:],
+		},
+	} {
+		a := &assembler{maxFrameSize: 16}
+		r := &fakePayloadReader{transfers: tc.transfers, err: io.EOF, onError: func(error) { a.drop() }}
+		s := newStream(r, a)
+		var got []VideoFrame
+		for f := range s.Frames() {
+			got = append(got, *f)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got frames %+v, want %+v", tc.desc, got, tc.want)
+		}
+		s.Close()
+	}
+}
+
+func TestStreamClose(t *testing.T) {
+	s := newStream(&fakePayloadReader{transfers: transfers(testPayloads[:3], 2)}, &assembler{maxFrameSize: 16})
+	f := <-s.Frames()
+	if !bytes.Equal(f.Data, wantFrames[0].Data) {
+		t.Errorf("first frame: got %v, want %v", f.Data, wantFrames[0].Data)
+	}
+	released := false
+	s.release = func() { released = true }
+	if err := s.Close(); err != nil {
+		t.Errorf("Close(): %v", err)
+	}
+	if !released {
+		t.Error("Close() did not release the interface")
+	}
+	if _, ok := <-s.Frames(); ok {
+		t.Error("Frames() channel is still open after Close()")
+	}
+}
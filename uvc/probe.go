@@ -0,0 +1,203 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uvc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+// Class-specific request codes.
+const (
+	reqSetCur = 0x01
+	reqGetCur = 0x81
+)
+
+// VideoStreaming interface control selectors.
+const (
+	vsProbeControl  = 0x01
+	vsCommitControl = 0x02
+)
+
+// Sizes of the probe/commit control structure for the different UVC versions.
+const (
+	probeSize10 = 26
+	probeSize11 = 34
+	probeSize15 = 48
+)
+
+// controller carries the PROBE and COMMIT requests of the streaming
+// parameter negotiation. *gousb.Device implements controller.
+type controller interface {
+	Control(rType, request uint8, val, idx uint16, data []byte) (int, error)
+}
+
+// Probe is the video probe and commit control structure, used to negotiate
+// the streaming parameters with the device.
+type Probe struct {
+	// Hint tells the device which fields should be kept fixed during
+	// negotiation. Bit 0 fixes the frame interval.
+	Hint uint16
+	// FormatIndex and FrameIndex select the video format and frame.
+	FormatIndex, FrameIndex int
+	// FrameInterval is the requested frame interval.
+	FrameInterval time.Duration
+	// KeyFrameRate, PFrameRate, CompQuality and CompWindowSize are
+	// parameters of compressed formats.
+	KeyFrameRate, PFrameRate, CompQuality, CompWindowSize uint16
+	// Delay is the internal video streaming latency of the device, in ms.
+	Delay uint16
+	// MaxVideoFrameSize is the maximum size of a video frame in bytes.
+	MaxVideoFrameSize uint32
+	// MaxPayloadTransferSize is the maximum number of bytes the device
+	// can transmit in a single payload transfer.
+	MaxPayloadTransferSize uint32
+	// ClockFrequency is the device clock frequency in Hz (UVC 1.1+).
+	ClockFrequency uint32
+	// FramingInfo, PreferedVersion, MinVersion and MaxVersion are
+	// UVC 1.1+ fields.
+	FramingInfo, PreferedVersion, MinVersion, MaxVersion uint8
+	// Remaining holds the UVC 1.5 fields (bUsage onwards), not interpreted
+	// by this package but preserved during negotiation.
+	Remaining [probeSize15 - probeSize11]byte
+}
+
+// String returns a human-readable summary of the probe.
+func (p Probe) String() string {
+	return fmt.Sprintf("format #%d frame #%d interval %v (max frame %d bytes, max payload %d bytes)", p.FormatIndex, p.FrameIndex, p.FrameInterval, p.MaxVideoFrameSize, p.MaxPayloadTransferSize)
+}
+
+// probeSize returns the size of the probe structure for a given UVC version.
+func probeSize(uvc gousb.BCD) int {
+	switch {
+	case uvc >= gousb.Version(1, 50):
+		return probeSize15
+	case uvc >= gousb.Version(1, 10):
+		return probeSize11
+	}
+	return probeSize10
+}
+
+func (p *Probe) marshal(size int) []byte {
+	b := make([]byte, probeSize15)
+	binary.LittleEndian.PutUint16(b[0:], p.Hint)
+	b[2] = uint8(p.FormatIndex)
+	b[3] = uint8(p.FrameIndex)
+	binary.LittleEndian.PutUint32(b[4:], uint32(p.FrameInterval/frameIntervalUnit))
+	binary.LittleEndian.PutUint16(b[8:], p.KeyFrameRate)
+	binary.LittleEndian.PutUint16(b[10:], p.PFrameRate)
+	binary.LittleEndian.PutUint16(b[12:], p.CompQuality)
+	binary.LittleEndian.PutUint16(b[14:], p.CompWindowSize)
+	binary.LittleEndian.PutUint16(b[16:], p.Delay)
+	binary.LittleEndian.PutUint32(b[18:], p.MaxVideoFrameSize)
+	binary.LittleEndian.PutUint32(b[22:], p.MaxPayloadTransferSize)
+	binary.LittleEndian.PutUint32(b[26:], p.ClockFrequency)
+	b[30] = p.FramingInfo
+	b[31] = p.PreferedVersion
+	b[32] = p.MinVersion
+	b[33] = p.MaxVersion
+	copy(b[34:], p.Remaining[:])
+	return b[:size]
+}
+
+func (p *Probe) unmarshal(b []byte) error {
+	if len(b) < probeSize10 {
+		return fmt.Errorf("probe control too short: got %d bytes, want at least %d", len(b), probeSize10)
+	}
+	full := make([]byte, probeSize15)
+	copy(full, b)
+	*p = Probe{
+		Hint:                   binary.LittleEndian.Uint16(full[0:]),
+		FormatIndex:            int(full[2]),
+		FrameIndex:             int(full[3]),
+		FrameInterval:          interval(full[4:]),
+		KeyFrameRate:           binary.LittleEndian.Uint16(full[8:]),
+		PFrameRate:             binary.LittleEndian.Uint16(full[10:]),
+		CompQuality:            binary.LittleEndian.Uint16(full[12:]),
+		CompWindowSize:         binary.LittleEndian.Uint16(full[14:]),
+		Delay:                  binary.LittleEndian.Uint16(full[16:]),
+		MaxVideoFrameSize:      le32(full[18:]),
+		MaxPayloadTransferSize: le32(full[22:]),
+		ClockFrequency:         le32(full[26:]),
+		FramingInfo:            full[30],
+		PreferedVersion:        full[31],
+		MinVersion:             full[32],
+		MaxVersion:             full[33],
+	}
+	copy(p.Remaining[:], full[34:])
+	return nil
+}
+
+// negotiator performs the probe and commit sequence on a VideoStreaming
+// interface.
+type negotiator struct {
+	ctl   controller
+	intf  int
+	size  int
+	retry int
+}
+
+func (n *negotiator) get(req uint8, sel int) (*Probe, error) {
+	buf := make([]byte, n.size)
+	got, err := n.ctl.Control(gousb.ControlIn|gousb.ControlClass|gousb.ControlInterface, req, uint16(sel)<<8, uint16(n.intf), buf)
+	if err != nil {
+		return nil, fmt.Errorf("GET request 0x%02x for control %d on interface %d: %v", req, sel, n.intf, err)
+	}
+	p := new(Probe)
+	if err := p.unmarshal(buf[:got]); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (n *negotiator) set(sel int, p *Probe) error {
+	if _, err := n.ctl.Control(gousb.ControlOut|gousb.ControlClass|gousb.ControlInterface, reqSetCur, uint16(sel)<<8, uint16(n.intf), p.marshal(n.size)); err != nil {
+		return fmt.Errorf("SET_CUR for control %d on interface %d: %v", sel, n.intf, err)
+	}
+	return nil
+}
+
+// negotiate sends the requested probe to the device, reads back the values
+// the device is willing to accept and commits them. The committed probe is
+// returned.
+func (n *negotiator) negotiate(want *Probe) (*Probe, error) {
+	cur := *want
+	for i := 0; ; i++ {
+		if err := n.set(vsProbeControl, &cur); err != nil {
+			return nil, err
+		}
+		got, err := n.get(reqGetCur, vsProbeControl)
+		if err != nil {
+			return nil, err
+		}
+		if got.FormatIndex == want.FormatIndex && got.FrameIndex == want.FrameIndex {
+			cur = *got
+			break
+		}
+		// The device modified the format or frame. Some devices need
+		// a few rounds to settle, but eventually they either accept the
+		// request or the request is not supported.
+		if i >= n.retry {
+			return nil, fmt.Errorf("device rejected %s, offered %s instead", want, got)
+		}
+	}
+	if err := n.set(vsCommitControl, &cur); err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
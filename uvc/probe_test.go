@@ -0,0 +1,161 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uvc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+// fakeCamera implements the probe and commit controls of a VideoStreaming
+// interface.
+type fakeCamera struct {
+	intf int
+	// accept returns the probe the device will report after
+	// SET_CUR(PROBE) with the given probe.
+	accept func(Probe) Probe
+	probe  Probe
+	commit *Probe
+	sets   int
+}
+
+func (f *fakeCamera) Control(rType, request uint8, val, idx uint16, data []byte) (int, error) {
+	if int(idx) != f.intf {
+		return 0, errors.New("wrong interface")
+	}
+	sel := int(val >> 8)
+	switch {
+	case rType == gousb.ControlOut|gousb.ControlClass|gousb.ControlInterface && request == reqSetCur:
+		var p Probe
+		if err := p.unmarshal(data); err != nil {
+			return 0, err
+		}
+		f.sets++
+		switch sel {
+		case vsProbeControl:
+			f.probe = f.accept(p)
+		case vsCommitControl:
+			f.commit = &p
+		default:
+			return 0, errors.New("unknown control")
+		}
+		return len(data), nil
+	case rType == gousb.ControlIn|gousb.ControlClass|gousb.ControlInterface && request == reqGetCur && sel == vsProbeControl:
+		return copy(data, f.probe.marshal(len(data))), nil
+	}
+	return 0, errors.New("unsupported request")
+}
+
+func TestProbeMarshal(t *testing.T) {
+	p := Probe{
+		Hint:                   1,
+		FormatIndex:            2,
+		FrameIndex:             3,
+		FrameInterval:          fps30,
+		CompQuality:            5000,
+		MaxVideoFrameSize:      614400,
+		MaxPayloadTransferSize: 3072,
+		ClockFrequency:         48000000,
+		FramingInfo:            3,
+	}
+	b := p.marshal(probeSize11)
+	if len(b) != probeSize11 {
+		t.Fatalf("marshal(%d): got %d bytes", probeSize11, len(b))
+	}
+	if got, want := b[:6], []byte{0x01, 0x00, 0x02, 0x03, 0x15, 0x16}; !reflect.DeepEqual(got, want) {
+		t.Errorf("marshal(): first bytes %x, want %x", got, want)
+	}
+	var got Probe
+	if err := got.unmarshal(b); err != nil {
+		t.Fatalf("unmarshal(): %v", err)
+	}
+	if got != p {
+		t.Errorf("unmarshal(marshal(p)): got %+v, want %+v", got, p)
+	}
+	if err := got.unmarshal(b[:20]); err == nil {
+		t.Error("unmarshal() of a truncated probe: got nil error, want non-nil")
+	}
+}
+
+func TestProbeSize(t *testing.T) {
+	for _, tc := range []struct {
+		uvc  gousb.BCD
+		want int
+	}{
+		{gousb.Version(1, 0), probeSize10},
+		{gousb.Version(1, 10), probeSize11},
+		{gousb.Version(1, 50), probeSize15},
+	} {
+		if got := probeSize(tc.uvc); got != tc.want {
+			t.Errorf("probeSize(%s): got %d, want %d", tc.uvc, got, tc.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	fillIn := func(p Probe) Probe {
+		p.MaxVideoFrameSize = 614400
+		p.MaxPayloadTransferSize = 3072
+		return p
+	}
+	for _, tc := range []struct {
+		desc     string
+		accept   func(Probe) Probe
+		wantErr  bool
+		wantSets int
+	}{
+		{
+			desc:     "device accepts",
+			accept:   fillIn,
+			wantSets: 2,
+		},
+		{
+			desc: "device offers a different frame",
+			accept: func(p Probe) Probe {
+				p = fillIn(p)
+				p.FrameIndex = 1
+				return p
+			},
+			wantErr:  true,
+			wantSets: probeRetries + 1,
+		},
+	} {
+		cam := &fakeCamera{intf: 1, accept: tc.accept}
+		n := &negotiator{ctl: cam, intf: 1, size: probeSize11, retry: probeRetries}
+		want := &Probe{Hint: 1, FormatIndex: 1, FrameIndex: 2, FrameInterval: fps30}
+		got, err := n.negotiate(want)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: negotiate(): got error %v, want error: %v", tc.desc, err, tc.wantErr)
+		}
+		if cam.sets != tc.wantSets {
+			t.Errorf("%s: got %d SET_CUR requests, want %d", tc.desc, cam.sets, tc.wantSets)
+		}
+		if tc.wantErr {
+			if cam.commit != nil {
+				t.Errorf("%s: probe was committed after failed negotiation", tc.desc)
+			}
+			continue
+		}
+		if got.MaxPayloadTransferSize != 3072 || got.MaxVideoFrameSize != 614400 {
+			t.Errorf("%s: negotiate(): got %s, want values filled in by the device", tc.desc, got)
+		}
+		if cam.commit == nil || *cam.commit != *got {
+			t.Errorf("%s: committed %v, want %v", tc.desc, cam.commit, got)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package uvc implements video capture from USB Video Class (UVC) devices.
+//
+// A Camera is opened on top of a gousb.Device. It parses the class-specific
+// VideoControl and VideoStreaming descriptors, which describe the available
+// formats, frame sizes and frame intervals:
+//
+//	cam, err := uvc.Open(dev)
+//	...
+//	defer cam.Close()
+//	vs := cam.Streams[0]
+//	f := vs.Formats[0]
+//	s, err := cam.Start(vs, f.Index, f.DefaultFrame, 0)
+//	...
+//	for frame := range s.Frames() {
+//		// use frame.Data
+//	}
+//
+// Start negotiates the streaming parameters with the device through the
+// PROBE/COMMIT controls, selects an alternate setting of the VideoStreaming
+// interface with enough isochronous bandwidth (or uses the bulk endpoint)
+// and reassembles the payloads received from the device into complete
+// frames.
+package uvc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/internal/claim"
+)
+
+const (
+	// isoTransfers is the number of isochronous transfers kept in flight.
+	isoTransfers = 8
+	// isoPackets is the number of isochronous packets of each transfer.
+	// Each packet carries a single payload, see Camera.Start.
+	isoPackets = 32
+	// bulkTransfers is the number of bulk transfers kept in flight.
+	bulkTransfers = 4
+	// frameQueue is the number of complete frames buffered for the reader.
+	frameQueue = 4
+	// probeRetries is the number of times the probe is repeated if the
+	// device doesn't accept the requested parameters.
+	probeRetries = 3
+)
+
+// Camera is a UVC device with a claimed configuration.
+type Camera struct {
+	// Control describes the VideoControl interface of the device.
+	Control *VideoControl
+	// Streams lists the VideoStreaming interfaces of the device.
+	Streams []*StreamingInterface
+
+	dev *gousb.Device
+	cfg *gousb.Config
+}
+
+// Open claims the active configuration of the device and parses its video
+// class descriptors. The Camera should be Close()d after use.
+func Open(dev *gousb.Device) (*Camera, error) {
+	cfgNum, desc, err := claim.ActiveConfig(dev)
+	if err != nil {
+		return nil, err
+	}
+	c := &Camera{dev: dev}
+	if c.Control, c.Streams, err = parseConfig(desc); err != nil {
+		return nil, fmt.Errorf("device %s: %v", dev, err)
+	}
+	if c.cfg, err = dev.Config(cfgNum); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseConfig finds the VideoControl interface in the configuration and
+// parses its descriptors and the descriptors of all VideoStreaming
+// interfaces it refers to.
+func parseConfig(desc gousb.ConfigDesc) (*VideoControl, []*StreamingInterface, error) {
+	var vc *VideoControl
+	for _, intf := range desc.Interfaces {
+		alt := intf.AltSettings[0]
+		if alt.Class != gousb.ClassVideo || alt.SubClass != subClassVideoControl {
+			continue
+		}
+		var err error
+		if vc, err = ParseVideoControl(alt.Extra); err != nil {
+			return nil, nil, fmt.Errorf("VideoControl interface %d: %v", intf.Number, err)
+		}
+		vc.Interface = intf.Number
+		break
+	}
+	if vc == nil {
+		return nil, nil, fmt.Errorf("%s has no VideoControl interface", desc)
+	}
+	var streams []*StreamingInterface
+	for _, num := range vc.StreamingInterfaces {
+		intf, err := findInterface(desc, num)
+		if err != nil {
+			return nil, nil, err
+		}
+		alt := intf.AltSettings[0]
+		if alt.Class != gousb.ClassVideo || alt.SubClass != subClassVideoStreaming {
+			return nil, nil, fmt.Errorf("interface %d listed by the VideoControl header is not a VideoStreaming interface", num)
+		}
+		vs, err := ParseVideoStreaming(alt.Extra)
+		if err != nil {
+			return nil, nil, fmt.Errorf("VideoStreaming interface %d: %v", num, err)
+		}
+		vs.Interface = num
+		streams = append(streams, vs)
+	}
+	return vc, streams, nil
+}
+
+func findInterface(desc gousb.ConfigDesc, num int) (*gousb.InterfaceDesc, error) {
+	for i := range desc.Interfaces {
+		if desc.Interfaces[i].Number == num {
+			return &desc.Interfaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no interface %d", desc, num)
+}
+
+// selectAltSetting returns the alternate setting of the VideoStreaming
+// interface that should be used to receive payloads of a given size.
+// For isochronous endpoints it's the setting with the smallest packet size
+// that can still accommodate the payload. Bulk endpoints are available
+// in the default setting.
+func selectAltSetting(intf *gousb.InterfaceDesc, addr gousb.EndpointAddress, payloadSize int) (*gousb.InterfaceSetting, *gousb.EndpointDesc, error) {
+	var best *gousb.InterfaceSetting
+	var bestEp gousb.EndpointDesc
+	maxAvailable := 0
+	for i := range intf.AltSettings {
+		alt := &intf.AltSettings[i]
+		ep, ok := alt.Endpoints[addr]
+		if !ok {
+			continue
+		}
+		if ep.TransferType == gousb.TransferTypeBulk {
+			return alt, &ep, nil
+		}
+		if ep.MaxPacketSize > maxAvailable {
+			maxAvailable = ep.MaxPacketSize
+		}
+		if ep.MaxPacketSize < payloadSize {
+			continue
+		}
+		if best == nil || ep.MaxPacketSize < bestEp.MaxPacketSize {
+			best, bestEp = alt, ep
+		}
+	}
+	if best == nil {
+		return nil, nil, fmt.Errorf("insufficient bandwidth on interface %d: payloads of %d bytes requested, largest packet available on endpoint %s is %d bytes", intf.Number, payloadSize, addr, maxAvailable)
+	}
+	return best, &bestEp, nil
+}
+
+// Close releases the configuration claimed by the camera. All streams
+// must be closed before calling Close.
+func (c *Camera) Close() error {
+	return c.cfg.Close()
+}
+
+// Start negotiates the streaming parameters and starts streaming video
+// frames from the given VideoStreaming interface. The format and frame are
+// selected by their indexes, as in Format.Index and Frame.Index. An interval
+// of 0 selects the default frame interval of the frame.
+//
+// For isochronous endpoints each packet carries a single payload, since
+// the payload boundaries are determined by the packet boundaries. A frame
+// with a packet lost to an error is dropped and the stream continues with
+// the next frame.
+//
+// The returned Stream needs to be closed before the interface can be used
+// again.
+func (c *Camera) Start(vs *StreamingInterface, format, frame int, interval time.Duration) (*Stream, error) {
+	f, err := vs.Format(format)
+	if err != nil {
+		return nil, err
+	}
+	fr, err := f.Frame(frame)
+	if err != nil {
+		return nil, err
+	}
+	if interval == 0 {
+		interval = fr.DefaultInterval
+	}
+	intf, err := findInterface(c.cfg.Desc, vs.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	// The streaming interface must be idle (alternate setting 0) while
+	// the parameters are negotiated.
+	idle, err := c.cfg.Interface(vs.Interface, 0)
+	if err != nil {
+		return nil, err
+	}
+	n := &negotiator{
+		ctl:   c.dev,
+		intf:  vs.Interface,
+		size:  probeSize(c.Control.UVC),
+		retry: probeRetries,
+	}
+	p, err := n.negotiate(&Probe{
+		Hint:          1, // keep the frame interval fixed
+		FormatIndex:   format,
+		FrameIndex:    frame,
+		FrameInterval: interval,
+	})
+	idle.Close()
+	if err != nil {
+		return nil, fmt.Errorf("negotiation of %s, %s failed: %v", f, fr, err)
+	}
+
+	alt, ep, err := selectAltSetting(intf, vs.Endpoint, int(p.MaxPayloadTransferSize))
+	if err != nil {
+		return nil, err
+	}
+	active, err := c.cfg.Interface(alt.Number, alt.Alternate)
+	if err != nil {
+		return nil, err
+	}
+	in, err := active.InEndpoint(ep.Number)
+	if err != nil {
+		active.Close()
+		return nil, err
+	}
+	a := &assembler{maxFrameSize: int(p.MaxVideoFrameSize)}
+	size, count := ep.MaxPacketSize*isoPackets, isoTransfers
+	opts := gousb.StreamOptions{
+		Timeout: in.Timeout,
+		// The packets received before the lost one are dropped with
+		// the transfer, so is the frame they belong to.
+		SkipErrors: []gousb.TransferStatus{gousb.TransferError, gousb.TransferOverflow},
+		OnError:    func(error) { a.drop() },
+	}
+	if ep.TransferType == gousb.TransferTypeBulk {
+		size, count = int(p.MaxPayloadTransferSize), bulkTransfers
+		if rem := size % ep.MaxPacketSize; rem != 0 {
+			size += ep.MaxPacketSize - rem
+		}
+		opts = gousb.StreamOptions{Timeout: in.Timeout}
+	}
+	rs, err := in.NewStreamWithOptions(size, count, opts)
+	if err != nil {
+		active.Close()
+		return nil, err
+	}
+	s := newStream(rs, a)
+	s.Probe = *p
+	s.release = func() {
+		rs.Close()
+		active.Close()
+		// Switch the interface back to the zero-bandwidth setting.
+		if idle, err := c.cfg.Interface(vs.Interface, 0); err == nil {
+			idle.Close()
+		}
+	}
+	return s, nil
+}
+
+// payloadReader reads the payloads of a transfer, one per packet.
+// *gousb.ReadStream implements payloadReader.
+type payloadReader interface {
+	NextPackets(context.Context) ([][]byte, error)
+}
+
+// Stream delivers video frames received from a camera.
+type Stream struct {
+	// Probe contains the streaming parameters committed to the device.
+	Probe Probe
+
+	frames  chan *VideoFrame
+	cancel  context.CancelFunc
+	done    chan struct{}
+	release func()
+
+	mu  sync.Mutex
+	err error
+}
+
+func newStream(r payloadReader, a *assembler) *Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Stream{
+		frames: make(chan *VideoFrame, frameQueue),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.run(ctx, r, a)
+	return s
+}
+
+func (s *Stream) run(ctx context.Context, r payloadReader, a *assembler) {
+	defer close(s.done)
+	defer close(s.frames)
+	for {
+		pkts, err := r.NextPackets(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.setErr(err)
+			}
+			return
+		}
+		for _, pkt := range pkts {
+			frames, err := a.push(pkt)
+			if err != nil {
+				// A malformed payload only affects the frame it belongs to.
+				continue
+			}
+			for _, f := range frames {
+				select {
+				case s.frames <- f:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Stream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Frames returns the channel of complete video frames. The channel is closed
+// when the stream is closed or when reading from the device fails, see Err.
+// The receiver must keep up with the frame rate of the stream, otherwise
+// the device will drop data.
+func (s *Stream) Frames() <-chan *VideoFrame {
+	return s.frames
+}
+
+// Err returns the error that terminated the stream, if any. Err should be
+// called after the Frames channel is closed.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the stream and releases the VideoStreaming interface.
+func (s *Stream) Close() error {
+	s.cancel()
+	<-s.done
+	if s.release != nil {
+		s.release()
+		s.release = nil
+	}
+	return s.Err()
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 the gousb Authors.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uvc
+
+import (
+	"testing"
+
+	"github.com/google/gousb"
+)
+
+func isoSetting(alt, pktSize int) gousb.InterfaceSetting {
+	s := gousb.InterfaceSetting{
+		Number:    1,
+		Alternate: alt,
+		Class:     gousb.ClassVideo,
+		SubClass:  subClassVideoStreaming,
+	}
+	if pktSize > 0 {
+		s.Endpoints = map[gousb.EndpointAddress]gousb.EndpointDesc{
+			0x81: {
+				Address:       0x81,
+				Number:        1,
+				Direction:     gousb.EndpointDirectionIn,
+				MaxPacketSize: pktSize,
+				TransferType:  gousb.TransferTypeIsochronous,
+			},
+		}
+	}
+	return s
+}
+
+var testConfig = gousb.ConfigDesc{
+	Number: 1,
+	Interfaces: []gousb.InterfaceDesc{
+		{
+			Number: 0,
+			AltSettings: []gousb.InterfaceSetting{{
+				Number:   0,
+				Class:    gousb.ClassVideo,
+				SubClass: subClassVideoControl,
+				Extra:    testVC,
+			}},
+		},
+		{
+			Number: 1,
+			AltSettings: func() []gousb.InterfaceSetting {
+				alts := []gousb.InterfaceSetting{isoSetting(0, 0), isoSetting(1, 192), isoSetting(2, 1024), isoSetting(3, 3072)}
+				alts[0].Extra = testVS
+				return alts
+			}(),
+		},
+	},
+}
+
+func TestParseConfig(t *testing.T) {
+	vc, vs, err := parseConfig(testConfig)
+	if err != nil {
+		t.Fatalf("parseConfig(): %v", err)
+	}
+	if vc.Interface != 0 {
+		t.Errorf("VideoControl interface: got %d, want 0", vc.Interface)
+	}
+	if len(vs) != 1 || vs[0].Interface != 1 || len(vs[0].Formats) != 2 {
+		t.Errorf("VideoStreaming interfaces: got %+v, want interface 1 with 2 formats", vs)
+	}
+
+	noVC := testConfig
+	noVC.Interfaces = testConfig.Interfaces[1:]
+	if _, _, err := parseConfig(noVC); err == nil {
+		t.Error("parseConfig() without a VideoControl interface: got nil error, want non-nil")
+	}
+	noVS := testConfig
+	noVS.Interfaces = testConfig.Interfaces[:1]
+	if _, _, err := parseConfig(noVS); err == nil {
+		t.Error("parseConfig() without a VideoStreaming interface: got nil error, want non-nil")
+	}
+}
+
+func TestSelectAltSetting(t *testing.T) {
+	intf := &testConfig.Interfaces[1]
+	for _, tc := range []struct {
+		payload int
+		want    int
+		wantErr bool
+	}{
+		{payload: 100, want: 1},
+		{payload: 192, want: 1},
+		{payload: 193, want: 2},
+		{payload: 3000, want: 3},
+		{payload: 3073, wantErr: true},
+	} {
+		alt, ep, err := selectAltSetting(intf, 0x81, tc.payload)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("selectAltSetting(%d): got error %v, want error: %v", tc.payload, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if alt.Alternate != tc.want {
+			t.Errorf("selectAltSetting(%d): got alt %d, want %d", tc.payload, alt.Alternate, tc.want)
+		}
+		if ep.Address != 0x81 {
+			t.Errorf("selectAltSetting(%d): got endpoint %s, want 0x81", tc.payload, ep.Address)
+		}
+	}
+
+	bulk := &gousb.InterfaceDesc{
+		Number: 1,
+		AltSettings: []gousb.InterfaceSetting{{
+			Number: 1,
+			Endpoints: map[gousb.EndpointAddress]gousb.EndpointDesc{
+				0x81: {Address: 0x81, Number: 1, MaxPacketSize: 512, TransferType: gousb.TransferTypeBulk},
+			},
+		}},
+	}
+	if alt, _, err := selectAltSetting(bulk, 0x81, 1<<20); err != nil || alt.Alternate != 0 {
+		t.Errorf("selectAltSetting(bulk): got alt %v, error %v, want alt 0", alt, err)
+	}
+}